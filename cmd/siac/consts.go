@@ -17,6 +17,10 @@ const (
 	// determine download speeds.
 	SpeedEstimationWindow = 60 * time.Second
 
+	// hostFinancialsDateFormat is the format of the dates used by the host
+	// financials command.
+	hostFinancialsDateFormat = "2006-01-02"
+
 	// moduleNotReadyStatus is the error message displayed when an API call error
 	// suggests that a modules is not yet ready for usage.
 	moduleNotReadyStatus = "Module not loaded or still starting up"
@@ -1,12 +1,18 @@
 package main
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/wallet"
 	"go.sia.tech/siad/types"
 )
 
@@ -25,8 +31,144 @@ var (
 			"file. Intended for upload to `https://rankings.sia.tech/`.",
 		Run: wrap(renterexportcontracttxnscmd),
 	}
+
+	walletExportCmd = &cobra.Command{
+		Use:   "export [destination]",
+		Short: "export the wallet's transaction history for bookkeeping",
+		Long: `Export the wallet's confirmed transactions to the specified file in CSV or
+JSON format. Every transaction is exported with its date, id, direction, the
+siacoin amount and fee, its label and memo and the counterparty address.
+Amounts are denominated in siacoins. If a transaction has no label, the label
+of the counterparty address is used.`,
+		Run: wrap(walletexportcmd),
+	}
 )
 
+// walletExportRecord is a single transaction of the wallet export.
+type walletExportRecord struct {
+	Date          string `json:"date"`
+	TransactionID string `json:"txid"`
+	Direction     string `json:"direction"`
+	Amount        string `json:"amount"`
+	Fee           string `json:"fee"`
+	Label         string `json:"label"`
+	Memo          string `json:"memo"`
+	Counterparty  string `json:"counterparty"`
+}
+
+// exactSiacoins converts a currency to siacoins without losing precision.
+func exactSiacoins(c types.Currency) string {
+	sc := new(big.Rat).SetFrac(c.Big(), types.SiacoinPrecision.Big()).FloatString(24)
+	return strings.TrimSuffix(strings.TrimRight(sc, "0"), ".")
+}
+
+// walletExportRecords converts the valued transactions of the wallet into
+// export records.
+func walletExportRecords(txns []modules.ValuedTransaction) []walletExportRecord {
+	records := make([]walletExportRecord, 0, len(txns))
+	for _, txn := range txns {
+		var fee types.Currency
+		for _, output := range txn.Outputs {
+			if output.FundType == types.SpecifierMinerFee {
+				fee = fee.Add(output.Value)
+			}
+		}
+
+		// Determine the direction and the counterparty of the transaction.
+		// Fees are only accounted for if the wallet paid them.
+		record := walletExportRecord{
+			Date:          time.Unix(int64(txn.ConfirmationTimestamp), 0).UTC().Format(time.RFC3339),
+			TransactionID: txn.TransactionID.String(),
+			Label:         txn.Label,
+			Memo:          txn.Memo,
+		}
+		var amount types.Currency
+		var counterparty *types.UnlockHash
+		if txn.ConfirmedIncomingValue.Cmp(txn.ConfirmedOutgoingValue) >= 0 {
+			record.Direction = "incoming"
+			amount = txn.ConfirmedIncomingValue.Sub(txn.ConfirmedOutgoingValue)
+			fee = types.ZeroCurrency
+			for _, input := range txn.Inputs {
+				if input.FundType == types.SpecifierSiacoinInput && !input.WalletAddress {
+					counterparty = &input.RelatedAddress
+					break
+				}
+			}
+		} else {
+			record.Direction = "outgoing"
+			amount = txn.ConfirmedOutgoingValue.Sub(txn.ConfirmedIncomingValue)
+			if amount.Cmp(fee) >= 0 {
+				amount = amount.Sub(fee)
+			} else {
+				fee, amount = amount, types.ZeroCurrency
+			}
+			for _, output := range txn.Outputs {
+				if output.FundType == types.SpecifierSiacoinOutput && !output.WalletAddress {
+					counterparty = &output.RelatedAddress
+					break
+				}
+			}
+		}
+		record.Amount = exactSiacoins(amount)
+		record.Fee = exactSiacoins(fee)
+		if counterparty != nil {
+			record.Counterparty = counterparty.String()
+			for _, al := range txn.AddressLabels {
+				if al.Address == *counterparty && record.Label == "" {
+					record.Label = al.Label
+				}
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// walletexportcmd is the handler for the command `siac wallet export`.
+// Exports the confirmed transactions of the wallet to CSV or JSON.
+func walletexportcmd(destination string) {
+	if walletExportFormat != "csv" && walletExportFormat != "json" {
+		die("Unknown export format", walletExportFormat, "- must be 'csv' or 'json'")
+	}
+	wtg, err := httpClient.WalletTransactionsGet(types.BlockHeight(walletStartHeight), types.BlockHeight(walletEndHeight))
+	if err != nil {
+		die("Could not fetch transaction history:", err)
+	}
+	cg, err := httpClient.ConsensusGet()
+	if err != nil {
+		die("Could not fetch consensus information:", err)
+	}
+	txns, err := wallet.ComputeValuedTransactions(wtg.ConfirmedTransactions, cg.Height)
+	if err != nil {
+		die("Could not compute valued transactions:", err)
+	}
+	records := walletExportRecords(txns)
+
+	destination = abs(destination)
+	file, err := os.Create(destination)
+	if err != nil {
+		die("Could not export to file:", err)
+	}
+	defer file.Close()
+	if walletExportFormat == "json" {
+		enc := json.NewEncoder(file)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(records)
+	} else {
+		w := csv.NewWriter(file)
+		_ = w.Write([]string{"date", "txid", "direction", "amount", "fee", "label", "memo", "counterparty"})
+		for _, r := range records {
+			_ = w.Write([]string{r.Date, r.TransactionID, r.Direction, r.Amount, r.Fee, r.Label, r.Memo, r.Counterparty})
+		}
+		w.Flush()
+		err = w.Error()
+	}
+	if err != nil {
+		die("Could not export to file:", err)
+	}
+	fmt.Printf("Exported %v transactions to %v\n", len(records), destination)
+}
+
 // renterexportcontracttxnscmd is the handler for the command `siac renter export contract-txns`.
 // Exports the current contract set to JSON.
 func renterexportcontracttxnscmd(destination string) {
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestWalletExportRecords is a unit test for walletExportRecords.
+func TestWalletExportRecords(t *testing.T) {
+	walletAddr, external := types.UnlockHash{1}, types.UnlockHash{2}
+	txns := []modules.ValuedTransaction{
+		{
+			// Incoming payment from an external address.
+			ProcessedTransaction: modules.ProcessedTransaction{
+				TransactionID:         types.TransactionID{1},
+				ConfirmationTimestamp: 1e9,
+				Inputs: []modules.ProcessedInput{
+					{FundType: types.SpecifierSiacoinInput, RelatedAddress: external, Value: types.SiacoinPrecision.Mul64(3)},
+				},
+				Outputs: []modules.ProcessedOutput{
+					{FundType: types.SpecifierSiacoinOutput, WalletAddress: true, RelatedAddress: walletAddr, Value: types.SiacoinPrecision.Mul64(2)},
+					{FundType: types.SpecifierMinerFee, Value: types.SiacoinPrecision},
+				},
+				AddressLabels: []modules.AddressLabel{{Address: external, Label: "customer"}},
+			},
+			ConfirmedIncomingValue: types.SiacoinPrecision.Mul64(2),
+		},
+		{
+			// Outgoing payment with change and a fee.
+			ProcessedTransaction: modules.ProcessedTransaction{
+				TransactionID:         types.TransactionID{2},
+				ConfirmationTimestamp: 1e9,
+				Inputs: []modules.ProcessedInput{
+					{FundType: types.SpecifierSiacoinInput, WalletAddress: true, RelatedAddress: walletAddr, Value: types.SiacoinPrecision.Mul64(10)},
+				},
+				Outputs: []modules.ProcessedOutput{
+					{FundType: types.SpecifierSiacoinOutput, WalletAddress: true, RelatedAddress: walletAddr, Value: types.SiacoinPrecision.Mul64(6)},
+					{FundType: types.SpecifierSiacoinOutput, RelatedAddress: external, Value: types.SiacoinPrecision.Div64(2).Mul64(7)},
+					{FundType: types.SpecifierMinerFee, Value: types.SiacoinPrecision.Div64(2)},
+				},
+				Label:         "rent",
+				Memo:          "october",
+				AddressLabels: []modules.AddressLabel{{Address: external, Label: "landlord"}},
+			},
+			ConfirmedIncomingValue: types.SiacoinPrecision.Mul64(6),
+			ConfirmedOutgoingValue: types.SiacoinPrecision.Mul64(10),
+		},
+	}
+	expected := []walletExportRecord{
+		{
+			Date:          "2001-09-09T01:46:40Z",
+			TransactionID: types.TransactionID{1}.String(),
+			Direction:     "incoming",
+			Amount:        "2",
+			Fee:           "0",
+			Label:         "customer",
+			Counterparty:  external.String(),
+		},
+		{
+			Date:          "2001-09-09T01:46:40Z",
+			TransactionID: types.TransactionID{2}.String(),
+			Direction:     "outgoing",
+			Amount:        "3.5",
+			Fee:           "0.5",
+			Label:         "rent",
+			Memo:          "october",
+			Counterparty:  external.String(),
+		},
+	}
+	records := walletExportRecords(txns)
+	if len(records) != len(expected) {
+		t.Fatalf("expected %v records but got %v", len(expected), len(records))
+	}
+	for i := range records {
+		if records[i] != expected[i] {
+			t.Fatalf("record %v: expected %+v but got %+v", i, expected[i], records[i])
+		}
+	}
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"encoding/csv"
 	"fmt"
+	"io"
 	"math/big"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -70,6 +74,17 @@ Available settings:
      registrysize:       filesize
      customregistrypath: string
 
+     dynamicpricing:            boolean
+     pricingdampingfactor:      float in (0, 1]
+     maxdownloadbandwidthprice: currency / TB
+     maxstorageprice:           currency / TB / Month
+     maxuploadbandwidthprice:   currency / TB
+     pricingtargetbandwidth:    bandwidth
+
+     maxbandwidthperminute: filesize
+     maxconcurrentprograms: int
+     maxrequestsperminute:  int
+
 Currency units can be specified, e.g. 10SC; run 'siac help wallet' for details.
 
 Durations (maxduration and windowsize) must be specified in either blocks (b),
@@ -80,6 +95,15 @@ Timeouts (ephemeralaccountexpiry) must be specified in either seconds (s),
 hours (h), days (d), or weeks (w). One hour is 3600 seconds, a day is 86400
 seconds, and a week is 604800 seconds.
 
+With dynamicpricing enabled, the host adjusts its storage and bandwidth prices
+between the min and max prices based on its storage utilization, contract
+demand and bandwidth usage. Bandwidth (pricingtargetbandwidth) must be
+specified with units, e.g. 10MB/s or 100Mbps.
+
+The renter quotas (maxbandwidthperminute, maxconcurrentprograms and
+maxrequestsperminute) are enforced per ephemeral account and per contract. A
+value of 0 disables the corresponding quota.
+
 For a description of each parameter, see doc/API.md.
 
 To configure the host to accept new contracts, set acceptingcontracts to true:
@@ -100,6 +124,58 @@ Available output types:
 		Run: wrap(hostcontractcmd),
 	}
 
+	hostFinancialsCmd = &cobra.Command{
+		Use:   "financials",
+		Short: "Show the host's daily financial snapshots",
+		Long: `Show the host's daily financial snapshots. Every snapshot contains the
+cumulative financial metrics of the host at the end of that day (UTC), so the
+difference between two snapshots is the change within that period.
+
+The range of snapshots can be limited with --start and --end, both formatted
+as YYYY-MM-DD. With --csv the snapshots are printed as CSV with all currency
+values in hastings, e.g. to import them into a spreadsheet:
+	siac host financials --csv > financials.csv
+`,
+		Run: wrap(hostfinancialscmd),
+	}
+
+	hostMigrationCmd = &cobra.Command{
+		Use:   "migration",
+		Short: "Migrate the host to another node",
+		Long: `Migrate the host to another node, e.g. when moving to new hardware.
+
+The old node exports an archive containing its settings, storage obligations,
+registry, ephemeral accounts and the data of all its sectors. Once the export
+starts, the old node stops accepting uploads, renewals and payments but keeps
+submitting storage proofs. The new node imports the archive, verifies the
+roots of all sectors and takes over the host key of the old node.
+
+Migrating a host:
+	siac host migration export host.tar
+	siac -a newnode:9980 host migration import host.tar
+	restart the new node, announce it and shut down the old node
+
+With "-" as the path, the archive is streamed to stdout or read from stdin,
+which allows for migrating without an intermediate file:
+	siac host migration export - | siac -a newnode:9980 --apipassword <password> host migration import -
+`,
+	}
+
+	hostMigrationExportCmd = &cobra.Command{
+		Use:   "export [path]",
+		Short: "Export the host to an archive",
+		Long:  "Export the host to an archive which can be imported by another node.",
+		Run:   wrap(hostmigrationexportcmd),
+	}
+
+	hostMigrationImportCmd = &cobra.Command{
+		Use:   "import [path]",
+		Short: "Import a host from an archive",
+		Long: `Import a host from an archive created by another node. The node must not
+have any storage obligations yet and needs to be restarted after the import.`,
+		Run: wrap(hostmigrationimportcmd),
+	}
+
 	hostFolderAddCmd = &cobra.Command{
 		Use:   "add [path] [size]",
 		Short: "Add a storage folder to the host",
@@ -238,6 +314,17 @@ Host Internal Settings:
 	registrysize:       %v
 	customregistrypath: %v
 
+	dynamicpricing:            %v
+	pricingdampingfactor:      %v
+	maxdownloadbandwidthprice: %v / TB
+	maxstorageprice:           %v / TB / Month
+	maxuploadbandwidthprice:   %v / TB
+	pricingtargetbandwidth:    %v/s
+
+	maxbandwidthperminute: %v
+	maxconcurrentprograms: %v
+	maxrequestsperminute:  %v
+
 Host Financials:
 	Contract Count:               %v
 	Transaction Fee Compensation: %v
@@ -264,6 +351,10 @@ RPC Stats:
 	Revise Calls:       %v
 	Settings Calls:     %v
 	FormContract Calls: %v
+
+	Throttled Bandwidth Calls:   %v
+	Throttled Concurrency Calls: %v
+	Throttled Request Calls:     %v
 `,
 			connectabilityString,
 			es.Version,
@@ -292,6 +383,17 @@ RPC Stats:
 			modules.FilesizeUnits(is.RegistrySize),
 			is.CustomRegistryPath,
 
+			yesNo(is.DynamicPricing.Enabled),
+			is.DynamicPricing.DampingFactor,
+			currencyUnits(is.DynamicPricing.MaxDownloadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
+			currencyUnits(is.DynamicPricing.MaxStoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)),
+			currencyUnits(is.DynamicPricing.MaxUploadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
+			modules.FilesizeUnits(is.DynamicPricing.TargetBandwidth),
+
+			modules.FilesizeUnits(is.RenterQuotas.MaxBandwidthPerMinute),
+			is.RenterQuotas.MaxConcurrentPrograms,
+			is.RenterQuotas.MaxRequestsPerMinute,
+
 			fm.ContractCount, currencyUnits(fm.ContractCompensation),
 			currencyUnits(fm.PotentialContractCompensation),
 			currencyUnits(fm.TransactionFeeExpenses),
@@ -310,7 +412,10 @@ RPC Stats:
 
 			nm.ErrorCalls, nm.UnrecognizedCalls, nm.DownloadCalls,
 			nm.RenewCalls, nm.ReviseCalls, nm.SettingsCalls,
-			nm.FormContractCalls)
+			nm.FormContractCalls,
+
+			nm.ThrottledBandwidthCalls, nm.ThrottledConcurrencyCalls,
+			nm.ThrottledRequestCalls)
 	} else {
 		fmt.Printf(`Host info:
 	Connectability Status: %v
@@ -379,7 +484,7 @@ func hostconfigcmd(param, value string) {
 		}
 
 	// currency/TB (convert to hastings/byte)
-	case "mindownloadbandwidthprice", "minuploadbandwidthprice":
+	case "mindownloadbandwidthprice", "minuploadbandwidthprice", "maxdownloadbandwidthprice", "maxuploadbandwidthprice":
 		hastings, err := types.ParseCurrency(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
@@ -389,7 +494,7 @@ func hostconfigcmd(param, value string) {
 		value = c.String()
 
 	// currency/TB/month (convert to hastings/byte/block)
-	case "collateral", "minstorageprice":
+	case "collateral", "minstorageprice", "maxstorageprice":
 		hastings, err := types.ParseCurrency(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
@@ -399,7 +504,7 @@ func hostconfigcmd(param, value string) {
 		value = c.String()
 
 	// bool (allow "yes" and "no")
-	case "acceptingcontracts":
+	case "acceptingcontracts", "dynamicpricing":
 		switch strings.ToLower(value) {
 		case "yes":
 			value = "true"
@@ -415,7 +520,7 @@ func hostconfigcmd(param, value string) {
 		}
 
 	// filesize (convert to bytes)
-	case "registrysize":
+	case "registrysize", "maxbandwidthperminute":
 		value, err = parseFilesize(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
@@ -428,8 +533,16 @@ func hostconfigcmd(param, value string) {
 			die("Could not parse "+param+":", err)
 		}
 
+	// bandwidth (convert to bytes/s)
+	case "pricingtargetbandwidth":
+		bps, err := parseRatelimit(value)
+		if err != nil {
+			die("Could not parse "+param+":", err)
+		}
+		value = strconv.FormatInt(bps, 10)
+
 	// other valid settings
-	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress", "customregistrypath":
+	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress", "customregistrypath", "pricingdampingfactor", "maxconcurrentprograms", "maxrequestsperminute":
 
 	// invalid settings
 	default:
@@ -483,6 +596,87 @@ func hostcontractcmd() {
 	}
 }
 
+// hostfinancialscmd is the handler for the command `siac host financials`.
+func hostfinancialscmd() {
+	// Parse the range.
+	var start, end time.Time
+	var err error
+	if hostFinancialsStart != "" {
+		start, err = time.Parse(hostFinancialsDateFormat, hostFinancialsStart)
+		if err != nil {
+			die("Could not parse start:", err)
+		}
+	}
+	if hostFinancialsEnd != "" {
+		end, err = time.Parse(hostFinancialsDateFormat, hostFinancialsEnd)
+		if err != nil {
+			die("Could not parse end:", err)
+		}
+	}
+
+	hfg, err := httpClient.HostFinancialsGet(start, end)
+	if err != nil {
+		die("Could not fetch host financials:", err)
+	}
+
+	if hostFinancialsCSV {
+		w := csv.NewWriter(os.Stdout)
+		err = w.Write([]string{"date", "blockheight", "contractcount", "contractcompensation",
+			"storagerevenue", "potentialstoragerevenue", "downloadbandwidthrevenue",
+			"potentialdownloadbandwidthrevenue", "uploadbandwidthrevenue",
+			"potentialuploadbandwidthrevenue", "registryrevenue", "accountfunding",
+			"lockedstoragecollateral", "riskedstoragecollateral", "loststoragecollateral",
+			"lostrevenue", "transactionfeeexpenses"})
+		if err != nil {
+			die("Could not write csv header:", err)
+		}
+		for _, s := range hfg.Snapshots {
+			err = w.Write([]string{
+				s.Timestamp.Format(hostFinancialsDateFormat),
+				fmt.Sprint(s.BlockHeight),
+				fmt.Sprint(s.ContractCount),
+				s.ContractCompensation.String(),
+				s.StorageRevenue.String(),
+				s.PotentialStorageRevenue.String(),
+				s.DownloadBandwidthRevenue.String(),
+				s.PotentialDownloadBandwidthRevenue.String(),
+				s.UploadBandwidthRevenue.String(),
+				s.PotentialUploadBandwidthRevenue.String(),
+				s.RegistryRevenue.String(),
+				s.AccountFunding.String(),
+				s.LockedStorageCollateral.String(),
+				s.RiskedStorageCollateral.String(),
+				s.LostStorageCollateral.String(),
+				s.LostRevenue.String(),
+				s.TransactionFeeExpenses.String(),
+			})
+			if err != nil {
+				die("Could not write csv record:", err)
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			die("Could not write csv:", err)
+		}
+		return
+	}
+
+	if len(hfg.Snapshots) == 0 {
+		fmt.Println("No financial snapshots found.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintf(w, "Date\tHeight\tContracts\tStorage Revenue\tDownload Revenue\tUpload Revenue\tRegistry Revenue\tLocked Collateral\tRisked Collateral\tLost Collateral\tLost Revenue\n")
+	for _, s := range hfg.Snapshots {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", s.Timestamp.Format(hostFinancialsDateFormat), s.BlockHeight, s.ContractCount,
+			currencyUnits(s.StorageRevenue), currencyUnits(s.DownloadBandwidthRevenue), currencyUnits(s.UploadBandwidthRevenue), currencyUnits(s.RegistryRevenue),
+			currencyUnits(s.LockedStorageCollateral), currencyUnits(s.RiskedStorageCollateral), currencyUnits(s.LostStorageCollateral), currencyUnits(s.LostRevenue))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+}
+
 // hostannouncecmd is the handler for the command `siac host announce`.
 // Announces yourself as a host to the network. Optionally takes an address to
 // announce as.
@@ -569,6 +763,57 @@ func hostfolderresizecmd(path, newsize string) {
 	fmt.Printf("Resized folder %v to %v\n", path, newsize)
 }
 
+// hostmigrationexportcmd is the handler for the command `siac host migration
+// export [path]`.
+func hostmigrationexportcmd(path string) {
+	out := os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			die("Could not create archive:", err)
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				die("Could not close archive:", err)
+			}
+		}()
+		out = f
+	}
+	archive, err := httpClient.HostMigrationExportGet()
+	if err != nil {
+		die("Could not export host:", err)
+	}
+	defer func() {
+		_ = archive.Close()
+	}()
+	n, err := io.Copy(out, archive)
+	if err != nil {
+		die("Could not export host:", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported host (%v). The host no longer accepts uploads, renewals and payments.\n", modules.FilesizeUnits(uint64(n)))
+}
+
+// hostmigrationimportcmd is the handler for the command `siac host migration
+// import [path]`.
+func hostmigrationimportcmd(path string) {
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			die("Could not open archive:", err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		in = f
+	}
+	err := httpClient.HostMigrationImportPost(in)
+	if err != nil {
+		die("Could not import host:", err)
+	}
+	fmt.Println("Imported host. Restart the node to complete the migration, then announce it and shut down the old node.")
+}
+
 // hostsectordeletecmd deletes a sector from the host.
 func hostsectordeletecmd(root string) {
 	var hash crypto.Hash
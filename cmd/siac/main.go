@@ -32,22 +32,32 @@ var (
 
 	// Host Flags
 	hostContractOutputType string // output type for host contracts
+	hostFinancialsCSV      bool   // print host financials as csv
+	hostFinancialsEnd      string // end date of the host financials
+	hostFinancialsStart    string // start date of the host financials
 	hostFolderRemoveForce  bool   // force folder remove
 
 	// Renter Flags
-	dataPieces                string // the number of data pieces a file should be uploaded with
-	parityPieces              string // the number of parity pieces a file should be uploaded with
-	renterAllContracts        bool   // Show all active and expired contracts
-	renterBubbleAll           bool   // Bubble the entire directory tree
-	renterDeleteRoot          bool   // Delete path start from root instead of the UserFolder.
-	renterDownloadAsync       bool   // Downloads files asynchronously
-	renterDownloadRecursive   bool   // Downloads folders recursively.
-	renterDownloadRoot        bool   // Download path start from root instead of the UserFolder.
-	renterFuseMountAllowOther bool   // Mount fuse with 'AllowOther' set to true.
-	renterListRecursive       bool   // List files of folder recursively.
-	renterListRoot            bool   // List path start from root instead of the UserFolder.
-	renterRenameRoot          bool   // Rename files relative to root instead of the UserFolder.
-	renterShowHistory         bool   // Show download history in addition to download queue.
+	dataPieces                  string // the number of data pieces a file should be uploaded with
+	parityPieces                string // the number of parity pieces a file should be uploaded with
+	renterAllContracts          bool   // Show all active and expired contracts
+	renterBubbleAll             bool   // Bubble the entire directory tree
+	renterDeleteRoot            bool   // Delete path start from root instead of the UserFolder.
+	renterDownloadAsync         bool   // Downloads files asynchronously
+	renterDownloadRecursive     bool   // Downloads folders recursively.
+	renterDownloadResume        bool   // Reuses the data already present in the destination.
+	renterDownloadRoot          bool   // Download path start from root instead of the UserFolder.
+	renterFuseMountAllowOther   bool   // Mount fuse with 'AllowOther' set to true.
+	renterListRecursive         bool   // List files of folder recursively.
+	renterListRoot              bool   // List path start from root instead of the UserFolder.
+	renterReencodeCancel        bool   // Cancel an ongoing re-encode.
+	renterReencodeRoot          bool   // Re-encode path start from root instead of the UserFolder.
+	renterScheduleDownloadSpeed string // Download speed of a bandwidth schedule window.
+	renterSchedulePause         bool   // Pause repairs and uploads during a bandwidth schedule window.
+	renterScheduleUploadSpeed   string // Upload speed of a bandwidth schedule window.
+	renterRenameRoot            bool   // Rename files relative to root instead of the UserFolder.
+	renterShowHistory           bool   // Show download history in addition to download queue.
+	renterUploadChecksum        bool   // Record a checksum of the uploaded files.
 
 	// Renter Allowance Flags
 	allowanceFunds       string // amount of money to be used within a period
@@ -80,11 +90,47 @@ var (
 	// Wallet Flags
 	initForce            bool   // destroy and re-encrypt the wallet on init if it already exists
 	initPassword         bool   // supply a custom password when creating a wallet
+	walletExportFormat   string // format of the wallet export, csv or json
+	walletLabelMemo      string // memo of a transaction or address label
 	walletRawTxn         bool   // Encode/decode transactions in base64-encoded binary.
 	walletStartHeight    uint64 // Start height for transaction search.
 	walletEndHeight      uint64 // End height for transaction search.
 	walletTxnFeeIncluded bool   // include the fee in the balance being sent
+	walletTxnInputs      string // comma-separated list of outputs to spend
+	walletTxnStrategy    string // coin selection strategy used to fund a transaction
 	insecureInput        bool   // Insecure password/seed input. Disables the shoulder-surfing and Mac secure input feature.
+
+	// Wallet Defrag Flags
+	walletDefragBatchSize uint64 // number of outputs consolidated by a defrag
+	walletDefragDryRun    bool   // only simulate a defrag
+	walletDefragEnabled   bool   // enable automatic defrags
+	walletDefragMaxFee    string // maximum fee per byte for automatic defrags
+	walletDefragThreshold uint64 // number of outputs that triggers a defrag
+
+	// Wallet Invoice Flags
+	walletInvoiceCallback      string // URL notified about status changes of an invoice
+	walletInvoiceConfirmations uint64 // number of confirmations an invoice requires
+	walletInvoiceExpiry        string // time at which an unpaid invoice expires
+	walletInvoiceMemo          string // memo of an invoice
+
+	// Wallet Multisig Flags
+	walletMultisigLocalKeys string // comma-separated list of local keys of a multisig account
+	walletMultisigTimelock  uint64 // timelock of a multisig account
+	walletMultisigUnused    bool   // the multisig account hasn't been used yet, skip the rescan
+
+	// Wallet Timelock Flags
+	walletTimelockUnused bool // the timelocked addresses haven't been used yet, skip the rescan
+
+	// Wallet Rescan Flags
+	walletRescanBirthDate string // birth date of the wallet to rescan from
+	walletSeedStartHeight uint64 // height from which to scan when loading or sweeping a seed
+
+	// Wallet Schedule Flags
+	walletScheduleDescription string // description of a scheduled payment
+	walletScheduleHeight      uint64 // height at which a scheduled payment is due
+	walletScheduleInterval    string // interval of a recurring payment
+	walletScheduleMaxPayments uint64 // number of payments of a recurring payment
+	walletScheduleTime        string // time at which a scheduled payment is due
 )
 
 var (
@@ -297,10 +343,14 @@ func initCmds() *cobra.Command {
 	gatewayBlocklistCmd.AddCommand(gatewayBlocklistAppendCmd, gatewayBlocklistClearCmd, gatewayBlocklistRemoveCmd, gatewayBlocklistSetCmd)
 
 	root.AddCommand(hostCmd)
-	hostCmd.AddCommand(hostAnnounceCmd, hostConfigCmd, hostContractCmd, hostFolderCmd, hostSectorCmd)
+	hostCmd.AddCommand(hostAnnounceCmd, hostConfigCmd, hostContractCmd, hostFinancialsCmd, hostFolderCmd, hostMigrationCmd, hostSectorCmd)
 	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
+	hostMigrationCmd.AddCommand(hostMigrationExportCmd, hostMigrationImportCmd)
 	hostSectorCmd.AddCommand(hostSectorDeleteCmd)
 	hostContractCmd.Flags().StringVarP(&hostContractOutputType, "type", "t", "value", "Select output type")
+	hostFinancialsCmd.Flags().BoolVar(&hostFinancialsCSV, "csv", false, "Print the snapshots as csv with currency values in hastings")
+	hostFinancialsCmd.Flags().StringVar(&hostFinancialsEnd, "end", "", "Last day to show, formatted as YYYY-MM-DD")
+	hostFinancialsCmd.Flags().StringVar(&hostFinancialsStart, "start", "", "First day to show, formatted as YYYY-MM-DD")
 	hostFolderRemoveCmd.Flags().BoolVarP(&hostFolderRemoveForce, "force", "f", false, "Force the removal of the folder and its data")
 
 	root.AddCommand(hostdbCmd)
@@ -315,8 +365,8 @@ func initCmds() *cobra.Command {
 		renterCleanCmd, renterContractsCmd, renterContractsRecoveryScanProgressCmd, renterDownloadCancelCmd,
 		renterDownloadsCmd, renterExportCmd, renterFilesDeleteCmd, renterFilesDownloadCmd,
 		renterFilesListCmd, renterFilesRenameCmd, renterFilesUnstuckCmd, renterFilesUploadCmd,
-		renterFuseCmd, renterLostCmd, renterPricesCmd, renterRatelimitCmd, renterSetAllowanceCmd,
-		renterSetLocalPathCmd, renterTriggerContractRecoveryScanCmd, renterUploadsCmd, renterWorkersCmd,
+		renterFuseCmd, renterLostCmd, renterPricesCmd, renterRatelimitCmd, renterReencodeCmd, renterScheduleCmd, renterSetAllowanceCmd,
+		renterSetLocalPathCmd, renterSetPriorityCmd, renterTriggerContractRecoveryScanCmd, renterUploadsCmd, renterWorkersCmd,
 		renterHealthSummaryCmd)
 	renterWorkersCmd.AddCommand(renterWorkersAccountsCmd, renterWorkersDownloadsCmd, renterWorkersPriceTableCmd, renterWorkersReadJobsCmd, renterWorkersHasSectorJobSCmd, renterWorkersUploadsCmd, renterWorkersReadRegistryCmd, renterWorkersUpdateRegistryCmd)
 
@@ -330,13 +380,23 @@ func initCmds() *cobra.Command {
 	renterFilesDeleteCmd.Flags().BoolVar(&renterDeleteRoot, "root", false, "Delete files and folders from root instead of from the user home directory")
 	renterFilesDownloadCmd.Flags().BoolVarP(&renterDownloadAsync, "async", "A", false, "Download file asynchronously")
 	renterFilesDownloadCmd.Flags().BoolVarP(&renterDownloadRecursive, "recursive", "R", false, "Download folder recursively")
+	renterFilesDownloadCmd.Flags().BoolVar(&renterDownloadResume, "resume", false, "Resume an interrupted download, reusing the verified data already present in the destination")
 	renterFilesDownloadCmd.Flags().BoolVar(&renterDownloadRoot, "root", false, "Download files and folders from root instead of from the user home directory")
 	renterFilesListCmd.Flags().BoolVarP(&renterListRecursive, "recursive", "R", false, "Recursively list files and folders")
 	renterFilesListCmd.Flags().BoolVar(&renterListRoot, "root", false, "List files and folders from root instead of from the user home directory")
 	renterFilesUploadCmd.Flags().StringVar(&dataPieces, "data-pieces", "", "the number of data pieces a files should be uploaded with")
 	renterFilesUploadCmd.Flags().StringVar(&parityPieces, "parity-pieces", "", "the number of parity pieces a files should be uploaded with")
+	renterFilesUploadCmd.Flags().BoolVar(&renterUploadChecksum, "checksum", false, "record a checksum of the files which is used to verify full downloads")
 	renterExportCmd.AddCommand(renterExportContractTxnsCmd)
 	renterFilesRenameCmd.Flags().BoolVar(&renterRenameRoot, "root", false, "Rename files relative to root instead of the user homedir")
+	renterReencodeCmd.Flags().StringVar(&dataPieces, "data-pieces", "", "the number of data pieces the files should be re-encoded with")
+	renterReencodeCmd.Flags().StringVar(&parityPieces, "parity-pieces", "", "the number of parity pieces the files should be re-encoded with")
+	renterReencodeCmd.Flags().BoolVar(&renterReencodeCancel, "cancel", false, "Cancel the ongoing re-encode of the file or folder")
+	renterReencodeCmd.Flags().BoolVar(&renterReencodeRoot, "root", false, "Re-encode files and folders from root instead of from the user home directory")
+	renterScheduleCmd.AddCommand(renterScheduleAddCmd, renterScheduleClearCmd)
+	renterScheduleAddCmd.Flags().StringVar(&renterScheduleDownloadSpeed, "download-speed", "0", "max download speed during the window, 0 for no limit")
+	renterScheduleAddCmd.Flags().StringVar(&renterScheduleUploadSpeed, "upload-speed", "0", "max upload speed during the window, 0 for no limit")
+	renterScheduleAddCmd.Flags().BoolVar(&renterSchedulePause, "pause", false, "pause repairs and uploads during the window")
 
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceFunds, "amount", "", "amount of money in allowance, specified in currency units")
 	renterSetAllowanceCmd.Flags().StringVar(&allowancePeriod, "period", "", "period of allowance in blocks (b), hours (h), days (d) or weeks (w)")
@@ -374,15 +434,55 @@ func initCmds() *cobra.Command {
 	utilsVerifySeedCmd.Flags().StringVarP(&dictionaryLanguage, "language", "l", "english", "which dictionary you want to use")
 
 	root.AddCommand(walletCmd)
-	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletBalanceCmd, walletBroadcastCmd, walletChangepasswordCmd,
-		walletInitCmd, walletInitSeedCmd, walletLoadCmd, walletLockCmd, walletSeedsCmd, walletSendCmd,
-		walletSignCmd, walletSweepCmd, walletTransactionsCmd, walletUnlockCmd)
+	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletAddressGapCmd, walletBalanceCmd, walletBroadcastCmd, walletChangepasswordCmd, walletClaimsCmd,
+		walletDefragCmd, walletExportCmd, walletInitCmd, walletInitSeedCmd, walletInvoicesCmd, walletLabelsCmd, walletLoadCmd, walletLockCmd, walletMultisigCmd, walletOutputsCmd,
+		walletRescanCmd, walletSchedulesCmd, walletSeedsCmd, walletSendCmd, walletSignCmd, walletSignerCmd, walletSweepCmd, walletTimelockCmd, walletTransactionsCmd, walletUnlockCmd,
+		walletVestingCmd)
 	walletInitCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Prompt for a custom password")
 	walletInitCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet and re-encrypt")
 	walletInitSeedCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet")
 	walletLoadCmd.AddCommand(walletLoad033xCmd, walletLoadSeedCmd, walletLoadSiagCmd)
 	walletSendCmd.AddCommand(walletSendSiacoinsCmd, walletSendSiafundsCmd)
 	walletSendSiacoinsCmd.Flags().BoolVarP(&walletTxnFeeIncluded, "fee-included", "", false, "Take the transaction fee out of the balance being submitted instead of the fee being additional")
+	walletSendSiacoinsCmd.Flags().StringVarP(&walletTxnInputs, "inputs", "", "", "Comma-separated list of siacoin output ids to spend")
+	walletSendSiacoinsCmd.Flags().StringVarP(&walletTxnStrategy, "strategy", "", "", "Coin selection strategy: largest-first, smallest-first, minimize-change or privacy")
+	walletDefragCmd.AddCommand(walletDefragPolicyCmd, walletDefragWindowCmd)
+	walletDefragCmd.Flags().BoolVarP(&walletDefragDryRun, "dry-run", "", false, "Show what would be consolidated without broadcasting a transaction")
+	walletDefragPolicyCmd.AddCommand(walletDefragPolicySetCmd)
+	walletDefragPolicySetCmd.Flags().BoolVarP(&walletDefragEnabled, "enabled", "", true, "Enable automatic defrags")
+	walletDefragPolicySetCmd.Flags().Uint64VarP(&walletDefragThreshold, "threshold", "", 0, "Number of outputs which triggers a defrag")
+	walletDefragPolicySetCmd.Flags().Uint64VarP(&walletDefragBatchSize, "batch-size", "", 0, "Number of outputs consolidated by a defrag")
+	walletDefragPolicySetCmd.Flags().StringVarP(&walletDefragMaxFee, "max-fee", "", "", "Maximum fee per byte of automatic defrags, e.g. '10 nS'")
+	walletDefragWindowCmd.AddCommand(walletDefragWindowAddCmd, walletDefragWindowClearCmd)
+	walletExportCmd.Flags().StringVarP(&walletExportFormat, "format", "", "csv", "Export format, csv or json")
+	walletExportCmd.Flags().Uint64Var(&walletStartHeight, "startheight", 0, "Height of the block where the export should begin")
+	walletExportCmd.Flags().Uint64Var(&walletEndHeight, "endheight", math.MaxUint64, "Height of the block where the export should end")
+	walletInvoicesCmd.AddCommand(walletInvoicesAddCmd, walletInvoicesRemoveCmd)
+	walletInvoicesAddCmd.Flags().StringVarP(&walletInvoiceCallback, "callback", "", "", "URL which is notified about status changes of the invoice")
+	walletInvoicesAddCmd.Flags().Uint64VarP(&walletInvoiceConfirmations, "confirmations", "", 0, "Number of confirmations the payment requires, 0 for the default")
+	walletInvoicesAddCmd.Flags().StringVarP(&walletInvoiceExpiry, "expiry", "", "", "Time at which the invoice expires if it isn't paid")
+	walletInvoicesAddCmd.Flags().StringVarP(&walletInvoiceMemo, "memo", "", "", "Memo of the invoice")
+	walletLabelsCmd.AddCommand(walletLabelsAddressCmd, walletLabelsTransactionCmd)
+	walletLabelsAddressCmd.Flags().StringVarP(&walletLabelMemo, "memo", "", "", "Memo of the address")
+	walletLabelsTransactionCmd.Flags().StringVarP(&walletLabelMemo, "memo", "", "", "Memo of the transaction")
+	walletSignerCmd.AddCommand(walletSignerAddressCmd)
+	walletTimelockCmd.AddCommand(walletTimelockAddressCmd, walletTimelockSendCmd, walletTimelockTrackCmd)
+	walletTimelockTrackCmd.Flags().BoolVarP(&walletTimelockUnused, "unused", "", false, "The addresses haven't been used yet, skip the blockchain rescan")
+	walletMultisigCmd.AddCommand(walletMultisigAddCmd, walletMultisigRemoveCmd, walletMultisigSignCmd, walletMultisigSpendCmd)
+	walletMultisigAddCmd.Flags().StringVarP(&walletMultisigLocalKeys, "local-keys", "", "", "Comma separated list of the wallet's public keys which participate in the account")
+	walletMultisigAddCmd.Flags().Uint64VarP(&walletMultisigTimelock, "timelock", "", 0, "Timelock of the account's UnlockConditions")
+	walletMultisigAddCmd.Flags().BoolVarP(&walletMultisigUnused, "unused", "", false, "The account hasn't been used yet, skip the blockchain rescan")
+	walletMultisigRemoveCmd.Flags().BoolVarP(&walletMultisigUnused, "unused", "", false, "The account hasn't been used yet, skip the blockchain rescan")
+	walletOutputsCmd.AddCommand(walletOutputsFreezeCmd, walletOutputsFrozenCmd, walletOutputsUnfreezeCmd)
+	walletRescanCmd.Flags().StringVarP(&walletRescanBirthDate, "birthdate", "", "", "Birth date of the wallet, a unix timestamp or a date like 2006-01-02")
+	walletLoadSeedCmd.Flags().Uint64VarP(&walletSeedStartHeight, "startheight", "", 0, "Height of the first block to scan for the seed's transactions")
+	walletSweepCmd.Flags().Uint64VarP(&walletSeedStartHeight, "startheight", "", 0, "Height of the first block to scan for the seed's outputs")
+	walletSchedulesCmd.AddCommand(walletSchedulesAddCmd, walletSchedulesRemoveCmd)
+	walletSchedulesAddCmd.Flags().StringVarP(&walletScheduleDescription, "description", "", "", "Description of the payment")
+	walletSchedulesAddCmd.Flags().Uint64VarP(&walletScheduleHeight, "height", "", 0, "Block height at which the payment is due")
+	walletSchedulesAddCmd.Flags().StringVarP(&walletScheduleInterval, "interval", "", "", "Interval of a recurring payment, in blocks or as a duration")
+	walletSchedulesAddCmd.Flags().Uint64VarP(&walletScheduleMaxPayments, "max-payments", "", 0, "Maximum number of payments of a recurring payment, 0 for unlimited")
+	walletSchedulesAddCmd.Flags().StringVarP(&walletScheduleTime, "time", "", "", "Block time at which the payment is due")
 	walletUnlockCmd.Flags().BoolVarP(&insecureInput, "insecure-input", "", false, "Disable shoulder-surf protection (echoing passwords and seeds)")
 	walletUnlockCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Display interactive password prompt even if SIA_WALLET_PASSWORD is set")
 	walletBroadcastCmd.Flags().BoolVarP(&walletRawTxn, "raw", "", false, "Decode transaction as base64 instead of JSON")
@@ -16,6 +16,7 @@ import (
 	"gitlab.com/NebulousLabs/encoding"
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/types"
 )
 
@@ -290,6 +291,49 @@ func parseRatelimit(rateLimitStr string) (int64, error) {
 	return 0, ErrParseRateLimitUnits
 }
 
+// parseWeekdays converts a comma separated list of weekdays like "mon,tue" into
+// a slice of weekdays. "all" returns an empty slice which means every day.
+func parseWeekdays(daysStr string) ([]time.Weekday, error) {
+	daysStr = strings.ToLower(strings.TrimSpace(daysStr))
+	if daysStr == "all" {
+		return nil, nil
+	}
+	var days []time.Weekday
+	for _, dayStr := range strings.Split(daysStr, ",") {
+		dayStr = strings.TrimSpace(dayStr)
+		found := false
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			name := strings.ToLower(day.String())
+			if len(dayStr) >= 3 && strings.HasPrefix(name, dayStr) {
+				days = append(days, day)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown weekday '%v'", dayStr)
+		}
+	}
+	return days, nil
+}
+
+// parsePublicKeys converts a comma separated list of public keys like
+// "ed25519:<hex>,ed25519:<hex>" into a slice of SiaPublicKeys.
+func parsePublicKeys(keysStr string) ([]types.SiaPublicKey, error) {
+	var keys []types.SiaPublicKey
+	for _, keyStr := range strings.Split(keysStr, ",") {
+		var spk types.SiaPublicKey
+		if err := spk.LoadString(strings.TrimSpace(keyStr)); err != nil {
+			return nil, fmt.Errorf("invalid public key '%v': %v", keyStr, err)
+		}
+		if spk.Algorithm != types.SignatureEd25519 || len(spk.Key) != crypto.PublicKeySize {
+			return nil, fmt.Errorf("public key '%v' is not an ed25519 key", keyStr)
+		}
+		keys = append(keys, spk)
+	}
+	return keys, nil
+}
+
 // ratelimitUnits converts an int64 to a string with human-readable ratelimit
 // units. The unit used will be the largest unit that results in a value greater
 // than 1. The value is rounded to 4 significant digits.
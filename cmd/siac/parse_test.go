@@ -3,10 +3,14 @@ package main
 import (
 	"math"
 	"math/big"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/types"
 )
 
@@ -235,6 +239,58 @@ func TestParseRatelimit(t *testing.T) {
 	}
 }
 
+// TestParseWeekdays probes the parseWeekdays function
+func TestParseWeekdays(t *testing.T) {
+	tests := []struct {
+		in    string
+		out   []time.Weekday
+		valid bool
+	}{
+		{"all", nil, true},
+		{" ALL ", nil, true},
+		{"mon", []time.Weekday{time.Monday}, true},
+		{"Monday,tue, wednes", []time.Weekday{time.Monday, time.Tuesday, time.Wednesday}, true},
+		{"sat,sun", []time.Weekday{time.Saturday, time.Sunday}, true},
+		{"", nil, false},
+		{"mo", nil, false},
+		{"mon,", nil, false},
+		{"mondays", nil, false},
+	}
+	for _, test := range tests {
+		res, err := parseWeekdays(test.in)
+		if (err == nil) != test.valid || !reflect.DeepEqual(res, test.out) {
+			t.Errorf("parseWeekdays(%v): expected %v %v, got %v %v", test.in, test.out, test.valid, res, err)
+		}
+	}
+}
+
+// TestParsePublicKeys probes the parsePublicKeys function.
+func TestParsePublicKeys(t *testing.T) {
+	_, pk1 := crypto.GenerateKeyPair()
+	_, pk2 := crypto.GenerateKeyPair()
+	spk1, spk2 := types.Ed25519PublicKey(pk1), types.Ed25519PublicKey(pk2)
+
+	tests := []struct {
+		in    string
+		out   []types.SiaPublicKey
+		valid bool
+	}{
+		{spk1.String(), []types.SiaPublicKey{spk1}, true},
+		{spk1.String() + ", " + spk2.String(), []types.SiaPublicKey{spk1, spk2}, true},
+		{"", nil, false},
+		{spk1.String() + ",", nil, false},
+		{"ed25519:abcd", nil, false},
+		{"ed25519:" + strings.Repeat("zz", crypto.PublicKeySize), nil, false},
+		{"ed25518:" + strings.Repeat("ab", crypto.PublicKeySize), nil, false},
+	}
+	for _, test := range tests {
+		res, err := parsePublicKeys(test.in)
+		if (err == nil) != test.valid || !reflect.DeepEqual(res, test.out) {
+			t.Errorf("parsePublicKeys(%v): expected %v %v, got %v %v", test.in, test.out, test.valid, res, err)
+		}
+	}
+}
+
 // TestParsePercentages probes the parsePercentages function
 func TestParsePercentages(t *testing.T) {
 	tests := []struct {
@@ -168,6 +168,18 @@ the command 'siac renter lost' to see the renter's lost files.`,
 		Run:     wrap(renterfilesrenamecmd),
 	}
 
+	renterSetPriorityCmd = &cobra.Command{
+		Use:   "setpriority [path] [priority]",
+		Short: "Set the repair priority of a folder",
+		Long: `Set the repair priority of a folder. The priority is one of 'low', 'normal'
+or 'high'. Folders and files within the folder use the same priority unless
+they have a priority of their own. Setting the priority to 'inherit' makes the
+folder use the priority of its parent again. When repairs are needed, folders
+with a higher priority are repaired first.`,
+		Example: "siac renter setpriority backups/critical high",
+		Run:     wrap(rentersetprioritycmd),
+	}
+
 	renterFuseCmd = &cobra.Command{
 		Use:   "fuse",
 		Short: "Perform fuse actions.",
@@ -255,6 +267,45 @@ Set them to 0 for no limit.`,
 		Run: wrap(renterratelimitcmd),
 	}
 
+	renterScheduleCmd = &cobra.Command{
+		Use:   "schedule",
+		Short: "View the bandwidth schedule",
+		Long: `View the weekly bandwidth schedule of the renter. During a window of the
+schedule the renter uses the window's bandwidth limits instead of the ones set
+with 'siac renter ratelimit' and pauses repairs and uploads if requested.`,
+		Run: wrap(renterschedulecmd),
+	}
+
+	renterScheduleAddCmd = &cobra.Command{
+		Use:   "add [days] [start] [end]",
+		Short: "Add a window to the bandwidth schedule",
+		Long: `Add a window to the bandwidth schedule. Days is either 'all' or a comma
+separated list of weekdays like 'mon,tue'. Start and end are local times of the
+format 15:04. If end is not after start, the window ends on the following day.
+Windows which are added first take precedence over later windows.`,
+		Example: `siac renter schedule add all 00:00 06:00
+siac renter schedule add mon,tue,wed,thu,fri 09:00 17:00 --download-speed "2 MB/s" --upload-speed "2 MB/s"
+siac renter schedule add fri 18:00 00:00 --pause`,
+		Run: wrap(renterscheduleaddcmd),
+	}
+
+	renterScheduleClearCmd = &cobra.Command{
+		Use:   "clear",
+		Short: "Remove all windows from the bandwidth schedule",
+		Long:  "Remove all windows from the bandwidth schedule.",
+		Run:   wrap(renterscheduleclearcmd),
+	}
+
+	renterReencodeCmd = &cobra.Command{
+		Use:   "reencode [path]",
+		Short: "Re-encode a file or folder to a new redundancy",
+		Long: `Re-encode a file or all the files within a folder to the erasure code
+specified by --data-pieces and --parity-pieces. The re-encoding happens in the
+background and each file is switched over once its re-encoded data is fully
+healthy. Use --cancel to stop an ongoing re-encode.`,
+		Run: wrap(renterreencodecmd),
+	}
+
 	renterSetAllowanceCmd = &cobra.Command{
 		Use:   "setallowance",
 		Short: "Set the allowance",
@@ -1852,6 +1903,54 @@ func renterfilesrenamecmd(path, newpath string) {
 	fmt.Printf("Renamed %s to %s\n", path, newpath)
 }
 
+// rentersetprioritycmd is the handler for the command `siac renter
+// setpriority [path] [priority]`. It sets the repair priority of a folder.
+func rentersetprioritycmd(path, priorityStr string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	var priority modules.RepairPriority
+	if err := priority.FromString(strings.ToLower(priorityStr)); err != nil {
+		die("Couldn't parse priority, must be one of 'inherit', 'low', 'normal' or 'high':", err)
+	}
+	err = httpClient.RenterDirSetPriorityPost(siaPath, priority)
+	if err != nil {
+		die("Could not set priority:", err)
+	}
+	fmt.Printf("Set the repair priority of %s to %s\n", path, priority)
+}
+
+// renterreencodecmd is the handler for the command `siac renter reencode
+// [path]`. It re-encodes the file or folder at [path] to the erasure code
+// specified by the --data-pieces and --parity-pieces flags.
+func renterreencodecmd(path string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	if renterReencodeCancel {
+		err = httpClient.RenterReencodeCancelPost(siaPath, renterReencodeRoot)
+		if err != nil {
+			die("Could not cancel re-encode:", err)
+		}
+		fmt.Printf("Cancelled re-encoding %s\n", path)
+		return
+	}
+	numDataPieces, numParityPieces, err := api.ParseDataAndParityPieces(dataPieces, parityPieces)
+	if err != nil {
+		die("Could not parse data and parity pieces:", err)
+	}
+	if numDataPieces == 0 || numParityPieces == 0 {
+		die("Both --data-pieces and --parity-pieces need to be specified")
+	}
+	err = httpClient.RenterReencodePost(siaPath, uint64(numDataPieces), uint64(numParityPieces), renterReencodeRoot)
+	if err != nil {
+		die("Could not re-encode:", err)
+	}
+	fmt.Printf("Started re-encoding %s to %v data pieces and %v parity pieces\n", path, numDataPieces, numParityPieces)
+}
+
 // renterfusecmd displays the list of directories that are currently mounted via
 // fuse.
 func renterfusecmd() {
@@ -2046,7 +2145,7 @@ func renterfilesuploadcmd(source, path string) {
 			if err != nil {
 				die("Couldn't parse SiaPath:", err)
 			}
-			err = httpClient.RenterUploadPost(abs(file), fSiaPath, uint64(numDataPieces), uint64(numParityPieces))
+			err = uploadFile(abs(file), fSiaPath, uint64(numDataPieces), uint64(numParityPieces))
 			if err != nil {
 				failed++
 				fmt.Printf("Could not upload file %s :%v\n", file, err)
@@ -2060,7 +2159,7 @@ func renterfilesuploadcmd(source, path string) {
 		if err != nil {
 			die("Couldn't parse SiaPath:", err)
 		}
-		err = httpClient.RenterUploadPost(abs(source), siaPath, uint64(numDataPieces), uint64(numParityPieces))
+		err = uploadFile(abs(source), siaPath, uint64(numDataPieces), uint64(numParityPieces))
 		if err != nil {
 			die("Could not upload file:", err)
 		}
@@ -2191,6 +2290,95 @@ func renterratelimitcmd(downloadSpeedStr, uploadSpeedStr string) {
 	fmt.Println("Set renter maxdownloadspeed to ", downloadSpeedInt, " and maxuploadspeed to ", uploadSpeedInt)
 }
 
+// renterschedulecmd is the handler for the command `siac renter schedule`.
+// It prints the windows of the bandwidth schedule.
+func renterschedulecmd() {
+	rg, err := httpClient.RenterGet()
+	if err != nil {
+		die("Could not get renter settings:", err)
+	}
+	schedule := rg.Settings.BandwidthSchedule
+	if len(schedule) == 0 {
+		fmt.Println("No bandwidth schedule set.")
+		return
+	}
+	active, _, end := schedule.ActiveWindow(time.Now())
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  \tDays\tStart\tEnd\tDownload Speed\tUpload Speed\tPaused")
+	for i, window := range schedule {
+		marker := " "
+		if i == active {
+			marker = "*"
+		}
+		days := "all"
+		if len(window.Days) > 0 {
+			var names []string
+			for _, day := range window.Days {
+				names = append(names, day.String()[:3])
+			}
+			days = strings.Join(names, ",")
+		}
+		fmt.Fprintf(w, "%v %v\t%v\t%v\t%v\t%v\t%v\t%v\n", marker, i, days, window.Start, window.End,
+			scheduleSpeed(window.MaxDownloadSpeed), scheduleSpeed(window.MaxUploadSpeed), window.Paused)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+	if active >= 0 {
+		fmt.Printf("\nWindow %v is active until %v.\n", active, end.Format("Mon 15:04"))
+	}
+}
+
+// scheduleSpeed returns a human-readable bandwidth limit of a schedule window.
+func scheduleSpeed(speed int64) string {
+	if speed == 0 {
+		return "unlimited"
+	}
+	return ratelimitUnits(speed)
+}
+
+// renterscheduleaddcmd is the handler for the command `siac renter schedule
+// add [days] [start] [end]`. It appends a window to the bandwidth schedule.
+func renterscheduleaddcmd(daysStr, start, end string) {
+	days, err := parseWeekdays(daysStr)
+	if err != nil {
+		die(errors.AddContext(err, "unable to parse days"))
+	}
+	downloadSpeed, err := parseRatelimit(renterScheduleDownloadSpeed)
+	if err != nil {
+		die(errors.AddContext(err, "unable to parse download speed"))
+	}
+	uploadSpeed, err := parseRatelimit(renterScheduleUploadSpeed)
+	if err != nil {
+		die(errors.AddContext(err, "unable to parse upload speed"))
+	}
+	rg, err := httpClient.RenterGet()
+	if err != nil {
+		die("Could not get renter settings:", err)
+	}
+	schedule := append(rg.Settings.BandwidthSchedule, modules.BandwidthWindow{
+		Days:             days,
+		Start:            start,
+		End:              end,
+		MaxDownloadSpeed: downloadSpeed,
+		MaxUploadSpeed:   uploadSpeed,
+		Paused:           renterSchedulePause,
+	})
+	if err := httpClient.RenterPostBandwidthSchedule(schedule); err != nil {
+		die("Could not set bandwidth schedule:", err)
+	}
+	fmt.Println("Added window to the bandwidth schedule.")
+}
+
+// renterscheduleclearcmd is the handler for the command `siac renter schedule
+// clear`. It removes all windows from the bandwidth schedule.
+func renterscheduleclearcmd() {
+	if err := httpClient.RenterPostBandwidthSchedule(modules.BandwidthSchedule{}); err != nil {
+		die("Could not clear bandwidth schedule:", err)
+	}
+	fmt.Println("Cleared the bandwidth schedule.")
+}
+
 // renterworkerscmd is the handler for the command `siac renter workers`.
 // It lists the Renter's workers.
 func renterworkerscmd() {
@@ -96,6 +96,24 @@ func contractStats(contracts []api.RenterContract) (size uint64, spent, remainin
 	return
 }
 
+// downloadFullFile starts an async download of the file at siaPath to the
+// destination. The download is resumed if the --resume flag is set.
+func downloadFullFile(siaPath modules.SiaPath, destination string) (modules.DownloadID, error) {
+	if renterDownloadResume {
+		return httpClient.RenterDownloadResumeGet(siaPath, destination, true, true)
+	}
+	return httpClient.RenterDownloadFullGet(siaPath, destination, true, true)
+}
+
+// uploadFile uploads the file at source to siaPath. A checksum of the file is
+// recorded if the --checksum flag is set.
+func uploadFile(source string, siaPath modules.SiaPath, dataPieces, parityPieces uint64) error {
+	if renterUploadChecksum {
+		return httpClient.RenterUploadChecksumPost(source, siaPath, dataPieces, parityPieces)
+	}
+	return httpClient.RenterUploadPost(source, siaPath, dataPieces, parityPieces)
+}
+
 // downloadDir downloads the dir at the specified siaPath to the specified
 // location. It returns all the files for which a download was initialized as
 // tracked files and the ones which were ignored as skipped. Errors are composed
@@ -116,16 +134,17 @@ func downloadDir(siaPath modules.SiaPath, destination string) (tfs []trackedFile
 	for _, file := range rd.Files {
 		// Skip files that already exist.
 		dst := filepath.Join(destination, file.SiaPath.Name())
-		if _, err = os.Stat(dst); err == nil {
+		// Existing files are resumed instead if requested.
+		if _, err = os.Stat(dst); err == nil && !renterDownloadResume {
 			skipped = append(skipped, dst)
 			continue
-		} else if !os.IsNotExist(err) {
+		} else if err != nil && !os.IsNotExist(err) {
 			err = errors.AddContext(err, "failed to get file stats")
 			return
 		}
 		// Download file.
 		totalSize += file.Filesize
-		_, err = httpClient.RenterDownloadFullGet(file.SiaPath, dst, true, true)
+		_, err = downloadFullFile(file.SiaPath, dst)
 		if err != nil {
 			err = errors.AddContext(err, "Failed to start download")
 			return
@@ -527,7 +546,7 @@ func renterFilesDownload(path, destination string) {
 	// the call will return before the download has completed. The call is made
 	// as an async call.
 	start := time.Now()
-	cancelID, err := httpClient.RenterDownloadFullGet(siaPath, destination, true, true)
+	cancelID, err := downloadFullFile(siaPath, destination)
 	if err != nil {
 		die("Download could not be started:", err)
 	}
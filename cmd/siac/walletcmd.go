@@ -6,10 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -41,6 +43,19 @@ var (
 		Run:   wrap(walletaddressescmd),
 	}
 
+	walletAddressGapCmd = &cobra.Command{
+		Use:   "addressgap [gap]",
+		Short: "View or change the address gap",
+		Long: `View or change the number of unused addresses following the wallet's last
+used address that it watches for incoming transactions. Seeds which handed out
+many addresses without receiving funds on them need a larger gap. A gap of 0
+resets it to the default. Funds sent to addresses beyond the previous gap are
+only found after a rescan.`,
+		Example: `siac wallet addressgap
+siac wallet addressgap 20000`,
+		Run: walletaddressgapcmd,
+	}
+
 	walletBalanceCmd = &cobra.Command{
 		Use:   "balance",
 		Short: "View wallet balance",
@@ -63,6 +78,16 @@ be valid. txn may be either JSON, base64, or a file containing either.`,
 		Run:   wrap(walletchangepasswordcmd),
 	}
 
+	walletClaimsCmd = &cobra.Command{
+		Use:   "claims",
+		Short: "View siafund claims",
+		Long: `View the claims of the siafund outputs received by the wallet. For each output
+the claim start, the accrued claim and, once the output was spent, the payout
+transaction are listed. Outputs received before the wallet tracked claims are
+listed after a rescan.`,
+		Run: wrap(walletclaimscmd),
+	}
+
 	walletCmd = &cobra.Command{
 		Use:   "wallet",
 		Short: "Perform wallet actions",
@@ -82,6 +107,59 @@ The smallest unit of siacoins is the hasting. One siacoin is 10^24 hastings. Oth
 		Run: wrap(walletbalancecmd),
 	}
 
+	walletDefragCmd = &cobra.Command{
+		Use:   "defrag",
+		Short: "Consolidate the wallet's outputs",
+		Long: `Consolidate the wallet's smallest outputs into a single output. The wallet
+does this automatically according to its defrag policy, this command triggers
+a defrag manually regardless of the policy's threshold and fee limit. Use
+--dry-run to see which outputs would be consolidated and at what cost.`,
+		Run: wrap(walletdefragcmd),
+	}
+
+	walletDefragPolicyCmd = &cobra.Command{
+		Use:   "policy",
+		Short: "View the defrag policy",
+		Long:  "View the policy which determines when the wallet defrags automatically.",
+		Run:   wrap(walletdefragpolicycmd),
+	}
+
+	walletDefragPolicySetCmd = &cobra.Command{
+		Use:   "set",
+		Short: "Change the defrag policy",
+		Long: `Change the policy which determines when the wallet defrags automatically.
+Only the provided flags are changed. A threshold or batch size of 0 resets it
+to its default, a max fee of 0 removes the fee limit.`,
+		Example: `siac wallet defrag policy set --enabled=false
+siac wallet defrag policy set --threshold 100 --batch-size 50 --max-fee "10 nS"`,
+		Run: walletdefragpolicysetcmd,
+	}
+
+	walletDefragWindowCmd = &cobra.Command{
+		Use:   "window",
+		Short: "Perform actions related to the defrag windows",
+		Long: `Add or clear the time windows of the defrag policy. If the policy has time
+windows, the wallet only defrags automatically during one of them.`,
+	}
+
+	walletDefragWindowAddCmd = &cobra.Command{
+		Use:   "add [days] [start] [end]",
+		Short: "Add a time window to the defrag policy",
+		Long: `Add a time window to the defrag policy. Days is either 'all' or a comma
+separated list of weekdays like 'mon,tue'. Start and end are local times of the
+format 15:04. If end is not after start, the window ends on the following day.`,
+		Example: `siac wallet defrag window add all 02:00 05:00
+siac wallet defrag window add sat,sun 22:00 06:00`,
+		Run: wrap(walletdefragwindowaddcmd),
+	}
+
+	walletDefragWindowClearCmd = &cobra.Command{
+		Use:   "clear",
+		Short: "Remove all time windows from the defrag policy",
+		Long:  "Remove all time windows from the defrag policy, allowing automatic defrags at any time.",
+		Run:   wrap(walletdefragwindowclearcmd),
+	}
+
 	walletInitCmd = &cobra.Command{
 		Use:   "init",
 		Short: "Initialize and encrypt a new wallet",
@@ -114,8 +192,9 @@ By default the wallet encryption / unlock password is the same as the generated
 	walletLoadSeedCmd = &cobra.Command{
 		Use:   `seed`,
 		Short: "Add a seed to the wallet",
-		Long:  "Loads an auxiliary seed into the wallet.",
-		Run:   wrap(walletloadseedcmd),
+		Long: `Loads an auxiliary seed into the wallet. Blocks before the start height are
+skipped when scanning for the seed's transactions.`,
+		Run: wrap(walletloadseedcmd),
 	}
 
 	walletLoadSiagCmd = &cobra.Command{
@@ -126,6 +205,56 @@ By default the wallet encryption / unlock password is the same as the generated
 		Run:     wrap(walletloadsiagcmd),
 	}
 
+	walletInvoicesCmd = &cobra.Command{
+		Use:   "invoices",
+		Short: "View invoices",
+		Long:  "View the invoices of the wallet and the state of their payments.",
+		Run:   wrap(walletinvoicescmd),
+	}
+
+	walletInvoicesAddCmd = &cobra.Command{
+		Use:   "add [amount]",
+		Short: "Create an invoice",
+		Long: `Create an invoice for a siacoin payment to a new address of the wallet. The
+invoice is paid once the address received the amount and confirmed once the
+payments have the number of confirmations given by --confirmations.
+
+An invoice which isn't paid by the time given by --expiry expires. The time can
+be provided as a unix timestamp or in RFC3339 format. If --callback is set, the
+invoice is POSTed as JSON to the URL whenever its status changes.`,
+		Run: wrap(walletinvoicesaddcmd),
+	}
+
+	walletInvoicesRemoveCmd = &cobra.Command{
+		Use:   "remove [id]",
+		Short: "Remove an invoice",
+		Long:  "Stop tracking the payments of an invoice.",
+		Run:   wrap(walletinvoicesremovecmd),
+	}
+
+	walletLabelsCmd = &cobra.Command{
+		Use:   "labels",
+		Short: "View transaction and address labels",
+		Long:  "View the labels and memos of the wallet's transactions and addresses.",
+		Run:   wrap(walletlabelscmd),
+	}
+
+	walletLabelsAddressCmd = &cobra.Command{
+		Use:   "address [address] [label]",
+		Short: "Label an address",
+		Long: `Set the label and memo of an address. The address doesn't need to belong to
+the wallet. An empty label and memo remove the label.`,
+		Run: wrap(walletlabelsaddresscmd),
+	}
+
+	walletLabelsTransactionCmd = &cobra.Command{
+		Use:   "transaction [txid] [label]",
+		Short: "Label a transaction",
+		Long: `Set the label and memo of a transaction of the wallet. An empty label and
+memo remove the label.`,
+		Run: wrap(walletlabelstransactioncmd),
+	}
+
 	walletLockCmd = &cobra.Command{
 		Use:   "lock",
 		Short: "Lock the wallet",
@@ -133,6 +262,126 @@ By default the wallet encryption / unlock password is the same as the generated
 		Run:   wrap(walletlockcmd),
 	}
 
+	walletMultisigCmd = &cobra.Command{
+		Use:   "multisig",
+		Short: "View multisig accounts",
+		Long: `View the M-of-N multisig accounts of the wallet and their balances. The
+wallet tracks the outputs of its multisig accounts and signs spends from them
+with its local keys, the other cosigners add their signatures afterwards.`,
+		Run: wrap(walletmultisigcmd),
+	}
+
+	walletMultisigAddCmd = &cobra.Command{
+		Use:   "add [name] [required signatures] [public keys]",
+		Short: "Add a multisig account",
+		Long: `Add an M-of-N multisig account to the wallet. The public keys are a comma
+separated list of ed25519 keys like 'ed25519:<hex>' in the order of the
+account's UnlockConditions. The public keys of the wallet's addresses can be
+found with 'siac wallet address' and the /wallet/unlockconditions endpoint.
+The keys of the wallet which participate in the account are detected
+automatically unless --local-keys is provided.
+
+Unless --unused is set, the wallet rescans the blockchain to find the outputs
+of the account.`,
+		Run: wrap(walletmultisigaddcmd),
+	}
+
+	walletMultisigRemoveCmd = &cobra.Command{
+		Use:   "remove [address]",
+		Short: "Remove a multisig account",
+		Long: `Stop tracking a multisig account. Unless --unused is set, the wallet rescans
+the blockchain to rebuild its transaction history without the account.`,
+		Run: wrap(walletmultisigremovecmd),
+	}
+
+	walletMultisigSignCmd = &cobra.Command{
+		Use:   "sign [txn]",
+		Short: "Sign a multisig transaction",
+		Long: `Add the signatures of the wallet's local keys to a transaction spending
+from one of its multisig accounts. The transaction can be JSON, base64, or a
+path to a file containing either encoding. The signed transaction is printed
+as base64 to be passed on to the next cosigner or to be broadcast with
+'siac wallet broadcast' once it has enough signatures.`,
+		Run: wrap(walletmultisigsigncmd),
+	}
+
+	walletMultisigSpendCmd = &cobra.Command{
+		Use:   "spend [address] [amount] [dest]",
+		Short: "Spend siacoins from a multisig account",
+		Long: `Build a transaction sending siacoins from a multisig account, returning the
+change to the account. The transaction is signed with the wallet's local keys
+and printed as base64 to be signed by the other cosigners with
+'siac wallet multisig sign'.`,
+		Run: wrap(walletmultisigspendcmd),
+	}
+
+	walletOutputsCmd = &cobra.Command{
+		Use:   "outputs",
+		Short: "Manage the wallet's siacoin outputs",
+		Long:  "Freeze and unfreeze siacoin outputs. Frozen outputs are never used to fund transactions.",
+		// Run field is not set, as the outputs command itself is not a valid command.
+		// A subcommand must be provided.
+	}
+
+	walletOutputsFreezeCmd = &cobra.Command{
+		Use:   "freeze [id]...",
+		Short: "Freeze siacoin outputs",
+		Long:  "Prevent the wallet from spending the specified siacoin outputs until they are unfrozen.",
+		Run:   walletoutputsfreezecmd,
+	}
+
+	walletOutputsFrozenCmd = &cobra.Command{
+		Use:   "frozen",
+		Short: "List the frozen siacoin outputs",
+		Long:  "List the ids of the siacoin outputs which are frozen by the wallet.",
+		Run:   wrap(walletoutputsfrozencmd),
+	}
+
+	walletOutputsUnfreezeCmd = &cobra.Command{
+		Use:   "unfreeze [id]...",
+		Short: "Unfreeze siacoin outputs",
+		Long:  "Allow the wallet to spend the specified siacoin outputs again.",
+		Run:   walletoutputsunfreezecmd,
+	}
+
+	walletRescanCmd = &cobra.Command{
+		Use:   "rescan [height]",
+		Short: "Rescan the blockchain",
+		Long: `Delete the wallet's transaction history starting at the provided height, or
+at the height of the wallet's birth date, and rescan the blockchain from there.
+The birth date is either a unix timestamp or a date of the format 2006-01-02.`,
+		Example: `siac wallet rescan 250000
+siac wallet rescan --birthdate 2021-03-01`,
+		Run: walletrescancmd,
+	}
+
+	walletSchedulesCmd = &cobra.Command{
+		Use:   "schedules",
+		Short: "View scheduled payments",
+		Long:  "View the scheduled and recurring siacoin payments of the wallet.",
+		Run:   wrap(walletschedulescmd),
+	}
+
+	walletSchedulesAddCmd = &cobra.Command{
+		Use:   "add [amount] [dest]",
+		Short: "Schedule a payment",
+		Long: `Schedule a siacoin payment to an address. The payment is due once the
+block height given by --height or the block time given by --time is reached.
+The time can be provided as a unix timestamp or in RFC3339 format.
+
+Recurring payments are created with --interval. It is a number of blocks for
+height-based schedules and a duration, e.g. 720h, for time-based schedules.
+Failed payments are retried with every new block.`,
+		Run: wrap(walletschedulesaddcmd),
+	}
+
+	walletSchedulesRemoveCmd = &cobra.Command{
+		Use:   "remove [id]",
+		Short: "Remove a scheduled payment",
+		Long:  "Remove a scheduled payment from the wallet.",
+		Run:   wrap(walletschedulesremovecmd),
+	}
+
 	walletSeedsCmd = &cobra.Command{
 		Use:   "seeds",
 		Short: "View information about your seeds",
@@ -155,7 +404,11 @@ By default the wallet encryption / unlock password is the same as the generated
 'amount' can be specified in units, e.g. 1.23KS. Run 'wallet --help' for a list of units.
 If no unit is supplied, hastings will be assumed.
 
-A dynamic transaction fee is applied depending on the size of the transaction and how busy the network is.`,
+A dynamic transaction fee is applied depending on the size of the transaction and how busy the network is.
+
+Use --inputs to spend a specific set of outputs, or --strategy to change how the
+wallet selects outputs. Supported strategies are largest-first (default),
+smallest-first, minimize-change and privacy.`,
 		Run: wrap(walletsendsiacoinscmd),
 	}
 
@@ -182,14 +435,71 @@ provided, the wallet will fill in every TransactionSignature it has keys for.`,
 		Run: walletsigncmd,
 	}
 
+	walletSignerCmd = &cobra.Command{
+		Use:   "signer",
+		Short: "List the addresses of the external signer",
+		Long: `List the addresses of the wallet whose keys are held by the external signer
+configured with siad's --wallet-signer flag. Spends from these addresses are
+signed by the signer.`,
+		Run: wrap(walletsignercmd),
+	}
+
+	walletSignerAddressCmd = &cobra.Command{
+		Use:   "address",
+		Short: "Get a new address of the external signer",
+		Long: `Generate a new address of the wallet whose key is held by the external
+signer.`,
+		Run: wrap(walletsigneraddresscmd),
+	}
+
 	walletSweepCmd = &cobra.Command{
 		Use:   "sweep",
 		Short: "Sweep siacoins and siafunds from a seed.",
 		Long: `Sweep siacoins and siafunds from a seed. The outputs belonging to the seed
-will be sent to your wallet.`,
+will be sent to your wallet. Blocks before the start height are skipped when
+scanning for the seed's outputs.`,
 		Run: wrap(walletsweepcmd),
 	}
 
+	walletTimelockCmd = &cobra.Command{
+		Use:   "timelock",
+		Short: "View timelocked balances",
+		Long: `View the wallet's confirmed siacoins which are timelocked, grouped by the
+height at which they become spendable.`,
+		Run: wrap(wallettimelockcmd),
+	}
+
+	walletTimelockAddressCmd = &cobra.Command{
+		Use:   "address [height]",
+		Short: "Get a new timelocked address",
+		Long: `Generate a new address of the wallet which can't be spent from before the
+provided height. The printed unlock conditions have to be given to the sender,
+since they can't be derived from the address. The public key can be used to
+receive a vesting schedule.`,
+		Run: wrap(wallettimelockaddresscmd),
+	}
+
+	walletTimelockSendCmd = &cobra.Command{
+		Use:   "send [amount] [unlockconditions]",
+		Short: "Send siacoins to a timelocked address",
+		Long: `Send siacoins to a timelocked address. The unlock conditions are provided by
+the recipient as JSON, e.g. from 'siac wallet timelock address'.
+Run 'wallet send --help' to see a list of available units.`,
+		Run: wrap(wallettimelocksendcmd),
+	}
+
+	walletTimelockTrackCmd = &cobra.Command{
+		Use:   "track [unlockconditions]",
+		Short: "Track timelocked addresses",
+		Long: `Track timelocked addresses of the wallet's keys, e.g. the addresses of a
+vesting schedule created by a sender. The unlock conditions are a JSON object
+or array of objects as printed by 'siac wallet vesting'.
+
+Unless --unused is set, the wallet rescans the blockchain to find the outputs
+of the addresses.`,
+		Run: wrap(wallettimelocktrackcmd),
+	}
+
 	walletTransactionsCmd = &cobra.Command{
 		Use:   "transactions",
 		Short: "View transactions",
@@ -197,6 +507,18 @@ will be sent to your wallet.`,
 		Run:   wrap(wallettransactionscmd),
 	}
 
+	walletVestingCmd = &cobra.Command{
+		Use:   "vesting [amount] [publickey] [start] [interval] [tranches]",
+		Short: "Send a vesting schedule",
+		Long: `Send amount to the public key in tranches outputs with staggered timelocks.
+The first tranche unlocks at height start and every following tranche interval
+blocks later. The amount is split evenly, the last tranche receives the
+remainder. The printed unlock conditions have to be given to the recipient to
+track the schedule with 'siac wallet timelock track'.
+Run 'wallet send --help' to see a list of available units.`,
+		Run: wrap(walletvestingcmd),
+	}
+
 	walletUnlockCmd = &cobra.Command{
 		Use:   `unlock`,
 		Short: "Unlock the wallet",
@@ -269,6 +591,36 @@ func walletaddressescmd() {
 	}
 }
 
+// walletaddressgapcmd views or changes the address gap of the wallet.
+func walletaddressgapcmd(cmd *cobra.Command, args []string) {
+	switch len(args) {
+	case 0:
+		wsg, err := httpClient.WalletSettingsGet()
+		if err != nil {
+			die("Could not get wallet settings:", err)
+		}
+		if wsg.AddressGap == 0 {
+			fmt.Println("Address Gap: default")
+		} else {
+			fmt.Println("Address Gap:", wsg.AddressGap)
+		}
+	case 1:
+		gap, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			die("Could not parse address gap:", err)
+		}
+		values := url.Values{}
+		values.Set("addressgap", fmt.Sprint(gap))
+		if err := httpClient.WalletSettingsPost(values); err != nil {
+			die("Could not set address gap:", err)
+		}
+		fmt.Println("Address gap updated.")
+	default:
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+}
+
 // walletchangepasswordcmd changes the password of the wallet.
 func walletchangepasswordcmd() {
 	currentPassword, err := passwordPrompt(currentPasswordText)
@@ -288,6 +640,181 @@ func walletchangepasswordcmd() {
 	fmt.Println("Password changed successfully.")
 }
 
+// walletclaimscmd lists the siafund claims of the wallet.
+func walletclaimscmd() {
+	wscg, err := httpClient.WalletSiafundClaimsGet()
+	if err != nil {
+		die("Could not get siafund claims:", err)
+	}
+	if len(wscg.Claims) == 0 {
+		fmt.Println("No siafund outputs.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Output\tSiafunds\tReceived\tClaim Start\tClaim\tPayout Height\tPayout Transaction")
+	for _, sc := range wscg.Claims {
+		payoutHeight, payoutTxn := "-", "-"
+		if sc.Spent {
+			payoutHeight, payoutTxn = fmt.Sprint(sc.SpentHeight), sc.PayoutTransactionID.String()
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", sc.OutputID, sc.Value, sc.Height, currencyUnits(sc.ClaimStart),
+			currencyUnits(sc.Claim), payoutHeight, payoutTxn)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+	fmt.Printf("\nAccrued: %v\nPaid out: %v\n", currencyUnits(wscg.AccruedClaims), currencyUnits(wscg.PaidClaims))
+}
+
+// walletdefragcmd triggers or simulates a defrag of the wallet.
+func walletdefragcmd() {
+	wdp, err := httpClient.WalletDefragPost(walletDefragDryRun)
+	if err != nil {
+		die("Could not defrag wallet:", err)
+	}
+	r := wdp.Report
+	if walletDefragDryRun {
+		fmt.Println("Dry run, no transactions were broadcast.")
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Outputs:\t%v\n", len(r.Outputs))
+	fmt.Fprintf(w, "Amount:\t%v\n", currencyUnits(r.Amount))
+	fmt.Fprintf(w, "Fee:\t%v\n", currencyUnits(r.Fee))
+	fmt.Fprintf(w, "Fee per byte:\t%v\n", currencyUnits(r.FeePerByte))
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+	if !walletDefragDryRun {
+		fmt.Println("\nTransactions:")
+		for _, txid := range r.TransactionIDs {
+			fmt.Println(" ", txid)
+		}
+	}
+}
+
+// walletdefragpolicycmd prints the defrag policy of the wallet.
+func walletdefragpolicycmd() {
+	wsg, err := httpClient.WalletSettingsGet()
+	if err != nil {
+		die("Could not get wallet settings:", err)
+	}
+	p := wsg.DefragPolicy
+	threshold, batchSize, maxFee := fmt.Sprint(p.Threshold), fmt.Sprint(p.BatchSize), currencyUnits(p.MaxFeePerByte)
+	if p.Threshold == 0 {
+		threshold = "default"
+	}
+	if p.BatchSize == 0 {
+		batchSize = "default"
+	}
+	if p.MaxFeePerByte.IsZero() {
+		maxFee = "no limit"
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Enabled:\t%v\n", !wsg.NoDefrag)
+	fmt.Fprintf(w, "Threshold:\t%v\n", threshold)
+	fmt.Fprintf(w, "Batch Size:\t%v\n", batchSize)
+	fmt.Fprintf(w, "Max Fee Per Byte:\t%v\n", maxFee)
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+	if len(p.Windows) == 0 {
+		fmt.Println("\nNo defrag windows set, defrags are allowed at any time.")
+		return
+	}
+	fmt.Println("\nDefrag Windows:")
+	w = tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Days\tStart\tEnd")
+	for _, window := range p.Windows {
+		days := "all"
+		if len(window.Days) > 0 {
+			var names []string
+			for _, day := range window.Days {
+				names = append(names, day.String()[:3])
+			}
+			days = strings.Join(names, ",")
+		}
+		fmt.Fprintf(w, "  %v\t%v\t%v\n", days, window.Start, window.End)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// walletdefragpolicysetcmd changes the defrag policy of the wallet. Only the
+// flags which were provided are sent to siad.
+func walletdefragpolicysetcmd(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	values := url.Values{}
+	if cmd.Flags().Changed("enabled") {
+		values.Set("nodefrag", strconv.FormatBool(!walletDefragEnabled))
+	}
+	if cmd.Flags().Changed("threshold") {
+		values.Set("defragthreshold", fmt.Sprint(walletDefragThreshold))
+	}
+	if cmd.Flags().Changed("batch-size") {
+		values.Set("defragbatchsize", fmt.Sprint(walletDefragBatchSize))
+	}
+	if cmd.Flags().Changed("max-fee") {
+		hastings, err := types.ParseCurrency(walletDefragMaxFee)
+		if err != nil {
+			die("Could not parse max fee:", err)
+		}
+		values.Set("defragmaxfeeperbyte", hastings)
+	}
+	if len(values) == 0 {
+		die("No changes were provided.")
+	}
+	if err := httpClient.WalletSettingsPost(values); err != nil {
+		die("Could not set defrag policy:", err)
+	}
+	fmt.Println("Defrag policy updated.")
+}
+
+// walletdefragwindowaddcmd adds a time window to the defrag policy of the
+// wallet.
+func walletdefragwindowaddcmd(daysStr, start, end string) {
+	days, err := parseWeekdays(daysStr)
+	if err != nil {
+		die(errors.AddContext(err, "unable to parse days"))
+	}
+	wsg, err := httpClient.WalletSettingsGet()
+	if err != nil {
+		die("Could not get wallet settings:", err)
+	}
+	windows := append(wsg.DefragPolicy.Windows, modules.DefragWindow{
+		Days:  days,
+		Start: start,
+		End:   end,
+	})
+	if err := postDefragWindows(windows); err != nil {
+		die("Could not add defrag window:", err)
+	}
+	fmt.Println("Added window to the defrag policy.")
+}
+
+// walletdefragwindowclearcmd removes all time windows from the defrag policy
+// of the wallet.
+func walletdefragwindowclearcmd() {
+	if err := postDefragWindows([]modules.DefragWindow{}); err != nil {
+		die("Could not clear defrag windows:", err)
+	}
+	fmt.Println("Cleared the defrag windows.")
+}
+
+// postDefragWindows replaces the time windows of the wallet's defrag policy.
+func postDefragWindows(windows []modules.DefragWindow) error {
+	b, err := json.Marshal(windows)
+	if err != nil {
+		return err
+	}
+	values := url.Values{}
+	values.Set("defragwindows", string(b))
+	return httpClient.WalletSettingsPost(values)
+}
+
 // walletinitcmd encrypts the wallet with the given password
 func walletinitcmd() {
 	var password string
@@ -361,7 +888,7 @@ func walletloadseedcmd() {
 	if err != nil {
 		die("Reading password failed:", err)
 	}
-	err = httpClient.WalletSeedPost(seed, password)
+	err = httpClient.WalletSeedStartHeightPost(seed, password, types.BlockHeight(walletSeedStartHeight))
 	if err != nil {
 		die("Could not add seed:", err)
 	}
@@ -381,6 +908,128 @@ func walletloadsiagcmd(keyfiles string) {
 	fmt.Println("Wallet loading successful.")
 }
 
+// walletinvoicescmd lists the invoices of the wallet.
+func walletinvoicescmd() {
+	wig, err := httpClient.WalletInvoicesGet()
+	if err != nil {
+		die("Could not get invoices:", err)
+	}
+	if len(wig.Invoices) == 0 {
+		fmt.Println("No invoices.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tMemo\tAmount\tAddress\tExpiry\tReceived\tUnconfirmed\tConfirmations\tStatus")
+	for _, inv := range wig.Invoices {
+		expiry := "-"
+		if inv.Expiry != 0 {
+			expiry = time.Unix(int64(inv.Expiry), 0).Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v/%v\t%v\n", inv.ID, inv.Memo, currencyUnits(inv.Amount), inv.Address, expiry,
+			currencyUnits(inv.ConfirmedValue), currencyUnits(inv.UnconfirmedValue), inv.Confirmations, inv.RequiredConfirmations, inv.Status)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// walletinvoicesaddcmd creates an invoice.
+func walletinvoicesaddcmd(amount string) {
+	hastings, err := types.ParseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	inv := modules.Invoice{
+		Memo:                  walletInvoiceMemo,
+		RequiredConfirmations: walletInvoiceConfirmations,
+		CallbackURL:           walletInvoiceCallback,
+	}
+	if _, err := fmt.Sscan(hastings, &inv.Amount); err != nil {
+		die("Failed to parse amount", err)
+	}
+	if walletInvoiceExpiry != "" {
+		if unix, err := strconv.ParseUint(walletInvoiceExpiry, 10, 64); err == nil {
+			inv.Expiry = types.Timestamp(unix)
+		} else if t, err := time.Parse(time.RFC3339, walletInvoiceExpiry); err == nil {
+			inv.Expiry = types.Timestamp(t.Unix())
+		} else {
+			die("Could not parse expiry:", err)
+		}
+	}
+	wip, err := httpClient.WalletInvoicesPost(inv)
+	if err != nil {
+		die("Could not create invoice:", err)
+	}
+	fmt.Printf("Created invoice %v, awaiting payment to %v\n", wip.Invoice.ID, wip.Invoice.Address)
+}
+
+// walletinvoicesremovecmd removes an invoice.
+func walletinvoicesremovecmd(id string) {
+	var h crypto.Hash
+	if err := h.LoadString(id); err != nil {
+		die("Could not parse id:", err)
+	}
+	if err := httpClient.WalletInvoicesRemovePost(h); err != nil {
+		die("Could not remove invoice:", err)
+	}
+	fmt.Println("Removed invoice", id)
+}
+
+// walletlabelscmd lists the transaction and address labels of the wallet.
+func walletlabelscmd() {
+	wlg, err := httpClient.WalletLabelsGet()
+	if err != nil {
+		die("Could not get labels:", err)
+	}
+	if len(wlg.Transactions) == 0 && len(wlg.Addresses) == 0 {
+		fmt.Println("No labels.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if len(wlg.Transactions) > 0 {
+		fmt.Fprintln(w, "Transaction\tLabel\tMemo")
+		for _, tl := range wlg.Transactions {
+			fmt.Fprintf(w, "%v\t%v\t%v\n", tl.TransactionID, tl.Label, tl.Memo)
+		}
+	}
+	if len(wlg.Transactions) > 0 && len(wlg.Addresses) > 0 {
+		fmt.Fprintln(w)
+	}
+	if len(wlg.Addresses) > 0 {
+		fmt.Fprintln(w, "Address\tLabel\tMemo")
+		for _, al := range wlg.Addresses {
+			fmt.Fprintf(w, "%v\t%v\t%v\n", al.Address, al.Label, al.Memo)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// walletlabelsaddresscmd sets the label of an address.
+func walletlabelsaddresscmd(addr, label string) {
+	var uh types.UnlockHash
+	if err := uh.LoadString(addr); err != nil {
+		die("Could not parse address:", err)
+	}
+	if err := httpClient.WalletAddressLabelPost(uh, label, walletLabelMemo); err != nil {
+		die("Could not label address:", err)
+	}
+	fmt.Println("Labeled address", uh)
+}
+
+// walletlabelstransactioncmd sets the label of a transaction.
+func walletlabelstransactioncmd(txid, label string) {
+	var id types.TransactionID
+	if err := id.UnmarshalJSON([]byte(`"` + txid + `"`)); err != nil {
+		die("Could not parse transaction id:", err)
+	}
+	if err := httpClient.WalletTransactionLabelPost(id, label, walletLabelMemo); err != nil {
+		die("Could not label transaction:", err)
+	}
+	fmt.Println("Labeled transaction", id)
+}
+
 // walletlockcmd locks the wallet
 func walletlockcmd() {
 	err := httpClient.WalletLockPost()
@@ -389,6 +1038,120 @@ func walletlockcmd() {
 	}
 }
 
+// walletmultisigcmd lists the multisig accounts of the wallet.
+func walletmultisigcmd() {
+	wmg, err := httpClient.WalletMultisigGet()
+	if err != nil {
+		die("Could not get multisig accounts:", err)
+	}
+	if len(wmg.Accounts) == 0 {
+		fmt.Println("No multisig accounts.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Name\tAddress\tSignatures\tLocal Keys\tSiacoins\tSiafunds")
+	for _, ma := range wmg.Accounts {
+		uc := ma.UnlockConditions
+		fmt.Fprintf(w, "%v\t%v\t%v-of-%v\t%v\t%v\t%v\n", ma.Name, ma.Address, uc.SignaturesRequired, len(uc.PublicKeys),
+			len(ma.LocalKeys), currencyUnits(ma.ConfirmedSiacoinBalance), ma.ConfirmedSiafundBalance)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// walletmultisigaddcmd adds a multisig account to the wallet.
+func walletmultisigaddcmd(name, required, keys string) {
+	uc := types.UnlockConditions{
+		Timelock: types.BlockHeight(walletMultisigTimelock),
+	}
+	var err error
+	uc.SignaturesRequired, err = strconv.ParseUint(required, 10, 64)
+	if err != nil {
+		die("Could not parse required signatures:", err)
+	}
+	uc.PublicKeys, err = parsePublicKeys(keys)
+	if err != nil {
+		die("Could not parse public keys:", err)
+	}
+	var localKeys []types.SiaPublicKey
+	if walletMultisigLocalKeys != "" {
+		localKeys, err = parsePublicKeys(walletMultisigLocalKeys)
+		if err != nil {
+			die("Could not parse local keys:", err)
+		}
+	}
+	wmp, err := httpClient.WalletMultisigPost(name, uc, localKeys, walletMultisigUnused)
+	if err != nil {
+		die("Could not add multisig account:", err)
+	}
+	fmt.Printf("Added %v-of-%v multisig account %v with %v local key(s).\n", uc.SignaturesRequired, len(uc.PublicKeys),
+		wmp.Account.Address, len(wmp.Account.LocalKeys))
+}
+
+// walletmultisigremovecmd removes a multisig account from the wallet.
+func walletmultisigremovecmd(addrStr string) {
+	var addr types.UnlockHash
+	if err := addr.LoadString(addrStr); err != nil {
+		die("Could not parse address:", err)
+	}
+	if err := httpClient.WalletMultisigRemovePost(addr, walletMultisigUnused); err != nil {
+		die("Could not remove multisig account:", err)
+	}
+	fmt.Println("Removed multisig account", addr)
+}
+
+// walletmultisigsigncmd adds the wallet's signatures to a multisig
+// transaction.
+func walletmultisigsigncmd(txnStr string) {
+	txn, err := parseTxn(txnStr)
+	if err != nil {
+		die("Could not decode transaction:", err)
+	}
+	wmtp, err := httpClient.WalletMultisigSignPost(txn)
+	if err != nil {
+		die("Could not sign transaction:", err)
+	}
+	printMultisigTxn(wmtp)
+}
+
+// walletmultisigspendcmd builds a transaction spending from a multisig
+// account.
+func walletmultisigspendcmd(addrStr, amount, destStr string) {
+	var addr, dest types.UnlockHash
+	if err := addr.LoadString(addrStr); err != nil {
+		die("Could not parse address:", err)
+	}
+	if err := dest.LoadString(destStr); err != nil {
+		die("Could not parse destination address:", err)
+	}
+	hastings, err := types.ParseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	var value types.Currency
+	if _, err := fmt.Sscan(hastings, &value); err != nil {
+		die("Failed to parse amount", err)
+	}
+	wmtp, err := httpClient.WalletMultisigSpendPost(addr, []types.SiacoinOutput{{Value: value, UnlockHash: dest}})
+	if err != nil {
+		die("Could not create multisig spend:", err)
+	}
+	fmt.Printf("Sending %v with a fee of %v.\n", currencyUnits(value), currencyUnits(wmtp.Transaction.MinerFees[0]))
+	printMultisigTxn(wmtp)
+}
+
+// printMultisigTxn prints a multisig transaction as base64 together with its
+// signing status.
+func printMultisigTxn(wmtp api.WalletMultisigTransactionPOST) {
+	if wmtp.Complete {
+		fmt.Println("The transaction has enough signatures, broadcast it with 'siac wallet broadcast':")
+	} else {
+		fmt.Println("The transaction needs more signatures, pass it on to the next cosigner:")
+	}
+	fmt.Println(base64.StdEncoding.EncodeToString(encoding.Marshal(wmtp.Transaction)))
+}
+
 // walletseedcmd returns the current seed {
 func walletseedscmd() {
 	seedInfo, err := httpClient.WalletSeedsGet()
@@ -412,6 +1175,194 @@ func walletseedscmd() {
 	}
 }
 
+// parseOutputIDs parses a list of siacoin output ids.
+func parseOutputIDs(strs []string) ([]types.SiacoinOutputID, error) {
+	ids := make([]types.SiacoinOutputID, 0, len(strs))
+	for _, str := range strs {
+		var h crypto.Hash
+		if err := h.LoadString(strings.TrimSpace(str)); err != nil {
+			return nil, fmt.Errorf("invalid output id %q: %w", str, err)
+		}
+		ids = append(ids, types.SiacoinOutputID(h))
+	}
+	return ids, nil
+}
+
+// walletoutputsfreezecmd freezes siacoin outputs.
+func walletoutputsfreezecmd(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	ids, err := parseOutputIDs(args)
+	if err != nil {
+		die(err)
+	}
+	if err := httpClient.WalletOutputsFreezePost(ids); err != nil {
+		die("Could not freeze outputs:", err)
+	}
+	fmt.Printf("Froze %v output(s)\n", len(ids))
+}
+
+// walletoutputsfrozencmd lists the frozen siacoin outputs.
+func walletoutputsfrozencmd() {
+	wofg, err := httpClient.WalletOutputsFrozenGet()
+	if err != nil {
+		die("Could not get frozen outputs:", err)
+	}
+	if len(wofg.Outputs) == 0 {
+		fmt.Println("No frozen outputs.")
+		return
+	}
+	for _, id := range wofg.Outputs {
+		fmt.Println(id)
+	}
+}
+
+// walletoutputsunfreezecmd unfreezes siacoin outputs.
+func walletoutputsunfreezecmd(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	ids, err := parseOutputIDs(args)
+	if err != nil {
+		die(err)
+	}
+	if err := httpClient.WalletOutputsUnfreezePost(ids); err != nil {
+		die("Could not unfreeze outputs:", err)
+	}
+	fmt.Printf("Unfroze %v output(s)\n", len(ids))
+}
+
+// walletrescancmd rescans the blockchain starting at a height or the height
+// of a birth date.
+func walletrescancmd(cmd *cobra.Command, args []string) {
+	if len(args) > 1 || (len(args) == 1) == (walletRescanBirthDate != "") {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	fmt.Println("Rescanning the blockchain, this may take a while...")
+	var wrp api.WalletRescanPOST
+	var err error
+	if len(args) == 1 {
+		var height types.BlockHeight
+		if _, err := fmt.Sscan(args[0], &height); err != nil {
+			die("Could not parse height:", err)
+		}
+		wrp, err = httpClient.WalletRescanPost(height)
+	} else {
+		var birthDate types.Timestamp
+		if unix, err := strconv.ParseUint(walletRescanBirthDate, 10, 64); err == nil {
+			birthDate = types.Timestamp(unix)
+		} else if t, err := time.Parse("2006-01-02", walletRescanBirthDate); err == nil {
+			birthDate = types.Timestamp(t.Unix())
+		} else {
+			die("Could not parse birth date:", err)
+		}
+		wrp, err = httpClient.WalletRescanBirthDatePost(birthDate)
+	}
+	if err != nil {
+		die("Could not rescan the blockchain:", err)
+	}
+	fmt.Println("Rescanned the blockchain from height", wrp.ScanHeight)
+}
+
+// walletschedulescmd lists the payment schedules of the wallet.
+func walletschedulescmd() {
+	wsg, err := httpClient.WalletSchedulesGet()
+	if err != nil {
+		die("Could not get payment schedules:", err)
+	}
+	if len(wsg.Schedules) == 0 {
+		fmt.Println("No scheduled payments.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDescription\tAmount\tDestination\tNext\tInterval\tPayments\tStatus")
+	for _, ps := range wsg.Schedules {
+		next, interval := fmt.Sprintf("height %v", ps.NextHeight), fmt.Sprintf("%v blocks", ps.Interval)
+		if ps.NextTime != 0 {
+			next = time.Unix(int64(ps.NextTime), 0).Format(time.RFC3339)
+			interval = (time.Duration(ps.Interval) * time.Second).String()
+		}
+		if ps.Interval == 0 {
+			interval = "-"
+		}
+		status := "active"
+		if ps.Completed {
+			status, next = "completed", "-"
+		} else if ps.Failures > 0 {
+			status = fmt.Sprintf("failing (%v attempts): %v", ps.Failures, ps.LastError)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", ps.ID, ps.Description, currencyUnits(ps.Amount),
+			ps.Destination, next, interval, ps.Payments, status)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// walletschedulesaddcmd schedules a payment.
+func walletschedulesaddcmd(amount, dest string) {
+	hastings, err := types.ParseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	ps := modules.PaymentSchedule{
+		Description: walletScheduleDescription,
+		MaxPayments: walletScheduleMaxPayments,
+		NextHeight:  types.BlockHeight(walletScheduleHeight),
+	}
+	if _, err := fmt.Sscan(hastings, &ps.Amount); err != nil {
+		die("Failed to parse amount", err)
+	}
+	if _, err := fmt.Sscan(dest, &ps.Destination); err != nil {
+		die("Failed to parse destination address", err)
+	}
+	if (walletScheduleHeight == 0) == (walletScheduleTime == "") {
+		die("Exactly one of --height and --time must be provided")
+	}
+	if walletScheduleTime != "" {
+		if unix, err := strconv.ParseUint(walletScheduleTime, 10, 64); err == nil {
+			ps.NextTime = types.Timestamp(unix)
+		} else if t, err := time.Parse(time.RFC3339, walletScheduleTime); err == nil {
+			ps.NextTime = types.Timestamp(t.Unix())
+		} else {
+			die("Could not parse time:", err)
+		}
+	}
+	if walletScheduleInterval != "" && ps.NextTime != 0 {
+		d, err := time.ParseDuration(walletScheduleInterval)
+		if err != nil || d < time.Second {
+			die("Could not parse interval, expected a duration like 720h")
+		}
+		ps.Interval = uint64(d / time.Second)
+	} else if walletScheduleInterval != "" {
+		ps.Interval, err = strconv.ParseUint(walletScheduleInterval, 10, 64)
+		if err != nil {
+			die("Could not parse interval, expected a number of blocks:", err)
+		}
+	}
+	wsp, err := httpClient.WalletSchedulesPost(ps)
+	if err != nil {
+		die("Could not schedule payment:", err)
+	}
+	fmt.Println("Scheduled payment", wsp.Schedule.ID)
+}
+
+// walletschedulesremovecmd removes a scheduled payment.
+func walletschedulesremovecmd(id string) {
+	var h crypto.Hash
+	if err := h.LoadString(id); err != nil {
+		die("Could not parse id:", err)
+	}
+	if err := httpClient.WalletSchedulesRemovePost(h); err != nil {
+		die("Could not remove scheduled payment:", err)
+	}
+	fmt.Println("Removed scheduled payment", id)
+}
+
 // walletsendsiacoinscmd sends siacoins to a destination address.
 func walletsendsiacoinscmd(amount, dest string) {
 	hastings, err := types.ParseCurrency(amount)
@@ -426,7 +1377,16 @@ func walletsendsiacoinscmd(amount, dest string) {
 	if _, err := fmt.Sscan(dest, &hash); err != nil {
 		die("Failed to parse destination address", err)
 	}
-	_, err = httpClient.WalletSiacoinsPost(value, hash, walletTxnFeeIncluded)
+	cc := modules.CoinControl{
+		Strategy: modules.CoinSelectionStrategy(walletTxnStrategy),
+	}
+	if walletTxnInputs != "" {
+		cc.Inputs, err = parseOutputIDs(strings.Split(walletTxnInputs, ","))
+		if err != nil {
+			die("Failed to parse inputs:", err)
+		}
+	}
+	_, err = httpClient.WalletSiacoinsCoinControlPost(value, hash, walletTxnFeeIncluded, cc)
 	if err != nil {
 		die("Could not send siacoins:", err)
 	}
@@ -498,6 +1458,141 @@ Estimated Fee:       %v / KB
 `, encStatus, status.Height, currencyUnits(status.ConfirmedSiacoinBalance), delta,
 		status.ConfirmedSiacoinBalance, status.SiafundBalance, status.SiacoinClaimBalance,
 		fees.Maximum.Mul64(1e3).HumanString())
+
+	if len(status.TimelockedSiacoins) == 0 {
+		return
+	}
+	fmt.Printf("\nTimelocked Balance:  %v\n", currencyUnits(status.TimelockedSiacoinBalance))
+	for _, tb := range status.TimelockedSiacoins {
+		fmt.Printf("  %v unlocks at height %v\n", currencyUnits(tb.Value), tb.UnlockHeight)
+	}
+}
+
+// wallettimelockcmd lists the timelocked balances of the wallet.
+func wallettimelockcmd() {
+	status, err := httpClient.WalletGet()
+	if err != nil {
+		die("Could not get wallet status:", err)
+	}
+	if len(status.TimelockedSiacoins) == 0 {
+		fmt.Println("No timelocked siacoins.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Unlock Height\tBlocks Remaining\tSiacoins")
+	for _, tb := range status.TimelockedSiacoins {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", tb.UnlockHeight, tb.UnlockHeight-status.Height, currencyUnits(tb.Value))
+	}
+	fmt.Fprintf(w, "Total\t\t%v\n", currencyUnits(status.TimelockedSiacoinBalance))
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// wallettimelockaddresscmd generates a new timelocked address of the wallet.
+func wallettimelockaddresscmd(heightStr string) {
+	height, err := strconv.ParseUint(heightStr, 10, 64)
+	if err != nil {
+		die("Could not parse height:", err)
+	}
+	wtap, err := httpClient.WalletTimelockAddressPost(types.BlockHeight(height))
+	if err != nil {
+		die("Could not generate timelocked address:", err)
+	}
+	ucJSON, err := json.Marshal(wtap.UnlockConditions)
+	if err != nil {
+		die("Could not encode unlock conditions:", err)
+	}
+	fmt.Printf("Address:            %v\n", wtap.Address)
+	fmt.Printf("Public Key:         %v\n", wtap.UnlockConditions.PublicKeys[0])
+	fmt.Printf("Unlock Height:      %v\n", wtap.UnlockConditions.Timelock)
+	fmt.Printf("Unlock Conditions:  %s\n", ucJSON)
+}
+
+// wallettimelocksendcmd sends siacoins to a timelocked address.
+func wallettimelocksendcmd(amount, ucStr string) {
+	var uc types.UnlockConditions
+	if err := json.Unmarshal([]byte(ucStr), &uc); err != nil {
+		die("Could not parse unlock conditions:", err)
+	}
+	hastings, err := types.ParseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	var value types.Currency
+	if _, err := fmt.Sscan(hastings, &value); err != nil {
+		die("Failed to parse amount", err)
+	}
+	_, err = httpClient.WalletTimelockSendPost([]modules.TimelockedOutput{{UnlockConditions: uc, Value: value}})
+	if err != nil {
+		die("Could not send siacoins:", err)
+	}
+	fmt.Printf("Sent %v to %v, unlocking at height %v\n", currencyUnits(value), uc.UnlockHash(), uc.Timelock)
+}
+
+// wallettimelocktrackcmd tracks timelocked addresses of the wallet's keys.
+func wallettimelocktrackcmd(ucStr string) {
+	var ucs []types.UnlockConditions
+	if err := json.Unmarshal([]byte(ucStr), &ucs); err != nil {
+		var uc types.UnlockConditions
+		if err := json.Unmarshal([]byte(ucStr), &uc); err != nil {
+			die("Could not parse unlock conditions:", err)
+		}
+		ucs = []types.UnlockConditions{uc}
+	}
+	if err := httpClient.WalletTimelockTrackPost(ucs, walletTimelockUnused); err != nil {
+		die("Could not track timelocked addresses:", err)
+	}
+	fmt.Printf("Tracking %v timelocked address(es).\n", len(ucs))
+}
+
+// walletvestingcmd sends a vesting schedule to a public key.
+func walletvestingcmd(amount, pkStr, startStr, intervalStr, tranchesStr string) {
+	hastings, err := types.ParseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	var value types.Currency
+	if _, err := fmt.Sscan(hastings, &value); err != nil {
+		die("Failed to parse amount", err)
+	}
+	var pk types.SiaPublicKey
+	if err := pk.LoadString(pkStr); err != nil {
+		die("Could not parse public key:", err)
+	}
+	start, err := strconv.ParseUint(startStr, 10, 64)
+	if err != nil {
+		die("Could not parse start height:", err)
+	}
+	interval, err := strconv.ParseUint(intervalStr, 10, 64)
+	if err != nil {
+		die("Could not parse interval:", err)
+	}
+	tranches, err := strconv.ParseUint(tranchesStr, 10, 64)
+	if err != nil {
+		die("Could not parse tranches:", err)
+	}
+	wtsp, err := httpClient.WalletVestingPost(pk, value, types.BlockHeight(start), types.BlockHeight(interval), tranches)
+	if err != nil {
+		die("Could not send vesting schedule:", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Unlock Height\tAddress\tSiacoins")
+	ucs := make([]types.UnlockConditions, 0, len(wtsp.Outputs))
+	for _, o := range wtsp.Outputs {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", o.UnlockConditions.Timelock, o.UnlockConditions.UnlockHash(), currencyUnits(o.Value))
+		ucs = append(ucs, o.UnlockConditions)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+	ucsJSON, err := json.Marshal(ucs)
+	if err != nil {
+		die("Could not encode unlock conditions:", err)
+	}
+	fmt.Println("\nThe recipient can track the schedule with 'siac wallet timelock track' and these unlock conditions:")
+	fmt.Printf("%s\n", ucsJSON)
 }
 
 // walletbroadcastcmd broadcasts a transaction.
@@ -513,6 +1608,37 @@ func walletbroadcastcmd(txnStr string) {
 	fmt.Println("Transaction has been broadcast successfully")
 }
 
+// walletsignercmd lists the addresses of the external signer.
+func walletsignercmd() {
+	wsg, err := httpClient.WalletSignerGet()
+	if err != nil {
+		die("Could not get signer addresses:", err)
+	}
+	if len(wsg.Addresses) == 0 {
+		fmt.Println("No signer addresses.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Key Index\tAddress\tPublic Key")
+	for _, sa := range wsg.Addresses {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", sa.KeyIndex, sa.Address, sa.UnlockConditions.PublicKeys[0])
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// walletsigneraddresscmd generates a new address of the external signer.
+func walletsigneraddresscmd() {
+	wsap, err := httpClient.WalletSignerAddressPost()
+	if err != nil {
+		die("Could not generate signer address:", err)
+	}
+	fmt.Printf("Address:     %v\n", wsap.Address)
+	fmt.Printf("Public Key:  %v\n", wsap.UnlockConditions.PublicKeys[0])
+	fmt.Printf("Key Index:   %v\n", wsap.KeyIndex)
+}
+
 // walletsweepcmd sweeps coins and funds from a seed.
 func walletsweepcmd() {
 	seed, err := passwordPrompt("Seed: ")
@@ -520,7 +1646,7 @@ func walletsweepcmd() {
 		die("Reading seed failed:", err)
 	}
 
-	swept, err := httpClient.WalletSweepPost(seed)
+	swept, err := httpClient.WalletSweepStartHeightPost(seed, types.BlockHeight(walletSeedStartHeight))
 	if err != nil {
 		die("Could not sweep seed:", err)
 	}
@@ -16,6 +16,7 @@ import (
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/wallet"
 	"go.sia.tech/siad/node/api/server"
 	"go.sia.tech/siad/profile"
 )
@@ -219,6 +220,14 @@ func startDaemon(config Config) (err error) {
 	// Create the node params by parsing the modules specified in the config.
 	nodeParams := parseModules(config)
 
+	// Connect the wallet to the external signer.
+	if config.Siad.WalletSigner != "" {
+		nodeParams.WalletSigner, err = wallet.NewExternalSigner(config.Siad.WalletSigner)
+		if err != nil {
+			return errors.AddContext(err, "failed to create wallet signer")
+		}
+	}
+
 	// Start and run the server.
 	srv, err := server.New(config.Siad.APIaddr, config.Siad.RequiredUserAgent, config.APIPassword, nodeParams, loadStart)
 	if err != nil {
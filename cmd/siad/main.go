@@ -49,6 +49,8 @@ type Config struct {
 		Profile    string
 		ProfileDir string
 
+		WalletSigner string
+
 		// NOTE: SiaDir in this case is referencing the directory that siad is
 		// going to be running out of, not the actual siadir, which is where we
 		// put the apipassword file. This variable should not be altered if it
@@ -203,6 +205,7 @@ func main() {
 	root.Flags().StringVarP(&globalConfig.Siad.Modules, "modules", "M", "gctwrhfa", "enabled modules, see 'siad modules' for more info")
 	root.Flags().BoolVarP(&globalConfig.Siad.AuthenticateAPI, "authenticate-api", "", true, "enable API password protection")
 	root.Flags().BoolVarP(&globalConfig.Siad.TempPassword, "temp-password", "", false, "enter a temporary API password during startup")
+	root.Flags().StringVarP(&globalConfig.Siad.WalletSigner, "wallet-signer", "", "", "external signer for the wallet, a command or 'unix:' followed by the path of a socket")
 	root.Flags().BoolVarP(&globalConfig.Siad.AllowAPIBind, "disable-api-security", "", false, "allow siad to listen on a non-localhost address (DANGEROUS)")
 
 	// If globalConfig.Siad.SiaDir is not set, use the environment variable provided.
@@ -58,6 +58,24 @@ const (
 	AlertIDHostInsufficientCollateral = "host-insufficient-collateral"
 )
 
+// AlertIDHostStorageProof uses the id of a storage obligation to create a
+// unique AlertID for an alert about a storage proof the host can't provide.
+func AlertIDHostStorageProof(soid string) AlertID {
+	return AlertID(fmt.Sprintf("host-storage-proof:%v", soid))
+}
+
+// AlertIDWalletPaymentSchedule uses the id of a payment schedule to create a
+// unique AlertID for an alert about a scheduled payment that failed.
+func AlertIDWalletPaymentSchedule(id string) AlertID {
+	return AlertID(fmt.Sprintf("wallet-payment-schedule:%v", id))
+}
+
+// AlertIDWalletInvoice uses the id of an invoice to create a unique AlertID
+// for an alert about a change of the invoice's payment status.
+func AlertIDWalletInvoice(id string) AlertID {
+	return AlertID(fmt.Sprintf("wallet-invoice:%v", id))
+}
+
 // AlertIDSiafileLowRedundancy uses a Siafile's UID to create a unique AlertID
 // for a low redundancy alert.
 func AlertIDSiafileLowRedundancy(uid string) AlertID {
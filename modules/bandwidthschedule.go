@@ -0,0 +1,119 @@
+package modules
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// minutesPerDay is the number of minutes within a day.
+	minutesPerDay = 24 * 60
+
+	// minutesPerWeek is the number of minutes within a week.
+	minutesPerWeek = 7 * minutesPerDay
+)
+
+type (
+	// BandwidthSchedule is a weekly schedule of bandwidth windows. Whenever
+	// the current time falls within a window, the renter uses the window's
+	// bandwidth limits instead of the limits from its settings. If windows
+	// overlap, the first matching window is used.
+	BandwidthSchedule []BandwidthWindow
+
+	// BandwidthWindow is a recurring window within the week. The window starts
+	// at Start on each of its Days and ends at End, which may be on the
+	// following day if End is not after Start. A window with the same Start
+	// and End lasts a whole day. Times are in the renter's local time and use
+	// the format "15:04".
+	BandwidthWindow struct {
+		Days             []time.Weekday `json:"days"`
+		Start            string         `json:"start"`
+		End              string         `json:"end"`
+		MaxDownloadSpeed int64          `json:"maxdownloadspeed"`
+		MaxUploadSpeed   int64          `json:"maxuploadspeed"`
+		Paused           bool           `json:"paused"`
+	}
+)
+
+// parseTimeOfDay parses a time of the format "15:04" and returns the number of
+// minutes since midnight. "24:00" is accepted as the end of the day.
+func parseTimeOfDay(s string) (int, error) {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(s, "%d:%d", &hours, &minutes); err != nil {
+		return 0, errors.AddContext(err, fmt.Sprintf("invalid time of day '%v'", s))
+	}
+	if hours < 0 || minutes < 0 || minutes > 59 || hours*60+minutes > minutesPerDay {
+		return 0, fmt.Errorf("invalid time of day '%v'", s)
+	}
+	return hours*60 + minutes, nil
+}
+
+// days returns the days the window applies to.
+func (w BandwidthWindow) days() []time.Weekday {
+	if len(w.Days) > 0 {
+		return w.Days
+	}
+	return []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+}
+
+// span returns the start of the window in minutes since midnight and its
+// length in minutes.
+func (w BandwidthWindow) span() (start, length int, err error) {
+	start, err = parseTimeOfDay(w.Start)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseTimeOfDay(w.End)
+	if err != nil {
+		return 0, 0, err
+	}
+	length = ((end-start)%minutesPerDay + minutesPerDay) % minutesPerDay
+	if length == 0 {
+		length = minutesPerDay
+	}
+	return start % minutesPerDay, length, nil
+}
+
+// Validate checks that all the windows of the schedule are valid.
+func (bs BandwidthSchedule) Validate() error {
+	for i, w := range bs {
+		if _, _, err := w.span(); err != nil {
+			return errors.AddContext(err, fmt.Sprintf("window %v is invalid", i))
+		}
+		if w.MaxDownloadSpeed < 0 || w.MaxUploadSpeed < 0 {
+			return fmt.Errorf("window %v is invalid: bandwidth limits cannot be negative", i)
+		}
+		for _, day := range w.Days {
+			if day < time.Sunday || day > time.Saturday {
+				return fmt.Errorf("window %v is invalid: unknown weekday %v", i, int(day))
+			}
+		}
+	}
+	return nil
+}
+
+// ActiveWindow returns the index of the window which is active at time t
+// together with the time the current occurrence of the window started and
+// ends. If no window is active, the returned index is -1.
+func (bs BandwidthSchedule) ActiveWindow(t time.Time) (index int, start, end time.Time) {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	now := int(t.Weekday())*minutesPerDay + int(t.Sub(midnight)/time.Minute)
+	for i, w := range bs {
+		startOfDay, length, err := w.span()
+		if err != nil {
+			continue
+		}
+		for _, day := range w.days() {
+			windowStart := int(day)*minutesPerDay + startOfDay
+			elapsed := ((now-windowStart)%minutesPerWeek + minutesPerWeek) % minutesPerWeek
+			if elapsed >= length {
+				continue
+			}
+			start = t.Truncate(time.Minute).Add(-time.Duration(elapsed) * time.Minute)
+			return i, start, start.Add(time.Duration(length) * time.Minute)
+		}
+	}
+	return -1, time.Time{}, time.Time{}
+}
@@ -0,0 +1,80 @@
+package modules
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBandwidthScheduleValidate probes the Validate method of the
+// BandwidthSchedule.
+func TestBandwidthScheduleValidate(t *testing.T) {
+	tests := []struct {
+		window BandwidthWindow
+		valid  bool
+	}{
+		{BandwidthWindow{Start: "00:00", End: "06:00"}, true},
+		{BandwidthWindow{Start: "22:00", End: "24:00"}, true},
+		{BandwidthWindow{Start: "9:30", End: "9:30", Days: []time.Weekday{time.Monday}}, true},
+		{BandwidthWindow{Start: "", End: "06:00"}, false},
+		{BandwidthWindow{Start: "00:00", End: "24:01"}, false},
+		{BandwidthWindow{Start: "00:60", End: "06:00"}, false},
+		{BandwidthWindow{Start: "-1:00", End: "06:00"}, false},
+		{BandwidthWindow{Start: "00:00", End: "06:00", MaxDownloadSpeed: -1}, false},
+		{BandwidthWindow{Start: "00:00", End: "06:00", MaxUploadSpeed: -1}, false},
+		{BandwidthWindow{Start: "00:00", End: "06:00", Days: []time.Weekday{7}}, false},
+	}
+	for i, test := range tests {
+		err := BandwidthSchedule{test.window}.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%v: expected window to be valid: %v", i, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%v: expected window to be invalid", i)
+		}
+	}
+}
+
+// TestBandwidthScheduleActiveWindow probes the ActiveWindow method of the
+// BandwidthSchedule.
+func TestBandwidthScheduleActiveWindow(t *testing.T) {
+	schedule := BandwidthSchedule{
+		// Weekdays during office hours.
+		{Days: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, Start: "09:00", End: "17:00"},
+		// Friday night until Saturday morning.
+		{Days: []time.Weekday{time.Friday}, Start: "22:00", End: "06:00"},
+		// Every night, overlapping with the Friday window.
+		{Start: "23:00", End: "05:00"},
+	}
+	date := func(day, hour, minute int) time.Time {
+		// January 4th 2021 is a Monday.
+		return time.Date(2021, time.January, 3+day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		t     time.Time
+		index int
+		start time.Time
+		end   time.Time
+	}{
+		{date(1, 8, 59), -1, time.Time{}, time.Time{}},
+		{date(1, 9, 0), 0, date(1, 9, 0), date(1, 17, 0)},
+		{date(3, 16, 59), 0, date(3, 9, 0), date(3, 17, 0)},
+		{date(3, 17, 0), -1, time.Time{}, time.Time{}},
+		{date(6, 12, 0), -1, time.Time{}, time.Time{}},
+		{date(5, 23, 30), 1, date(5, 22, 0), date(6, 6, 0)},
+		{date(6, 5, 30), 1, date(5, 22, 0), date(6, 6, 0)},
+		{date(6, 6, 0), -1, time.Time{}, time.Time{}},
+		{date(6, 23, 0), 2, date(6, 23, 0), date(7, 5, 0)},
+		// Sunday night wraps around to Monday morning of the next week.
+		{date(8, 4, 59), 2, date(7, 23, 0), date(8, 5, 0)},
+	}
+	for i, test := range tests {
+		index, start, end := schedule.ActiveWindow(test.t)
+		if index != test.index || !start.Equal(test.start) || !end.Equal(test.end) {
+			t.Errorf("%v: expected %v %v %v but got %v %v %v", i, test.index, test.start, test.end, index, start, end)
+		}
+	}
+
+	// An empty schedule never has an active window.
+	if index, _, _ := (BandwidthSchedule{}).ActiveWindow(date(1, 12, 0)); index != -1 {
+		t.Fatal("empty schedule shouldn't have an active window", index)
+	}
+}
@@ -215,6 +215,12 @@ type (
 		// run any required closing routines.
 		Close() error
 
+		// ConsensusChangeAtHeight returns the id of a consensus change that
+		// can be passed to ConsensusSetSubscribe to receive the changes
+		// starting with the block at the provided height, and the height of
+		// the first block those changes apply, which may be lower.
+		ConsensusChangeAtHeight(types.BlockHeight) (ConsensusChangeID, types.BlockHeight, error)
+
 		// ConsensusSetSubscribe adds a subscriber to the list of subscribers
 		// and gives them every consensus change that has occurred since the
 		// change with the provided id. There are a few special cases,
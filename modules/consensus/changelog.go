@@ -128,6 +128,54 @@ func (ce *changeEntry) NextEntry(tx *bolt.Tx) (nextEntry changeEntry, exists boo
 	return getEntry(tx, cn.Next)
 }
 
+// ConsensusChangeAtHeight returns the id of the most recent consensus change
+// that left the current path complete up to the block preceding the provided
+// height. Subscribing with the returned id will send the consensus changes
+// starting with the block at that height, which allows subscribers to skip the
+// part of the blockchain they aren't interested in. Since a consensus change
+// may apply multiple blocks, the changes might start with an earlier block,
+// whose height is returned as well.
+func (cs *ConsensusSet) ConsensusChangeAtHeight(height types.BlockHeight) (ccid modules.ConsensusChangeID, start types.BlockHeight, err error) {
+	if err := cs.tg.Add(); err != nil {
+		return modules.ConsensusChangeID{}, 0, err
+	}
+	defer cs.tg.Done()
+	if height == 0 {
+		return modules.ConsensusChangeBeginning, 0, nil
+	}
+
+	// The scan walks the whole changelog, so it only relies on the consistent
+	// view of the read transaction instead of holding cs.mu, which would block
+	// new blocks from being accepted in the meantime.
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		if height > blockHeight(tx) {
+			return errHeightTooHigh
+		}
+		ccid, start = modules.ConsensusChangeBeginning, 0
+		entry, exists := cs.genesisEntry(), true
+		for ; exists; entry, exists = entry.NextEntry(tx) {
+			if len(entry.AppliedBlocks) == 0 {
+				continue
+			}
+			pb, err := getBlockMap(tx, entry.AppliedBlocks[len(entry.AppliedBlocks)-1])
+			if err != nil {
+				return err
+			}
+			// Changes leading to blocks that are no longer part of the
+			// current path will be reverted later on.
+			if pathID, err := getPath(tx, pb.Height); err != nil || pathID != pb.Block.ID() {
+				continue
+			}
+			if pb.Height >= height {
+				break
+			}
+			ccid, start = entry.ID(), pb.Height+1
+		}
+		return nil
+	})
+	return ccid, start, err
+}
+
 // createChangeLog assumes that no change log exists and creates a new one.
 func (cs *ConsensusSet) createChangeLog(tx *bolt.Tx) error {
 	// Create the changelog bucket.
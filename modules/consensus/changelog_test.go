@@ -1,8 +1,11 @@
 package consensus
 
 import (
+	"sync"
 	"testing"
+	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -61,3 +64,117 @@ func TestIntegrationChangeLog(t *testing.T) {
 		t.Error("subscribers have inconsistent update chains")
 	}
 }
+
+// TestConsensusChangeAtHeight checks that subscribing with the id returned by
+// ConsensusChangeAtHeight starts with the block at the requested height.
+func TestConsensusChangeAtHeight(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Height 0 starts at the beginning and heights past the current height
+	// are rejected.
+	height := cst.cs.Height()
+	if ccid, start, err := cst.cs.ConsensusChangeAtHeight(0); err != nil || ccid != modules.ConsensusChangeBeginning || start != 0 {
+		t.Fatal("expected ConsensusChangeBeginning for height 0", ccid, start, err)
+	}
+	if _, _, err := cst.cs.ConsensusChangeAtHeight(height + 1); !errors.Contains(err, errHeightTooHigh) {
+		t.Fatal("expected errHeightTooHigh but got", err)
+	}
+
+	for _, h := range []types.BlockHeight{1, height / 2, height} {
+		ccid, start, err := cst.cs.ConsensusChangeAtHeight(h)
+		if err != nil {
+			t.Fatal(err)
+		} else if start != h {
+			t.Fatalf("expected changes to start at height %v but got %v", h, start)
+		}
+		ms := newMockSubscriber()
+		if err := cst.cs.ConsensusSetSubscribe(&ms, ccid, cst.cs.tg.StopChan()); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := cst.cs.BlockAtHeight(h)
+		if len(ms.updates) != int(height-h+1) || ms.updates[0].AppliedBlocks[0].ID() != b.ID() {
+			t.Fatalf("subscription from height %v didn't start with the right block", h)
+		}
+	}
+}
+
+// TestConsensusChangeAtHeightConcurrentBlocks checks that ConsensusChangeAtHeight
+// doesn't need the consensus set's lock, which is held while accepting blocks.
+func TestConsensusChangeAtHeightConcurrentBlocks(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Hold the lock like block acceptance does and look up a consensus
+	// change in the meantime.
+	height := cst.cs.Height()
+	done := make(chan error)
+	cst.cs.mu.Lock()
+	go func() {
+		_, _, err := cst.cs.ConsensusChangeAtHeight(height)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		cst.cs.mu.Unlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		cst.cs.mu.Unlock()
+		t.Fatal("ConsensusChangeAtHeight blocked on the consensus set's lock")
+	}
+
+	// Blocks should still be accepted while lookups are running.
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if _, _, err := cst.cs.ConsensusChangeAtHeight(height); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 5; i++ {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cst.cs.Height() != height+5 {
+		t.Fatal("blocks weren't accepted", cst.cs.Height(), height+5)
+	}
+}
@@ -20,7 +20,8 @@ import (
 )
 
 var (
-	errNilGateway = errors.New("cannot have a nil gateway as input")
+	errHeightTooHigh = errors.New("height is greater than the current height of the consensus set")
+	errNilGateway    = errors.New("cannot have a nil gateway as input")
 )
 
 // marshaler marshals objects into byte slices and unmarshals byte
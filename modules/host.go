@@ -1,8 +1,11 @@
 package modules
 
 import (
+	"io"
+	"strings"
 	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/persist"
@@ -16,6 +19,11 @@ const (
 	// HostSettingsFile is the name of the host's persistence file.
 	HostSettingsFile = "host.json"
 
+	// HostMigratedKeysFile is the name of the file containing the key pair
+	// of a host which was imported from another node. The SiaMux picks it up
+	// on the next startup to take over the imported host's identity.
+	HostMigratedKeysFile = "migratedkeys.json"
+
 	// HostSiaMuxSubscriberName is the name used by the host to register a
 	// listener on the SiaMux.
 	HostSiaMuxSubscriberName = "host"
@@ -28,6 +36,17 @@ const (
 	HostRegistryFile = "registry.dat"
 )
 
+// The following constants are the types of a HostStorageProofAttempt.
+const (
+	// HostStorageProofAttemptPreflight is the type of a storage proof attempt
+	// verifying the sectors of an obligation before its proof window opens.
+	HostStorageProofAttemptPreflight = "preflight"
+
+	// HostStorageProofAttemptProof is the type of a storage proof attempt
+	// building the proof of an obligation within its proof window.
+	HostStorageProofAttemptProof = "proof"
+)
+
 var (
 	// Hostv112PersistMetadata is the header of the v112 host persist file.
 	Hostv112PersistMetadata = persist.Metadata{
@@ -52,8 +71,47 @@ var (
 		Header:  "Sia Host",
 		Version: "1.5.1",
 	}
+
+	// HostRegistryExportMetadata is the header of an exported host registry.
+	HostRegistryExportMetadata = persist.Metadata{
+		Header:  "Sia Host Registry Export",
+		Version: "1.5.5",
+	}
+
+	// HostMigratedKeysMetadata is the header of the HostMigratedKeysFile.
+	HostMigratedKeysMetadata = persist.Metadata{
+		Header:  "Sia Host Migrated Keys",
+		Version: "1.5.5",
+	}
 )
 
+var (
+	// ErrRenterBandwidthQuotaExceeded is returned by the host if a renter used
+	// up its bandwidth quota for the current period.
+	ErrRenterBandwidthQuotaExceeded = errors.New("renter exceeded the host's bandwidth quota, back off and retry later")
+
+	// ErrRenterConcurrencyLimitReached is returned by the host if a renter
+	// tries to execute more programs in parallel than the host allows.
+	ErrRenterConcurrencyLimitReached = errors.New("renter reached the host's limit of concurrent programs, back off and retry later")
+
+	// ErrRenterRequestQuotaExceeded is returned by the host if a renter sent
+	// more requests within the current period than the host allows.
+	ErrRenterRequestQuotaExceeded = errors.New("renter exceeded the host's request quota, back off and retry later")
+)
+
+// IsRenterQuotaError returns true if the provided error indicates that a host
+// throttled the renter. Since errors are sent over the wire as strings, the
+// check is based on the error messages.
+func IsRenterQuotaError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, ErrRenterBandwidthQuotaExceeded.Error()) ||
+		strings.Contains(msg, ErrRenterConcurrencyLimitReached.Error()) ||
+		strings.Contains(msg, ErrRenterRequestQuotaExceeded.Error())
+}
+
 const (
 	// DefaultMaxDuration defines the maximum number of blocks into the future
 	// that the host will accept for the duration of an incoming file contract
@@ -261,6 +319,113 @@ type (
 		PotentialDownloadBandwidthRevenue types.Currency `json:"potentialdownloadbandwidthrevenue"`
 		PotentialUploadBandwidthRevenue   types.Currency `json:"potentialuploadbandwidthrevenue"`
 		UploadBandwidthRevenue            types.Currency `json:"uploadbandwidthrevenue"`
+
+		// RegistryRevenue is the revenue from reading and updating registry
+		// entries.
+		RegistryRevenue types.Currency `json:"registryrevenue"`
+	}
+
+	// HostEphemeralAccount contains information about an ephemeral account
+	// on the host.
+	HostEphemeralAccount struct {
+		ID      string         `json:"id"`
+		Balance types.Currency `json:"balance"`
+
+		// MaxBalance is the maximum balance of the account. Capped is set if
+		// the host operator lowered it below the host's max balance.
+		Capped     bool           `json:"capped"`
+		MaxBalance types.Currency `json:"maxbalance"`
+
+		// PendingRisk is the amount withdrawn from the account which hasn't
+		// been persisted yet.
+		PendingRisk types.Currency `json:"pendingrisk"`
+
+		// BlockedWithdrawals is the number of withdrawals waiting for the
+		// account to be funded and PendingWithdrawals is their total value.
+		BlockedWithdrawals uint64         `json:"blockedwithdrawals"`
+		PendingWithdrawals types.Currency `json:"pendingwithdrawals"`
+
+		// LastActivity is the time of the last deposit or withdrawal. Expiry
+		// is the time at which the account expires due to inactivity, it is
+		// zero if the host doesn't expire accounts.
+		LastActivity time.Time `json:"lastactivity"`
+		Expiry       time.Time `json:"expiry"`
+	}
+
+	// HostEphemeralAccountMetrics contains aggregate metrics about the
+	// ephemeral accounts on the host.
+	HostEphemeralAccountMetrics struct {
+		NumAccounts uint64 `json:"numaccounts"`
+
+		// TotalBalance is the total amount of money deposited in all
+		// accounts.
+		TotalBalance types.Currency `json:"totalbalance"`
+
+		// CurrentRisk is the amount of money the host could lose due to
+		// deposits and withdrawals which aren't persisted yet. Once it exceeds
+		// MaxRisk, deposits and withdrawals are blocked.
+		CurrentRisk types.Currency `json:"currentrisk"`
+		MaxRisk     types.Currency `json:"maxrisk"`
+
+		// BlockedDeposits and BlockedWithdrawals are the number of deposits
+		// and withdrawals which are blocked because CurrentRisk exceeds
+		// MaxRisk. BlockedWithdrawalsAmount is the total value of the blocked
+		// withdrawals.
+		BlockedDeposits          uint64         `json:"blockeddeposits"`
+		BlockedWithdrawals       uint64         `json:"blockedwithdrawals"`
+		BlockedWithdrawalsAmount types.Currency `json:"blockedwithdrawalsamount"`
+
+		// UnfundedWithdrawals is the number of withdrawals waiting for their
+		// account to be funded and UnfundedWithdrawalsAmount is their total
+		// value.
+		UnfundedWithdrawals       uint64         `json:"unfundedwithdrawals"`
+		UnfundedWithdrawalsAmount types.Currency `json:"unfundedwithdrawalsamount"`
+	}
+
+	// HostRegistryEntry describes an entry of the host's registry.
+	HostRegistryEntry struct {
+		PublicKey types.SiaPublicKey `json:"publickey"`
+		Tweak     crypto.Hash        `json:"tweak"`
+		Revision  uint64             `json:"revision"`
+		Expiry    types.BlockHeight  `json:"expiry"`
+		Size      uint64             `json:"size"`
+		Type      RegistryEntryType  `json:"type"`
+	}
+
+	// HostRegistryExportEntry is an entry of an exported registry. Other than
+	// a HostRegistryEntry it contains the data and signature of the entry.
+	HostRegistryExportEntry struct {
+		HostRegistryEntry
+		Data      []byte           `json:"data"`
+		Signature crypto.Signature `json:"signature"`
+	}
+
+	// HostRegistryExport is the portable format the host's registry is
+	// exported in.
+	HostRegistryExport struct {
+		Header  string                    `json:"header"`
+		Version string                    `json:"version"`
+		Entries []HostRegistryExportEntry `json:"entries"`
+	}
+
+	// HostRegistryKeyLimit is the limit of registry entries a public key can
+	// register on the host, together with the number of entries it has
+	// registered.
+	HostRegistryKeyLimit struct {
+		PublicKey types.SiaPublicKey `json:"publickey"`
+		Limit     uint64             `json:"limit"`
+		Entries   uint64             `json:"entries"`
+	}
+
+	// HostFinancialSnapshot is a snapshot of the host's financial metrics at
+	// the end of a day. The metrics are cumulative, the difference between two
+	// snapshots is the change within that period.
+	HostFinancialSnapshot struct {
+		// Timestamp is the start of the day the snapshot belongs to in UTC.
+		Timestamp   time.Time         `json:"timestamp"`
+		BlockHeight types.BlockHeight `json:"blockheight"`
+
+		HostFinancialMetrics
 	}
 
 	// HostInternalSettings contains a list of settings that can be changed.
@@ -289,6 +454,64 @@ type (
 
 		CustomRegistryPath string `json:"customregistrypath"`
 		RegistrySize       uint64 `json:"registrysize"`
+
+		DynamicPricing HostDynamicPricingSettings `json:"dynamicpricing"`
+
+		RenterQuotas HostRenterQuotaSettings `json:"renterquotas"`
+	}
+
+	// HostRenterQuotaSettings limits how many resources a single renter can
+	// use on the host. The limits are applied per ephemeral account and per
+	// file contract. A value of 0 disables the corresponding limit.
+	HostRenterQuotaSettings struct {
+		// MaxBandwidthPerMinute is the number of bytes a renter can transfer
+		// in either direction per minute.
+		MaxBandwidthPerMinute uint64 `json:"maxbandwidthperminute"`
+
+		// MaxConcurrentPrograms is the number of MDM programs a renter can
+		// execute in parallel.
+		MaxConcurrentPrograms uint64 `json:"maxconcurrentprograms"`
+
+		// MaxRequestsPerMinute is the number of requests a renter can send
+		// per minute.
+		MaxRequestsPerMinute uint64 `json:"maxrequestsperminute"`
+	}
+
+	// HostDynamicPricingSettings configures the host's optional pricing
+	// engine. When enabled, the host adjusts its storage, upload and download
+	// prices between the corresponding Min*Price internal settings and the
+	// maximums below, based on its utilization.
+	HostDynamicPricingSettings struct {
+		Enabled bool `json:"enabled"`
+
+		// DampingFactor is the weight given to a new target price when it is
+		// blended with the previous price. A value of 1 applies the target
+		// price immediately, smaller values smooth out price changes.
+		DampingFactor float64 `json:"dampingfactor"`
+
+		MaxDownloadBandwidthPrice types.Currency `json:"maxdownloadbandwidthprice"`
+		MaxStoragePrice           types.Currency `json:"maxstorageprice"`
+		MaxUploadBandwidthPrice   types.Currency `json:"maxuploadbandwidthprice"`
+
+		// TargetBandwidth is the bandwidth in bytes per second in each
+		// direction at which the bandwidth prices reach their maximum.
+		TargetBandwidth uint64 `json:"targetbandwidth"`
+	}
+
+	// HostPricingDecision describes a single price adjustment made by the
+	// host's pricing engine together with the inputs that led to it.
+	HostPricingDecision struct {
+		Timestamp time.Time `json:"timestamp"`
+
+		// The utilization inputs are all within [0, 1].
+		ContractDemand      float64 `json:"contractdemand"`
+		DownloadUtilization float64 `json:"downloadutilization"`
+		StorageUtilization  float64 `json:"storageutilization"`
+		UploadUtilization   float64 `json:"uploadutilization"`
+
+		DownloadBandwidthPrice types.Currency `json:"downloadbandwidthprice"`
+		StoragePrice           types.Currency `json:"storageprice"`
+		UploadBandwidthPrice   types.Currency `json:"uploadbandwidthprice"`
 	}
 
 	// HostNetworkMetrics reports the quantity of each type of RPC call that
@@ -301,6 +524,13 @@ type (
 		ReviseCalls       uint64 `json:"revisecalls"`
 		SettingsCalls     uint64 `json:"settingscalls"`
 		UnrecognizedCalls uint64 `json:"unrecognizedcalls"`
+
+		// Throttling statistics. Each counter is incremented whenever a
+		// request was rejected because a renter exceeded the corresponding
+		// quota.
+		ThrottledBandwidthCalls   uint64 `json:"throttledbandwidthcalls"`
+		ThrottledConcurrencyCalls uint64 `json:"throttledconcurrencycalls"`
+		ThrottledRequestCalls     uint64 `json:"throttledrequestcalls"`
 	}
 
 	// StorageObligation contains information about a storage obligation that
@@ -341,6 +571,26 @@ type (
 		// or a proof has been confirmed on the blockchain.
 		ValidProofOutputs  []types.SiacoinOutput `json:"validproofoutputs"`
 		MissedProofOutputs []types.SiacoinOutput `json:"missedproofoutputs"`
+
+		// ProofAttempts is the history of the host's attempts to verify and
+		// build the storage proof of the obligation, oldest first. It is only
+		// populated when fetching a single storage obligation.
+		ProofAttempts []HostStorageProofAttempt `json:"proofattempts,omitempty"`
+	}
+
+	// HostStorageProofAttempt describes an attempt of the host to verify or
+	// build the storage proof of a storage obligation. Pre-flight attempts
+	// verify all sectors of the obligation before the proof window opens,
+	// since the segment to prove isn't known until then. Proof attempts build
+	// and verify the proof of the segment selected by the consensus set.
+	HostStorageProofAttempt struct {
+		BlockHeight  types.BlockHeight `json:"blockheight"`
+		Timestamp    time.Time         `json:"timestamp"`
+		Type         string            `json:"type"`
+		SegmentIndex uint64            `json:"segmentindex"`
+		SectorRoot   crypto.Hash       `json:"sectorroot"`
+		Success      bool              `json:"success"`
+		Error        string            `json:"error,omitempty"`
 	}
 
 	// HostWorkingStatus reports the working state of a host. Can be one of
@@ -405,16 +655,52 @@ type (
 		// requests to remove data.
 		DeleteSector(sectorRoot crypto.Hash) error
 
+		// CapEphemeralAccount limits the balance of an ephemeral account to
+		// the provided max balance. The account loses any balance above the
+		// cap.
+		CapEphemeralAccount(id AccountID, maxBalance types.Currency) error
+
+		// ExportMigration writes an archive containing everything another
+		// node needs to take over the host to the provided writer. Once the
+		// export starts, the host stops accepting changes to its storage
+		// obligations and ephemeral accounts.
+		ExportMigration(w io.Writer) error
+
+		// ExportRegistry writes all entries of the host's registry to the
+		// provided writer in a portable, json encoded format.
+		ExportRegistry(w io.Writer) error
+
 		// ExternalSettings returns the settings of the host as seen by an
 		// untrusted node querying the host for settings.
 		ExternalSettings() HostExternalSettings
 
+		// EphemeralAccounts returns information about all ephemeral accounts
+		// on the host.
+		EphemeralAccounts() []HostEphemeralAccount
+
+		// EphemeralAccountMetrics returns aggregate metrics about the
+		// ephemeral accounts on the host.
+		EphemeralAccountMetrics() HostEphemeralAccountMetrics
+
+		// ExpireEphemeralAccount expires an ephemeral account, deleting it
+		// together with its balance.
+		ExpireEphemeralAccount(id AccountID) error
+
 		// BandwidthCounters returns the Hosts's upload and download bandwidth
 		BandwidthCounters() (uint64, uint64, time.Time, error)
 
 		// FinancialMetrics returns the financial statistics of the host.
 		FinancialMetrics() HostFinancialMetrics
 
+		// FinancialSnapshots returns the host's daily financial snapshots
+		// within the provided time range, oldest first.
+		FinancialSnapshots(start, end time.Time) ([]HostFinancialSnapshot, error)
+
+		// ImportMigration imports an archive created by ExportMigration,
+		// taking over the exported host's obligations, sectors and identity.
+		// The host needs to be restarted afterwards.
+		ImportMigration(r io.Reader) error
+
 		// InternalSettings returns the host's internal settings, including
 		// potentially private or sensitive information.
 		InternalSettings() HostInternalSettings
@@ -428,6 +714,10 @@ type (
 		// PriceTable returns the host's current price table.
 		PriceTable() RPCPriceTable
 
+		// PricingDecisions returns the most recent price adjustments made by
+		// the host's dynamic pricing engine, oldest first.
+		PricingDecisions() []HostPricingDecision
+
 		// PruneStaleStorageObligations will delete storage obligations from the
 		// host that, for whatever reason, did not make it on the block chain.
 		// As these stale storage obligations have an impact on the host
@@ -457,6 +747,20 @@ type (
 		// of all at once.
 		MarkSectorsForRemoval(sectorRoots []crypto.Hash) error
 
+		// RegistryEntries returns the entries of the host's registry within
+		// the provided range, sorted by public key and tweak, together with
+		// the total number of entries. A limit of 0 returns all entries after
+		// the offset.
+		RegistryEntries(offset, limit uint64) ([]HostRegistryEntry, uint64)
+
+		// RegistryKeyLimits returns the limits of registry entries set for
+		// public keys.
+		RegistryKeyLimits() []HostRegistryKeyLimit
+
+		// RemoveRegistryKeyLimit removes the limit of registry entries the
+		// public key can register.
+		RemoveRegistryKeyLimit(pubKey types.SiaPublicKey) error
+
 		// RemoveStorageFolder will remove a storage folder from the host. All
 		// storage on the folder will be moved to other storage folders, meaning
 		// that no data will be lost. If the host is unable to save data, an
@@ -483,6 +787,11 @@ type (
 		// SetInternalSettings sets the hosting parameters of the host.
 		SetInternalSettings(HostInternalSettings) error
 
+		// SetRegistryKeyLimit limits the number of registry entries the
+		// public key can register, preventing a single renter from filling up
+		// the whole registry.
+		SetRegistryKeyLimit(pubKey types.SiaPublicKey, limit uint64) error
+
 		// StorageObligation returns the storage obligation matching the id or
 		// an error if it does not exist
 		StorageObligation(obligationID types.FileContractID) (StorageObligation, error)
@@ -0,0 +1,243 @@
+package host
+
+import (
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// ErrAccountNotFound occurs when an ephemeral account which doesn't exist
+	// is administered.
+	ErrAccountNotFound = errors.New("ephemeral account not found")
+)
+
+// loadBalanceCaps loads the balance caps set by the host operator from the
+// database. Caps of accounts which expired in the meantime are deleted.
+func (am *accountManager) loadBalanceCaps() error {
+	return am.h.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketAccountBalanceCaps)
+		var expired [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			var id modules.AccountID
+			if err := id.LoadString(string(k)); err != nil {
+				return err
+			}
+			if _, exists := am.accounts[id]; !exists {
+				expired = append(expired, k)
+				return nil
+			}
+			var maxBalance types.Currency
+			if err := encoding.Unmarshal(v, &maxBalance); err != nil {
+				return errors.AddContext(err, "failed to unmarshal balance cap")
+			}
+			am.balanceCaps[id] = maxBalance
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// maxBalance returns the max balance of the account with the given id, taking
+// its balance cap into account.
+func (am *accountManager) maxBalance(id modules.AccountID, hostMaxBalance types.Currency) (types.Currency, bool) {
+	maxBalance, capped := am.balanceCaps[id]
+	if !capped || maxBalance.Cmp(hostMaxBalance) > 0 {
+		return hostMaxBalance, capped
+	}
+	return maxBalance, capped
+}
+
+// expireAccount deletes the account from memory and signals all threads
+// waiting for it to be persisted that it expired.
+func (am *accountManager) expireAccount(acc *account) {
+	for _, c := range acc.persistResults {
+		c.externErr = ErrAccountExpired
+		close(c.errAvail)
+	}
+	delete(am.accounts, acc.id)
+	delete(am.balanceCaps, acc.id)
+}
+
+// managedDeleteAccounts deletes the expired accounts with the given indexes
+// from disk and releases their indexes. Saves of the accounts which are still
+// in progress are waited for first, otherwise they could overwrite the deleted
+// data or the data of an account which reuses the index.
+func (am *accountManager) managedDeleteAccounts(expired []uint32) error {
+	am.mu.Lock()
+	var saving []chan struct{}
+	for _, index := range expired {
+		if c, exists := am.savingIndexes[index]; exists {
+			saving = append(saving, c)
+		}
+	}
+	am.mu.Unlock()
+	for _, c := range saving {
+		<-c
+	}
+
+	deleted, err := am.staticAccountsPersister.callBatchDeleteAccount(expired)
+
+	// Once deleted from disk, recycle the indexes by releasing them.
+	am.mu.Lock()
+	for _, index := range deleted {
+		am.accountBitfield.releaseIndex(index)
+	}
+	am.mu.Unlock()
+	return err
+}
+
+// managedCapAccount sets the balance cap of the account with the given id and
+// lowers its balance to the cap.
+func (am *accountManager) managedCapAccount(id modules.AccountID, maxBalance types.Currency) error {
+	am.mu.Lock()
+	acc, exists := am.accounts[id]
+	if !exists {
+		am.mu.Unlock()
+		return ErrAccountNotFound
+	}
+
+	// Persist the cap before applying it. The lock is held to prevent the
+	// account from expiring in the meantime.
+	err := am.h.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketAccountBalanceCaps).Put([]byte(id.SPK().String()), encoding.Marshal(maxBalance))
+	})
+	if err != nil {
+		am.mu.Unlock()
+		return errors.AddContext(err, "failed to persist balance cap")
+	}
+	am.balanceCaps[id] = maxBalance
+	if acc.balance.Cmp(maxBalance) <= 0 {
+		am.mu.Unlock()
+		return nil
+	}
+	acc.balance = maxBalance
+	pr := &persistResult{
+		errAvail: make(chan struct{}),
+	}
+	am.schedulePersist(acc, pr)
+	am.mu.Unlock()
+
+	// Wait for the new balance to be persisted.
+	return am.staticWaitForDepositResult(pr)
+}
+
+// managedExpireAccount expires the account with the given id.
+func (am *accountManager) managedExpireAccount(id modules.AccountID) error {
+	am.mu.Lock()
+	acc, exists := am.accounts[id]
+	if !exists {
+		am.mu.Unlock()
+		return ErrAccountNotFound
+	}
+	am.expireAccount(acc)
+	am.mu.Unlock()
+
+	err := am.managedDeleteAccounts([]uint32{acc.index})
+	if err != nil {
+		return err
+	}
+	return am.h.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketAccountBalanceCaps).Delete([]byte(id.SPK().String()))
+	})
+}
+
+// managedAccounts returns information about all accounts, sorted by id.
+func (am *accountManager) managedAccounts(his modules.HostInternalSettings) []modules.HostEphemeralAccount {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	accounts := make([]modules.HostEphemeralAccount, 0, len(am.accounts))
+	for id, acc := range am.accounts {
+		maxBalance, capped := am.maxBalance(id, his.MaxEphemeralAccountBalance)
+		lastActivity := time.Unix(acc.lastTxnTime, 0)
+		var expiry time.Time
+		if his.EphemeralAccountExpiry > 0 {
+			expiry = lastActivity.Add(his.EphemeralAccountExpiry)
+		}
+		accounts = append(accounts, modules.HostEphemeralAccount{
+			ID:                 id.SPK().String(),
+			Balance:            acc.balance,
+			Capped:             capped,
+			MaxBalance:         maxBalance,
+			PendingRisk:        acc.pendingRisk,
+			BlockedWithdrawals: uint64(acc.blockedWithdrawals.Len()),
+			PendingWithdrawals: acc.blockedWithdrawals.Value(),
+			LastActivity:       lastActivity,
+			Expiry:             expiry,
+		})
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].ID < accounts[j].ID
+	})
+	return accounts
+}
+
+// managedMetrics returns aggregate metrics about all accounts.
+func (am *accountManager) managedMetrics(his modules.HostInternalSettings) modules.HostEphemeralAccountMetrics {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	metrics := modules.HostEphemeralAccountMetrics{
+		NumAccounts:        uint64(len(am.accounts)),
+		CurrentRisk:        am.currentRisk,
+		MaxRisk:            his.MaxEphemeralAccountRisk,
+		BlockedDeposits:    uint64(len(am.blockedDeposits)),
+		BlockedWithdrawals: uint64(len(am.blockedWithdrawals)),
+	}
+	for _, bw := range am.blockedWithdrawals {
+		metrics.BlockedWithdrawalsAmount = metrics.BlockedWithdrawalsAmount.Add(bw.withdrawal.Amount)
+	}
+	for _, acc := range am.accounts {
+		metrics.TotalBalance = metrics.TotalBalance.Add(acc.balance)
+		metrics.UnfundedWithdrawals += uint64(acc.blockedWithdrawals.Len())
+		metrics.UnfundedWithdrawalsAmount = metrics.UnfundedWithdrawalsAmount.Add(acc.blockedWithdrawals.Value())
+	}
+	return metrics
+}
+
+// CapEphemeralAccount limits the balance of an ephemeral account to the
+// provided max balance. The account loses any balance above the cap. The cap
+// is removed when the account expires.
+func (h *Host) CapEphemeralAccount(id modules.AccountID, maxBalance types.Currency) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+	return h.staticAccountManager.managedCapAccount(id, maxBalance)
+}
+
+// EphemeralAccounts returns information about all ephemeral accounts on the
+// host.
+func (h *Host) EphemeralAccounts() []modules.HostEphemeralAccount {
+	return h.staticAccountManager.managedAccounts(h.managedInternalSettings())
+}
+
+// EphemeralAccountMetrics returns aggregate metrics about the ephemeral
+// accounts on the host.
+func (h *Host) EphemeralAccountMetrics() modules.HostEphemeralAccountMetrics {
+	return h.staticAccountManager.managedMetrics(h.managedInternalSettings())
+}
+
+// ExpireEphemeralAccount expires an ephemeral account, deleting it together
+// with its balance.
+func (h *Host) ExpireEphemeralAccount(id modules.AccountID) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+	return h.staticAccountManager.managedExpireAccount(id)
+}
@@ -0,0 +1,215 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestAccountAdministration tests inspecting, capping and expiring ephemeral
+// accounts.
+func TestAccountAdministration(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := ht.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	// Fund two accounts.
+	_, aid1 := prepareAccount()
+	_, aid2 := prepareAccount()
+	if err := callDeposit(ht.host.staticAccountManager, aid1, types.NewCurrency64(100)); err != nil {
+		t.Fatal(err)
+	}
+	if err := callDeposit(ht.host.staticAccountManager, aid2, types.NewCurrency64(50)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the accounts and metrics.
+	accounts := ht.host.EphemeralAccounts()
+	if len(accounts) != 2 {
+		t.Fatal("expected 2 accounts", len(accounts))
+	}
+	his := ht.host.InternalSettings()
+	for _, acc := range accounts {
+		if acc.Capped || !acc.MaxBalance.Equals(his.MaxEphemeralAccountBalance) {
+			t.Fatal("account shouldn't be capped", acc)
+		}
+		if acc.Expiry.Sub(acc.LastActivity) != his.EphemeralAccountExpiry {
+			t.Fatal("unexpected expiry", acc.Expiry, acc.LastActivity)
+		}
+	}
+	metrics := ht.host.EphemeralAccountMetrics()
+	if metrics.NumAccounts != 2 || !metrics.TotalBalance.Equals64(150) {
+		t.Fatal("unexpected metrics", metrics)
+	}
+
+	// Cap the first account. Its balance should be lowered and deposits above
+	// the cap should fail.
+	err = ht.host.CapEphemeralAccount(aid1, types.NewCurrency64(40))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance := getAccountBalance(ht.host.staticAccountManager, aid1); !balance.Equals64(40) {
+		t.Fatal("balance wasn't capped", balance)
+	}
+	err = callDeposit(ht.host.staticAccountManager, aid1, types.NewCurrency64(1))
+	if !errors.Contains(err, ErrBalanceMaxExceeded) {
+		t.Fatal("expected ErrBalanceMaxExceeded", err)
+	}
+
+	// Expire the second account.
+	err = ht.host.ExpireEphemeralAccount(aid2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ht.host.ExpireEphemeralAccount(aid2)
+	if !errors.Contains(err, ErrAccountNotFound) {
+		t.Fatal("expected ErrAccountNotFound", err)
+	}
+	err = ht.host.CapEphemeralAccount(aid2, types.ZeroCurrency)
+	if !errors.Contains(err, ErrAccountNotFound) {
+		t.Fatal("expected ErrAccountNotFound", err)
+	}
+
+	// The cap and the expiry should survive a restart.
+	err = reloadHost(ht)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts = ht.host.EphemeralAccounts()
+	if len(accounts) != 1 {
+		t.Fatal("expected 1 account", len(accounts))
+	}
+	if acc := accounts[0]; acc.ID != aid1.SPK().String() || !acc.Capped || !acc.MaxBalance.Equals64(40) || !acc.Balance.Equals64(40) {
+		t.Fatal("unexpected account", acc)
+	}
+	if metrics := ht.host.EphemeralAccountMetrics(); metrics.NumAccounts != 1 || !metrics.TotalBalance.Equals64(40) {
+		t.Fatal("unexpected metrics", metrics)
+	}
+}
+
+// TestAccountAdministrationMetrics tests that blocked and unfunded withdrawals
+// are reported separately.
+func TestAccountAdministrationMetrics(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := ht.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	am := ht.host.staticAccountManager
+
+	_, aid := prepareAccount()
+	if err := callDeposit(am, aid, types.NewCurrency64(100)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a withdrawal blocked by the risk and one waiting for funds.
+	am.mu.Lock()
+	am.blockedWithdrawals = append(am.blockedWithdrawals, &blockedWithdrawal{
+		withdrawal: &modules.WithdrawalMessage{Amount: types.NewCurrency64(3)},
+	})
+	am.accounts[aid].blockedWithdrawals = blockedWithdrawalHeap{&blockedWithdrawal{
+		withdrawal: &modules.WithdrawalMessage{Amount: types.NewCurrency64(200)},
+	}}
+	am.mu.Unlock()
+
+	metrics := ht.host.EphemeralAccountMetrics()
+	if metrics.BlockedWithdrawals != 1 || !metrics.BlockedWithdrawalsAmount.Equals64(3) {
+		t.Fatal("unexpected blocked withdrawals", metrics)
+	}
+	if metrics.UnfundedWithdrawals != 1 || !metrics.UnfundedWithdrawalsAmount.Equals64(200) {
+		t.Fatal("unexpected unfunded withdrawals", metrics)
+	}
+
+	am.mu.Lock()
+	am.blockedWithdrawals = am.blockedWithdrawals[:0]
+	am.accounts[aid].blockedWithdrawals = blockedWithdrawalHeap{}
+	am.mu.Unlock()
+}
+
+// TestExpireAccountWaitsForSave tests that expiring an account waits for an
+// ongoing save of the account before its index is released.
+func TestExpireAccountWaitsForSave(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := ht.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	am := ht.host.staticAccountManager
+
+	_, aid := prepareAccount()
+	if err := callDeposit(am, aid, types.NewCurrency64(100)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pretend that the account is being saved.
+	saving := make(chan struct{})
+	am.mu.Lock()
+	index := am.accounts[aid].index
+	am.savingIndexes[index] = saving
+	am.mu.Unlock()
+	indexTaken := func() bool {
+		am.mu.Lock()
+		defer am.mu.Unlock()
+		return am.accountBitfield[index/64]&(1<<(index%64)) != 0
+	}
+
+	// Expiring the account shouldn't release the index before the save is
+	// done.
+	done := make(chan error)
+	go func() {
+		done <- ht.host.ExpireEphemeralAccount(aid)
+	}()
+	select {
+	case err := <-done:
+		t.Fatal("expiry didn't wait for the save", err)
+	case <-time.After(time.Second):
+	}
+	if !indexTaken() {
+		t.Fatal("index was released during the save")
+	}
+	am.mu.Lock()
+	close(saving)
+	delete(am.savingIndexes, index)
+	am.mu.Unlock()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if indexTaken() {
+		t.Fatal("index wasn't released")
+	}
+}
@@ -102,6 +102,17 @@ type (
 		// persisted.
 		accountBitfield accountBitfield
 
+		// balanceCaps contains the max balances of accounts which were capped
+		// by the host operator.
+		balanceCaps map[modules.AccountID]types.Currency
+
+		// savingIndexes contains a channel for the index of every account
+		// which is currently being written to disk by threadedSaveAccount.
+		// The channel is closed once the write is done. The index of an
+		// expired account is only released after its write is done, so a
+		// slow save can't overwrite a reused index.
+		savingIndexes map[uint32]chan struct{}
+
 		// To increase performance, deposits get credited before the file
 		// contract fsynced, and withdrawals do not block until the ephemeral
 		// account is safely persisted. This allows users to transact with the
@@ -251,6 +262,8 @@ func (h *Host) newAccountManager() (_ *accountManager, err error) {
 		blockedDeposits:    make([]*blockedDeposit, 0),
 		blockedWithdrawals: make([]*blockedWithdrawal, 0),
 		accountBitfield:    make(accountBitfield, 0),
+		balanceCaps:        make(map[modules.AccountID]types.Currency),
+		savingIndexes:      make(map[uint32]chan struct{}),
 		h:                  h,
 
 		// withdrawals are inactive until the host is synced, consensus updates
@@ -276,6 +289,11 @@ func (h *Host) newAccountManager() (_ *accountManager, err error) {
 	// Build the account index
 	am.accountBitfield.buildIndex(am.accounts)
 
+	// Load the balance caps
+	if err = am.loadBalanceCaps(); err != nil {
+		return nil, errors.AddContext(err, "failed to load balance caps")
+	}
+
 	// Close any open file handles if we receive a stop signal
 	am.h.tg.AfterStop(func() {
 		am.staticAccountsPersister.callClose()
@@ -359,6 +377,11 @@ func (am *accountManager) callRefund(id modules.AccountID, amount types.Currency
 // after FC sync: EA is updated, FC is updated, there is no risk to the host at
 // this point
 func (am *accountManager) managedDeposit(id modules.AccountID, amount types.Currency, refund bool, syncChan chan struct{}) error {
+	// Accounts can't be modified while the host is being migrated.
+	if am.h.staticMigrating() {
+		return errHostMigrating
+	}
+
 	// Gather some variables.
 	bh := am.h.BlockHeight()
 	his := am.h.managedInternalSettings()
@@ -386,6 +409,11 @@ func (am *accountManager) managedDeposit(id modules.AccountID, amount types.Curr
 // withdrawals get processed in the event they are blocked due to insufficient
 // funds.
 func (am *accountManager) callWithdraw(msg *modules.WithdrawalMessage, sig crypto.Signature, priority int64, bh types.BlockHeight) error {
+	// Accounts can't be modified while the host is being migrated.
+	if am.h.staticMigrating() {
+		return errHostMigrating
+	}
+
 	// Gather some variables
 	his := am.h.managedInternalSettings()
 	maxRisk := his.MaxEphemeralAccountRisk
@@ -469,6 +497,7 @@ func (am *accountManager) deposit(id modules.AccountID, amount, maxRisk, maxBala
 	}
 
 	// Verify if the deposit does not exceed the maximum
+	maxBalance, _ = am.maxBalance(id, maxBalance)
 	if !refund && acc.depositExceedsMaxBalance(amount, maxBalance) {
 		pr.externErr = ErrBalanceMaxExceeded
 		close(pr.errAvail)
@@ -558,7 +587,24 @@ func (am *accountManager) managedWithdraw(msg *modules.WithdrawalMessage, fp cry
 func (am *accountManager) managedAccountPersistInfo(id modules.AccountID) *accountPersistInfo {
 	am.mu.Lock()
 	defer am.mu.Unlock()
+	return am.accountPersistInfo(id)
+}
+
+// managedStartSave collects the data to persist like managedAccountPersistInfo
+// and marks the account's index as being saved.
+func (am *accountManager) managedStartSave(id modules.AccountID) *accountPersistInfo {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	accInfo := am.accountPersistInfo(id)
+	if accInfo != nil {
+		am.savingIndexes[accInfo.index] = make(chan struct{})
+	}
+	return accInfo
+}
 
+// accountPersistInfo collects the data required to persist the account with
+// the given id.
+func (am *accountManager) accountPersistInfo(id modules.AccountID) *accountPersistInfo {
 	acc, exists := am.accounts[id]
 	if !exists {
 		return nil
@@ -613,7 +659,7 @@ func (am *accountManager) threadedUpdateRiskAfterSync(deposit types.Currency, sy
 // inside the goroutine, the host risks losing money even on graceful shutdowns.
 func (am *accountManager) threadedSaveAccount(id modules.AccountID) (waiting int) {
 	// Gather all information required to persist and process it afterwards
-	accInfo := am.managedAccountPersistInfo(id)
+	accInfo := am.managedStartSave(id)
 	if accInfo == nil {
 		// Account expired
 		return
@@ -629,6 +675,10 @@ func (am *accountManager) threadedSaveAccount(id modules.AccountID) (waiting int
 	am.mu.Lock()
 	defer am.mu.Unlock()
 
+	// Signal that the index is no longer being written to.
+	close(am.savingIndexes[accInfo.index])
+	delete(am.savingIndexes, accInfo.index)
+
 	// Take care of the pending risk in the account. We lower the risk by the
 	// amount of risk that was captured in account info. This is necessary
 	// seeing the pendingRisk can have been increased in the mean time, and that
@@ -870,20 +920,10 @@ func (am *accountManager) threadedPruneExpiredAccounts() {
 			}
 
 			// Batch delete the expired accounts on disk
-			deleted, err := am.staticAccountsPersister.callBatchDeleteAccount(expired)
+			err := am.managedDeleteAccounts(expired)
 			if err != nil {
 				am.h.log.Println(errors.AddContext(err, "prune expired accounts failed"))
 			}
-			if len(deleted) == 0 {
-				return
-			}
-
-			// Once deleted from disk, recycle the indexes by releasing them.
-			am.mu.Lock()
-			for _, index := range deleted {
-				am.accountBitfield.releaseIndex(index)
-			}
-			am.mu.Unlock()
 		}()
 
 		// Block until next cycle.
@@ -934,14 +974,10 @@ func (am *accountManager) managedExpireAccounts(threshold int64) []uint32 {
 
 	var deleted []uint32
 	now := time.Now().Unix()
-	for id, acc := range am.accounts {
+	for _, acc := range am.accounts {
 		if force || now-acc.lastTxnTime > threshold {
 			// Signal all waiting result chans this account has expired
-			for _, c := range acc.persistResults {
-				c.externErr = ErrAccountExpired
-				close(c.errAvail)
-			}
-			delete(am.accounts, id)
+			am.expireAccount(acc)
 			deleted = append(deleted, acc.index)
 		}
 	}
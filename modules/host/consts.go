@@ -12,6 +12,10 @@ const (
 	// AlertMSGHostInsufficientCollateral indicates that a host has insufficient
 	// collateral budget remaining
 	AlertMSGHostInsufficientCollateral = "host has insufficient collateral budget"
+
+	// AlertMSGHostStorageProof indicates that the host is unable to provide a
+	// valid storage proof for a storage obligation.
+	AlertMSGHostStorageProof = "host is unable to provide a storage proof"
 )
 
 const (
@@ -26,6 +30,15 @@ const (
 	// contract revision, or a storage proof.
 	resubmissionTimeout = 3
 
+	// maxStorageProofAttempts is the number of storage proof attempts the
+	// host keeps in the history of a storage obligation.
+	maxStorageProofAttempts = 50
+
+	// maxPreflightSectors is the maximum number of sectors the host reads
+	// when verifying ahead of the proof window that it can build the storage
+	// proof of an obligation.
+	maxPreflightSectors = 64
+
 	// rpcRequestInterval is the amount of time that the renter has to send
 	// the next RPC ID in the new RPC loop. (More time is alloted for sending
 	// the actual RPC request object.)
@@ -160,6 +173,31 @@ var (
 	// bucketStorageObligations contains a set of serialized
 	// 'storageObligations' sorted by their file contract id.
 	bucketStorageObligations = []byte("BucketStorageObligations")
+
+	// bucketTemporarySectors maps a blockchain height to a list of sector
+	// roots of sectors that were stored without a file contract and expire at
+	// that height. Like with the action items, the height is stored as a big
+	// endian uint64.
+	bucketTemporarySectors = []byte("BucketTemporarySectors")
+
+	// bucketFinancialSnapshots maps the start of a day, stored as a big endian
+	// uint64 unix timestamp, to the json encoded financial snapshot of that
+	// day.
+	bucketFinancialSnapshots = []byte("BucketFinancialSnapshots")
+
+	// bucketAccountBalanceCaps maps the id of an ephemeral account to the max
+	// balance the host operator capped it at.
+	bucketAccountBalanceCaps = []byte("BucketAccountBalanceCaps")
+
+	// bucketStorageProofAttempts maps the id of a storage obligation to the
+	// json encoded history of the host's attempts to verify and build its
+	// storage proof.
+	bucketStorageProofAttempts = []byte("BucketStorageProofAttempts")
+
+	// bucketRegistryKeyLimits maps the string representation of a public key
+	// to the number of registry entries the host operator allows it to
+	// register.
+	bucketRegistryKeyLimits = []byte("BucketRegistryKeyLimits")
 )
 
 // init runs a series of sanity checks to verify that the constants have sane
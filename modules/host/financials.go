@@ -0,0 +1,118 @@
+package host
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// financialSnapshotInterval is the length of the period covered by a
+	// single financial snapshot.
+	financialSnapshotInterval = build.Select(build.Var{
+		Standard: 24 * time.Hour,
+		Dev:      time.Hour,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+
+	// financialSnapshotFrequency is the frequency at which the host updates
+	// the snapshot of the current period.
+	financialSnapshotFrequency = build.Select(build.Var{
+		Standard: time.Hour,
+		Dev:      5 * time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+)
+
+// snapshotKey returns the database key of the snapshot covering the provided
+// time. Times before the unix epoch, like the zero time, map to the key of the
+// epoch.
+func snapshotKey(t time.Time) []byte {
+	ts := t.UTC().Truncate(financialSnapshotInterval).Unix()
+	if ts < 0 {
+		ts = 0
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(ts))
+	return key
+}
+
+// managedSnapshotFinancials updates the financial snapshot of the period
+// containing the provided time with the host's current financial metrics.
+func (h *Host) managedSnapshotFinancials(now time.Time) error {
+	h.mu.RLock()
+	snapshot := modules.HostFinancialSnapshot{
+		Timestamp:            now.UTC().Truncate(financialSnapshotInterval),
+		BlockHeight:          h.blockHeight,
+		HostFinancialMetrics: h.financialMetrics,
+	}
+	h.mu.RUnlock()
+
+	value, err := json.Marshal(snapshot)
+	if err != nil {
+		return errors.AddContext(err, "failed to marshal financial snapshot")
+	}
+	return h.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketFinancialSnapshots).Put(snapshotKey(now), value)
+	})
+}
+
+// threadedSnapshotFinancials periodically persists a snapshot of the host's
+// financial metrics.
+func (h *Host) threadedSnapshotFinancials() {
+	for {
+		func() {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			defer h.tg.Done()
+			if err := h.managedSnapshotFinancials(time.Now()); err != nil {
+				h.log.Println("ERROR: failed to snapshot financial metrics:", err)
+			}
+		}()
+
+		// Block until next cycle.
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(financialSnapshotFrequency):
+			continue
+		}
+	}
+}
+
+// FinancialSnapshots returns the host's financial snapshots of the periods
+// overlapping the provided time range, oldest first. A zero end time means
+// that there is no upper bound.
+func (h *Host) FinancialSnapshots(start, end time.Time) ([]modules.HostFinancialSnapshot, error) {
+	if err := h.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer h.tg.Done()
+	if !end.IsZero() && end.Before(start) {
+		return nil, errors.New("end of the range can't be before its start")
+	}
+
+	var snapshots []modules.HostFinancialSnapshot
+	err := h.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketFinancialSnapshots).Cursor()
+		endKey := snapshotKey(end)
+		for k, v := c.Seek(snapshotKey(start)); k != nil; k, v = c.Next() {
+			if !end.IsZero() && binary.BigEndian.Uint64(k) > binary.BigEndian.Uint64(endKey) {
+				break
+			}
+			var snapshot modules.HostFinancialSnapshot
+			if err := json.Unmarshal(v, &snapshot); err != nil {
+				return errors.AddContext(err, "failed to unmarshal financial snapshot")
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+		return nil
+	})
+	return snapshots, err
+}
@@ -0,0 +1,111 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/types"
+)
+
+// TestFinancialSnapshots tests persisting and querying the host's financial
+// snapshots.
+func TestFinancialSnapshots(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	// Take snapshots for 3 consecutive periods starting at a fixed time, so
+	// the snapshots don't collide with the ones taken by the background
+	// thread.
+	start := time.Unix(0, 0).Add(1000 * financialSnapshotInterval)
+	for i := 0; i < 3; i++ {
+		h.mu.Lock()
+		h.financialMetrics.StorageRevenue = types.NewCurrency64(uint64(i))
+		h.mu.Unlock()
+		now := start.Add(time.Duration(i) * financialSnapshotInterval)
+		err = h.managedSnapshotFinancials(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Taking another snapshot within the last period should overwrite the
+	// previous one.
+	h.mu.Lock()
+	h.financialMetrics.StorageRevenue = types.NewCurrency64(3)
+	h.mu.Unlock()
+	last := start.Add(2*financialSnapshotInterval + financialSnapshotInterval/2)
+	err = h.managedSnapshotFinancials(last)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Query all snapshots within the range.
+	snapshots, err := h.FinancialSnapshots(start, last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 3 {
+		t.Fatalf("expected 3 snapshots but got %v", len(snapshots))
+	}
+	for i, s := range snapshots {
+		expected := uint64(i)
+		if i == 2 {
+			expected = 3
+		}
+		if !s.StorageRevenue.Equals64(expected) {
+			t.Fatalf("snapshot %v: expected revenue %v but got %v", i, expected, s.StorageRevenue)
+		}
+		if !s.Timestamp.Equal(start.Add(time.Duration(i) * financialSnapshotInterval)) {
+			t.Fatalf("snapshot %v: unexpected timestamp %v", i, s.Timestamp)
+		}
+	}
+
+	// Query a subset of the snapshots.
+	snapshots, err = h.FinancialSnapshots(start.Add(financialSnapshotInterval), start.Add(financialSnapshotInterval))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || !snapshots[0].StorageRevenue.Equals64(1) {
+		t.Fatal("unexpected snapshots", snapshots)
+	}
+
+	// Without a range, the snapshot of the current period should be included
+	// as well. The background thread might have added more snapshots since
+	// the host was created.
+	now := time.Now()
+	err = h.managedSnapshotFinancials(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshots, err = h.FinancialSnapshots(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) < 4 {
+		t.Fatalf("expected at least 4 snapshots but got %v", len(snapshots))
+	}
+	if !snapshots[0].Timestamp.Equal(start) {
+		t.Fatal("snapshots aren't sorted", snapshots[0].Timestamp)
+	}
+	if latest := snapshots[len(snapshots)-1]; !latest.Timestamp.Equal(now.UTC().Truncate(financialSnapshotInterval)) {
+		t.Fatal("latest snapshot should belong to the current period", latest.Timestamp)
+	}
+
+	// An invalid range should return an error.
+	_, err = h.FinancialSnapshots(last, start)
+	if err == nil {
+		t.Fatal("expected error for invalid range")
+	}
+}
@@ -137,6 +137,16 @@ type Host struct {
 	atomicSettingsCalls     uint64
 	atomicUnrecognizedCalls uint64
 
+	// Throttling metrics - the number of requests rejected because a renter
+	// exceeded one of the host's quotas. These values are not persistent.
+	atomicThrottledBandwidthCalls   uint64
+	atomicThrottledConcurrencyCalls uint64
+	atomicThrottledRequestCalls     uint64
+
+	// atomicMigrating is set to 1 once the host started exporting a
+	// migration to another node.
+	atomicMigrating uint64
+
 	// Error management. There are a few different types of errors returned by
 	// the host. These errors intentionally not persistent, so that the logging
 	// limits of each error type will be reset each time the host is reset.
@@ -194,6 +204,14 @@ type Host struct {
 	// of such conditions are congestion, load, liquidity, etc.
 	staticPriceTables *hostPrices
 
+	// The dynamic pricing engine which adjusts the host's storage and
+	// bandwidth prices based on its utilization.
+	staticPricingEngine *pricingEngine
+
+	// The resources used by renters, used to enforce the per-account and
+	// per-contract quotas.
+	staticRenterQuotas *renterQuotas
+
 	// Fields related to RHP3 bandwidhth.
 	atomicStreamUpload   uint64
 	atomicStreamDownload uint64
@@ -346,6 +364,9 @@ func (h *Host) managedUpdatePriceTable() {
 		DropSectorsUnitCost: types.NewCurrency64(1),
 		SwapSectorCost:      types.NewCurrency64(1),
 
+		// UpdateSector related costs.
+		UpdateSectorBaseCost: types.NewCurrency64(1),
+
 		// Read related costs.
 		ReadBaseCost:   hes.SectorAccessPrice, // roughly equal to 64 kib download
 		ReadLengthCost: types.NewCurrency64(1),
@@ -455,6 +476,8 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 				heap: make([]*hostRPCPriceTable, 0),
 			},
 		},
+		staticPricingEngine:         new(pricingEngine),
+		staticRenterQuotas:          newRenterQuotas(),
 		staticRegistrySubscriptions: newRegistrySubscriptions(),
 		persistDir:                  persistDir,
 	}
@@ -523,6 +546,10 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 	if err != nil {
 		return nil, err
 	}
+	err = h.loadRegistryKeyLimits()
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to load registry key limits")
+	}
 
 	// Add the account manager subsystem
 	h.staticAccountManager, err = h.newAccountManager()
@@ -561,6 +588,12 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 	// Ensure the expired RPC tables get pruned as to not leak memory
 	go h.threadedPruneExpiredPriceTables()
 
+	// Periodically adjust the host's prices if dynamic pricing is enabled.
+	go h.threadedUpdatePricing()
+
+	// Periodically persist snapshots of the host's financial metrics.
+	go h.threadedSnapshotFinancials()
+
 	return h, nil
 }
 
@@ -692,6 +725,10 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		}
 	}
 
+	if err := checkDynamicPricingSettings(settings); err != nil {
+		return errors.AddContext(err, "internal settings not updated")
+	}
+
 	// Check if the net address for the host has changed. If it has, and it's
 	// not equal to the auto address, then the host is going to need to make
 	// another blockchain announcement.
@@ -89,6 +89,13 @@ func (tb *testProgramBuilder) AddReadOffsetInstruction(length, offset uint64, me
 	tb.staticValues.AddReadOffsetInstruction(length)
 }
 
+// AddReadRangeInstruction adds a readrange instruction to the builder,
+// keeping track of running values.
+func (tb *testProgramBuilder) AddReadRangeInstruction(length, offset uint64, merkleProof bool) {
+	tb.staticPB.AddReadRangeInstruction(length, offset, merkleProof)
+	tb.staticValues.AddReadRangeInstruction(length, offset)
+}
+
 // AddReadSectorInstruction adds a readsector instruction to the builder,
 // keeping track of running values.
 func (tb *testProgramBuilder) AddReadSectorInstruction(length, offset uint64, merkleRoot crypto.Hash, merkleProof bool) {
@@ -103,6 +110,16 @@ func (tb *testProgramBuilder) AddRevisionInstruction() {
 	tb.staticValues.AddRevisionInstruction()
 }
 
+// AddStoreSectorInstruction adds a StoreSector instruction to the builder,
+// keeping track of running values.
+func (tb *testProgramBuilder) AddStoreSectorInstruction(data []byte, duration types.BlockHeight) {
+	err := tb.staticPB.AddStoreSectorInstruction(data, duration)
+	if err != nil {
+		panic(err)
+	}
+	tb.staticValues.AddStoreSectorInstruction(duration)
+}
+
 // AddSwapSectorInstruction adds a SwapSector instruction to the builder,
 // keeping track of running values.
 func (tb *testProgramBuilder) AddSwapSectorInstruction(sector1Idx, sector2Idx uint64, merkleProof bool) {
@@ -110,6 +127,16 @@ func (tb *testProgramBuilder) AddSwapSectorInstruction(sector1Idx, sector2Idx ui
 	tb.staticValues.AddSwapSectorInstruction()
 }
 
+// AddUpdateSectorInstruction adds an UpdateSector instruction to the builder,
+// keeping track of running values.
+func (tb *testProgramBuilder) AddUpdateSectorInstruction(offset uint64, data []byte, merkleProof bool) {
+	err := tb.staticPB.AddUpdateSectorInstruction(offset, data, merkleProof)
+	if err != nil {
+		panic(err)
+	}
+	tb.staticValues.AddUpdateSectorInstruction(uint64(len(data)))
+}
+
 // AddUpdateRegistryInstruction adds an UpdateRegistry instruction to the
 // builder, keeping track of running values.
 func (tb *testProgramBuilder) AddUpdateRegistryInstruction(spk types.SiaPublicKey, rv modules.SignedRegistryValue) {
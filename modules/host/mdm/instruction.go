@@ -30,6 +30,17 @@ type instruction interface {
 	Time() (uint64, error)
 }
 
+// irrevocableInstruction is implemented by instructions whose changes take
+// effect right away instead of when the program is committed. Once such an
+// instruction succeeded, its failure refund is no longer refunded if the
+// program fails later on.
+type irrevocableInstruction interface {
+	instruction
+	// Irrevocable returns whether the changes of the instruction can't be
+	// undone after it was executed successfully.
+	Irrevocable() bool
+}
+
 // Output is the type of the outputs returned by a program run on the MDM.
 type Output struct {
 	output
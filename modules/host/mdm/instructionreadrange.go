@@ -0,0 +1,162 @@
+package mdm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// instructionReadRange is an instruction which reads a range of data from the
+// file contract which can span multiple consecutive sectors.
+type instructionReadRange struct {
+	commonInstruction
+
+	lengthOffset uint64
+	offsetOffset uint64
+}
+
+// staticDecodeReadRangeInstruction creates a new 'ReadRange' instruction from
+// the provided generic instruction.
+func (p *program) staticDecodeReadRangeInstruction(instruction modules.Instruction) (instruction, error) {
+	// Check specifier.
+	if instruction.Specifier != modules.SpecifierReadRange {
+		return nil, fmt.Errorf("expected specifier %v but got %v",
+			modules.SpecifierReadRange, instruction.Specifier)
+	}
+	// Check args.
+	if len(instruction.Args) != modules.RPCIReadRangeLen {
+		return nil, fmt.Errorf("expected instruction to have len %v but was %v",
+			modules.RPCIReadRangeLen, len(instruction.Args))
+	}
+	// Read args.
+	offsetOffset := binary.LittleEndian.Uint64(instruction.Args[0:8])
+	lengthOffset := binary.LittleEndian.Uint64(instruction.Args[8:16])
+	return &instructionReadRange{
+		commonInstruction: commonInstruction{
+			staticData:        p.staticData,
+			staticMerkleProof: instruction.Args[16] == 1,
+			staticState:       p.staticProgramState,
+		},
+		lengthOffset: lengthOffset,
+		offsetOffset: offsetOffset,
+	}, nil
+}
+
+// Batch declares whether or not this instruction can be batched together with
+// the previous instruction.
+func (i instructionReadRange) Batch() bool {
+	return false
+}
+
+// Execute executes the 'ReadRange' instruction.
+func (i *instructionReadRange) Execute(previousOutput output) (output, types.Currency) {
+	// Fetch the operands.
+	length, err := i.staticData.Uint64(i.lengthOffset)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+	offset, err := i.staticData.Uint64(i.offsetOffset)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+
+	// Validate the request.
+	merkleRoots := i.staticState.sectors.merkleRoots
+	contractSize := uint64(len(merkleRoots)) * modules.SectorSize
+	numSectors := modules.MDMReadRangeSectors(offset, length)
+	switch {
+	case length == 0:
+		err = errors.New("length cannot be zero")
+	case offset+length < offset || offset+length > contractSize:
+		err = fmt.Errorf("request is out of bounds %v + %v > %v", offset, length, contractSize)
+	case numSectors > modules.MDMMaxReadRangeSectors:
+		err = fmt.Errorf("request spans %v sectors but at most %v are allowed", numSectors, modules.MDMMaxReadRangeSectors)
+	case i.staticMerkleProof && (offset%crypto.SegmentSize != 0 || length%crypto.SegmentSize != 0):
+		err = fmt.Errorf("offset (%v) and length (%v) must be multiples of SegmentSize (%v) when requesting a Merkle proof", offset, length, crypto.SegmentSize)
+	}
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+
+	// Read the sectors. Sectors which are only partially covered by the range
+	// are needed in full to build the proof, the roots of all other sectors
+	// are used as they are.
+	firstSector := offset / modules.SectorSize
+	readData := make([]byte, 0, length)
+	var partialSectors []byte
+	proofRoots := append([]crypto.Hash{}, merkleRoots[:firstSector]...)
+	for secIdx := firstSector; secIdx < firstSector+numSectors; secIdx++ {
+		sectorData, err := i.staticState.sectors.readSector(i.staticState.host, merkleRoots[secIdx])
+		if err != nil {
+			return errOutput(err), types.ZeroCurrency
+		}
+		sectorStart := secIdx * modules.SectorSize
+		start, end := uint64(0), modules.SectorSize
+		if offset > sectorStart {
+			start = offset - sectorStart
+		}
+		if offset+length < sectorStart+modules.SectorSize {
+			end = offset + length - sectorStart
+		}
+		readData = append(readData, sectorData[start:end]...)
+		if start == 0 && end == modules.SectorSize {
+			proofRoots = append(proofRoots, merkleRoots[secIdx])
+		} else {
+			partialSectors = append(partialSectors, sectorData...)
+		}
+	}
+	proofRoots = append(proofRoots, merkleRoots[firstSector+numSectors:]...)
+
+	// Construct the Merkle proof, if requested.
+	var proof []crypto.Hash
+	if i.staticMerkleProof {
+		proofStart := int(offset / crypto.SegmentSize)
+		proofEnd := int((offset + length) / crypto.SegmentSize)
+		proof = crypto.MerkleMixedRangeProof(proofRoots, partialSectors, int(modules.SectorSize), proofStart, proofEnd)
+	}
+	return output{
+		NewSize:       previousOutput.NewSize,       // size stays the same
+		NewMerkleRoot: previousOutput.NewMerkleRoot, // root stays the same
+		Output:        readData,
+		Proof:         proof,
+	}, types.ZeroCurrency
+}
+
+// Collateral is zero for the ReadRange instruction.
+func (i *instructionReadRange) Collateral() types.Currency {
+	return modules.MDMReadCollateral()
+}
+
+// Cost returns the cost of a ReadRange instruction.
+func (i *instructionReadRange) Cost() (executionCost, _ types.Currency, err error) {
+	var length uint64
+	length, err = i.staticData.Uint64(i.lengthOffset)
+	if err != nil {
+		return
+	}
+	executionCost = modules.MDMReadCost(i.staticState.priceTable, length)
+	return
+}
+
+// Memory returns the memory allocated by the 'ReadRange' instruction beyond
+// the lifetime of the instruction.
+func (i *instructionReadRange) Memory() uint64 {
+	return modules.MDMReadMemory()
+}
+
+// Time returns the execution time of a 'ReadRange' instruction.
+func (i *instructionReadRange) Time() (uint64, error) {
+	length, err := i.staticData.Uint64(i.lengthOffset)
+	if err != nil {
+		return 0, err
+	}
+	offset, err := i.staticData.Uint64(i.offsetOffset)
+	if err != nil {
+		return 0, err
+	}
+	return modules.MDMReadRangeTime(offset, length), nil
+}
@@ -0,0 +1,124 @@
+package mdm
+
+import (
+	"bytes"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestInstructionReadRange tests executing a program with a single
+// ReadRangeInstruction.
+func TestInstructionReadRange(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	// Prepare a priceTable.
+	pt := newTestPriceTable()
+	duration := types.BlockHeight(fastrand.Uint64n(5))
+	// Prepare storage obligation.
+	so := host.newTestStorageObligation(true)
+	so.AddRandomSectors(4)
+	var contractData []byte
+	for _, root := range so.sectorRoots {
+		sectorData, err := host.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contractData = append(contractData, sectorData...)
+	}
+	ics := so.ContractSize()
+	imr := so.MerkleRoot()
+
+	// Read the two middle sectors. Since they are read in full, the proof is a
+	// regular sector range proof.
+	tb := newTestProgramBuilder(pt, duration)
+	tb.AddReadRangeInstruction(2*modules.SectorSize, modules.SectorSize, true)
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, duration, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedProof := crypto.MerkleSectorRangeProof(so.sectorRoots, 1, 3)
+	err = outputs[0].assert(ics, imr, expectedProof, contractData[modules.SectorSize:3*modules.SectorSize], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read from the middle of the first sector to the middle of the last one.
+	offset := modules.SectorSize / 2
+	length := 3 * modules.SectorSize
+	tb = newTestProgramBuilder(pt, duration)
+	tb.AddReadRangeInstruction(length, offset, true)
+	outputs, err = mdm.ExecuteProgramWithBuilder(tb, so, duration, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proofStart := int(offset / crypto.SegmentSize)
+	proofEnd := int((offset + length) / crypto.SegmentSize)
+	partialSectors := append(append([]byte{}, contractData[:modules.SectorSize]...), contractData[3*modules.SectorSize:]...)
+	expectedProof = crypto.MerkleMixedRangeProof(so.sectorRoots[1:3], partialSectors, int(modules.SectorSize), proofStart, proofEnd)
+	err = outputs[0].assert(ics, imr, expectedProof, contractData[offset:offset+length], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := crypto.VerifyMixedRangeProof(outputs[0].Output, outputs[0].Proof, outputs[0].NewMerkleRoot, proofStart, proofEnd)
+	if !ok {
+		t.Fatal("failed to verify mixed range proof")
+	}
+
+	// Read a few segments across a sector boundary.
+	offset = modules.SectorSize - 2*crypto.SegmentSize
+	length = 4 * crypto.SegmentSize
+	tb = newTestProgramBuilder(pt, duration)
+	tb.AddReadRangeInstruction(length, offset, true)
+	outputs, err = mdm.ExecuteProgramWithBuilder(tb, so, duration, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(outputs[0].Output, contractData[offset:offset+length]) {
+		t.Fatal("wrong output")
+	}
+	proofStart = int(offset / crypto.SegmentSize)
+	proofEnd = int((offset + length) / crypto.SegmentSize)
+	ok = crypto.VerifyMixedRangeProof(outputs[0].Output, outputs[0].Proof, outputs[0].NewMerkleRoot, proofStart, proofEnd)
+	if !ok {
+		t.Fatal("failed to verify mixed range proof")
+	}
+
+	// Unaligned reads are only possible without a proof.
+	offset, length = 1, modules.SectorSize
+	tb = newTestProgramBuilder(pt, duration)
+	tb.AddReadRangeInstruction(length, offset, false)
+	outputs, err = mdm.ExecuteProgramWithBuilder(tb, so, duration, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = outputs[0].assert(ics, imr, nil, contractData[offset:offset+length], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tb = newTestProgramBuilder(pt, duration)
+	tb.AddReadRangeInstruction(length, offset, true)
+	outputs, err = mdm.ExecuteProgramWithBuilder(tb, so, duration, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outputs[0].Error == nil {
+		t.Fatal("expected unaligned read with proof to fail")
+	}
+
+	// Reading beyond the end of the contract should fail.
+	tb = newTestProgramBuilder(pt, duration)
+	tb.AddReadRangeInstruction(2*modules.SectorSize, 3*modules.SectorSize, true)
+	outputs, err = mdm.ExecuteProgramWithBuilder(tb, so, duration, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outputs[0].Error == nil {
+		t.Fatal("expected out of bounds read to fail")
+	}
+}
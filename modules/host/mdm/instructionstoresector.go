@@ -0,0 +1,128 @@
+package mdm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// instructionStoreSector is an instruction that stores a sector on the host
+// for a fixed number of blocks without adding it to a file contract.
+type instructionStoreSector struct {
+	commonInstruction
+
+	durationOffset uint64
+	dataOffset     uint64
+}
+
+// staticDecodeStoreSectorInstruction creates a new 'StoreSector' instruction
+// from the provided generic instruction.
+func (p *program) staticDecodeStoreSectorInstruction(instruction modules.Instruction) (instruction, error) {
+	// Check specifier.
+	if instruction.Specifier != modules.SpecifierStoreSector {
+		return nil, fmt.Errorf("expected specifier %v but got %v",
+			modules.SpecifierStoreSector, instruction.Specifier)
+	}
+	// Check args.
+	if len(instruction.Args) != modules.RPCIStoreSectorLen {
+		return nil, fmt.Errorf("expected instruction to have len %v but was %v",
+			modules.RPCIStoreSectorLen, len(instruction.Args))
+	}
+	// Read args.
+	durationOffset := binary.LittleEndian.Uint64(instruction.Args[:8])
+	dataOffset := binary.LittleEndian.Uint64(instruction.Args[8:16])
+	return &instructionStoreSector{
+		commonInstruction: commonInstruction{
+			staticData:  p.staticData,
+			staticState: p.staticProgramState,
+		},
+		durationOffset: durationOffset,
+		dataOffset:     dataOffset,
+	}, nil
+}
+
+// Batch declares whether or not this instruction can be batched together with
+// the previous instruction.
+func (i instructionStoreSector) Batch() bool {
+	return false
+}
+
+// Execute executes the 'StoreSector' instruction.
+func (i *instructionStoreSector) Execute(prevOutput output) (output, types.Currency) {
+	// Fetch the args.
+	duration, err := i.staticDuration()
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+	sectorData, err := i.staticData.Bytes(i.dataOffset, modules.SectorSize)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+
+	// Make sure the duration is within the bounds set by the host.
+	if duration == 0 {
+		return errOutput(fmt.Errorf("duration needs to be greater than 0")), types.ZeroCurrency
+	}
+	if maxDuration := i.staticState.priceTable.MaxDuration; duration > maxDuration {
+		return errOutput(fmt.Errorf("duration %v exceeds the host's max duration %v", duration, maxDuration)), types.ZeroCurrency
+	}
+
+	// Store the sector.
+	root := crypto.MerkleRoot(sectorData)
+	expiry := i.staticState.host.BlockHeight() + duration
+	err = i.staticState.host.StoreSector(root, sectorData, expiry)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+
+	return output{
+		NewSize:       prevOutput.NewSize,
+		NewMerkleRoot: prevOutput.NewMerkleRoot,
+		Output:        root[:],
+	}, types.ZeroCurrency
+}
+
+// Collateral returns the collateral the host has to put up for this
+// instruction.
+func (i *instructionStoreSector) Collateral() types.Currency {
+	return modules.MDMStoreSectorCollateral()
+}
+
+// Cost returns the Cost of this `StoreSector` instruction.
+func (i *instructionStoreSector) Cost() (executionCost, storeCost types.Currency, err error) {
+	duration, err := i.staticDuration()
+	if err != nil {
+		return
+	}
+	executionCost, storeCost = modules.MDMStoreSectorCost(i.staticState.priceTable, duration)
+	return
+}
+
+// Irrevocable returns true since the sector is stored right away and a later
+// failure of the program can't undo that.
+func (i *instructionStoreSector) Irrevocable() bool {
+	return true
+}
+
+// Memory returns the memory allocated by the 'StoreSector' instruction beyond
+// the lifetime of the instruction.
+func (i *instructionStoreSector) Memory() uint64 {
+	return modules.MDMStoreSectorMemory()
+}
+
+// Time returns the execution time of a 'StoreSector' instruction.
+func (i *instructionStoreSector) Time() (uint64, error) {
+	return modules.MDMTimeStoreSector, nil
+}
+
+// staticDuration fetches the duration from the program data.
+func (i *instructionStoreSector) staticDuration() (types.BlockHeight, error) {
+	duration, err := i.staticData.Uint64(i.durationOffset)
+	if err != nil {
+		return 0, fmt.Errorf("bad input: durationOffset: %v", err)
+	}
+	return types.BlockHeight(duration), nil
+}
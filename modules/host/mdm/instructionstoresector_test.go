@@ -0,0 +1,159 @@
+package mdm
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestInstructionStoreSector tests executing a program with a single
+// StoreSector instruction.
+func TestInstructionStoreSector(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	// Prepare a priceTable.
+	pt := newTestPriceTable()
+	pt.MaxDuration = 100
+
+	// Store a random sector.
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root := crypto.MerkleRoot(data)
+	duration := types.BlockHeight(fastrand.Uint64n(uint64(pt.MaxDuration))) + 1
+	so := host.newTestStorageObligation(true)
+	tb := newTestProgramBuilder(pt, 0)
+	tb.AddStoreSectorInstruction(data, duration)
+
+	// Execute it.
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assert the output. The contract shouldn't have changed.
+	err = outputs[0].assert(0, crypto.Hash{}, []crypto.Hash{}, root[:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(so.sectorRoots) != 0 {
+		t.Fatal("contract shouldn't contain any sectors")
+	}
+
+	// The host should store the sector with the right expiry.
+	host.mu.Lock()
+	stored, exists := host.sectors[root]
+	expiry := host.expiries[root]
+	height := host.blockHeight
+	host.mu.Unlock()
+	if !exists || !bytes.Equal(stored, data) {
+		t.Fatal("sector wasn't stored")
+	}
+	if expiry != height+duration {
+		t.Fatalf("wrong expiry %v != %v", expiry, height+duration)
+	}
+
+	// A duration exceeding the host's max duration should fail.
+	tb = newTestProgramBuilder(pt, 0)
+	tb.AddStoreSectorInstruction(data, pt.MaxDuration+1)
+	outputs, err = mdm.executeFailingProgram(tb, so)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := outputs[0].Error; err == nil || !strings.Contains(err.Error(), "exceeds the host's max duration") {
+		t.Fatal("expected execution to fail", err)
+	}
+
+	// The builder should refuse partial sectors and a zero duration.
+	pb := modules.NewProgramBuilder(pt, 0)
+	if err := pb.AddStoreSectorInstruction(data[:1], duration); err == nil {
+		t.Fatal("expected partial sector to fail")
+	}
+	if err := pb.AddStoreSectorInstruction(data, 0); err == nil {
+		t.Fatal("expected zero duration to fail")
+	}
+}
+
+// TestInstructionStoreSectorFollowedByFailure tests that the storage cost of a
+// StoreSector instruction isn't refunded when a later instruction fails since
+// the sector was already stored.
+func TestInstructionStoreSectorFollowedByFailure(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	// Prepare a priceTable.
+	pt := newTestPriceTable()
+	pt.MaxDuration = 100
+
+	// Store a sector and read from the empty contract afterwards, which fails.
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root := crypto.MerkleRoot(data)
+	so := host.newTestStorageObligation(true)
+	tb := newTestProgramBuilder(pt, 0)
+	tb.AddStoreSectorInstruction(data, pt.MaxDuration)
+	tb.AddReadOffsetInstruction(modules.SectorSize, 0, false)
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 2 {
+		t.Fatalf("expected 2 outputs but got %v", len(outputs))
+	}
+	if outputs[0].Error != nil || outputs[1].Error == nil {
+		t.Fatal("expected only the second instruction to fail", outputs[0].Error, outputs[1].Error)
+	}
+
+	// The sector was stored but none of its cost is refunded.
+	host.mu.Lock()
+	_, exists := host.sectors[root]
+	host.mu.Unlock()
+	if !exists {
+		t.Fatal("sector wasn't stored")
+	}
+	_, storeCost := modules.MDMStoreSectorCost(pt, pt.MaxDuration)
+	if storeCost.IsZero() {
+		t.Fatal("test requires a non-zero storage cost")
+	}
+	for i, output := range outputs {
+		if !output.FailureRefund.IsZero() {
+			t.Fatalf("output %v: expected no failure refund but got %v", i, output.FailureRefund)
+		}
+	}
+
+	// A StoreSector instruction which fails itself still refunds the storage
+	// cost.
+	tb = newTestProgramBuilder(pt, 0)
+	tb.AddStoreSectorInstruction(data, pt.MaxDuration+1)
+	outputs, err = mdm.executeFailingProgram(tb, so)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, storeCost = modules.MDMStoreSectorCost(pt, pt.MaxDuration+1)
+	if outputs[0].Error == nil || !outputs[0].FailureRefund.Equals(storeCost) {
+		t.Fatal("expected the failed instruction to refund the storage cost", outputs[0].Error, outputs[0].FailureRefund)
+	}
+}
+
+// executeFailingProgram executes the program of tb without asserting its costs
+// since the test values assume that a StoreSector instruction succeeds.
+func (mdm *MDM) executeFailingProgram(tb *testProgramBuilder, so *TestStorageObligation) ([]Output, error) {
+	program, programData := tb.Program()
+	values := tb.Cost()
+	_, _, collateral, _ := values.Cost()
+	_, outputChan, err := mdm.ExecuteProgram(context.Background(), tb.staticPT, program, values.Budget(false), collateral, so, 0, uint64(len(programData)), bytes.NewReader(programData))
+	if err != nil {
+		return nil, err
+	}
+	var outputs []Output
+	for output := range outputChan {
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
@@ -0,0 +1,165 @@
+package mdm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// instructionUpdateSector is an instruction that overwrites a range of data
+// within a sector of a file contract.
+type instructionUpdateSector struct {
+	commonInstruction
+
+	offsetOffset uint64
+	lengthOffset uint64
+	dataOffset   uint64
+}
+
+// staticDecodeUpdateSectorInstruction creates a new 'UpdateSector' instruction
+// from the provided generic instruction.
+func (p *program) staticDecodeUpdateSectorInstruction(instruction modules.Instruction) (instruction, error) {
+	// Check specifier.
+	if instruction.Specifier != modules.SpecifierUpdateSector {
+		return nil, fmt.Errorf("expected specifier %v but got %v",
+			modules.SpecifierUpdateSector, instruction.Specifier)
+	}
+	// Check args.
+	if len(instruction.Args) != modules.RPCIUpdateSectorLen {
+		return nil, fmt.Errorf("expected instruction to have len %v but was %v",
+			modules.RPCIUpdateSectorLen, len(instruction.Args))
+	}
+	// Read args.
+	offsetOffset := binary.LittleEndian.Uint64(instruction.Args[:8])
+	lengthOffset := binary.LittleEndian.Uint64(instruction.Args[8:16])
+	dataOffset := binary.LittleEndian.Uint64(instruction.Args[16:24])
+	return &instructionUpdateSector{
+		commonInstruction: commonInstruction{
+			staticData:        p.staticData,
+			staticMerkleProof: instruction.Args[24] == 1,
+			staticState:       p.staticProgramState,
+		},
+		offsetOffset: offsetOffset,
+		lengthOffset: lengthOffset,
+		dataOffset:   dataOffset,
+	}, nil
+}
+
+// Batch declares whether or not this instruction can be batched together with
+// the previous instruction.
+func (i instructionUpdateSector) Batch() bool {
+	return false
+}
+
+// Execute executes the 'UpdateSector' instruction.
+func (i *instructionUpdateSector) Execute(prevOutput output) (output, types.Currency) {
+	// Fetch the operands.
+	offset, err := i.staticData.Uint64(i.offsetOffset)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+	length, err := i.staticData.Uint64(i.lengthOffset)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+	data, err := i.staticData.Bytes(i.dataOffset, length)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+
+	// Validate the range. It needs to be segment aligned and can't cross a
+	// sector boundary.
+	if length == 0 {
+		return errOutput(fmt.Errorf("update length must be greater than 0")), types.ZeroCurrency
+	}
+	if offset%crypto.SegmentSize != 0 || length%crypto.SegmentSize != 0 {
+		return errOutput(fmt.Errorf("offset (%v) and length (%v) must be multiples of SegmentSize (%v)", offset, length, crypto.SegmentSize)), types.ZeroCurrency
+	}
+	ps := i.staticState
+	relOffset, secIdx, err := ps.sectors.translateOffset(offset)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+	if relOffset+length > modules.SectorSize {
+		return errOutput(fmt.Errorf("update range [%v:%v] exceeds sector boundary", relOffset, relOffset+length)), types.ZeroCurrency
+	}
+
+	// Read the old sector and create an updated copy of it. The copy is
+	// necessary since the old data might be owned by the program cache.
+	oldRoot := ps.sectors.merkleRoots[secIdx]
+	oldSector, err := ps.sectors.readSector(ps.host, oldRoot)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+	newSector := make([]byte, len(oldSector))
+	copy(newSector, oldSector)
+	copy(newSector[relOffset:], data)
+
+	newMerkleRoot, err := ps.sectors.updateSector(secIdx, newSector)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+	resp := modules.MDMInstructionUpdateSectorResponse{
+		OldSectorRoot: oldRoot,
+		NewSectorRoot: ps.sectors.merkleRoots[secIdx],
+	}
+
+	// If no proof was requested we are done.
+	if !i.staticMerkleProof {
+		return output{
+			NewSize:       prevOutput.NewSize,
+			NewMerkleRoot: newMerkleRoot,
+			Output:        encoding.Marshal(resp),
+		}, types.ZeroCurrency
+	}
+
+	// Create the proof for the updated segments within the sector. The proof
+	// is the same for both the old and the new sector since only the leaves
+	// within the range change.
+	segStart := relOffset / crypto.SegmentSize
+	segEnd := (relOffset + length) / crypto.SegmentSize
+	resp.OldData = oldSector[relOffset : relOffset+length]
+	resp.SectorProof = crypto.MerkleRangeProof(newSector, int(segStart), int(segEnd))
+
+	// Create the proof for the updated sector within the contract. Same as for
+	// the sector proof, it is valid for both the old and the new root.
+	ranges := []crypto.ProofRange{{
+		Start: secIdx,
+		End:   secIdx + 1,
+	}}
+	roots := ps.sectors.merkleRoots
+	proof := crypto.MerkleDiffProof(ranges, uint64(len(roots)), nil, roots)
+
+	return output{
+		NewSize:       prevOutput.NewSize,
+		NewMerkleRoot: newMerkleRoot,
+		Output:        encoding.Marshal(resp),
+		Proof:         proof,
+	}, types.ZeroCurrency
+}
+
+// Collateral returns the collateral cost of updating a sector.
+func (i *instructionUpdateSector) Collateral() types.Currency {
+	return modules.MDMUpdateSectorCollateral()
+}
+
+// Cost returns the Cost of this `UpdateSector` instruction.
+func (i *instructionUpdateSector) Cost() (executionCost, storage types.Currency, err error) {
+	executionCost = modules.MDMUpdateSectorCost(i.staticState.priceTable)
+	return
+}
+
+// Memory returns the memory allocated by the 'UpdateSector' instruction beyond
+// the lifetime of the instruction.
+func (i *instructionUpdateSector) Memory() uint64 {
+	return modules.MDMUpdateSectorMemory()
+}
+
+// Time returns the execution time of an 'UpdateSector' instruction.
+func (i *instructionUpdateSector) Time() (uint64, error) {
+	return modules.MDMTimeUpdateSector, nil
+}
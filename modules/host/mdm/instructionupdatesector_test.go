@@ -0,0 +1,204 @@
+package mdm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestInstructionUpdateSector tests executing a program with a single
+// UpdateSector instruction.
+func TestInstructionUpdateSector(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	// Helper to create a storage obligation with some random sectors. Every
+	// test gets its own obligation since updated sectors are only added to
+	// the obligation and not the host.
+	numSectors := 10
+	newSO := func() *TestStorageObligation {
+		so := host.newTestStorageObligation(true)
+		so.AddRandomSectors(numSectors)
+		return so
+	}
+
+	// Prepare a priceTable and duration.
+	pt := newTestPriceTable()
+	duration := types.BlockHeight(fastrand.Uint64n(5)) // random since it doesn't matter for updates
+
+	t.Run("Basic", func(t *testing.T) {
+		testInstructionUpdateSectorBasic(t, mdm, uint64(numSectors), pt, duration, newSO())
+	})
+	t.Run("NoProof", func(t *testing.T) {
+		testInstructionUpdateSectorNoProof(t, mdm, uint64(numSectors), pt, duration, newSO())
+	})
+	t.Run("OutOfBounds", func(t *testing.T) {
+		testInstructionUpdateSectorOutOfBounds(t, mdm, uint64(numSectors), pt, duration, newSO())
+	})
+}
+
+// testInstructionUpdateSectorBasic tests updating a random range within a
+// random sector of a filecontract and verifying the returned proofs.
+func testInstructionUpdateSectorBasic(t *testing.T, mdm *MDM, numSectors uint64, pt *modules.RPCPriceTable, duration types.BlockHeight, so *TestStorageObligation) {
+	// Choose a random sector and a random segment aligned range within it.
+	secIdx := fastrand.Uint64n(numSectors)
+	numSegments := modules.SectorSize / crypto.SegmentSize
+	segStart := fastrand.Uint64n(numSegments)
+	segEnd := segStart + 1 + fastrand.Uint64n(numSegments-segStart)
+	relOffset := segStart * crypto.SegmentSize
+	offset := secIdx*modules.SectorSize + relOffset
+	data := fastrand.Bytes(int((segEnd - segStart) * crypto.SegmentSize))
+
+	ics := so.ContractSize()
+	imr := so.MerkleRoot()
+	oldRoots := append([]crypto.Hash{}, so.sectorRoots...)
+	oldSector, err := so.host.ReadSector(oldRoots[secIdx])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Use a builder to build the program.
+	tb := newTestProgramBuilder(pt, duration)
+	tb.AddUpdateSectorInstruction(offset, data, true)
+
+	// Execute it.
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, duration, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := outputs[0]
+
+	// Compute the expected new sector and roots.
+	newSector := append([]byte{}, oldSector...)
+	copy(newSector[relOffset:], data)
+	newRoots := append([]crypto.Hash{}, oldRoots...)
+	newRoots[secIdx] = crypto.MerkleRoot(newSector)
+	nmr := cachedMerkleRoot(newRoots)
+	if nmr == imr {
+		t.Fatal("nmr shouldn't match imr")
+	}
+
+	// Compute the expected output.
+	ranges := []crypto.ProofRange{{Start: secIdx, End: secIdx + 1}}
+	expectedProof := crypto.MerkleDiffProof(ranges, numSectors, nil, oldRoots)
+	expectedOutput := encoding.Marshal(modules.MDMInstructionUpdateSectorResponse{
+		OldSectorRoot: oldRoots[secIdx],
+		NewSectorRoot: newRoots[secIdx],
+		OldData:       oldSector[relOffset : relOffset+uint64(len(data))],
+		SectorProof:   crypto.MerkleRangeProof(oldSector, int(segStart), int(segEnd)),
+	})
+
+	// Assert the output.
+	err = output.assert(ics, nmr, expectedProof, expectedOutput, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify the output the way a renter would.
+	var resp modules.MDMInstructionUpdateSectorResponse
+	err = encoding.Unmarshal(output.Output, &resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = resp.Verify(relOffset, data, output.Proof, secIdx, numSectors, imr, nmr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Tampering with the data should cause the verification to fail.
+	badData := append([]byte{}, data...)
+	badData[0]++
+	err = resp.Verify(relOffset, badData, output.Proof, secIdx, numSectors, imr, nmr)
+	if err == nil {
+		t.Fatal("verification should fail for modified data")
+	}
+
+	// Make sure the sector root was updated and the new sector was added.
+	if so.sectorRoots[secIdx] != newRoots[secIdx] {
+		t.Fatal("sector root wasn't updated")
+	}
+	gained, exists := so.sectorMap[newRoots[secIdx]]
+	if !exists || !bytes.Equal(gained, newSector) {
+		t.Fatal("new sector wasn't added")
+	}
+	if _, exists := so.sectorMap[oldRoots[secIdx]]; exists {
+		t.Fatal("old sector wasn't removed")
+	}
+}
+
+// testInstructionUpdateSectorNoProof tests updating a sector without requesting
+// a proof.
+func testInstructionUpdateSectorNoProof(t *testing.T, mdm *MDM, numSectors uint64, pt *modules.RPCPriceTable, duration types.BlockHeight, so *TestStorageObligation) {
+	// Update the first segment of a random sector.
+	secIdx := fastrand.Uint64n(numSectors)
+	offset := secIdx * modules.SectorSize
+	data := fastrand.Bytes(crypto.SegmentSize)
+
+	ics := so.ContractSize()
+	oldRoots := append([]crypto.Hash{}, so.sectorRoots...)
+	oldSector, err := so.host.ReadSector(oldRoots[secIdx])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Use a builder to build the program.
+	tb := newTestProgramBuilder(pt, duration)
+	tb.AddUpdateSectorInstruction(offset, data, false)
+
+	// Execute it.
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, duration, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Compute the expected root.
+	newSector := append([]byte{}, oldSector...)
+	copy(newSector, data)
+	newRoots := append([]crypto.Hash{}, oldRoots...)
+	newRoots[secIdx] = crypto.MerkleRoot(newSector)
+	nmr := cachedMerkleRoot(newRoots)
+	expectedOutput := encoding.Marshal(modules.MDMInstructionUpdateSectorResponse{
+		OldSectorRoot: oldRoots[secIdx],
+		NewSectorRoot: newRoots[secIdx],
+	})
+
+	// Assert the output.
+	err = outputs[0].assert(ics, nmr, []crypto.Hash{}, expectedOutput, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testInstructionUpdateSectorOutOfBounds tests that invalid ranges cause the
+// execution to fail.
+func testInstructionUpdateSectorOutOfBounds(t *testing.T, mdm *MDM, numSectors uint64, pt *modules.RPCPriceTable, duration types.BlockHeight, so *TestStorageObligation) {
+	// Offset beyond the contract.
+	tb := newTestProgramBuilder(pt, duration)
+	tb.AddUpdateSectorInstruction(numSectors*modules.SectorSize, fastrand.Bytes(crypto.SegmentSize), true)
+	_, err := mdm.ExecuteProgramWithBuilder(tb, so, duration, true)
+	if err == nil || !strings.Contains(err.Error(), "translateOffset: secOff out of bounds") {
+		t.Fatal("expected execution to fail with out of bounds error", err)
+	}
+
+	// The builder should refuse unaligned and sector spanning updates.
+	pb := modules.NewProgramBuilder(pt, duration)
+	if err := pb.AddUpdateSectorInstruction(1, fastrand.Bytes(crypto.SegmentSize), true); err == nil {
+		t.Fatal("expected unaligned offset to fail")
+	}
+	if err := pb.AddUpdateSectorInstruction(0, fastrand.Bytes(crypto.SegmentSize+1), true); err == nil {
+		t.Fatal("expected unaligned length to fail")
+	}
+	if err := pb.AddUpdateSectorInstruction(modules.SectorSize-crypto.SegmentSize, fastrand.Bytes(2*crypto.SegmentSize), true); err == nil {
+		t.Fatal("expected sector spanning update to fail")
+	}
+	if err := pb.AddUpdateSectorInstruction(0, nil, true); err == nil {
+		t.Fatal("expected empty update to fail")
+	}
+}
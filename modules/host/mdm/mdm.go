@@ -37,6 +37,7 @@ type Host interface {
 	ReadSector(sectorRoot crypto.Hash) ([]byte, error)
 	RegistryUpdate(rv modules.SignedRegistryValue, pubKey types.SiaPublicKey, expiry types.BlockHeight) (modules.SignedRegistryValue, error)
 	RegistryGet(sid modules.RegistryEntryID) (types.SiaPublicKey, modules.SignedRegistryValue, bool)
+	StoreSector(sectorRoot crypto.Hash, sectorData []byte, expiry types.BlockHeight) error
 }
 
 // MDM (Merklized Data Machine) is a virtual machine that executes instructions
@@ -26,6 +26,7 @@ type (
 		blockHeight     types.BlockHeight
 		sectors         map[crypto.Hash][]byte
 		registry        map[modules.RegistryEntryID]TestRegistryValue
+		expiries        map[crypto.Hash]types.BlockHeight
 		mu              sync.Mutex
 	}
 	TestRegistryValue struct {
@@ -52,6 +53,7 @@ func newTestHost() *TestHost {
 func newCustomTestHost(generateSectors bool) *TestHost {
 	return &TestHost{
 		generateSectors: generateSectors,
+		expiries:        make(map[crypto.Hash]types.BlockHeight),
 		registry:        make(map[modules.RegistryEntryID]TestRegistryValue),
 		sectors:         make(map[crypto.Hash][]byte),
 	}
@@ -116,6 +118,16 @@ func (h *TestHost) RegistryUpdate(rv modules.SignedRegistryValue, pubKey types.S
 	return oldRV.SignedRegistryValue, nil
 }
 
+// StoreSector implements the Host interface by adding the sector to the host
+// and remembering its expiry.
+func (h *TestHost) StoreSector(sectorRoot crypto.Hash, sectorData []byte, expiry types.BlockHeight) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sectors[sectorRoot] = sectorData
+	h.expiries[sectorRoot] = expiry
+	return nil
+}
+
 // ReadSector implements the Host interface by returning a random sector for
 // each root. Calling ReadSector multiple times on the same root will result in
 // the same data.
@@ -138,6 +150,7 @@ func (so *TestStorageObligation) AddRandomSector() {
 	data := fastrand.Bytes(int(modules.SectorSize))
 	root := crypto.MerkleRoot(data)
 	so.host.sectors[root] = data
+	so.sectorMap[root] = data
 	so.sectorRoots = append(so.sectorRoots, root)
 }
 
@@ -241,6 +254,9 @@ func newTestPriceTable() *modules.RPCPriceTable {
 		WriteLengthCost:     types.NewCurrency64(1),
 		WriteStoreCost:      types.NewCurrency64(1),
 
+		// UpdateSector costs
+		UpdateSectorBaseCost: types.NewCurrency64(1),
+
 		// Bandwidth costs
 		DownloadBandwidthCost: types.NewCurrency64(1),
 		UploadBandwidthCost:   types.NewCurrency64(1),
@@ -97,10 +97,16 @@ func decodeInstruction(p *program, i modules.Instruction) (instruction, error) {
 		return p.staticDecodeReadSectorInstruction(i)
 	case modules.SpecifierReadOffset:
 		return p.staticDecodeReadOffsetInstruction(i)
+	case modules.SpecifierReadRange:
+		return p.staticDecodeReadRangeInstruction(i)
 	case modules.SpecifierRevision:
 		return p.staticDecodeRevisionInstruction(i)
+	case modules.SpecifierStoreSector:
+		return p.staticDecodeStoreSectorInstruction(i)
 	case modules.SpecifierSwapSector:
 		return p.staticDecodeSwapSectorInstruction(i)
+	case modules.SpecifierUpdateSector:
+		return p.staticDecodeUpdateSectorInstruction(i)
 	case modules.SpecifierUpdateRegistry:
 		return p.staticDecodeUpdateRegistryInstruction(i)
 	case modules.SpecifierReadRegistry:
@@ -274,6 +280,11 @@ func (p *program) executeInstructions(ctx context.Context, fcSize uint64, fcRoot
 		if !refund.IsZero() {
 			p.refundCost(refund)
 		}
+		// The failure refund of an irrevocable instruction is spent once the
+		// instruction succeeded.
+		if ii, ok := i.(irrevocableInstruction); ok && ii.Irrevocable() && output.Error == nil {
+			p.failureRefund = p.failureRefund.Sub(failureRefund)
+		}
 		p.outputChan <- Output{
 			output:               output,
 			Batch:                batch,
@@ -244,6 +244,57 @@ func TestDropSectors(t *testing.T) {
 	}
 }
 
+// TestUpdateSector tests updating sectors in the cache.
+func TestUpdateSector(t *testing.T) {
+	// Initialize the sectors.
+	sectorRoots := randomSectorRoots(initialContractSectors)
+	s := newSectors(append([]crypto.Hash{}, sectorRoots...))
+
+	// Update a sector.
+	data := fastrand.Bytes(int(modules.SectorSize))
+	newRoot := crypto.MerkleRoot(data)
+	root, err := s.updateSector(3, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedRoots := append([]crypto.Hash{}, sectorRoots...)
+	expectedRoots[3] = newRoot
+	if root != cachedMerkleRoot(expectedRoots) {
+		t.Fatalf("unexpected merkle root")
+	}
+	if _, removed := s.sectorsRemoved[sectorRoots[3]]; !removed || len(s.sectorsRemoved) != 1 {
+		t.Fatal("old sector should be marked as removed")
+	}
+	if _, gained := s.sectorsGained[newRoot]; !gained || len(s.sectorsGained) != 1 {
+		t.Fatal("new sector should be marked as gained")
+	}
+
+	// Update it again. The intermediate sector should vanish from the cache.
+	data2 := fastrand.Bytes(int(modules.SectorSize))
+	newRoot2 := crypto.MerkleRoot(data2)
+	_, err = s.updateSector(3, data2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, gained := s.sectorsGained[newRoot2]; !gained || len(s.sectorsGained) != 1 {
+		t.Fatal("only the latest sector should be marked as gained")
+	}
+	if len(s.sectorsRemoved) != 1 {
+		t.Fatal("only the original sector should be marked as removed")
+	}
+
+	// Updating an out-of-bounds sector should fail.
+	_, err = s.updateSector(initialContractSectors, data)
+	if err == nil {
+		t.Fatal("expected error when updating out-of-bounds sector")
+	}
+	// Updating with a partial sector should fail.
+	_, err = s.updateSector(0, data[:1])
+	if err == nil {
+		t.Fatal("expected error when updating with partial sector")
+	}
+}
+
 // TestHasSector tests checking if a sector exists in the cache or host.
 func TestHasSector(t *testing.T) {
 	// Initialize the sectors.
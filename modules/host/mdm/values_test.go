@@ -100,6 +100,19 @@ func (v *TestValues) AddReadOffsetInstruction(length uint64) {
 	v.addInstruction(collateral, cost, types.ZeroCurrency, types.ZeroCurrency, memory, time, newData, readonly, batch)
 }
 
+// AddReadRangeInstruction adds a readrange instruction to the builder,
+// keeping track of running values.
+func (v *TestValues) AddReadRangeInstruction(length, offset uint64) {
+	collateral := modules.MDMReadCollateral()
+	cost := modules.MDMReadCost(v.staticPT, length)
+	memory := modules.MDMReadMemory()
+	time := modules.MDMReadRangeTime(offset, length)
+	newData := 8 + 8
+	readonly := true
+	batch := false
+	v.addInstruction(collateral, cost, types.ZeroCurrency, types.ZeroCurrency, memory, time, newData, readonly, batch)
+}
+
 // AddReadSectorInstruction adds a readsector instruction to the builder,
 // keeping track of running values.
 func (v *TestValues) AddReadSectorInstruction(length uint64) {
@@ -125,6 +138,20 @@ func (v *TestValues) AddRevisionInstruction() {
 	v.addInstruction(collateral, cost, types.ZeroCurrency, types.ZeroCurrency, memory, time, 0, readonly, batch)
 }
 
+// AddStoreSectorInstruction adds a StoreSector instruction to the builder,
+// keeping track of running values.
+func (v *TestValues) AddStoreSectorInstruction(duration types.BlockHeight) {
+	collateral := modules.MDMStoreSectorCollateral()
+	cost, _ := modules.MDMStoreSectorCost(v.staticPT, duration)
+	memory := modules.MDMStoreSectorMemory()
+	time := uint64(modules.MDMTimeStoreSector)
+	newData := 8 + int(modules.SectorSize)
+	readonly := true
+	batch := false
+	// The storage cost isn't refundable once the sector was stored.
+	v.addInstruction(collateral, cost, types.ZeroCurrency, types.ZeroCurrency, memory, time, newData, readonly, batch)
+}
+
 // AddSwapSectorInstruction adds a revision instruction to the builder, keeping
 // track of running values.
 func (v *TestValues) AddSwapSectorInstruction() {
@@ -138,6 +165,19 @@ func (v *TestValues) AddSwapSectorInstruction() {
 	v.addInstruction(collateral, cost, types.ZeroCurrency, types.ZeroCurrency, memory, time, newData, readonly, batch)
 }
 
+// AddUpdateSectorInstruction adds an UpdateSector instruction to the builder,
+// keeping track of running values.
+func (v *TestValues) AddUpdateSectorInstruction(length uint64) {
+	collateral := modules.MDMUpdateSectorCollateral()
+	cost := modules.MDMUpdateSectorCost(v.staticPT)
+	memory := modules.MDMUpdateSectorMemory()
+	time := uint64(modules.MDMTimeUpdateSector)
+	newData := 8 + 8 + int(length)
+	readonly := false
+	batch := false
+	v.addInstruction(collateral, cost, types.ZeroCurrency, types.ZeroCurrency, memory, time, newData, readonly, batch)
+}
+
 // AddUpdateRegistryInstruction adds a revision instruction to the builder, keeping
 // track of running values.
 func (v *TestValues) AddUpdateRegistryInstruction(spk types.SiaPublicKey, rv modules.SignedRegistryValue) {
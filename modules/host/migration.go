@@ -0,0 +1,521 @@
+package host
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/host/registry"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// The following constants are the names of the entries of a migration
+// archive. The entries are written in this order, followed by one entry per
+// sector.
+const (
+	migrationManifestEntry = "manifest.json"
+	migrationPersistEntry  = "host.json"
+	migrationDatabaseEntry = "host.db"
+	migrationRegistryEntry = "registry.dat"
+	migrationAccountsEntry = "accounts.dat"
+	migrationSectorsPrefix = "sectors/"
+
+	// migrationDatabaseFile is the name of the file the database of an
+	// imported host is temporarily written to.
+	migrationDatabaseFile = "migration.db"
+
+	// migrationMaxEntrySize is the maximum size of the non-sector entries of
+	// a migration archive which are read into memory.
+	migrationMaxEntrySize = 1 << 30
+)
+
+var (
+	// errHostMigrating is returned when a storage obligation or ephemeral
+	// account is modified after the host started exporting a migration.
+	errHostMigrating = errors.New("host is being migrated to another node")
+
+	// errHostNotEmpty is returned when a migration is imported by a host
+	// which already has storage obligations.
+	errHostNotEmpty = errors.New("migrations can only be imported by hosts without storage obligations")
+
+	// errInvalidMigration is returned when a migration archive is malformed.
+	errInvalidMigration = errors.New("invalid migration archive")
+
+	// migrationManifestHeader is the header of the manifest of a migration
+	// archive.
+	migrationManifestHeader = "Sia Host Migration"
+
+	// migrationManifestVersion is the version of the migration archive.
+	migrationManifestVersion = "1.5.5"
+
+	// migrationBuckets are the buckets of the host's database which are
+	// migrated.
+	migrationBuckets = [][]byte{
+		bucketActionItems,
+		bucketStorageObligations,
+		bucketTemporarySectors,
+		bucketFinancialSnapshots,
+		bucketAccountBalanceCaps,
+		bucketStorageProofAttempts,
+		bucketRegistryKeyLimits,
+	}
+)
+
+// migrationManifest is the first entry of a migration archive and describes
+// its contents.
+type migrationManifest struct {
+	Header      string             `json:"header"`
+	Version     string             `json:"version"`
+	BlockHeight types.BlockHeight  `json:"blockheight"`
+	PublicKey   types.SiaPublicKey `json:"publickey"`
+	NumSectors  uint64             `json:"numsectors"`
+}
+
+// staticMigrating returns whether the host started exporting a migration.
+func (h *Host) staticMigrating() bool {
+	return atomic.LoadUint64(&h.atomicMigrating) == 1
+}
+
+// migrationSectors returns the roots of all sectors referenced by the
+// database together with the number of references. The host's storage
+// manager tracks the same number of references for each sector.
+func migrationSectors(tx *bolt.Tx) (map[crypto.Hash]uint64, error) {
+	sectors := make(map[crypto.Hash]uint64)
+	err := tx.Bucket(bucketStorageObligations).ForEach(func(_, v []byte) error {
+		var so storageObligation
+		if err := json.Unmarshal(v, &so); err != nil {
+			return errors.AddContext(err, "failed to unmarshal storage obligation")
+		}
+		if so.ObligationStatus != obligationUnresolved {
+			return nil
+		}
+		for _, root := range so.SectorRoots {
+			sectors[root]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Bucket(bucketTemporarySectors).ForEach(func(_, v []byte) error {
+		for i := 0; i+crypto.HashSize <= len(v); i += crypto.HashSize {
+			var root crypto.Hash
+			copy(root[:], v[i:])
+			sectors[root]++
+		}
+		return nil
+	})
+	return sectors, err
+}
+
+// managedAccountsData returns the persisted data of all ephemeral accounts.
+func (am *accountManager) managedAccountsData() []accountData {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	data := make([]accountData, 0, len(am.accounts))
+	for _, acc := range am.accounts {
+		data = append(data, *acc.accountData())
+	}
+	return data
+}
+
+// writeMigrationEntry writes an entry with the provided data to the archive.
+func writeMigrationEntry(tw *tar.Writer, name string, data []byte) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name: name,
+		Mode: 0600,
+		Size: int64(len(data)),
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to write header of "+name)
+	}
+	_, err = tw.Write(data)
+	return errors.AddContext(err, "failed to write "+name)
+}
+
+// readMigrationEntry reads the next entry of the archive and verifies that it
+// has the expected name.
+func readMigrationEntry(tr *tar.Reader, name string) ([]byte, error) {
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to read "+name)
+	}
+	if hdr.Name != name || hdr.Size > migrationMaxEntrySize {
+		return nil, errors.AddContext(errInvalidMigration, "expected "+name+" but got "+hdr.Name)
+	}
+	data, err := ioutil.ReadAll(tr)
+	return data, errors.AddContext(err, "failed to read "+name)
+}
+
+// ExportMigration writes an archive containing the host's persistence,
+// database, registry, ephemeral accounts and the data of all sectors to the
+// provided writer. Another node can take over the host by importing the
+// archive.
+//
+// Once the export starts, the host stops accepting changes to its storage
+// obligations and ephemeral accounts to make sure that the archive stays up to
+// date. The host keeps submitting storage proofs until it is shut down. If the
+// export fails, the host accepts changes again.
+func (h *Host) ExportMigration(w io.Writer) (err error) {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	atomic.StoreUint64(&h.atomicMigrating, 1)
+	defer func() {
+		if err != nil {
+			atomic.StoreUint64(&h.atomicMigrating, 0)
+		}
+	}()
+
+	// Wait for modifications which are already in progress by locking all
+	// storage obligations.
+	var ids []types.FileContractID
+	err = h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(k, _ []byte) error {
+			var id types.FileContractID
+			copy(id[:], k)
+			ids = append(ids, id)
+			return nil
+		})
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to get storage obligations")
+	}
+	for _, id := range ids {
+		h.managedLockStorageObligation(id)
+	}
+
+	// Write the manifest, the persistence and the database.
+	tw := tar.NewWriter(w)
+	var sectors map[crypto.Hash]uint64
+	err = h.db.View(func(tx *bolt.Tx) error {
+		var err error
+		sectors, err = migrationSectors(tx)
+		if err != nil {
+			return err
+		}
+
+		h.mu.RLock()
+		p := h.persistData()
+		h.mu.RUnlock()
+		manifest, err := json.Marshal(migrationManifest{
+			Header:      migrationManifestHeader,
+			Version:     migrationManifestVersion,
+			BlockHeight: p.BlockHeight,
+			PublicKey:   p.PublicKey,
+			NumSectors:  uint64(len(sectors)),
+		})
+		if err != nil {
+			return err
+		}
+		if err := writeMigrationEntry(tw, migrationManifestEntry, manifest); err != nil {
+			return err
+		}
+		persistence, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		if err := writeMigrationEntry(tw, migrationPersistEntry, persistence); err != nil {
+			return err
+		}
+
+		err = tw.WriteHeader(&tar.Header{
+			Name: migrationDatabaseEntry,
+			Mode: 0600,
+			Size: tx.Size(),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tx.WriteTo(tw)
+		return err
+	})
+	for _, id := range ids {
+		h.managedUnlockStorageObligation(id)
+	}
+	if err != nil {
+		return errors.AddContext(err, "failed to export database")
+	}
+
+	// Write the registry and the accounts.
+	err = writeMigrationEntry(tw, migrationRegistryEntry, encoding.Marshal(h.staticRegistry.Entries()))
+	if err != nil {
+		return err
+	}
+	err = writeMigrationEntry(tw, migrationAccountsEntry, encoding.Marshal(h.staticAccountManager.managedAccountsData()))
+	if err != nil {
+		return err
+	}
+
+	// Write the sectors.
+	roots := make([]crypto.Hash, 0, len(sectors))
+	for root := range sectors {
+		roots = append(roots, root)
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i].String() < roots[j].String()
+	})
+	for _, root := range roots {
+		data, err := h.ReadSector(root)
+		if err != nil {
+			return errors.AddContext(err, "failed to read sector "+root.String())
+		}
+		if err := writeMigrationEntry(tw, migrationSectorsPrefix+root.String(), data); err != nil {
+			return err
+		}
+	}
+	return errors.AddContext(tw.Close(), "failed to finish archive")
+}
+
+// ImportMigration imports an archive created by ExportMigration. The root of
+// every sector is verified before the sector is added to the storage manager.
+// Only once all sectors were added, the host takes over the storage
+// obligations, registry, ephemeral accounts and identity of the exported
+// host.
+//
+// The host needs to be restarted after the import for the SiaMux to use the
+// imported host's key. Until the restart, renters can't connect to the host
+// using the new protocol.
+func (h *Host) ImportMigration(r io.Reader) (err error) {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	// Only hosts without storage obligations can import a migration.
+	err = h.db.View(func(tx *bolt.Tx) error {
+		if k, _ := tx.Bucket(bucketStorageObligations).Cursor().First(); k != nil {
+			return errHostNotEmpty
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Read the manifest and the persistence.
+	tr := tar.NewReader(r)
+	data, err := readMigrationEntry(tr, migrationManifestEntry)
+	if err != nil {
+		return err
+	}
+	var manifest migrationManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return errors.AddContext(err, "failed to unmarshal manifest")
+	}
+	if manifest.Header != migrationManifestHeader || manifest.Version != migrationManifestVersion {
+		return errors.AddContext(errInvalidMigration, "unknown manifest header or version")
+	}
+	data, err = readMigrationEntry(tr, migrationPersistEntry)
+	if err != nil {
+		return err
+	}
+	var p persistence
+	if err := json.Unmarshal(data, &p); err != nil {
+		return errors.AddContext(err, "failed to unmarshal persistence")
+	}
+	if !p.PublicKey.Equals(manifest.PublicKey) {
+		return errors.AddContext(errInvalidMigration, "public key doesn't match manifest")
+	}
+
+	// Write the database to a temporary file and open it.
+	hdr, err := tr.Next()
+	if err != nil {
+		return errors.AddContext(err, "failed to read database")
+	}
+	if hdr.Name != migrationDatabaseEntry {
+		return errors.AddContext(errInvalidMigration, "expected "+migrationDatabaseEntry+" but got "+hdr.Name)
+	}
+	dbPath := filepath.Join(h.persistDir, migrationDatabaseFile)
+	defer func() {
+		err = errors.Compose(err, os.RemoveAll(dbPath))
+	}()
+	f, err := os.OpenFile(dbPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.AddContext(err, "failed to create temporary database")
+	}
+	_, err = io.Copy(f, tr)
+	err = errors.Compose(err, f.Sync(), f.Close())
+	if err != nil {
+		return errors.AddContext(err, "failed to write temporary database")
+	}
+	db, err := bolt.Open(dbPath, 0600, nil)
+	if err != nil {
+		return errors.AddContext(err, "failed to open temporary database")
+	}
+	defer func() {
+		err = errors.Compose(err, db.Close())
+	}()
+	var sectors map[crypto.Hash]uint64
+	err = db.View(func(tx *bolt.Tx) error {
+		for _, bucket := range migrationBuckets {
+			if tx.Bucket(bucket) == nil {
+				return errors.AddContext(errInvalidMigration, "database is missing bucket "+string(bucket))
+			}
+		}
+		var err error
+		sectors, err = migrationSectors(tx)
+		return err
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to read database")
+	}
+	if uint64(len(sectors)) != manifest.NumSectors {
+		return errors.AddContext(errInvalidMigration, "number of sectors doesn't match manifest")
+	}
+
+	// Read the registry and the accounts.
+	data, err = readMigrationEntry(tr, migrationRegistryEntry)
+	if err != nil {
+		return err
+	}
+	var entries []registry.Entry
+	if err := encoding.Unmarshal(data, &entries); err != nil {
+		return errors.AddContext(err, "failed to unmarshal registry")
+	}
+	data, err = readMigrationEntry(tr, migrationAccountsEntry)
+	if err != nil {
+		return err
+	}
+	var accounts []accountData
+	if err := encoding.Unmarshal(data, &accounts); err != nil {
+		return errors.AddContext(err, "failed to unmarshal accounts")
+	}
+
+	// Verify and add the sectors. If the import fails before the database
+	// was copied, the added sectors are removed again.
+	added := make(map[crypto.Hash]uint64)
+	var committed bool
+	defer func() {
+		if err == nil || committed {
+			return
+		}
+		for root, n := range added {
+			for i := uint64(0); i < n; i++ {
+				err = errors.Compose(err, h.RemoveSector(root))
+			}
+		}
+	}()
+	sectorData := make([]byte, modules.SectorSize)
+	for {
+		hdr, err := tr.Next()
+		if errors.Contains(err, io.EOF) {
+			break
+		} else if err != nil {
+			return errors.AddContext(err, "failed to read sector")
+		}
+		var root crypto.Hash
+		if !strings.HasPrefix(hdr.Name, migrationSectorsPrefix) || root.LoadString(strings.TrimPrefix(hdr.Name, migrationSectorsPrefix)) != nil {
+			return errors.AddContext(errInvalidMigration, "unexpected entry "+hdr.Name)
+		}
+		n, exists := sectors[root]
+		if !exists || added[root] > 0 || hdr.Size != int64(modules.SectorSize) {
+			return errors.AddContext(errInvalidMigration, "unexpected sector "+root.String())
+		}
+		if _, err := io.ReadFull(tr, sectorData); err != nil {
+			return errors.AddContext(err, "failed to read sector "+root.String())
+		}
+		if crypto.MerkleRoot(sectorData) != root {
+			return errors.AddContext(errInvalidMigration, "sector data doesn't match root "+root.String())
+		}
+		for i := uint64(0); i < n; i++ {
+			if err := h.AddSector(root, sectorData); err != nil {
+				return errors.AddContext(err, "failed to add sector "+root.String())
+			}
+			added[root]++
+		}
+	}
+	if len(added) != len(sectors) {
+		return errors.AddContext(errInvalidMigration, "archive is missing sectors")
+	}
+
+	// Copy the database.
+	err = db.View(func(src *bolt.Tx) error {
+		return h.db.Update(func(dst *bolt.Tx) error {
+			for _, bucket := range migrationBuckets {
+				b := dst.Bucket(bucket)
+				err := src.Bucket(bucket).ForEach(func(k, v []byte) error {
+					return b.Put(k, v)
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to import database")
+	}
+	committed = true
+
+	// Take over the identity of the exported host. The settings specific to
+	// this node are kept.
+	h.mu.Lock()
+	settings := p.Settings
+	settings.NetAddress = h.settings.NetAddress
+	settings.CustomRegistryPath = h.settings.CustomRegistryPath
+	if h.settings.RegistrySize > settings.RegistrySize {
+		settings.RegistrySize = h.settings.RegistrySize
+	}
+	h.announced = false
+	h.financialMetrics = p.FinancialMetrics
+	h.publicKey = p.PublicKey
+	h.secretKey = p.SecretKey
+	if p.RevisionNumber > h.revisionNumber {
+		h.revisionNumber = p.RevisionNumber
+	}
+	blockHeight := h.blockHeight
+	err = h.saveSync()
+	h.mu.Unlock()
+	if err != nil {
+		return errors.AddContext(err, "failed to save host identity")
+	}
+	keys := struct {
+		PublicKey types.SiaPublicKey `json:"publickey"`
+		SecretKey crypto.SecretKey   `json:"secretkey"`
+	}{p.PublicKey, p.SecretKey}
+	err = persist.SaveJSON(modules.HostMigratedKeysMetadata, keys, filepath.Join(h.persistDir, modules.HostMigratedKeysFile))
+	if err != nil {
+		return errors.AddContext(err, "failed to save host keys")
+	}
+	if err := h.SetInternalSettings(settings); err != nil {
+		return errors.AddContext(err, "failed to import settings")
+	}
+
+	// Import the registry entries which didn't expire yet and the accounts.
+	// At this point the import can't be undone anymore, so failures are only
+	// logged.
+	for _, e := range entries {
+		if e.Expiry <= blockHeight {
+			continue
+		}
+		if _, err := h.staticRegistry.Update(e.Value, e.PubKey, e.Expiry); err != nil {
+			h.log.Println("WARN: failed to import registry entry:", err)
+		}
+	}
+	if err := h.loadRegistryKeyLimits(); err != nil {
+		h.log.Println("WARN: failed to import registry key limits:", err)
+	}
+	for _, a := range accounts {
+		if err := h.staticAccountManager.callRefund(a.ID, a.Balance); err != nil {
+			h.log.Println("WARN: failed to import ephemeral account:", err)
+		}
+	}
+	h.log.Printf("Imported host %v with %v sectors", p.PublicKey, len(sectors))
+	return nil
+}
@@ -0,0 +1,103 @@
+package host
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestMigration tests exporting a host and importing it on another node.
+func TestMigration(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester(t.Name() + "Old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	// Store a sector and fund an account.
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root := crypto.MerkleRoot(data)
+	h.mu.RLock()
+	bh := h.blockHeight
+	h.mu.RUnlock()
+	if err := h.StoreSector(root, data, bh+100); err != nil {
+		t.Fatal(err)
+	}
+	_, aid := prepareAccount()
+	if err := callDeposit(h.staticAccountManager, aid, types.NewCurrency64(100)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Export the host. Afterwards the accounts can't be used anymore.
+	var archive bytes.Buffer
+	if err := h.ExportMigration(&archive); err != nil {
+		t.Fatal(err)
+	}
+	err = callDeposit(h.staticAccountManager, aid, types.NewCurrency64(1))
+	if !errors.Contains(err, errHostMigrating) {
+		t.Fatal("expected errHostMigrating", err)
+	}
+
+	// Importing a corrupted archive should fail without changing the host.
+	ht2, err := newHostTester(t.Name() + "New")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h2 := ht2.host
+	corrupted := append([]byte(nil), archive.Bytes()...)
+	corrupted[len(corrupted)-2048] ^= 1
+	err = h2.ImportMigration(bytes.NewReader(corrupted))
+	if err == nil {
+		t.Fatal("expected corrupted archive to be rejected")
+	}
+	if h2.HasSector(root) {
+		t.Fatal("sector of failed import should have been removed")
+	}
+	if h2.PublicKey().Equals(h.PublicKey()) {
+		t.Fatal("failed import shouldn't change the host key")
+	}
+
+	// Import the archive.
+	netAddress := h2.InternalSettings().NetAddress
+	err = h2.ImportMigration(bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h2.HasSector(root) {
+		t.Fatal("sector wasn't imported")
+	}
+	if !h2.PublicKey().Equals(h.PublicKey()) {
+		t.Fatal("host key wasn't imported")
+	}
+	if h2.InternalSettings().NetAddress != netAddress {
+		t.Fatal("net address shouldn't be imported")
+	}
+	if balance := getAccountBalance(h2.staticAccountManager, aid); !balance.Equals64(100) {
+		t.Fatal("account wasn't imported", balance)
+	}
+	if _, err := os.Stat(filepath.Join(h2.persistDir, modules.HostMigratedKeysFile)); err != nil {
+		t.Fatal("migrated keys weren't saved", err)
+	}
+}
@@ -64,6 +64,10 @@ func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExt
 		maxCollateral = h.settings.CollateralBudget.Sub(h.financialMetrics.LockedStorageCollateral)
 	}
 
+	// Get the storage and bandwidth prices. If dynamic pricing is enabled,
+	// they are set by the pricing engine.
+	storagePrice, uploadPrice, downloadPrice := h.staticPricingEngine.managedPrices(h.settings)
+
 	// Extract the port from the SiaMux's address
 	_, port, err := net.SplitHostPort(h.staticMux.Address().String())
 	if err != nil {
@@ -87,10 +91,10 @@ func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExt
 
 		BaseRPCPrice:           h.settings.MinBaseRPCPrice,
 		ContractPrice:          contractPrice,
-		DownloadBandwidthPrice: h.settings.MinDownloadBandwidthPrice,
+		DownloadBandwidthPrice: downloadPrice,
 		SectorAccessPrice:      h.settings.MinSectorAccessPrice,
-		StoragePrice:           h.settings.MinStoragePrice,
-		UploadBandwidthPrice:   h.settings.MinUploadBandwidthPrice,
+		StoragePrice:           storagePrice,
+		UploadBandwidthPrice:   uploadPrice,
 
 		EphemeralAccountExpiry:     h.settings.EphemeralAccountExpiry,
 		MaxEphemeralAccountBalance: h.settings.MaxEphemeralAccountBalance,
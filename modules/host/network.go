@@ -483,5 +483,9 @@ func (h *Host) NetworkMetrics() modules.HostNetworkMetrics {
 		ReviseCalls:       atomic.LoadUint64(&h.atomicReviseCalls),
 		SettingsCalls:     atomic.LoadUint64(&h.atomicSettingsCalls),
 		UnrecognizedCalls: atomic.LoadUint64(&h.atomicUnrecognizedCalls),
+
+		ThrottledBandwidthCalls:   atomic.LoadUint64(&h.atomicThrottledBandwidthCalls),
+		ThrottledConcurrencyCalls: atomic.LoadUint64(&h.atomicThrottledConcurrencyCalls),
+		ThrottledRequestCalls:     atomic.LoadUint64(&h.atomicThrottledRequestCalls),
 	}
 }
@@ -49,7 +49,7 @@ func (h *Host) staticPayByEphemeralAccount(stream siamux.Stream, bh types.BlockH
 	}
 
 	// Payment done through EAs don't move collateral
-	return newPaymentDetails(req.Message.Account, req.Message.Amount), nil
+	return newPaymentDetails(req.Message.Account, req.Message.Amount, false), nil
 }
 
 // managedPayByContract processes a PayByContractRequest coming in over the
@@ -131,7 +131,7 @@ func (h *Host) managedPayByContract(stream siamux.Stream, bh types.BlockHeight)
 		return nil, errors.AddContext(err, "Could not send PayByContractResponse")
 	}
 
-	return newPaymentDetails(accountID, amount), nil
+	return newPaymentDetails(accountID, amount, true), nil
 }
 
 // managedFundAccount processes a PayByContractRequest coming in over the given
@@ -399,18 +399,27 @@ func verifyPayByContractRevision(current, payment types.FileContractRevision, bl
 // payment details is a helper struct that implements the PaymentDetails
 // interface.
 type paymentDetails struct {
-	account modules.AccountID
-	amount  types.Currency
+	account    modules.AccountID
+	amount     types.Currency
+	byContract bool
 }
 
 // newPaymentDetails returns a new paymentDetails object using the given values
-func newPaymentDetails(account modules.AccountID, amountPaid types.Currency) *paymentDetails {
+func newPaymentDetails(account modules.AccountID, amountPaid types.Currency, byContract bool) *paymentDetails {
 	return &paymentDetails{
-		account: account,
-		amount:  amountPaid,
+		account:    account,
+		amount:     amountPaid,
+		byContract: byContract,
 	}
 }
 
+// paidByEphemeralAccount returns whether the payment was made from an
+// ephemeral account.
+func paidByEphemeralAccount(pd modules.PaymentDetails) bool {
+	details, ok := pd.(*paymentDetails)
+	return ok && !details.byContract
+}
+
 // AccountID returns the account id used for payment. For payments made by
 // contract this will return the empty string.
 func (pd *paymentDetails) AccountID() modules.AccountID { return pd.account }
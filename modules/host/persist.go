@@ -30,6 +30,9 @@ type persistence struct {
 	SecretKey        crypto.SecretKey             `json:"secretkey"`
 	Settings         modules.HostInternalSettings `json:"settings"`
 	UnlockHash       types.UnlockHash             `json:"unlockhash"`
+
+	// Dynamic Pricing.
+	Pricing pricingPersist `json:"pricing"`
 }
 
 // persistData returns the data in the Host that will be saved to disk.
@@ -48,6 +51,9 @@ func (h *Host) persistData() persistence {
 		SecretKey:        h.secretKey,
 		Settings:         h.settings,
 		UnlockHash:       h.unlockHash,
+
+		// Dynamic Pricing.
+		Pricing: h.staticPricingEngine.managedPersistData(),
 	}
 }
 
@@ -121,6 +127,9 @@ func (h *Host) loadPersistObject(p *persistence) {
 		h.settings.NetAddress = ""
 	}
 	h.unlockHash = p.UnlockHash
+
+	// Copy over the dynamic pricing state.
+	h.staticPricingEngine.managedLoad(p.Pricing)
 }
 
 // initDB will check that the database has been initialized and if not, will
@@ -144,6 +153,11 @@ func (h *Host) initDB() (err error) {
 		buckets := [][]byte{
 			bucketActionItems,
 			bucketStorageObligations,
+			bucketTemporarySectors,
+			bucketFinancialSnapshots,
+			bucketAccountBalanceCaps,
+			bucketStorageProofAttempts,
+			bucketRegistryKeyLimits,
 		}
 		for _, bucket := range buckets {
 			_, err := tx.CreateBucketIfNotExists(bucket)
@@ -0,0 +1,385 @@
+package host
+
+import (
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errInvalidPricingDampingFactor is returned if dynamic pricing is
+	// enabled with a damping factor outside of (0, 1].
+	errInvalidPricingDampingFactor = errors.New("dynamic pricing damping factor needs to be greater than 0 and at most 1")
+
+	// errMaxStoragePriceTooLow, errMaxUploadBandwidthPriceTooLow and
+	// errMaxDownloadBandwidthPriceTooLow are returned if the upper bound of a
+	// dynamic price is lower than the corresponding minimum price.
+	errMaxStoragePriceTooLow           = errors.New("dynamic pricing max storage price is lower than the min storage price")
+	errMaxUploadBandwidthPriceTooLow   = errors.New("dynamic pricing max upload bandwidth price is lower than the min upload bandwidth price")
+	errMaxDownloadBandwidthPriceTooLow = errors.New("dynamic pricing max download bandwidth price is lower than the min download bandwidth price")
+)
+
+var (
+	// pricingUpdateFrequency is the frequency at which the host's pricing
+	// engine re-evaluates its prices.
+	pricingUpdateFrequency = build.Select(build.Var{
+		Standard: 10 * time.Minute,
+		Dev:      time.Minute,
+		Testing:  3 * time.Second,
+	}).(time.Duration)
+
+	// pricingDecisionHistoryLen is the number of pricing decisions the host
+	// keeps in memory.
+	pricingDecisionHistoryLen = build.Select(build.Var{
+		Standard: 144,
+		Dev:      60,
+		Testing:  10,
+	}).(int)
+)
+
+const (
+	// pricingStorageUtilizationWeight and pricingContractDemandWeight are
+	// the weights of the storage utilization and the contract demand when
+	// computing the storage price. They add up to 1.
+	pricingStorageUtilizationWeight = 0.8
+	pricingContractDemandWeight     = 0.2
+)
+
+type (
+	// pricingEngine keeps track of the prices set by the host's dynamic
+	// pricing as well as the inputs of the previous update. It is covered by
+	// its own mutex and never acquires the host's lock.
+	pricingEngine struct {
+		// Current prices. Only valid if 'active' is set.
+		active                 bool
+		downloadBandwidthPrice types.Currency
+		storagePrice           types.Currency
+		uploadBandwidthPrice   types.Currency
+
+		// Inputs of the previous update.
+		lastContractCount uint64
+		lastReceived      uint64
+		lastSent          uint64
+		lastUpdate        time.Time
+
+		decisions []modules.HostPricingDecision
+		mu        sync.Mutex
+	}
+
+	// pricingPersist is the part of the pricing engine that is persisted with
+	// the host's settings. The inputs of the previous update are not
+	// persisted since the bandwidth counters are reset on restart.
+	pricingPersist struct {
+		Active                 bool                          `json:"active"`
+		DownloadBandwidthPrice types.Currency                `json:"downloadbandwidthprice"`
+		StoragePrice           types.Currency                `json:"storageprice"`
+		UploadBandwidthPrice   types.Currency                `json:"uploadbandwidthprice"`
+		Decisions              []modules.HostPricingDecision `json:"decisions"`
+	}
+
+	// pricingInputs are the utilization metrics the pricing engine bases its
+	// decisions on.
+	pricingInputs struct {
+		contractCount    uint64
+		received         uint64
+		remainingStorage uint64
+		totalStorage     uint64
+		sent             uint64
+		timestamp        time.Time
+	}
+)
+
+// clampUtilization clamps a utilization value to [0, 1].
+func clampUtilization(u float64) float64 {
+	if u < 0 {
+		return 0
+	}
+	if u > 1 {
+		return 1
+	}
+	return u
+}
+
+// clampPrice makes sure a price is within [min, max]. The min takes
+// precedence if max is smaller than min.
+func clampPrice(price, min, max types.Currency) types.Currency {
+	if price.Cmp(max) > 0 {
+		price = max
+	}
+	if price.Cmp(min) < 0 {
+		price = min
+	}
+	return price
+}
+
+// targetPrice returns the price within [min, max] which corresponds to the
+// provided utilization.
+func targetPrice(min, max types.Currency, utilization float64) types.Currency {
+	if max.Cmp(min) <= 0 {
+		return min
+	}
+	return min.Add(max.Sub(min).MulFloat(utilization))
+}
+
+// dampenPrice blends the previous price with the target price using the
+// damping factor.
+func dampenPrice(prev, target types.Currency, damping float64) types.Currency {
+	return prev.MulFloat(1 - damping).Add(target.MulFloat(damping))
+}
+
+// bandwidthUtilization returns the utilization of the bandwidth given the
+// amount of bytes transferred within a period and the target bandwidth.
+func bandwidthUtilization(bytes uint64, period time.Duration, target uint64) float64 {
+	if target == 0 || period <= 0 {
+		return 0
+	}
+	return clampUtilization(float64(bytes) / period.Seconds() / float64(target))
+}
+
+// managedPrices returns the prices set by the pricing engine clamped to the
+// bounds of the provided settings. If dynamic pricing is disabled or the
+// engine didn't make a decision yet, the minimum prices are returned.
+func (pe *pricingEngine) managedPrices(settings modules.HostInternalSettings) (storage, upload, download types.Currency) {
+	storage = settings.MinStoragePrice
+	upload = settings.MinUploadBandwidthPrice
+	download = settings.MinDownloadBandwidthPrice
+	if !settings.DynamicPricing.Enabled {
+		return
+	}
+
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	if !pe.active {
+		return
+	}
+	dps := settings.DynamicPricing
+	storage = clampPrice(pe.storagePrice, storage, dps.MaxStoragePrice)
+	upload = clampPrice(pe.uploadBandwidthPrice, upload, dps.MaxUploadBandwidthPrice)
+	download = clampPrice(pe.downloadBandwidthPrice, download, dps.MaxDownloadBandwidthPrice)
+	return
+}
+
+// managedDecisions returns a copy of the engine's pricing decisions.
+func (pe *pricingEngine) managedDecisions() []modules.HostPricingDecision {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	return append([]modules.HostPricingDecision{}, pe.decisions...)
+}
+
+// managedPersistData returns the engine's data that is persisted with the
+// host's settings.
+func (pe *pricingEngine) managedPersistData() pricingPersist {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	return pricingPersist{
+		Active:                 pe.active,
+		DownloadBandwidthPrice: pe.downloadBandwidthPrice,
+		StoragePrice:           pe.storagePrice,
+		UploadBandwidthPrice:   pe.uploadBandwidthPrice,
+		Decisions:              append([]modules.HostPricingDecision{}, pe.decisions...),
+	}
+}
+
+// managedLoad restores the engine's prices and decisions from persisted data.
+func (pe *pricingEngine) managedLoad(p pricingPersist) {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	pe.active = p.Active
+	pe.downloadBandwidthPrice = p.DownloadBandwidthPrice
+	pe.storagePrice = p.StoragePrice
+	pe.uploadBandwidthPrice = p.UploadBandwidthPrice
+	pe.decisions = p.Decisions
+	if len(pe.decisions) > pricingDecisionHistoryLen {
+		pe.decisions = pe.decisions[len(pe.decisions)-pricingDecisionHistoryLen:]
+	}
+}
+
+// managedUpdate updates the engine's prices using the provided settings and
+// inputs. If dynamic pricing is disabled, only the inputs are recorded and
+// the engine is reset. The returned bool indicates whether a decision was
+// made.
+func (pe *pricingEngine) managedUpdate(settings modules.HostInternalSettings, in pricingInputs) (modules.HostPricingDecision, bool) {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	// Compute the deltas since the last update. The bandwidth counters are
+	// reset on restart which is why we need to check for underflows.
+	period := in.timestamp.Sub(pe.lastUpdate)
+	var received, sent uint64
+	if in.received >= pe.lastReceived {
+		received = in.received - pe.lastReceived
+	}
+	if in.sent >= pe.lastSent {
+		sent = in.sent - pe.lastSent
+	}
+	var newContracts uint64
+	if in.contractCount > pe.lastContractCount {
+		newContracts = in.contractCount - pe.lastContractCount
+	}
+	firstUpdate := pe.lastUpdate.IsZero()
+	lastContractCount := pe.lastContractCount
+
+	// Remember the inputs for the next update.
+	pe.lastContractCount = in.contractCount
+	pe.lastReceived = in.received
+	pe.lastSent = in.sent
+	pe.lastUpdate = in.timestamp
+
+	// Without dynamic pricing enabled or without a previous update to compare
+	// to, there is nothing to decide.
+	if !settings.DynamicPricing.Enabled {
+		pe.active = false
+		return modules.HostPricingDecision{}, false
+	}
+	if firstUpdate {
+		return modules.HostPricingDecision{}, false
+	}
+
+	// Compute the utilization.
+	dps := settings.DynamicPricing
+	d := modules.HostPricingDecision{
+		Timestamp: in.timestamp,
+	}
+	if in.totalStorage > 0 && in.remainingStorage <= in.totalStorage {
+		d.StorageUtilization = float64(in.totalStorage-in.remainingStorage) / float64(in.totalStorage)
+	}
+	if lastContractCount > 0 {
+		d.ContractDemand = clampUtilization(float64(newContracts) / float64(lastContractCount))
+	} else if newContracts > 0 {
+		d.ContractDemand = 1
+	}
+	// Download bandwidth is data sent by the host and upload bandwidth is
+	// data received by the host.
+	d.DownloadUtilization = bandwidthUtilization(sent, period, dps.TargetBandwidth)
+	d.UploadUtilization = bandwidthUtilization(received, period, dps.TargetBandwidth)
+
+	// Compute the target prices.
+	storageScore := pricingStorageUtilizationWeight*d.StorageUtilization + pricingContractDemandWeight*d.ContractDemand
+	storageTarget := targetPrice(settings.MinStoragePrice, dps.MaxStoragePrice, clampUtilization(storageScore))
+	uploadTarget := targetPrice(settings.MinUploadBandwidthPrice, dps.MaxUploadBandwidthPrice, d.UploadUtilization)
+	downloadTarget := targetPrice(settings.MinDownloadBandwidthPrice, dps.MaxDownloadBandwidthPrice, d.DownloadUtilization)
+
+	// The first decision after enabling the engine applies the target prices
+	// directly. After that the prices move towards the target by the damping
+	// factor.
+	if !pe.active {
+		d.StoragePrice = storageTarget
+		d.UploadBandwidthPrice = uploadTarget
+		d.DownloadBandwidthPrice = downloadTarget
+	} else {
+		d.StoragePrice = dampenPrice(pe.storagePrice, storageTarget, dps.DampingFactor)
+		d.UploadBandwidthPrice = dampenPrice(pe.uploadBandwidthPrice, uploadTarget, dps.DampingFactor)
+		d.DownloadBandwidthPrice = dampenPrice(pe.downloadBandwidthPrice, downloadTarget, dps.DampingFactor)
+	}
+	d.StoragePrice = clampPrice(d.StoragePrice, settings.MinStoragePrice, dps.MaxStoragePrice)
+	d.UploadBandwidthPrice = clampPrice(d.UploadBandwidthPrice, settings.MinUploadBandwidthPrice, dps.MaxUploadBandwidthPrice)
+	d.DownloadBandwidthPrice = clampPrice(d.DownloadBandwidthPrice, settings.MinDownloadBandwidthPrice, dps.MaxDownloadBandwidthPrice)
+
+	// Apply the decision.
+	pe.active = true
+	pe.storagePrice = d.StoragePrice
+	pe.uploadBandwidthPrice = d.UploadBandwidthPrice
+	pe.downloadBandwidthPrice = d.DownloadBandwidthPrice
+	pe.decisions = append(pe.decisions, d)
+	if len(pe.decisions) > pricingDecisionHistoryLen {
+		pe.decisions = pe.decisions[len(pe.decisions)-pricingDecisionHistoryLen:]
+	}
+	return d, true
+}
+
+// checkDynamicPricingSettings checks that the dynamic pricing settings are
+// consistent with the rest of the host's internal settings.
+func checkDynamicPricingSettings(settings modules.HostInternalSettings) error {
+	dps := settings.DynamicPricing
+	if !dps.Enabled {
+		return nil
+	}
+	if dps.DampingFactor <= 0 || dps.DampingFactor > 1 {
+		return errInvalidPricingDampingFactor
+	}
+	if dps.MaxStoragePrice.Cmp(settings.MinStoragePrice) < 0 {
+		return errMaxStoragePriceTooLow
+	}
+	if dps.MaxUploadBandwidthPrice.Cmp(settings.MinUploadBandwidthPrice) < 0 {
+		return errMaxUploadBandwidthPriceTooLow
+	}
+	if dps.MaxDownloadBandwidthPrice.Cmp(settings.MinDownloadBandwidthPrice) < 0 {
+		return errMaxDownloadBandwidthPriceTooLow
+	}
+	return nil
+}
+
+// managedPricingInputs gathers the current inputs for the pricing engine.
+func (h *Host) managedPricingInputs() (modules.HostInternalSettings, pricingInputs, error) {
+	upload, download, _, err := h.BandwidthCounters()
+	if err != nil {
+		return modules.HostInternalSettings{}, pricingInputs{}, err
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	total, remaining := h.capacity()
+	return h.settings, pricingInputs{
+		contractCount:    h.financialMetrics.ContractCount,
+		received:         download,
+		remainingStorage: remaining,
+		totalStorage:     total,
+		sent:             upload,
+		timestamp:        time.Now(),
+	}, nil
+}
+
+// managedUpdatePricing runs a single update of the pricing engine, persists
+// the decision and refreshes the host's price table if the prices changed.
+func (h *Host) managedUpdatePricing() {
+	settings, inputs, err := h.managedPricingInputs()
+	if err != nil {
+		h.log.Debugln("Unable to gather pricing inputs:", err)
+		return
+	}
+	d, updated := h.staticPricingEngine.managedUpdate(settings, inputs)
+	if !updated {
+		return
+	}
+	h.log.Printf("Dynamic pricing: storage utilization %.2f, contract demand %.2f, upload utilization %.2f, download utilization %.2f -> storage price %v, upload price %v, download price %v",
+		d.StorageUtilization, d.ContractDemand, d.UploadUtilization, d.DownloadUtilization,
+		d.StoragePrice.HumanString(), d.UploadBandwidthPrice.HumanString(), d.DownloadBandwidthPrice.HumanString())
+	h.mu.Lock()
+	err = h.saveSync()
+	h.mu.Unlock()
+	if err != nil {
+		h.log.Println("Unable to save pricing decision:", err)
+	}
+	h.managedUpdatePriceTable()
+}
+
+// threadedUpdatePricing periodically updates the host's prices using the
+// dynamic pricing engine.
+func (h *Host) threadedUpdatePricing() {
+	for {
+		func() {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			defer h.tg.Done()
+			h.managedUpdatePricing()
+		}()
+
+		// Block until next cycle.
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(pricingUpdateFrequency):
+			continue
+		}
+	}
+}
+
+// PricingDecisions returns the most recent price adjustments made by the
+// host's dynamic pricing engine, oldest first.
+func (h *Host) PricingDecisions() []modules.HostPricingDecision {
+	return h.staticPricingEngine.managedDecisions()
+}
@@ -0,0 +1,215 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestPricingEngineUpdate is a unit test for the pricing engine's update.
+func TestPricingEngineUpdate(t *testing.T) {
+	t.Parallel()
+
+	settings := modules.HostInternalSettings{
+		MinDownloadBandwidthPrice: types.NewCurrency64(100),
+		MinStoragePrice:           types.NewCurrency64(100),
+		MinUploadBandwidthPrice:   types.NewCurrency64(100),
+		DynamicPricing: modules.HostDynamicPricingSettings{
+			Enabled:                   true,
+			DampingFactor:             0.5,
+			MaxDownloadBandwidthPrice: types.NewCurrency64(200),
+			MaxStoragePrice:           types.NewCurrency64(200),
+			MaxUploadBandwidthPrice:   types.NewCurrency64(200),
+			TargetBandwidth:           1000,
+		},
+	}
+	pe := new(pricingEngine)
+
+	// The first update only records the inputs.
+	now := time.Now()
+	in := pricingInputs{
+		contractCount:    10,
+		totalStorage:     1000,
+		remainingStorage: 1000,
+		timestamp:        now,
+	}
+	if _, updated := pe.managedUpdate(settings, in); updated {
+		t.Fatal("first update shouldn't result in a decision")
+	}
+	storage, upload, download := pe.managedPrices(settings)
+	if !storage.Equals(settings.MinStoragePrice) || !upload.Equals(settings.MinUploadBandwidthPrice) || !download.Equals(settings.MinDownloadBandwidthPrice) {
+		t.Fatal("expected min prices before the first decision")
+	}
+
+	// Half of the storage is used, the contracts doubled, the host sent data
+	// at the target bandwidth and didn't receive any data. The first decision
+	// applies the target prices directly.
+	in.contractCount = 20
+	in.remainingStorage = 500
+	in.sent = 10e3
+	in.timestamp = now.Add(10 * time.Second)
+	d, updated := pe.managedUpdate(settings, in)
+	if !updated {
+		t.Fatal("expected a decision")
+	}
+	if d.StorageUtilization != 0.5 || d.ContractDemand != 1 || d.DownloadUtilization != 1 || d.UploadUtilization != 0 {
+		t.Fatal("unexpected utilization", d)
+	}
+	// 100 + 100 * (0.8 * 0.5 + 0.2 * 1) = 160
+	if !d.StoragePrice.Equals64(160) {
+		t.Fatal("unexpected storage price", d.StoragePrice)
+	}
+	if !d.DownloadBandwidthPrice.Equals64(200) {
+		t.Fatal("unexpected download price", d.DownloadBandwidthPrice)
+	}
+	if !d.UploadBandwidthPrice.Equals64(100) {
+		t.Fatal("unexpected upload price", d.UploadBandwidthPrice)
+	}
+
+	// Without any activity the prices should move halfway towards the min
+	// prices.
+	in.timestamp = in.timestamp.Add(10 * time.Second)
+	d, updated = pe.managedUpdate(settings, in)
+	if !updated {
+		t.Fatal("expected a decision")
+	}
+	// 0.5 * 160 + 0.5 * (100 + 100 * 0.8 * 0.5) = 150
+	if !d.StoragePrice.Equals64(150) {
+		t.Fatal("unexpected storage price", d.StoragePrice)
+	}
+	if !d.DownloadBandwidthPrice.Equals64(150) {
+		t.Fatal("unexpected download price", d.DownloadBandwidthPrice)
+	}
+	storage, upload, download = pe.managedPrices(settings)
+	if !storage.Equals64(150) || !upload.Equals64(100) || !download.Equals64(150) {
+		t.Fatal("unexpected prices", storage, upload, download)
+	}
+	if len(pe.managedDecisions()) != 2 {
+		t.Fatal("expected 2 decisions", len(pe.managedDecisions()))
+	}
+
+	// Lowering the max prices should clamp the current prices.
+	lowered := settings
+	lowered.DynamicPricing.MaxStoragePrice = types.NewCurrency64(120)
+	storage, _, _ = pe.managedPrices(lowered)
+	if !storage.Equals64(120) {
+		t.Fatal("expected storage price to be clamped", storage)
+	}
+
+	// Disabling dynamic pricing resets the engine to the min prices.
+	disabled := settings
+	disabled.DynamicPricing.Enabled = false
+	in.timestamp = in.timestamp.Add(10 * time.Second)
+	if _, updated := pe.managedUpdate(disabled, in); updated {
+		t.Fatal("disabled engine shouldn't make decisions")
+	}
+	storage, _, _ = pe.managedPrices(settings)
+	if !storage.Equals(settings.MinStoragePrice) {
+		t.Fatal("expected min storage price after reset", storage)
+	}
+
+	// The decision history is bounded.
+	for i := 0; i < pricingDecisionHistoryLen+5; i++ {
+		in.timestamp = in.timestamp.Add(10 * time.Second)
+		pe.managedUpdate(settings, in)
+	}
+	if len(pe.managedDecisions()) != pricingDecisionHistoryLen {
+		t.Fatal("decision history not bounded", len(pe.managedDecisions()))
+	}
+}
+
+// TestDynamicPricing tests enabling dynamic pricing on a host.
+func TestDynamicPricing(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	// Invalid settings should be rejected.
+	settings := h.InternalSettings()
+	settings.DynamicPricing = modules.HostDynamicPricingSettings{
+		Enabled:                   true,
+		MaxDownloadBandwidthPrice: settings.MinDownloadBandwidthPrice.Mul64(2),
+		MaxStoragePrice:           settings.MinStoragePrice.Mul64(2),
+		MaxUploadBandwidthPrice:   settings.MinUploadBandwidthPrice.Mul64(2),
+	}
+	err = h.SetInternalSettings(settings)
+	if !errors.Contains(err, errInvalidPricingDampingFactor) {
+		t.Fatal("expected errInvalidPricingDampingFactor", err)
+	}
+	settings.DynamicPricing.DampingFactor = 1
+	settings.DynamicPricing.MaxStoragePrice = settings.MinStoragePrice.Sub64(1)
+	err = h.SetInternalSettings(settings)
+	if !errors.Contains(err, errMaxStoragePriceTooLow) {
+		t.Fatal("expected errMaxStoragePriceTooLow", err)
+	}
+
+	// Enable dynamic pricing. The host has storage and uses some of it, so
+	// the storage price should eventually rise above the min.
+	settings.DynamicPricing.MaxStoragePrice = settings.MinStoragePrice.Mul64(2)
+	err = h.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(int(modules.SectorSize))
+	err = h.AddSector(crypto.MerkleRoot(data), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if len(h.PricingDecisions()) == 0 {
+			return errors.New("no decision yet")
+		}
+		es := h.ExternalSettings()
+		if es.StoragePrice.Cmp(settings.MinStoragePrice) <= 0 {
+			return errors.New("storage price didn't increase")
+		}
+		if es.StoragePrice.Cmp(settings.DynamicPricing.MaxStoragePrice) > 0 {
+			return errors.New("storage price exceeds max")
+		}
+		if pt := h.PriceTable(); !pt.WriteStoreCost.Equals(es.StoragePrice) {
+			return errors.New("price table wasn't updated")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The prices and decisions should survive a restart.
+	decisions := h.PricingDecisions()
+	err = reloadHost(ht)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h = ht.host
+	reloaded := h.PricingDecisions()
+	if len(reloaded) < len(decisions) {
+		t.Fatal("decisions weren't persisted", len(reloaded), len(decisions))
+	}
+	for i := range decisions {
+		if !reloaded[i].StoragePrice.Equals(decisions[i].StoragePrice) {
+			t.Fatal("decision mismatch", reloaded[i], decisions[i])
+		}
+	}
+	last := reloaded[len(reloaded)-1]
+	if es := h.ExternalSettings(); !es.StoragePrice.Equals(last.StoragePrice) {
+		t.Fatal("storage price wasn't persisted", es.StoragePrice, last.StoragePrice)
+	}
+}
@@ -0,0 +1,172 @@
+package host
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// renterQuotaWindow is the period over which the request and bandwidth
+	// quotas of a renter are tracked.
+	renterQuotaWindow = build.Select(build.Var{
+		Standard: time.Minute,
+		Dev:      time.Minute,
+		Testing:  30 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// renterQuotas tracks the resources used by renters to enforce the host's
+	// per-account and per-contract quotas.
+	renterQuotas struct {
+		quotas    map[string]*renterQuota
+		lastPrune time.Time
+		mu        sync.Mutex
+	}
+
+	// renterQuota contains the resources used by a single account or
+	// contract.
+	renterQuota struct {
+		activePrograms uint64
+		activeRequests uint64
+		bandwidth      uint64
+		requests       uint64
+		windowStart    time.Time
+	}
+)
+
+// newRenterQuotas creates a new renterQuotas object.
+func newRenterQuotas() *renterQuotas {
+	return &renterQuotas{
+		quotas: make(map[string]*renterQuota),
+	}
+}
+
+// accountQuotaKey returns the key used to track the quota of an ephemeral
+// account.
+func accountQuotaKey(id modules.AccountID) string {
+	return "account:" + id.SPK().String()
+}
+
+// contractQuotaKey returns the key used to track the quota of a file
+// contract.
+func contractQuotaKey(fcid types.FileContractID) string {
+	return "contract:" + fcid.String()
+}
+
+// staleAt returns whether the quota's window has passed at the provided time.
+func (rq *renterQuota) staleAt(now time.Time) bool {
+	return now.Sub(rq.windowStart) >= renterQuotaWindow
+}
+
+// managedAcquire checks whether a new request for the provided keys is within
+// the provided limits and registers it if it is. If 'program' is set, the
+// request also occupies a program slot until it is released. The returned
+// function needs to be called with the number of bytes transferred once the
+// request is done.
+func (rqs *renterQuotas) managedAcquire(settings modules.HostRenterQuotaSettings, program bool, keys ...string) (func(bandwidth uint64), error) {
+	rqs.mu.Lock()
+	defer rqs.mu.Unlock()
+	now := time.Now()
+
+	// Get the quotas and start a new window for quotas which are stale.
+	quotas := make([]*renterQuota, 0, len(keys))
+	for _, key := range keys {
+		rq, exists := rqs.quotas[key]
+		if !exists {
+			rq = &renterQuota{windowStart: now}
+			rqs.quotas[key] = rq
+		} else if rq.staleAt(now) {
+			rq.bandwidth = 0
+			rq.requests = 0
+			rq.windowStart = now
+		}
+		quotas = append(quotas, rq)
+	}
+
+	// Check all the limits before applying the request to any of the quotas.
+	for _, rq := range quotas {
+		if settings.MaxBandwidthPerMinute > 0 && rq.bandwidth >= settings.MaxBandwidthPerMinute {
+			return nil, modules.ErrRenterBandwidthQuotaExceeded
+		}
+		if settings.MaxRequestsPerMinute > 0 && rq.requests >= settings.MaxRequestsPerMinute {
+			return nil, modules.ErrRenterRequestQuotaExceeded
+		}
+		if program && settings.MaxConcurrentPrograms > 0 && rq.activePrograms >= settings.MaxConcurrentPrograms {
+			return nil, modules.ErrRenterConcurrencyLimitReached
+		}
+	}
+	for _, rq := range quotas {
+		rq.requests++
+		rq.activeRequests++
+		if program {
+			rq.activePrograms++
+		}
+	}
+
+	// Prune idle quotas once per window to prevent the map from growing
+	// indefinitely.
+	if now.Sub(rqs.lastPrune) >= renterQuotaWindow {
+		for key, rq := range rqs.quotas {
+			if rq.activeRequests == 0 && rq.staleAt(now) {
+				delete(rqs.quotas, key)
+			}
+		}
+		rqs.lastPrune = now
+	}
+
+	var once sync.Once
+	release := func(bandwidth uint64) {
+		once.Do(func() {
+			rqs.managedRelease(quotas, program, bandwidth)
+		})
+	}
+	return release, nil
+}
+
+// managedRelease releases a request previously registered with
+// managedAcquire.
+func (rqs *renterQuotas) managedRelease(quotas []*renterQuota, program bool, bandwidth uint64) {
+	rqs.mu.Lock()
+	defer rqs.mu.Unlock()
+	now := time.Now()
+	for _, rq := range quotas {
+		rq.activeRequests--
+		if program {
+			rq.activePrograms--
+		}
+		// Bandwidth is accounted for in the window in which the request
+		// finished.
+		if rq.staleAt(now) {
+			rq.bandwidth = 0
+			rq.requests = 0
+			rq.windowStart = now
+		}
+		rq.bandwidth += bandwidth
+	}
+}
+
+// managedAcquireRenterQuota registers a request with the host's renter quotas
+// and updates the throttling statistics if the request is rejected.
+func (h *Host) managedAcquireRenterQuota(program bool, keys ...string) (func(bandwidth uint64), error) {
+	h.mu.RLock()
+	settings := h.settings.RenterQuotas
+	h.mu.RUnlock()
+
+	release, err := h.staticRenterQuotas.managedAcquire(settings, program, keys...)
+	switch {
+	case errors.Contains(err, modules.ErrRenterBandwidthQuotaExceeded):
+		atomic.AddUint64(&h.atomicThrottledBandwidthCalls, 1)
+	case errors.Contains(err, modules.ErrRenterConcurrencyLimitReached):
+		atomic.AddUint64(&h.atomicThrottledConcurrencyCalls, 1)
+	case errors.Contains(err, modules.ErrRenterRequestQuotaExceeded):
+		atomic.AddUint64(&h.atomicThrottledRequestCalls, 1)
+	}
+	return release, err
+}
@@ -0,0 +1,186 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestRenterQuotas is a unit test for the renterQuotas.
+func TestRenterQuotas(t *testing.T) {
+	t.Parallel()
+
+	aid, _ := modules.NewAccountID()
+	accountKey := accountQuotaKey(aid)
+	contractKey := contractQuotaKey(types.FileContractID{1})
+	settings := modules.HostRenterQuotaSettings{
+		MaxBandwidthPerMinute: 100,
+		MaxConcurrentPrograms: 2,
+		MaxRequestsPerMinute:  3,
+	}
+	rqs := newRenterQuotas()
+
+	// Acquire 2 programs. The third one should fail.
+	release1, err := rqs.managedAcquire(settings, true, accountKey, contractKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release2, err := rqs.managedAcquire(settings, true, accountKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = rqs.managedAcquire(settings, true, accountKey)
+	if !errors.Contains(err, modules.ErrRenterConcurrencyLimitReached) {
+		t.Fatal("expected ErrRenterConcurrencyLimitReached", err)
+	}
+
+	// The contract only has a single program, so it can still execute one.
+	// Releasing it again shouldn't count any bandwidth.
+	release3, err := rqs.managedAcquire(settings, true, contractKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release3(0)
+
+	// Release the first program. Releasing twice should be a no-op.
+	release1(10)
+	release1(10)
+	if rq := rqs.quotas[accountKey]; rq.activePrograms != 1 || rq.bandwidth != 10 {
+		t.Fatal("unexpected quota", rq.activePrograms, rq.bandwidth)
+	}
+
+	// The account sent 2 requests since the rejected one doesn't count. It can
+	// send one more before being throttled.
+	_, err = rqs.managedAcquire(settings, false, accountKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = rqs.managedAcquire(settings, false, accountKey)
+	if !errors.Contains(err, modules.ErrRenterRequestQuotaExceeded) {
+		t.Fatal("expected ErrRenterRequestQuotaExceeded", err)
+	}
+
+	// The contract sent 2 requests. After using up its bandwidth, the third
+	// request should be rejected.
+	release4, err := rqs.managedAcquire(settings, false, contractKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release4(settings.MaxBandwidthPerMinute)
+	_, err = rqs.managedAcquire(settings, false, contractKey)
+	if !errors.Contains(err, modules.ErrRenterBandwidthQuotaExceeded) {
+		t.Fatal("expected ErrRenterBandwidthQuotaExceeded", err)
+	}
+
+	// Once the window passes, requests are allowed again.
+	rqs.mu.Lock()
+	for _, rq := range rqs.quotas {
+		rq.windowStart = rq.windowStart.Add(-renterQuotaWindow)
+	}
+	rqs.mu.Unlock()
+	release5, err := rqs.managedAcquire(settings, false, accountKey, contractKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release5(0)
+
+	// Without limits, every request is accepted.
+	for i := 0; i < 10; i++ {
+		if _, err := rqs.managedAcquire(modules.HostRenterQuotaSettings{}, true, accountKey); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Idle quotas are pruned once their window passed.
+	release2(0)
+	rqs.mu.Lock()
+	rqs.quotas[contractKey].windowStart = time.Now().Add(-renterQuotaWindow)
+	rqs.lastPrune = time.Time{}
+	rqs.mu.Unlock()
+	_, err = rqs.managedAcquire(settings, false, contractQuotaKey(types.FileContractID{2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := rqs.quotas[contractKey]; exists {
+		t.Fatal("idle quota wasn't pruned")
+	}
+	if _, exists := rqs.quotas[accountKey]; !exists {
+		t.Fatal("active quota was pruned")
+	}
+}
+
+// TestExecuteProgramRenterQuota tests that the host rejects programs from
+// renters which exceed their quota.
+func TestExecuteProgramRenterQuota(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// create a blank host tester
+	rhp, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := rhp.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	ht := rhp.staticHT
+
+	// Limit the renter to a single request per window.
+	is := ht.host.InternalSettings()
+	is.RenterQuotas.MaxRequestsPerMinute = 1
+	err = ht.host.SetInternalSettings(is)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a sector to the host.
+	sectorData := fastrand.Bytes(int(modules.SectorSize))
+	sectorRoot := crypto.MerkleRoot(sectorData)
+	err = ht.host.AddSector(sectorRoot, sectorData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a 'HasSector' program.
+	pt := rhp.managedPriceTable()
+	pb := modules.NewProgramBuilder(pt, 0)
+	pb.AddHasSectorInstruction(sectorRoot)
+	program, data := pb.Program()
+	programCost, _, _ := pb.Cost(true)
+	epr := modules.RPCExecuteProgramRequest{
+		FileContractID:    rhp.staticFCID,
+		Program:           program,
+		ProgramDataLength: uint64(len(data)),
+	}
+
+	// Fund an account with the max balance.
+	maxBalance := ht.host.managedInternalSettings().MaxEphemeralAccountBalance
+	_, err = rhp.managedFundEphemeralAccount(maxBalance.Add(pt.FundAccountCost), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first execution succeeds, the second one is throttled.
+	budget := programCost.Add(maxBalance.Div64(4))
+	_, _, err = rhp.managedExecuteProgram(epr, data, budget, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = rhp.managedExecuteProgram(epr, data, budget, false, true)
+	if !modules.IsRenterQuotaError(err) {
+		t.Fatal("expected quota error", err)
+	}
+	if nm := ht.host.NetworkMetrics(); nm.ThrottledRequestCalls != 1 {
+		t.Fatal("expected 1 throttled request", nm.ThrottledRequestCalls)
+	}
+}
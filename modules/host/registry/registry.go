@@ -42,6 +42,9 @@ var (
 	// errSamePath is returned if the registry is about to be migrated to its
 	// current path.
 	errSamePath = errors.New("registry can't be migrated to its current path")
+	// ErrKeyLimitReached is returned if a new entry is registered for a public
+	// key which already has as many entries as its limit allows.
+	ErrKeyLimitReached = errors.New("public key reached its limit of registry entries")
 )
 
 type (
@@ -49,6 +52,8 @@ type (
 	// register data with a given pubkey and secondary key (tweak).
 	Registry struct {
 		entries    map[modules.RegistryEntryID]*value
+		keyEntries map[string]uint64
+		keyLimits  map[string]uint64
 		staticHPK  types.SiaPublicKey
 		staticPath string
 		staticFile *os.File
@@ -56,6 +61,22 @@ type (
 		mu         sync.Mutex
 	}
 
+	// Entry is an entry of the registry together with the public key it was
+	// registered with and its expiry.
+	Entry struct {
+		PubKey types.SiaPublicKey
+		Value  modules.SignedRegistryValue
+		Expiry types.BlockHeight
+	}
+
+	// KeyLimit is the limit of entries a public key can register together
+	// with the number of entries it registered.
+	KeyLimit struct {
+		PubKey  types.SiaPublicKey
+		Limit   uint64
+		Entries uint64
+	}
+
 	// values represents the value associated with a registered key.
 	value struct {
 		// key
@@ -139,6 +160,30 @@ func (r *Registry) Get(sid modules.RegistryEntryID) (types.SiaPublicKey, modules
 	return v.key, modules.NewSignedRegistryValue(v.tweak, v.data, v.revision, v.signature, v.entryType), true
 }
 
+// Entries returns all the entries of the registry.
+func (r *Registry) Entries() []Entry {
+	r.mu.Lock()
+	values := make([]*value, 0, len(r.entries))
+	for _, v := range r.entries {
+		values = append(values, v)
+	}
+	r.mu.Unlock()
+
+	entries := make([]Entry, 0, len(values))
+	for _, v := range values {
+		v.mu.Lock()
+		if !v.invalid {
+			entries = append(entries, Entry{
+				PubKey: v.key,
+				Value:  modules.NewSignedRegistryValue(v.tweak, v.data, v.revision, v.signature, v.entryType),
+				Expiry: v.expiry,
+			})
+		}
+		v.mu.Unlock()
+	}
+	return entries
+}
+
 // Len returns the length of the registry.
 func (r *Registry) Len() uint64 {
 	r.mu.Lock()
@@ -146,6 +191,47 @@ func (r *Registry) Len() uint64 {
 	return uint64(len(r.entries))
 }
 
+// KeyLimits returns the limits of all public keys with a limit, sorted by
+// public key.
+func (r *Registry) KeyLimits() []KeyLimit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	limits := make([]KeyLimit, 0, len(r.keyLimits))
+	for key, limit := range r.keyLimits {
+		var spk types.SiaPublicKey
+		if err := spk.LoadString(key); err != nil {
+			build.Critical("KeyLimits: failed to load public key", err)
+			continue
+		}
+		limits = append(limits, KeyLimit{
+			PubKey:  spk,
+			Limit:   limit,
+			Entries: r.keyEntries[key],
+		})
+	}
+	sort.Slice(limits, func(i, j int) bool {
+		return limits[i].PubKey.String() < limits[j].PubKey.String()
+	})
+	return limits
+}
+
+// RemoveKeyLimit removes the limit of entries the public key can register.
+func (r *Registry) RemoveKeyLimit(pubKey types.SiaPublicKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.keyLimits, pubKey.String())
+}
+
+// SetKeyLimit limits the number of entries the public key can register.
+// Entries which were registered before the limit was set are kept, even if
+// they exceed the limit, but no new entries can be registered until the
+// number of entries drops below the limit.
+func (r *Registry) SetKeyLimit(pubKey types.SiaPublicKey, limit uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keyLimits[pubKey.String()] = limit
+}
+
 // Truncate resizes the registry. If 'force' was specified, it will allow to
 // shrink the registry below its current size. This will cause random values to
 // be lost.
@@ -215,6 +301,7 @@ func (r *Registry) Truncate(newMaxEntries uint64, force bool) error {
 		// the in-memory map.
 		for _, entry := range entriesToMove {
 			delete(r.entries, entry.mapKey())
+			r.decrementKeyEntries(entry.key)
 		}
 	}
 
@@ -272,6 +359,8 @@ func New(path string, maxEntries uint64, hpk types.SiaPublicKey) (_ *Registry, e
 	}
 	// Create the registry.
 	reg := &Registry{
+		keyEntries: make(map[string]uint64),
+		keyLimits:  make(map[string]uint64),
 		staticFile: f,
 		staticHPK:  hpk,
 		staticPath: path,
@@ -282,6 +371,9 @@ func New(path string, maxEntries uint64, hpk types.SiaPublicKey) (_ *Registry, e
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to load registry entries")
 	}
+	for _, entry := range reg.entries {
+		reg.keyEntries[entry.key.String()]++
+	}
 	// If an upgrade happened, sync the body and upgrade the metadata
 	// afterwards. Then sync again.
 	if compatV100 {
@@ -383,11 +475,27 @@ func (r *Registry) managedDeleteFromMemory(v *value) {
 	}
 	// Delete the entry from the map.
 	delete(r.entries, v.mapKey())
+	r.decrementKeyEntries(v.key)
+}
+
+// decrementKeyEntries decrements the number of entries registered by the
+// public key.
+func (r *Registry) decrementKeyEntries(pubKey types.SiaPublicKey) {
+	key := pubKey.String()
+	if r.keyEntries[key] <= 1 {
+		delete(r.keyEntries, key)
+		return
+	}
+	r.keyEntries[key]--
 }
 
 // newValue creates a new value and assigns it a free bit from the bitfield. It
 // adds the new value to the registry as well.
 func (r *Registry) newValue(rv modules.SignedRegistryValue, pubKey types.SiaPublicKey, expiry types.BlockHeight) (*value, error) {
+	key := pubKey.String()
+	if limit, limited := r.keyLimits[key]; limited && r.keyEntries[key] >= limit {
+		return nil, ErrKeyLimitReached
+	}
 	bit, err := r.usage.SetRandom()
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to obtain free slot")
@@ -411,6 +519,7 @@ func (r *Registry) newValue(rv modules.SignedRegistryValue, pubKey types.SiaPubl
 		signature:   rv.Signature,
 	}
 	r.entries[v.mapKey()] = v
+	r.keyEntries[key]++
 	return v, nil
 }
 
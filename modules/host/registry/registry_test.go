@@ -1432,3 +1432,162 @@ func TestFailedLoadLargeRegistry(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestEntries tests that Entries returns all entries of the registry.
+func TestEntries(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := testDir(t.Name())
+
+	// Create a new registry.
+	registryPath := filepath.Join(dir, "registry")
+	r, err := New(registryPath, testingDefaultMaxEntries, types.SiaPublicKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(c io.Closer) {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}(r)
+
+	// Add 2 entries.
+	rv1, v1, _ := randomValue(0)
+	_, err = r.Update(rv1, v1.key, v1.expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv2, v2, _ := randomValue(0)
+	_, err = r.Update(rv2, v2.key, v2.expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both entries should be returned.
+	entries := r.Entries()
+	if len(entries) != 2 {
+		t.Fatal("wrong number of entries", len(entries))
+	}
+	for _, e := range entries {
+		var rv modules.SignedRegistryValue
+		var v *value
+		switch modules.DeriveRegistryEntryID(e.PubKey, e.Value.Tweak) {
+		case v1.mapKey():
+			rv, v = rv1, v1
+		case v2.mapKey():
+			rv, v = rv2, v2
+		default:
+			t.Fatal("unknown entry")
+		}
+		if !reflect.DeepEqual(e.Value, rv) || e.Expiry != v.expiry {
+			t.Fatal("wrong entry", e)
+		}
+	}
+}
+
+// TestKeyLimits tests limiting the number of entries a public key can
+// register.
+func TestKeyLimits(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := testDir(t.Name())
+
+	// Create a new registry.
+	registryPath := filepath.Join(dir, "registry")
+	r, err := New(registryPath, testingDefaultMaxEntries, types.SiaPublicKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Register an entry and limit its key to 2 entries.
+	rv, v, sk := randomValue(0)
+	_, err = r.Update(rv, v.key, v.expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.SetKeyLimit(v.key, 2)
+
+	// newEntry registers a new entry for the key with the given expiry.
+	newEntry := func(expiry types.BlockHeight) (modules.SignedRegistryValue, error) {
+		var tweak crypto.Hash
+		fastrand.Read(tweak[:])
+		rv := modules.NewRegistryValue(tweak, fastrand.Bytes(10), 0, modules.RegistryTypeWithoutPubkey).Sign(sk)
+		_, err := r.Update(rv, v.key, expiry)
+		return rv, err
+	}
+
+	// The key should be able to register one more entry.
+	_, err = newEntry(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = newEntry(1)
+	if !errors.Contains(err, ErrKeyLimitReached) {
+		t.Fatal("expected ErrKeyLimitReached", err)
+	}
+	limits := r.KeyLimits()
+	if len(limits) != 1 || !limits[0].PubKey.Equals(v.key) || limits[0].Limit != 2 || limits[0].Entries != 2 {
+		t.Fatal("unexpected limits", limits)
+	}
+
+	// Existing entries can still be updated and other keys are unaffected.
+	rv.Revision++
+	rv = rv.Sign(sk)
+	_, err = r.Update(rv, v.key, v.expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rvOther, vOther, _ := randomValue(0)
+	_, err = r.Update(rvOther, vOther.key, vOther.expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pruning the entry with the low expiry frees up a slot.
+	_, err = r.Prune(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits := r.KeyLimits(); limits[0].Entries != 1 {
+		t.Fatal("wrong number of entries", limits[0].Entries)
+	}
+	_, err = newEntry(v.expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Removing the limit allows for registering more entries.
+	r.RemoveKeyLimit(v.key)
+	if len(r.KeyLimits()) != 0 {
+		t.Fatal("limit wasn't removed")
+	}
+	_, err = newEntry(v.expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// After reloading the registry, the entries should be counted again.
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err = New(registryPath, testingDefaultMaxEntries, types.SiaPublicKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.SetKeyLimit(v.key, 3)
+	_, err = newEntry(v.expiry)
+	if !errors.Contains(err, ErrKeyLimitReached) {
+		t.Fatal("expected ErrKeyLimitReached", err)
+	}
+}
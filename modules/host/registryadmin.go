@@ -0,0 +1,165 @@
+package host
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/host/registry"
+	"go.sia.tech/siad/types"
+)
+
+// loadRegistryKeyLimits applies the registry key limits set by the host
+// operator to the registry.
+func (h *Host) loadRegistryKeyLimits() error {
+	return h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketRegistryKeyLimits).ForEach(func(k, v []byte) error {
+			var spk types.SiaPublicKey
+			if err := spk.LoadString(string(k)); err != nil {
+				return errors.AddContext(err, "failed to load public key of registry key limit")
+			}
+			var limit uint64
+			if err := encoding.Unmarshal(v, &limit); err != nil {
+				return errors.AddContext(err, "failed to unmarshal registry key limit")
+			}
+			h.staticRegistry.SetKeyLimit(spk, limit)
+			return nil
+		})
+	})
+}
+
+// sortedRegistryEntries returns the entries of the registry sorted by public
+// key and tweak.
+func sortedRegistryEntries(r *registry.Registry) []registry.Entry {
+	entries := r.Entries()
+	keys := make([]string, len(entries))
+	for i := range entries {
+		keys[i] = entries[i].PubKey.String() + entries[i].Value.Tweak.String()
+	}
+	sort.Sort(registryEntriesByKey{entries, keys})
+	return entries
+}
+
+// registryEntriesByKey sorts registry entries by their precomputed keys.
+type registryEntriesByKey struct {
+	entries []registry.Entry
+	keys    []string
+}
+
+func (s registryEntriesByKey) Len() int           { return len(s.entries) }
+func (s registryEntriesByKey) Less(i, j int) bool { return s.keys[i] < s.keys[j] }
+func (s registryEntriesByKey) Swap(i, j int) {
+	s.entries[i], s.entries[j] = s.entries[j], s.entries[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+// hostRegistryEntry converts an entry of the registry into a
+// modules.HostRegistryEntry.
+func hostRegistryEntry(e registry.Entry) modules.HostRegistryEntry {
+	return modules.HostRegistryEntry{
+		PublicKey: e.PubKey,
+		Tweak:     e.Value.Tweak,
+		Revision:  e.Value.Revision,
+		Expiry:    e.Expiry,
+		Size:      uint64(len(e.Value.Data)),
+		Type:      e.Value.Type,
+	}
+}
+
+// ExportRegistry writes all entries of the registry to the provided writer in
+// a json encoded format which doesn't depend on the layout of the registry on
+// disk.
+func (h *Host) ExportRegistry(w io.Writer) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	entries := sortedRegistryEntries(h.staticRegistry)
+	export := modules.HostRegistryExport{
+		Header:  modules.HostRegistryExportMetadata.Header,
+		Version: modules.HostRegistryExportMetadata.Version,
+		Entries: make([]modules.HostRegistryExportEntry, 0, len(entries)),
+	}
+	for _, e := range entries {
+		export.Entries = append(export.Entries, modules.HostRegistryExportEntry{
+			HostRegistryEntry: hostRegistryEntry(e),
+			Data:              e.Value.Data,
+			Signature:         e.Value.Signature,
+		})
+	}
+	return errors.AddContext(json.NewEncoder(w).Encode(export), "failed to write registry export")
+}
+
+// RegistryEntries returns the entries of the registry within the provided
+// range, sorted by public key and tweak, together with the total number of
+// entries. A limit of 0 returns all entries after the offset.
+func (h *Host) RegistryEntries(offset, limit uint64) ([]modules.HostRegistryEntry, uint64) {
+	entries := sortedRegistryEntries(h.staticRegistry)
+	total := uint64(len(entries))
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	page := make([]modules.HostRegistryEntry, 0, end-offset)
+	for _, e := range entries[offset:end] {
+		page = append(page, hostRegistryEntry(e))
+	}
+	return page, total
+}
+
+// RegistryKeyLimits returns the limits of registry entries set for public
+// keys.
+func (h *Host) RegistryKeyLimits() []modules.HostRegistryKeyLimit {
+	keyLimits := h.staticRegistry.KeyLimits()
+	limits := make([]modules.HostRegistryKeyLimit, 0, len(keyLimits))
+	for _, l := range keyLimits {
+		limits = append(limits, modules.HostRegistryKeyLimit{
+			PublicKey: l.PubKey,
+			Limit:     l.Limit,
+			Entries:   l.Entries,
+		})
+	}
+	return limits
+}
+
+// RemoveRegistryKeyLimit removes the limit of registry entries the public key
+// can register.
+func (h *Host) RemoveRegistryKeyLimit(pubKey types.SiaPublicKey) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketRegistryKeyLimits).Delete([]byte(pubKey.String()))
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to remove registry key limit")
+	}
+	h.staticRegistry.RemoveKeyLimit(pubKey)
+	return nil
+}
+
+// SetRegistryKeyLimit limits the number of registry entries the public key can
+// register. Entries registered before the limit was set are kept.
+func (h *Host) SetRegistryKeyLimit(pubKey types.SiaPublicKey, limit uint64) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketRegistryKeyLimits).Put([]byte(pubKey.String()), encoding.Marshal(limit))
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to persist registry key limit")
+	}
+	h.staticRegistry.SetKeyLimit(pubKey, limit)
+	return nil
+}
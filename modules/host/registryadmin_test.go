@@ -0,0 +1,144 @@
+package host
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/host/registry"
+)
+
+// TestRegistryAdministration tests browsing and exporting the registry as well
+// as limiting the number of entries of a public key.
+func TestRegistryAdministration(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Add some space to the host's registry.
+	is := ht.host.InternalSettings()
+	is.RegistrySize += (modules.RegistryEntrySize * 100)
+	if err := ht.host.SetInternalSettings(is); err != nil {
+		t.Fatal(err)
+	}
+
+	// Register an entry and limit its key to 2 entries.
+	rv, spk, sk := randomRegistryValue()
+	expiry := ht.host.BlockHeight() + 100
+	if _, err := ht.host.RegistryUpdate(rv, spk, expiry); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.SetRegistryKeyLimit(spk, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// The key should be able to register one more entry.
+	update := func() error {
+		var tweak crypto.Hash
+		fastrand.Read(tweak[:])
+		rv := modules.NewRegistryValue(tweak, fastrand.Bytes(10), 0, modules.RegistryTypeWithoutPubkey).Sign(sk)
+		_, err := ht.host.RegistryUpdate(rv, spk, expiry)
+		return err
+	}
+	if err := update(); err != nil {
+		t.Fatal(err)
+	}
+	if err := update(); !errors.Contains(err, registry.ErrKeyLimitReached) {
+		t.Fatal("expected ErrKeyLimitReached", err)
+	}
+
+	// Other keys are unaffected.
+	rvOther, spkOther, _ := randomRegistryValue()
+	if _, err := ht.host.RegistryUpdate(rvOther, spkOther, expiry); err != nil {
+		t.Fatal(err)
+	}
+
+	// Page through the entries.
+	all, total := ht.host.RegistryEntries(0, 0)
+	if total != 3 || len(all) != 3 {
+		t.Fatal("wrong number of entries", total, len(all))
+	}
+	var paged []modules.HostRegistryEntry
+	for offset := uint64(0); offset < total; offset += 2 {
+		page, _ := ht.host.RegistryEntries(offset, 2)
+		paged = append(paged, page...)
+	}
+	if len(paged) != len(all) {
+		t.Fatal("wrong number of paged entries", len(paged))
+	}
+	for i := range all {
+		if !all[i].PublicKey.Equals(paged[i].PublicKey) || all[i].Tweak != paged[i].Tweak {
+			t.Fatal("pages don't match", i)
+		}
+	}
+	if page, total := ht.host.RegistryEntries(10, 2); len(page) != 0 || total != 3 {
+		t.Fatal("expected empty page", len(page), total)
+	}
+	for _, e := range all {
+		if e.PublicKey.Equals(spk) && e.Tweak == rv.Tweak {
+			if e.Revision != rv.Revision || e.Expiry != expiry || e.Size != uint64(len(rv.Data)) {
+				t.Fatal("wrong entry", e)
+			}
+		}
+	}
+
+	// Export the registry.
+	var buf bytes.Buffer
+	if err := ht.host.ExportRegistry(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var export modules.HostRegistryExport
+	if err := json.Unmarshal(buf.Bytes(), &export); err != nil {
+		t.Fatal(err)
+	}
+	if export.Header != modules.HostRegistryExportMetadata.Header || len(export.Entries) != 3 {
+		t.Fatal("unexpected export", export.Header, len(export.Entries))
+	}
+	for _, e := range export.Entries {
+		erv := modules.NewSignedRegistryValue(e.Tweak, e.Data, e.Revision, e.Signature, e.Type)
+		if err := erv.Verify(e.PublicKey.ToPublicKey()); err != nil {
+			t.Fatal("exported entry can't be verified", err)
+		}
+	}
+
+	// The limit should survive a restart.
+	if err := reloadHost(ht); err != nil {
+		t.Fatal(err)
+	}
+	limits := ht.host.RegistryKeyLimits()
+	if len(limits) != 1 || !limits[0].PublicKey.Equals(spk) || limits[0].Limit != 2 || limits[0].Entries != 2 {
+		t.Fatal("unexpected limits", limits)
+	}
+	if err := update(); !errors.Contains(err, registry.ErrKeyLimitReached) {
+		t.Fatal("expected ErrKeyLimitReached", err)
+	}
+
+	// Removing the limit allows the key to register more entries.
+	if err := ht.host.RemoveRegistryKeyLimit(spk); err != nil {
+		t.Fatal(err)
+	}
+	if err := update(); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloadHost(ht); err != nil {
+		t.Fatal(err)
+	}
+	if limits := ht.host.RegistryKeyLimits(); len(limits) != 0 {
+		t.Fatal("limit wasn't removed", limits)
+	}
+}
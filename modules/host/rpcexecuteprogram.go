@@ -17,6 +17,12 @@ import (
 	"go.sia.tech/siad/types"
 )
 
+var (
+	// errStoreSectorRequiresAccount is returned if a program stores sectors
+	// without a contract and isn't paid from an ephemeral account.
+	errStoreSectorRequiresAccount = errors.New("storing sectors without a contract requires payment from an ephemeral account")
+)
+
 const (
 	// maxRPCExecuteProgramRequestSize is the max size we allocate for
 	// reading a RPCExecuteProgramRequest.
@@ -81,6 +87,26 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 	fcid, instructions, dataLength := epr.FileContractID, epr.Program, epr.ProgramDataLength
 	program := modules.Program(instructions)
 
+	// Sectors stored without a contract need to be paid from an ephemeral
+	// account.
+	if fcid == (types.FileContractID{}) && program.StoresSectors() && !paidByEphemeralAccount(pd) {
+		return errStoreSectorRequiresAccount
+	}
+
+	// Make sure the renter is within its quotas. The program counts towards
+	// the quotas of both the paying account and the contract it modifies.
+	quotaKeys := []string{accountQuotaKey(pd.AccountID())}
+	if fcid != (types.FileContractID{}) {
+		quotaKeys = append(quotaKeys, contractQuotaKey(fcid))
+	}
+	releaseQuota, err := h.managedAcquireRenterQuota(true, quotaKeys...)
+	if err != nil {
+		return errors.AddContext(err, "renter quota exceeded")
+	}
+	defer func() {
+		releaseQuota(bandwidthLimit.Uploaded() + bandwidthLimit.Downloaded())
+	}()
+
 	// If the program isn't readonly we need to acquire a lock on the storage
 	// obligation.
 	readonly := program.ReadOnly()
@@ -145,6 +171,7 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 	}
 
 	// Handle outputs.
+	var prevExecutionCost, registryRevenue types.Currency
 	executionFailed := false
 	numOutputs := 0
 	var output mdm.Output
@@ -194,8 +221,17 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 		}
 
 		instructionSpecifier := program[numOutputs-1].Specifier
-		readInstruction := instructionSpecifier == modules.SpecifierReadOffset || instructionSpecifier == modules.SpecifierReadSector
+		readInstruction := instructionSpecifier == modules.SpecifierReadOffset || instructionSpecifier == modules.SpecifierReadRange || instructionSpecifier == modules.SpecifierReadSector
 		updateRegistryInstruction := instructionSpecifier == modules.SpecifierUpdateRegistry
+
+		// Keep track of the revenue from registry instructions. The execution
+		// cost of an output is the total cost of the program up to and
+		// including the instruction.
+		readRegistryInstruction := instructionSpecifier == modules.SpecifierReadRegistry || instructionSpecifier == modules.SpecifierReadRegistryEID
+		if (readRegistryInstruction || updateRegistryInstruction) && output.ExecutionCost.Cmp(prevExecutionCost) > 0 {
+			registryRevenue = registryRevenue.Add(output.ExecutionCost.Sub(prevExecutionCost))
+		}
+		prevExecutionCost = output.ExecutionCost
 		if (readInstruction || updateRegistryInstruction) && h.dependencies.Disrupt("CorruptMDMOutput") {
 			// Replace output with same amount of random data.
 			fastrand.Read(output.Output)
@@ -237,6 +273,14 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 		}
 	}
 
+	// Update the registry revenue. The execution cost is never refunded, so
+	// the revenue is earned even if the execution failed.
+	if !registryRevenue.IsZero() {
+		h.mu.Lock()
+		h.financialMetrics.RegistryRevenue = h.financialMetrics.RegistryRevenue.Add(registryRevenue)
+		h.mu.Unlock()
+	}
+
 	// Sanity check that we received at least 1 output.
 	if numOutputs == 0 {
 		err := errors.New("program returned 0 outputs - should never happen")
@@ -1658,3 +1658,86 @@ func testExecuteReadRegistryProgram(t *testing.T, regEntryType modules.RegistryE
 		t.Fatal(err)
 	}
 }
+
+// TestExecuteStoreSectorProgramPayment verifies that a program which stores a
+// sector without a contract is only executed if it is paid from an ephemeral
+// account.
+func TestExecuteStoreSectorProgramPayment(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// create a testing pair.
+	rhp, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := rhp.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	// create the 'StoreSector' program.
+	pt, err := rhp.managedFetchPriceTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sectorData := fastrand.Bytes(int(modules.SectorSize))
+	pb := modules.NewProgramBuilder(pt, 0)
+	err = pb.AddStoreSectorInstruction(sectorData, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	program, data := pb.Program()
+	programCost, _, _ := pb.Cost(true)
+
+	// prepare the request without a contract.
+	epr := modules.RPCExecuteProgramRequest{
+		Program:           program,
+		ProgramDataLength: uint64(len(data)),
+	}
+
+	// Paying by contract should be rejected.
+	err = func() (err error) {
+		stream := rhp.managedNewStream()
+		defer func() {
+			err = errors.Compose(err, stream.Close())
+		}()
+		err = modules.RPCWriteAll(stream, modules.RPCExecuteProgram, pt.UID)
+		if err != nil {
+			return err
+		}
+		err = rhp.managedPayByContract(stream, programCost, rhp.staticAccountID)
+		if err != nil {
+			return err
+		}
+		err = modules.RPCWrite(stream, epr)
+		if err != nil {
+			return err
+		}
+		var ct modules.MDMCancellationToken
+		return modules.RPCRead(stream, &ct)
+	}()
+	if err == nil || !strings.Contains(err.Error(), errStoreSectorRequiresAccount.Error()) {
+		t.Fatal("expected errStoreSectorRequiresAccount but got", err)
+	}
+
+	// Paying from an ephemeral account should work. The account already
+	// received the refund of the rejected program.
+	budget := rhp.staticHT.host.managedInternalSettings().MaxEphemeralAccountBalance.Div64(4)
+	_, err = rhp.managedFundEphemeralAccount(budget.Add(pt.FundAccountCost), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resps, _, err := rhp.managedExecuteProgram(epr, data, budget, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := crypto.MerkleRoot(sectorData)
+	if len(resps) != 1 || resps[0].Error != nil || !bytes.Equal(resps[0].Output, root[:]) {
+		t.Fatal("unexpected response", resps)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
+	connmonitor "gitlab.com/NebulousLabs/monitor"
 	"golang.org/x/crypto/chacha20poly1305"
 
 	"gitlab.com/NebulousLabs/encoding"
@@ -96,6 +97,10 @@ func (h *Host) managedRPCLoop(conn net.Conn) error {
 		build.Critical("could not create cipher")
 		return err
 	}
+	// monitor the session's bandwidth to enforce the renter's bandwidth quota
+	sessionMonitor := connmonitor.NewMonitor()
+	conn = connmonitor.NewMonitoredConn(conn, sessionMonitor)
+
 	// create the session object
 	s := &rpcSession{
 		conn: conn,
@@ -120,6 +125,11 @@ func (h *Host) managedRPCLoop(conn net.Conn) error {
 	}()
 
 	// enter RPC loop
+	quotaRPCs := map[types.Specifier]bool{
+		modules.RPCLoopRead:        true,
+		modules.RPCLoopSectorRoots: true,
+		modules.RPCLoopWrite:       true,
+	}
 	rpcs := map[types.Specifier]func(*rpcSession) error{
 		modules.RPCLoopLock:               h.managedRPCLoopLock,
 		modules.RPCLoopUnlock:             h.managedRPCLoopUnlock,
@@ -140,9 +150,27 @@ func (h *Host) managedRPCLoop(conn net.Conn) error {
 		} else if id == modules.RPCLoopExit {
 			return nil
 		}
-		if rpcFn, ok := rpcs[id]; !ok {
+		rpcFn, ok := rpcs[id]
+		if !ok {
 			return errors.New("invalid or unknown RPC ID: " + id.String())
-		} else if err := rpcFn(s); err != nil {
+		}
+
+		// RPCs which operate on a locked contract count towards the
+		// contract's quota.
+		var releaseQuota func(uint64)
+		if quotaRPCs[id] && len(s.so.OriginTransactionSet) != 0 {
+			releaseQuota, err = h.managedAcquireRenterQuota(false, contractQuotaKey(s.so.id()))
+			if err != nil {
+				return errors.Compose(err, s.writeError(err))
+			}
+		}
+		readBefore, writtenBefore := sessionMonitor.Counts()
+		err = rpcFn(s)
+		if releaseQuota != nil {
+			read, written := sessionMonitor.Counts()
+			releaseQuota(read - readBefore + written - writtenBefore)
+		}
+		if err != nil {
 			return extendErr("incoming RPC"+id.String()+" failed: ", err)
 		}
 	}
@@ -598,6 +598,11 @@ func (h *Host) queueActionItem(height types.BlockHeight, id types.FileContractID
 // creating a new, empty file contract or when renewing an existing file
 // contract.
 func (h *Host) managedAddStorageObligation(so storageObligation) error {
+	// Obligations can't be added while the host is being migrated.
+	if h.staticMigrating() {
+		return errHostMigrating
+	}
+
 	var soid types.FileContractID
 	err := func() error {
 		h.mu.Lock()
@@ -686,6 +691,11 @@ func (h *Host) managedAddStorageObligation(so storageObligation) error {
 // managedAddRenewedStorageObligation adds a new obligation to the host and
 // modifies the old obligation from which it was renewed from atomically.
 func (h *Host) managedAddRenewedStorageObligation(oldSO, newSO storageObligation) error {
+	// Obligations can't be renewed while the host is being migrated.
+	if h.staticMigrating() {
+		return errHostMigrating
+	}
+
 	// Sanity check - obligations should be under lock while being modified.
 	h.mu.Lock()
 	_, exists1 := h.lockedStorageObligations[oldSO.id()]
@@ -843,6 +853,11 @@ func (h *Host) updateFinancialMetricsUpdateSO(oldSO, newSO storageObligation) {
 // will need to appear in 'sectorsRemoved' multiple times. Same with
 // 'sectorsGained'.
 func (h *Host) managedModifyStorageObligation(so storageObligation, sectorsRemoved []crypto.Hash, sectorsGained map[crypto.Hash][]byte) error {
+	// Obligations can't be modified while the host is being migrated.
+	if h.staticMigrating() {
+		return errHostMigrating
+	}
+
 	// Sanity check - all of the sector data should be modules.SectorSize
 	for _, data := range sectorsGained {
 		if uint64(len(data)) != modules.SectorSize {
@@ -1066,10 +1081,17 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 	// obligation status is updated so that the user can see how the obligation
 	// ended up, and the sector roots are removed because they are large
 	// objects with little purpose once storage proofs are no longer needed.
+	// The same goes for the history of storage proof attempts and the alert of
+	// a failed attempt.
 	h.financialMetrics.ContractCount--
 	so.ObligationStatus = sos
 	so.SectorRoots = nil
+	h.staticAlerter.UnregisterAlert(modules.AlertIDHostStorageProof(so.id().String()))
 	return h.db.Update(func(tx *bolt.Tx) error {
+		soid := so.id()
+		if err := tx.Bucket(bucketStorageProofAttempts).Delete(soid[:]); err != nil {
+			return err
+		}
 		return putStorageObligation(tx, so)
 	})
 }
@@ -1284,6 +1306,24 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		// return
 	}
 
+	// Verify that the host will be able to provide the storage proof before
+	// the proof window opens, giving the host operator time to fix missing or
+	// corrupt sectors. Failed verifications are retried until the window
+	// opens.
+	if !so.ProofConfirmed && so.requiresProof() && blockHeight >= so.expiration()-revisionSubmissionBuffer && blockHeight < so.expiration() && !h.managedPreflightSucceeded(soid) {
+		err := h.managedPreflightStorageProof(so)
+		if err != nil && blockHeight+resubmissionTimeout < so.expiration() {
+			h.mu.Lock()
+			err = h.queueActionItem(blockHeight+resubmissionTimeout, so.id())
+			h.mu.Unlock()
+			if err != nil {
+				h.log.Printf("contract %s action: Error queuing action item: %s", soid, err)
+			}
+		} else if err == nil {
+			h.log.Debugf("contract %s action: storage proof pre-flight verification succeeded", soid)
+		}
+	}
+
 	// Check whether a storage proof is ready to be provided, and whether it
 	// has been accepted. Check for death.
 	if !so.ProofConfirmed && blockHeight >= so.expiration()+resubmissionTimeout {
@@ -1323,10 +1363,13 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 			return
 		}
 
-		// Build StorageProof.
-		sp, err := h.managedBuildStorageProof(so, segmentIndex)
-		if err != nil {
-			h.log.Printf("contract %s action: Host encountered an error when building the storage proof: %s", soid, err)
+		// Build and verify the StorageProof. A proof which can't be verified is
+		// still submitted since the consensus set might know about a more
+		// recent revision of the contract than the host.
+		sp, sectorRoot, proofErr := h.managedBuildVerifiedStorageProof(so, segmentIndex)
+		if proofErr != nil && !errors.Contains(proofErr, errInvalidStorageProof) {
+			h.log.Printf("contract %s action: Host encountered an error when building the storage proof: %s", soid, proofErr)
+			h.managedRecordStorageProofAttempt(soid, modules.HostStorageProofAttemptProof, segmentIndex, sectorRoot, proofErr)
 			return
 		}
 
@@ -1363,10 +1406,12 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		err = h.tpool.AcceptTransactionSet(storageProofSet)
 		if err != nil {
 			h.log.Printf("contract %s action: failed to build storage proof trransaction: Host unable to submit storage proof transaction to transaction pool: %s", soid, err)
+			h.managedRecordStorageProofAttempt(soid, modules.HostStorageProofAttemptProof, segmentIndex, sectorRoot, errors.AddContext(err, "failed to submit storage proof"))
 			builder.Drop()
 			return
 		}
 		so.TransactionFeesAdded = so.TransactionFeesAdded.Add(requiredFee)
+		h.managedRecordStorageProofAttempt(soid, modules.HostStorageProofAttemptProof, segmentIndex, sectorRoot, proofErr)
 
 		// Queue another action item to check whether the storage proof
 		// got confirmed.
@@ -1479,6 +1524,13 @@ func (h *Host) StorageObligation(obligationID types.FileContractID) (modules.Sto
 	if err != nil {
 		return modules.StorageObligation{}, errors.AddContext(err, "failed to fetch storage obligation")
 	}
-
-	return so.StorageObligation(), nil
+	mso := so.StorageObligation()
+	err = h.db.View(func(tx *bolt.Tx) error {
+		mso.ProofAttempts, err = getStorageProofAttempts(tx, obligationID)
+		return err
+	})
+	if err != nil {
+		return modules.StorageObligation{}, errors.AddContext(err, "failed to fetch storage proof attempts")
+	}
+	return mso, nil
 }
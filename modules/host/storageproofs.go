@@ -0,0 +1,189 @@
+package host
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errCorruptSector is returned when the data of a sector doesn't match its
+	// root.
+	errCorruptSector = errors.New("sector data doesn't match its root")
+
+	// errInvalidStorageProof is returned when a storage proof built by the
+	// host doesn't verify against the merkle root of the contract.
+	errInvalidStorageProof = errors.New("storage proof doesn't match the merkle root of the contract")
+
+	// errSectorRootsMismatch is returned when the sector roots of a storage
+	// obligation don't add up to the merkle root or file size of the contract.
+	errSectorRootsMismatch = errors.New("sector roots don't match the merkle root or file size of the contract")
+)
+
+// getStorageProofAttempts returns the history of storage proof attempts of
+// the storage obligation with the given id.
+func getStorageProofAttempts(tx *bolt.Tx, soid types.FileContractID) ([]modules.HostStorageProofAttempt, error) {
+	v := tx.Bucket(bucketStorageProofAttempts).Get(soid[:])
+	if v == nil {
+		return nil, nil
+	}
+	var attempts []modules.HostStorageProofAttempt
+	err := json.Unmarshal(v, &attempts)
+	return attempts, errors.AddContext(err, "failed to unmarshal storage proof attempts")
+}
+
+// verifyStorageProof checks whether the storage proof for the segment with the
+// given index is valid for the obligation, using the same rules as the
+// consensus set.
+func verifyStorageProof(so storageObligation, sp types.StorageProof, segmentIndex uint64) bool {
+	fileSize := so.fileSize()
+	leaves := crypto.CalculateLeaves(fileSize)
+	segmentLen := uint64(crypto.SegmentSize)
+	if segmentIndex == leaves-1 {
+		segmentLen = fileSize % crypto.SegmentSize
+	}
+	if segmentLen == 0 {
+		segmentLen = uint64(crypto.SegmentSize)
+	}
+	verified := crypto.VerifySegment(sp.Segment[:segmentLen], sp.HashSet, leaves, segmentIndex, so.merkleRoot())
+	return verified || fileSize == 0
+}
+
+// managedRecordStorageProofAttempt adds an attempt to the history of the
+// storage obligation. A failed attempt registers a critical alert naming the
+// obligation and the sector, which is unregistered again by the next
+// successful attempt.
+func (h *Host) managedRecordStorageProofAttempt(soid types.FileContractID, attemptType string, segmentIndex uint64, sectorRoot crypto.Hash, attemptErr error) {
+	h.mu.RLock()
+	attempt := modules.HostStorageProofAttempt{
+		BlockHeight:  h.blockHeight,
+		Timestamp:    time.Now(),
+		Type:         attemptType,
+		SegmentIndex: segmentIndex,
+		SectorRoot:   sectorRoot,
+		Success:      attemptErr == nil,
+	}
+	h.mu.RUnlock()
+
+	alertID := modules.AlertIDHostStorageProof(soid.String())
+	if attemptErr != nil {
+		attempt.Error = attemptErr.Error()
+		cause := fmt.Sprintf("storage obligation %v", soid)
+		if sectorRoot != (crypto.Hash{}) {
+			cause += fmt.Sprintf(", sector %v", sectorRoot)
+		}
+		cause += ": " + attemptErr.Error()
+		h.log.Printf("contract %s action: %s storage proof attempt failed: %s", soid, attemptType, cause)
+		h.staticAlerter.RegisterAlert(alertID, AlertMSGHostStorageProof, cause, modules.SeverityCritical)
+	} else {
+		h.staticAlerter.UnregisterAlert(alertID)
+	}
+
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		attempts, err := getStorageProofAttempts(tx, soid)
+		if err != nil {
+			return err
+		}
+		attempts = append(attempts, attempt)
+		if len(attempts) > maxStorageProofAttempts {
+			attempts = attempts[len(attempts)-maxStorageProofAttempts:]
+		}
+		v, err := json.Marshal(attempts)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(bucketStorageProofAttempts).Put(soid[:], v)
+	})
+	if err != nil {
+		h.log.Printf("contract %s action: failed to record storage proof attempt: %s", soid, err)
+	}
+}
+
+// managedPreflightSucceeded returns whether the last pre-flight verification
+// of the storage obligation succeeded.
+func (h *Host) managedPreflightSucceeded(soid types.FileContractID) bool {
+	var succeeded bool
+	err := h.db.View(func(tx *bolt.Tx) error {
+		attempts, err := getStorageProofAttempts(tx, soid)
+		for i := len(attempts) - 1; i >= 0; i-- {
+			if attempts[i].Type == modules.HostStorageProofAttemptPreflight {
+				succeeded = attempts[i].Success
+				break
+			}
+		}
+		return err
+	})
+	return err == nil && succeeded
+}
+
+// managedPreflightStorageProof verifies that the host is able to build the
+// storage proof of the obligation before its proof window opens. Since the
+// segment to prove is only selected by the block preceding the window, a
+// random sample of at most maxPreflightSectors sectors of the obligation is
+// read and verified against their roots. The roots are also verified against
+// the merkle root of the contract. The result is added to the obligation's
+// history of storage proof attempts.
+func (h *Host) managedPreflightStorageProof(so storageObligation) error {
+	sectorRoot, err := func() (crypto.Hash, error) {
+		if uint64(len(so.SectorRoots))*modules.SectorSize != so.fileSize() || cachedMerkleRoot(so.SectorRoots) != so.merkleRoot() {
+			return crypto.Hash{}, errSectorRootsMismatch
+		}
+		unique := make(map[crypto.Hash]struct{}, len(so.SectorRoots))
+		var roots []crypto.Hash
+		for _, root := range so.SectorRoots {
+			if _, exists := unique[root]; !exists {
+				unique[root] = struct{}{}
+				roots = append(roots, root)
+			}
+		}
+		if len(roots) > maxPreflightSectors {
+			fastrand.Shuffle(len(roots), func(i, j int) {
+				roots[i], roots[j] = roots[j], roots[i]
+			})
+			roots = roots[:maxPreflightSectors]
+		}
+		for _, root := range roots {
+			data, err := h.ReadSector(root)
+			if err != nil {
+				return root, errors.AddContext(err, "failed to read sector")
+			}
+			if crypto.MerkleRoot(data) != root {
+				return root, errCorruptSector
+			}
+		}
+		return crypto.Hash{}, nil
+	}()
+	h.managedRecordStorageProofAttempt(so.id(), modules.HostStorageProofAttemptPreflight, 0, sectorRoot, err)
+	return err
+}
+
+// managedBuildVerifiedStorageProof builds the storage proof for the segment
+// with the given index and verifies it before it is submitted. Since reading
+// a sector can fail temporarily, e.g. when a disk is busy, the proof is
+// rebuilt once if the first attempt fails. If the proof was built but
+// couldn't be verified, it is returned together with errInvalidStorageProof.
+func (h *Host) managedBuildVerifiedStorageProof(so storageObligation, segmentIndex uint64) (sp types.StorageProof, sectorRoot crypto.Hash, err error) {
+	if sectorIndex := segmentIndex / (modules.SectorSize / crypto.SegmentSize); sectorIndex < uint64(len(so.SectorRoots)) {
+		sectorRoot = so.SectorRoots[sectorIndex]
+	}
+	for i := 0; i < 2; i++ {
+		sp, err = h.managedBuildStorageProof(so, segmentIndex)
+		if err == nil && !verifyStorageProof(so, sp, segmentIndex) {
+			err = errInvalidStorageProof
+		}
+		if err == nil {
+			return sp, sectorRoot, nil
+		}
+	}
+	if errors.Contains(err, errInvalidStorageProof) {
+		return sp, sectorRoot, err
+	}
+	return types.StorageProof{}, sectorRoot, err
+}
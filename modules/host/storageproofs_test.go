@@ -0,0 +1,167 @@
+package host
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestStorageProofPreflight tests verifying the storage proof of an obligation
+// ahead of its proof window and recording the attempts.
+func TestStorageProofPreflight(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	// Create a storage obligation with two sectors.
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.managedLockStorageObligation(so.id())
+	err = h.managedAddStorageObligation(so)
+	h.managedUnlockStorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	root1, data1 := randSector()
+	root2, data2 := randSector()
+	so.SectorRoots = []crypto.Hash{root1, root2}
+	validPayouts, missedPayouts := so.payouts()
+	so.RevisionTransactionSet = []types.Transaction{{
+		FileContractRevisions: []types.FileContractRevision{{
+			ParentID:              so.id(),
+			NewRevisionNumber:     1,
+			NewFileSize:           2 * modules.SectorSize,
+			NewFileMerkleRoot:     cachedMerkleRoot(so.SectorRoots),
+			NewWindowStart:        so.expiration(),
+			NewWindowEnd:          so.proofDeadline(),
+			NewValidProofOutputs:  validPayouts,
+			NewMissedProofOutputs: missedPayouts,
+			NewUnlockHash:         types.UnlockConditions{}.UnlockHash(),
+		}},
+	}}
+	h.managedLockStorageObligation(so.id())
+	err = h.managedModifyStorageObligation(so, nil, map[crypto.Hash][]byte{root1: data1, root2: data2})
+	h.managedUnlockStorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The pre-flight verification should succeed.
+	if err := h.managedPreflightStorageProof(so); err != nil {
+		t.Fatal(err)
+	}
+	if !h.managedPreflightSucceeded(so.id()) {
+		t.Fatal("pre-flight should have succeeded")
+	}
+
+	// Remove the second sector. The verification should fail, naming the
+	// sector, and a critical alert should be registered.
+	if err := h.RemoveSector(root2); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.managedPreflightStorageProof(so); err == nil {
+		t.Fatal("pre-flight should fail for missing sector")
+	}
+	if h.managedPreflightSucceeded(so.id()) {
+		t.Fatal("pre-flight shouldn't have succeeded")
+	}
+	crit, _, _, _ := h.Alerts()
+	var found bool
+	for _, alert := range crit {
+		found = found || alert.Msg == AlertMSGHostStorageProof
+	}
+	if !found {
+		t.Fatal("expected critical alert", crit)
+	}
+	mso, err := h.StorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mso.ProofAttempts) != 2 {
+		t.Fatal("expected 2 attempts", len(mso.ProofAttempts))
+	}
+	if attempt := mso.ProofAttempts[1]; attempt.Success || attempt.SectorRoot != root2 || attempt.Type != modules.HostStorageProofAttemptPreflight || attempt.Error == "" {
+		t.Fatal("unexpected attempt", attempt)
+	}
+
+	// Proofs for segments of the intact sector can still be built, proofs
+	// for the missing sector can't.
+	segmentsPerSector := modules.SectorSize / crypto.SegmentSize
+	segmentIndex := fastrand.Uint64n(segmentsPerSector)
+	if _, sectorRoot, err := h.managedBuildVerifiedStorageProof(so, segmentIndex); err != nil || sectorRoot != root1 {
+		t.Fatal("failed to build proof", err, sectorRoot)
+	}
+	if _, sectorRoot, err := h.managedBuildVerifiedStorageProof(so, segmentsPerSector+segmentIndex); err == nil || sectorRoot != root2 {
+		t.Fatal("expected proof for missing sector to fail", sectorRoot)
+	}
+
+	// A proof which doesn't match the contract should be rejected.
+	corrupt := so
+	corrupt.RevisionTransactionSet = []types.Transaction{{
+		FileContractRevisions: []types.FileContractRevision{so.RevisionTransactionSet[0].FileContractRevisions[0]},
+	}}
+	corrupt.RevisionTransactionSet[0].FileContractRevisions[0].NewFileMerkleRoot = crypto.Hash{}
+	if _, _, err := h.managedBuildVerifiedStorageProof(corrupt, segmentIndex); !errors.Contains(err, errInvalidStorageProof) {
+		t.Fatal("expected errInvalidStorageProof", err)
+	}
+
+	// Restore the sector. The verification should succeed again and the alert
+	// should be removed.
+	if err := h.AddSector(root2, data2); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.managedPreflightStorageProof(so); err != nil {
+		t.Fatal(err)
+	}
+	crit, _, _, _ = h.Alerts()
+	for _, alert := range crit {
+		if alert.Msg == AlertMSGHostStorageProof {
+			t.Fatal("alert should have been unregistered")
+		}
+	}
+
+	// Fail the verification again and remove the obligation. Its history of
+	// attempts and the alert should be removed with it.
+	if err := h.RemoveSector(root2); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.managedPreflightStorageProof(so); err == nil {
+		t.Fatal("pre-flight should fail for missing sector")
+	}
+	h.mu.Lock()
+	err = h.removeStorageObligation(so, obligationFailed)
+	h.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mso, err = h.StorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mso.ProofAttempts) != 0 {
+		t.Fatal("expected attempts to be removed", len(mso.ProofAttempts))
+	}
+	crit, _, _, _ = h.Alerts()
+	for _, alert := range crit {
+		if alert.Msg == AlertMSGHostStorageProof {
+			t.Fatal("alert should have been unregistered")
+		}
+	}
+}
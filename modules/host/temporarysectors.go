@@ -0,0 +1,91 @@
+package host
+
+import (
+	"encoding/binary"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// StoreSector stores a sector that is not part of a file contract until the
+// provided expiry height. The sector is added to the storage manager like any
+// other sector and its expiry is tracked in the host's database. Once the
+// expiry height is reached, the sector is removed again.
+func (h *Host) StoreSector(sectorRoot crypto.Hash, sectorData []byte, expiry types.BlockHeight) error {
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	// Add the sector to the storage manager first. If the expiry can't be
+	// tracked afterwards, the sector is removed again.
+	err = h.AddSector(sectorRoot, sectorData)
+	if err != nil {
+		return errors.AddContext(err, "failed to add sector")
+	}
+	h.mu.Lock()
+	err = h.queueTemporarySector(expiry, sectorRoot)
+	h.mu.Unlock()
+	if err != nil {
+		err = errors.AddContext(err, "failed to track sector expiry")
+		return errors.Compose(err, h.RemoveSector(sectorRoot))
+	}
+	return nil
+}
+
+// queueTemporarySector adds a sector root to the list of temporary sectors
+// which expire at the provided height.
+func (h *Host) queueTemporarySector(height types.BlockHeight, root crypto.Hash) error {
+	// Sanity check - the sector should expire at a height greater than the
+	// current one.
+	if height <= h.blockHeight {
+		return errors.New("temporary sector queued with an expiry in the past")
+	}
+	return h.db.Update(func(tx *bolt.Tx) error {
+		// Translate the height into a byte slice.
+		heightBytes := make([]byte, 8)
+		binary.BigEndian.PutUint64(heightBytes, uint64(height))
+
+		// Get the list of sectors already expiring at this height and extend
+		// it.
+		bts := tx.Bucket(bucketTemporarySectors)
+		existingRoots := bts.Get(heightBytes)
+		var extendedRoots = make([]byte, len(existingRoots), len(existingRoots)+len(root))
+		copy(extendedRoots, existingRoots)
+		extendedRoots = append(extendedRoots, root[:]...)
+		return bts.Put(heightBytes, extendedRoots)
+	})
+}
+
+// popExpiredTemporarySectors returns the roots of the temporary sectors that
+// expire at the provided height and removes them from the database.
+func popExpiredTemporarySectors(tx *bolt.Tx, height types.BlockHeight) ([]crypto.Hash, error) {
+	heightBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(heightBytes, uint64(height))
+	bts := tx.Bucket(bucketTemporarySectors)
+	rootBytes := bts.Get(heightBytes)
+	if len(rootBytes) == 0 {
+		return nil, nil
+	}
+	roots := make([]crypto.Hash, len(rootBytes)/crypto.HashSize)
+	for i := range roots {
+		copy(roots[i][:], rootBytes[i*crypto.HashSize:])
+	}
+	return roots, bts.Delete(heightBytes)
+}
+
+// threadedRemoveTemporarySectors removes expired temporary sectors from the
+// storage manager.
+func (h *Host) threadedRemoveTemporarySectors(roots []crypto.Hash) {
+	err := h.tg.Add()
+	if err != nil {
+		return
+	}
+	defer h.tg.Done()
+	if err := h.MarkSectorsForRemoval(roots); err != nil {
+		h.log.Println("ERROR: failed to remove expired temporary sectors:", err)
+	}
+}
@@ -0,0 +1,90 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestStoreSector tests that sectors stored without a contract are removed
+// from the host once they expire.
+func TestStoreSector(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	// Expiries in the past should be rejected.
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root := crypto.MerkleRoot(data)
+	h.mu.RLock()
+	bh := h.blockHeight
+	h.mu.RUnlock()
+	err = h.StoreSector(root, data, bh)
+	if err == nil {
+		t.Fatal("expected error for expiry in the past")
+	}
+	if h.HasSector(root) {
+		t.Fatal("sector shouldn't have been stored")
+	}
+
+	// Store the sector for 2 blocks.
+	err = h.StoreSector(root, data, bh+2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h.HasSector(root) {
+		t.Fatal("sector should be stored")
+	}
+
+	// Mine a block. The sector should still be there.
+	_, err = ht.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h.HasSector(root) {
+		t.Fatal("sector should be stored")
+	}
+
+	// Mine another block. The sector should be removed.
+	_, err = ht.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if h.HasSector(root) {
+			return errors.New("sector wasn't removed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The expiry should no longer be tracked.
+	err = h.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketTemporarySectors).Stats().KeyN != 0 {
+			return errors.New("temporary sectors bucket should be empty")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
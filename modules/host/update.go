@@ -135,6 +135,7 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 	// Wrap the whole parsing into a single large database tx to keep things
 	// efficient.
 	var actionItems []types.FileContractID
+	var expiredSectors []crypto.Hash
 	err := h.db.Update(func(tx *bolt.Tx) error {
 		for _, block := range cc.RevertedBlocks {
 			// Look for transactions relevant to open storage obligations.
@@ -290,6 +291,13 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 					knownActionItems[soid] = struct{}{}
 				}
 			}
+
+			// Collect the temporary sectors expiring at the current height.
+			roots, err := popExpiredTemporarySectors(tx, h.blockHeight)
+			if err != nil {
+				return err
+			}
+			expiredSectors = append(expiredSectors, roots...)
 		}
 		return nil
 	})
@@ -299,6 +307,9 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 	for i := range actionItems {
 		go h.threadedHandleActionItem(actionItems[i])
 	}
+	if len(expiredSectors) > 0 {
+		go h.threadedRemoveTemporarySectors(expiredSectors)
+	}
 
 	// Update the host's recent change pointer to point to the most recent
 	// change.
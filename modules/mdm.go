@@ -2,6 +2,7 @@ package modules
 
 import (
 	"encoding/binary"
+	"fmt"
 	"sync"
 	"time"
 
@@ -85,15 +86,32 @@ const (
 	// MDMTimeReadOffset is the time for executing a 'ReadOffset' instruction.
 	MDMTimeReadOffset = 1000
 
+	// MDMTimeReadRangeSector is the time for reading a single sector of a
+	// 'ReadRange' instruction.
+	MDMTimeReadRangeSector = 1000
+
+	// MDMMaxReadRangeSectors is the maximum number of sectors a 'ReadRange'
+	// instruction can span. It limits the amount of data the host needs to
+	// hold in memory for a single instruction.
+	MDMMaxReadRangeSectors = 16
+
 	// MDMTimeReadSector is the time for executing a 'ReadSector' instruction.
 	MDMTimeReadSector = 1000
 
 	// MDMTimeRevision is the time for executing a 'Revision' instruction.
 	MDMTimeRevision = 1
 
+	// MDMTimeStoreSector is the time for executing a 'StoreSector'
+	// instruction.
+	MDMTimeStoreSector = 10000
+
 	// MDMTimeSwapSector is the time for executing an 'SwapSector' instruction.
 	MDMTimeSwapSector = 1
 
+	// MDMTimeUpdateSector is the time for executing an 'UpdateSector'
+	// instruction.
+	MDMTimeUpdateSector = 20000
+
 	// MDMTimeWriteSector is the time for executing a 'WriteSector' instruction.
 	MDMTimeWriteSector = 10000
 
@@ -125,14 +143,26 @@ const (
 	// instruction.
 	RPCIReadOffsetLen = 17
 
+	// RPCIReadRangeLen is the expected length of the 'Args' of a ReadRange
+	// instruction.
+	RPCIReadRangeLen = 17 // 2 uint64 offsets + merkle proof flag
+
 	// RPCIRevisionLen is the expected length of the 'Args' of a Revision
 	// instruction.
 	RPCIRevisionLen = 0
 
+	// RPCIStoreSectorLen is the expected length of the 'Args' of a
+	// StoreSector instruction.
+	RPCIStoreSectorLen = 16 // 2 uint64 offsets
+
 	// RPCISwapSectorLen is the expected length of the 'Args' of an SwapSector
 	// instructon.
 	RPCISwapSectorLen = 17 // 2 uint64 offsets + merkle proof flag
 
+	// RPCIUpdateSectorLen is the expected length of the 'Args' of an
+	// UpdateSector instruction.
+	RPCIUpdateSectorLen = 25 // 3 uint64 offsets + merkle proof flag
+
 	// RPCIUpdateRegistryLen is the expected length of the 'Args' of an
 	// UpdateRegistry instruction.
 	// tweakOffset + revisionOffset + signatureOffset + pubKeyOffset +
@@ -191,15 +221,24 @@ var (
 	// SpecifierReadOffset is the specifier for the ReadOffset instruction.
 	SpecifierReadOffset = InstructionSpecifier{'R', 'e', 'a', 'd', 'O', 'f', 'f', 's', 'e', 't'}
 
+	// SpecifierReadRange is the specifier for the ReadRange instruction.
+	SpecifierReadRange = InstructionSpecifier{'R', 'e', 'a', 'd', 'R', 'a', 'n', 'g', 'e'}
+
 	// SpecifierReadSector is the specifier for the ReadSector instruction.
 	SpecifierReadSector = InstructionSpecifier{'R', 'e', 'a', 'd', 'S', 'e', 'c', 't', 'o', 'r'}
 
 	// SpecifierRevision is the specifier for the Revision instruction.
 	SpecifierRevision = InstructionSpecifier{'R', 'e', 'v', 'i', 's', 'i', 'o', 'n'}
 
+	// SpecifierStoreSector is the specifier for the StoreSector instruction.
+	SpecifierStoreSector = InstructionSpecifier{'S', 't', 'o', 'r', 'e', 'S', 'e', 'c', 't', 'o', 'r'}
+
 	// SpecifierSwapSector is the specifier for the SwapSector instruction.
 	SpecifierSwapSector = InstructionSpecifier{'S', 'w', 'a', 'p', 'S', 'e', 'c', 't', 'o', 'r'}
 
+	// SpecifierUpdateSector is the specifier for the UpdateSector instruction.
+	SpecifierUpdateSector = InstructionSpecifier{'U', 'p', 'd', 'a', 't', 'e', 'S', 'e', 'c', 't', 'o', 'r'}
+
 	// SpecifierUpdateRegistry is the specifier for the UpdateRegistry
 	// instruction.
 	SpecifierUpdateRegistry = InstructionSpecifier{'U', 'p', 'd', 'a', 't', 'e', 'R', 'e', 'g', 'i', 's', 't', 'r', 'y'}
@@ -233,6 +272,18 @@ type (
 		RevisionTxn types.Transaction
 	}
 
+	// MDMInstructionUpdateSectorResponse is the format of the MDM's
+	// UpdateSector instruction's output. OldData and SectorProof are only set
+	// if a merkle proof was requested. SectorProof is a range proof for the
+	// updated segments within the sector which is valid for both OldData
+	// against OldSectorRoot and the new data against NewSectorRoot.
+	MDMInstructionUpdateSectorResponse struct {
+		OldSectorRoot crypto.Hash
+		NewSectorRoot crypto.Hash
+		OldData       []byte
+		SectorProof   []crypto.Hash
+	}
+
 	// RegistryEntryID is a hash derived from the public key and tweak that a
 	// renter would like to subscribe to.
 	RegistryEntryID crypto.Hash
@@ -278,6 +329,41 @@ func MDMAppendCost(pt *RPCPriceTable, duration types.BlockHeight) (types.Currenc
 	return writeCost.Add(storeCost), storeCost
 }
 
+// Verify verifies the output of an 'UpdateSector' instruction that was
+// executed with a merkle proof. relOffset is the offset of the update within
+// the sector at sectorIndex and newData is the data the renter wrote.
+// contractProof is the proof returned alongside the output and numSectors the
+// number of sectors within the contract.
+func (resp MDMInstructionUpdateSectorResponse) Verify(relOffset uint64, newData []byte, contractProof []crypto.Hash, sectorIndex, numSectors uint64, oldContractRoot, newContractRoot crypto.Hash) error {
+	if len(resp.OldData) != len(newData) {
+		return fmt.Errorf("length of old data %v doesn't match length of new data %v", len(resp.OldData), len(newData))
+	}
+	if relOffset%crypto.SegmentSize != 0 || uint64(len(newData))%crypto.SegmentSize != 0 {
+		return errors.New("update range isn't segment aligned")
+	}
+	// Verify the sector proofs.
+	segStart := int(relOffset / crypto.SegmentSize)
+	segEnd := segStart + len(newData)/crypto.SegmentSize
+	if !crypto.VerifyRangeProof(resp.OldData, resp.SectorProof, segStart, segEnd, resp.OldSectorRoot) {
+		return errors.New("invalid sector proof for old data")
+	}
+	if !crypto.VerifyRangeProof(newData, resp.SectorProof, segStart, segEnd, resp.NewSectorRoot) {
+		return errors.New("invalid sector proof for new data")
+	}
+	// Verify the contract proofs.
+	ranges := []crypto.ProofRange{{
+		Start: sectorIndex,
+		End:   sectorIndex + 1,
+	}}
+	if !crypto.VerifyDiffProof(ranges, numSectors, contractProof, []crypto.Hash{resp.OldSectorRoot}, oldContractRoot) {
+		return errors.New("invalid contract proof for old sector root")
+	}
+	if !crypto.VerifyDiffProof(ranges, numSectors, contractProof, []crypto.Hash{resp.NewSectorRoot}, newContractRoot) {
+		return errors.New("invalid contract proof for new sector root")
+	}
+	return nil
+}
+
 // MDMCopyCost is the cost of executing a 'Copy' instruction.
 func MDMCopyCost(pt RPCPriceTable, contractSize uint64) types.Currency {
 	return types.SiacoinPrecision // TODO: figure out good cost
@@ -315,11 +401,33 @@ func MDMRevisionCost(pt *RPCPriceTable) types.Currency {
 	return cost
 }
 
+// MDMStoreSectorCost is the cost of executing a 'StoreSector' instruction
+// which stores a sector for the given number of blocks. Similar to the
+// registry, the cost consists of the cost of writing the sector and the cost
+// of storing it for the whole duration. The latter is returned separately
+// since it is refunded if the instruction itself fails. Once the sector is
+// stored, the storage cost is no longer refundable.
+func MDMStoreSectorCost(pt *RPCPriceTable, duration types.BlockHeight) (_, _ types.Currency) {
+	writeCost := MDMWriteCost(pt, SectorSize)
+	storeCost := pt.WriteStoreCost.Mul64(SectorSize).Mul64(uint64(duration))
+	return writeCost.Add(storeCost), storeCost
+}
+
 // MDMSwapSectorCost is the cost of executing a 'SwapSector' instruction.
 func MDMSwapSectorCost(pt *RPCPriceTable) types.Currency {
 	return pt.SwapSectorCost
 }
 
+// MDMUpdateSectorCost is the cost of executing an 'UpdateSector' instruction.
+// Since the host needs to read the whole sector, modify it and write it back
+// to disk, the cost is the base cost plus the cost of reading and writing a
+// full sector.
+func MDMUpdateSectorCost(pt *RPCPriceTable) types.Currency {
+	readCost := MDMReadCost(pt, SectorSize)
+	writeCost := MDMWriteCost(pt, SectorSize)
+	return pt.UpdateSectorBaseCost.Add(readCost).Add(writeCost)
+}
+
 // V154MDMUpdateRegistryCost is the cost of executing a 'UpdateRegistry'
 // instruction in host versions 1.5.4 and below.
 func V154MDMUpdateRegistryCost(pt *RPCPriceTable) (_, _ types.Currency) {
@@ -434,12 +542,24 @@ func MDMRevisionMemory() uint64 {
 	return 0 // 'Revision' doesn't hold on to any memory beyond the lifetime of the instruction.
 }
 
+// MDMStoreSectorMemory returns the additional memory consumption of a
+// 'StoreSector' instruction.
+func MDMStoreSectorMemory() uint64 {
+	return 0 // 'StoreSector' writes the sector to disk right away.
+}
+
 // MDMSwapSectorMemory returns the additional memory consumption of a
 // 'SwapSector' instruction.
 func MDMSwapSectorMemory() uint64 {
 	return 0 // 'SwapSector' doesn't hold on to any memory beyond the lifetime of the instruction.
 }
 
+// MDMUpdateSectorMemory returns the additional memory consumption of an
+// 'UpdateSector' instruction.
+func MDMUpdateSectorMemory() uint64 {
+	return SectorSize // The updated sector is kept in the program's memory until the program is finalized.
+}
+
 // MDMUpdateRegistryMemory returns the additional memory consumption of a
 // 'UpdateRegistry' instruction.
 func MDMUpdateRegistryMemory() uint64 {
@@ -471,6 +591,21 @@ func MDMDropSectorsTime(numSectorsDropped uint64) uint64 {
 	return MDMTimeDropSectorsBase + MDMTimeDropSingleSector*numSectorsDropped
 }
 
+// MDMReadRangeTime returns the time for executing a 'ReadRange' instruction
+// which reads length bytes starting at offset.
+func MDMReadRangeTime(offset, length uint64) uint64 {
+	return MDMTimeReadRangeSector * MDMReadRangeSectors(offset, length)
+}
+
+// MDMReadRangeSectors returns the number of sectors a 'ReadRange' instruction
+// which reads length bytes starting at offset needs to read.
+func MDMReadRangeSectors(offset, length uint64) uint64 {
+	if length == 0 {
+		return 0
+	}
+	return (offset+length-1)/SectorSize - offset/SectorSize + 1
+}
+
 // MDMAppendCollateral returns the additional collateral a 'Append' instruction
 // requires the host to put up.
 func MDMAppendCollateral(pt *RPCPriceTable, duration types.BlockHeight) types.Currency {
@@ -501,12 +636,24 @@ func MDMRevisionCollateral() types.Currency {
 	return types.ZeroCurrency
 }
 
+// MDMStoreSectorCollateral returns the additional collateral a 'StoreSector'
+// instruction requires the host to put up.
+func MDMStoreSectorCollateral() types.Currency {
+	return types.ZeroCurrency // there is no contract to put collateral into
+}
+
 // MDMSwapSectorCollateral returns the additional collateral a 'SwapSector'
 // instruction requires the host to put up.
 func MDMSwapSectorCollateral() types.Currency {
 	return types.ZeroCurrency
 }
 
+// MDMUpdateSectorCollateral returns the additional collateral an
+// 'UpdateSector' instruction requires the host to put up.
+func MDMUpdateSectorCollateral() types.Currency {
+	return types.ZeroCurrency // the contract size doesn't change
+}
+
 // MDMUpdateRegistryCollateral returns the additional collateral a
 // 'UpdateRegistry' instruction requires the host to put up.
 func MDMUpdateRegistryCollateral() types.Currency {
@@ -529,10 +676,15 @@ func (p Program) ReadOnly() bool {
 			return false
 		case SpecifierHasSector:
 		case SpecifierReadOffset:
+		case SpecifierReadRange:
 		case SpecifierReadSector:
 		case SpecifierRevision:
+		case SpecifierStoreSector:
+			// considered read-only cause it doesn't update a contract
 		case SpecifierSwapSector:
 			return false
+		case SpecifierUpdateSector:
+			return false
 		case SpecifierUpdateRegistry:
 			// considered read-only cause it doesn't update a contract
 		case SpecifierReadRegistry:
@@ -544,6 +696,17 @@ func (p Program) ReadOnly() bool {
 	return true
 }
 
+// StoresSectors returns true if the program contains a 'StoreSector'
+// instruction.
+func (p Program) StoresSectors() bool {
+	for _, instruction := range p {
+		if instruction.Specifier == SpecifierStoreSector {
+			return true
+		}
+	}
+	return false
+}
+
 // RequiresSnapshot returns true if an instruction requires access to the sector
 // roots of a filecontract and therefore requires the host to load a snapshot
 // from disk to provide that information.
@@ -558,11 +721,16 @@ func (p Program) RequiresSnapshot() bool {
 		case SpecifierHasSector:
 		case SpecifierReadOffset:
 			return true
+		case SpecifierReadRange:
+			return true
 		case SpecifierReadSector:
 		case SpecifierRevision:
 			return true
+		case SpecifierStoreSector:
 		case SpecifierSwapSector:
 			return true
+		case SpecifierUpdateSector:
+			return true
 		case SpecifierUpdateRegistry:
 		case SpecifierReadRegistry:
 		case SpecifierReadRegistryEID:
@@ -221,11 +221,21 @@ func TestRequiresReadonlyAndSnapshot(t *testing.T) {
 			true,
 			true,
 		},
+		{
+			SpecifierStoreSector,
+			true,
+			false,
+		},
 		{
 			SpecifierSwapSector,
 			false,
 			true,
 		},
+		{
+			SpecifierUpdateSector,
+			false,
+			true,
+		},
 	}
 
 	for i, test := range tests {
@@ -122,6 +122,26 @@ func (pb *ProgramBuilder) AddReadOffsetInstruction(length, offset uint64, merkle
 	pb.addInstruction(collateral, cost, types.ZeroCurrency, memory, time)
 }
 
+// AddReadRangeInstruction adds a ReadRange instruction to the program.
+func (pb *ProgramBuilder) AddReadRangeInstruction(length, offset uint64, merkleProof bool) {
+	// Compute the argument offsets.
+	lengthOffset := uint64(pb.programData.Len())
+	offsetOffset := lengthOffset + 8
+	// Extend the programData.
+	binary.Write(pb.programData, binary.LittleEndian, length)
+	binary.Write(pb.programData, binary.LittleEndian, offset)
+	// Create the instruction.
+	i := NewReadRangeInstruction(lengthOffset, offsetOffset, merkleProof)
+	// Append instruction
+	pb.program = append(pb.program, i)
+	// Update cost, collateral and memory usage.
+	collateral := MDMReadCollateral()
+	cost := MDMReadCost(pb.staticPT, length)
+	memory := MDMReadMemory()
+	time := MDMReadRangeTime(offset, length)
+	pb.addInstruction(collateral, cost, types.ZeroCurrency, memory, time)
+}
+
 // AddReadSectorInstruction adds a ReadSector instruction to the program.
 func (pb *ProgramBuilder) AddReadSectorInstruction(length, offset uint64, merkleRoot crypto.Hash, merkleProof bool) {
 	// Compute the argument offsets.
@@ -160,6 +180,36 @@ func (pb *ProgramBuilder) AddRevisionInstruction() {
 	pb.addInstruction(collateral, cost, types.ZeroCurrency, memory, time)
 }
 
+// AddStoreSectorInstruction adds a StoreSector instruction to the program. The
+// sector is stored by the host for the given number of blocks without a file
+// contract.
+func (pb *ProgramBuilder) AddStoreSectorInstruction(data []byte, duration types.BlockHeight) error {
+	if uint64(len(data)) != SectorSize {
+		return fmt.Errorf("expected stored data to have size %v but was %v", SectorSize, len(data))
+	}
+	if duration == 0 {
+		return errors.New("duration needs to be greater than 0")
+	}
+	// Compute the argument offsets.
+	durationOffset := uint64(pb.programData.Len())
+	dataOffset := durationOffset + 8
+	// Extend the programData.
+	binary.Write(pb.programData, binary.LittleEndian, uint64(duration))
+	binary.Write(pb.programData, binary.LittleEndian, data)
+	// Create the instruction.
+	i := NewStoreSectorInstruction(durationOffset, dataOffset)
+	// Append instruction
+	pb.program = append(pb.program, i)
+	// Update cost, collateral and memory usage.
+	collateral := MDMStoreSectorCollateral()
+	cost, _ := MDMStoreSectorCost(pb.staticPT, duration)
+	memory := MDMStoreSectorMemory()
+	time := uint64(MDMTimeStoreSector)
+	// The storage cost is only refunded if the instruction itself fails.
+	pb.addInstruction(collateral, cost, types.ZeroCurrency, memory, time)
+	return nil
+}
+
 // AddSwapSectorInstruction adds a SwapSector instruction to the program.
 func (pb *ProgramBuilder) AddSwapSectorInstruction(sector1Idx, sector2Idx uint64, merkleProof bool) {
 	// Compute the argument offsets.
@@ -181,6 +231,40 @@ func (pb *ProgramBuilder) AddSwapSectorInstruction(sector1Idx, sector2Idx uint64
 	pb.readonly = false
 }
 
+// AddUpdateSectorInstruction adds an UpdateSector instruction to the program.
+// The offset is the offset within the contract and both offset and the length
+// of the data need to be multiples of crypto.SegmentSize. The updated range
+// can't span multiple sectors.
+func (pb *ProgramBuilder) AddUpdateSectorInstruction(offset uint64, data []byte, merkleProof bool) error {
+	length := uint64(len(data))
+	if length == 0 || offset%crypto.SegmentSize != 0 || length%crypto.SegmentSize != 0 {
+		return fmt.Errorf("offset (%v) and length (%v) need to be non-zero multiples of %v", offset, length, crypto.SegmentSize)
+	}
+	if offset%SectorSize+length > SectorSize {
+		return fmt.Errorf("update of length %v at offset %v spans multiple sectors", length, offset)
+	}
+	// Compute the argument offsets.
+	offsetOffset := uint64(pb.programData.Len())
+	lengthOffset := offsetOffset + 8
+	dataOffset := lengthOffset + 8
+	// Extend the programData.
+	binary.Write(pb.programData, binary.LittleEndian, offset)
+	binary.Write(pb.programData, binary.LittleEndian, length)
+	binary.Write(pb.programData, binary.LittleEndian, data)
+	// Create the instruction.
+	i := NewUpdateSectorInstruction(offsetOffset, lengthOffset, dataOffset, merkleProof)
+	// Append instruction
+	pb.program = append(pb.program, i)
+	// Update cost, collateral and memory usage.
+	collateral := MDMUpdateSectorCollateral()
+	cost := MDMUpdateSectorCost(pb.staticPT)
+	memory := MDMUpdateSectorMemory()
+	time := uint64(MDMTimeUpdateSector)
+	pb.addInstruction(collateral, cost, types.ZeroCurrency, memory, time)
+	pb.readonly = false
+	return nil
+}
+
 // V156AddUpdateRegistryInstruction adds an UpdateRegistry instruction to the
 // program.
 func (pb *ProgramBuilder) V156AddUpdateRegistryInstruction(spk types.SiaPublicKey, rv SignedRegistryValue) error {
@@ -560,6 +644,20 @@ func NewReadOffsetInstruction(lengthOffset, offsetOffset uint64, merkleProof boo
 	return i
 }
 
+// NewReadRangeInstruction creates a modules.Instruction from arguments.
+func NewReadRangeInstruction(lengthOffset, offsetOffset uint64, merkleProof bool) Instruction {
+	i := Instruction{
+		Specifier: SpecifierReadRange,
+		Args:      make([]byte, RPCIReadRangeLen),
+	}
+	binary.LittleEndian.PutUint64(i.Args[:8], offsetOffset)
+	binary.LittleEndian.PutUint64(i.Args[8:16], lengthOffset)
+	if merkleProof {
+		i.Args[16] = 1
+	}
+	return i
+}
+
 // NewReadSectorInstruction creates a modules.Instruction from arguments.
 func NewReadSectorInstruction(lengthOffset, offsetOffset, merkleRootOffset uint64, merkleProof bool) Instruction {
 	i := Instruction{
@@ -575,6 +673,17 @@ func NewReadSectorInstruction(lengthOffset, offsetOffset, merkleRootOffset uint6
 	return i
 }
 
+// NewStoreSectorInstruction creates a modules.Instruction from arguments.
+func NewStoreSectorInstruction(durationOffset, dataOffset uint64) Instruction {
+	i := Instruction{
+		Specifier: SpecifierStoreSector,
+		Args:      make([]byte, RPCIStoreSectorLen),
+	}
+	binary.LittleEndian.PutUint64(i.Args[:8], durationOffset)
+	binary.LittleEndian.PutUint64(i.Args[8:16], dataOffset)
+	return i
+}
+
 // NewSwapSectorInstruction creates a modules.Instruction from arguments.
 func NewSwapSectorInstruction(sector1Offset, sector2Offset uint64, merkleProof bool) Instruction {
 	i := Instruction{
@@ -589,6 +698,21 @@ func NewSwapSectorInstruction(sector1Offset, sector2Offset uint64, merkleProof b
 	return i
 }
 
+// NewUpdateSectorInstruction creates a modules.Instruction from arguments.
+func NewUpdateSectorInstruction(offsetOffset, lengthOffset, dataOffset uint64, merkleProof bool) Instruction {
+	i := Instruction{
+		Specifier: SpecifierUpdateSector,
+		Args:      make([]byte, RPCIUpdateSectorLen),
+	}
+	binary.LittleEndian.PutUint64(i.Args[:8], offsetOffset)
+	binary.LittleEndian.PutUint64(i.Args[8:16], lengthOffset)
+	binary.LittleEndian.PutUint64(i.Args[16:24], dataOffset)
+	if merkleProof {
+		i.Args[24] = 1
+	}
+	return i
+}
+
 // NewRevisionInstruction creates a modules.Instruction from arguments.
 func NewRevisionInstruction(merkleRootOffset uint64) Instruction {
 	return Instruction{
@@ -69,6 +69,10 @@ var (
 	// available.
 	ErrNotEnoughWorkersInWorkerPool = errors.New("not enough workers in worker pool")
 
+	// ErrUploadSessionOffsetMismatch is returned if data is written to an
+	// upload session at an offset other than the session's committed offset.
+	ErrUploadSessionOffsetMismatch = errors.New("offset doesn't match the committed offset of the upload session")
+
 	// PriceEstimationScope is the number of hosts that get queried by the
 	// renter when providing price estimates. Especially for the 'Standard'
 	// variable, there should be congruence with the number of contracts being
@@ -93,6 +97,10 @@ type DataSourceID crypto.Hash
 // mode
 type FilterMode int
 
+// RepairPriority is the helper type for the enum constants for the repair
+// priority of a directory.
+type RepairPriority uint8
+
 // FileListFunc is a type that's passed in to functions related to iterating
 // over the filesystem.
 type FileListFunc func(FileInfo)
@@ -146,6 +154,21 @@ const (
 	HostDBActiveWhitelist
 )
 
+// RepairPriorityInherit RepairPriorityLow RepairPriorityNormal and
+// RepairPriorityHigh are the constants used to set the repair priority of a
+// directory. A directory with RepairPriorityInherit uses the priority of its
+// parent. Directories with a higher priority are repaired first.
+const (
+	RepairPriorityInherit RepairPriority = iota
+	RepairPriorityLow
+	RepairPriorityNormal
+	RepairPriorityHigh
+
+	// DefaultRepairPriority is the priority of directories which don't have
+	// an ancestor with an explicit priority.
+	DefaultRepairPriority = RepairPriorityNormal
+)
+
 // Filesystem related consts.
 const (
 	// DefaultDirPerm defines the default permissions used for a new dir if no
@@ -191,6 +214,39 @@ func (fm *FilterMode) FromString(s string) error {
 	return nil
 }
 
+// String returns the string value for the RepairPriority
+func (rp RepairPriority) String() string {
+	switch rp {
+	case RepairPriorityInherit:
+		return "inherit"
+	case RepairPriorityLow:
+		return "low"
+	case RepairPriorityNormal:
+		return "normal"
+	case RepairPriorityHigh:
+		return "high"
+	default:
+		return ""
+	}
+}
+
+// FromString assigns the RepairPriority from the provided string
+func (rp *RepairPriority) FromString(s string) error {
+	switch s {
+	case "inherit":
+		*rp = RepairPriorityInherit
+	case "low":
+		*rp = RepairPriorityLow
+	case "normal":
+		*rp = RepairPriorityNormal
+	case "high":
+		*rp = RepairPriorityHigh
+	default:
+		return fmt.Errorf("could not assign RepairPriority from string %v", s)
+	}
+	return nil
+}
+
 // IsHostsFault indicates if a returned error is the host's fault.
 func IsHostsFault(err error) bool {
 	return errors.Contains(err, ErrHostFault)
@@ -239,6 +295,21 @@ type (
 	// part of its filename on disk.
 	CombinedChunkID string
 
+	// UploadSessionID is a unique identifier for a resumable upload session.
+	UploadSessionID string
+
+	// UploadSession describes a resumable upload. Data is written to the
+	// session at increasing offsets and the session reports the offset up to
+	// which data has been durably committed.
+	UploadSession struct {
+		ID         UploadSessionID `json:"id"`
+		SiaPath    SiaPath         `json:"siapath"`
+		Length     uint64          `json:"length"`
+		Offset     uint64          `json:"offset"`
+		Complete   bool            `json:"complete"`
+		CreateTime time.Time       `json:"createtime"`
+	}
+
 	// PartialChunk holds some information about a combined chunk
 	PartialChunk struct {
 		ChunkID        CombinedChunkID // The ChunkID of the combined chunk the partial is in.
@@ -359,6 +430,12 @@ type DirectoryInfo struct {
 	AggregateStuckHealth         float64   `json:"aggregatestuckhealth"`
 	AggregateStuckSize           uint64    `json:"aggregatestucksize"`
 
+	// AggregatePriority is the highest repair priority set on the siadir or
+	// any of its sub siadirs. Priority is the repair priority set on the
+	// siadir itself.
+	AggregatePriority RepairPriority `json:"aggregatepriority"`
+	Priority          RepairPriority `json:"priority"`
+
 	// The following fields are information specific to the siadir that is not
 	// an aggregate of the entire sub directory tree
 	Health              float64     `json:"health"`
@@ -433,6 +510,11 @@ type FileUploadParams struct {
 	// to create a CipherKey with the given CipherType. This value override
 	// CipherType if it is set.
 	CipherKey crypto.CipherKey
+
+	// Checksum indicates whether the renter should compute a hash of the
+	// file's plaintext data and store it in the SiaFile. Downloads of the
+	// whole file to disk are verified against that hash.
+	Checksum bool
 }
 
 // FileInfo provides information about a file.
@@ -440,6 +522,7 @@ type FileInfo struct {
 	AccessTime       time.Time         `json:"accesstime"`
 	Available        bool              `json:"available"`
 	ChangeTime       time.Time         `json:"changetime"`
+	Checksum         crypto.Hash       `json:"checksum"`
 	CipherType       string            `json:"ciphertype"`
 	CreateTime       time.Time         `json:"createtime"`
 	Expiration       types.BlockHeight `json:"expiration"`
@@ -618,6 +701,10 @@ type RenterSettings struct {
 	MaxUploadSpeed   int64         `json:"maxuploadspeed"`
 	MaxDownloadSpeed int64         `json:"maxdownloadspeed"`
 	UploadsStatus    UploadsStatus `json:"uploadsstatus"`
+
+	// BandwidthSchedule overrides the bandwidth limits above and pauses
+	// repairs and uploads during its windows.
+	BandwidthSchedule BandwidthSchedule `json:"bandwidthschedule"`
 }
 
 // UploadsStatus contains information about the Renter's Uploads
@@ -1158,6 +1245,34 @@ type Renter interface {
 	// RenameDir changes the path of a dir.
 	RenameDir(oldPath, newPath SiaPath) error
 
+	// Reencode re-encodes the file or all files within the dir at siaPath to
+	// the provided erasure code in the background.
+	Reencode(siaPath SiaPath, ec ErasureCoder) error
+
+	// CancelReencode stops the re-encode of the file or all files within the
+	// dir at siaPath.
+	CancelReencode(siaPath SiaPath) error
+
+	// AbortUploadSession aborts the upload session with the given id and
+	// deletes the partially uploaded file.
+	AbortUploadSession(id UploadSessionID) error
+
+	// CreateUploadSession creates a new resumable upload session for a file of
+	// the given length.
+	CreateUploadSession(up FileUploadParams, length uint64) (UploadSession, error)
+
+	// UploadSession returns the upload session with the given id.
+	UploadSession(id UploadSessionID) (UploadSession, error)
+
+	// UploadSessions returns all the upload sessions of the renter.
+	UploadSessions() []UploadSession
+
+	// WriteUploadSession writes the data from the reader to the upload
+	// session, starting at offset. The offset needs to match the session's
+	// committed offset. The updated session is returned even if the write
+	// only partially succeeded.
+	WriteUploadSession(id UploadSessionID, offset uint64, r io.Reader) (UploadSession, error)
+
 	// EstimateHostScore will return the score for a host with the provided
 	// settings, assuming perfect age and uptime adjustments
 	EstimateHostScore(entry HostDBEntry, allowance Allowance) (HostScoreBreakdown, error)
@@ -1211,6 +1326,11 @@ type Renter interface {
 	// DeleteDir deletes a directory from the renter
 	DeleteDir(siaPath SiaPath) error
 
+	// SetDirPriority sets the repair priority of a directory. The priority is
+	// inherited by all the files and directories within the directory which
+	// don't have a priority of their own.
+	SetDirPriority(siaPath SiaPath, priority RepairPriority) error
+
 	// DirList lists the directories in a siadir
 	DirList(siaPath SiaPath) ([]DirectoryInfo, error)
 
@@ -1253,6 +1373,11 @@ type RenterDownloadParameters struct {
 	SiaPath          SiaPath
 	Destination      string
 	DisableDiskFetch bool
+
+	// Resume indicates whether a download to a local destination should reuse
+	// the chunks which are already present and correct in the destination
+	// file instead of downloading them again.
+	Resume bool
 }
 
 // HealthPercentage returns the health in a more human understandable format out
@@ -0,0 +1,107 @@
+package renter
+
+import (
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+)
+
+// Bandwidth Schedule Overview:
+// The renter's settings contain an optional weekly bandwidth schedule. The
+// schedule is checked periodically by a background thread. Whenever a window of
+// the schedule starts or ends, the renter's rate limits are updated to the
+// window's limits or back to the limits from the settings. If a window is
+// marked as paused, repairs and uploads are paused until the window ends.
+// Changes are only applied when the active window changes, which means that a
+// user can still manually resume uploads during a paused window.
+
+var (
+	// bandwidthScheduleInterval is how often the renter checks whether the
+	// active window of the bandwidth schedule changed.
+	bandwidthScheduleInterval = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+)
+
+// bandwidthScheduler tracks the window of the bandwidth schedule which was
+// applied last.
+type bandwidthScheduler struct {
+	activeWindow int
+	activeStart  time.Time
+	paused       bool
+	mu           sync.Mutex
+}
+
+// newBandwidthScheduler returns a bandwidthScheduler without an active window.
+func newBandwidthScheduler() *bandwidthScheduler {
+	return &bandwidthScheduler{
+		activeWindow: -1,
+	}
+}
+
+// threadedBandwidthSchedule periodically applies the renter's bandwidth
+// schedule.
+func (r *Renter) threadedBandwidthSchedule() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		if err := r.managedApplyBandwidthSchedule(false); err != nil {
+			r.log.Println("WARN: failed to apply bandwidth schedule:", err)
+		}
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(bandwidthScheduleInterval):
+		}
+	}
+}
+
+// managedApplyBandwidthSchedule sets the renter's bandwidth limits and pause
+// status according to the window of the bandwidth schedule which is currently
+// active. Unless force is set, nothing is changed if the active window is the
+// same as the last time the schedule was applied.
+func (r *Renter) managedApplyBandwidthSchedule(force bool) error {
+	id := r.mu.RLock()
+	schedule := r.persist.BandwidthSchedule
+	downloadSpeed, uploadSpeed := r.persist.MaxDownloadSpeed, r.persist.MaxUploadSpeed
+	r.mu.RUnlock(id)
+
+	index, start, end := schedule.ActiveWindow(time.Now())
+
+	bs := r.staticBandwidthScheduler
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	if !force && index == bs.activeWindow && start.Equal(bs.activeStart) {
+		return nil
+	}
+	bs.activeWindow = index
+	bs.activeStart = start
+
+	// Update the rate limits.
+	if index >= 0 {
+		downloadSpeed = schedule[index].MaxDownloadSpeed
+		uploadSpeed = schedule[index].MaxUploadSpeed
+	}
+	if err := r.setBandwidthLimits(downloadSpeed, uploadSpeed); err != nil {
+		return errors.AddContext(err, "unable to set bandwidth limits")
+	}
+
+	// Pause repairs and uploads until the end of the window or resume them if
+	// the schedule paused them before.
+	if index >= 0 && schedule[index].Paused {
+		r.uploadHeap.managedPause(time.Until(end))
+		bs.paused = true
+	} else if bs.paused {
+		r.uploadHeap.managedResume()
+		bs.paused = false
+	}
+	return nil
+}
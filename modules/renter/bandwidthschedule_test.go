@@ -0,0 +1,85 @@
+package renter
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestBandwidthSchedule tests that the renter applies the limits and pause
+// status of the active window of its bandwidth schedule and reverts to its
+// settings once there is no active window anymore.
+func TestBandwidthSchedule(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Set a schedule with a paused window which is always active.
+	settings, err := r.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.MaxDownloadSpeed = 1000
+	settings.MaxUploadSpeed = 2000
+	settings.BandwidthSchedule = modules.BandwidthSchedule{{
+		Start:            "00:00",
+		End:              "00:00",
+		MaxDownloadSpeed: 3000,
+		MaxUploadSpeed:   4000,
+		Paused:           true,
+	}}
+	if err := r.SetSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if down, up, _ := r.rl.Limits(); down != 3000 || up != 4000 {
+		t.Fatal("window limits weren't applied", down, up)
+	}
+	if paused, _ := r.uploadHeap.managedPauseStatus(); !paused {
+		t.Fatal("uploads should be paused")
+	}
+
+	// The settings should still report the limits which aren't part of the
+	// schedule.
+	settings, err = r.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.MaxDownloadSpeed != 1000 || settings.MaxUploadSpeed != 2000 {
+		t.Fatal("wrong limits in settings", settings.MaxDownloadSpeed, settings.MaxUploadSpeed)
+	}
+	if len(settings.BandwidthSchedule) != 1 {
+		t.Fatal("schedule wasn't returned by settings", settings.BandwidthSchedule)
+	}
+
+	// An invalid schedule should be rejected.
+	invalid := settings
+	invalid.BandwidthSchedule = modules.BandwidthSchedule{{Start: "25:00", End: "00:00"}}
+	if err := r.SetSettings(invalid); err == nil {
+		t.Fatal("invalid schedule should be rejected")
+	}
+
+	// Clear the schedule. The limits of the settings should be applied again
+	// and uploads should be resumed.
+	settings.BandwidthSchedule = nil
+	if err := r.SetSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if down, up, _ := r.rl.Limits(); down != 1000 || up != 2000 {
+		t.Fatal("setting limits weren't applied", down, up)
+	}
+	if paused, _ := r.uploadHeap.managedPauseStatus(); paused {
+		t.Fatal("uploads should be resumed")
+	}
+}
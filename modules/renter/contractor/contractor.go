@@ -2,6 +2,7 @@ package contractor
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"net"
 	"os"
@@ -125,6 +126,24 @@ type PaymentDetails struct {
 	SpendingDetails modules.SpendingDetails
 }
 
+// SectorUpdateDetails is a helper struct that contains the information the
+// contractor needs to verify and finalize a partial sector update which was
+// executed by the host using an UpdateSector instruction.
+type SectorUpdateDetails struct {
+	// destination details
+	Host types.SiaPublicKey
+
+	// update details
+	SectorIndex   uint64
+	OldSectorRoot crypto.Hash
+	Offset        uint64 // offset within the sector
+	Data          []byte
+
+	// host response
+	Response modules.RPCExecuteProgramResponse
+	Output   modules.MDMInstructionUpdateSectorResponse
+}
+
 // Allowance returns the current allowance.
 func (c *Contractor) Allowance() modules.Allowance {
 	c.mu.RLock()
@@ -347,6 +366,101 @@ func (c *Contractor) ProvidePayment(stream io.ReadWriter, pt *modules.RPCPriceTa
 	return nil
 }
 
+// FinalizeSectorUpdate verifies the response of a program that updated a
+// sector within the contract with the given host and finalizes it by signing a
+// revision with the new contract root. The stream is expected to be the stream
+// the program was executed on.
+func (c *Contractor) FinalizeSectorUpdate(stream io.ReadWriter, bh types.BlockHeight, details SectorUpdateDetails) error {
+	// find a contract for the given host
+	contract, exists := c.ContractByPublicKey(details.Host)
+	if !exists {
+		return errContractNotFound
+	}
+
+	// acquire a safe contract
+	sc, exists := c.staticContracts.Acquire(contract.ID)
+	if !exists {
+		return errContractNotFound
+	}
+	defer c.staticContracts.Return(sc)
+
+	// verify the update against the latest revision
+	current := sc.LastRevision()
+	numSectors := current.NewFileSize / modules.SectorSize
+	if details.SectorIndex >= numSectors {
+		return fmt.Errorf("sector index %v out of bounds for contract with %v sectors", details.SectorIndex, numSectors)
+	}
+	if details.Output.OldSectorRoot != details.OldSectorRoot {
+		return errors.New("host updated the wrong sector")
+	}
+	if details.Response.NewSize != current.NewFileSize {
+		return fmt.Errorf("host changed the contract size from %v to %v", current.NewFileSize, details.Response.NewSize)
+	}
+	err := details.Output.Verify(details.Offset, details.Data, details.Response.Proof, details.SectorIndex, numSectors, current.NewFileMerkleRoot, details.Response.NewMerkleRoot)
+	if err != nil {
+		return errors.AddContext(err, "failed to verify sector update")
+	}
+
+	// create a new revision
+	transfer := details.Response.AdditionalCollateral.Add(details.Response.FailureRefund)
+	rev, err := current.ExecuteProgramRevision(current.NewRevisionNumber+1, transfer, details.Response.NewMerkleRoot, details.Response.NewSize)
+	if err != nil {
+		return errors.AddContext(err, "failed to create a program revision")
+	}
+
+	// create transaction containing the revision
+	signedTxn := rev.ToTransaction()
+	sig := sc.Sign(signedTxn.SigHash(0, bh))
+	signedTxn.TransactionSignatures[0].Signature = sig[:]
+
+	// record the update intent
+	walTxn, err := sc.RecordUpdateSectorIntent(rev, details.SectorIndex, details.Output.NewSectorRoot)
+	if err != nil {
+		return errors.AddContext(err, "failed to record sector update intent")
+	}
+
+	// send the revision signing request
+	newValidProofValues := make([]types.Currency, len(rev.NewValidProofOutputs))
+	for i, o := range rev.NewValidProofOutputs {
+		newValidProofValues[i] = o.Value
+	}
+	newMissedProofValues := make([]types.Currency, len(rev.NewMissedProofOutputs))
+	for i, o := range rev.NewMissedProofOutputs {
+		newMissedProofValues[i] = o.Value
+	}
+	err = modules.RPCWrite(stream, modules.RPCExecuteProgramRevisionSigningRequest{
+		Signature:            sig[:],
+		NewRevisionNumber:    rev.NewRevisionNumber,
+		NewValidProofValues:  newValidProofValues,
+		NewMissedProofValues: newMissedProofValues,
+	})
+	if err != nil {
+		return errors.AddContext(err, "unable to write the revision signing request")
+	}
+
+	// receive the host's signature
+	var resp modules.RPCExecuteProgramRevisionSigningResponse
+	err = modules.RPCRead(stream, &resp)
+	if err != nil {
+		return errors.AddContext(err, "unable to read the revision signing response")
+	}
+
+	// verify the host's signature
+	signedTxn.TransactionSignatures = append(signedTxn.TransactionSignatures, types.TransactionSignature{
+		ParentID:       crypto.Hash(rev.ParentID),
+		CoveredFields:  types.CoveredFields{FileContractRevisions: []uint64{0}},
+		PublicKeyIndex: 1,
+		Signature:      resp.Signature,
+	})
+	err = modules.VerifyFileContractRevisionTransactionSignatures(rev, signedTxn.TransactionSignatures, bh)
+	if err != nil {
+		return errors.AddContext(err, "could not verify host's signature")
+	}
+
+	// commit the update
+	return errors.AddContext(sc.CommitUpdateSector(walTxn, signedTxn), "failed to commit sector update")
+}
+
 // RecoveryScanStatus returns a bool indicating if a scan for recoverable
 // contracts is in progress and if it is, the current progress of the scan.
 func (c *Contractor) RecoveryScanStatus() (bool, types.BlockHeight) {
@@ -24,9 +24,11 @@ type directory struct {
 
 	// mu controlled fields
 	aggregateHealth       float64
+	aggregatePriority     modules.RepairPriority
 	aggregateRemoteHealth float64
 	explored              bool
 	health                float64
+	priority              modules.RepairPriority
 	remoteHealth          float64
 
 	mu sync.Mutex
@@ -65,6 +67,22 @@ func (d *directory) managedHeapHealth() (float64, bool) {
 	return health, false
 }
 
+// managedHeapPriority returns the repair priority that should be used to
+// prioritize the directory in the heap.
+//
+// If a directory is explored then we should use the priority of the directory
+// itself. If a directory is unexplored then we should also consider the
+// AggregatePriority to ensure that high priority sub directories are explored
+// first.
+func (d *directory) managedHeapPriority() modules.RepairPriority {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.explored && d.aggregatePriority > d.priority {
+		return d.aggregatePriority
+	}
+	return d.priority
+}
+
 // directoryHeap contains a priority sorted heap of directories that are being
 // explored and repaired
 type directoryHeap struct {
@@ -89,7 +107,17 @@ func (rdh repairDirectoryHeap) Less(i, j int) bool {
 	iHealth, iRemote := rdh[i].managedHeapHealth()
 	jHealth, jRemote := rdh[j].managedHeapHealth()
 
-	// Prioritize based on Remote first
+	// If both directories need to be repaired, prioritize based on the repair
+	// priority first
+	if modules.NeedsRepair(iHealth) && modules.NeedsRepair(jHealth) {
+		iPriority := rdh[i].managedHeapPriority()
+		jPriority := rdh[j].managedHeapPriority()
+		if iPriority != jPriority {
+			return iPriority > jPriority
+		}
+	}
+
+	// Prioritize based on Remote next
 	if iRemote && !jRemote {
 		return true
 	}
@@ -206,6 +234,11 @@ func (dh *directoryHeap) managedReset() {
 // Similarly, if either the new dir or the existing dir are marked as
 // unexplored, the new dir will be marked as unexplored to ensure that all
 // subdirs of the dir get added to the heap.
+//
+// The repair priority of the pushed dir replaces the existing one since it
+// reflects the directory's current setting. The aggregate priority keeps the
+// higher value, like the aggregate health, so that high priority subdirs are
+// not shadowed.
 func (dh *directoryHeap) update(d *directory) bool {
 	heapDir, exists := dh.heapDirectories[d.staticSiaPath]
 	if !exists {
@@ -214,6 +247,10 @@ func (dh *directoryHeap) update(d *directory) bool {
 	// Update the health fields of the directory in the heap.
 	heapDir.mu.Lock()
 	heapDir.aggregateHealth = math.Max(heapDir.aggregateHealth, d.aggregateHealth)
+	if d.aggregatePriority > heapDir.aggregatePriority {
+		heapDir.aggregatePriority = d.aggregatePriority
+	}
+	heapDir.priority = d.priority
 	heapDir.aggregateRemoteHealth = math.Max(heapDir.aggregateRemoteHealth, d.aggregateRemoteHealth)
 	heapDir.health = math.Max(heapDir.health, d.health)
 	heapDir.remoteHealth = math.Max(heapDir.remoteHealth, d.remoteHealth)
@@ -226,13 +263,20 @@ func (dh *directoryHeap) update(d *directory) bool {
 	return true
 }
 
-// managedPushDirectory adds a directory to the directory heap
-func (dh *directoryHeap) managedPushDirectory(siaPath modules.SiaPath, metadata siadir.Metadata, explored bool) {
+// managedPushDirectory adds a directory to the directory heap. If the directory
+// doesn't have a repair priority of its own, it uses parentPriority.
+func (dh *directoryHeap) managedPushDirectory(siaPath modules.SiaPath, metadata siadir.Metadata, explored bool, parentPriority modules.RepairPriority) {
+	priority := metadata.Priority
+	if priority == modules.RepairPriorityInherit {
+		priority = parentPriority
+	}
 	d := &directory{
 		aggregateHealth:       metadata.AggregateHealth,
+		aggregatePriority:     metadata.AggregatePriority,
 		aggregateRemoteHealth: metadata.AggregateRemoteHealth,
 		explored:              explored,
 		health:                metadata.Health,
+		priority:              priority,
 		remoteHealth:          metadata.RemoteHealth,
 		staticSiaPath:         siaPath,
 	}
@@ -291,8 +335,11 @@ func (r *Renter) managedPushSubDirectories(d *directory) error {
 		contextStr := fmt.Sprintf("unable to get subdirectories for `%v`", d.staticSiaPath)
 		return errors.AddContext(err, contextStr)
 	}
+	d.mu.Lock()
+	priority := d.priority
+	d.mu.Unlock()
 	for _, subDir := range subDirs {
-		err = r.managedPushUnexploredDirectory(subDir)
+		err = r.managedPushUnexploredSubDirectory(subDir, priority)
 		if err != nil {
 			contextStr := fmt.Sprintf("unable to push unexplored directory `%v`", subDir)
 			return errors.AddContext(err, contextStr)
@@ -302,8 +349,27 @@ func (r *Renter) managedPushSubDirectories(d *directory) error {
 }
 
 // managedPushUnexploredDirectory reads the health from the siadir metadata and
-// pushes an unexplored directory element onto the heap
-func (r *Renter) managedPushUnexploredDirectory(siaPath modules.SiaPath) (err error) {
+// pushes an unexplored directory element onto the heap. The repair priority the
+// directory inherits is looked up from its ancestors.
+func (r *Renter) managedPushUnexploredDirectory(siaPath modules.SiaPath) error {
+	parentPriority := modules.DefaultRepairPriority
+	if !siaPath.IsRoot() {
+		parent, err := siaPath.Dir()
+		if err != nil {
+			return err
+		}
+		parentPriority, err = r.managedRepairPriority(parent)
+		if err != nil {
+			return err
+		}
+	}
+	return r.managedPushUnexploredSubDirectory(siaPath, parentPriority)
+}
+
+// managedPushUnexploredSubDirectory reads the health from the siadir metadata
+// and pushes an unexplored directory element onto the heap. If the directory
+// doesn't have a repair priority of its own, it uses parentPriority.
+func (r *Renter) managedPushUnexploredSubDirectory(siaPath modules.SiaPath, parentPriority modules.RepairPriority) (err error) {
 	// Grab the siadir metadata. If it doesn't exist, create it.
 	siaDir, err := r.staticFileSystem.OpenSiaDirCustom(siaPath, true)
 	if err != nil {
@@ -318,6 +384,6 @@ func (r *Renter) managedPushUnexploredDirectory(siaPath modules.SiaPath) (err er
 	}
 
 	// Push unexplored directory onto heap.
-	r.directoryHeap.managedPushDirectory(siaPath, metadata, false)
+	r.directoryHeap.managedPushDirectory(siaPath, metadata, false, parentPriority)
 	return nil
 }
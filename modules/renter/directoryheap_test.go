@@ -548,3 +548,62 @@ func TestDirectoryHeapHealth(t *testing.T) {
 		t.Errorf("Expected heapHealth to be %v but was %v", d.health, heapHealth)
 	}
 }
+
+// TestDirectoryHeapPriority probes how the repair priority of directories
+// affects the order of the directory heap.
+func TestDirectoryHeapPriority(t *testing.T) {
+	dh := directoryHeap{
+		heapDirectories: make(map[modules.SiaPath]*directory),
+	}
+	newDir := func(health float64, priority modules.RepairPriority) *directory {
+		return &directory{
+			aggregateHealth: health,
+			explored:        true,
+			health:          health,
+			priority:        priority,
+			staticSiaPath:   modules.RandomSiaPath(),
+		}
+	}
+
+	// A high priority directory should be popped before directories with a
+	// worse health as long as it needs to be repaired. A healthy high
+	// priority directory should still be popped last.
+	healthy := newDir(0, modules.RepairPriorityHigh)
+	low := newDir(2, modules.RepairPriorityLow)
+	normal := newDir(1.5, modules.RepairPriorityNormal)
+	high := newDir(0.5, modules.RepairPriorityHigh)
+	for _, d := range []*directory{healthy, low, normal, high} {
+		dh.managedPush(d)
+	}
+	for _, expected := range []*directory{high, normal, low, healthy} {
+		if d := dh.managedPop(); d != expected {
+			t.Fatalf("expected %v but got %v", expected.staticSiaPath, d.staticSiaPath)
+		}
+	}
+
+	// An unexplored directory should use its aggregate priority if it is
+	// higher than its own priority.
+	unexplored := newDir(0.5, modules.RepairPriorityLow)
+	unexplored.explored = false
+	unexplored.aggregatePriority = modules.RepairPriorityHigh
+	if unexplored.managedHeapPriority() != modules.RepairPriorityHigh {
+		t.Fatal("unexplored directory should use its aggregate priority")
+	}
+	unexplored.explored = true
+	if unexplored.managedHeapPriority() != modules.RepairPriorityLow {
+		t.Fatal("explored directory should use its own priority")
+	}
+
+	// Pushing a directory again should replace its priority, even if the new
+	// priority is lower.
+	d := newDir(1, modules.RepairPriorityHigh)
+	dh.managedPush(d)
+	dh.managedPush(&directory{
+		explored:      true,
+		priority:      modules.RepairPriorityLow,
+		staticSiaPath: d.staticSiaPath,
+	})
+	if d.managedHeapPriority() != modules.RepairPriorityLow {
+		t.Fatal("update should replace the priority")
+	}
+}
@@ -1,6 +1,7 @@
 package renter
 
 import (
+	"fmt"
 	"os"
 	"sort"
 	"sync"
@@ -71,3 +72,60 @@ func (r *Renter) RenameDir(oldPath, newPath modules.SiaPath) error {
 	}
 	return r.staticFileSystem.RenameDir(oldPath, newPath)
 }
+
+// SetDirPriority sets the repair priority of a directory. Setting the priority
+// to RepairPriorityInherit makes the directory use the priority of its parent
+// again.
+func (r *Renter) SetDirPriority(siaPath modules.SiaPath, priority modules.RepairPriority) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	if priority > modules.RepairPriorityHigh {
+		return fmt.Errorf("unknown repair priority %v", priority)
+	}
+	dir, err := r.staticFileSystem.OpenSiaDir(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to open directory")
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	if err := dir.SetPriority(priority); err != nil {
+		return errors.AddContext(err, "unable to set priority")
+	}
+
+	// Queue a bubble to update the AggregatePriority of the directory and its
+	// parents, ignore the return channel as we do not want to block on this
+	// update.
+	_ = r.staticBubbleScheduler.callQueueBubble(siaPath)
+	return nil
+}
+
+// managedRepairPriority returns the repair priority of the directory at
+// siaPath. If the directory doesn't have a priority of its own, the priority of
+// the closest ancestor with a priority is returned.
+func (r *Renter) managedRepairPriority(siaPath modules.SiaPath) (modules.RepairPriority, error) {
+	for {
+		dir, err := r.staticFileSystem.OpenSiaDir(siaPath)
+		if err != nil {
+			return modules.DefaultRepairPriority, errors.AddContext(err, "unable to open directory")
+		}
+		md, err := dir.Metadata()
+		err = errors.Compose(err, dir.Close())
+		if err != nil {
+			return modules.DefaultRepairPriority, errors.AddContext(err, "unable to read directory metadata")
+		}
+		if md.Priority != modules.RepairPriorityInherit {
+			return md.Priority, nil
+		}
+		if siaPath.IsRoot() {
+			return modules.DefaultRepairPriority, nil
+		}
+		siaPath, err = siaPath.Dir()
+		if err != nil {
+			return modules.DefaultRepairPriority, errors.AddContext(err, "unable to get parent directory")
+		}
+	}
+}
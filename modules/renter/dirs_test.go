@@ -440,3 +440,104 @@ func compareDirectoryInfoAndMetadataCustom(di modules.DirectoryInfo, siaDir *fil
 	}
 	return nil
 }
+
+// TestSetDirPriority tests that the repair priority of a directory is
+// persisted, inherited by sub directories and bubbled up.
+func TestSetDirPriority(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTesterWithDependency(t.Name(), &dependencies.DependencyDisableRepairAndHealthLoops{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	foo, err := modules.NewSiaPath("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bar, err := foo.Join("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	baz, err := bar.Join("baz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CreateDir(baz, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+
+	// checkPriority checks the priority the directory at siaPath uses.
+	checkPriority := func(siaPath modules.SiaPath, expected modules.RepairPriority) {
+		t.Helper()
+		priority, err := r.managedRepairPriority(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if priority != expected {
+			t.Fatalf("expected priority %v for %v but got %v", expected, siaPath, priority)
+		}
+	}
+
+	// Without any priorities set, all directories use the default.
+	checkPriority(baz, modules.DefaultRepairPriority)
+
+	// Set a high priority on foo. Its sub directories should inherit it.
+	if err := r.SetDirPriority(foo, modules.RepairPriorityHigh); err != nil {
+		t.Fatal(err)
+	}
+	checkPriority(foo, modules.RepairPriorityHigh)
+	checkPriority(baz, modules.RepairPriorityHigh)
+	checkPriority(modules.RootSiaPath(), modules.DefaultRepairPriority)
+
+	// Set a low priority on bar. Only bar and baz should use it.
+	if err := r.SetDirPriority(bar, modules.RepairPriorityLow); err != nil {
+		t.Fatal(err)
+	}
+	checkPriority(foo, modules.RepairPriorityHigh)
+	checkPriority(bar, modules.RepairPriorityLow)
+	checkPriority(baz, modules.RepairPriorityLow)
+
+	// The priorities should be reported by the directory info and the
+	// aggregate priority should be bubbled to the root.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		dis, err := r.DirList(modules.RootSiaPath())
+		if err != nil {
+			return err
+		}
+		if dis[0].AggregatePriority != modules.RepairPriorityHigh {
+			return fmt.Errorf("expected root aggregate priority %v but got %v", modules.RepairPriorityHigh, dis[0].AggregatePriority)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dis, err := r.DirList(bar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dis[0].Priority != modules.RepairPriorityLow {
+		t.Fatal("wrong priority in directory info", dis[0].Priority)
+	}
+
+	// Reset bar to inherit the priority of foo again.
+	if err := r.SetDirPriority(bar, modules.RepairPriorityInherit); err != nil {
+		t.Fatal(err)
+	}
+	checkPriority(baz, modules.RepairPriorityHigh)
+
+	// Unknown priorities should be rejected.
+	if err := r.SetDirPriority(bar, modules.RepairPriorityHigh+1); err == nil {
+		t.Fatal("unknown priority should be rejected")
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/filesystem/siafile"
 	"go.sia.tech/siad/types"
@@ -65,6 +66,7 @@ type (
 
 	// downloadParams is the set of parameters to use when downloading a file.
 	downloadParams struct {
+		checksum          crypto.Hash         // Checksum to verify the downloaded data against. Ignored if empty.
 		destination       downloadDestination // The place to write the downloaded data.
 		destinationType   string              // "file", "buffer", "http stream", etc.
 		destinationString string              // The string to report to the user for the destination.
@@ -76,6 +78,7 @@ type (
 		offset            uint64              // Offset within the file to start the download. Must be less than the total filesize.
 		overdrive         int                 // How many extra pieces to download to prevent slow hosts from being a bottleneck.
 		priority          uint64              // Files with a higher priority will be downloaded first.
+		resume            bool                // Whether chunks already present in the destination should be skipped.
 
 		staticMemoryManager *memoryManager
 
@@ -111,6 +114,32 @@ func (d *download) managedFail(err error) {
 	d.markComplete()
 }
 
+// managedMarkComplete marks the download as complete after verifying the
+// downloaded data against the checksum of the file, if one was provided. The
+// data is verified without holding the lock since reading it back from disk
+// might take a while.
+func (d *download) managedMarkComplete() {
+	d.mu.Lock()
+	verify := d.err == nil && d.staticParams.checksum != (crypto.Hash{})
+	d.mu.Unlock()
+
+	var err error
+	if verify {
+		err = d.staticVerifyChecksum()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// The download might have failed in the meantime.
+	if d.staticComplete() {
+		return
+	}
+	if d.err == nil {
+		d.err = err
+	}
+	d.markComplete()
+}
+
 // markComplete is a helper method which closes the completeChan and and
 // executes the downloadCompleteFuncs. The completeChan should always be closed
 // using this method.
@@ -269,6 +298,9 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 	if p.Destination != "" && !filepath.IsAbs(p.Destination) {
 		return nil, errors.New("destination must be an absolute path")
 	}
+	if p.Resume && isHTTPResp {
+		return nil, errors.New("cannot resume download to http response")
+	}
 	if p.Offset == entry.Size() && entry.Size() != 0 {
 		return nil, errors.New("offset equals filesize")
 	}
@@ -284,6 +316,13 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 		return nil, fmt.Errorf("offset and length combination invalid, max byte is at index %d", entry.Size()-1)
 	}
 
+	// The checksum can only be verified if the whole file is downloaded to
+	// disk.
+	var checksum crypto.Hash
+	if !isHTTPResp && p.Offset == 0 && p.Length == entry.Size() {
+		checksum = entry.Checksum()
+	}
+
 	// Instantiate the correct downloadWriter implementation.
 	var dw downloadDestination
 	var destinationType string
@@ -291,7 +330,13 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 		dw = newDownloadDestinationWriter(p.Httpwriter)
 		destinationType = "http stream"
 	} else {
-		osFile, err := os.OpenFile(p.Destination, os.O_CREATE|os.O_WRONLY, entry.Mode())
+		// The destination only needs to be readable if chunks are verified
+		// or if the downloaded data is checked against the file's checksum.
+		flag := os.O_CREATE | os.O_WRONLY
+		if p.Resume || checksum != (crypto.Hash{}) {
+			flag = os.O_CREATE | os.O_RDWR
+		}
+		osFile, err := os.OpenFile(p.Destination, flag, entry.Mode())
 		if err != nil {
 			return nil, err
 		}
@@ -319,6 +364,7 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 	}
 	// Create the download object.
 	d, err := r.managedNewDownload(downloadParams{
+		checksum:          checksum,
 		destination:       dw,
 		destinationType:   destinationType,
 		destinationString: p.Destination,
@@ -331,6 +377,7 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 		offset:        p.Offset,
 		overdrive:     3, // TODO: moderate default until full overdrive support is added.
 		priority:      5, // TODO: moderate default until full priority support is added.
+		resume:        p.Resume,
 
 		staticMemoryManager:    r.userDownloadMemoryManager, // user initiated download
 		staticSpendingCategory: categoryDownload,
@@ -421,9 +468,7 @@ func (r *Renter) managedNewDownload(params downloadParams) (*download, error) {
 func (d *download) Start() error {
 	// Nothing more to do for 0-byte files or 0-length downloads.
 	if d.staticLength == 0 {
-		d.mu.Lock()
-		d.markComplete()
-		d.mu.Unlock()
+		d.managedMarkComplete()
 		return nil
 	}
 
@@ -468,9 +513,24 @@ func (d *download) Start() error {
 		}
 	}
 
+	// If the download is resumed, figure out which chunks don't need to be
+	// downloaded again.
+	var present map[uint64]struct{}
+	if params.resume {
+		var err error
+		present, err = d.managedPresentChunks(minChunk, maxChunk)
+		if err != nil {
+			return errors.AddContext(err, "unable to determine chunks present in destination")
+		}
+	}
+
 	// Queue the downloads for each chunk.
 	writeOffset := int64(0) // where to write a chunk within the download destination.
-	d.chunksRemaining += maxChunk - minChunk + 1
+	d.chunksRemaining += maxChunk - minChunk + 1 - uint64(len(present))
+	if d.chunksRemaining == 0 {
+		d.managedMarkComplete()
+		return nil
+	}
 	for i := minChunk; i <= maxChunk; i++ {
 		udc := &unfinishedDownloadChunk{
 			destination: params.destination,
@@ -527,6 +587,11 @@ func (d *download) Start() error {
 		udc.staticWriteOffset = writeOffset
 		writeOffset += int64(udc.staticFetchLength)
 
+		// Skip the chunk if it is already present in the destination.
+		if _, skip := present[i]; skip {
+			continue
+		}
+
 		// TODO: Currently all chunks are given overdrive. This should probably
 		// be changed once the hostdb knows how to measure host speed/latency
 		// and once we can assign overdrive dynamically.
@@ -152,11 +152,12 @@ func (udc *unfinishedDownloadChunk) managedFinalizeRecovery() {
 
 	// Update the download and signal completion of this chunk.
 	udc.download.mu.Lock()
-	defer udc.download.mu.Unlock()
 	udc.download.chunksRemaining--
-	if udc.download.chunksRemaining == 0 {
+	complete := udc.download.chunksRemaining == 0
+	udc.download.mu.Unlock()
+	if complete {
 		// Download is complete, send out a notification.
-		udc.download.markComplete()
+		udc.download.managedMarkComplete()
 	}
 }
 
@@ -0,0 +1,154 @@
+package renter
+
+// Resuming Downloads:
+// A download to a local file can be resumed by setting the Resume flag of the
+// download parameters. Before any chunks are queued, the renter checks which
+// chunks of the requested range are already present in the destination file. A
+// chunk is considered present if its data in the destination re-encodes to
+// pieces whose merkle roots match the roots recorded in the SiaFile. Only
+// chunks which are fully covered by the requested range can be checked that
+// way. All other chunks are downloaded again.
+//
+// Checksums:
+// A SiaFile can store an optional hash of the plaintext data of the file. The
+// hash is computed at upload time if requested by the upload parameters. If a
+// file has a checksum, every download of the whole file to a local destination
+// is verified against it before the download is marked as complete.
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+)
+
+var (
+	// errDownloadChecksumMismatch is returned if the downloaded data doesn't
+	// match the checksum stored in the SiaFile.
+	errDownloadChecksumMismatch = errors.New("downloaded data doesn't match the checksum of the file")
+)
+
+// checksumReader returns the checksum of all the data read from r.
+func checksumReader(r io.Reader) (checksum crypto.Hash, err error) {
+	h := crypto.NewHash()
+	if _, err := io.Copy(h, r); err != nil {
+		return crypto.Hash{}, err
+	}
+	copy(checksum[:], h.Sum(nil))
+	return checksum, nil
+}
+
+// checksumFile returns the checksum of the file at path.
+func checksumFile(path string) (_ crypto.Hash, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return crypto.Hash{}, err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	return checksumReader(f)
+}
+
+// staticVerifyChunkData checks whether data is the logical data of the chunk
+// at chunkIndex of the file. The data is erasure coded and encrypted the same
+// way it was during the upload and the resulting pieces are compared to the
+// piece roots of the file. The data is considered correct if none of the
+// checked pieces mismatch and if enough pieces matched to recover the chunk.
+func staticVerifyChunkData(file *siafile.Snapshot, chunkIndex uint64, data []byte) bool {
+	ec := file.ErasureCode()
+	dataPieces, _, err := readDataPieces(bytes.NewReader(data), ec, file.PieceSize())
+	if err != nil {
+		return false
+	}
+	logicalChunkData, err := ec.EncodeShards(dataPieces)
+	if err != nil {
+		return false
+	}
+	var matches int
+	for pieceIndex, pieceSet := range file.Pieces(chunkIndex) {
+		if len(pieceSet) == 0 {
+			continue
+		}
+		padAndEncryptPiece(chunkIndex, uint64(pieceIndex), logicalChunkData, file.MasterKey())
+		root := crypto.MerkleRoot(logicalChunkData[pieceIndex])
+		for _, piece := range pieceSet {
+			if piece.MerkleRoot != root {
+				return false
+			}
+		}
+		matches++
+		if matches >= ec.MinPieces() {
+			return true
+		}
+	}
+	return false
+}
+
+// managedPresentChunks returns the chunks between minChunk and maxChunk which
+// are already present in the download's destination file. The amount of data
+// within these chunks is added to the download's progress.
+func (d *download) managedPresentChunks(minChunk, maxChunk uint64) (map[uint64]struct{}, error) {
+	params := d.staticParams
+	ddf, ok := params.destination.(*downloadDestinationFile)
+	if !ok {
+		return nil, errors.New("only downloads to a file can be resumed")
+	}
+	fi, err := ddf.f.Stat()
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to stat destination file")
+	}
+	destinationSize := uint64(fi.Size())
+
+	present := make(map[uint64]struct{})
+	chunkSize := params.file.ChunkSize()
+	for chunkIndex := minChunk; chunkIndex <= maxChunk; chunkIndex++ {
+		// Only chunks which are covered completely by the download can be
+		// verified.
+		chunkStart := chunkIndex * chunkSize
+		chunkEnd := chunkStart + chunkSize
+		if chunkEnd > params.file.Size() {
+			chunkEnd = params.file.Size()
+		}
+		if chunkStart < params.offset || chunkEnd > params.offset+params.length {
+			continue
+		}
+		// Check if the destination contains the chunk's data.
+		writeOffset := chunkStart - params.offset
+		if writeOffset+chunkEnd-chunkStart > destinationSize {
+			break
+		}
+		data := make([]byte, chunkEnd-chunkStart)
+		if _, err := ddf.f.ReadAt(data, int64(writeOffset)); err != nil {
+			return nil, errors.AddContext(err, "unable to read chunk from destination file")
+		}
+		if !staticVerifyChunkData(params.file, chunkIndex, data) {
+			continue
+		}
+		present[chunkIndex] = struct{}{}
+		atomic.AddUint64(&d.atomicDataReceived, uint64(len(data)))
+	}
+	return present, nil
+}
+
+// staticVerifyChecksum checks that the data in the download's destination file
+// matches the checksum of the SiaFile.
+func (d *download) staticVerifyChecksum() error {
+	ddf, ok := d.staticParams.destination.(*downloadDestinationFile)
+	if !ok {
+		return errors.New("only downloads to a file can be verified")
+	}
+	checksum, err := checksumReader(io.NewSectionReader(ddf.f, 0, int64(d.staticLength)))
+	if err != nil {
+		return errors.AddContext(err, "unable to compute checksum of downloaded data")
+	}
+	if checksum != d.staticParams.checksum {
+		return errDownloadChecksumMismatch
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package renter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/types"
+)
+
+// newVerifiableTestFile creates a single chunk test file and adds the roots of
+// its parity pieces, which is enough to verify the chunk's data. The data of
+// the file is returned together with the file.
+func (r *Renter) newVerifiableTestFile() (*filesystem.FileNode, []byte, error) {
+	ec, err := modules.NewRSSubCode(2, 2, crypto.SegmentSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	node, err := r.createRenterTestFileWithParams(modules.RandomSiaPath(), ec, crypto.TypeDefaultRenter)
+	if err != nil {
+		return nil, nil, err
+	}
+	data := fastrand.Bytes(int(node.Size()))
+	dataPieces, _, err := readDataPieces(bytes.NewReader(data), ec, node.PieceSize())
+	if err != nil {
+		return nil, nil, errors.Compose(err, node.Close())
+	}
+	pieces, err := ec.EncodeShards(dataPieces)
+	if err != nil {
+		return nil, nil, errors.Compose(err, node.Close())
+	}
+	for pieceIndex := ec.MinPieces(); pieceIndex < ec.NumPieces(); pieceIndex++ {
+		padAndEncryptPiece(0, uint64(pieceIndex), pieces, node.MasterKey())
+		pk := types.SiaPublicKey{Key: fastrand.Bytes(32)}
+		if err := node.AddPiece(pk, 0, uint64(pieceIndex), crypto.MerkleRoot(pieces[pieceIndex])); err != nil {
+			return nil, nil, errors.Compose(err, node.Close())
+		}
+	}
+	return node, data, nil
+}
+
+// TestStaticVerifyChunkData tests that staticVerifyChunkData only accepts the
+// data which matches the piece roots of a chunk.
+func TestStaticVerifyChunkData(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// A chunk without pieces can't be verified.
+	node, err := r.createRenterTestFile(modules.RandomSiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := node.Snapshot(modules.RandomSiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if staticVerifyChunkData(snap, 0, fastrand.Bytes(int(node.Size()))) {
+		t.Fatal("chunk without pieces shouldn't be verified")
+	}
+	if err := node.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A chunk with enough piece roots can be verified.
+	node, data, err := r.newVerifiableTestFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := node.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	snap, err = node.Snapshot(modules.RandomSiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !staticVerifyChunkData(snap, 0, data) {
+		t.Fatal("correct data should be verified")
+	}
+
+	// Corrupt the data.
+	data[fastrand.Intn(len(data))]++
+	if staticVerifyChunkData(snap, 0, data) {
+		t.Fatal("corrupted data shouldn't be verified")
+	}
+}
+
+// TestDownloadResumeAndChecksum tests that a resumed download skips the chunks
+// which are already present in the destination and that the downloaded data
+// is verified against the file's checksum.
+func TestDownloadResumeAndChecksum(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	node, data, err := r.newVerifiableTestFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := node.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	snap, err := node.Snapshot(modules.RandomSiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Write the file's data to the destination.
+	path := filepath.Join(r.persistDir, "download")
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := r.managedNewDownload(downloadParams{
+		checksum:    crypto.HashBytes(data),
+		destination: &downloadDestinationFile{f: f, staticChunkSize: int64(snap.ChunkSize())},
+		file:        snap,
+		length:      snap.Size(),
+		resume:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.OnComplete(func(_ error) error {
+		return f.Close()
+	})
+
+	// The chunk should be present.
+	present, err := d.managedPresentChunks(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := present[0]; !exists || len(present) != 1 {
+		t.Fatal("chunk should be present", present)
+	}
+	if d.atomicDataReceived != snap.Size() {
+		t.Fatal("wrong amount of data received", d.atomicDataReceived)
+	}
+	d.atomicDataReceived = 0
+
+	// The checksum should match.
+	if err := d.staticVerifyChecksum(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Starting the download shouldn't queue any chunks and complete the
+	// download right away.
+	if err := d.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if !d.staticComplete() || d.Err() != nil {
+		t.Fatal("download should be complete", d.Err())
+	}
+
+	// Corrupt the destination. The chunk shouldn't be present anymore and
+	// the checksum shouldn't match.
+	data[fastrand.Intn(len(data))]++
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	f, err = os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	d.staticParams.destination = &downloadDestinationFile{f: f, staticChunkSize: int64(snap.ChunkSize())}
+	d.staticParams.file = snap
+	present, err = d.managedPresentChunks(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(present) != 0 {
+		t.Fatal("corrupted chunk shouldn't be present")
+	}
+	if err := d.staticVerifyChecksum(); !errors.Contains(err, errDownloadChecksumMismatch) {
+		t.Fatal("expected errDownloadChecksumMismatch but got", err)
+	}
+}
+
+// TestChecksumReader tests that checksumReader computes the same hash as
+// crypto.HashBytes.
+func TestChecksumReader(t *testing.T) {
+	t.Parallel()
+
+	data := fastrand.Bytes(fastrand.Intn(1000))
+	checksum, err := checksumReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checksum != crypto.HashBytes(data) {
+		t.Fatal("checksums don't match")
+	}
+}
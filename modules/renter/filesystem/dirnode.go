@@ -134,6 +134,17 @@ func (n *DirNode) Path() (string, error) {
 	return sd.Path(), nil
 }
 
+// SetPriority is a wrapper for SiaDir.SetPriority.
+func (n *DirNode) SetPriority(priority modules.RepairPriority) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sd, err := n.siaDir()
+	if err != nil {
+		return err
+	}
+	return sd.SetPriority(priority)
+}
+
 // UpdateBubbledMetadata is a wrapper for SiaDir.UpdateBubbledMetadata.
 func (n *DirNode) UpdateBubbledMetadata(md siadir.Metadata) error {
 	n.mu.Lock()
@@ -592,6 +603,10 @@ func (n *DirNode) managedInfo(siaPath modules.SiaPath) (modules.DirectoryInfo, e
 		AggregateStuckHealth:         metadata.AggregateStuckHealth,
 		AggregateStuckSize:           metadata.AggregateStuckSize,
 
+		// Priority Fields
+		AggregatePriority: metadata.AggregatePriority,
+		Priority:          metadata.Priority,
+
 		// SiaDir Fields
 		Health:              metadata.Health,
 		LastHealthCheckTime: metadata.LastHealthCheckTime,
@@ -141,6 +141,7 @@ func (n *FileNode) managedFileInfo(siaPath modules.SiaPath, offline map[string]b
 		AccessTime:       n.AccessTime(),
 		Available:        redundancy >= 1,
 		ChangeTime:       n.ChangeTime(),
+		Checksum:         n.Checksum(),
 		CipherType:       n.MasterKey().Type().String(),
 		CreateTime:       n.CreateTime(),
 		Expiration:       n.Expiration(contracts),
@@ -207,6 +208,38 @@ func (n *FileNode) managedRename(newName string, oldParent, newParent *DirNode)
 	return err
 }
 
+// managedReplace moves the fNode's underlying file to the location of target
+// and deletes target.
+func (n *FileNode) managedReplace(target *FileNode, oldParent, newParent *DirNode) error {
+	// Lock the parents. If they are the same, only lock one.
+	if oldParent.staticUID == newParent.staticUID {
+		oldParent.node.mu.Lock()
+		defer oldParent.node.mu.Unlock()
+	} else {
+		oldParent.node.mu.Lock()
+		defer oldParent.node.mu.Unlock()
+		newParent.node.mu.Lock()
+		defer newParent.node.mu.Unlock()
+	}
+	n.node.mu.Lock()
+	defer n.node.mu.Unlock()
+	target.node.mu.Lock()
+	defer target.node.mu.Unlock()
+	// Replace the file.
+	if err := n.SiaFile.Replace(target.SiaFile); err != nil {
+		return err
+	}
+	// Remove both files from their parents and add the file to the new parent
+	// in place of the target.
+	oldParent.removeFile(n)
+	newParent.removeFile(target)
+	n.parent = newParent
+	*n.name = *target.name
+	*n.path = *target.path
+	n.parent.files[*n.name] = n
+	return nil
+}
+
 // cachedFileInfo returns information on a siafile. As a performance
 // optimization, the fileInfo takes the maps returned by
 // renter.managedContractUtilityMaps for many files at once.
@@ -225,6 +258,7 @@ func (n *FileNode) staticCachedInfo(siaPath modules.SiaPath) (modules.FileInfo,
 		AccessTime:       md.AccessTime,
 		Available:        md.CachedUserRedundancy >= 1,
 		ChangeTime:       md.ChangeTime,
+		Checksum:         md.Checksum,
 		CipherType:       md.StaticMasterKeyType.String(),
 		CreateTime:       md.CreateTime,
 		Expiration:       md.CachedExpiration,
@@ -519,6 +519,51 @@ func (fs *FileSystem) RenameFile(oldSiaPath, newSiaPath modules.SiaPath) (err er
 	return sf.managedRename(newSiaPath.Name(), oldDir, newDir)
 }
 
+// ReplaceFile moves the file at newSiaPath to siaPath and deletes the file
+// that was previously stored at siaPath. Both changes are applied atomically.
+func (fs *FileSystem) ReplaceFile(siaPath, newSiaPath modules.SiaPath) (err error) {
+	// Open the file to replace and its SiaDir.
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		return err
+	}
+	dir, err := fs.managedOpenSiaDir(dirSiaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	target, err := dir.managedOpenFile(siaPath.Name())
+	if err != nil {
+		return errors.AddContext(err, "failed to open file to replace")
+	}
+	defer func() {
+		err = errors.Compose(err, target.Close())
+	}()
+
+	// Open the replacement and its SiaDir.
+	newDirSiaPath, err := newSiaPath.Dir()
+	if err != nil {
+		return err
+	}
+	newDir, err := fs.managedOpenSiaDir(newDirSiaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, newDir.Close())
+	}()
+	sf, err := newDir.managedOpenFile(newSiaPath.Name())
+	if err != nil {
+		return errors.AddContext(err, "failed to open replacement file")
+	}
+	defer func() {
+		err = errors.Compose(err, sf.Close())
+	}()
+	return sf.managedReplace(target, newDir, dir)
+}
+
 // RenameDir takes an existing directory and changes the path. The original
 // directory must exist, and there must not be any directory that already has
 // the replacement path.  All sia files within directory will also be renamed
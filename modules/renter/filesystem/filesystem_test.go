@@ -757,6 +757,73 @@ func TestRenameFile(t *testing.T) {
 	sf.Close()
 }
 
+// TestReplaceFile tests replacing a file with another one.
+func TestReplaceFile(t *testing.T) {
+	if testing.Short() && !build.VLONG {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// Create filesystem.
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	fs := newTestFileSystem(root)
+	// Add two files in different dirs.
+	foo := newSiaPath("foo")
+	barfoo := newSiaPath("bar/foo")
+	fs.addTestSiaFile(foo)
+	fs.addTestSiaFile(barfoo)
+	// Keep the original open.
+	old, err := fs.OpenSiaFile(foo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf, err := fs.OpenSiaFile(barfoo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := sf.UID()
+	if err := sf.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Replace foo with bar/foo.
+	if err := fs.ReplaceFile(foo, barfoo); err != nil {
+		t.Fatal(err)
+	}
+	if !old.Deleted() {
+		t.Fatal("original should be deleted")
+	}
+	if err := old.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.OpenSiaFile(barfoo); !errors.Contains(err, ErrNotExist) {
+		t.Fatal("expected ErrNotExist but got:", err)
+	}
+	sf, err = fs.OpenSiaFile(foo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.UID() != uid {
+		t.Fatal("file wasn't replaced")
+	}
+	if err := sf.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// The replacement should also be found after reloading the filesystem.
+	sf, err = newTestFileSystem(root).OpenSiaFile(foo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.UID() != uid {
+		t.Fatal("file wasn't replaced on disk")
+	}
+	if err := sf.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Replacing a file that doesn't exist should fail.
+	if err := fs.ReplaceFile(foo, barfoo); !errors.Contains(err, ErrNotExist) {
+		t.Fatal("expected ErrNotExist but got:", err)
+	}
+}
+
 // TestThreadedAccess tests rapidly opening and closing files and directories
 // from multiple threads to check the locking conventions.
 func TestThreadedAccess(t *testing.T) {
@@ -149,10 +149,28 @@ func (sd *SiaDir) UpdateBubbledMetadata(metadata Metadata) error {
 	sd.mu.Lock()
 	defer sd.mu.Unlock()
 	metadata.Mode = sd.metadata.Mode
+	metadata.Priority = sd.metadata.Priority
 	metadata.Version = sd.metadata.Version
+	if metadata.Priority > metadata.AggregatePriority {
+		metadata.AggregatePriority = metadata.Priority
+	}
 	return sd.updateMetadata(metadata)
 }
 
+// SetPriority sets the repair priority of the SiaDir and saves the change to
+// disk. The AggregatePriority is only raised. Lowering it requires a bubble
+// since the sub tree might contain a higher priority.
+func (sd *SiaDir) SetPriority(priority modules.RepairPriority) error {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	md := sd.metadata
+	md.Priority = priority
+	if priority > md.AggregatePriority {
+		md.AggregatePriority = priority
+	}
+	return sd.updateMetadata(md)
+}
+
 // UpdateLastHealthCheckTime updates the SiaDir LastHealthCheckTime and
 // AggregateLastHealthCheckTime and saves the changes to disk
 func (sd *SiaDir) UpdateLastHealthCheckTime(aggregateLastHealthCheckTime, lastHealthCheckTime time.Time) error {
@@ -211,6 +229,9 @@ func (sd *SiaDir) updateMetadata(metadata Metadata) error {
 	sd.metadata.AggregateStuckHealth = metadata.AggregateStuckHealth
 	sd.metadata.AggregateStuckSize = metadata.AggregateStuckSize
 
+	sd.metadata.AggregatePriority = metadata.AggregatePriority
+	sd.metadata.Priority = metadata.Priority
+
 	sd.metadata.Health = metadata.Health
 	sd.metadata.LastHealthCheckTime = metadata.LastHealthCheckTime
 	sd.metadata.MinRedundancy = metadata.MinRedundancy
@@ -71,6 +71,13 @@ type (
 		AggregateStuckHealth         float64   `json:"aggregatestuckhealth"`
 		AggregateStuckSize           uint64    `json:"aggregatestucksize"`
 
+		// AggregatePriority is the highest repair priority set on the siadir
+		// or any siadir in its sub tree. Priority is the repair priority set
+		// on the siadir itself. Unlike the other siadir specific fields, the
+		// Priority is set by the user and is not bubbled.
+		AggregatePriority modules.RepairPriority `json:"aggregatepriority"`
+		Priority          modules.RepairPriority `json:"priority"`
+
 		// The following fields are information specific to the siadir that is not
 		// an aggregate of the entire sub directory tree
 		Health              float64     `json:"health"`
@@ -55,6 +55,10 @@ type (
 		StaticSharingKey     []byte            `json:"sharingkey"` // key used to encrypt shared pieces
 		StaticSharingKeyType crypto.CipherType `json:"sharingkeytype"`
 
+		// Checksum is an optional hash of the plaintext data of the file which
+		// is recorded at upload time and used to verify full downloads.
+		Checksum crypto.Hash `json:"checksum"`
+
 		// Fields for partial uploads
 		DisablePartialChunk bool               `json:"disablepartialchunk"` // determines whether the file should be treated like legacy files
 		PartialChunks       []PartialChunkInfo `json:"partialchunks"`       // information about the partial chunk.
@@ -230,6 +234,14 @@ func (sf *SiaFile) LastHealthCheckTime() time.Time {
 	return sf.staticMetadata.LastHealthCheckTime
 }
 
+// Checksum returns the hash of the file's plaintext data. It is empty if no
+// checksum was recorded for the file.
+func (sf *SiaFile) Checksum() crypto.Hash {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.Checksum
+}
+
 // LocalPath returns the path of the local data of the file.
 func (sf *SiaFile) LocalPath() string {
 	sf.mu.RLock()
@@ -288,6 +300,19 @@ func (sf *SiaFile) Rename(newSiaFilePath string) error {
 	return sf.rename(newSiaFilePath)
 }
 
+// Replace moves the file to the location of the file old and deletes old. Both
+// changes are applied within a single wal transaction.
+func (sf *SiaFile) Replace(old *SiaFile) error {
+	if sf == old {
+		return errors.New("can't replace siafile with itself")
+	}
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	old.mu.Lock()
+	defer old.mu.Unlock()
+	return sf.replace(old)
+}
+
 // backup creates a deep-copy of a Metadata.
 func (md Metadata) backup() (b Metadata) {
 	// Copy the static fields first. They are shallow copies since they are not
@@ -309,6 +334,7 @@ func (md Metadata) backup() (b Metadata) {
 	b.UniqueID = md.UniqueID
 	b.FileSize = md.FileSize
 	b.LocalPath = md.LocalPath
+	b.Checksum = md.Checksum
 	b.DisablePartialChunk = md.DisablePartialChunk
 	b.HasPartialChunk = md.HasPartialChunk
 	b.ModTime = md.ModTime
@@ -360,6 +386,7 @@ func (md *Metadata) restore(b Metadata) {
 	md.UniqueID = b.UniqueID
 	md.FileSize = b.FileSize
 	md.LocalPath = b.LocalPath
+	md.Checksum = b.Checksum
 	md.DisablePartialChunk = b.DisablePartialChunk
 	md.PartialChunks = b.PartialChunks
 	md.HasPartialChunk = b.HasPartialChunk
@@ -442,11 +469,51 @@ func (sf *SiaFile) rename(newSiaFilePath string) (err error) {
 	if err != nil {
 		return err
 	}
+	// Apply updates.
+	updates, err := sf.moveUpdates(newSiaFilePath)
+	if err != nil {
+		return err
+	}
+	return createAndApplyTransaction(sf.wal, updates...)
+}
+
+// replace moves the file to the location of the file old and deletes old
+// within a single wal transaction. That way the location of old always
+// contains one of the two files, even after a crash.
+func (sf *SiaFile) replace(old *SiaFile) (err error) {
+	if sf.deleted || old.deleted {
+		return errors.New("can't replace deleted siafile")
+	}
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	oldPath := sf.siaFilePath
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+			sf.siaFilePath = oldPath
+		}
+	}(sf.staticMetadata.backup())
+	// Delete old first to make room for the moved file.
+	moveUpdates, err := sf.moveUpdates(old.siaFilePath)
+	if err != nil {
+		return err
+	}
+	updates := append([]writeaheadlog.Update{old.createDeleteUpdate()}, moveUpdates...)
+	if err := createAndApplyTransaction(sf.wal, updates...); err != nil {
+		return err
+	}
+	old.deleted = true
+	return nil
+}
+
+// moveUpdates changes the in-memory path of the file to newSiaFilePath and
+// returns the updates which move the file on disk.
+func (sf *SiaFile) moveUpdates(newSiaFilePath string) ([]writeaheadlog.Update, error) {
 	// Create the delete update before changing the path to the new one.
 	updates := []writeaheadlog.Update{sf.createDeleteUpdate()}
 	// Load all the chunks.
 	chunks := make([]chunk, 0, sf.numChunks)
-	err = sf.iterateChunksReadonly(func(chunk chunk) error {
+	err := sf.iterateChunksReadonly(func(chunk chunk) error {
 		if _, ok := sf.isIncludedPartialChunk(uint64(chunk.Index)); ok {
 			return nil // Ignore partial chunk
 		}
@@ -454,7 +521,7 @@ func (sf *SiaFile) rename(newSiaFilePath string) (err error) {
 		return nil
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// Rename file in memory.
 	sf.siaFilePath = newSiaFilePath
@@ -463,15 +530,14 @@ func (sf *SiaFile) rename(newSiaFilePath string) (err error) {
 	// Write the header to the new location.
 	headerUpdate, err := sf.saveHeaderUpdates()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	updates = append(updates, headerUpdate...)
 	// Write the chunks to the new location.
 	for _, chunk := range chunks {
 		updates = append(updates, sf.saveChunkUpdate(chunk))
 	}
-	// Apply updates.
-	return createAndApplyTransaction(sf.wal, updates...)
+	return updates, nil
 }
 
 // SetMode sets the filemode of the sia file.
@@ -530,6 +596,29 @@ func (sf *SiaFile) SetLocalPath(path string) (err error) {
 	return sf.createAndApplyTransaction(updates...)
 }
 
+// SetChecksum sets the hash of the file's plaintext data which is used to
+// verify downloads of the whole file.
+func (sf *SiaFile) SetChecksum(checksum crypto.Hash) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	sf.staticMetadata.Checksum = checksum
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
 // Size returns the file's size.
 func (sf *SiaFile) Size() uint64 {
 	sf.mu.RLock()
@@ -18,6 +18,7 @@ type (
 	// can be accessed without locking at the cost of being a frozen readonly
 	// representation of a siafile which only exists in memory.
 	Snapshot struct {
+		staticChecksum        crypto.Hash
 		staticChunks          []Chunk
 		staticFileSize        int64
 		staticPieceSize       uint64
@@ -144,6 +145,11 @@ func (s *Snapshot) IsIncompletePartialChunk(chunkIndex uint64) bool {
 	return s.staticPartialChunks[idx].Status < CombinedChunkStatusCompleted
 }
 
+// Checksum returns the hash of the file's plaintext data.
+func (s *Snapshot) Checksum() crypto.Hash {
+	return s.staticChecksum
+}
+
 // LocalPath returns the localPath used to repair the file.
 func (s *Snapshot) LocalPath() string {
 	return s.staticLocalPath
@@ -280,8 +286,10 @@ func (sf *SiaFile) readlockSnapshot(sp modules.SiaPath, chunks []chunk) (*Snapsh
 	hasPartial := sf.staticMetadata.HasPartialChunk
 	pcs := sf.staticMetadata.PartialChunks
 	localPath := sf.staticMetadata.LocalPath
+	checksum := sf.staticMetadata.Checksum
 
 	return &Snapshot{
+		staticChecksum:        checksum,
 		staticChunks:          exportedChunks,
 		staticPartialChunks:   pcs,
 		staticHasPartialChunk: hasPartial,
@@ -232,6 +232,9 @@ func (r *Renter) callCalculateDirectoryMetadata(siaPath modules.SiaPath) (siadir
 			metadata.AggregateRepairSize += dirMetadata.AggregateRepairSize
 			metadata.AggregateSize += dirMetadata.AggregateSize
 			metadata.AggregateStuckSize += dirMetadata.AggregateStuckSize
+			if dirMetadata.AggregatePriority > metadata.AggregatePriority {
+				metadata.AggregatePriority = dirMetadata.AggregatePriority
+			}
 
 			// Add 1 to the AggregateNumSubDirs to account for this subdirectory.
 			metadata.AggregateNumSubDirs++
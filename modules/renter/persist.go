@@ -56,10 +56,11 @@ var (
 type (
 	// persist contains all of the persistent renter data.
 	persistence struct {
-		MaxDownloadSpeed int64
-		MaxUploadSpeed   int64
-		UploadedBackups  []modules.UploadedBackup
-		SyncedContracts  []types.FileContractID
+		MaxDownloadSpeed  int64
+		MaxUploadSpeed    int64
+		BandwidthSchedule modules.BandwidthSchedule
+		UploadedBackups   []modules.UploadedBackup
+		SyncedContracts   []types.FileContractID
 	}
 )
 
@@ -294,6 +294,41 @@ func (c *SafeContract) RecordPaymentIntent(rev types.FileContractRevision, amoun
 	return t, nil
 }
 
+// RecordUpdateSectorIntent creates a WAL update that replaces the root of the
+// sector at the given index after the host updated a range of its data.
+func (c *SafeContract) RecordUpdateSectorIntent(rev types.FileContractRevision, index uint64, root crypto.Hash) (*unappliedWalTxn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if index >= uint64(c.merkleRoots.len()) {
+		return nil, fmt.Errorf("sector index %v out of bounds for contract with %v roots", index, c.merkleRoots.len())
+	}
+
+	// NOTE: this header will not include the host signature
+	newHeader := c.header
+	newHeader.Transaction.FileContractRevisions = []types.FileContractRevision{rev}
+	newHeader.Transaction.TransactionSignatures = nil
+
+	t, err := c.newWalTxn([]writeaheadlog.Update{
+		c.makeUpdateSetHeader(newHeader),
+		c.makeUpdateSetRoot(root, int(index)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := <-t.SignalSetupComplete(); err != nil {
+		return nil, err
+	}
+	c.unappliedTxns = append(c.unappliedTxns, t)
+	return t, nil
+}
+
+// CommitUpdateSector commits the intent to update a sector by applying the
+// signed txn to the contract's header and replacing the sector's root. See
+// managedCommitAppend.
+func (c *SafeContract) CommitUpdateSector(t *unappliedWalTxn, signedTxn types.Transaction) error {
+	return c.managedCommitAppend(t, signedTxn, types.ZeroCurrency, types.ZeroCurrency)
+}
+
 // Sign will sign the given hash using the safecontract's secret key
 func (c *SafeContract) Sign(hash crypto.Hash) crypto.Signature {
 	c.mu.Lock()
@@ -0,0 +1,554 @@
+package renter
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/persist"
+)
+
+// Re-encoding Overview:
+// A file's erasure code is fixed when the SiaFile is created. To change it,
+// the renter streams the file's data from the network into a new SiaFile with
+// the requested erasure code. The new SiaFile lives in the ReencodeFolder
+// while it is being uploaded, which means that it is picked up by the regular
+// repair loop like any other file. Once the new SiaFile reaches full health,
+// it replaces the original SiaFile within a single filesystem operation.
+//
+// The ongoing re-encodes are persisted and resumed on startup. A re-encode is
+// aborted if the original file is deleted, replaced or resized, if it doesn't
+// reach full health within reencodeTimeout or if it is cancelled by the user.
+
+var (
+	// errReencodeInProgress is returned if a re-encode is requested for a file
+	// that is already being re-encoded.
+	errReencodeInProgress = errors.New("file is already being re-encoded")
+
+	// errReencodeNotFound is returned if a re-encode is cancelled for a file
+	// that isn't being re-encoded.
+	errReencodeNotFound = errors.New("file isn't being re-encoded")
+
+	// errReencodeSameErasureCode is returned if a re-encode is requested with
+	// the erasure code the file already uses.
+	errReencodeSameErasureCode = errors.New("file already uses the requested erasure code")
+
+	// errReencodeSourceChanged is returned if the original file changed while
+	// it was being re-encoded.
+	errReencodeSourceChanged = errors.New("original file changed during re-encode")
+
+	// errReencodeCancelled is returned if a re-encode was cancelled.
+	errReencodeCancelled = errors.New("re-encode was cancelled")
+
+	// errReencodeTimeout is returned if the re-encoded file didn't reach full
+	// health in time.
+	errReencodeTimeout = errors.New("re-encoded file didn't reach full health in time")
+
+	// reencodeMetadata is the persist metadata of the reencodeSet.
+	reencodeMetadata = persist.Metadata{
+		Header:  "Re-encodes",
+		Version: "1.5.6",
+	}
+)
+
+var (
+	// reencodeHealthCheckInterval is how often a background re-encode checks
+	// whether the new SiaFile has reached full health.
+	reencodeHealthCheckInterval = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: time.Minute,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
+	// reencodeTimeout is the time a re-encoded file has to reach full health
+	// after the re-encode was started before the re-encode is aborted.
+	reencodeTimeout = build.Select(build.Var{
+		Dev:      time.Hour,
+		Standard: 7 * 24 * time.Hour,
+		Testing:  time.Minute,
+	}).(time.Duration)
+)
+
+const (
+	// reencodeNewDir is the directory within the ReencodeFolder that holds the
+	// re-encoded siafiles until they replace the originals.
+	reencodeNewDir = "new"
+
+	// reencodePersistFile is the name of the file within the renter's persist
+	// dir that holds the ongoing re-encodes.
+	reencodePersistFile = "reencodes.json"
+)
+
+type (
+	// reencodePersist is the persisted state of a single re-encode.
+	reencodePersist struct {
+		SiaPath modules.SiaPath `json:"siapath"`
+
+		// DataPieces and ParityPieces describe the RSSubCode the file is
+		// re-encoded to.
+		DataPieces   int `json:"datapieces"`
+		ParityPieces int `json:"paritypieces"`
+
+		// SourceUID and SourceSize identify the original file. If the file at
+		// SiaPath no longer matches them, the re-encode is aborted.
+		SourceUID  siafile.SiafileUID `json:"sourceuid"`
+		SourceSize uint64             `json:"sourcesize"`
+
+		// NewUID is the UID of the re-encoded file. It is set once the data
+		// was uploaded to the re-encoded file.
+		NewUID siafile.SiafileUID `json:"newuid"`
+
+		StartTime time.Time `json:"starttime"`
+	}
+
+	// reencode is an ongoing re-encode.
+	reencode struct {
+		persist reencodePersist
+
+		// cancel is closed to stop the re-encode.
+		cancel chan struct{}
+	}
+
+	// reencodeSet tracks the files which are currently being re-encoded.
+	reencodeSet struct {
+		files      map[modules.SiaPath]*reencode
+		staticPath string
+		mu         sync.Mutex
+	}
+)
+
+// newReencodeSet loads the reencodeSet from the provided path.
+func newReencodeSet(path string) (*reencodeSet, error) {
+	rs := &reencodeSet{
+		files:      make(map[modules.SiaPath]*reencode),
+		staticPath: path,
+	}
+	var rps []reencodePersist
+	err := persist.LoadJSON(reencodeMetadata, &rps, path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, rp := range rps {
+		rs.files[rp.SiaPath] = &reencode{
+			persist: rp,
+			cancel:  make(chan struct{}),
+		}
+	}
+	return rs, nil
+}
+
+// saveSync persists the set.
+func (rs *reencodeSet) saveSync() error {
+	rps := make([]reencodePersist, 0, len(rs.files))
+	for _, re := range rs.files {
+		rps = append(rps, re.persist)
+	}
+	return persist.SaveJSON(reencodeMetadata, rps, rs.staticPath)
+}
+
+// managedAdd adds a re-encode to the set. It returns errReencodeInProgress if
+// the file is already in the set.
+func (rs *reencodeSet) managedAdd(rp reencodePersist) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if _, exists := rs.files[rp.SiaPath]; exists {
+		return errReencodeInProgress
+	}
+	rs.files[rp.SiaPath] = &reencode{
+		persist: rp,
+		cancel:  make(chan struct{}),
+	}
+	if err := rs.saveSync(); err != nil {
+		delete(rs.files, rp.SiaPath)
+		return err
+	}
+	return nil
+}
+
+// managedCancel stops the re-encode of the file at siaPath or of all the files
+// within the directory at siaPath.
+func (rs *reencodeSet) managedCancel(siaPath modules.SiaPath) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	found := false
+	for sp, re := range rs.files {
+		if !sp.Equals(siaPath) && !siaPath.IsRoot() && !strings.HasPrefix(sp.String(), siaPath.String()+"/") {
+			continue
+		}
+		found = true
+		select {
+		case <-re.cancel:
+		default:
+			close(re.cancel)
+		}
+	}
+	if !found {
+		return errReencodeNotFound
+	}
+	return nil
+}
+
+// managedCancelChan returns the channel which is closed when the re-encode of
+// the file at siaPath is cancelled.
+func (rs *reencodeSet) managedCancelChan(siaPath modules.SiaPath) <-chan struct{} {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	re, exists := rs.files[siaPath]
+	if !exists {
+		c := make(chan struct{})
+		close(c)
+		return c
+	}
+	return re.cancel
+}
+
+// managedPersists returns the persisted state of all the re-encodes.
+func (rs *reencodeSet) managedPersists() []reencodePersist {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rps := make([]reencodePersist, 0, len(rs.files))
+	for _, re := range rs.files {
+		rps = append(rps, re.persist)
+	}
+	return rps
+}
+
+// managedRemove removes a file from the set.
+func (rs *reencodeSet) managedRemove(siaPath modules.SiaPath) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	delete(rs.files, siaPath)
+	return rs.saveSync()
+}
+
+// managedSetNewUID records the UID of the uploaded re-encoded file.
+func (rs *reencodeSet) managedSetNewUID(siaPath modules.SiaPath, uid siafile.SiafileUID) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	re, exists := rs.files[siaPath]
+	if !exists {
+		return errReencodeNotFound
+	}
+	re.persist.NewUID = uid
+	return rs.saveSync()
+}
+
+// reencodeSiaPath returns the SiaPath within the given directory of the
+// ReencodeFolder that is used while re-encoding the file at siaPath.
+func reencodeSiaPath(dir string, siaPath modules.SiaPath) (modules.SiaPath, error) {
+	return modules.ReencodeFolder.Join(dir + "/" + siaPath.String())
+}
+
+// Reencode re-encodes the file or all the files within the directory at the
+// given siaPath to the provided erasure code. The re-encoding happens in the
+// background and the files are switched over once the re-encoded data
+// reaches full health.
+func (r *Renter) Reencode(siaPath modules.SiaPath, ec modules.ErasureCoder) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	if ec == nil {
+		return errors.New("no erasure code provided")
+	}
+	if siaPath.IsRoot() || strings.HasPrefix(siaPath.String(), modules.ReencodeFolder.String()) {
+		return errors.New("cannot re-encode the provided siapath")
+	}
+
+	// Check if the siaPath points to a single file.
+	exists, err := r.staticFileSystem.FileExists(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to check if file exists")
+	}
+	if exists {
+		return r.managedStartReencode(siaPath, ec)
+	}
+
+	// Otherwise re-encode all the files within the directory.
+	var siaPaths []modules.SiaPath
+	var mu sync.Mutex
+	err = r.staticFileSystem.CachedList(siaPath, true, func(fi modules.FileInfo) {
+		mu.Lock()
+		siaPaths = append(siaPaths, fi.SiaPath)
+		mu.Unlock()
+	}, func(modules.DirectoryInfo) {})
+	if err != nil {
+		return errors.AddContext(err, "unable to list directory")
+	}
+	for _, sp := range siaPaths {
+		err = r.managedStartReencode(sp, ec)
+		if errors.Contains(err, errReencodeSameErasureCode) || errors.Contains(err, errReencodeInProgress) {
+			continue
+		}
+		if err != nil {
+			return errors.AddContext(err, "unable to start re-encode of "+sp.String())
+		}
+	}
+	return nil
+}
+
+// CancelReencode stops the re-encode of the file or all the files within the
+// directory at the given siaPath. The original files are kept.
+func (r *Renter) CancelReencode(siaPath modules.SiaPath) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticReencodes.managedCancel(siaPath)
+}
+
+// managedStartReencode checks that the file at siaPath can be re-encoded to ec
+// and starts the background re-encode.
+func (r *Renter) managedStartReencode(siaPath modules.SiaPath, ec modules.ErasureCoder) (err error) {
+	node, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, node.Close())
+	}()
+	if node.ErasureCode().Identifier() == ec.Identifier() {
+		return errReencodeSameErasureCode
+	}
+	rp := reencodePersist{
+		SiaPath:      siaPath,
+		DataPieces:   ec.MinPieces(),
+		ParityPieces: ec.NumPieces() - ec.MinPieces(),
+		SourceUID:    node.UID(),
+		SourceSize:   node.Size(),
+		StartTime:    time.Now(),
+	}
+	if err := r.staticReencodes.managedAdd(rp); err != nil {
+		return err
+	}
+	go r.threadedReencode(rp)
+	return nil
+}
+
+// managedResumeReencodes resumes the re-encodes which were persisted before
+// the last shutdown.
+func (r *Renter) managedResumeReencodes() {
+	for _, rp := range r.staticReencodes.managedPersists() {
+		// If the re-encoded file already replaced the original, only the
+		// persisted state is left to clean up.
+		uid, _, err := r.managedFileIdentity(rp.SiaPath)
+		if err == nil && rp.NewUID != "" && uid == rp.NewUID {
+			if err := r.staticReencodes.managedRemove(rp.SiaPath); err != nil {
+				r.log.Printf("Unable to remove finished re-encode of %v: %v", rp.SiaPath, err)
+			}
+			continue
+		}
+		go r.threadedReencode(rp)
+	}
+}
+
+// managedFileIdentity returns the UID and size of the file at siaPath.
+func (r *Renter) managedFileIdentity(siaPath modules.SiaPath) (_ siafile.SiafileUID, _ uint64, err error) {
+	node, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() {
+		err = errors.Compose(err, node.Close())
+	}()
+	return node.UID(), node.Size(), nil
+}
+
+// managedCheckReencodeSource returns errReencodeSourceChanged if the file at
+// the re-encode's siapath is no longer the file the re-encode was started
+// for.
+func (r *Renter) managedCheckReencodeSource(rp reencodePersist) error {
+	uid, size, err := r.managedFileIdentity(rp.SiaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return errReencodeSourceChanged
+	}
+	if err != nil {
+		return err
+	}
+	if uid != rp.SourceUID || size != rp.SourceSize {
+		return errReencodeSourceChanged
+	}
+	return nil
+}
+
+// threadedReencode performs the re-encode described by rp. Unless the renter
+// shuts down, the re-encoded file is cleaned up and the re-encode is removed
+// from the set once it is done.
+func (r *Renter) threadedReencode(rp reencodePersist) {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	err := r.managedReencode(rp)
+	select {
+	case <-r.tg.StopChan():
+		// The re-encode is resumed on startup.
+		return
+	default:
+	}
+	if err != nil {
+		r.log.Printf("Re-encode of %v failed: %v", rp.SiaPath, err)
+		if err := r.managedDeleteReencodedFile(rp.SiaPath); err != nil {
+			r.log.Printf("Unable to delete re-encoded file of %v: %v", rp.SiaPath, err)
+		}
+	} else {
+		r.log.Printf("Re-encode of %v finished", rp.SiaPath)
+	}
+	if err := r.staticReencodes.managedRemove(rp.SiaPath); err != nil {
+		r.log.Printf("Unable to remove re-encode of %v: %v", rp.SiaPath, err)
+	}
+}
+
+// managedReencode performs the re-encode described by rp.
+func (r *Renter) managedReencode(rp reencodePersist) error {
+	ec, err := modules.NewRSSubCode(rp.DataPieces, rp.ParityPieces, crypto.SegmentSize)
+	if err != nil {
+		return err
+	}
+	newPath, err := reencodeSiaPath(reencodeNewDir, rp.SiaPath)
+	if err != nil {
+		return err
+	}
+	cancel := r.staticReencodes.managedCancelChan(rp.SiaPath)
+
+	// Upload the data unless that already happened before a restart.
+	if rp.NewUID == "" {
+		if err := r.managedUploadReencodedFile(rp, ec, newPath); err != nil {
+			return err
+		}
+	}
+
+	// Wait for the repair loop to bring the new SiaFile to full health.
+	for {
+		if err := r.managedCheckReencodeSource(rp); err != nil {
+			return err
+		}
+		offline, goodForRenew, contracts := r.managedContractUtilityMaps()
+		fi, err := r.staticFileSystem.FileInfo(newPath, offline, goodForRenew, contracts)
+		if err != nil {
+			return errors.AddContext(err, "unable to get re-encoded file info")
+		}
+		if fi.MaxHealth <= 0 {
+			break
+		}
+		if time.Since(rp.StartTime) > reencodeTimeout {
+			return errReencodeTimeout
+		}
+		select {
+		case <-r.tg.StopChan():
+			return errors.New("interrupted by shutdown")
+		case <-cancel:
+			return errReencodeCancelled
+		case <-time.After(reencodeHealthCheckInterval):
+		}
+	}
+	if err := r.managedCheckReencodeSource(rp); err != nil {
+		return err
+	}
+	return r.managedSwapReencodedFile(rp.SiaPath, newPath)
+}
+
+// managedUploadReencodedFile streams the data of the original file into a new
+// SiaFile at newPath which uses the erasure code ec.
+func (r *Renter) managedUploadReencodedFile(rp reencodePersist, ec modules.ErasureCoder, newPath modules.SiaPath) error {
+	if err := r.managedCheckReencodeSource(rp); err != nil {
+		return err
+	}
+	node, err := r.staticFileSystem.OpenSiaFile(rp.SiaPath)
+	if err != nil {
+		return err
+	}
+	up := modules.FileUploadParams{
+		SiaPath:     newPath,
+		Source:      node.LocalPath(),
+		ErasureCode: ec,
+		Force:       true,
+		CipherType:  node.MasterKey().Type(),
+	}
+	if err := node.Close(); err != nil {
+		return err
+	}
+
+	// Stream the original file into the new SiaFile.
+	_, stream, err := r.Streamer(rp.SiaPath, false)
+	if err != nil {
+		return errors.AddContext(err, "unable to open streamer")
+	}
+	fileNode, err := r.callUploadStreamFromReader(up, stream)
+	err = errors.Compose(err, stream.Close())
+	if fileNode != nil {
+		err = errors.Compose(err, fileNode.Close())
+	}
+	if err != nil {
+		return errors.AddContext(err, "unable to upload re-encoded file")
+	}
+	return r.staticReencodes.managedSetNewUID(rp.SiaPath, fileNode.UID())
+}
+
+// managedDeleteReencodedFile deletes the re-encoded file of the file at
+// siaPath if it exists.
+func (r *Renter) managedDeleteReencodedFile(siaPath modules.SiaPath) error {
+	newPath, err := reencodeSiaPath(reencodeNewDir, siaPath)
+	if err != nil {
+		return err
+	}
+	err = r.staticFileSystem.DeleteFile(newPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// managedSwapReencodedFile replaces the file at siaPath with the re-encoded
+// file at reencodedPath. The replacement is a single filesystem operation, so
+// a crash can't leave siaPath without a file.
+func (r *Renter) managedSwapReencodedFile(siaPath, reencodedPath modules.SiaPath) error {
+	// Carry over the checksum of the original file's data.
+	if err := r.managedCopyChecksum(siaPath, reencodedPath); err != nil {
+		return errors.AddContext(err, "unable to copy checksum to re-encoded file")
+	}
+	if err := r.staticFileSystem.ReplaceFile(siaPath, reencodedPath); err != nil {
+		return errors.AddContext(err, "unable to replace original file")
+	}
+
+	// Bubble the directory of the file.
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		return err
+	}
+	_ = r.staticBubbleScheduler.callQueueBubble(dirSiaPath)
+	return nil
+}
+
+// managedCopyChecksum sets the checksum of the file at src on the file at dst.
+func (r *Renter) managedCopyChecksum(src, dst modules.SiaPath) (err error) {
+	srcNode, err := r.staticFileSystem.OpenSiaFile(src)
+	if err != nil {
+		return err
+	}
+	checksum := srcNode.Checksum()
+	if err := srcNode.Close(); err != nil {
+		return err
+	}
+	if checksum == (crypto.Hash{}) {
+		return nil
+	}
+	dstNode, err := r.staticFileSystem.OpenSiaFile(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dstNode.Close())
+	}()
+	return dstNode.SetChecksum(checksum)
+}
@@ -0,0 +1,364 @@
+package renter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestReencodeSet is a unit test for the reencodeSet.
+func TestReencodeSet(t *testing.T) {
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, reencodePersistFile)
+	rs, err := newReencodeSet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp, err := modules.NewSiaPath("dir/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp := reencodePersist{
+		SiaPath:      sp,
+		DataPieces:   2,
+		ParityPieces: 4,
+		SourceUID:    "source",
+		SourceSize:   100,
+		StartTime:    time.Now(),
+	}
+	if err := rs.managedAdd(rp); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.managedAdd(rp); !errors.Contains(err, errReencodeInProgress) {
+		t.Fatal("expected errReencodeInProgress but got", err)
+	}
+	if err := rs.managedSetNewUID(sp, "new"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The set should survive a reload.
+	rs, err = newReencodeSet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rps := rs.managedPersists()
+	if len(rps) != 1 || rps[0].SiaPath != sp || rps[0].NewUID != "new" || rps[0].SourceUID != rp.SourceUID || rps[0].DataPieces != rp.DataPieces {
+		t.Fatal("wrong persisted re-encodes", rps)
+	}
+
+	// Cancelling the parent dir should cancel the re-encode.
+	if err := rs.managedCancel(modules.RandomSiaPath()); !errors.Contains(err, errReencodeNotFound) {
+		t.Fatal("expected errReencodeNotFound but got", err)
+	}
+	cancel := rs.managedCancelChan(sp)
+	dirPath, err := sp.Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.managedCancel(dirPath); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-cancel:
+	default:
+		t.Fatal("re-encode wasn't cancelled")
+	}
+	if err := rs.managedCancel(sp); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rs.managedRemove(sp); err != nil {
+		t.Fatal(err)
+	}
+	rs, err = newReencodeSet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs.managedPersists()) != 0 {
+		t.Fatal("re-encode wasn't removed")
+	}
+}
+
+// TestReencode tests the validation of the Reencode method and the swapping of
+// a re-encoded file with the original.
+func TestReencode(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create a file.
+	sp := modules.RandomSiaPath()
+	oldEC, err := modules.NewRSSubCode(1, 1, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := r.createRenterTestFileWithParams(sp, oldEC, crypto.TypeDefaultRenter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checksum := crypto.HashBytes(fastrand.Bytes(64))
+	if err := node.SetChecksum(checksum); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-encoding to the same erasure code should fail.
+	err = r.Reencode(sp, oldEC)
+	if !errors.Contains(err, errReencodeSameErasureCode) {
+		t.Fatal("expected errReencodeSameErasureCode but got", err)
+	}
+	// Re-encoding the root or the reencode folder should fail.
+	newEC, err := modules.NewRSSubCode(2, 4, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reencode(modules.RootSiaPath(), newEC); err == nil {
+		t.Fatal("expected re-encoding root to fail")
+	}
+	if err := r.Reencode(modules.ReencodeFolder, newEC); err == nil {
+		t.Fatal("expected re-encoding the reencode folder to fail")
+	}
+
+	// Create the re-encoded file manually and swap it in.
+	reencodedPath, err := reencodeSiaPath(reencodeNewDir, sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err = r.createRenterTestFileWithParams(reencodedPath, newEC, crypto.TypeDefaultRenter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := node.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.managedSwapReencodedFile(sp, reencodedPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file at the original path should use the new erasure code.
+	node, err = r.staticFileSystem.OpenSiaFile(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.ErasureCode().Identifier() != newEC.Identifier() {
+		t.Fatal("file wasn't swapped")
+	}
+	// The checksum of the original file should be kept.
+	if node.Checksum() != checksum {
+		t.Fatal("checksum wasn't carried over", node.Checksum(), checksum)
+	}
+	if err := node.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// The re-encoded file shouldn't be left in the reencode folder.
+	exists, err := r.staticFileSystem.FileExists(reencodedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Fatal("re-encoded file shouldn't exist")
+	}
+}
+
+// TestReencodeAbort tests that a background re-encode stops and cleans up
+// after itself when it is cancelled, when the original file changes and when
+// it times out. It also tests the recovery of a finished re-encode on startup.
+func TestReencodeAbort(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	oldEC, err := modules.NewRSSubCode(1, 1, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newEC, err := modules.NewRSSubCode(2, 4, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// startReencode creates an original and an already uploaded re-encoded
+	// file which never reaches full health and starts the background
+	// re-encode.
+	startReencode := func(startTime time.Time) (reencodePersist, modules.SiaPath) {
+		sp := modules.RandomSiaPath()
+		node, err := r.createRenterTestFileWithParams(sp, oldEC, crypto.TypeDefaultRenter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := node.Close(); err != nil {
+			t.Fatal(err)
+		}
+		newPath, err := reencodeSiaPath(reencodeNewDir, sp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node, err = r.createRenterTestFileWithParams(newPath, newEC, crypto.TypeDefaultRenter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := node.Close(); err != nil {
+			t.Fatal(err)
+		}
+		uid, size, err := r.managedFileIdentity(sp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		newUID, _, err := r.managedFileIdentity(newPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rp := reencodePersist{
+			SiaPath:      sp,
+			DataPieces:   newEC.MinPieces(),
+			ParityPieces: newEC.NumPieces() - newEC.MinPieces(),
+			SourceUID:    uid,
+			SourceSize:   size,
+			NewUID:       newUID,
+			StartTime:    startTime,
+		}
+		if err := r.staticReencodes.managedAdd(rp); err != nil {
+			t.Fatal(err)
+		}
+		go r.threadedReencode(rp)
+		return rp, newPath
+	}
+	// checkAborted checks that the re-encode was removed together with the
+	// re-encoded file while the original file was kept.
+	checkAborted := func(rp reencodePersist, newPath modules.SiaPath, originalExists bool) {
+		err := build.Retry(100, 100*time.Millisecond, func() error {
+			if len(r.staticReencodes.managedPersists()) != 0 {
+				return errors.New("re-encode wasn't removed")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exists, err := r.staticFileSystem.FileExists(newPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exists {
+			t.Fatal("re-encoded file wasn't deleted")
+		}
+		exists, err = r.staticFileSystem.FileExists(rp.SiaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exists != originalExists {
+			t.Fatalf("original file exists: %v, expected %v", exists, originalExists)
+		}
+	}
+
+	// Cancel a re-encode.
+	rp, newPath := startReencode(time.Now())
+	if err := r.CancelReencode(rp.SiaPath); err != nil {
+		t.Fatal(err)
+	}
+	checkAborted(rp, newPath, true)
+	if err := r.CancelReencode(rp.SiaPath); !errors.Contains(err, errReencodeNotFound) {
+		t.Fatal("expected errReencodeNotFound but got", err)
+	}
+
+	// Delete the original file during a re-encode.
+	rp, newPath = startReencode(time.Now())
+	if err := r.DeleteFile(rp.SiaPath); err != nil {
+		t.Fatal(err)
+	}
+	checkAborted(rp, newPath, false)
+
+	// Replace the original file during a re-encode.
+	rp, newPath = startReencode(time.Now())
+	if err := r.DeleteFile(rp.SiaPath); err != nil {
+		t.Fatal(err)
+	}
+	node, err := r.createRenterTestFileWithParams(rp.SiaPath, oldEC, crypto.TypeDefaultRenter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := node.Close(); err != nil {
+		t.Fatal(err)
+	}
+	checkAborted(rp, newPath, true)
+
+	// Time out a re-encode.
+	rp, newPath = startReencode(time.Now().Add(-reencodeTimeout))
+	checkAborted(rp, newPath, true)
+
+	// A re-encode which replaced the original file before a shutdown should
+	// only be removed on startup.
+	sp := modules.RandomSiaPath()
+	node, err = r.createRenterTestFileWithParams(sp, newEC, crypto.TypeDefaultRenter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := node.Close(); err != nil {
+		t.Fatal(err)
+	}
+	uid, _, err := r.managedFileIdentity(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.staticReencodes.managedAdd(reencodePersist{
+		SiaPath:      sp,
+		DataPieces:   newEC.MinPieces(),
+		ParityPieces: newEC.NumPieces() - newEC.MinPieces(),
+		SourceUID:    "original",
+		NewUID:       uid,
+		StartTime:    time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.managedResumeReencodes()
+	if len(r.staticReencodes.managedPersists()) != 0 {
+		t.Fatal("finished re-encode wasn't removed")
+	}
+	exists, err := r.staticFileSystem.FileExists(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Fatal("re-encoded file was deleted")
+	}
+}
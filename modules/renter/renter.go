@@ -118,6 +118,11 @@ type hostContractor interface {
 	// response objects to the host. It returns an error in case of failure.
 	ProvidePayment(stream io.ReadWriter, pt *modules.RPCPriceTable, details contractor.PaymentDetails) error
 
+	// FinalizeSectorUpdate verifies the response of a program that updated a
+	// sector within the contract with the given host and finalizes it by
+	// signing a revision with the new contract root.
+	FinalizeSectorUpdate(stream io.ReadWriter, bh types.BlockHeight, details contractor.SectorUpdateDetails) error
+
 	// OldContracts returns the oldContracts of the renter's hostContractor.
 	OldContracts() []modules.RenterContract
 
@@ -255,8 +260,11 @@ type Renter struct {
 	repairLog                          *persist.Logger
 	staticAccountManager               *accountManager
 	staticAlerter                      *modules.GenericAlerter
+	staticBandwidthScheduler           *bandwidthScheduler
 	staticFileSystem                   *filesystem.FileSystem
 	staticFuseManager                  renterFuseManager
+	staticReencodes                    *reencodeSet
+	staticUploadSessions               *uploadSessionSet
 	staticStreamBufferSet              *streamBufferSet
 	tg                                 threadgroup.ThreadGroup
 	tpool                              modules.TransactionPool
@@ -604,6 +612,9 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	if s.MaxDownloadSpeed < 0 || s.MaxUploadSpeed < 0 {
 		return errors.New("bandwidth limits cannot be negative")
 	}
+	if err := s.BandwidthSchedule.Validate(); err != nil {
+		return errors.AddContext(err, "invalid bandwidth schedule")
+	}
 
 	// Set allowance.
 	err := r.hostContractor.SetAllowance(s.Allowance)
@@ -614,22 +625,23 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	// Set IPViolationsCheck
 	r.hostDB.SetIPViolationCheck(s.IPViolationCheck)
 
-	// Set the bandwidth limits.
-	err = r.setBandwidthLimits(s.MaxDownloadSpeed, s.MaxUploadSpeed)
-	if err != nil {
-		return err
-	}
-
 	// Save the changes.
 	id := r.mu.Lock()
 	r.persist.MaxDownloadSpeed = s.MaxDownloadSpeed
 	r.persist.MaxUploadSpeed = s.MaxUploadSpeed
+	r.persist.BandwidthSchedule = s.BandwidthSchedule
 	err = r.saveSync()
 	r.mu.Unlock(id)
 	if err != nil {
 		return err
 	}
 
+	// Set the bandwidth limits according to the schedule.
+	err = r.managedApplyBandwidthSchedule(true)
+	if err != nil {
+		return err
+	}
+
 	// Update the worker pool so that the changes are immediately apparent to
 	// users.
 	r.staticWorkerPool.callUpdate()
@@ -811,7 +823,12 @@ func (r *Renter) Settings() (modules.RenterSettings, error) {
 		return modules.RenterSettings{}, err
 	}
 	defer r.tg.Done()
-	download, upload, _ := r.rl.Limits()
+	// Report the limits from the persistence rather than the rate limiter
+	// since the rate limiter might currently follow the bandwidth schedule.
+	id := r.mu.RLock()
+	download, upload := r.persist.MaxDownloadSpeed, r.persist.MaxUploadSpeed
+	schedule := append(modules.BandwidthSchedule{}, r.persist.BandwidthSchedule...)
+	r.mu.RUnlock(id)
 	enabled, err := r.hostDB.IPViolationsCheck()
 	if err != nil {
 		return modules.RenterSettings{}, errors.AddContext(err, "error getting IPViolationsCheck:")
@@ -826,6 +843,7 @@ func (r *Renter) Settings() (modules.RenterSettings, error) {
 			Paused:       paused,
 			PauseEndTime: endTime,
 		},
+		BandwidthSchedule: schedule,
 	}, nil
 }
 
@@ -925,6 +943,7 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		mu:             siasync.New(modules.SafeMutexDelay, 1),
 		tpool:          tpool,
 	}
+	r.staticBandwidthScheduler = newBandwidthScheduler()
 	r.staticBubbleScheduler = newBubbleScheduler(r)
 	r.staticStreamBufferSet = newStreamBufferSet(&r.tg)
 	r.staticUploadChunkDistributionQueue = newUploadChunkDistributionQueue(r)
@@ -979,6 +998,18 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		return nil, err
 	}
 
+	// Load the upload sessions.
+	r.staticUploadSessions, err = newUploadSessionSet(filepath.Join(r.persistDir, uploadSessionsDir))
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load upload sessions")
+	}
+
+	// Load the ongoing re-encodes.
+	r.staticReencodes, err = newReencodeSet(filepath.Join(r.persistDir, reencodePersistFile))
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load re-encodes")
+	}
+
 	// After persist is initialized, create the worker pool.
 	r.staticWorkerPool = r.newWorkerPool()
 
@@ -990,6 +1021,10 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	r.managedUpdateRenterContractsAndUtilities()
 	go r.threadedUpdateRenterContractsAndUtilities()
 
+	// Apply the bandwidth schedule and kick off a thread that keeps following
+	// it.
+	go r.threadedBandwidthSchedule()
+
 	// Spin up background threads which are not depending on the renter being
 	// up-to-date with consensus.
 	if !r.deps.Disrupt("DisableRepairAndHealthLoops") {
@@ -1043,6 +1078,8 @@ func renterAsyncStartup(r *Renter, cs modules.ConsensusSet) error {
 	if !r.deps.Disrupt("DisableSnapshotSync") {
 		go r.threadedSynchronizeSnapshots()
 	}
+	// Resume the re-encodes which were interrupted by the last shutdown.
+	r.managedResumeReencodes()
 	return nil
 }
 
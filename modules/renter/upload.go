@@ -101,6 +101,17 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 		return errors.AddContext(err, "could not open the new sia file")
 	}
 
+	// Record the checksum of the file's data if requested.
+	if up.Checksum {
+		checksum, err := checksumFile(up.Source)
+		if err != nil {
+			return errors.Compose(errors.AddContext(err, "unable to compute checksum"), entry.Close())
+		}
+		if err := entry.SetChecksum(checksum); err != nil {
+			return errors.Compose(errors.AddContext(err, "unable to set checksum"), entry.Close())
+		}
+	}
+
 	// No need to upload zero-byte files.
 	if sourceInfo.Size() == 0 {
 		return nil
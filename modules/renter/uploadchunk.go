@@ -42,6 +42,10 @@ type unfinishedUploadChunk struct {
 	stuck                  bool   // indicates if the chunk was marked as stuck during last repair
 	stuckRepair            bool   // indicates if the chunk was identified for repair by the stuck loop
 
+	// repairPriority is the repair priority of the directory the chunk's file
+	// is in.
+	repairPriority modules.RepairPriority
+
 	staticMemoryManager *memoryManager
 
 	// Static cached fields.
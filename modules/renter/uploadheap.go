@@ -84,11 +84,15 @@ func (uch uploadChunkHeap) Less(i, j int) bool {
 	//  3) Stuck Chunks
 	//    - These are chunks added by the stuck loop
 	//
-	//  4) Remote Chunks
+	//  4) Repair Priority
+	//    - These are chunks of a siafile within a directory with a higher
+	//    repair priority
+	//
+	//  5) Remote Chunks
 	//    - These are chunks of a siafile that do not have a local file to repair
 	//    from
 	//
-	//  5) Worst Health Chunk
+	//  6) Worst Health Chunk
 	//    - The base priority of chunks in the heap is by the worst health
 
 	// Check for Priority chunks
@@ -126,6 +130,11 @@ func (uch uploadChunkHeap) Less(i, j int) bool {
 		return false
 	}
 
+	// Check for Repair Priority
+	if uch[i].repairPriority != uch[j].repairPriority {
+		return uch[i].repairPriority > uch[j].repairPriority
+	}
+
 	// Check for Remote Chunks
 	if !uch[i].onDisk && uch[j].onDisk {
 		return true
@@ -839,7 +848,7 @@ func (r *Renter) managedAddChunksToHeap(hosts map[string]struct{}) (*uniqueRefre
 }
 
 // managedBuildAndPushRandomChunk randomly selects a stuck chunk from a file and
-// adds it to the upload heap
+// adds it to the upload heap with the repair priority of the file's directory.
 func (r *Renter) managedBuildAndPushRandomChunk(siaPath modules.SiaPath, hosts map[string]struct{}, target repairTarget, mm *memoryManager) error {
 	// Open file
 	file, err := r.staticFileSystem.OpenSiaFile(siaPath)
@@ -862,6 +871,15 @@ func (r *Renter) managedBuildAndPushRandomChunk(siaPath modules.SiaPath, hosts m
 	randChunkIndex := fastrand.Intn(len(unfinishedUploadChunks))
 	randChunk := unfinishedUploadChunks[randChunkIndex]
 	randChunk.stuckRepair = true
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		r.log.Println("WARN: unable to get directory SiaPath of stuck file, using default repair priority:", err)
+	} else {
+		randChunk.repairPriority, err = r.managedRepairPriority(dirSiaPath)
+		if err != nil {
+			r.log.Println("WARN: unable to get repair priority of directory, using default:", err)
+		}
+	}
 	unfinishedUploadChunks = append(unfinishedUploadChunks[:randChunkIndex], unfinishedUploadChunks[randChunkIndex+1:]...)
 	var allErrs error
 	defer func() {
@@ -916,6 +934,18 @@ func (r *Renter) callBuildAndPushChunks(files []*filesystem.FileNode, hosts map[
 	// separately, so that if we skip only chunks that have better health than
 	// the next directory, when we re-add this directory to the directory heap,
 	// it gets added behind the next directory, ensuring progress is made.
+	// All files submitted are from the same directory so use the first one to
+	// get the directory siapath and the repair priority of the directory.
+	dirSiaPath, err := r.staticFileSystem.FileSiaPath(files[0]).Dir()
+	if err != nil {
+		r.log.Println("WARN: unable to get directory SiaPath of files to repair:", err)
+		return
+	}
+	priority, err := r.managedRepairPriority(dirSiaPath)
+	if err != nil {
+		r.log.Println("WARN: unable to get repair priority of directory, using default:", err)
+	}
+
 	var tempChunkHeap uploadChunkHeap
 	nextDirHealth, nextDirRemote := r.directoryHeap.managedPeekHealth()
 	wh := worstIgnoredHealth{
@@ -942,6 +972,7 @@ func (r *Renter) callBuildAndPushChunks(files []*filesystem.FileNode, hosts map[
 		unfinishedUploadChunks := r.managedBuildUnfinishedChunks(file, hosts, target, offline, goodForRenew, r.repairMemoryManager)
 		for i := 0; i < len(unfinishedUploadChunks); i++ {
 			chunk := unfinishedUploadChunks[i]
+			chunk.repairPriority = priority
 			// Skip adding this chunk if it is already in the upload heap.
 			if r.uploadHeap.managedExists(chunk.id) {
 				// Close the file entry before skipping the chunk.
@@ -1060,7 +1091,7 @@ func (r *Renter) callBuildAndPushChunks(files []*filesystem.FileNode, hosts map[
 	}
 	// We are done with the temporary heap, reset it so the resources are closed
 	// and the memory is released.
-	err := tempChunkHeap.reset()
+	err = tempChunkHeap.reset()
 	if err != nil {
 		r.log.Println("WARN: error resetting the temporary upload heap:", err)
 	}
@@ -1086,14 +1117,6 @@ func (r *Renter) callBuildAndPushChunks(files []*filesystem.FileNode, hosts map[
 	// match its actual health, this is okay because the goal is to make sure
 	// that the upload heap is making progress.
 
-	// All files submitted are from the same directory so use the first one to
-	// get the directory siapath
-	dirSiaPath, err := r.staticFileSystem.FileSiaPath(files[0]).Dir()
-	if err != nil {
-		r.log.Println("WARN: unable to get directory SiaPath and add directory back to directory heap:", err)
-		return
-	}
-
 	// The directory will be added back as 'explored', under the assumption that
 	// only explored directories are having their chunks added to the upload
 	// heap.
@@ -1111,6 +1134,7 @@ func (r *Renter) callBuildAndPushChunks(files []*filesystem.FileNode, hosts map[
 		aggregateHealth: wh.health,
 		explored:        true,
 		health:          wh.health,
+		priority:        priority,
 		staticSiaPath:   dirSiaPath,
 	}
 	// The remote health values should only be set if the worst health of any
@@ -2,6 +2,7 @@ package renter
 
 import (
 	"bytes"
+	"container/heap"
 	"fmt"
 	"io"
 	"os"
@@ -1260,3 +1261,25 @@ func (rt *renterTester) managedBlockUntilBubblesComplete() {
 		bs.mu.Unlock()
 	}
 }
+
+// TestUploadChunkHeapRepairPriority probes how the repair priority of chunks
+// affects the order of the upload chunk heap.
+func TestUploadChunkHeapRepairPriority(t *testing.T) {
+	// Chunks with a higher repair priority should be popped first, even if
+	// they are healthier or on disk. Stuck chunks should still be popped
+	// before all of them.
+	stuck := &unfinishedUploadChunk{health: 1, onDisk: true, repairPriority: modules.RepairPriorityLow, stuck: true}
+	high := &unfinishedUploadChunk{health: 1, onDisk: true, repairPriority: modules.RepairPriorityHigh}
+	normal := &unfinishedUploadChunk{health: 2, repairPriority: modules.RepairPriorityNormal}
+	low := &unfinishedUploadChunk{health: 3, repairPriority: modules.RepairPriorityLow}
+
+	var uch uploadChunkHeap
+	for _, chunk := range []*unfinishedUploadChunk{low, normal, high, stuck} {
+		heap.Push(&uch, chunk)
+	}
+	for _, expected := range []*unfinishedUploadChunk{stuck, high, normal, low} {
+		if chunk := heap.Pop(&uch).(*unfinishedUploadChunk); chunk != expected {
+			t.Fatalf("expected chunk with priority %v and health %v but got %v and %v", expected.repairPriority, expected.health, chunk.repairPriority, chunk.health)
+		}
+	}
+}
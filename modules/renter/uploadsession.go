@@ -0,0 +1,461 @@
+package renter
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/persist"
+	siasync "go.sia.tech/siad/sync"
+	"go.sia.tech/siad/types"
+)
+
+// Upload Session Overview:
+// An upload session allows for uploading a file of a known length over many
+// requests. Every request writes data at the session's committed offset. Data
+// is buffered on disk until a full chunk is available, at which point the
+// chunk is pushed through the upload heap the same way streamed chunks are.
+// Once a chunk is available on the network its data is dropped from the
+// buffer. Both the buffer and the session metadata are synced to disk before
+// a write returns, which means that a session can be resumed from its
+// committed offset after a restart of either the client or siad.
+
+const (
+	// uploadSessionsDir is the name of the directory within the renter's
+	// persist dir that contains the upload sessions.
+	uploadSessionsDir = "uploadsessions"
+
+	// uploadSessionMetadataExtension is the extension of an upload session's
+	// metadata file.
+	uploadSessionMetadataExtension = ".json"
+
+	// uploadSessionBufferExtension is the extension of the file which buffers
+	// an upload session's data until a full chunk is available.
+	uploadSessionBufferExtension = ".buf"
+)
+
+var (
+	// errUploadSessionComplete is returned when writing to a session that is
+	// already complete.
+	errUploadSessionComplete = errors.New("upload session is already complete")
+
+	// errUploadSessionNotFound is returned if a session can't be found.
+	errUploadSessionNotFound = errors.New("upload session not found")
+
+	// errUploadSessionWriteInProgress is returned when writing to a session
+	// while another write to it is still in progress.
+	errUploadSessionWriteInProgress = errors.New("another write to the upload session is in progress")
+
+	// errUploadSessionTooMuchData is returned if more data than the session's
+	// length is written to a session.
+	errUploadSessionTooMuchData = errors.New("data exceeds the length of the upload session")
+
+	// uploadSessionMetadata is the persist metadata of an upload session.
+	uploadSessionMetadata = persist.Metadata{
+		Header:  "Upload Session",
+		Version: "1.5.6",
+	}
+)
+
+type (
+	// uploadSessionPersist is the persisted state of an upload session.
+	uploadSessionPersist struct {
+		modules.UploadSession
+
+		// UploadedChunks is the number of chunks of the session which are
+		// available on the network.
+		UploadedChunks uint64 `json:"uploadedchunks"`
+	}
+
+	// uploadSession is a resumable upload. The persisted state is only
+	// modified by the holder of writeMu, which is held for the duration of a
+	// write including the upload of the session's chunks. mu protects the
+	// persisted state against concurrent reads and is never held while
+	// waiting for the network.
+	uploadSession struct {
+		persist uploadSessionPersist
+
+		staticMetadataPath string
+		staticBufferPath   string
+		mu                 sync.Mutex
+		writeMu            siasync.TryMutex
+	}
+
+	// uploadSessionSet contains all the upload sessions of the renter.
+	uploadSessionSet struct {
+		sessions  map[modules.UploadSessionID]*uploadSession
+		staticDir string
+		mu        sync.Mutex
+	}
+)
+
+// newUploadSessionSet loads the upload sessions from the provided dir.
+func newUploadSessionSet(dir string) (*uploadSessionSet, error) {
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		return nil, errors.AddContext(err, "failed to create upload sessions dir")
+	}
+	uss := &uploadSessionSet{
+		sessions:  make(map[modules.UploadSessionID]*uploadSession),
+		staticDir: dir,
+	}
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, fi := range fis {
+		if fi.IsDir() || filepath.Ext(fi.Name()) != uploadSessionMetadataExtension {
+			continue
+		}
+		id := modules.UploadSessionID(strings.TrimSuffix(fi.Name(), uploadSessionMetadataExtension))
+		us := uss.newSession(id)
+		err := persist.LoadJSON(uploadSessionMetadata, &us.persist, us.staticMetadataPath)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to load upload session "+string(id))
+		}
+		uss.sessions[id] = us
+	}
+	return uss, nil
+}
+
+// newSession creates a new uploadSession for the given id without adding it to
+// the set.
+func (uss *uploadSessionSet) newSession(id modules.UploadSessionID) *uploadSession {
+	return &uploadSession{
+		staticMetadataPath: filepath.Join(uss.staticDir, string(id)+uploadSessionMetadataExtension),
+		staticBufferPath:   filepath.Join(uss.staticDir, string(id)+uploadSessionBufferExtension),
+	}
+}
+
+// managedSession returns the session with the given id.
+func (uss *uploadSessionSet) managedSession(id modules.UploadSessionID) (*uploadSession, error) {
+	uss.mu.Lock()
+	defer uss.mu.Unlock()
+	us, exists := uss.sessions[id]
+	if !exists {
+		return nil, errUploadSessionNotFound
+	}
+	return us, nil
+}
+
+// saveSync persists the session's metadata.
+func (us *uploadSession) saveSync() error {
+	return persist.SaveJSON(uploadSessionMetadata, us.persist, us.staticMetadataPath)
+}
+
+// managedUpdate applies the update to the session's persisted state and
+// persists it. The caller needs to hold writeMu.
+func (us *uploadSession) managedUpdate(update func(*uploadSessionPersist)) error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	update(&us.persist)
+	return us.saveSync()
+}
+
+// managedInfo returns the session's public information.
+func (us *uploadSession) managedInfo() modules.UploadSession {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	return us.persist.UploadSession
+}
+
+// CreateUploadSession creates a new resumable upload session for a file of the
+// given length.
+func (r *Renter) CreateUploadSession(up modules.FileUploadParams, length uint64) (modules.UploadSession, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.UploadSession{}, err
+	}
+	defer r.tg.Done()
+
+	if length == 0 {
+		return modules.UploadSession{}, errors.New("upload session length must be greater than 0")
+	}
+	if up.Repair {
+		return modules.UploadSession{}, errors.New("upload sessions can't be used for repairs")
+	}
+
+	// Create the siafile.
+	fileNode, err := r.managedInitUploadStream(up)
+	if err != nil {
+		return modules.UploadSession{}, err
+	}
+	if err := fileNode.Close(); err != nil {
+		return modules.UploadSession{}, err
+	}
+
+	// Create and persist the session.
+	id := modules.UploadSessionID(hex.EncodeToString(fastrand.Bytes(16)))
+	us := r.staticUploadSessions.newSession(id)
+	us.persist.UploadSession = modules.UploadSession{
+		ID:         id,
+		SiaPath:    up.SiaPath,
+		Length:     length,
+		CreateTime: time.Now(),
+	}
+	if err := us.saveSync(); err != nil {
+		return modules.UploadSession{}, errors.AddContext(err, "failed to persist upload session")
+	}
+	r.staticUploadSessions.mu.Lock()
+	r.staticUploadSessions.sessions[id] = us
+	r.staticUploadSessions.mu.Unlock()
+	return us.persist.UploadSession, nil
+}
+
+// UploadSession returns the upload session with the given id.
+func (r *Renter) UploadSession(id modules.UploadSessionID) (modules.UploadSession, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.UploadSession{}, err
+	}
+	defer r.tg.Done()
+	us, err := r.staticUploadSessions.managedSession(id)
+	if err != nil {
+		return modules.UploadSession{}, err
+	}
+	return us.managedInfo(), nil
+}
+
+// UploadSessions returns all the upload sessions of the renter sorted by
+// creation time.
+func (r *Renter) UploadSessions() []modules.UploadSession {
+	r.staticUploadSessions.mu.Lock()
+	sessions := make([]*uploadSession, 0, len(r.staticUploadSessions.sessions))
+	for _, us := range r.staticUploadSessions.sessions {
+		sessions = append(sessions, us)
+	}
+	r.staticUploadSessions.mu.Unlock()
+
+	infos := make([]modules.UploadSession, 0, len(sessions))
+	for _, us := range sessions {
+		infos = append(infos, us.managedInfo())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].CreateTime.Before(infos[j].CreateTime)
+	})
+	return infos
+}
+
+// AbortUploadSession aborts the upload session with the given id. If the
+// session wasn't complete yet, the partially uploaded file is deleted.
+func (r *Renter) AbortUploadSession(id modules.UploadSessionID) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	r.staticUploadSessions.mu.Lock()
+	us, exists := r.staticUploadSessions.sessions[id]
+	delete(r.staticUploadSessions.sessions, id)
+	r.staticUploadSessions.mu.Unlock()
+	if !exists {
+		return errUploadSessionNotFound
+	}
+
+	// Wait for any ongoing writes to finish.
+	us.writeMu.Lock()
+	defer us.writeMu.Unlock()
+	var err error
+	if !us.persist.Complete {
+		err = r.DeleteFile(us.persist.SiaPath)
+		if errors.Contains(err, filesystem.ErrNotExist) {
+			err = nil
+		}
+	}
+	err = errors.Compose(err, os.Remove(us.staticMetadataPath))
+	if rmErr := os.Remove(us.staticBufferPath); rmErr != nil && !os.IsNotExist(rmErr) {
+		err = errors.Compose(err, rmErr)
+	}
+	return err
+}
+
+// WriteUploadSession writes the data from the reader to the upload session
+// starting at offset. The offset needs to match the committed offset of the
+// session. Data is committed as it is read from the reader which means that
+// the returned session reflects the progress even if an error is returned.
+func (r *Renter) WriteUploadSession(id modules.UploadSessionID, offset uint64, reader io.Reader) (modules.UploadSession, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.UploadSession{}, err
+	}
+	defer r.tg.Done()
+
+	us, err := r.staticUploadSessions.managedSession(id)
+	if err != nil {
+		return modules.UploadSession{}, err
+	}
+	if !us.writeMu.TryLock() {
+		return us.managedInfo(), errUploadSessionWriteInProgress
+	}
+	defer us.writeMu.Unlock()
+	err = r.managedWriteUploadSession(us, offset, reader)
+	return us.managedInfo(), err
+}
+
+// managedWriteUploadSession writes the data from the reader to the session.
+// The caller needs to hold the session's writeMu.
+func (r *Renter) managedWriteUploadSession(us *uploadSession, offset uint64, reader io.Reader) (err error) {
+	if us.persist.Complete {
+		return errUploadSessionComplete
+	}
+	if offset != us.persist.Offset {
+		return modules.ErrUploadSessionOffsetMismatch
+	}
+
+	fileNode, err := r.staticFileSystem.OpenSiaFile(us.persist.SiaPath)
+	if err != nil {
+		return errors.AddContext(err, "failed to open siafile of upload session")
+	}
+	defer func() {
+		err = errors.Compose(err, fileNode.Close())
+	}()
+	buf, err := os.OpenFile(us.staticBufferPath, os.O_RDWR|os.O_CREATE, modules.DefaultFilePerm)
+	if err != nil {
+		return errors.AddContext(err, "failed to open upload session buffer")
+	}
+	defer func() {
+		err = errors.Compose(err, buf.Close())
+	}()
+
+	// The buffer might contain data beyond the committed offset if siad was
+	// interrupted during a previous write. Drop that data.
+	chunkSize := fileNode.ChunkSize()
+	bufLen := us.persist.Offset - us.persist.UploadedChunks*chunkSize
+	if err := buf.Truncate(int64(bufLen)); err != nil {
+		return err
+	}
+
+	for {
+		// Upload the buffered data once it makes up a full chunk.
+		if bufLen == chunkSize {
+			if err := r.managedUploadSessionChunk(us, fileNode, buf, bufLen); err != nil {
+				return err
+			}
+			bufLen = 0
+		}
+		remaining := us.persist.Length - us.persist.Offset
+		if remaining == 0 {
+			break
+		}
+
+		// Append data to the buffer until it contains a full chunk.
+		toRead := chunkSize - bufLen
+		if toRead > remaining {
+			toRead = remaining
+		}
+		if _, err := buf.Seek(int64(bufLen), io.SeekStart); err != nil {
+			return err
+		}
+		n, readErr := io.CopyN(buf, reader, int64(toRead))
+		if n > 0 {
+			if err := buf.Sync(); err != nil {
+				return err
+			}
+			bufLen += uint64(n)
+			err := us.managedUpdate(func(usp *uploadSessionPersist) {
+				usp.Offset += uint64(n)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if errors.Contains(readErr, io.EOF) {
+			return nil
+		}
+		if readErr != nil {
+			return errors.AddContext(readErr, "failed to read upload session data")
+		}
+	}
+
+	// Make sure the client didn't send more data than the session's length
+	// before completing the session.
+	if n, _ := reader.Read(make([]byte, 1)); n > 0 {
+		return errUploadSessionTooMuchData
+	}
+
+	// All the data was received. Upload the remaining data and complete the
+	// session.
+	if bufLen > 0 {
+		if err := r.managedUploadSessionChunk(us, fileNode, buf, bufLen); err != nil {
+			return err
+		}
+	}
+	if err := fileNode.SetFileSize(us.persist.Length); err != nil {
+		return errors.AddContext(err, "failed to set the final file size")
+	}
+	err = us.managedUpdate(func(usp *uploadSessionPersist) {
+		usp.Complete = true
+	})
+	if err != nil {
+		return err
+	}
+	return os.Remove(us.staticBufferPath)
+}
+
+// managedUploadSessionChunk uploads the first bufLen bytes of the buffer as the
+// next chunk of the session and waits for it to become available. Afterwards
+// the buffer is truncated.
+func (r *Renter) managedUploadSessionChunk(us *uploadSession, fileNode *filesystem.FileNode, buf *os.File, bufLen uint64) error {
+	data := make([]byte, bufLen)
+	if _, err := buf.ReadAt(data, 0); err != nil {
+		return errors.AddContext(err, "failed to read upload session buffer")
+	}
+	chunkIndex := us.persist.UploadedChunks
+
+	// Grow the SiaFile to include the chunk.
+	if err := fileNode.SiaFile.GrowNumChunks(chunkIndex + 1); err != nil {
+		return err
+	}
+	pks := make(map[string]types.SiaPublicKey)
+	for _, pk := range fileNode.HostPublicKeys() {
+		pks[string(pk.Key)] = pk
+	}
+	hosts := r.managedRefreshHostsAndWorkers()
+	offline, goodForRenew, _ := r.managedContractUtilityMaps()
+	uuc, err := r.managedBuildUnfinishedChunk(fileNode, chunkIndex, hosts, pks, memoryPriorityHigh, offline, goodForRenew, r.userUploadMemoryManager)
+	if err != nil {
+		return errors.AddContext(err, "unable to fetch chunk for upload session")
+	}
+
+	// The chunk might have been uploaded before a restart without the session
+	// being updated. In that case there is nothing left to do.
+	if uuc.piecesCompleted < uuc.staticPiecesNeeded {
+		ss := NewStreamShard(bytes.NewReader(data), nil)
+		uuc.sourceReader = ss
+		pushed, err := r.managedPushChunkForRepair(uuc, chunkTypeStreamChunk)
+		if err != nil {
+			return errors.AddContext(err, "unable to push chunk")
+		}
+		if !pushed {
+			return errors.New("chunk is already being repaired, try again later")
+		}
+		select {
+		case <-r.tg.StopChan():
+			return errors.New("upload interrupted by shutdown")
+		case <-uuc.staticAvailableChan:
+		}
+		uuc.mu.Lock()
+		err = uuc.err
+		uuc.mu.Unlock()
+		if err != nil {
+			return errors.AddContext(err, "failed to upload chunk of upload session")
+		}
+	}
+
+	// Drop the chunk's data from the buffer.
+	err = us.managedUpdate(func(usp *uploadSessionPersist) {
+		usp.UploadedChunks++
+	})
+	if err != nil {
+		return err
+	}
+	return buf.Truncate(0)
+}
@@ -0,0 +1,162 @@
+package renter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestUploadSession tests creating, writing to, reloading and aborting an
+// upload session without uploading a full chunk.
+func TestUploadSession(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create a session.
+	up := modules.FileUploadParams{
+		SiaPath:     modules.RandomSiaPath(),
+		ErasureCode: modules.NewRSSubCodeDefault(),
+		CipherType:  crypto.TypeDefaultRenter,
+	}
+	session, err := r.CreateUploadSession(up, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Offset != 0 || session.Length != 1000 || session.Complete {
+		t.Fatal("unexpected session", session)
+	}
+
+	// Write some data.
+	data := fastrand.Bytes(100)
+	session, err = r.WriteUploadSession(session.ID, 0, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Offset != uint64(len(data)) {
+		t.Fatal("wrong offset", session.Offset)
+	}
+
+	// Writing at the wrong offset should fail.
+	_, err = r.WriteUploadSession(session.ID, 0, bytes.NewReader(data))
+	if !errors.Contains(err, modules.ErrUploadSessionOffsetMismatch) {
+		t.Fatal("expected ErrUploadSessionOffsetMismatch but got", err)
+	}
+
+	// The session can be queried during a write while other writes are
+	// rejected.
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	done := make(chan error)
+	go func() {
+		_, err := r.WriteUploadSession(session.ID, session.Offset, pr)
+		done <- err
+	}()
+	if _, err := pw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 10*time.Millisecond, func() error {
+		_, err := r.WriteUploadSession(session.ID, session.Offset, bytes.NewReader(data))
+		if !errors.Contains(err, errUploadSessionWriteInProgress) {
+			return fmt.Errorf("expected errUploadSessionWriteInProgress but got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	infoChan := make(chan error)
+	go func() {
+		_, err := r.UploadSession(session.ID)
+		infoChan <- err
+	}()
+	select {
+	case err := <-infoChan:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("querying the session blocked on the write")
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	info, err := r.UploadSession(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Offset != 2*uint64(len(data)) {
+		t.Fatal("wrong offset", info.Offset)
+	}
+
+	// Sending more data than the session's length should fail without
+	// completing the session.
+	session, err = r.WriteUploadSession(session.ID, 2*uint64(len(data)), bytes.NewReader(fastrand.Bytes(int(session.Length)+1-2*len(data))))
+	if !errors.Contains(err, errUploadSessionTooMuchData) {
+		t.Fatal("expected errUploadSessionTooMuchData but got", err)
+	}
+	if session.Complete || session.Offset != session.Length {
+		t.Fatal("unexpected session", session)
+	}
+
+	// Reload the sessions from disk.
+	uss, err := newUploadSessionSet(r.staticUploadSessions.staticDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	us, err := uss.managedSession(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if us.managedInfo().Offset != session.Offset {
+		t.Fatal("wrong offset after reload", us.managedInfo().Offset)
+	}
+	if len(r.UploadSessions()) != 1 {
+		t.Fatal("expected 1 session")
+	}
+
+	// Abort the session.
+	if err := r.AbortUploadSession(session.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.UploadSession(session.ID); !errors.Contains(err, errUploadSessionNotFound) {
+		t.Fatal("expected errUploadSessionNotFound but got", err)
+	}
+	exists, err := r.staticFileSystem.FileExists(up.SiaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Fatal("siafile should have been deleted")
+	}
+	path := filepath.Join(r.staticUploadSessions.staticDir, string(session.ID)+uploadSessionBufferExtension)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("buffer should have been deleted", err)
+	}
+}
@@ -2,6 +2,7 @@ package renter
 
 import (
 	"fmt"
+	"hash"
 	"io"
 	"sync"
 
@@ -218,10 +219,20 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		}
 	}()
 
+	// If a checksum was requested, hash all the data read from the stream.
+	var checksum hash.Hash
+	if up.Checksum {
+		checksum = crypto.NewHash()
+		reader = io.TeeReader(reader, checksum)
+	}
+
 	// Check if stream has at least one byte. No need to upload empty data.
 	peek := []byte{0}
 	_, err = io.ReadFull(reader, peek)
 	if errors.Contains(err, io.EOF) || errors.Contains(err, io.ErrUnexpectedEOF) {
+		if err := r.staticSetStreamChecksum(fileNode, checksum); err != nil {
+			return nil, err
+		}
 		return fileNode, nil
 	} else if err != nil {
 		return nil, err
@@ -346,5 +357,19 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 	if r.deps.Disrupt("failUploadStreamFromReader") {
 		return nil, errors.New("disrupted by failUploadStreamFromReader")
 	}
+	if err := r.staticSetStreamChecksum(fileNode, checksum); err != nil {
+		return nil, err
+	}
 	return fileNode, nil
 }
+
+// staticSetStreamChecksum stores the checksum of a stream upload in the
+// SiaFile. A nil checksum is ignored.
+func (r *Renter) staticSetStreamChecksum(fileNode *filesystem.FileNode, checksum hash.Hash) error {
+	if checksum == nil {
+		return nil
+	}
+	var h crypto.Hash
+	copy(h[:], checksum.Sum(nil))
+	return errors.AddContext(fileNode.SetChecksum(h), "unable to set checksum")
+}
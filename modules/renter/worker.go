@@ -35,6 +35,10 @@ const (
 	// host to support the registry.
 	minRegistryVersion = "1.5.1"
 
+	// minReadRangeVersion defines the minimum version that is required for a
+	// host to support reading ranges which span multiple sectors.
+	minReadRangeVersion = "1.5.10"
+
 	// registryCacheSize is the cache size used by a single worker for the
 	// registry cache.
 	registryCacheSize = 1 << 20 // 1 MiB
@@ -86,6 +90,7 @@ type (
 		staticJobReadRegistryQueue     *jobReadRegistryQueue
 		staticJobRenewQueue            *jobRenewQueue
 		staticJobUpdateRegistryQueue   *jobUpdateRegistryQueue
+		staticJobUpdateSectorQueue     *jobUpdateSectorQueue
 		staticJobUploadSnapshotQueue   *jobUploadSnapshotQueue
 
 		// Upload variables.
@@ -264,6 +269,7 @@ func (r *Renter) newWorker(hostPubKey types.SiaPublicKey) (*worker, error) {
 	w.initJobDownloadSnapshotQueue()
 	w.initJobReadRegistryQueue()
 	w.initJobUpdateRegistryQueue()
+	w.initJobUpdateSectorQueue()
 	w.initJobUploadSnapshotQueue()
 
 	// Close the worker when the renter is stopped.
@@ -165,6 +165,12 @@ func TestReadOffsetCorruptedProof(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	// The snapshot's data is stored in the second sector, so a range across
+	// both sectors can be read as well.
+	_, err = wt.ReadOffset(context.Background(), categorySnapshotDownload, modules.SectorSize/2, modules.SectorSize)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	// Do it again but this time corrupt the output to make sure the proof
 	// doesn't match.
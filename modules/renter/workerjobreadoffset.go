@@ -6,6 +6,7 @@ import (
 
 	"gitlab.com/NebulousLabs/encoding"
 	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 )
@@ -37,7 +38,11 @@ func (j *jobReadOffset) managedReadOffset() ([]byte, error) {
 	pt := w.staticPriceTable().staticPriceTable
 	pb := modules.NewProgramBuilder(&pt, 0) // 0 duration since Read doesn't depend on it.
 	pb.AddRevisionInstruction()
-	pb.AddReadOffsetInstruction(j.staticLength, j.staticOffset, true)
+	if modules.MDMReadRangeSectors(j.staticOffset, j.staticLength) > 1 {
+		pb.AddReadRangeInstruction(j.staticLength, j.staticOffset, true)
+	} else {
+		pb.AddReadOffsetInstruction(j.staticLength, j.staticOffset, true)
+	}
 	program, programData := pb.Program()
 	cost, _, _ := pb.Cost(true)
 
@@ -97,8 +102,13 @@ func (j *jobReadOffset) managedReadOffset() ([]byte, error) {
 	return downloadResponse.Output, nil
 }
 
-// ReadOffset is a helper method to run a ReadOffset job on a worker.
+// ReadOffset is a helper method to run a ReadOffset job on a worker. Ranges
+// which span multiple sectors are read with a single ReadRange instruction
+// which requires the host to run at least minReadRangeVersion.
 func (w *worker) ReadOffset(ctx context.Context, category spendingCategory, offset, length uint64) ([]byte, error) {
+	if modules.MDMReadRangeSectors(offset, length) > 1 && build.VersionCmp(w.staticCache().staticHostVersion, minReadRangeVersion) < 0 {
+		return nil, errors.New("host doesn't support reading ranges which span multiple sectors")
+	}
 	readOffsetRespChan := make(chan *jobReadResponse)
 	jro := &jobReadOffset{
 		jobRead: jobRead{
@@ -0,0 +1,217 @@
+package renter
+
+import (
+	"context"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/contractor"
+)
+
+type (
+	// jobUpdateSector contains information about an UpdateSector query.
+	jobUpdateSector struct {
+		staticSectorIndex  uint64
+		staticSectorRoot   crypto.Hash
+		staticOffset       uint64
+		staticData         []byte
+		staticResponseChan chan *jobUpdateSectorResponse
+
+		*jobGeneric
+	}
+
+	// jobUpdateSectorQueue is a list of UpdateSector queries that have been
+	// assigned to the worker.
+	jobUpdateSectorQueue struct {
+		*jobGenericQueue
+	}
+
+	// jobUpdateSectorResponse contains the result of an UpdateSector query.
+	jobUpdateSectorResponse struct {
+		staticNewSectorRoot crypto.Hash
+		staticErr           error
+	}
+)
+
+// updateSectorJobExpectedBandwidth is a helper function that returns the
+// expected bandwidth consumption of an update sector job which updates 'length'
+// bytes of a sector.
+func updateSectorJobExpectedBandwidth(length uint64) (ul, dl uint64) {
+	ul = uint64(float64(length)*1.01) + 1<<15 // (updateSize * 1.01 + 32 KiB)
+	dl = uint64(float64(length)*1.01) + 1<<14 // (updateSize * 1.01 + 16 KiB)
+	return
+}
+
+// callDiscard will discard a job, sending the provided error.
+func (j *jobUpdateSector) callDiscard(err error) {
+	w := j.staticQueue.staticWorker()
+	w.renter.tg.Launch(func() {
+		response := &jobUpdateSectorResponse{
+			staticErr: errors.Extend(err, ErrJobDiscarded),
+		}
+		select {
+		case j.staticResponseChan <- response:
+		case <-j.staticCtx.Done():
+		case <-w.renter.tg.StopChan():
+		}
+	})
+}
+
+// callExecute will run the update sector job.
+func (j *jobUpdateSector) callExecute() {
+	w := j.staticQueue.staticWorker()
+
+	// Proactively try to fix a revision mismatch.
+	w.externTryFixRevisionMismatch()
+
+	newRoot, err := j.managedUpdateSector()
+
+	// If the error could be caused by a revision number mismatch,
+	// signal it by setting the flag.
+	if errCausedByRevisionMismatch(err) {
+		w.staticSetSuspectRevisionMismatch()
+		w.staticWake()
+	}
+
+	// Send the response.
+	response := &jobUpdateSectorResponse{
+		staticNewSectorRoot: newRoot,
+		staticErr:           err,
+	}
+	w.renter.tg.Launch(func() {
+		select {
+		case j.staticResponseChan <- response:
+		case <-j.staticCtx.Done():
+		case <-w.renter.tg.StopChan():
+		}
+	})
+
+	// Report success or failure to the queue.
+	if err != nil {
+		j.staticQueue.callReportFailure(err)
+		return
+	}
+	j.staticQueue.callReportSuccess()
+}
+
+// callExpectedBandwidth returns the amount of bandwidth this job is expected to
+// consume.
+func (j *jobUpdateSector) callExpectedBandwidth() (ul, dl uint64) {
+	return updateSectorJobExpectedBandwidth(uint64(len(j.staticData)))
+}
+
+// managedUpdateSector updates a range of the sector with the job's data using
+// an UpdateSector program and finalizes the program by signing a revision with
+// the updated contract root. It returns the new root of the sector.
+func (j *jobUpdateSector) managedUpdateSector() (crypto.Hash, error) {
+	w := j.staticQueue.staticWorker()
+
+	// Get the contract with the host.
+	contract, exists := w.renter.hostContractor.ContractByPublicKey(w.staticHostPubKey)
+	if !exists {
+		return crypto.Hash{}, errors.New("no contract with host")
+	}
+
+	// Create the program. UpdateSector doesn't add storage to the contract, so
+	// the duration doesn't matter.
+	pt := w.staticPriceTable().staticPriceTable
+	pb := modules.NewProgramBuilder(&pt, 0)
+	err := pb.AddUpdateSectorInstruction(j.staticSectorIndex*modules.SectorSize+j.staticOffset, j.staticData, true)
+	if err != nil {
+		return crypto.Hash{}, errors.AddContext(err, "failed to add UpdateSector instruction")
+	}
+	program, programData := pb.Program()
+	cost, _, _ := pb.Cost(true)
+
+	// take into account bandwidth costs
+	ulBandwidth, dlBandwidth := j.callExpectedBandwidth()
+	bandwidthCost := modules.MDMBandwidthCost(pt, ulBandwidth, dlBandwidth)
+	cost = cost.Add(bandwidthCost)
+
+	// Verify the update and sign the new revision before the host commits it.
+	var newRoot crypto.Hash
+	finalize := func(stream siamux.Stream, responses []programResponse) error {
+		var output modules.MDMInstructionUpdateSectorResponse
+		err := encoding.Unmarshal(responses[0].Output, &output)
+		if err != nil {
+			return errors.AddContext(err, "failed to decode UpdateSector output")
+		}
+		err = w.renter.hostContractor.FinalizeSectorUpdate(stream, pt.HostBlockHeight, contractor.SectorUpdateDetails{
+			Host:          w.staticHostPubKey,
+			SectorIndex:   j.staticSectorIndex,
+			OldSectorRoot: j.staticSectorRoot,
+			Offset:        j.staticOffset,
+			Data:          j.staticData,
+			Response:      responses[0].RPCExecuteProgramResponse,
+			Output:        output,
+		})
+		if err != nil {
+			return errors.AddContext(err, "failed to finalize sector update")
+		}
+		newRoot = output.NewSectorRoot
+		return nil
+	}
+
+	// Execute the program and parse the responses.
+	responses, _, err := w.managedExecuteProgramWithFinalizer(program, programData, contract.ID, categoryUpload, cost, finalize)
+	if err != nil {
+		return crypto.Hash{}, errors.AddContext(err, "Unable to execute program")
+	}
+	for _, resp := range responses {
+		if resp.Error != nil {
+			return crypto.Hash{}, errors.AddContext(resp.Error, "Output error")
+		}
+	}
+	if len(responses) != len(program) {
+		return crypto.Hash{}, errors.New("received invalid number of responses but no error")
+	}
+	return newRoot, nil
+}
+
+// initJobUpdateSectorQueue will initialize a queue for updating sectors with a
+// host for the worker. This is only meant to be run once at startup.
+func (w *worker) initJobUpdateSectorQueue() {
+	// Sanity check that there is no existing job queue.
+	if w.staticJobUpdateSectorQueue != nil {
+		w.renter.log.Critical("incorrect call on initJobUpdateSectorQueue")
+		return
+	}
+
+	w.staticJobUpdateSectorQueue = &jobUpdateSectorQueue{
+		jobGenericQueue: newJobGenericQueue(w),
+	}
+}
+
+// UpdateSector overwrites the data at the given offset within the sector at
+// sectorIndex of the contract with the worker's host. The root is the sector's
+// current root and is used to verify that the host updated the right sector.
+// Both the offset and the length of the data need to be multiples of
+// crypto.SegmentSize. The new root of the sector is returned.
+func (w *worker) UpdateSector(ctx context.Context, sectorIndex uint64, root crypto.Hash, offset uint64, data []byte) (crypto.Hash, error) {
+	updateSectorRespChan := make(chan *jobUpdateSectorResponse)
+	jus := &jobUpdateSector{
+		staticSectorIndex:  sectorIndex,
+		staticSectorRoot:   root,
+		staticOffset:       offset,
+		staticData:         data,
+		staticResponseChan: updateSectorRespChan,
+		jobGeneric:         newJobGeneric(ctx, w.staticJobUpdateSectorQueue, nil),
+	}
+
+	// Add the job to the queue.
+	if !w.staticJobUpdateSectorQueue.callAdd(jus) {
+		return crypto.Hash{}, errors.New("worker unavailable")
+	}
+
+	// Wait for the response.
+	var resp *jobUpdateSectorResponse
+	select {
+	case <-ctx.Done():
+		return crypto.Hash{}, errors.New("UpdateSector interrupted")
+	case resp = <-updateSectorRespChan:
+	}
+	return resp.staticNewSectorRoot, resp.staticErr
+}
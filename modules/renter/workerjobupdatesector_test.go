@@ -0,0 +1,115 @@
+package renter
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestUpdateSector is a unit test for the worker's UpdateSector method.
+func TestUpdateSector(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a worker.
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Upload a sector to the contract.
+	editor, err := wt.renter.hostContractor.Editor(wt.staticHostPubKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sector := fastrand.Bytes(int(modules.SectorSize))
+	root, err := editor.Upload(sector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Close(); err != nil {
+		t.Fatal(err)
+	}
+	contract, ok := wt.renter.hostContractor.ContractByPublicKey(wt.staticHostPubKey)
+	if !ok {
+		t.Fatal("contract doesn't exist")
+	}
+	sectorIndex := contract.Size()/modules.SectorSize - 1
+	revNum := contract.Transaction.FileContractRevisions[0].NewRevisionNumber
+
+	// Update the sector.
+	offset := uint64(4 * crypto.SegmentSize)
+	data := fastrand.Bytes(2 * crypto.SegmentSize)
+	newRoot, err := wt.UpdateSector(context.Background(), sectorIndex, root, offset, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(sector[offset:], data)
+	if newRoot != crypto.MerkleRoot(sector) {
+		t.Fatal("wrong sector root returned")
+	}
+
+	// The renter's revision should reflect the update.
+	contract, ok = wt.renter.hostContractor.ContractByPublicKey(wt.staticHostPubKey)
+	if !ok {
+		t.Fatal("contract doesn't exist")
+	}
+	if contract.Transaction.FileContractRevisions[0].NewRevisionNumber <= revNum {
+		t.Fatal("revision number wasn't incremented")
+	}
+	if contract.Size()/modules.SectorSize != sectorIndex+1 {
+		t.Fatal("contract size changed")
+	}
+
+	// The host should serve the updated sector.
+	downloaded, err := wt.ReadSector(context.Background(), categoryDownload, newRoot, 0, modules.SectorSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, sector) {
+		t.Fatal("downloaded data doesn't match updated sector")
+	}
+
+	// Another update on top of the first one should succeed since the renter's
+	// contract root needs to match the host's to verify the update.
+	data = fastrand.Bytes(crypto.SegmentSize)
+	newRoot, err = wt.UpdateSector(context.Background(), sectorIndex, newRoot, 0, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(sector, data)
+	if newRoot != crypto.MerkleRoot(sector) {
+		t.Fatal("wrong sector root returned")
+	}
+
+	// Updating the sector with the wrong root should fail without changing
+	// the contract.
+	contract, ok = wt.renter.hostContractor.ContractByPublicKey(wt.staticHostPubKey)
+	if !ok {
+		t.Fatal("contract doesn't exist")
+	}
+	revNum = contract.Transaction.FileContractRevisions[0].NewRevisionNumber
+	_, err = wt.UpdateSector(context.Background(), sectorIndex, root, 0, data)
+	if err == nil || !strings.Contains(err.Error(), "host updated the wrong sector") {
+		t.Fatal("expected update with wrong root to fail", err)
+	}
+	contract, ok = wt.renter.hostContractor.ContractByPublicKey(wt.staticHostPubKey)
+	if !ok {
+		t.Fatal("contract doesn't exist")
+	}
+	if contract.Transaction.FileContractRevisions[0].NewRevisionNumber != revNum {
+		t.Fatal("failed update changed the contract")
+	}
+}
@@ -44,8 +44,19 @@ type programResponse struct {
 	Output []byte
 }
 
+// programFinalizer finalizes a program which modified a contract. It is called
+// with the stream the program was executed on and the program's responses.
+type programFinalizer func(stream siamux.Stream, responses []programResponse) error
+
 // managedExecuteProgram performs the ExecuteProgramRPC on the host
 func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid types.FileContractID, category spendingCategory, cost types.Currency) (responses []programResponse, limit mux.BandwidthLimit, err error) {
+	return w.managedExecuteProgramWithFinalizer(p, data, fcid, category, cost, nil)
+}
+
+// managedExecuteProgramWithFinalizer performs the ExecuteProgramRPC on the host
+// and calls the finalizer if all instructions were executed successfully. Write
+// programs need a finalizer to sign the revision which commits their changes.
+func (w *worker) managedExecuteProgramWithFinalizer(p modules.Program, data []byte, fcid types.FileContractID, category spendingCategory, cost types.Currency, finalize programFinalizer) (responses []programResponse, limit mux.BandwidthLimit, err error) {
 	// Defer a function that schedules a price table update in case we received
 	// an error that indicates the host deems our price table invalid.
 	defer func() {
@@ -157,6 +168,18 @@ func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid type
 			break
 		}
 	}
+
+	// Finalize the program if it was executed successfully.
+	if finalize == nil || len(responses) != len(epr.Program) || responses[len(responses)-1].Error != nil {
+		return
+	}
+	err = finalize(stream, responses)
+	if err != nil {
+		return
+	}
+
+	// The host doesn't refund a finalized program.
+	refund = types.ZeroCurrency
 	return
 }
 
@@ -139,6 +139,11 @@ type RPCPriceTable struct {
 	// SwapSectorCost is the cost of swapping 2 full sectors by root.
 	SwapSectorCost types.Currency `json:"swapsectorcost"`
 
+	// UpdateSectorBaseCost is the base cost of updating a range of data within
+	// an existing sector. On top of that the renter pays for reading and
+	// rewriting the full sector.
+	UpdateSectorBaseCost types.Currency `json:"updatesectorbasecost"`
+
 	// Cost values specific to the Write instruction.
 	WriteBaseCost   types.Currency `json:"writebasecost"`   // per write
 	WriteLengthCost types.Currency `json:"writelengthcost"` // per byte written
@@ -1,10 +1,10 @@
 package modules
 
 import (
-	"errors"
 	"os"
 	"path/filepath"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/siamux"
 	"gitlab.com/NebulousLabs/siamux/mux"
 	"go.sia.tech/siad/build"
@@ -57,9 +57,41 @@ func NewSiaMux(siaMuxDir, siaDir, tcpaddress, wsaddress string) (*siamux.SiaMux,
 	if compat {
 		return siamux.CompatV1421NewWithKeyPair(tcpaddress, wsaddress, logger.Logger, siaMuxDir, privKey, pubKey)
 	}
+
+	// If the host imported the identity of another host, the siamux takes
+	// over its key pair.
+	pubKey, privKey, migrated := loadMigratedHostKeys(siaDir)
+	if migrated {
+		sm, err := siamux.CompatV1421NewWithKeyPair(tcpaddress, wsaddress, logger.Logger, siaMuxDir, privKey, pubKey)
+		if err != nil {
+			return nil, err
+		}
+		err = os.Remove(filepath.Join(siaDir, HostDir, HostMigratedKeysFile))
+		if err != nil {
+			return nil, errors.Compose(err, sm.Close())
+		}
+		return sm, nil
+	}
 	return siamux.New(tcpaddress, wsaddress, logger.Logger, siaMuxDir)
 }
 
+// loadMigratedHostKeys will try and load the key pair of a host which was
+// imported from another node.
+func loadMigratedHostKeys(persistDir string) (pubKey mux.ED25519PublicKey, privKey mux.ED25519SecretKey, migrated bool) {
+	hk := struct {
+		PublicKey types.SiaPublicKey `json:"publickey"`
+		SecretKey crypto.SecretKey   `json:"secretkey"`
+	}{}
+	err := persist.LoadJSON(HostMigratedKeysMetadata, &hk, filepath.Join(persistDir, HostDir, HostMigratedKeysFile))
+	if err != nil || len(hk.PublicKey.Key) != len(pubKey) {
+		return
+	}
+	copy(pubKey[:], hk.PublicKey.Key)
+	copy(privKey[:], hk.SecretKey[:])
+	migrated = true
+	return
+}
+
 // SiaPKToMuxPK turns a SiaPublicKey into a mux.ED25519PublicKey
 func SiaPKToMuxPK(spk types.SiaPublicKey) (mk mux.ED25519PublicKey) {
 	// Sanity check key length
@@ -53,6 +53,10 @@ var (
 	// accessible data.
 	HomeFolder = NewGlobalSiaPath("/home")
 
+	// ReencodeFolder is the Sia folder where the renter stores siafiles while
+	// they are being re-encoded to a new erasure code.
+	ReencodeFolder = NewGlobalSiaPath("/reencode")
+
 	// UserFolder is the Sia folder that is used to store the renter's siafiles.
 	UserFolder = NewGlobalSiaPath("/home/user")
 )
@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"regexp"
 	"strings"
+	"time"
 	"unicode"
 
+	"gitlab.com/NebulousLabs/encoding"
 	mnemonics "gitlab.com/NebulousLabs/entropy-mnemonics"
 
 	"go.sia.tech/siad/crypto"
@@ -27,6 +30,57 @@ const (
 	WalletDir = "wallet"
 )
 
+const (
+	// CoinSelectionLargestFirst spends the largest outputs first. It is the
+	// default strategy and minimizes the number of inputs of a transaction.
+	CoinSelectionLargestFirst CoinSelectionStrategy = "largest-first"
+
+	// CoinSelectionSmallestFirst spends the smallest outputs first, which
+	// consolidates small outputs at the cost of larger transactions.
+	CoinSelectionSmallestFirst CoinSelectionStrategy = "smallest-first"
+
+	// CoinSelectionMinimizeChange prefers the smallest single output which
+	// covers the amount, avoiding change outputs where possible.
+	CoinSelectionMinimizeChange CoinSelectionStrategy = "minimize-change"
+
+	// CoinSelectionPrivacy avoids linking addresses by spending the outputs
+	// of as few addresses as possible, always spending all outputs of a
+	// selected address together.
+	CoinSelectionPrivacy CoinSelectionStrategy = "privacy"
+)
+
+const (
+	// InvoiceStatusPending indicates that no payment for the invoice has
+	// been seen yet.
+	InvoiceStatusPending InvoiceStatus = "pending"
+
+	// InvoiceStatusPartial indicates that the invoice received payments
+	// which don't cover its amount yet.
+	InvoiceStatusPartial InvoiceStatus = "partial"
+
+	// InvoiceStatusPaid indicates that the payments cover the amount of the
+	// invoice but don't have the required number of confirmations yet.
+	InvoiceStatusPaid InvoiceStatus = "paid"
+
+	// InvoiceStatusConfirmed indicates that the payments covering the amount
+	// of the invoice have the required number of confirmations.
+	InvoiceStatusConfirmed InvoiceStatus = "confirmed"
+
+	// InvoiceStatusExpired indicates that the invoice expired before its
+	// amount was paid.
+	InvoiceStatusExpired InvoiceStatus = "expired"
+)
+
+const (
+	// SignerMethodPublicKey requests the public key of the external signer
+	// for a derivation index.
+	SignerMethodPublicKey = "publickey"
+
+	// SignerMethodSign requests signatures for a transaction from the
+	// external signer.
+	SignerMethodSign = "sign"
+)
+
 var (
 	// ErrBadEncryptionKey is returned if the incorrect encryption key to a
 	// file is provided.
@@ -38,6 +92,10 @@ var (
 	// being 'unconfirmed' yet.
 	ErrIncompleteTransactions = errors.New("wallet has coins spent in incomplete transactions - not enough remaining coins")
 
+	// ErrInvalidVestingSchedule is returned if the parameters of a vesting
+	// schedule don't describe at least one non-empty tranche.
+	ErrInvalidVestingSchedule = errors.New("vesting schedule requires at least one tranche, a non-zero amount per tranche and a non-zero interval between tranches")
+
 	// ErrLockedWallet is returned when an action cannot be performed due to
 	// the wallet being locked.
 	ErrLockedWallet = errors.New("wallet must be unlocked before it can be used")
@@ -46,6 +104,26 @@ var (
 	// complete the desired action.
 	ErrLowBalance = errors.New("insufficient balance")
 
+	// ErrUnknownInvoice is returned if an invoice is not known to the
+	// wallet.
+	ErrUnknownInvoice = errors.New("unknown invoice")
+
+	// ErrUnknownMultisigAccount is returned if a multisig account is not
+	// known to the wallet.
+	ErrUnknownMultisigAccount = errors.New("unknown multisig account")
+
+	// ErrUnknownPaymentSchedule is returned if a payment schedule is not known
+	// to the wallet.
+	ErrUnknownPaymentSchedule = errors.New("unknown payment schedule")
+
+	// ErrUnknownTransaction is returned if a transaction is not known to the
+	// wallet.
+	ErrUnknownTransaction = errors.New("transaction is not known to the wallet")
+
+	// ErrUnknownCoinSelectionStrategy is returned if a coin selection
+	// strategy is not supported by the wallet.
+	ErrUnknownCoinSelectionStrategy = errors.New("unknown coin selection strategy")
+
 	// ErrWalletShutdown is returned when a method can't continue execution due
 	// to the wallet shutting down.
 	ErrWalletShutdown = errors.New("wallet is shutting down")
@@ -106,6 +184,124 @@ type (
 
 		Inputs  []ProcessedInput  `json:"inputs"`
 		Outputs []ProcessedOutput `json:"outputs"`
+
+		// Label and Memo annotate the transaction. AddressLabels contains the
+		// labels of the addresses related to the transaction. The labels are
+		// stored separately by the wallet and are not part of the encoding
+		// of a ProcessedTransaction.
+		Label         string         `json:"label,omitempty"`
+		Memo          string         `json:"memo,omitempty"`
+		AddressLabels []AddressLabel `json:"addresslabels,omitempty"`
+	}
+
+	// TransactionLabel is a user-provided label and memo of a transaction.
+	TransactionLabel struct {
+		TransactionID types.TransactionID `json:"transactionid"`
+		Label         string              `json:"label"`
+		Memo          string              `json:"memo"`
+	}
+
+	// AddressLabel is a user-provided label and memo of an address. Addresses
+	// don't need to belong to the wallet to be labeled.
+	AddressLabel struct {
+		Address types.UnlockHash `json:"address"`
+		Label   string           `json:"label"`
+		Memo    string           `json:"memo"`
+	}
+
+	// MultisigAccount is an M-of-N multisig address tracked by the wallet.
+	// LocalKeys are the public keys of the UnlockConditions which belong to
+	// the wallet's seeds. The wallet signs spends from the account with these
+	// keys, the remaining signatures have to be added by the other cosigners.
+	MultisigAccount struct {
+		Name             string                 `json:"name"`
+		Address          types.UnlockHash       `json:"address"`
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+		LocalKeys        []types.SiaPublicKey   `json:"localkeys"`
+
+		// The balances of the account are computed when the accounts are
+		// requested.
+		ConfirmedSiacoinBalance types.Currency `json:"confirmedsiacoinbalance"`
+		ConfirmedSiafundBalance types.Currency `json:"confirmedsiafundbalance"`
+	}
+
+	// TimelockedBalance is the value of the wallet's confirmed outputs which
+	// become spendable at UnlockHeight.
+	TimelockedBalance struct {
+		UnlockHeight types.BlockHeight `json:"unlockheight"`
+		Value        types.Currency    `json:"value"`
+	}
+
+	// TimelockedOutput is a siacoin output which can't be spent before the
+	// Timelock of its UnlockConditions. The UnlockConditions are provided by
+	// the recipient, since they can't be derived from the address.
+	TimelockedOutput struct {
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+		Value            types.Currency         `json:"value"`
+	}
+
+	// SiafundClaim describes the siacoin claim of a siafund output received
+	// by the wallet. The claim accrues from ClaimStart, the value of the
+	// siafund pool when the output was created, until the output is spent,
+	// at which point the claim is paid out to ClaimAddress. Claim is the
+	// accrued claim of unspent outputs and the payout of spent ones.
+	SiafundClaim struct {
+		OutputID      types.SiafundOutputID `json:"outputid"`
+		Address       types.UnlockHash      `json:"address"`
+		Value         types.Currency        `json:"value"`
+		ClaimStart    types.Currency        `json:"claimstart"`
+		Height        types.BlockHeight     `json:"height"`
+		Timestamp     types.Timestamp       `json:"timestamp"`
+		TransactionID types.TransactionID   `json:"transactionid"`
+		Claim         types.Currency        `json:"claim"`
+
+		// The following fields are only set once the output was spent.
+		Spent                bool                `json:"spent"`
+		SpentHeight          types.BlockHeight   `json:"spentheight"`
+		SpentTimestamp       types.Timestamp     `json:"spenttimestamp"`
+		PayoutTransactionID  types.TransactionID `json:"payouttransactionid"`
+		ClaimAddress         types.UnlockHash    `json:"claimaddress"`
+		PayoutMaturityHeight types.BlockHeight   `json:"payoutmaturityheight"`
+	}
+
+	// SignerAddress is an address of the wallet whose key is held by an
+	// external signer. KeyIndex is the derivation index of the key within
+	// the signer.
+	SignerAddress struct {
+		Address          types.UnlockHash       `json:"address"`
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+		KeyIndex         uint64                 `json:"keyindex"`
+	}
+
+	// SignerRequest is the request sent to an external signer. Requests with
+	// the SignerMethodPublicKey method only set the KeyIndex, requests with
+	// the SignerMethodSign method set the remaining fields.
+	SignerRequest struct {
+		Method      string             `json:"method"`
+		KeyIndex    uint64             `json:"keyindex,omitempty"`
+		Transaction types.Transaction  `json:"transaction"`
+		Height      types.BlockHeight  `json:"height"`
+		Signatures  []SignatureRequest `json:"signatures,omitempty"`
+	}
+
+	// SignatureRequest describes a TransactionSignature which an external
+	// signer has to sign. Index is the index of the TransactionSignature
+	// within the transaction and SigHash the hash the signer signs with the
+	// key at KeyIndex, whose public key is PublicKey.
+	SignatureRequest struct {
+		Index     uint64             `json:"index"`
+		SigHash   crypto.Hash        `json:"sighash"`
+		KeyIndex  uint64             `json:"keyindex"`
+		PublicKey types.SiaPublicKey `json:"publickey"`
+	}
+
+	// SignerResponse is the response of an external signer. Signatures are
+	// returned in the order of the requested signatures. A non-empty Error
+	// indicates that the signer rejected the request.
+	SignerResponse struct {
+		PublicKey  types.SiaPublicKey `json:"publickey"`
+		Signatures [][]byte           `json:"signatures"`
+		Error      string             `json:"error"`
 	}
 
 	// ValuedTransaction is a transaction that has been given incoming and
@@ -118,7 +314,7 @@ type (
 	}
 
 	// A UnspentOutput is a SiacoinOutput or SiafundOutput that the wallet
-	// is tracking.
+	// is tracking. Frozen outputs are not used to fund transactions.
 	UnspentOutput struct {
 		ID                 types.OutputID    `json:"id"`
 		FundType           types.Specifier   `json:"fundtype"`
@@ -126,6 +322,90 @@ type (
 		Value              types.Currency    `json:"value"`
 		ConfirmationHeight types.BlockHeight `json:"confirmationheight"`
 		IsWatchOnly        bool              `json:"iswatchonly"`
+		Frozen             bool              `json:"frozen"`
+	}
+
+	// CoinSelectionStrategy determines the order in which the wallet selects
+	// siacoin outputs to fund a transaction.
+	CoinSelectionStrategy string
+
+	// CoinControl controls which siacoin outputs the wallet spends to fund a
+	// transaction. If Inputs is set, exactly those outputs are spent and the
+	// Strategy is ignored. Otherwise the outputs are selected according to
+	// the Strategy, which defaults to CoinSelectionLargestFirst.
+	CoinControl struct {
+		Inputs   []types.SiacoinOutputID `json:"inputs"`
+		Strategy CoinSelectionStrategy   `json:"strategy"`
+	}
+
+	// InvoiceStatus describes the payment state of an invoice.
+	InvoiceStatus string
+
+	// Invoice is a request for a siacoin payment to a fresh address of the
+	// wallet. The wallet tracks the payments to the address and updates the
+	// status of the invoice with every block and every change of the
+	// transaction pool.
+	Invoice struct {
+		ID      crypto.Hash      `json:"id"`
+		Address types.UnlockHash `json:"address"`
+		Amount  types.Currency   `json:"amount"`
+		Memo    string           `json:"memo"`
+		Created types.Timestamp  `json:"created"`
+
+		// Expiry is the time after which an unpaid invoice expires, zero
+		// means never. RequiredConfirmations is the number of blocks
+		// confirming the payment before the invoice is confirmed. If
+		// CallbackURL is set, the wallet POSTs the invoice to it as JSON
+		// whenever its status changes.
+		Expiry                types.Timestamp `json:"expiry"`
+		RequiredConfirmations uint64          `json:"requiredconfirmations"`
+		CallbackURL           string          `json:"callbackurl"`
+
+		// The following fields describe the payment state of the invoice.
+		// PaidHeight is the height of the block whose payment completed the
+		// amount of the invoice.
+		Status           InvoiceStatus     `json:"status"`
+		ConfirmedValue   types.Currency    `json:"confirmedvalue"`
+		UnconfirmedValue types.Currency    `json:"unconfirmedvalue"`
+		PaidHeight       types.BlockHeight `json:"paidheight"`
+		Confirmations    uint64            `json:"confirmations"`
+	}
+
+	// PaymentSchedule describes a scheduled siacoin payment which the wallet
+	// executes automatically once it is due. A schedule is triggered either by
+	// a block height or by a block timestamp, so exactly one of NextHeight and
+	// NextTime must be set. Schedules with a non-zero Interval are recurring.
+	PaymentSchedule struct {
+		ID          crypto.Hash      `json:"id"`
+		Description string           `json:"description"`
+		Destination types.UnlockHash `json:"destination"`
+		Amount      types.Currency   `json:"amount"`
+
+		// NextHeight and NextTime determine when the next payment is due.
+		NextHeight types.BlockHeight `json:"nextheight"`
+		NextTime   types.Timestamp   `json:"nexttime"`
+
+		// Interval is the time between two payments of a recurring schedule.
+		// It is measured in blocks for height-based schedules and in seconds
+		// for time-based schedules. MaxPayments limits the number of payments
+		// of a recurring schedule, zero means unlimited.
+		Interval    uint64 `json:"interval"`
+		MaxPayments uint64 `json:"maxpayments"`
+
+		// The following fields describe the status of the schedule. Failures
+		// counts the failed attempts since the last successful payment.
+		Completed         bool                `json:"completed"`
+		Failures          uint64              `json:"failures"`
+		LastError         string              `json:"lasterror"`
+		LastPaymentHeight types.BlockHeight   `json:"lastpaymentheight"`
+		LastTransactionID types.TransactionID `json:"lasttransactionid"`
+		Payments          uint64              `json:"payments"`
+
+		// PendingTransactions is the transaction set of a payment which is
+		// stored before it is broadcast. If the wallet stops before the
+		// outcome of the payment is recorded, the set is broadcast again
+		// instead of paying twice.
+		PendingTransactions []types.Transaction `json:"pendingtransactions"`
 	}
 
 	// TransactionBuilder is used to construct custom transactions. A transaction
@@ -148,6 +428,10 @@ type (
 		// transaction failed.
 		FundSiacoins(amount types.Currency) error
 
+		// FundSiacoinsCoinControl works like FundSiacoins but selects the
+		// outputs to spend according to the provided CoinControl.
+		FundSiacoinsCoinControl(amount types.Currency, cc CoinControl) error
+
 		// FundSiafunds will add a siafund input of exactly 'amount' to the
 		// transaction. A parent transaction may be needed to achieve an input
 		// with the correct value. The siafund input will not be signed until
@@ -370,8 +654,10 @@ type (
 		// LoadSeed will recreate a wallet file using the recovery phrase.
 		// LoadSeed only needs to be called if the original seed file or
 		// encryption password was lost. The master key is used to encrypt the
-		// recovery seed before saving it to disk.
-		LoadSeed(crypto.CipherKey, Seed) error
+		// recovery seed before saving it to disk. The blocks before the
+		// provided height are skipped when scanning for the seed's
+		// transactions.
+		LoadSeed(crypto.CipherKey, Seed, types.BlockHeight) error
 
 		// LoadSiagKeys will take a set of filepaths that point to a siag key
 		// and will have the siag keys loaded into the wallet so that they will
@@ -404,8 +690,21 @@ type (
 		// creates a transaction that transfers them to the wallet. Note that
 		// this incurs a transaction fee. It returns the total value of the
 		// outputs, minus the fee. If only siafunds were found, the fee is
-		// deducted from the wallet.
-		SweepSeed(seed Seed) (coins, funds types.Currency, err error)
+		// deducted from the wallet. The blocks before startHeight are skipped
+		// when scanning for the seed's outputs.
+		SweepSeed(seed Seed, startHeight types.BlockHeight) (coins, funds types.Currency, err error)
+	}
+
+	// ExternalSigner signs transactions with keys that are kept outside of
+	// the wallet, e.g. in a hardened process or a hardware device. Keys are
+	// identified by their derivation index.
+	ExternalSigner interface {
+		// PublicKey returns the public key at the derivation index.
+		PublicKey(keyIndex uint64) (types.SiaPublicKey, error)
+
+		// Sign returns a signature for each of the requested signatures of
+		// the transaction, in the order in which they were requested.
+		Sign(txn types.Transaction, height types.BlockHeight, sigs []SignatureRequest) ([][]byte, error)
 	}
 
 	// SiacoinSenderMulti is the minimal interface for an object that can send
@@ -484,16 +783,29 @@ type (
 		// rebuild its transaction history.
 		RemoveWatchAddresses(addrs []types.UnlockHash, unused bool) error
 
+		// Rescan deletes the wallet's transaction history starting at the
+		// provided height and rescans the blockchain from there.
+		Rescan(startHeight types.BlockHeight) error
+
 		// Rescanning reports whether the wallet is currently rescanning the
 		// blockchain.
 		Rescanning() (bool, error)
 
+		// BirthHeight returns a height from which the blockchain can be
+		// rescanned safely for a wallet created at the provided birth date.
+		BirthHeight(birthDate types.Timestamp) (types.BlockHeight, error)
+
 		// Settings returns the Wallet's current settings.
 		Settings() (WalletSettings, error)
 
 		// SetSettings sets the Wallet's settings.
 		SetSettings(WalletSettings) error
 
+		// Defrag consolidates the wallet's siacoin outputs immediately,
+		// regardless of the threshold, fee limit and time windows of the
+		// defrag policy. If dryRun is set, the defrag is only simulated.
+		Defrag(dryRun bool) (DefragReport, error)
+
 		// StartTransaction is a convenience method that calls
 		// RegisterTransaction(types.Transaction{}, nil)
 		StartTransaction() (TransactionBuilder, error)
@@ -507,8 +819,123 @@ type (
 		// SendSiacoinsFeeIncluded sends siacoins with fees included.
 		SendSiacoinsFeeIncluded(amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error)
 
+		// SendSiacoinsCoinControl works like SendSiacoins, or
+		// SendSiacoinsFeeIncluded if feeIncluded is set, but funds the
+		// transaction according to the provided CoinControl.
+		SendSiacoinsCoinControl(amount types.Currency, dest types.UnlockHash, feeIncluded bool, cc CoinControl) ([]types.Transaction, error)
+
 		SiacoinSenderMulti
 
+		// SendSiacoinsMultiCoinControl works like SendSiacoinsMulti but funds
+		// the transaction according to the provided CoinControl.
+		SendSiacoinsMultiCoinControl(outputs []types.SiacoinOutput, cc CoinControl) ([]types.Transaction, error)
+
+		// FreezeOutputs prevents the wallet from spending the siacoin outputs
+		// until they are unfrozen again.
+		FreezeOutputs(ids []types.SiacoinOutputID) error
+
+		// FrozenOutputs returns the ids of the frozen siacoin outputs.
+		FrozenOutputs() ([]types.SiacoinOutputID, error)
+
+		// UnfreezeOutputs allows the wallet to spend frozen siacoin outputs
+		// again.
+		UnfreezeOutputs(ids []types.SiacoinOutputID) error
+
+		// AddPaymentSchedule adds a scheduled payment to the wallet and
+		// returns it with its assigned ID.
+		AddPaymentSchedule(ps PaymentSchedule) (PaymentSchedule, error)
+
+		// PaymentSchedules returns all scheduled payments of the wallet.
+		PaymentSchedules() ([]PaymentSchedule, error)
+
+		// RemovePaymentSchedule removes a scheduled payment from the wallet.
+		RemovePaymentSchedule(id crypto.Hash) error
+
+		// AddInvoice creates an invoice for a payment to a new address of the
+		// wallet and starts tracking the payments to it.
+		AddInvoice(inv Invoice) (Invoice, error)
+
+		// Invoices returns all invoices of the wallet.
+		Invoices() ([]Invoice, error)
+
+		// RemoveInvoice stops tracking an invoice.
+		RemoveInvoice(id crypto.Hash) error
+
+		// SetTransactionLabel sets the label and memo of a transaction known
+		// to the wallet. An empty label and memo remove the label.
+		SetTransactionLabel(txid types.TransactionID, label, memo string) error
+
+		// SetAddressLabel sets the label and memo of an address. An empty
+		// label and memo remove the label.
+		SetAddressLabel(addr types.UnlockHash, label, memo string) error
+
+		// TransactionLabels returns the labels of all labeled transactions.
+		TransactionLabels() ([]TransactionLabel, error)
+
+		// AddressLabels returns the labels of all labeled addresses.
+		AddressLabels() ([]AddressLabel, error)
+
+		// AddMultisigAccount registers an M-of-N multisig address with the
+		// wallet. localKeys are the public keys of the wallet's seeds which
+		// participate in the account, if none are provided they are detected
+		// automatically. If the address hasn't appeared in the blockchain
+		// yet, the unused flag may be set to true. Otherwise, the wallet must
+		// rescan the blockchain to find the account's outputs.
+		AddMultisigAccount(name string, uc types.UnlockConditions, localKeys []types.SiaPublicKey, unused bool) (MultisigAccount, error)
+
+		// MultisigAccounts returns the multisig accounts of the wallet
+		// together with their balances.
+		MultisigAccounts() ([]MultisigAccount, error)
+
+		// RemoveMultisigAccount stops tracking a multisig account. The unused
+		// flag has the same meaning as for RemoveWatchAddresses.
+		RemoveMultisigAccount(addr types.UnlockHash, unused bool) error
+
+		// MultisigSpend builds a transaction which sends the outputs from a
+		// multisig account and returns the change to the account. The
+		// transaction is signed with the local keys of the account and
+		// complete reports whether it has enough signatures to be broadcast.
+		MultisigSpend(addr types.UnlockHash, outputs []types.SiacoinOutput) (txn types.Transaction, complete bool, err error)
+
+		// MultisigSign adds the signatures of the local keys to the inputs of
+		// txn which spend from the wallet's multisig accounts. complete
+		// reports whether all inputs have enough signatures.
+		MultisigSign(txn *types.Transaction) (complete bool, err error)
+
+		// AddTimelockedAddresses instructs the wallet to track timelocked
+		// addresses of its own keys, e.g. the addresses of a vesting
+		// schedule created by a sender. Every set of UnlockConditions must
+		// have a timelock and a single public key which belongs to the
+		// wallet's seeds. The unused flag has the same meaning as for
+		// AddWatchAddresses.
+		AddTimelockedAddresses(ucs []types.UnlockConditions, unused bool) error
+
+		// NewTimelockedAddress returns the UnlockConditions of a new address
+		// of the wallet which can't be spent from before the timelock.
+		NewTimelockedAddress(timelock types.BlockHeight) (types.UnlockConditions, error)
+
+		// SendTimelockedSiacoins sends siacoins to the timelocked addresses
+		// described by the outputs' UnlockConditions.
+		SendTimelockedSiacoins(outputs []TimelockedOutput) ([]types.Transaction, error)
+
+		// TimelockedBalances returns the value of the wallet's confirmed
+		// outputs which are still timelocked, grouped by the height at which
+		// they unlock.
+		TimelockedBalances() ([]TimelockedBalance, error)
+
+		// NewSignerAddress returns a new address of the wallet whose key is
+		// held by the external signer. Spends from the address are signed by
+		// the signer.
+		NewSignerAddress() (SignerAddress, error)
+
+		// SignerAddresses returns the addresses of the wallet whose keys are
+		// held by the external signer.
+		SignerAddresses() ([]SignerAddress, error)
+
+		// SiafundClaims returns the claims of the siafund outputs the wallet
+		// received, including the spent ones.
+		SiafundClaims() ([]SiafundClaim, error)
+
 		// SendSiafunds is a tool for sending siafunds from the wallet to an
 		// address. Sending money usually results in multiple transactions. The
 		// transactions are automatically given to the transaction pool, and
@@ -533,7 +960,54 @@ type (
 
 	// WalletSettings control the behavior of the Wallet.
 	WalletSettings struct {
-		NoDefrag bool `json:"nodefrag"`
+		NoDefrag     bool         `json:"nodefrag"`
+		DefragPolicy DefragPolicy `json:"defragpolicy"`
+
+		// AddressGap is the number of unused addresses following the primary
+		// seed progress that the wallet watches for incoming transactions.
+		// Seeds that handed out many addresses without receiving funds on
+		// them need a larger gap. Zero selects the wallet's default.
+		AddressGap uint64 `json:"addressgap"`
+	}
+
+	// DefragPolicy controls when and how the wallet automatically
+	// consolidates its siacoin outputs. Zero values select the wallet's
+	// defaults.
+	DefragPolicy struct {
+		// Threshold is the number of spendable outputs above which the wallet
+		// is defragmented. BatchSize is the number of outputs combined by a
+		// single defrag.
+		Threshold uint64 `json:"threshold"`
+		BatchSize uint64 `json:"batchsize"`
+
+		// MaxFeePerByte prevents defrags while the recommended fee per byte
+		// is higher. Zero means unlimited.
+		MaxFeePerByte types.Currency `json:"maxfeeperbyte"`
+
+		// Windows restricts defrags to certain times of the week. No windows
+		// means defrags are allowed at any time.
+		Windows []DefragWindow `json:"windows"`
+	}
+
+	// DefragWindow is a recurring window within the week during which
+	// defrags are allowed. Like a BandwidthWindow, it starts at Start on each
+	// of its Days and ends at End, which may be on the following day. Times
+	// are in the node's local time and use the format "15:04". No days means
+	// every day.
+	DefragWindow struct {
+		Days  []time.Weekday `json:"days"`
+		Start string         `json:"start"`
+		End   string         `json:"end"`
+	}
+
+	// DefragReport describes the consolidation of siacoin outputs by a
+	// defrag. TransactionIDs is empty for simulated defrags.
+	DefragReport struct {
+		Outputs        []types.SiacoinOutputID `json:"outputs"`
+		Amount         types.Currency          `json:"amount"`
+		Fee            types.Currency          `json:"fee"`
+		FeePerByte     types.Currency          `json:"feeperbyte"`
+		TransactionIDs []types.TransactionID   `json:"transactionids"`
 	}
 )
 
@@ -543,6 +1017,82 @@ func CalculateWalletTransactionID(tid types.TransactionID, oid types.OutputID) W
 	return WalletTransactionID(crypto.HashAll(tid, oid))
 }
 
+// schedule converts the windows of the policy into a BandwidthSchedule to
+// share its validation and matching of time windows.
+func (p DefragPolicy) schedule() BandwidthSchedule {
+	bs := make(BandwidthSchedule, len(p.Windows))
+	for i, dw := range p.Windows {
+		bs[i] = BandwidthWindow{Days: dw.Days, Start: dw.Start, End: dw.End}
+	}
+	return bs
+}
+
+// ValidateWindows checks that all the time windows of the policy are valid.
+func (p DefragPolicy) ValidateWindows() error {
+	return p.schedule().Validate()
+}
+
+// AllowedAt returns whether the policy's time windows allow a defrag at time
+// t.
+func (p DefragPolicy) AllowedAt(t time.Time) bool {
+	if len(p.Windows) == 0 {
+		return true
+	}
+	index, _, _ := p.schedule().ActiveWindow(t)
+	return index >= 0
+}
+
+// VestingSchedule splits amount into tranches outputs to the public key,
+// which unlock every interval blocks starting at start. The amount is split
+// evenly, the last tranche receives the remainder.
+func VestingSchedule(pk types.SiaPublicKey, amount types.Currency, start, interval types.BlockHeight, tranches uint64) ([]TimelockedOutput, error) {
+	if tranches == 0 || (tranches > 1 && interval == 0) {
+		return nil, ErrInvalidVestingSchedule
+	}
+	perTranche := amount.Div64(tranches)
+	if perTranche.IsZero() {
+		return nil, ErrInvalidVestingSchedule
+	}
+	outputs := make([]TimelockedOutput, tranches)
+	for i := range outputs {
+		outputs[i] = TimelockedOutput{
+			UnlockConditions: types.UnlockConditions{
+				Timelock:           start + types.BlockHeight(i)*interval,
+				PublicKeys:         []types.SiaPublicKey{pk},
+				SignaturesRequired: 1,
+			},
+			Value: perTranche,
+		}
+	}
+	outputs[tranches-1].Value = amount.Sub(perTranche.Mul64(tranches - 1))
+	return outputs, nil
+}
+
+// MarshalSia implements encoding.SiaMarshaler. The labels of the transaction
+// are omitted.
+func (pt ProcessedTransaction) MarshalSia(w io.Writer) error {
+	return encoding.NewEncoder(w).EncodeAll(
+		pt.Transaction,
+		pt.TransactionID,
+		pt.ConfirmationHeight,
+		pt.ConfirmationTimestamp,
+		pt.Inputs,
+		pt.Outputs,
+	)
+}
+
+// UnmarshalSia implements encoding.SiaUnmarshaler.
+func (pt *ProcessedTransaction) UnmarshalSia(r io.Reader) error {
+	return encoding.NewDecoder(r, encoding.DefaultAllocLimit).DecodeAll(
+		&pt.Transaction,
+		&pt.TransactionID,
+		&pt.ConfirmationHeight,
+		&pt.ConfirmationTimestamp,
+		&pt.Inputs,
+		&pt.Outputs,
+	)
+}
+
 // SeedToString converts a wallet seed to a human friendly string.
 func SeedToString(seed Seed, did mnemonics.DictionaryID) (string, error) {
 	fullChecksum := crypto.HashObject(seed)
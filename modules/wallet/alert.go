@@ -6,5 +6,5 @@ import (
 
 // Alerts implements the Alerter interface for the wallet.
 func (w *Wallet) Alerts() (crit, err, warn, info []modules.Alert) {
-	return
+	return w.staticAlerter.Alerts()
 }
@@ -0,0 +1,194 @@
+package wallet
+
+import (
+	"fmt"
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errDuplicateInput is returned if an output is requested more than once
+	// as an explicit input of a transaction.
+	errDuplicateInput = errors.New("output was requested more than once")
+
+	// errOutputFrozen indicates an output is not spendable because it was
+	// frozen by the user.
+	errOutputFrozen = errors.New("output is frozen")
+
+	// errUnknownOutput is returned if an output is not known to the wallet.
+	errUnknownOutput = errors.New("output is not known to the wallet")
+)
+
+// filterRequestedOutputs returns the outputs of 'so' with the requested ids in
+// the order in which they were requested.
+func filterRequestedOutputs(so sortedOutputs, ids []types.SiacoinOutputID) (sortedOutputs, error) {
+	known := make(map[types.SiacoinOutputID]types.SiacoinOutput, len(so.ids))
+	for i, id := range so.ids {
+		known[id] = so.outputs[i]
+	}
+	requested := make(map[types.SiacoinOutputID]struct{}, len(ids))
+	var filtered sortedOutputs
+	for _, id := range ids {
+		if _, exists := requested[id]; exists {
+			return sortedOutputs{}, errors.AddContext(errDuplicateInput, id.String())
+		}
+		requested[id] = struct{}{}
+		sco, exists := known[id]
+		if !exists {
+			return sortedOutputs{}, errors.AddContext(errUnknownOutput, id.String())
+		}
+		filtered.ids = append(filtered.ids, id)
+		filtered.outputs = append(filtered.outputs, sco)
+	}
+	return filtered, nil
+}
+
+// selectOutputs selects the outputs of 'so' which are used to fund 'amount'
+// according to the provided strategy. If the outputs are not sufficient to
+// cover the amount, all of them are returned.
+func selectOutputs(so sortedOutputs, amount types.Currency, strategy modules.CoinSelectionStrategy) (sortedOutputs, error) {
+	switch strategy {
+	case "", modules.CoinSelectionLargestFirst:
+		sort.Sort(sort.Reverse(so))
+		return selectPrefix(so, amount), nil
+	case modules.CoinSelectionSmallestFirst:
+		sort.Sort(so)
+		return selectPrefix(so, amount), nil
+	case modules.CoinSelectionMinimizeChange:
+		sort.Sort(so)
+		for i := range so.ids {
+			if so.outputs[i].Value.Cmp(amount) >= 0 {
+				return sortedOutputs{
+					ids:     []types.SiacoinOutputID{so.ids[i]},
+					outputs: []types.SiacoinOutput{so.outputs[i]},
+				}, nil
+			}
+		}
+		// No single output covers the amount.
+		sort.Sort(sort.Reverse(so))
+		return selectPrefix(so, amount), nil
+	case modules.CoinSelectionPrivacy:
+		return selectByAddress(so, amount), nil
+	default:
+		return sortedOutputs{}, errors.AddContext(modules.ErrUnknownCoinSelectionStrategy, string(strategy))
+	}
+}
+
+// selectPrefix returns the shortest prefix of 'so' which covers 'amount'.
+func selectPrefix(so sortedOutputs, amount types.Currency) sortedOutputs {
+	var fund types.Currency
+	for i := range so.ids {
+		fund = fund.Add(so.outputs[i].Value)
+		if fund.Cmp(amount) >= 0 {
+			return sortedOutputs{ids: so.ids[:i+1], outputs: so.outputs[:i+1]}
+		}
+	}
+	return so
+}
+
+// selectByAddress selects outputs without linking more addresses than
+// necessary. The outputs of an address are always spent together. If a single
+// address covers the amount, the one with the smallest balance is used.
+// Otherwise addresses are added in order of descending balance.
+func selectByAddress(so sortedOutputs, amount types.Currency) sortedOutputs {
+	type addressOutputs struct {
+		total types.Currency
+		so    sortedOutputs
+	}
+	var addrs []*addressOutputs
+	byAddr := make(map[types.UnlockHash]*addressOutputs)
+	for i := range so.ids {
+		uh := so.outputs[i].UnlockHash
+		ao, exists := byAddr[uh]
+		if !exists {
+			ao = &addressOutputs{}
+			byAddr[uh] = ao
+			addrs = append(addrs, ao)
+		}
+		ao.total = ao.total.Add(so.outputs[i].Value)
+		ao.so.ids = append(ao.so.ids, so.ids[i])
+		ao.so.outputs = append(ao.so.outputs, so.outputs[i])
+	}
+	sort.SliceStable(addrs, func(i, j int) bool {
+		return addrs[i].total.Cmp(addrs[j].total) < 0
+	})
+	for _, ao := range addrs {
+		if ao.total.Cmp(amount) >= 0 {
+			return ao.so
+		}
+	}
+	var fund types.Currency
+	var selected sortedOutputs
+	for i := len(addrs) - 1; i >= 0 && fund.Cmp(amount) < 0; i-- {
+		fund = fund.Add(addrs[i].total)
+		selected.ids = append(selected.ids, addrs[i].so.ids...)
+		selected.outputs = append(selected.outputs, addrs[i].so.outputs...)
+	}
+	return selected
+}
+
+// FreezeOutputs prevents the wallet from spending the siacoin outputs until
+// they are unfrozen again. Only confirmed outputs of the wallet can be frozen.
+func (w *Wallet) FreezeOutputs(ids []types.SiacoinOutputID) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := dbGetSiacoinOutput(w.dbTx, id); errors.Contains(err, errNoKey) {
+			return errors.AddContext(errUnknownOutput, id.String())
+		} else if err != nil {
+			return err
+		}
+	}
+	for _, id := range ids {
+		if err := dbPutFrozenOutput(w.dbTx, id, height); err != nil {
+			return errors.AddContext(err, fmt.Sprintf("failed to freeze output %v", id))
+		}
+	}
+	return w.syncDB()
+}
+
+// FrozenOutputs returns the ids of the frozen siacoin outputs.
+func (w *Wallet) FrozenOutputs() ([]types.SiacoinOutputID, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var ids []types.SiacoinOutputID
+	err := dbForEachFrozenOutput(w.dbTx, func(id types.SiacoinOutputID, _ types.BlockHeight) {
+		ids = append(ids, id)
+	})
+	return ids, err
+}
+
+// UnfreezeOutputs allows the wallet to spend frozen siacoin outputs again.
+// Outputs which aren't frozen are ignored.
+func (w *Wallet) UnfreezeOutputs(ids []types.SiacoinOutputID) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, id := range ids {
+		if err := dbDeleteFrozenOutput(w.dbTx, id); err != nil {
+			return errors.AddContext(err, fmt.Sprintf("failed to unfreeze output %v", id))
+		}
+	}
+	return w.syncDB()
+}
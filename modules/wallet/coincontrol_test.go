@@ -0,0 +1,203 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestSelectOutputs probes the coin selection strategies.
+func TestSelectOutputs(t *testing.T) {
+	newOutputs := func() sortedOutputs {
+		return sortedOutputs{
+			ids: []types.SiacoinOutputID{{0}, {1}, {2}, {3}, {4}},
+			outputs: []types.SiacoinOutput{
+				{Value: types.NewCurrency64(5), UnlockHash: types.UnlockHash{1}},
+				{Value: types.NewCurrency64(1), UnlockHash: types.UnlockHash{2}},
+				{Value: types.NewCurrency64(8), UnlockHash: types.UnlockHash{3}},
+				{Value: types.NewCurrency64(3), UnlockHash: types.UnlockHash{2}},
+				{Value: types.NewCurrency64(2), UnlockHash: types.UnlockHash{1}},
+			},
+		}
+	}
+
+	tests := []struct {
+		strategy modules.CoinSelectionStrategy
+		amount   uint64
+		expected []types.SiacoinOutputID
+	}{
+		{"", 10, []types.SiacoinOutputID{{2}, {0}}},
+		{modules.CoinSelectionLargestFirst, 8, []types.SiacoinOutputID{{2}}},
+		{modules.CoinSelectionSmallestFirst, 5, []types.SiacoinOutputID{{1}, {4}, {3}}},
+		{modules.CoinSelectionMinimizeChange, 4, []types.SiacoinOutputID{{0}}},
+		{modules.CoinSelectionMinimizeChange, 9, []types.SiacoinOutputID{{2}, {0}}},
+		{modules.CoinSelectionPrivacy, 4, []types.SiacoinOutputID{{1}, {3}}},
+		{modules.CoinSelectionPrivacy, 7, []types.SiacoinOutputID{{0}, {4}}},
+		{modules.CoinSelectionPrivacy, 12, []types.SiacoinOutputID{{2}, {0}, {4}}},
+		{modules.CoinSelectionPrivacy, 100, []types.SiacoinOutputID{{2}, {0}, {4}, {1}, {3}}},
+	}
+	for _, test := range tests {
+		selected, err := selectOutputs(newOutputs(), types.NewCurrency64(test.amount), test.strategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(selected.ids) != len(test.expected) || len(selected.outputs) != len(test.expected) {
+			t.Fatalf("%v/%v: expected %v outputs but got %v", test.strategy, test.amount, len(test.expected), len(selected.ids))
+		}
+		for i := range test.expected {
+			if selected.ids[i] != test.expected[i] {
+				t.Fatalf("%v/%v: unexpected output at index %v", test.strategy, test.amount, i)
+			}
+		}
+	}
+
+	_, err := selectOutputs(newOutputs(), types.NewCurrency64(1), "random")
+	if !errors.Contains(err, modules.ErrUnknownCoinSelectionStrategy) {
+		t.Fatal("expected ErrUnknownCoinSelectionStrategy but got", err)
+	}
+}
+
+// TestCoinControl tests funding transactions with explicit inputs and frozen
+// outputs.
+func TestCoinControl(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mine a few more blocks to get multiple spendable outputs.
+	for i := 0; i < 3; i++ {
+		b, _ := wt.miner.FindBlock()
+		if err := wt.cs.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var outputs []modules.UnspentOutput
+	uos, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, uo := range uos {
+		if uo.FundType == types.SpecifierSiacoinOutput {
+			outputs = append(outputs, uo)
+		}
+	}
+	if len(outputs) < 2 {
+		t.Fatal("expected multiple outputs", len(outputs))
+	}
+	spent := func(txns []types.Transaction) map[types.SiacoinOutputID]struct{} {
+		ids := make(map[types.SiacoinOutputID]struct{})
+		for _, txn := range txns {
+			for _, sci := range txn.SiacoinInputs {
+				ids[sci.ParentID] = struct{}{}
+			}
+		}
+		return ids
+	}
+	fund := func(amount types.Currency, cc modules.CoinControl) error {
+		tb, err := wt.wallet.StartTransaction()
+		if err != nil {
+			return err
+		}
+		defer tb.Drop()
+		return tb.FundSiacoinsCoinControl(amount, cc)
+	}
+	first := types.SiacoinOutputID(outputs[0].ID)
+	second := types.SiacoinOutputID(outputs[1].ID)
+
+	// Freeze the first output.
+	if err := wt.wallet.FreezeOutputs([]types.SiacoinOutputID{first}); err != nil {
+		t.Fatal(err)
+	}
+	frozen, err := wt.wallet.FrozenOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frozen) != 1 || frozen[0] != first {
+		t.Fatal("unexpected frozen outputs", frozen)
+	}
+	uos, err = wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, uo := range uos {
+		if uo.Frozen != (uo.ID == types.OutputID(first)) {
+			t.Fatal("wrong frozen flag for output", uo.ID)
+		}
+	}
+
+	// Unknown outputs can't be frozen.
+	err = wt.wallet.FreezeOutputs([]types.SiacoinOutputID{{1}})
+	if !errors.Contains(err, errUnknownOutput) {
+		t.Fatal("expected errUnknownOutput but got", err)
+	}
+
+	// The frozen output can't be spent explicitly.
+	amount := types.SiacoinPrecision
+	cc := modules.CoinControl{Inputs: []types.SiacoinOutputID{first}}
+	err = fund(amount, cc)
+	if !errors.Contains(err, errOutputFrozen) {
+		t.Fatal("expected errOutputFrozen but got", err)
+	}
+
+	// Spending all but 1 SC shouldn't touch the frozen output either. The
+	// remaining change is not enough to fund another 2 SC.
+	var total types.Currency
+	for _, o := range outputs[1:] {
+		total = total.Add(o.Value)
+	}
+	cc = modules.CoinControl{Strategy: modules.CoinSelectionSmallestFirst}
+	txns, err := wt.wallet.SendSiacoinsCoinControl(total.Sub(types.SiacoinPrecision), types.UnlockHash{}, true, cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := spent(txns)[first]; ok {
+		t.Fatal("frozen output was spent")
+	}
+	err = fund(amount.Mul64(2), modules.CoinControl{})
+	if !errors.Contains(err, modules.ErrIncompleteTransactions) && !errors.Contains(err, modules.ErrLowBalance) {
+		t.Fatal("expected the wallet to run out of spendable outputs but got", err)
+	}
+
+	// Unfreeze the output and spend it explicitly.
+	if err := wt.wallet.UnfreezeOutputs([]types.SiacoinOutputID{first}); err != nil {
+		t.Fatal(err)
+	}
+	if frozen, err := wt.wallet.FrozenOutputs(); err != nil || len(frozen) != 0 {
+		t.Fatal("output wasn't unfrozen", frozen, err)
+	}
+	cc = modules.CoinControl{Inputs: []types.SiacoinOutputID{first}}
+	txns, err = wt.wallet.SendSiacoinsCoinControl(amount, types.UnlockHash{}, false, cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := spent(txns)[first]; !ok {
+		t.Fatal("requested input wasn't spent")
+	}
+
+	// Outputs that were already spent and duplicates are rejected.
+	cc = modules.CoinControl{Inputs: []types.SiacoinOutputID{second}}
+	if err := fund(amount, cc); !errors.Contains(err, errSpendHeightTooHigh) {
+		t.Fatal("expected errSpendHeightTooHigh but got", err)
+	}
+	cc = modules.CoinControl{Inputs: []types.SiacoinOutputID{first, first}}
+	if err := fund(amount, cc); !errors.Contains(err, errDuplicateInput) {
+		t.Fatal("expected errDuplicateInput but got", err)
+	}
+
+	// Unknown strategies are rejected.
+	cc = modules.CoinControl{Strategy: "random"}
+	if err := fund(amount, cc); !errors.Contains(err, modules.ErrUnknownCoinSelectionStrategy) {
+		t.Fatal("expected ErrUnknownCoinSelectionStrategy but got", err)
+	}
+}
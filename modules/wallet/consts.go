@@ -1,6 +1,8 @@
 package wallet
 
 import (
+	"time"
+
 	"go.sia.tech/siad/build"
 )
 
@@ -15,6 +17,46 @@ const (
 	// defragThreshold is the number of outputs a wallet is allowed before it is
 	// defragmented.
 	defragThreshold = 50
+
+	// maxDefragBatchSize is the maximum number of outputs a defrag policy
+	// may combine during one defrag. It keeps the defrag transaction well
+	// below the transaction size limit.
+	maxDefragBatchSize = 75
+)
+
+const (
+	// maxLabelLength is the maximum length of a transaction or address label
+	// in bytes.
+	maxLabelLength = 256
+
+	// birthDateMargin is the number of seconds by which the wallet moves a
+	// birth date back before looking up the height to rescan from.
+	birthDateMargin = 24 * 60 * 60
+
+	// maxMemoLength is the maximum length of a transaction or address memo in
+	// bytes.
+	maxMemoLength = 4096
+)
+
+const (
+	// AlertMSGWalletPaymentSchedule indicates that the wallet failed to
+	// execute a scheduled payment. The payment is retried with every new
+	// block.
+	AlertMSGWalletPaymentSchedule = "wallet failed to execute a scheduled payment"
+
+	// AlertMSGWalletInvoice indicates that the payment status of an invoice
+	// changed.
+	AlertMSGWalletInvoice = "payment status of an invoice changed"
+)
+
+const (
+	// defaultInvoiceConfirmations is the number of confirmations an invoice
+	// requires if none are specified.
+	defaultInvoiceConfirmations = 6
+
+	// invoiceCallbackTimeout is the time the wallet waits for the callback
+	// URL of an invoice to accept a notification.
+	invoiceCallbackTimeout = 30 * time.Second
 )
 
 var (
@@ -33,6 +75,27 @@ var (
 		Standard: uint64(1000),
 		Testing:  uint64(10),
 	}).(uint64)
+
+	// defaultAddressGap is the number of unused addresses following the
+	// primary seed progress that the wallet watches if no address gap is set.
+	defaultAddressGap = lookaheadRescanThreshold + lookaheadBuffer
+
+	// maxAddressGap is the largest address gap the wallet accepts, which
+	// bounds the number of keys kept in memory.
+	maxAddressGap = build.Select(build.Var{
+		Dev:      uint64(1e6),
+		Standard: uint64(10e6),
+		Testing:  uint64(10e3),
+	}).(uint64)
+
+	// signerTimeout is the time the wallet waits for a response of the
+	// external signer. Signers may ask the user to confirm a transaction, so
+	// the timeout is generous.
+	signerTimeout = build.Select(build.Var{
+		Dev:      30 * time.Second,
+		Standard: 5 * time.Minute,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
 )
 
 func init() {
@@ -44,7 +107,8 @@ func init() {
 }
 
 // maxLookahead returns the size of the lookahead for a given seed progress
-// which usually is the current primarySeedProgress
-func maxLookahead(start uint64) uint64 {
-	return start + lookaheadRescanThreshold + lookaheadBuffer + start/10
+// which usually is the current primarySeedProgress and the address gap of the
+// wallet
+func maxLookahead(start, gap uint64) uint64 {
+	return start + gap + start/10
 }
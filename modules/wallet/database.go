@@ -11,6 +11,7 @@ import (
 	"gitlab.com/NebulousLabs/fastrand"
 
 	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -46,6 +47,32 @@ var (
 	// bucketWallet contains various fields needed by the wallet, such as its
 	// UID, EncryptionVerification, and PrimarySeedFile.
 	bucketWallet = []byte("bucketWallet")
+	// bucketFrozenOutputs maps a SiacoinOutputID to the height at which it
+	// was frozen. The wallet doesn't use frozen outputs to fund transactions.
+	bucketFrozenOutputs = []byte("bucketFrozenOutputs")
+	// bucketPaymentSchedules maps the id of a payment schedule to the
+	// modules.PaymentSchedule.
+	bucketPaymentSchedules = []byte("bucketPaymentSchedules")
+	// bucketTransactionLabels maps a TransactionID to its user-provided
+	// TransactionLabel.
+	bucketTransactionLabels = []byte("bucketTransactionLabels")
+	// bucketAddressLabels maps an UnlockHash to its user-provided
+	// AddressLabel.
+	bucketAddressLabels = []byte("bucketAddressLabels")
+	// bucketMultisigAccounts maps the UnlockHash of a multisig account to the
+	// modules.MultisigAccount.
+	bucketMultisigAccounts = []byte("bucketMultisigAccounts")
+	// bucketTimelockedAddresses maps a timelocked address of the wallet's
+	// keys to its UnlockConditions.
+	bucketTimelockedAddresses = []byte("bucketTimelockedAddresses")
+	// bucketSignerAddresses maps an address whose key is held by the external
+	// signer to its SignerAddress.
+	bucketSignerAddresses = []byte("bucketSignerAddresses")
+	// bucketInvoices maps the id of an invoice to the modules.Invoice.
+	bucketInvoices = []byte("bucketInvoices")
+	// bucketSiafundClaims maps the id of a siafund output received by the
+	// wallet to its modules.SiafundClaim.
+	bucketSiafundClaims = []byte("bucketSiafundClaims")
 
 	dbBuckets = [][]byte{
 		bucketProcessedTransactions,
@@ -56,6 +83,15 @@ var (
 		bucketSpentOutputs,
 		bucketUnlockConditions,
 		bucketWallet,
+		bucketFrozenOutputs,
+		bucketPaymentSchedules,
+		bucketTransactionLabels,
+		bucketAddressLabels,
+		bucketMultisigAccounts,
+		bucketTimelockedAddresses,
+		bucketSignerAddresses,
+		bucketInvoices,
+		bucketSiafundClaims,
 	}
 
 	errNoKey = errors.New("key does not exist")
@@ -72,6 +108,7 @@ var (
 	keySalt                   = []byte("keyUID")
 	keyWalletPassword         = []byte("keyWalletPassword")
 	keyWatchedAddrs           = []byte("keyWatchedAddrs")
+	keyWalletSettings         = []byte("keyWalletSettings")
 )
 
 // threadedDBUpdate commits the active database transaction and starts a new
@@ -204,6 +241,10 @@ func dbForEach(b *bolt.Bucket, fn interface{}) error {
 func dbPutSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID, output types.SiacoinOutput) error {
 	return dbPut(tx.Bucket(bucketSiacoinOutputs), id, output)
 }
+func dbGetSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) (output types.SiacoinOutput, err error) {
+	err = dbGet(tx.Bucket(bucketSiacoinOutputs), id, &output)
+	return
+}
 func dbDeleteSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) error {
 	return dbDelete(tx.Bucket(bucketSiacoinOutputs), id)
 }
@@ -232,6 +273,118 @@ func dbDeleteSpentOutput(tx *bolt.Tx, id types.OutputID) error {
 	return dbDelete(tx.Bucket(bucketSpentOutputs), id)
 }
 
+func dbPutFrozenOutput(tx *bolt.Tx, id types.SiacoinOutputID, height types.BlockHeight) error {
+	return dbPut(tx.Bucket(bucketFrozenOutputs), id, height)
+}
+func dbGetFrozenOutput(tx *bolt.Tx, id types.SiacoinOutputID) (height types.BlockHeight, err error) {
+	err = dbGet(tx.Bucket(bucketFrozenOutputs), id, &height)
+	return
+}
+func dbDeleteFrozenOutput(tx *bolt.Tx, id types.SiacoinOutputID) error {
+	return dbDelete(tx.Bucket(bucketFrozenOutputs), id)
+}
+func dbForEachFrozenOutput(tx *bolt.Tx, fn func(types.SiacoinOutputID, types.BlockHeight)) error {
+	return dbForEach(tx.Bucket(bucketFrozenOutputs), fn)
+}
+
+func dbPutPaymentSchedule(tx *bolt.Tx, ps modules.PaymentSchedule) error {
+	return dbPut(tx.Bucket(bucketPaymentSchedules), ps.ID, ps)
+}
+func dbGetPaymentSchedule(tx *bolt.Tx, id crypto.Hash) (ps modules.PaymentSchedule, err error) {
+	err = dbGet(tx.Bucket(bucketPaymentSchedules), id, &ps)
+	return
+}
+func dbDeletePaymentSchedule(tx *bolt.Tx, id crypto.Hash) error {
+	return dbDelete(tx.Bucket(bucketPaymentSchedules), id)
+}
+func dbForEachPaymentSchedule(tx *bolt.Tx, fn func(crypto.Hash, modules.PaymentSchedule)) error {
+	return dbForEach(tx.Bucket(bucketPaymentSchedules), fn)
+}
+
+func dbPutInvoice(tx *bolt.Tx, inv modules.Invoice) error {
+	return dbPut(tx.Bucket(bucketInvoices), inv.ID, inv)
+}
+func dbGetInvoice(tx *bolt.Tx, id crypto.Hash) (inv modules.Invoice, err error) {
+	err = dbGet(tx.Bucket(bucketInvoices), id, &inv)
+	return
+}
+func dbDeleteInvoice(tx *bolt.Tx, id crypto.Hash) error {
+	return dbDelete(tx.Bucket(bucketInvoices), id)
+}
+func dbForEachInvoice(tx *bolt.Tx, fn func(crypto.Hash, modules.Invoice)) error {
+	return dbForEach(tx.Bucket(bucketInvoices), fn)
+}
+
+func dbPutSiafundClaim(tx *bolt.Tx, sc modules.SiafundClaim) error {
+	return dbPut(tx.Bucket(bucketSiafundClaims), sc.OutputID, sc)
+}
+func dbGetSiafundClaim(tx *bolt.Tx, id types.SiafundOutputID) (sc modules.SiafundClaim, err error) {
+	err = dbGet(tx.Bucket(bucketSiafundClaims), id, &sc)
+	return
+}
+func dbDeleteSiafundClaim(tx *bolt.Tx, id types.SiafundOutputID) error {
+	return dbDelete(tx.Bucket(bucketSiafundClaims), id)
+}
+func dbForEachSiafundClaim(tx *bolt.Tx, fn func(types.SiafundOutputID, modules.SiafundClaim)) error {
+	return dbForEach(tx.Bucket(bucketSiafundClaims), fn)
+}
+
+func dbPutTransactionLabel(tx *bolt.Tx, tl modules.TransactionLabel) error {
+	return dbPut(tx.Bucket(bucketTransactionLabels), tl.TransactionID, tl)
+}
+func dbGetTransactionLabel(tx *bolt.Tx, txid types.TransactionID) (tl modules.TransactionLabel, err error) {
+	err = dbGet(tx.Bucket(bucketTransactionLabels), txid, &tl)
+	return
+}
+func dbDeleteTransactionLabel(tx *bolt.Tx, txid types.TransactionID) error {
+	return dbDelete(tx.Bucket(bucketTransactionLabels), txid)
+}
+func dbForEachTransactionLabel(tx *bolt.Tx, fn func(types.TransactionID, modules.TransactionLabel)) error {
+	return dbForEach(tx.Bucket(bucketTransactionLabels), fn)
+}
+
+func dbPutAddressLabel(tx *bolt.Tx, al modules.AddressLabel) error {
+	return dbPut(tx.Bucket(bucketAddressLabels), al.Address, al)
+}
+func dbGetAddressLabel(tx *bolt.Tx, addr types.UnlockHash) (al modules.AddressLabel, err error) {
+	err = dbGet(tx.Bucket(bucketAddressLabels), addr, &al)
+	return
+}
+func dbDeleteAddressLabel(tx *bolt.Tx, addr types.UnlockHash) error {
+	return dbDelete(tx.Bucket(bucketAddressLabels), addr)
+}
+func dbForEachAddressLabel(tx *bolt.Tx, fn func(types.UnlockHash, modules.AddressLabel)) error {
+	return dbForEach(tx.Bucket(bucketAddressLabels), fn)
+}
+
+func dbPutMultisigAccount(tx *bolt.Tx, ma modules.MultisigAccount) error {
+	return dbPut(tx.Bucket(bucketMultisigAccounts), ma.Address, ma)
+}
+func dbGetMultisigAccount(tx *bolt.Tx, addr types.UnlockHash) (ma modules.MultisigAccount, err error) {
+	err = dbGet(tx.Bucket(bucketMultisigAccounts), addr, &ma)
+	return
+}
+func dbDeleteMultisigAccount(tx *bolt.Tx, addr types.UnlockHash) error {
+	return dbDelete(tx.Bucket(bucketMultisigAccounts), addr)
+}
+func dbForEachMultisigAccount(tx *bolt.Tx, fn func(types.UnlockHash, modules.MultisigAccount)) error {
+	return dbForEach(tx.Bucket(bucketMultisigAccounts), fn)
+}
+
+func dbPutTimelockedAddress(tx *bolt.Tx, uc types.UnlockConditions) error {
+	return dbPut(tx.Bucket(bucketTimelockedAddresses), uc.UnlockHash(), uc)
+}
+func dbForEachTimelockedAddress(tx *bolt.Tx, fn func(types.UnlockHash, types.UnlockConditions)) error {
+	return dbForEach(tx.Bucket(bucketTimelockedAddresses), fn)
+}
+
+func dbPutSignerAddress(tx *bolt.Tx, sa modules.SignerAddress) error {
+	return dbPut(tx.Bucket(bucketSignerAddresses), sa.Address, sa)
+}
+func dbForEachSignerAddress(tx *bolt.Tx, fn func(types.UnlockHash, modules.SignerAddress)) error {
+	return dbForEach(tx.Bucket(bucketSignerAddresses), fn)
+}
+
 func dbPutAddrTransactions(tx *bolt.Tx, addr types.UnlockHash, txns []uint64) error {
 	return dbPut(tx.Bucket(bucketAddrTransactions), addr, txns)
 }
@@ -485,6 +638,21 @@ func dbPutSiafundPool(tx *bolt.Tx, pool types.Currency) error {
 	return tx.Bucket(bucketWallet).Put(keySiafundPool, encoding.Marshal(pool))
 }
 
+// dbGetWalletSettings returns the persisted settings of the wallet.
+func dbGetWalletSettings(tx *bolt.Tx) (settings modules.WalletSettings, err error) {
+	settingsBytes := tx.Bucket(bucketWallet).Get(keyWalletSettings)
+	if settingsBytes == nil {
+		return modules.WalletSettings{}, errNoKey
+	}
+	err = encoding.Unmarshal(settingsBytes, &settings)
+	return
+}
+
+// dbPutWalletSettings stores the settings of the wallet.
+func dbPutWalletSettings(tx *bolt.Tx, settings modules.WalletSettings) error {
+	return tx.Bucket(bucketWallet).Put(keyWalletSettings, encoding.Marshal(settings))
+}
+
 // dbPutWatchedAddresses stores the set of watched addresses.
 func dbPutWatchedAddresses(tx *bolt.Tx, addrs []types.UnlockHash) error {
 	return tx.Bucket(bucketWallet).Put(keyWatchedAddrs, encoding.Marshal(addrs))
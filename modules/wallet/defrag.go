@@ -1,24 +1,70 @@
 package wallet
 
 import (
+	"fmt"
 	"sort"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
 
 var (
 	errDefragNotNeeded = errors.New("defragging not needed, wallet is already sufficiently defragged")
+
+	// errDefragFeeTooHigh is returned if the recommended fee exceeds the
+	// maximum fee of the defrag policy.
+	errDefragFeeTooHigh = errors.New("recommended fee exceeds the maximum fee of the defrag policy")
+
+	// errDefragUneconomical is returned if the fee of a defrag exceeds the
+	// value of the outputs which would be consolidated.
+	errDefragUneconomical = errors.New("defrag fee exceeds the value of the outputs")
+
+	// errInvalidDefragBatchSize is returned if the batch size of a defrag
+	// policy is out of bounds.
+	errInvalidDefragBatchSize = fmt.Errorf("defrag batch size must be between 2 and %v", maxDefragBatchSize)
+
+	// errInvalidDefragThreshold is returned if the threshold of a defrag
+	// policy is too low for its batch size.
+	errInvalidDefragThreshold = fmt.Errorf("defrag threshold must be at least %v larger than the batch size", defragStartIndex)
 )
 
+// defragLimits returns the threshold and batch size of the policy, falling
+// back to the defaults for unset values.
+func defragLimits(p modules.DefragPolicy) (threshold, batchSize uint64) {
+	threshold, batchSize = defragThreshold, defragBatchSize
+	if p.Threshold != 0 {
+		threshold = p.Threshold
+	}
+	if p.BatchSize != 0 {
+		batchSize = p.BatchSize
+	}
+	return
+}
+
+// validateDefragPolicy returns an error if the defrag policy is invalid.
+func validateDefragPolicy(p modules.DefragPolicy) error {
+	threshold, batchSize := defragLimits(p)
+	if batchSize < 2 || batchSize > maxDefragBatchSize {
+		return errInvalidDefragBatchSize
+	}
+	if threshold < defragStartIndex+batchSize {
+		return errInvalidDefragThreshold
+	}
+	return errors.AddContext(p.ValidateWindows(), "invalid defrag window")
+}
+
 // managedCreateDefragTransaction creates a transaction that spends multiple existing
-// wallet outputs into a single new address.
-func (w *Wallet) managedCreateDefragTransaction() (_ []types.Transaction, err error) {
+// wallet outputs into a single new address. Unless 'manual' is set, the
+// transaction is only created if the defrag policy's threshold and fee limit
+// are met. If 'dryRun' is set, only the report is created.
+func (w *Wallet) managedCreateDefragTransaction(manual, dryRun bool) (_ []types.Transaction, _ modules.DefragReport, err error) {
 	// dustThreshold and minFee have to be obtained separate from the lock
 	dustThreshold, err := w.DustThreshold()
 	if err != nil {
-		return nil, err
+		return nil, modules.DefragReport{}, err
 	}
 	minFee, _ := w.tpool.FeeEstimation()
 
@@ -27,33 +73,52 @@ func (w *Wallet) managedCreateDefragTransaction() (_ []types.Transaction, err er
 
 	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
 	if err != nil {
-		return nil, err
+		return nil, modules.DefragReport{}, err
 	}
 
 	// Collect a value-sorted set of siacoin outputs.
 	var so sortedOutputs
 	err = dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if _, err := dbGetFrozenOutput(w.dbTx, scoid); err == nil {
+			return // frozen outputs are never spent
+		}
+		if _, ok := w.signerAddrs[sco.UnlockHash]; ok {
+			return // the signer isn't asked to sign defrags
+		}
 		if w.checkOutput(w.dbTx, consensusHeight, scoid, sco, dustThreshold) == nil {
 			so.ids = append(so.ids, scoid)
 			so.outputs = append(so.outputs, sco)
 		}
 	})
 	if err != nil {
-		return nil, err
+		return nil, modules.DefragReport{}, err
 	}
 	sort.Sort(sort.Reverse(so))
 
-	// Only defrag if there are enough outputs to merit defragging.
-	if len(so.ids) <= defragThreshold {
-		return nil, errDefragNotNeeded
+	// Only defrag if there are enough outputs to merit defragging and the
+	// fees are acceptable.
+	threshold, batchSize := defragLimits(w.defragPolicy)
+	if !manual && uint64(len(so.ids)) <= threshold {
+		return nil, modules.DefragReport{}, errDefragNotNeeded
+	}
+	maxFee := w.defragPolicy.MaxFeePerByte
+	if !manual && !maxFee.IsZero() && minFee.Cmp(maxFee) > 0 {
+		return nil, modules.DefragReport{}, errDefragFeeTooHigh
 	}
 
 	// Skip over the 'defragStartIndex' largest outputs, so that the user can
 	// still reasonably use their wallet while the defrag is happening.
+	end := defragStartIndex + batchSize
+	if end > uint64(len(so.ids)) {
+		end = uint64(len(so.ids))
+	}
+	if end < defragStartIndex+2 {
+		return nil, modules.DefragReport{}, errDefragNotNeeded
+	}
 	var amount types.Currency
 	var parentTxn types.Transaction
 	var spentScoids []types.SiacoinOutputID
-	for i := defragStartIndex; i < defragStartIndex+defragBatchSize; i++ {
+	for i := uint64(defragStartIndex); i < end; i++ {
 		scoid := so.ids[i]
 		sco := so.outputs[i]
 
@@ -70,11 +135,27 @@ func (w *Wallet) managedCreateDefragTransaction() (_ []types.Transaction, err er
 		amount = amount.Add(sco.Value)
 	}
 
+	// compute the transaction fee.
+	sizeAvgOutput := uint64(250)
+	fee := minFee.Mul64(sizeAvgOutput * uint64(len(spentScoids)))
+	if fee.Cmp(amount) >= 0 {
+		return nil, modules.DefragReport{}, errDefragUneconomical
+	}
+	report := modules.DefragReport{
+		Outputs:    spentScoids,
+		Amount:     amount,
+		Fee:        fee,
+		FeePerByte: minFee,
+	}
+	if dryRun {
+		return nil, report, nil
+	}
+
 	// Create and add the output that will be used to fund the defrag
 	// transaction.
 	parentUnlockConditions, err := w.nextPrimarySeedAddress(w.dbTx)
 	if err != nil {
-		return nil, err
+		return nil, modules.DefragReport{}, err
 	}
 	defer func() {
 		if err != nil {
@@ -95,7 +176,7 @@ func (w *Wallet) managedCreateDefragTransaction() (_ []types.Transaction, err er
 	// Create the defrag transaction.
 	refundAddr, err := w.nextPrimarySeedAddress(w.dbTx)
 	if err != nil {
-		return nil, err
+		return nil, modules.DefragReport{}, err
 	}
 	defer func() {
 		if err != nil {
@@ -103,10 +184,6 @@ func (w *Wallet) managedCreateDefragTransaction() (_ []types.Transaction, err er
 		}
 	}()
 
-	// compute the transaction fee.
-	sizeAvgOutput := uint64(250)
-	fee := minFee.Mul64(sizeAvgOutput * defragBatchSize)
-
 	txn := types.Transaction{
 		SiacoinInputs: []types.SiacoinInput{{
 			ParentID:         parentTxn.SiacoinOutputID(0),
@@ -123,17 +200,54 @@ func (w *Wallet) managedCreateDefragTransaction() (_ []types.Transaction, err er
 	// Mark all outputs that were spent as spent.
 	for _, scoid := range spentScoids {
 		if err = dbPutSpentOutput(w.dbTx, types.OutputID(scoid), consensusHeight); err != nil {
-			return nil, err
+			return nil, modules.DefragReport{}, err
 		}
 	}
 	// Mark the parent output as spent. Must be done after the transaction is
 	// finished because otherwise the txid and output id will change.
 	if err = dbPutSpentOutput(w.dbTx, types.OutputID(parentTxn.SiacoinOutputID(0)), consensusHeight); err != nil {
-		return nil, err
+		return nil, modules.DefragReport{}, err
 	}
 
 	// Construct the final transaction set
-	return []types.Transaction{parentTxn, txn}, nil
+	report.TransactionIDs = []types.TransactionID{parentTxn.ID(), txn.ID()}
+	return []types.Transaction{parentTxn, txn}, report, nil
+}
+
+// managedUnspendDefragOutputs marks the outputs spent by a defrag transaction
+// set as unspent again after the set couldn't be submitted.
+func (w *Wallet) managedUnspendDefragOutputs(txnSet []types.Transaction) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, txn := range txnSet {
+		for _, sci := range txn.SiacoinInputs {
+			dbDeleteSpentOutput(w.dbTx, types.OutputID(sci.ParentID))
+		}
+	}
+}
+
+// Defrag consolidates the wallet's siacoin outputs immediately, regardless of
+// the threshold, fee limit and time windows of the defrag policy. If dryRun is
+// set, the defrag is only simulated.
+func (w *Wallet) Defrag(dryRun bool) (modules.DefragReport, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.DefragReport{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if !w.managedUnlocked() {
+		return modules.DefragReport{}, modules.ErrLockedWallet
+	}
+
+	txnSet, report, err := w.managedCreateDefragTransaction(true, dryRun)
+	if err != nil || dryRun {
+		return report, err
+	}
+	if err := w.tpool.AcceptTransactionSet(txnSet); err != nil {
+		w.managedUnspendDefragOutputs(txnSet)
+		return modules.DefragReport{}, errors.AddContext(err, "defrag transaction was rejected")
+	}
+	w.log.Println("Submitted a manual defrag of", len(report.Outputs), "outputs, IDs:", report.TransactionIDs)
+	return report, nil
 }
 
 // threadedDefragWallet computes the sum of the 15 largest outputs in the wallet and
@@ -141,11 +255,12 @@ func (w *Wallet) managedCreateDefragTransaction() (_ []types.Transaction, err er
 // operation is only performed if the wallet has greater than defragThreshold
 // outputs.
 func (w *Wallet) threadedDefragWallet() {
-	// Don't defrag if it was disabled
+	// Don't defrag if it was disabled or outside of the allowed time windows.
 	w.mu.RLock()
 	disabled := w.defragDisabled
+	allowed := w.defragPolicy.AllowedAt(time.Now())
 	w.mu.RUnlock()
-	if disabled {
+	if disabled || !allowed {
 		return
 	}
 
@@ -165,20 +280,14 @@ func (w *Wallet) threadedDefragWallet() {
 	}
 
 	// Create the defrag transaction.
-	txnSet, err := w.managedCreateDefragTransaction()
+	txnSet, _, err := w.managedCreateDefragTransaction(false, false)
 	defer func() {
 		if err == nil {
 			return
 		}
-		w.mu.Lock()
-		defer w.mu.Unlock()
-		for _, txn := range txnSet {
-			for _, sci := range txn.SiacoinInputs {
-				dbDeleteSpentOutput(w.dbTx, types.OutputID(sci.ParentID))
-			}
-		}
+		w.managedUnspendDefragOutputs(txnSet)
 	}()
-	if errors.Contains(err, errDefragNotNeeded) {
+	if errors.Contains(err, errDefragNotNeeded) || errors.Contains(err, errDefragFeeTooHigh) {
 		// begin
 		return
 	} else if err != nil {
@@ -329,3 +329,133 @@ func TestDefragInterrupted(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestDefragPolicyValidation is a unit test for validateDefragPolicy.
+func TestDefragPolicyValidation(t *testing.T) {
+	tests := []struct {
+		policy modules.DefragPolicy
+		err    error
+	}{
+		{modules.DefragPolicy{}, nil},
+		{modules.DefragPolicy{BatchSize: 1}, errInvalidDefragBatchSize},
+		{modules.DefragPolicy{BatchSize: maxDefragBatchSize + 1}, errInvalidDefragBatchSize},
+		{modules.DefragPolicy{BatchSize: 60}, errInvalidDefragThreshold},
+		{modules.DefragPolicy{BatchSize: 60, Threshold: 70}, nil},
+		{modules.DefragPolicy{Threshold: defragStartIndex + defragBatchSize - 1}, errInvalidDefragThreshold},
+		{modules.DefragPolicy{Windows: []modules.DefragWindow{{Start: "22:00", End: "06:00"}}}, nil},
+	}
+	for i, test := range tests {
+		if err := validateDefragPolicy(test.policy); err != test.err {
+			t.Fatalf("%v: expected %v but got %v", i, test.err, err)
+		}
+	}
+
+	// Invalid windows are rejected.
+	invalid := [][]modules.DefragWindow{
+		{{Start: "22:00", End: "25:00"}},
+		{{Start: "10pm", End: "06:00"}},
+		{{Days: []time.Weekday{7}, Start: "22:00", End: "06:00"}},
+	}
+	for i, windows := range invalid {
+		if err := validateDefragPolicy(modules.DefragPolicy{Windows: windows}); err == nil {
+			t.Fatalf("%v: expected window to be invalid", i)
+		}
+	}
+}
+
+// TestDefragPolicy tests that the wallet respects its defrag policy and that
+// defrags can be triggered manually.
+func TestDefragPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Invalid policies are rejected.
+	err = wt.wallet.SetSettings(modules.WalletSettings{DefragPolicy: modules.DefragPolicy{BatchSize: 1}})
+	if !errors.Contains(err, errInvalidDefragBatchSize) {
+		t.Fatal("expected errInvalidDefragBatchSize but got", err)
+	}
+
+	// Set a policy with a small batch size and a fee limit that is always
+	// exceeded.
+	policy := modules.DefragPolicy{
+		Threshold:     defragStartIndex + 5,
+		BatchSize:     5,
+		MaxFeePerByte: types.NewCurrency64(1),
+	}
+	if err := wt.wallet.SetSettings(modules.WalletSettings{DefragPolicy: policy}); err != nil {
+		t.Fatal(err)
+	}
+	settings, err := wt.wallet.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.DefragPolicy.BatchSize != policy.BatchSize || settings.DefragPolicy.MaxFeePerByte.Cmp(policy.MaxFeePerByte) != 0 {
+		t.Fatal("settings weren't updated", settings)
+	}
+	wt.wallet.mu.Lock()
+	persisted, err := dbGetWalletSettings(wt.wallet.dbTx)
+	wt.wallet.mu.Unlock()
+	if err != nil || persisted.DefragPolicy.Threshold != policy.Threshold {
+		t.Fatal("settings weren't persisted", persisted, err)
+	}
+
+	// Mine enough blocks to exceed the threshold. The fee limit prevents the
+	// automatic defrag.
+	for i := 0; i < defragStartIndex+10; i++ {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := wt.wallet.managedCreateDefragTransaction(false, true); !errors.Contains(err, errDefragFeeTooHigh) {
+		t.Fatal("expected errDefragFeeTooHigh but got", err)
+	}
+
+	// A dry run reports the outputs of a manual defrag without spending them.
+	report, err := wt.wallet.Defrag(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Outputs) != int(policy.BatchSize) || len(report.TransactionIDs) != 0 || report.Fee.IsZero() {
+		t.Fatal("unexpected dry run report", report)
+	}
+	var expected types.Currency
+	uos, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range report.Outputs {
+		for _, uo := range uos {
+			if uo.ID == types.OutputID(id) {
+				expected = expected.Add(uo.Value)
+			}
+		}
+	}
+	if expected.Cmp(report.Amount) != 0 {
+		t.Fatal("reported amount doesn't match the outputs", expected, report.Amount)
+	}
+
+	// A manual defrag ignores the fee limit.
+	report, err = wt.wallet.Defrag(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Outputs) != int(policy.BatchSize) || len(report.TransactionIDs) != 2 {
+		t.Fatal("unexpected defrag report", report)
+	}
+	for _, txid := range report.TransactionIDs {
+		if _, _, exists := wt.tpool.Transaction(txid); !exists {
+			t.Fatal("defrag transaction wasn't submitted")
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package wallet
 
-import "go.sia.tech/siad/modules"
+import (
+	"sync"
+
+	"go.sia.tech/siad/modules"
+)
 
 type (
 	// dependencyAcceptTxnSetFailed is a dependency used to cause a call to
@@ -17,6 +21,15 @@ type (
 		modules.ProductionDependencies
 		f bool // indicates if the next call should fail
 	}
+
+	// dependencyPaymentScheduleInterrupted is a dependency used to skip
+	// recording the outcome of the next scheduled payment, as if the wallet
+	// stopped right after broadcasting it.
+	dependencyPaymentScheduleInterrupted struct {
+		modules.ProductionDependencies
+		f  bool // indicates if the next payment should be interrupted
+		mu sync.Mutex
+	}
 )
 
 // Disrupt will return true if fail was called and the correct string value is
@@ -50,3 +63,22 @@ func (d *dependencyDefragInterrupted) Disrupt(s string) bool {
 func (d *dependencyDefragInterrupted) fail() {
 	d.f = true
 }
+
+// Disrupt will return true if fail was called and the correct string value is
+// provided. It also resets f back to false.
+func (d *dependencyPaymentScheduleInterrupted) Disrupt(s string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.f && s == "PaymentScheduleInterrupted" {
+		d.f = false
+		return true
+	}
+	return false
+}
+
+// fail causes the next PaymentScheduleInterrupted disrupt to return true
+func (d *dependencyPaymentScheduleInterrupted) fail() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.f = true
+}
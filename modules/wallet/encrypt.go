@@ -195,6 +195,9 @@ func (w *Wallet) managedBlockingUnlock(masterKey crypto.CipherKey) (modules.Cons
 	var auxiliarySeedFiles []seedFile
 	var unseededKeyFiles []spendableKeyFile
 	var watchedAddrs []types.UnlockHash
+	var multisigAddrs []types.UnlockHash
+	var timelockedAddrs []types.UnlockConditions
+	var signerAddrs []modules.SignerAddress
 	err := func() error {
 		w.mu.Lock()
 		defer w.mu.Unlock()
@@ -237,7 +240,26 @@ func (w *Wallet) managedBlockingUnlock(masterKey crypto.CipherKey) (modules.Cons
 			return err
 		}
 
-		return nil
+		// multisigAddrs
+		err = dbForEachMultisigAccount(w.dbTx, func(addr types.UnlockHash, _ modules.MultisigAccount) {
+			multisigAddrs = append(multisigAddrs, addr)
+		})
+		if err != nil {
+			return err
+		}
+
+		// timelockedAddrs
+		err = dbForEachTimelockedAddress(w.dbTx, func(_ types.UnlockHash, uc types.UnlockConditions) {
+			timelockedAddrs = append(timelockedAddrs, uc)
+		})
+		if err != nil {
+			return err
+		}
+
+		// signerAddrs
+		return dbForEachSignerAddress(w.dbTx, func(_ types.UnlockHash, sa modules.SignerAddress) {
+			signerAddrs = append(signerAddrs, sa)
+		})
 	}()
 	if err != nil {
 		return modules.ConsensusChangeID{}, err
@@ -281,6 +303,21 @@ func (w *Wallet) managedBlockingUnlock(masterKey crypto.CipherKey) (modules.Cons
 			w.watchedAddrs[addr] = struct{}{}
 		}
 
+		// multisigAddrs
+		for _, addr := range multisigAddrs {
+			w.multisigAddrs[addr] = struct{}{}
+		}
+
+		// timelockedAddrs, their keys have to be integrated after the seeds
+		for _, uc := range timelockedAddrs {
+			w.integrateTimelockedAddress(uc)
+		}
+
+		// signerAddrs
+		for _, sa := range signerAddrs {
+			w.integrateSignerAddress(sa)
+		}
+
 		// COMPATv141 if the wallet password hasn't been encrypted yet using the seed,
 		// do it.
 		wpk := walletPasswordEncryptionKey(primarySeed, dbGetWalletSalt(w.dbTx))
@@ -452,6 +489,7 @@ func (w *Wallet) Reset() error {
 	w.wipeSecrets()
 	w.keys = make(map[types.UnlockHash]spendableKey)
 	w.lookahead = make(map[types.UnlockHash]uint64)
+	w.signerAddrs = make(map[types.UnlockHash]modules.SignerAddress)
 	w.seeds = []modules.Seed{}
 	w.unconfirmedProcessedTransactions = []modules.ProcessedTransaction{}
 	w.unlocked = false
@@ -486,7 +524,10 @@ func (w *Wallet) InitFromSeed(masterKey crypto.CipherKey, seed modules.Seed) err
 	defer w.scanLock.Unlock()
 
 	// estimate the primarySeedProgress by scanning the blockchain
-	s := newSeedScanner(seed, w.log)
+	s, err := w.managedNewSeedScanner(seed, 0)
+	if err != nil {
+		return err
+	}
 	if err := s.scan(w.cs, w.tg.StopChan()); err != nil {
 		return err
 	}
@@ -0,0 +1,314 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errInvalidInvoiceAmount is returned if an invoice doesn't request an
+	// amount.
+	errInvalidInvoiceAmount = errors.New("invoice amount must be greater than zero")
+
+	// errInvalidInvoiceCallback is returned if the callback URL of an
+	// invoice isn't an http or https URL.
+	errInvalidInvoiceCallback = errors.New("invoice callback must be an http or https URL")
+
+	// errInvalidInvoiceExpiry is returned if an invoice would expire before
+	// it is created.
+	errInvalidInvoiceExpiry = errors.New("invoice expiry must be in the future")
+)
+
+// updateInvoice recomputes the payment state of the invoice from the
+// transactions paying to its address.
+func (w *Wallet) updateInvoice(inv *modules.Invoice, height types.BlockHeight, now types.Timestamp) error {
+	// Collect the confirmed payments to the address, in the order in which
+	// they were confirmed.
+	type payment struct {
+		height types.BlockHeight
+		value  types.Currency
+	}
+	var payments []payment
+	confirmed := make(map[types.TransactionID]struct{})
+	txnIndices, err := dbGetAddrTransactions(w.dbTx, inv.Address)
+	if err != nil && !errors.Contains(err, errNoKey) {
+		return err
+	}
+	for _, i := range txnIndices {
+		pt, err := dbGetProcessedTransaction(w.dbTx, i)
+		if err != nil {
+			continue
+		}
+		confirmed[pt.TransactionID] = struct{}{}
+		for _, o := range pt.Outputs {
+			if o.FundType == types.SpecifierSiacoinOutput && o.RelatedAddress == inv.Address {
+				payments = append(payments, payment{pt.ConfirmationHeight, o.Value})
+			}
+		}
+	}
+	sort.Slice(payments, func(i, j int) bool {
+		return payments[i].height < payments[j].height
+	})
+
+	paid := false
+	inv.ConfirmedValue = types.ZeroCurrency
+	inv.PaidHeight = 0
+	inv.Confirmations = 0
+	for _, p := range payments {
+		inv.ConfirmedValue = inv.ConfirmedValue.Add(p.value)
+		if !paid && inv.ConfirmedValue.Cmp(inv.Amount) >= 0 {
+			paid = true
+			inv.PaidHeight = p.height
+		}
+	}
+	if paid && height >= inv.PaidHeight {
+		inv.Confirmations = uint64(height-inv.PaidHeight) + 1
+	}
+
+	inv.UnconfirmedValue = types.ZeroCurrency
+	for _, pt := range w.unconfirmedProcessedTransactions {
+		// The wallet might process a block before the transaction pool
+		// drops the transactions it confirmed.
+		if _, ok := confirmed[pt.TransactionID]; ok {
+			continue
+		}
+		for _, o := range pt.Outputs {
+			if o.FundType == types.SpecifierSiacoinOutput && o.RelatedAddress == inv.Address {
+				inv.UnconfirmedValue = inv.UnconfirmedValue.Add(o.Value)
+			}
+		}
+	}
+
+	received := inv.ConfirmedValue.Add(inv.UnconfirmedValue)
+	switch {
+	case paid && inv.Confirmations >= inv.RequiredConfirmations:
+		inv.Status = modules.InvoiceStatusConfirmed
+	case received.Cmp(inv.Amount) >= 0:
+		inv.Status = modules.InvoiceStatusPaid
+	case inv.Expiry != 0 && now >= inv.Expiry:
+		inv.Status = modules.InvoiceStatusExpired
+	case !received.IsZero():
+		inv.Status = modules.InvoiceStatusPartial
+	default:
+		inv.Status = modules.InvoiceStatusPending
+	}
+	return nil
+}
+
+// addInvoiceAddresses adds the wallet addresses receiving siacoins in the
+// provided transactions to addrs. Invoices always pay to a wallet address.
+func (w *Wallet) addInvoiceAddresses(addrs map[types.UnlockHash]struct{}, txns []types.Transaction) {
+	for _, txn := range txns {
+		for _, sco := range txn.SiacoinOutputs {
+			if w.isWalletAddress(sco.UnlockHash) {
+				addrs[sco.UnlockHash] = struct{}{}
+			}
+		}
+	}
+}
+
+// updateInvoices updates the payment state of the invoices which aren't
+// confirmed yet and notifies about the invoices whose status changed.
+// Confirmed invoices are no longer updated.
+//
+// Only the invoices paying to one of the touched addresses are updated. On a
+// new block, the confirmations and expiry of the other invoices are updated as
+// well, except for expired invoices which didn't receive anything since only
+// a payment can change their state. If touched is nil, all invoices which
+// aren't confirmed are updated.
+func (w *Wallet) updateInvoices(height types.BlockHeight, now types.Timestamp, touched map[types.UnlockHash]struct{}, newBlock bool) error {
+	if touched != nil && len(touched) == 0 && !newBlock {
+		return nil
+	}
+	var invoices []modules.Invoice
+	err := dbForEachInvoice(w.dbTx, func(_ crypto.Hash, inv modules.Invoice) {
+		if inv.Status == modules.InvoiceStatusConfirmed {
+			return
+		}
+		_, ok := touched[inv.Address]
+		idle := inv.Status == modules.InvoiceStatusExpired && inv.ConfirmedValue.IsZero() && inv.UnconfirmedValue.IsZero()
+		if touched == nil || ok || (newBlock && !idle) {
+			invoices = append(invoices, inv)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, inv := range invoices {
+		status := inv.Status
+		if err := w.updateInvoice(&inv, height, now); err != nil {
+			return errors.AddContext(err, "failed to update invoice")
+		}
+		if err := dbPutInvoice(w.dbTx, inv); err != nil {
+			return errors.AddContext(err, "failed to store invoice")
+		}
+		if inv.Status != status {
+			w.notifyInvoice(inv)
+		}
+	}
+	return nil
+}
+
+// notifyInvoice registers an alert about the status of the invoice and
+// notifies its callback URL.
+func (w *Wallet) notifyInvoice(inv modules.Invoice) {
+	var severity modules.AlertSeverity = modules.SeverityInfo
+	if inv.Status == modules.InvoiceStatusExpired {
+		severity = modules.SeverityWarning
+	}
+	cause := fmt.Sprintf("invoice %v for %v is %v", inv.ID, inv.Amount.HumanString(), inv.Status)
+	w.staticAlerter.RegisterAlert(modules.AlertIDWalletInvoice(inv.ID.String()), AlertMSGWalletInvoice, cause, severity)
+	w.log.Printf("Invoice %v is %v, received %v confirmed and %v unconfirmed", inv.ID, inv.Status, inv.ConfirmedValue.HumanString(), inv.UnconfirmedValue.HumanString())
+	if inv.CallbackURL != "" {
+		go w.threadedInvoiceCallback(inv)
+	}
+}
+
+// threadedInvoiceCallback POSTs the invoice to its callback URL. Failed
+// notifications are logged and not retried.
+func (w *Wallet) threadedInvoiceCallback(inv modules.Invoice) {
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+
+	err := func() error {
+		b, err := json.Marshal(inv)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(w.tg.StopCtx(), invoiceCallbackTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, inv.CallbackURL, bytes.NewReader(b))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("callback responded with status %v", resp.Status)
+		}
+		return nil
+	}()
+	if err != nil {
+		w.log.Printf("WARN: failed to notify callback of invoice %v: %v", inv.ID, err)
+	}
+}
+
+// AddInvoice creates an invoice for a payment to a new address of the wallet.
+// The invoice is assigned a random ID and its payment state is tracked from
+// then on.
+func (w *Wallet) AddInvoice(inv modules.Invoice) (modules.Invoice, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.Invoice{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	// Validate the invoice.
+	now := types.CurrentTimestamp()
+	if inv.Amount.IsZero() {
+		return modules.Invoice{}, errInvalidInvoiceAmount
+	}
+	if len(inv.Memo) > maxMemoLength {
+		return modules.Invoice{}, errMemoTooLong
+	}
+	if inv.Expiry != 0 && inv.Expiry <= now {
+		return modules.Invoice{}, errInvalidInvoiceExpiry
+	}
+	if inv.CallbackURL != "" {
+		u, err := url.Parse(inv.CallbackURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return modules.Invoice{}, errInvalidInvoiceCallback
+		}
+	}
+	if inv.RequiredConfirmations == 0 {
+		inv.RequiredConfirmations = defaultInvoiceConfirmations
+	}
+
+	uc, err := w.NextAddress()
+	if err != nil {
+		return modules.Invoice{}, errors.AddContext(err, "failed to get address for invoice")
+	}
+
+	// Reset the status fields.
+	inv = modules.Invoice{
+		Address:               uc.UnlockHash(),
+		Amount:                inv.Amount,
+		Memo:                  inv.Memo,
+		Created:               now,
+		Expiry:                inv.Expiry,
+		RequiredConfirmations: inv.RequiredConfirmations,
+		CallbackURL:           inv.CallbackURL,
+		Status:                modules.InvoiceStatusPending,
+	}
+	fastrand.Read(inv.ID[:])
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := dbPutInvoice(w.dbTx, inv); err != nil {
+		return modules.Invoice{}, errors.AddContext(err, "failed to store invoice")
+	}
+	if err := w.syncDB(); err != nil {
+		return modules.Invoice{}, err
+	}
+	w.log.Printf("Added invoice %v for %v to %v", inv.ID, inv.Amount.HumanString(), inv.Address)
+	return inv, nil
+}
+
+// Invoices returns all invoices of the wallet.
+func (w *Wallet) Invoices() ([]modules.Invoice, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var invoices []modules.Invoice
+	err := dbForEachInvoice(w.dbTx, func(_ crypto.Hash, inv modules.Invoice) {
+		invoices = append(invoices, inv)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(invoices, func(i, j int) bool {
+		return invoices[i].Created < invoices[j].Created
+	})
+	return invoices, nil
+}
+
+// RemoveInvoice stops tracking an invoice.
+func (w *Wallet) RemoveInvoice(id crypto.Hash) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := dbGetInvoice(w.dbTx, id); errors.Contains(err, errNoKey) {
+		return modules.ErrUnknownInvoice
+	} else if err != nil {
+		return err
+	}
+	if err := dbDeleteInvoice(w.dbTx, id); err != nil {
+		return err
+	}
+	w.staticAlerter.UnregisterAlert(modules.AlertIDWalletInvoice(id.String()))
+	return w.syncDB()
+}
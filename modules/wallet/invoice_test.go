@@ -0,0 +1,232 @@
+package wallet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestInvoices tests tracking the payments of an invoice.
+func TestInvoices(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Move past the hardfork which changes the replay protection of the
+	// signatures.
+	for wt.cs.Height() <= types.ASICHardforkHeight {
+		mineSyncedBlock(t, wt)
+	}
+
+	// Record the notifications of the callback.
+	notifications := make(chan modules.Invoice, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var inv modules.Invoice
+		if err := json.NewDecoder(req.Body).Decode(&inv); err != nil {
+			t.Error(err)
+		}
+		notifications <- inv
+	}))
+	defer srv.Close()
+	notified := func(status modules.InvoiceStatus) {
+		t.Helper()
+		select {
+		case inv := <-notifications:
+			if inv.Status != status {
+				t.Fatalf("expected notification about %v invoice but got %v", status, inv.Status)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("callback wasn't notified about", status)
+		}
+	}
+
+	// Invalid invoices are rejected.
+	amount := types.SiacoinPrecision.Mul64(10)
+	now := types.CurrentTimestamp()
+	invalid := []struct {
+		inv modules.Invoice
+		err error
+	}{
+		{modules.Invoice{}, errInvalidInvoiceAmount},
+		{modules.Invoice{Amount: amount, Expiry: now - 1}, errInvalidInvoiceExpiry},
+		{modules.Invoice{Amount: amount, CallbackURL: "ftp://localhost"}, errInvalidInvoiceCallback},
+		{modules.Invoice{Amount: amount, CallbackURL: "localhost"}, errInvalidInvoiceCallback},
+	}
+	for i, test := range invalid {
+		if _, err := wt.wallet.AddInvoice(test.inv); !errors.Contains(err, test.err) {
+			t.Fatalf("%v: expected %v but got %v", i, test.err, err)
+		}
+	}
+
+	inv, err := wt.wallet.AddInvoice(modules.Invoice{
+		Amount:                amount,
+		Memo:                  "order 42",
+		RequiredConfirmations: 2,
+		CallbackURL:           srv.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.Status != modules.InvoiceStatusPending || inv.Address == (types.UnlockHash{}) {
+		t.Fatal("wrong invoice", inv)
+	}
+	invoice := func() modules.Invoice {
+		t.Helper()
+		invs, err := wt.wallet.Invoices()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(invs) != 1 || invs[0].ID != inv.ID {
+			t.Fatal("expected the invoice but got", invs)
+		}
+		return invs[0]
+	}
+
+	// A partial payment is tracked as soon as it is in the transaction pool.
+	if _, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(4), inv.Address); err != nil {
+		t.Fatal(err)
+	}
+	notified(modules.InvoiceStatusPartial)
+	if inv := invoice(); !inv.UnconfirmedValue.Equals(types.SiacoinPrecision.Mul64(4)) {
+		t.Fatal("wrong unconfirmed value", inv.UnconfirmedValue)
+	}
+
+	// Dropping the payment from the transaction pool reverts the invoice once
+	// the wallet processed the next block, which could have confirmed it.
+	// Purging the transaction pool doesn't notify the subscribers, which are
+	// notified about the dropped set along with the next transaction.
+	wt.tpool.PurgeTransactionPool()
+	if _, err := wt.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{}); err != nil {
+		t.Fatal(err)
+	}
+	if inv := invoice(); inv.Status != modules.InvoiceStatusPartial {
+		t.Fatal("invoice was updated before the next block", inv)
+	}
+	mineSyncedBlock(t, wt)
+	notified(modules.InvoiceStatusPending)
+	if inv := invoice(); !inv.UnconfirmedValue.IsZero() || !inv.ConfirmedValue.IsZero() {
+		t.Fatal("dropped payment wasn't reverted", inv)
+	}
+
+	// Payments in a diff which also drops transactions are tracked right
+	// away.
+	if _, err := wt.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{}); err != nil {
+		t.Fatal(err)
+	}
+	wt.tpool.PurgeTransactionPool()
+	if _, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(4), inv.Address); err != nil {
+		t.Fatal(err)
+	}
+	notified(modules.InvoiceStatusPartial)
+	mineSyncedBlock(t, wt)
+
+	// Paying the remainder pays the invoice, which is confirmed after the
+	// required number of blocks.
+	if _, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(6), inv.Address); err != nil {
+		t.Fatal(err)
+	}
+	notified(modules.InvoiceStatusPaid)
+	mineSyncedBlock(t, wt)
+	if inv := invoice(); inv.Status != modules.InvoiceStatusPaid || inv.Confirmations != 1 || inv.PaidHeight != wt.cs.Height() || !inv.ConfirmedValue.Equals(amount) {
+		t.Fatal("wrong invoice after one confirmation", inv)
+	}
+	mineSyncedBlock(t, wt)
+	notified(modules.InvoiceStatusConfirmed)
+	if inv := invoice(); inv.Status != modules.InvoiceStatusConfirmed || inv.Confirmations != 2 {
+		t.Fatal("invoice wasn't confirmed", inv)
+	}
+	_, _, _, info := wt.wallet.Alerts()
+	var found bool
+	for _, a := range info {
+		found = found || a.Module == "wallet" && a.Msg == AlertMSGWalletInvoice
+	}
+	if !found {
+		t.Fatal("no alert was registered for the invoice")
+	}
+
+	// Unpaid invoices expire.
+	expiring, err := wt.wallet.AddInvoice(modules.Invoice{Amount: amount, Expiry: now + 3600})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.wallet.mu.Lock()
+	err = wt.wallet.updateInvoices(wt.cs.Height(), now+3600, nil, true)
+	wt.wallet.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, warns, _ := wt.wallet.Alerts()
+	if len(warns) != 1 || warns[0].Cause == "" {
+		t.Fatal("expected a warning about the expired invoice", warns)
+	}
+
+	// Expired invoices which didn't receive anything are only updated if
+	// their address is touched.
+	update := func(touched map[types.UnlockHash]struct{}) modules.Invoice {
+		t.Helper()
+		wt.wallet.mu.Lock()
+		defer wt.wallet.mu.Unlock()
+		stored, err := dbGetInvoice(wt.wallet.dbTx, expiring.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored.Confirmations = 1
+		if err := dbPutInvoice(wt.wallet.dbTx, stored); err != nil {
+			t.Fatal(err)
+		}
+		if err := wt.wallet.updateInvoices(wt.cs.Height(), now+3600, touched, true); err != nil {
+			t.Fatal(err)
+		}
+		stored, err = dbGetInvoice(wt.wallet.dbTx, expiring.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stored
+	}
+	if stored := update(map[types.UnlockHash]struct{}{}); stored.Confirmations != 1 {
+		t.Fatal("idle expired invoice shouldn't be updated", stored)
+	}
+	if stored := update(map[types.UnlockHash]struct{}{expiring.Address: {}}); stored.Confirmations != 0 || stored.Status != modules.InvoiceStatusExpired {
+		t.Fatal("touched expired invoice should be updated", stored)
+	}
+
+	// Removing the invoices removes their alerts.
+	for _, id := range []crypto.Hash{inv.ID, expiring.ID} {
+		if err := wt.wallet.RemoveInvoice(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := wt.wallet.RemoveInvoice(inv.ID); !errors.Contains(err, modules.ErrUnknownInvoice) {
+		t.Fatal("expected ErrUnknownInvoice but got", err)
+	}
+	if invs, err := wt.wallet.Invoices(); err != nil || len(invs) != 0 {
+		t.Fatal("invoices weren't removed", invs, err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		_, _, warns, info := wt.wallet.Alerts()
+		if len(info)+len(warns) != 0 {
+			return errors.New("alerts weren't unregistered")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
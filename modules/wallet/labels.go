@@ -0,0 +1,159 @@
+package wallet
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errLabelTooLong is returned if a label exceeds maxLabelLength.
+	errLabelTooLong = fmt.Errorf("label must not be longer than %v bytes", maxLabelLength)
+
+	// errMemoTooLong is returned if a memo exceeds maxMemoLength.
+	errMemoTooLong = fmt.Errorf("memo must not be longer than %v bytes", maxMemoLength)
+)
+
+// checkLabel returns an error if the label or memo are too long.
+func checkLabel(label, memo string) error {
+	if len(label) > maxLabelLength {
+		return errLabelTooLong
+	}
+	if len(memo) > maxMemoLength {
+		return errMemoTooLong
+	}
+	return nil
+}
+
+// dbLabelTransactions attaches the labels of the transactions and of their
+// related addresses to the processed transactions.
+func dbLabelTransactions(tx *bolt.Tx, pts []modules.ProcessedTransaction) {
+	for i := range pts {
+		pt := &pts[i]
+		if tl, err := dbGetTransactionLabel(tx, pt.TransactionID); err == nil {
+			pt.Label, pt.Memo = tl.Label, tl.Memo
+		}
+		pt.AddressLabels = nil
+		seen := make(map[types.UnlockHash]struct{})
+		addLabel := func(addr types.UnlockHash) {
+			if _, exists := seen[addr]; exists {
+				return
+			}
+			seen[addr] = struct{}{}
+			if al, err := dbGetAddressLabel(tx, addr); err == nil {
+				pt.AddressLabels = append(pt.AddressLabels, al)
+			}
+		}
+		for _, input := range pt.Inputs {
+			addLabel(input.RelatedAddress)
+		}
+		for _, output := range pt.Outputs {
+			addLabel(output.RelatedAddress)
+		}
+	}
+}
+
+// SetTransactionLabel sets the label and memo of a transaction known to the
+// wallet. An empty label and memo remove the label.
+func (w *Wallet) SetTransactionLabel(txid types.TransactionID, label, memo string) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if err := checkLabel(label, memo); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if label == "" && memo == "" {
+		if err := dbDeleteTransactionLabel(w.dbTx, txid); err != nil {
+			return errors.AddContext(err, "failed to remove transaction label")
+		}
+		return w.syncDB()
+	}
+
+	// Only transactions that are known to the wallet can be labeled.
+	_, err := dbGetTransactionIndex(w.dbTx, txid)
+	known := err == nil
+	for _, pt := range w.unconfirmedProcessedTransactions {
+		known = known || pt.TransactionID == txid
+	}
+	if !known {
+		return errors.AddContext(modules.ErrUnknownTransaction, txid.String())
+	}
+	tl := modules.TransactionLabel{
+		TransactionID: txid,
+		Label:         label,
+		Memo:          memo,
+	}
+	if err := dbPutTransactionLabel(w.dbTx, tl); err != nil {
+		return errors.AddContext(err, "failed to store transaction label")
+	}
+	return w.syncDB()
+}
+
+// SetAddressLabel sets the label and memo of an address. The address doesn't
+// need to belong to the wallet. An empty label and memo remove the label.
+func (w *Wallet) SetAddressLabel(addr types.UnlockHash, label, memo string) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if err := checkLabel(label, memo); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if label == "" && memo == "" {
+		if err := dbDeleteAddressLabel(w.dbTx, addr); err != nil {
+			return errors.AddContext(err, "failed to remove address label")
+		}
+		return w.syncDB()
+	}
+	al := modules.AddressLabel{
+		Address: addr,
+		Label:   label,
+		Memo:    memo,
+	}
+	if err := dbPutAddressLabel(w.dbTx, al); err != nil {
+		return errors.AddContext(err, "failed to store address label")
+	}
+	return w.syncDB()
+}
+
+// TransactionLabels returns the labels of all labeled transactions.
+func (w *Wallet) TransactionLabels() ([]modules.TransactionLabel, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var labels []modules.TransactionLabel
+	err := dbForEachTransactionLabel(w.dbTx, func(_ types.TransactionID, tl modules.TransactionLabel) {
+		labels = append(labels, tl)
+	})
+	return labels, err
+}
+
+// AddressLabels returns the labels of all labeled addresses.
+func (w *Wallet) AddressLabels() ([]modules.AddressLabel, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var labels []modules.AddressLabel
+	err := dbForEachAddressLabel(w.dbTx, func(_ types.UnlockHash, al modules.AddressLabel) {
+		labels = append(labels, al)
+	})
+	return labels, err
+}
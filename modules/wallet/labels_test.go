@@ -0,0 +1,161 @@
+package wallet
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestProcessedTransactionLabelEncoding checks that the labels of a
+// ProcessedTransaction are not part of its encoding.
+func TestProcessedTransactionLabelEncoding(t *testing.T) {
+	pt := modules.ProcessedTransaction{
+		TransactionID:      types.TransactionID{1},
+		ConfirmationHeight: 2,
+		Outputs:            []modules.ProcessedOutput{{Value: types.NewCurrency64(3)}},
+	}
+	labeled := pt
+	labeled.Label = "label"
+	labeled.Memo = "memo"
+	labeled.AddressLabels = []modules.AddressLabel{{Label: "address"}}
+	if !bytes.Equal(encoding.Marshal(pt), encoding.Marshal(labeled)) {
+		t.Fatal("labels are part of the encoding")
+	}
+	var decoded modules.ProcessedTransaction
+	if err := encoding.Unmarshal(encoding.Marshal(labeled), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.TransactionID != pt.TransactionID || decoded.ConfirmationHeight != pt.ConfirmationHeight || len(decoded.Outputs) != 1 || decoded.Label != "" {
+		t.Fatal("decoded transaction doesn't match", decoded)
+	}
+}
+
+// TestLabels tests labeling transactions and addresses.
+func TestLabels(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Send coins to an external address to create an unconfirmed transaction.
+	dest := types.UnlockHash{1}
+	txns, err := wt.wallet.SendSiacoins(types.SiacoinPrecision, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txns[len(txns)-1].ID()
+
+	// Unknown transactions can't be labeled and labels must not be too long.
+	err = wt.wallet.SetTransactionLabel(types.TransactionID{1}, "label", "")
+	if !errors.Contains(err, modules.ErrUnknownTransaction) {
+		t.Fatal("expected ErrUnknownTransaction but got", err)
+	}
+	err = wt.wallet.SetTransactionLabel(txid, strings.Repeat("a", maxLabelLength+1), "")
+	if !errors.Contains(err, errLabelTooLong) {
+		t.Fatal("expected errLabelTooLong but got", err)
+	}
+	err = wt.wallet.SetAddressLabel(dest, "", strings.Repeat("a", maxMemoLength+1))
+	if !errors.Contains(err, errMemoTooLong) {
+		t.Fatal("expected errMemoTooLong but got", err)
+	}
+
+	// Label the transaction and the destination.
+	if err := wt.wallet.SetTransactionLabel(txid, "rent", "october"); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.SetAddressLabel(dest, "landlord", ""); err != nil {
+		t.Fatal(err)
+	}
+	checkLabels := func(pt modules.ProcessedTransaction) {
+		t.Helper()
+		if pt.Label != "rent" || pt.Memo != "october" {
+			t.Fatal("wrong transaction label", pt.Label, pt.Memo)
+		}
+		if len(pt.AddressLabels) != 1 || pt.AddressLabels[0].Address != dest || pt.AddressLabels[0].Label != "landlord" {
+			t.Fatal("wrong address labels", pt.AddressLabels)
+		}
+	}
+	find := func(pts []modules.ProcessedTransaction) modules.ProcessedTransaction {
+		t.Helper()
+		for _, pt := range pts {
+			if pt.TransactionID == txid {
+				return pt
+			}
+		}
+		t.Fatal("transaction not found")
+		return modules.ProcessedTransaction{}
+	}
+	upts, err := wt.wallet.UnconfirmedTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLabels(find(upts))
+	wt.wallet.mu.Lock()
+	for _, upt := range wt.wallet.unconfirmedProcessedTransactions {
+		if upt.Label != "" {
+			t.Fatal("labels were attached to the wallet's unconfirmed transactions")
+		}
+	}
+	wt.wallet.mu.Unlock()
+
+	// The labels are attached to the confirmed transaction as well.
+	b, _ := wt.miner.FindBlock()
+	if err := wt.cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	pt, found, err := wt.wallet.Transaction(txid)
+	if err != nil || !found {
+		t.Fatal("transaction not found", err)
+	}
+	checkLabels(pt)
+	height, err := wt.wallet.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts, err := wt.wallet.Transactions(height, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLabels(find(pts))
+	pts, err = wt.wallet.AddressTransactions(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLabels(find(pts))
+
+	// List and remove the labels.
+	tls, err := wt.wallet.TransactionLabels()
+	if err != nil || len(tls) != 1 || tls[0].TransactionID != txid {
+		t.Fatal("wrong transaction labels", tls, err)
+	}
+	als, err := wt.wallet.AddressLabels()
+	if err != nil || len(als) != 1 || als[0].Address != dest {
+		t.Fatal("wrong address labels", als, err)
+	}
+	if err := wt.wallet.SetTransactionLabel(txid, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.SetAddressLabel(dest, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	pt, _, err = wt.wallet.Transaction(txid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt.Label != "" || pt.Memo != "" || len(pt.AddressLabels) != 0 {
+		t.Fatal("labels weren't removed", pt)
+	}
+}
@@ -112,21 +112,21 @@ func (w *Wallet) UnconfirmedBalance() (outgoingSiacoins types.Currency, incoming
 // transaction is submitted to the transaction pool and is also returned. Fees
 // are added to the amount sent.
 func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error) {
-	if err := w.tg.Add(); err != nil {
-		err = modules.ErrWalletShutdown
-		return nil, err
-	}
-	defer w.tg.Done()
-
-	_, fee := w.tpool.FeeEstimation()
-	fee = fee.Mul64(estimatedTransactionSize)
-	return w.managedSendSiacoins(amount, fee, dest)
+	return w.SendSiacoinsCoinControl(amount, dest, false, modules.CoinControl{})
 }
 
 // SendSiacoinsFeeIncluded creates a transaction sending 'amount' to 'dest'. The
 // transaction is submitted to the transaction pool and is also returned. Fees
 // are subtracted from the amount sent.
 func (w *Wallet) SendSiacoinsFeeIncluded(amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error) {
+	return w.SendSiacoinsCoinControl(amount, dest, true, modules.CoinControl{})
+}
+
+// SendSiacoinsCoinControl creates a transaction sending 'amount' to 'dest'
+// which is funded according to the provided CoinControl. The transaction is
+// submitted to the transaction pool and is also returned. If feeIncluded is
+// set, fees are subtracted from the amount sent, otherwise they are added.
+func (w *Wallet) SendSiacoinsCoinControl(amount types.Currency, dest types.UnlockHash, feeIncluded bool, cc modules.CoinControl) ([]types.Transaction, error) {
 	if err := w.tg.Add(); err != nil {
 		err = modules.ErrWalletShutdown
 		return nil, err
@@ -135,18 +135,23 @@ func (w *Wallet) SendSiacoinsFeeIncluded(amount types.Currency, dest types.Unloc
 
 	_, fee := w.tpool.FeeEstimation()
 	fee = fee.Mul64(estimatedTransactionSize)
+	if !feeIncluded {
+		return w.managedSendSiacoins(amount, fee, dest, cc, nil)
+	}
 	// Don't allow sending an amount equal to the fee, as zero spending is not
 	// allowed and would error out later.
 	if amount.Cmp(fee) <= 0 {
 		w.log.Println("Attempt to send coins has failed - not enough to cover fee")
 		return nil, errors.AddContext(modules.ErrLowBalance, "not enough coins to cover fee")
 	}
-	return w.managedSendSiacoins(amount.Sub(fee), fee, dest)
+	return w.managedSendSiacoins(amount.Sub(fee), fee, dest, cc, nil)
 }
 
 // managedSendSiacoins creates a transaction sending 'amount' to 'dest'. The
-// transaction is submitted to the transaction pool and is also returned.
-func (w *Wallet) managedSendSiacoins(amount, fee types.Currency, dest types.UnlockHash) (txns []types.Transaction, err error) {
+// transaction is submitted to the transaction pool and is also returned. If
+// beforeBroadcast is provided, it is called with the signed transaction set
+// before it is submitted and aborts the payment if it returns an error.
+func (w *Wallet) managedSendSiacoins(amount, fee types.Currency, dest types.UnlockHash, cc modules.CoinControl, beforeBroadcast func([]types.Transaction) error) (txns []types.Transaction, err error) {
 	// Check if consensus is synced
 	if !w.cs.Synced() || w.deps.Disrupt("UnsyncedConsensus") {
 		return nil, errors.New("cannot send siacoin until fully synced")
@@ -174,7 +179,7 @@ func (w *Wallet) managedSendSiacoins(amount, fee types.Currency, dest types.Unlo
 			txnBuilder.Drop()
 		}
 	}()
-	err = txnBuilder.FundSiacoins(amount.Add(fee))
+	err = txnBuilder.FundSiacoinsCoinControl(amount.Add(fee), cc)
 	if err != nil {
 		w.log.Println("Attempt to send coins has failed - failed to fund transaction:", err)
 		return nil, build.ExtendErr("unable to fund transaction", err)
@@ -186,6 +191,11 @@ func (w *Wallet) managedSendSiacoins(amount, fee types.Currency, dest types.Unlo
 		w.log.Println("Attempt to send coins has failed - failed to sign transaction:", err)
 		return nil, build.ExtendErr("unable to sign transaction", err)
 	}
+	if beforeBroadcast != nil {
+		if err = beforeBroadcast(txnSet); err != nil {
+			return nil, err
+		}
+	}
 	if w.deps.Disrupt("SendSiacoinsInterrupted") {
 		return nil, errors.New("failed to accept transaction set (SendSiacoinsInterrupted)")
 	}
@@ -204,7 +214,14 @@ func (w *Wallet) managedSendSiacoins(amount, fee types.Currency, dest types.Unlo
 // SendSiacoinsMulti creates a transaction that includes the specified
 // outputs. The transaction is submitted to the transaction pool and is also
 // returned.
-func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.Transaction, err error) {
+func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) ([]types.Transaction, error) {
+	return w.SendSiacoinsMultiCoinControl(outputs, modules.CoinControl{})
+}
+
+// SendSiacoinsMultiCoinControl creates a transaction that includes the
+// specified outputs and is funded according to the provided CoinControl. The
+// transaction is submitted to the transaction pool and is also returned.
+func (w *Wallet) SendSiacoinsMultiCoinControl(outputs []types.SiacoinOutput, cc modules.CoinControl) (txns []types.Transaction, err error) {
 	if err := w.tg.Add(); err != nil {
 		err = modules.ErrWalletShutdown
 		return nil, err
@@ -250,7 +267,7 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 	for _, sco := range outputs {
 		totalCost = totalCost.Add(sco.Value)
 	}
-	err = txnBuilder.FundSiacoins(totalCost)
+	err = txnBuilder.FundSiacoinsCoinControl(totalCost, cc)
 	if err != nil {
 		return nil, build.ExtendErr("unable to fund transaction", err)
 	}
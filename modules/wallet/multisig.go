@@ -0,0 +1,460 @@
+package wallet
+
+import (
+	"bytes"
+	"sort"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errInvalidMultisigConditions is returned if the UnlockConditions of a
+	// multisig account don't describe an M-of-N multisig address.
+	errInvalidMultisigConditions = errors.New("multisig accounts require at least 2 public keys and between 1 and N required signatures")
+
+	// errMultisigAccountExists is returned when registering a multisig
+	// account twice.
+	errMultisigAccountExists = errors.New("multisig account already exists")
+
+	// errNoLocalMultisigKeys is returned if none of the public keys of a
+	// multisig account belong to the wallet.
+	errNoLocalMultisigKeys = errors.New("none of the multisig account's public keys belong to the wallet")
+
+	// errNoMultisigInputs is returned when signing a transaction which doesn't
+	// spend from any of the wallet's multisig accounts.
+	errNoMultisigInputs = errors.New("transaction doesn't spend from any multisig account of the wallet")
+
+	// errNoMultisigOutputs is returned when building a spend without any
+	// outputs.
+	errNoMultisigOutputs = errors.New("multisig spend requires at least one output")
+
+	// errUnknownMultisigKey is returned if a local key of a multisig account
+	// is not part of its UnlockConditions or doesn't belong to the wallet.
+	errUnknownMultisigKey = errors.New("local key is not a public key of the account or doesn't belong to the wallet")
+)
+
+// seedSecretKey returns the secret key of the wallet's key with the provided
+// public key. Only keys with a single public key and no timelock are
+// considered.
+func (w *Wallet) seedSecretKey(pk types.SiaPublicKey) (crypto.SecretKey, bool) {
+	uc := types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{pk},
+		SignaturesRequired: 1,
+	}
+	sk, ok := w.keys[uc.UnlockHash()]
+	if !ok || len(sk.SecretKeys) != 1 {
+		return crypto.SecretKey{}, false
+	}
+	return sk.SecretKeys[0], true
+}
+
+// containsKey returns whether pk is one of the provided keys.
+func containsKey(keys []types.SiaPublicKey, pk types.SiaPublicKey) bool {
+	for _, key := range keys {
+		if key.Algorithm == pk.Algorithm && bytes.Equal(key.Key, pk.Key) {
+			return true
+		}
+	}
+	return false
+}
+
+// inputSignatures returns the number of signatures for the input with the
+// provided parent id.
+func inputSignatures(txn types.Transaction, parentID crypto.Hash) (n uint64) {
+	for _, sig := range txn.TransactionSignatures {
+		if sig.ParentID == parentID {
+			n++
+		}
+	}
+	return
+}
+
+// multisigComplete returns whether all inputs of the transaction have as
+// many signatures as their UnlockConditions require.
+func multisigComplete(txn types.Transaction) bool {
+	for _, sci := range txn.SiacoinInputs {
+		if inputSignatures(txn, crypto.Hash(sci.ParentID)) < sci.UnlockConditions.SignaturesRequired {
+			return false
+		}
+	}
+	for _, sfi := range txn.SiafundInputs {
+		if inputSignatures(txn, crypto.Hash(sfi.ParentID)) < sfi.UnlockConditions.SignaturesRequired {
+			return false
+		}
+	}
+	return true
+}
+
+// estimatedMultisigSize estimates the size of the transaction once it is
+// fully signed and has a change output and a miner fee.
+func estimatedMultisigSize(txn types.Transaction, uc types.UnlockConditions) uint64 {
+	est := txn
+	est.SiacoinOutputs = append(append([]types.SiacoinOutput(nil), txn.SiacoinOutputs...), types.SiacoinOutput{
+		Value:      types.SiacoinPrecision.Mul64(1e9),
+		UnlockHash: uc.UnlockHash(),
+	})
+	est.MinerFees = []types.Currency{types.SiacoinPrecision}
+	est.TransactionSignatures = nil
+	for _, sci := range txn.SiacoinInputs {
+		for i := uint64(0); i < uc.SignaturesRequired; i++ {
+			est.TransactionSignatures = append(est.TransactionSignatures, types.TransactionSignature{
+				ParentID:       crypto.Hash(sci.ParentID),
+				PublicKeyIndex: i,
+				CoveredFields:  types.FullCoveredFields,
+				Signature:      make([]byte, crypto.SignatureSize),
+			})
+		}
+	}
+	return uint64(len(encoding.Marshal(est)))
+}
+
+// signMultisigInputs adds the signatures of the local keys to the inputs of
+// txn which spend from the wallet's multisig accounts. Inputs which already
+// have enough signatures are skipped to avoid frivolous signatures.
+func (w *Wallet) signMultisigInputs(txn *types.Transaction, height types.BlockHeight) error {
+	type input struct {
+		parentID crypto.Hash
+		uc       types.UnlockConditions
+	}
+	var inputs []input
+	for _, sci := range txn.SiacoinInputs {
+		inputs = append(inputs, input{crypto.Hash(sci.ParentID), sci.UnlockConditions})
+	}
+	for _, sfi := range txn.SiafundInputs {
+		inputs = append(inputs, input{crypto.Hash(sfi.ParentID), sfi.UnlockConditions})
+	}
+
+	var found bool
+	for _, in := range inputs {
+		addr := in.uc.UnlockHash()
+		if _, ok := w.multisigAddrs[addr]; !ok {
+			continue
+		}
+		ma, err := dbGetMultisigAccount(w.dbTx, addr)
+		if err != nil {
+			return errors.AddContext(err, "failed to fetch multisig account")
+		}
+		found = true
+
+	keys:
+		for i, pk := range in.uc.PublicKeys {
+			if inputSignatures(*txn, in.parentID) >= in.uc.SignaturesRequired {
+				break
+			}
+			if !containsKey(ma.LocalKeys, pk) {
+				continue
+			}
+			for _, sig := range txn.TransactionSignatures {
+				if sig.ParentID == in.parentID && sig.PublicKeyIndex == uint64(i) {
+					continue keys // already signed
+				}
+			}
+			sk, ok := w.seedSecretKey(pk)
+			if !ok {
+				return errUnknownMultisigKey
+			}
+			txn.TransactionSignatures = append(txn.TransactionSignatures, types.TransactionSignature{
+				ParentID:       in.parentID,
+				PublicKeyIndex: uint64(i),
+				CoveredFields:  types.FullCoveredFields,
+			})
+			sigIndex := len(txn.TransactionSignatures) - 1
+			sigHash := txn.SigHash(sigIndex, height)
+			encodedSig := crypto.SignHash(sigHash, sk)
+			txn.TransactionSignatures[sigIndex].Signature = encodedSig[:]
+		}
+	}
+	if !found {
+		return errNoMultisigInputs
+	}
+	return nil
+}
+
+// AddMultisigAccount registers an M-of-N multisig address with the wallet.
+// localKeys are the public keys of the wallet's seeds which participate in
+// the account, if none are provided they are detected automatically. If the
+// address hasn't appeared in the blockchain yet, the unused flag may be set
+// to true. Otherwise, the wallet must rescan the blockchain to find the
+// account's outputs.
+func (w *Wallet) AddMultisigAccount(name string, uc types.UnlockConditions, localKeys []types.SiaPublicKey, unused bool) (modules.MultisigAccount, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.MultisigAccount{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	if len(uc.PublicKeys) < 2 || uc.SignaturesRequired == 0 || uc.SignaturesRequired > uint64(len(uc.PublicKeys)) {
+		return modules.MultisigAccount{}, errInvalidMultisigConditions
+	}
+	if err := checkLabel(name, ""); err != nil {
+		return modules.MultisigAccount{}, err
+	}
+	ma := modules.MultisigAccount{
+		Name:             name,
+		Address:          uc.UnlockHash(),
+		UnlockConditions: uc,
+	}
+
+	err := func() error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return modules.ErrLockedWallet
+		}
+		if _, exists := w.multisigAddrs[ma.Address]; exists {
+			return errMultisigAccountExists
+		}
+
+		// Check the local keys or detect them if none were provided.
+		if len(localKeys) == 0 {
+			for _, pk := range uc.PublicKeys {
+				if _, ok := w.seedSecretKey(pk); ok {
+					ma.LocalKeys = append(ma.LocalKeys, pk)
+				}
+			}
+		}
+		for _, lk := range localKeys {
+			_, local := w.seedSecretKey(lk)
+			if !local || !containsKey(uc.PublicKeys, lk) {
+				return errors.AddContext(errUnknownMultisigKey, lk.String())
+			}
+			ma.LocalKeys = append(ma.LocalKeys, lk)
+		}
+		if len(ma.LocalKeys) == 0 {
+			return errNoLocalMultisigKeys
+		}
+
+		if err := dbPutMultisigAccount(w.dbTx, ma); err != nil {
+			return errors.AddContext(err, "failed to store multisig account")
+		}
+		if err := dbPutUnlockConditions(w.dbTx, uc); err != nil {
+			return errors.AddContext(err, "failed to store unlock conditions")
+		}
+		w.multisigAddrs[ma.Address] = struct{}{}
+		if !unused {
+			// prepare to rescan
+			if err := w.resetTransactionHistory(modules.ConsensusChangeBeginning, 0); err != nil {
+				return err
+			}
+		}
+		return w.syncDB()
+	}()
+	if err != nil {
+		return modules.MultisigAccount{}, err
+	}
+	w.log.Printf("Added %v-of-%v multisig account %v", uc.SignaturesRequired, len(uc.PublicKeys), ma.Address)
+
+	if !unused {
+		return ma, w.managedRescan(modules.ConsensusChangeBeginning)
+	}
+	return ma, nil
+}
+
+// MultisigAccounts returns the multisig accounts of the wallet together with
+// their confirmed balances.
+func (w *Wallet) MultisigAccounts() ([]modules.MultisigAccount, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var accounts []modules.MultisigAccount
+	indices := make(map[types.UnlockHash]int)
+	err := dbForEachMultisigAccount(w.dbTx, func(addr types.UnlockHash, ma modules.MultisigAccount) {
+		indices[addr] = len(accounts)
+		accounts = append(accounts, ma)
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if i, ok := indices[sco.UnlockHash]; ok {
+			accounts[i].ConfirmedSiacoinBalance = accounts[i].ConfirmedSiacoinBalance.Add(sco.Value)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = dbForEachSiafundOutput(w.dbTx, func(_ types.SiafundOutputID, sfo types.SiafundOutput) {
+		if i, ok := indices[sfo.UnlockHash]; ok {
+			accounts[i].ConfirmedSiafundBalance = accounts[i].ConfirmedSiafundBalance.Add(sfo.Value)
+		}
+	})
+	return accounts, err
+}
+
+// RemoveMultisigAccount stops tracking a multisig account. If the account's
+// address has appeared in the blockchain, unused must be false to rebuild the
+// wallet's transaction history without it.
+func (w *Wallet) RemoveMultisigAccount(addr types.UnlockHash, unused bool) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	err := func() error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return modules.ErrLockedWallet
+		}
+		if _, exists := w.multisigAddrs[addr]; !exists {
+			return modules.ErrUnknownMultisigAccount
+		}
+		if err := dbDeleteMultisigAccount(w.dbTx, addr); err != nil {
+			return err
+		}
+		delete(w.multisigAddrs, addr)
+		if !unused {
+			if err := w.deleteUntrackedOutputs(); err != nil {
+				return err
+			}
+			// prepare to rescan
+			if err := w.resetTransactionHistory(modules.ConsensusChangeBeginning, 0); err != nil {
+				return err
+			}
+		}
+		return w.syncDB()
+	}()
+	if err != nil {
+		return err
+	}
+	w.log.Println("Removed multisig account", addr)
+
+	if !unused {
+		return w.managedRescan(modules.ConsensusChangeBeginning)
+	}
+	return nil
+}
+
+// MultisigSpend builds a transaction which sends the outputs from a multisig
+// account and returns the change to the account. The largest outputs of the
+// account are spent first. The transaction is signed with the local keys of
+// the account but not broadcast, complete reports whether it has enough
+// signatures to be broadcast.
+func (w *Wallet) MultisigSpend(addr types.UnlockHash, outputs []types.SiacoinOutput) (_ types.Transaction, complete bool, err error) {
+	if err := w.tg.Add(); err != nil {
+		return types.Transaction{}, false, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if len(outputs) == 0 {
+		return types.Transaction{}, false, errNoMultisigOutputs
+	}
+
+	// dustThreshold and the fee have to be obtained separate from the lock
+	dustThreshold, err := w.DustThreshold()
+	if err != nil {
+		return types.Transaction{}, false, err
+	}
+	_, feePerByte := w.tpool.FeeEstimation()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return types.Transaction{}, false, modules.ErrLockedWallet
+	}
+	ma, err := dbGetMultisigAccount(w.dbTx, addr)
+	if errors.Contains(err, errNoKey) {
+		return types.Transaction{}, false, modules.ErrUnknownMultisigAccount
+	} else if err != nil {
+		return types.Transaction{}, false, err
+	}
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return types.Transaction{}, false, err
+	}
+	if height < ma.UnlockConditions.Timelock {
+		return types.Transaction{}, false, errOutputTimelock
+	}
+
+	// Collect the outputs of the account which aren't spent by pending
+	// transactions yet, largest first.
+	pending := make(map[types.OutputID]struct{})
+	for _, pt := range w.unconfirmedProcessedTransactions {
+		for _, input := range pt.Inputs {
+			pending[input.ParentID] = struct{}{}
+		}
+	}
+	var so sortedOutputs
+	err = dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if _, spent := pending[types.OutputID(scoid)]; spent || sco.UnlockHash != addr {
+			return
+		}
+		if _, err := dbGetFrozenOutput(w.dbTx, scoid); err == nil {
+			return // frozen outputs are never spent
+		}
+		if sco.Value.Cmp(dustThreshold) >= 0 {
+			so.ids = append(so.ids, scoid)
+			so.outputs = append(so.outputs, sco)
+		}
+	})
+	if err != nil {
+		return types.Transaction{}, false, err
+	}
+	sort.Sort(sort.Reverse(so))
+
+	// Add inputs until they cover the outputs and the fee.
+	var amount types.Currency
+	for _, sco := range outputs {
+		amount = amount.Add(sco.Value)
+	}
+	txn := types.Transaction{
+		SiacoinOutputs: append([]types.SiacoinOutput(nil), outputs...),
+	}
+	var fund, fee types.Currency
+	for i := range so.ids {
+		txn.SiacoinInputs = append(txn.SiacoinInputs, types.SiacoinInput{
+			ParentID:         so.ids[i],
+			UnlockConditions: ma.UnlockConditions,
+		})
+		fund = fund.Add(so.outputs[i].Value)
+		fee = feePerByte.Mul64(estimatedMultisigSize(txn, ma.UnlockConditions))
+		if fund.Cmp(amount.Add(fee)) >= 0 {
+			break
+		}
+	}
+	if fund.Cmp(amount.Add(fee)) < 0 {
+		return types.Transaction{}, false, modules.ErrLowBalance
+	}
+	txn.MinerFees = []types.Currency{fee}
+	if change := fund.Sub(amount).Sub(fee); !change.IsZero() {
+		txn.SiacoinOutputs = append(txn.SiacoinOutputs, types.SiacoinOutput{
+			Value:      change,
+			UnlockHash: addr,
+		})
+	}
+
+	if err := w.signMultisigInputs(&txn, height); err != nil {
+		return types.Transaction{}, false, errors.AddContext(err, "failed to sign multisig spend")
+	}
+	return txn, multisigComplete(txn), nil
+}
+
+// MultisigSign adds the signatures of the local keys to the inputs of txn
+// which spend from the wallet's multisig accounts. complete reports whether
+// all inputs of the transaction have enough signatures.
+func (w *Wallet) MultisigSign(txn *types.Transaction) (complete bool, err error) {
+	if err := w.tg.Add(); err != nil {
+		return false, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return false, modules.ErrLockedWallet
+	}
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return false, err
+	}
+	if err := w.signMultisigInputs(txn, height); err != nil {
+		return false, err
+	}
+	return multisigComplete(*txn), nil
+}
@@ -0,0 +1,216 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestMultisigAccount tests registering a multisig account, tracking its
+// balance and spending from it together with a cosigner.
+func TestMultisigAccount(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	account := func(addr types.UnlockHash) modules.MultisigAccount {
+		accounts, err := wt.wallet.MultisigAccounts()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ma := range accounts {
+			if ma.Address == addr {
+				return ma
+			}
+		}
+		t.Fatal("multisig account not found")
+		return modules.MultisigAccount{}
+	}
+
+	// Move past the hardfork which changes the replay protection of the
+	// signatures.
+	for wt.cs.Height() <= types.ASICHardforkHeight {
+		mineSyncedBlock(t, wt)
+	}
+
+	// Create a 2-of-3 account with two keys of the wallet and a key of a
+	// cosigner.
+	uc1, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	uc2, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cosignerSK, cosignerPK := crypto.GenerateKeyPair()
+	uc := types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{uc1.PublicKeys[0], types.Ed25519PublicKey(cosignerPK), uc2.PublicKeys[0]},
+		SignaturesRequired: 2,
+	}
+
+	// Invalid accounts are rejected.
+	invalid := []struct {
+		uc        types.UnlockConditions
+		localKeys []types.SiaPublicKey
+		err       error
+	}{
+		{types.UnlockConditions{PublicKeys: uc.PublicKeys[:1], SignaturesRequired: 1}, nil, errInvalidMultisigConditions},
+		{types.UnlockConditions{PublicKeys: uc.PublicKeys, SignaturesRequired: 4}, nil, errInvalidMultisigConditions},
+		{uc, []types.SiaPublicKey{types.Ed25519PublicKey(cosignerPK)}, errUnknownMultisigKey},
+		{types.UnlockConditions{PublicKeys: []types.SiaPublicKey{types.Ed25519PublicKey(cosignerPK), {}}, SignaturesRequired: 1}, nil, errNoLocalMultisigKeys},
+	}
+	for i, test := range invalid {
+		if _, err := wt.wallet.AddMultisigAccount("", test.uc, test.localKeys, true); !errors.Contains(err, test.err) {
+			t.Fatalf("%v: expected %v but got %v", i, test.err, err)
+		}
+	}
+
+	// Register the account with only the first key as local key, so that the
+	// wallet can only add one of the two signatures.
+	ma, err := wt.wallet.AddMultisigAccount("shared", uc, []types.SiaPublicKey{uc1.PublicKeys[0]}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ma.Address != uc.UnlockHash() || len(ma.LocalKeys) != 1 {
+		t.Fatal("wrong account", ma)
+	}
+	if _, err := wt.wallet.AddMultisigAccount("shared", uc, nil, true); !errors.Contains(err, errMultisigAccountExists) {
+		t.Fatal("expected errMultisigAccountExists but got", err)
+	}
+
+	// Fund the account.
+	funding := types.SiacoinPrecision.Mul64(100)
+	if _, err := wt.wallet.SendSiacoins(funding, ma.Address); err != nil {
+		t.Fatal(err)
+	}
+	mineSyncedBlock(t, wt)
+	if balance := account(ma.Address).ConfirmedSiacoinBalance; !balance.Equals(funding) {
+		t.Fatalf("expected balance %v but got %v", funding, balance)
+	}
+
+	// The wallet must not use the account's outputs to fund its own
+	// transactions.
+	uos, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, uo := range uos {
+		if uo.UnlockHash == ma.Address && !uo.IsWatchOnly {
+			t.Fatal("multisig output should be reported as watch-only")
+		}
+	}
+
+	// Spend from the account. The wallet signs with its local key only.
+	amount := types.SiacoinPrecision.Mul64(10)
+	dest := types.UnlockHash{1}
+	txn, complete, err := wt.wallet.MultisigSpend(ma.Address, []types.SiacoinOutput{{Value: amount, UnlockHash: dest}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if complete || len(txn.TransactionSignatures) != 1 {
+		t.Fatal("spend should only be signed by the local key", len(txn.TransactionSignatures))
+	}
+	if err := wt.tpool.AcceptTransactionSet([]types.Transaction{txn}); err == nil {
+		t.Fatal("partially signed transaction was accepted")
+	}
+
+	// Signing again doesn't add another signature.
+	if complete, err := wt.wallet.MultisigSign(&txn); err != nil || complete || len(txn.TransactionSignatures) != 1 {
+		t.Fatal("signature was duplicated", complete, err)
+	}
+
+	// The cosigner adds the second signature.
+	height := wt.cs.Height()
+	txn.TransactionSignatures = append(txn.TransactionSignatures, types.TransactionSignature{
+		ParentID:       crypto.Hash(txn.SiacoinInputs[0].ParentID),
+		PublicKeyIndex: 1,
+		CoveredFields:  types.FullCoveredFields,
+	})
+	sig := crypto.SignHash(txn.SigHash(len(txn.TransactionSignatures)-1, height), cosignerSK)
+	txn.TransactionSignatures[len(txn.TransactionSignatures)-1].Signature = sig[:]
+	if !multisigComplete(txn) {
+		t.Fatal("transaction should be complete")
+	}
+	if err := wt.tpool.AcceptTransactionSet([]types.Transaction{txn}); err != nil {
+		t.Fatal(err)
+	}
+	mineSyncedBlock(t, wt)
+
+	// The account keeps the change.
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		expected := funding.Sub(amount).Sub(txn.MinerFees[0])
+		if balance := account(ma.Address).ConfirmedSiacoinBalance; !balance.Equals(expected) {
+			return errors.New("wrong balance after spend")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Transactions that don't spend from an account can't be signed.
+	if _, err := wt.wallet.MultisigSign(&types.Transaction{}); !errors.Contains(err, errNoMultisigInputs) {
+		t.Fatal("expected errNoMultisigInputs but got", err)
+	}
+
+	// Remove the account.
+	if err := wt.wallet.RemoveMultisigAccount(ma.Address, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.RemoveMultisigAccount(ma.Address, true); !errors.Contains(err, modules.ErrUnknownMultisigAccount) {
+		t.Fatal("expected ErrUnknownMultisigAccount but got", err)
+	}
+	if accounts, err := wt.wallet.MultisigAccounts(); err != nil || len(accounts) != 0 {
+		t.Fatal("account wasn't removed", accounts, err)
+	}
+}
+
+// mineSyncedBlock mines a block on top of the current block and waits for the
+// wallet and the transaction pool to process it. The wallet signs
+// transactions for its own height and the transaction pool would treat the
+// mined transactions as unconfirmed parents until it is synced.
+func mineSyncedBlock(t *testing.T, wt *walletTester) {
+	t.Helper()
+	var b types.Block
+	err := build.Retry(50, 100*time.Millisecond, func() (err error) {
+		b, err = wt.miner.FindBlock()
+		if err != nil {
+			return err
+		}
+		if b.ParentID != wt.cs.CurrentBlock().ID() {
+			return errors.New("miner hasn't caught up with the consensus set")
+		}
+		return wt.cs.AcceptBlock(b)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if height, _ := wt.wallet.Height(); height != wt.cs.Height() {
+			return errors.New("wallet isn't synced")
+		}
+		for _, txn := range b.Transactions {
+			if _, _, exists := wt.tpool.Transaction(txn.ID()); exists {
+				return errors.New("transaction pool isn't synced")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -97,10 +97,16 @@ outer:
 		}
 	}
 
-	// mark the watch-only outputs
+	// mark the watch-only and frozen outputs, multisig outputs are reported
+	// as watch-only since the wallet can't spend them on its own
 	for i, o := range outputs {
-		_, ok := w.watchedAddrs[o.UnlockHash]
-		outputs[i].IsWatchOnly = ok
+		_, watched := w.watchedAddrs[o.UnlockHash]
+		_, multisig := w.multisigAddrs[o.UnlockHash]
+		outputs[i].IsWatchOnly = watched || multisig
+		if o.FundType == types.SpecifierSiacoinOutput {
+			_, err := dbGetFrozenOutput(w.dbTx, types.SiacoinOutputID(o.ID))
+			outputs[i].Frozen = err == nil
+		}
 	}
 
 	return outputs, nil
@@ -149,21 +155,35 @@ func (w *Wallet) AddUnlockConditions(uc types.UnlockConditions) error {
 // SignTransaction signs txn using secret keys known to the wallet. The
 // transaction should be complete with the exception of the Signature fields
 // of each TransactionSignature referenced by toSign. For convenience, if
-// toSign is empty, SignTransaction signs everything that it can.
+// toSign is empty, SignTransaction signs everything that it can. Inputs of
+// signer addresses are signed by the external signer.
 func (w *Wallet) SignTransaction(txn *types.Transaction, toSign []crypto.Hash) error {
 	if err := w.tg.Add(); err != nil {
 		return err
 	}
 	defer w.tg.Done()
 
+	// The external signer is only asked for its signatures after the
+	// wallet's lock was released.
+	reqs, consensusHeight, err := w.managedSignTransaction(txn, toSign)
+	if err != nil {
+		return err
+	}
+	return w.managedSignerSign(txn, consensusHeight, reqs)
+}
+
+// managedSignTransaction signs the inputs of txn referenced by toSign using the
+// wallet's secret keys and returns the requests for the signatures of the
+// external signer.
+func (w *Wallet) managedSignTransaction(txn *types.Transaction, toSign []crypto.Hash) ([]modules.SignatureRequest, types.BlockHeight, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	if !w.unlocked {
-		return modules.ErrLockedWallet
+		return nil, 0, modules.ErrLockedWallet
 	}
 	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
 	// if toSign is empty, sign all inputs that we have keys for
@@ -179,7 +199,36 @@ func (w *Wallet) SignTransaction(txn *types.Transaction, toSign []crypto.Hash) e
 			}
 		}
 	}
-	return signTransaction(txn, w.keys, toSign, consensusHeight)
+
+	// inputs of signer addresses are signed by the external signer
+	var local []crypto.Hash
+	var signerSigs []int
+	for _, id := range toSign {
+		uc, ok := findUnlockConditions(*txn, id)
+		if _, signer := w.signerAddrs[uc.UnlockHash()]; !ok || !signer {
+			local = append(local, id)
+			continue
+		}
+		sigIndex := -1
+		for i, sig := range txn.TransactionSignatures {
+			if sig.ParentID == id {
+				sigIndex = i
+				break
+			}
+		}
+		if sigIndex == -1 {
+			return nil, 0, errors.New("toSign references signatures not present in transaction")
+		}
+		signerSigs = append(signerSigs, sigIndex)
+	}
+	if err := signTransaction(txn, w.keys, local, consensusHeight); err != nil {
+		return nil, 0, err
+	}
+	if len(signerSigs) == 0 {
+		return nil, consensusHeight, nil
+	}
+	reqs, err := w.signerRequests(*txn, signerSigs, consensusHeight)
+	return reqs, consensusHeight, err
 }
 
 // SignTransaction signs txn using secret keys derived from seed. The
@@ -212,24 +261,25 @@ func SignTransaction(txn *types.Transaction, seed modules.Seed, toSign []crypto.
 	return signTransaction(txn, keys, toSign, height)
 }
 
-// signTransaction signs the specified inputs of txn using the specified keys.
-// It returns an error if any of the specified inputs cannot be signed.
-func signTransaction(txn *types.Transaction, keys map[types.UnlockHash]spendableKey, toSign []crypto.Hash, height types.BlockHeight) error {
-	// helper function to lookup unlock conditions in the txn associated with
-	// a transaction signature's ParentID
-	findUnlockConditions := func(id crypto.Hash) (types.UnlockConditions, bool) {
-		for _, sci := range txn.SiacoinInputs {
-			if crypto.Hash(sci.ParentID) == id {
-				return sci.UnlockConditions, true
-			}
+// findUnlockConditions returns the unlock conditions of the input of txn
+// which is associated with a transaction signature's ParentID.
+func findUnlockConditions(txn types.Transaction, id crypto.Hash) (types.UnlockConditions, bool) {
+	for _, sci := range txn.SiacoinInputs {
+		if crypto.Hash(sci.ParentID) == id {
+			return sci.UnlockConditions, true
 		}
-		for _, sfi := range txn.SiafundInputs {
-			if crypto.Hash(sfi.ParentID) == id {
-				return sfi.UnlockConditions, true
-			}
+	}
+	for _, sfi := range txn.SiafundInputs {
+		if crypto.Hash(sfi.ParentID) == id {
+			return sfi.UnlockConditions, true
 		}
-		return types.UnlockConditions{}, false
 	}
+	return types.UnlockConditions{}, false
+}
+
+// signTransaction signs the specified inputs of txn using the specified keys.
+// It returns an error if any of the specified inputs cannot be signed.
+func signTransaction(txn *types.Transaction, keys map[types.UnlockHash]spendableKey, toSign []crypto.Hash, height types.BlockHeight) error {
 	// helper function to lookup the secret key that can sign
 	findSigningKey := func(uc types.UnlockConditions, pubkeyIndex uint64) (crypto.SecretKey, bool) {
 		if pubkeyIndex >= uint64(len(uc.PublicKeys)) {
@@ -262,7 +312,7 @@ func signTransaction(txn *types.Transaction, keys map[types.UnlockHash]spendable
 			return errors.New("toSign references signatures not present in transaction")
 		}
 		// find associated input
-		uc, ok := findUnlockConditions(id)
+		uc, ok := findUnlockConditions(*txn, id)
 		if !ok {
 			return errors.New("toSign references IDs not present in transaction")
 		}
@@ -285,6 +335,74 @@ func signTransaction(txn *types.Transaction, keys map[types.UnlockHash]spendable
 	return nil
 }
 
+// resetTransactionHistory deletes the wallet's processed transactions
+// confirmed at or above startHeight and resets its consensus progress to the
+// consensus change start to prepare a rescan of the blockchain from there.
+func (w *Wallet) resetTransactionHistory(start modules.ConsensusChangeID, startHeight types.BlockHeight) error {
+	if startHeight == 0 {
+		if err := w.dbTx.DeleteBucket(bucketProcessedTransactions); err != nil {
+			return err
+		}
+		if _, err := w.dbTx.CreateBucket(bucketProcessedTransactions); err != nil {
+			return err
+		}
+	} else {
+		// Transactions are stored in the order they were confirmed, so the
+		// ones to delete are at the end.
+		for {
+			pt, err := dbGetLastProcessedTransaction(w.dbTx)
+			if err != nil || pt.ConfirmationHeight < startHeight {
+				break // bucket is empty or done
+			}
+			if err := dbDeleteLastProcessedTransaction(w.dbTx); err != nil {
+				return err
+			}
+		}
+	}
+	w.unconfirmedProcessedTransactions = nil
+	if err := dbPutConsensusChangeID(w.dbTx, start); err != nil {
+		return err
+	}
+	var height types.BlockHeight
+	if startHeight > 0 {
+		height = startHeight - 1
+	}
+	return dbPutConsensusHeight(w.dbTx, height)
+}
+
+// deleteUntrackedOutputs removes the siacoin outputs of addresses which are
+// no longer tracked by the wallet.
+func (w *Wallet) deleteUntrackedOutputs() error {
+	var outputIDs []types.SiacoinOutputID
+	dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if !w.isWalletAddress(sco.UnlockHash) {
+			outputIDs = append(outputIDs, scoid)
+		}
+	})
+	for _, scoid := range outputIDs {
+		if err := dbDeleteSiacoinOutput(w.dbTx, scoid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// managedRescan resubscribes the wallet to the consensus set and transaction
+// pool, rescanning the blockchain from the consensus change start.
+func (w *Wallet) managedRescan(start modules.ConsensusChangeID) error {
+	w.cs.Unsubscribe(w)
+	w.tpool.Unsubscribe(w)
+
+	done := make(chan struct{})
+	go w.rescanMessage(done)
+	defer close(done)
+	if err := w.cs.ConsensusSetSubscribe(w, start, w.tg.StopChan()); err != nil {
+		return err
+	}
+	w.tpool.TransactionPoolSubscribe(w)
+	return nil
+}
+
 // AddWatchAddresses instructs the wallet to begin tracking a set of
 // addresses, in addition to the addresses it was previously tracking. If none
 // of the addresses have appeared in the blockchain, the unused flag may be
@@ -318,17 +436,7 @@ func (w *Wallet) AddWatchAddresses(addrs []types.UnlockHash, unused bool) error
 
 		if !unused {
 			// prepare to rescan
-			if err := w.dbTx.DeleteBucket(bucketProcessedTransactions); err != nil {
-				return err
-			}
-			if _, err := w.dbTx.CreateBucket(bucketProcessedTransactions); err != nil {
-				return err
-			}
-			w.unconfirmedProcessedTransactions = nil
-			if err := dbPutConsensusChangeID(w.dbTx, modules.ConsensusChangeBeginning); err != nil {
-				return err
-			}
-			if err := dbPutConsensusHeight(w.dbTx, 0); err != nil {
+			if err := w.resetTransactionHistory(modules.ConsensusChangeBeginning, 0); err != nil {
 				return err
 			}
 		}
@@ -339,19 +447,8 @@ func (w *Wallet) AddWatchAddresses(addrs []types.UnlockHash, unused bool) error
 	}
 
 	if !unused {
-		// rescan the blockchain
-		w.cs.Unsubscribe(w)
-		w.tpool.Unsubscribe(w)
-
-		done := make(chan struct{})
-		go w.rescanMessage(done)
-		defer close(done)
-		if err := w.cs.ConsensusSetSubscribe(w, modules.ConsensusChangeBeginning, w.tg.StopChan()); err != nil {
-			return err
-		}
-		w.tpool.TransactionPoolSubscribe(w)
+		return w.managedRescan(modules.ConsensusChangeBeginning)
 	}
-
 	return nil
 }
 
@@ -389,30 +486,12 @@ func (w *Wallet) RemoveWatchAddresses(addrs []types.UnlockHash, unused bool) err
 			// outputs associated with the addresses may be present in the
 			// SiacoinOutputs bucket. Iterate through the bucket and remove
 			// any outputs that we are no longer watching.
-			var outputIDs []types.SiacoinOutputID
-			dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
-				if !w.isWalletAddress(sco.UnlockHash) {
-					outputIDs = append(outputIDs, scoid)
-				}
-			})
-			for _, scoid := range outputIDs {
-				if err := dbDeleteSiacoinOutput(w.dbTx, scoid); err != nil {
-					return err
-				}
+			if err := w.deleteUntrackedOutputs(); err != nil {
+				return err
 			}
 
 			// prepare to rescan
-			if err := w.dbTx.DeleteBucket(bucketProcessedTransactions); err != nil {
-				return err
-			}
-			if _, err := w.dbTx.CreateBucket(bucketProcessedTransactions); err != nil {
-				return err
-			}
-			w.unconfirmedProcessedTransactions = nil
-			if err := dbPutConsensusChangeID(w.dbTx, modules.ConsensusChangeBeginning); err != nil {
-				return err
-			}
-			if err := dbPutConsensusHeight(w.dbTx, 0); err != nil {
+			if err := w.resetTransactionHistory(modules.ConsensusChangeBeginning, 0); err != nil {
 				return err
 			}
 		}
@@ -423,19 +502,8 @@ func (w *Wallet) RemoveWatchAddresses(addrs []types.UnlockHash, unused bool) err
 	}
 
 	if !unused {
-		// rescan the blockchain
-		w.cs.Unsubscribe(w)
-		w.tpool.Unsubscribe(w)
-
-		done := make(chan struct{})
-		go w.rescanMessage(done)
-		defer close(done)
-		if err := w.cs.ConsensusSetSubscribe(w, modules.ConsensusChangeBeginning, w.tg.StopChan()); err != nil {
-			return err
-		}
-		w.tpool.TransactionPoolSubscribe(w)
+		return w.managedRescan(modules.ConsensusChangeBeginning)
 	}
-
 	return nil
 }
 
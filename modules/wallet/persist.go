@@ -153,6 +153,16 @@ func (w *Wallet) initPersist() error {
 		}
 	}
 
+	// load the settings of the wallet
+	settings, err := dbGetWalletSettings(w.dbTx)
+	if err == nil {
+		w.defragDisabled = settings.NoDefrag
+		w.defragPolicy = settings.DefragPolicy
+		w.addressGap = settings.AddressGap
+	} else if !errors.Contains(err, errNoKey) {
+		return errors.AddContext(err, "failed to load wallet settings")
+	}
+
 	// ensure that the final db transaction is committed when the wallet closes
 	err = w.tg.AfterStop(func() error {
 		w.mu.Lock()
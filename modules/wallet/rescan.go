@@ -0,0 +1,134 @@
+package wallet
+
+import (
+	"fmt"
+	"sort"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errInvalidAddressGap is returned if the address gap of the wallet
+	// settings exceeds maxAddressGap.
+	errInvalidAddressGap = fmt.Errorf("address gap must not be larger than %v", maxAddressGap)
+)
+
+// lookaheadGap returns the number of unused addresses of the primary seed
+// that the wallet watches.
+func (w *Wallet) lookaheadGap() uint64 {
+	if w.addressGap == 0 {
+		return defaultAddressGap
+	}
+	return w.addressGap
+}
+
+// resizeLookahead regenerates the lookahead of an unlocked wallet after its
+// address gap changed.
+func (w *Wallet) resizeLookahead() error {
+	if !w.unlocked {
+		return nil
+	}
+	progress, err := dbGetPrimarySeedProgress(w.dbTx)
+	if err != nil {
+		return err
+	}
+	w.lookahead = make(map[types.UnlockHash]uint64)
+	w.regenerateLookahead(progress)
+	return nil
+}
+
+// settings returns the wallet's current settings.
+func (w *Wallet) settings() modules.WalletSettings {
+	return modules.WalletSettings{
+		NoDefrag:     w.defragDisabled,
+		DefragPolicy: w.defragPolicy,
+		AddressGap:   w.addressGap,
+	}
+}
+
+// scanStart returns the consensus change from which the wallet scans the
+// blockchain to skip the blocks before startHeight, and the height of the
+// first block it will receive.
+func (w *Wallet) scanStart(startHeight types.BlockHeight) (modules.ConsensusChangeID, types.BlockHeight, error) {
+	// The consensus set may not have reached the start height yet, in which
+	// case there is nothing to skip past the current block.
+	if current := w.cs.Height(); startHeight > current {
+		startHeight = current
+	}
+	return w.cs.ConsensusChangeAtHeight(startHeight)
+}
+
+// managedNewSeedScanner returns a seedScanner for the seed which honors the
+// address gap of the wallet and skips the blocks before startHeight.
+func (w *Wallet) managedNewSeedScanner(seed modules.Seed, startHeight types.BlockHeight) (*seedScanner, error) {
+	start, _, err := w.scanStart(startHeight)
+	if err != nil {
+		return nil, err
+	}
+	s := newSeedScanner(seed, w.log)
+	s.start = start
+	w.mu.RLock()
+	s.addressGap = w.lookaheadGap()
+	w.mu.RUnlock()
+	return s, nil
+}
+
+// Rescan deletes the wallet's transaction history starting at startHeight and
+// rescans the blockchain from there.
+func (w *Wallet) Rescan(startHeight types.BlockHeight) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	if !w.scanLock.TryLock() {
+		return errScanInProgress
+	}
+	defer w.scanLock.Unlock()
+
+	start, startHeight, err := w.scanStart(startHeight)
+	if err != nil {
+		return err
+	}
+	err = func() error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return modules.ErrLockedWallet
+		}
+		// prepare to rescan
+		if err := w.resetTransactionHistory(start, startHeight); err != nil {
+			return err
+		}
+		return w.syncDB()
+	}()
+	if err != nil {
+		return err
+	}
+	return w.managedRescan(start)
+}
+
+// BirthHeight returns the height of the first block mined less than a day
+// before the birth date. Block timestamps may deviate from the time the
+// blocks were actually mined, so the day serves as a safety margin.
+func (w *Wallet) BirthHeight(birthDate types.Timestamp) (types.BlockHeight, error) {
+	if err := w.tg.Add(); err != nil {
+		return 0, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	if birthDate < birthDateMargin {
+		return 0, nil
+	}
+	birthDate -= birthDateMargin
+	height := w.cs.Height()
+	i := sort.Search(int(height)+1, func(i int) bool {
+		b, exists := w.cs.BlockAtHeight(types.BlockHeight(i))
+		return !exists || b.Timestamp >= birthDate
+	})
+	if types.BlockHeight(i) > height {
+		return height, nil
+	}
+	return types.BlockHeight(i), nil
+}
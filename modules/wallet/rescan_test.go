@@ -0,0 +1,243 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestRescanFromHeight tests that a rescan only recreates the transaction
+// history starting at the provided height and that the height only applies to
+// that rescan.
+func TestRescanFromHeight(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Move past the hardfork which changes the replay protection of the
+	// signatures.
+	for wt.cs.Height() <= types.ASICHardforkHeight {
+		mineSyncedBlock(t, wt)
+	}
+
+	// Send coins to an address the wallet doesn't know about.
+	keys := []spendableKey{generateSpendableKey(modules.Seed{1}, 0), generateSpendableKey(modules.Seed{1}, 1)}
+	addrs := []types.UnlockHash{keys[0].UnlockConditions.UnlockHash(), keys[1].UnlockConditions.UnlockHash()}
+	txns, err := wt.wallet.SendSiacoins(types.SiacoinPrecision, addrs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendTxn := txns[len(txns)-1]
+	mineSyncedBlock(t, wt)
+
+	// Forward them to another unknown address in a transaction which isn't
+	// part of the wallet's history and mine another block on top.
+	fee := types.SiacoinPrecision.Div64(10)
+	forwardTxn := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{{
+			Value:      types.SiacoinPrecision.Sub(fee),
+			UnlockHash: addrs[1],
+		}},
+		MinerFees: []types.Currency{fee},
+	}
+	for i, sco := range sendTxn.SiacoinOutputs {
+		if sco.UnlockHash == addrs[0] {
+			forwardTxn.SiacoinInputs = append(forwardTxn.SiacoinInputs, types.SiacoinInput{
+				ParentID:         sendTxn.SiacoinOutputID(uint64(i)),
+				UnlockConditions: keys[0].UnlockConditions,
+			})
+		}
+	}
+	addSignatures(&forwardTxn, types.FullCoveredFields, keys[0].UnlockConditions, crypto.Hash(forwardTxn.SiacoinInputs[0].ParentID), keys[0], wt.cs.Height())
+	if err := wt.tpool.AcceptTransactionSet([]types.Transaction{forwardTxn}); err != nil {
+		t.Fatal(err)
+	}
+	mineSyncedBlock(t, wt)
+	forwardHeight := wt.cs.Height()
+	mineSyncedBlock(t, wt)
+
+	// Watch the address without rescanning.
+	if err := wt.wallet.AddWatchAddresses(addrs[1:], true); err != nil {
+		t.Fatal(err)
+	}
+	history, err := wt.wallet.Transactions(0, wt.cs.Height())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rescan rescans the blockchain and returns the number of times the
+	// transactions appear in the wallet's history.
+	rescan := func(height types.BlockHeight) (sends, forwards int) {
+		if err := wt.wallet.Rescan(height); err != nil {
+			t.Fatal(err)
+		}
+		if walletHeight, err := wt.wallet.Height(); err != nil || walletHeight != wt.cs.Height() {
+			t.Fatal("wallet didn't rescan to the current height", walletHeight, err)
+		}
+		pts, err := wt.wallet.Transactions(0, wt.cs.Height())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pt := range pts {
+			switch pt.TransactionID {
+			case sendTxn.ID():
+				sends++
+			case forwardTxn.ID():
+				forwards++
+			}
+		}
+		return sends, forwards
+	}
+
+	// The forwarding transaction is only found when rescanning from its
+	// height. Other rescans aren't affected by the start height of a previous
+	// one.
+	if sends, forwards := rescan(forwardHeight + 1); sends != 1 || forwards != 0 {
+		t.Fatal("unexpected history after rescanning past the transaction", sends, forwards)
+	}
+	if err := wt.wallet.RemoveWatchAddresses([]types.UnlockHash{{1}}, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, found, err := wt.wallet.Transaction(forwardTxn.ID()); err != nil || !found {
+		t.Fatal("transaction wasn't found by a full rescan", err)
+	}
+
+	// The history before the start height is kept without duplicating the
+	// transactions at the start height.
+	if sends, forwards := rescan(forwardHeight); sends != 1 || forwards != 1 {
+		t.Fatal("unexpected history after rescanning from the transaction", sends, forwards)
+	}
+	if sends, forwards := rescan(forwardHeight + 1); sends != 1 || forwards != 1 {
+		t.Fatal("unexpected history after rescanning past the transaction", sends, forwards)
+	}
+	pts, err := wt.wallet.Transactions(0, wt.cs.Height())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pts) != len(history)+1 {
+		t.Fatalf("expected %v transactions but got %v", len(history)+1, len(pts))
+	}
+}
+
+// TestAddressGap tests that the wallet finds funds sent to addresses beyond
+// the default lookahead once the address gap is increased.
+func TestAddressGap(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for wt.cs.Height() <= types.ASICHardforkHeight {
+		mineSyncedBlock(t, wt)
+	}
+
+	// Gaps above the maximum are rejected.
+	settings, err := wt.wallet.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.AddressGap = maxAddressGap + 1
+	if err := wt.wallet.SetSettings(settings); !errors.Contains(err, errInvalidAddressGap) {
+		t.Fatal("expected errInvalidAddressGap but got", err)
+	}
+
+	// Increasing the gap resizes the lookahead.
+	gap := 10 * defaultAddressGap
+	settings.AddressGap = gap
+	if err := wt.wallet.SetSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	seed, _, err := wt.wallet.PrimarySeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seedProgress := func() uint64 {
+		wt.wallet.mu.RLock()
+		defer wt.wallet.mu.RUnlock()
+		progress, err := dbGetPrimarySeedProgress(wt.wallet.dbTx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return progress
+	}
+	progress := seedProgress()
+	wt.wallet.mu.RLock()
+	lookahead := uint64(len(wt.wallet.lookahead))
+	wt.wallet.mu.RUnlock()
+	if lookahead != maxLookahead(progress, gap) {
+		t.Fatalf("expected %v lookahead keys but got %v", maxLookahead(progress, gap), lookahead)
+	}
+
+	// Send coins to the first address past the default lookahead.
+	index := progress + maxLookahead(progress, defaultAddressGap)
+	addr := generateSpendableKey(seed, index).UnlockConditions.UnlockHash()
+	if _, err := wt.wallet.SendSiacoins(types.SiacoinPrecision, addr); err != nil {
+		t.Fatal(err)
+	}
+	mineSyncedBlock(t, wt)
+
+	if progress := seedProgress(); progress != index+1 || !wt.wallet.managedCanSpendUnlockHash(addr) {
+		t.Fatal("address within the address gap wasn't found", progress, index)
+	}
+}
+
+// TestBirthHeight tests converting birth dates to scan heights.
+func TestBirthHeight(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	current := wt.cs.CurrentBlock().Timestamp
+	tests := []types.Timestamp{0, types.GenesisTimestamp, current, current + birthDateMargin, current + 2*birthDateMargin}
+	for _, birthDate := range tests {
+		height, err := wt.wallet.BirthHeight(birthDate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if height > wt.cs.Height() {
+			t.Fatalf("%v: birth height %v is above the current height", birthDate, height)
+		}
+		// All blocks before the birth height are at least a day older than
+		// the birth date.
+		for h := types.BlockHeight(0); h < height; h++ {
+			if b, _ := wt.cs.BlockAtHeight(h); b.Timestamp+birthDateMargin >= birthDate {
+				t.Fatalf("%v: block %v is less than a day older than the birth date", birthDate, h)
+			}
+		}
+		// Unless the birth date is more than a day in the future, the block
+		// at the birth height isn't.
+		if birthDate <= current+birthDateMargin {
+			if b, _ := wt.cs.BlockAtHeight(height); b.Timestamp+birthDateMargin < birthDate {
+				t.Fatalf("%v: birth height %v is too high", birthDate, height)
+			}
+		}
+	}
+}
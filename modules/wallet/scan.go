@@ -54,6 +54,7 @@ type scannedOutput struct {
 // A seedScanner scans the blockchain for addresses that belong to a given
 // seed.
 type seedScanner struct {
+	addressGap       uint64                      // minimum number of unused keys scanned after the largest index seen
 	dustThreshold    types.Currency              // minimum value of outputs to be included
 	keys             map[types.UnlockHash]uint64 // map address to seed index
 	largestIndexSeen uint64                      // largest index that has appeared in the blockchain
@@ -61,6 +62,7 @@ type seedScanner struct {
 	seed             modules.Seed
 	siacoinOutputs   map[types.SiacoinOutputID]scannedOutput
 	siafundOutputs   map[types.SiafundOutputID]scannedOutput
+	start            modules.ConsensusChangeID // consensus change after which the scan starts
 
 	log *persist.Logger
 }
@@ -157,13 +159,23 @@ func (s *seedScanner) scan(cs modules.ConsensusSet, cancel <-chan struct{}) erro
 	//
 	// NOTE: since scanning is very slow, we aim to only scan once, which
 	// means generating many keys.
+	//
+	// Generating at least twice the address gap guarantees that the gap
+	// following the largest index seen has been scanned once the upper half
+	// is unused.
 	numKeys := numInitialKeys
+	if numKeys < 2*s.addressGap {
+		numKeys = 2 * s.addressGap
+	}
 	for s.numKeys() < maxScanKeys {
+		if numKeys > maxScanKeys-s.numKeys() {
+			numKeys = maxScanKeys - s.numKeys()
+		}
 		s.generateKeys(numKeys)
 
 		// Reset scan height between scans.
 		s.scannedHeight = 0
-		if err := cs.ConsensusSetSubscribe(s, modules.ConsensusChangeBeginning, cancel); err != nil {
+		if err := cs.ConsensusSetSubscribe(s, s.start, cancel); err != nil {
 			return err
 		}
 		cs.Unsubscribe(s)
@@ -173,9 +185,6 @@ func (s *seedScanner) scan(cs modules.ConsensusSet, cancel <-chan struct{}) erro
 		// increase number of keys generated each iteration, capping so that
 		// we do not exceed maxScanKeys
 		numKeys *= scanMultiplier
-		if numKeys > maxScanKeys-s.numKeys() {
-			numKeys = maxScanKeys - s.numKeys()
-		}
 	}
 	return errMaxKeys
 }
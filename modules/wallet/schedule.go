@@ -0,0 +1,269 @@
+package wallet
+
+import (
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errInvalidScheduleAmount is returned if a payment schedule doesn't
+	// specify an amount to pay.
+	errInvalidScheduleAmount = errors.New("payment schedule amount must be greater than zero")
+
+	// errInvalidScheduleLimit is returned if a one-off payment schedule
+	// specifies a limit for the number of payments.
+	errInvalidScheduleLimit = errors.New("maxpayments requires a recurring payment schedule")
+
+	// errInvalidScheduleTrigger is returned if a payment schedule doesn't
+	// specify exactly one of a height or a time.
+	errInvalidScheduleTrigger = errors.New("payment schedule must specify either a height or a time")
+)
+
+// isDue returns whether a payment of the schedule is due at the provided
+// height and timestamp.
+func isDue(ps modules.PaymentSchedule, height types.BlockHeight, timestamp types.Timestamp) bool {
+	if ps.Completed {
+		return false
+	}
+	if ps.NextHeight != 0 {
+		return height >= ps.NextHeight
+	}
+	return timestamp >= ps.NextTime
+}
+
+// advanceSchedule updates the schedule after a successful payment. Recurring
+// schedules are moved to their next occurrence after the provided height and
+// timestamp, occurrences which were missed while the payment couldn't be made
+// are not paid retroactively.
+func advanceSchedule(ps *modules.PaymentSchedule, height types.BlockHeight, timestamp types.Timestamp) {
+	ps.Payments++
+	if ps.Interval == 0 || (ps.MaxPayments > 0 && ps.Payments >= ps.MaxPayments) {
+		ps.Completed = true
+		return
+	}
+	if ps.NextHeight != 0 {
+		for ps.NextHeight <= height {
+			ps.NextHeight += types.BlockHeight(ps.Interval)
+		}
+		return
+	}
+	for ps.NextTime <= timestamp {
+		ps.NextTime += types.Timestamp(ps.Interval)
+	}
+}
+
+// AddPaymentSchedule adds a scheduled payment to the wallet. The schedule is
+// assigned a random ID and executed once it is due, which might be right after
+// the next block.
+func (w *Wallet) AddPaymentSchedule(ps modules.PaymentSchedule) (modules.PaymentSchedule, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.PaymentSchedule{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	// Validate the schedule.
+	if ps.Amount.IsZero() {
+		return modules.PaymentSchedule{}, errInvalidScheduleAmount
+	}
+	if (ps.NextHeight == 0) == (ps.NextTime == 0) {
+		return modules.PaymentSchedule{}, errInvalidScheduleTrigger
+	}
+	if ps.Interval == 0 && ps.MaxPayments > 0 {
+		return modules.PaymentSchedule{}, errInvalidScheduleLimit
+	}
+
+	// Reset the status fields.
+	ps = modules.PaymentSchedule{
+		Description: ps.Description,
+		Destination: ps.Destination,
+		Amount:      ps.Amount,
+		NextHeight:  ps.NextHeight,
+		NextTime:    ps.NextTime,
+		Interval:    ps.Interval,
+		MaxPayments: ps.MaxPayments,
+	}
+	fastrand.Read(ps.ID[:])
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := dbPutPaymentSchedule(w.dbTx, ps); err != nil {
+		return modules.PaymentSchedule{}, errors.AddContext(err, "failed to store payment schedule")
+	}
+	if err := w.syncDB(); err != nil {
+		return modules.PaymentSchedule{}, err
+	}
+	w.log.Printf("Added payment schedule %v paying %v to %v", ps.ID, ps.Amount.HumanString(), ps.Destination)
+	return ps, nil
+}
+
+// PaymentSchedules returns all scheduled payments of the wallet, including
+// completed ones.
+func (w *Wallet) PaymentSchedules() ([]modules.PaymentSchedule, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var schedules []modules.PaymentSchedule
+	err := dbForEachPaymentSchedule(w.dbTx, func(_ crypto.Hash, ps modules.PaymentSchedule) {
+		schedules = append(schedules, ps)
+	})
+	return schedules, err
+}
+
+// RemovePaymentSchedule removes a scheduled payment from the wallet.
+func (w *Wallet) RemovePaymentSchedule(id crypto.Hash) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := dbGetPaymentSchedule(w.dbTx, id); errors.Contains(err, errNoKey) {
+		return modules.ErrUnknownPaymentSchedule
+	} else if err != nil {
+		return err
+	}
+	if err := dbDeletePaymentSchedule(w.dbTx, id); err != nil {
+		return err
+	}
+	w.staticAlerter.UnregisterAlert(modules.AlertIDWalletPaymentSchedule(id.String()))
+	return w.syncDB()
+}
+
+// managedSetPendingPayment stores the transaction set of a scheduled payment
+// before it is broadcast.
+func (w *Wallet) managedSetPendingPayment(id crypto.Hash, txns []types.Transaction) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ps, err := dbGetPaymentSchedule(w.dbTx, id)
+	if errors.Contains(err, errNoKey) {
+		return modules.ErrUnknownPaymentSchedule
+	} else if err != nil {
+		return err
+	}
+	ps.PendingTransactions = txns
+	if err := dbPutPaymentSchedule(w.dbTx, ps); err != nil {
+		return errors.AddContext(err, "failed to store pending payment")
+	}
+	return w.syncDB()
+}
+
+// managedResumePendingPayment determines the outcome of a scheduled payment
+// whose transaction set was stored but whose outcome wasn't recorded. The
+// payment was made if the wallet knows the transaction or the set can still
+// be broadcast. Otherwise its inputs were spent elsewhere and the payment has
+// to be made again.
+func (w *Wallet) managedResumePendingPayment(ps modules.PaymentSchedule) bool {
+	txns := ps.PendingTransactions
+	if _, found, err := w.Transaction(txns[len(txns)-1].ID()); err == nil && found {
+		return true
+	}
+	err := w.tpool.AcceptTransactionSet(txns)
+	if err != nil && !errors.Contains(err, modules.ErrDuplicateTransactionSet) {
+		w.log.Printf("Pending transactions of scheduled payment %v were not broadcast: %v", ps.ID, err)
+		return false
+	}
+	return true
+}
+
+// managedSendScheduledPayment executes a scheduled payment. The transaction
+// set is stored with the schedule before it is broadcast so that the payment
+// isn't repeated if the wallet stops before its outcome is recorded.
+func (w *Wallet) managedSendScheduledPayment(ps modules.PaymentSchedule) ([]types.Transaction, error) {
+	_, fee := w.tpool.FeeEstimation()
+	fee = fee.Mul64(estimatedTransactionSize)
+	return w.managedSendSiacoins(ps.Amount, fee, ps.Destination, modules.CoinControl{}, func(txns []types.Transaction) error {
+		return w.managedSetPendingPayment(ps.ID, txns)
+	})
+}
+
+// managedUpdatePaymentSchedule records the outcome of an attempt to execute a
+// scheduled payment.
+func (w *Wallet) managedUpdatePaymentSchedule(id crypto.Hash, height types.BlockHeight, timestamp types.Timestamp, txns []types.Transaction, payErr error) {
+	alertID := modules.AlertIDWalletPaymentSchedule(id.String())
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ps, err := dbGetPaymentSchedule(w.dbTx, id)
+	if errors.Contains(err, errNoKey) {
+		// The schedule was removed in the meantime.
+		return
+	} else if err != nil {
+		w.log.Println("ERROR: failed to fetch payment schedule:", err)
+		return
+	}
+	ps.PendingTransactions = nil
+	if payErr != nil {
+		ps.Failures++
+		ps.LastError = payErr.Error()
+		w.staticAlerter.RegisterAlert(alertID, AlertMSGWalletPaymentSchedule, payErr.Error(), modules.SeverityError)
+		w.log.Printf("Scheduled payment %v failed, retrying with the next block: %v", id, payErr)
+	} else {
+		ps.Failures = 0
+		ps.LastError = ""
+		ps.LastPaymentHeight = height
+		ps.LastTransactionID = txns[len(txns)-1].ID()
+		advanceSchedule(&ps, height, timestamp)
+		w.staticAlerter.UnregisterAlert(alertID)
+		w.log.Printf("Executed scheduled payment %v in transaction %v", id, ps.LastTransactionID)
+	}
+	if err := dbPutPaymentSchedule(w.dbTx, ps); err != nil {
+		w.log.Println("ERROR: failed to update payment schedule:", err)
+		return
+	}
+	if err := w.syncDB(); err != nil {
+		w.log.Println("ERROR: failed to sync payment schedule:", err)
+	}
+}
+
+// threadedExecutePaymentSchedules executes all payment schedules that are due
+// at the provided height and timestamp. Failed payments stay due and are
+// therefore retried with the next block.
+func (w *Wallet) threadedExecutePaymentSchedules(height types.BlockHeight, timestamp types.Timestamp) {
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+
+	// Make sure only one thread is executing payments at a time. The due
+	// schedules are collected after acquiring the lock to see the updates of
+	// the previous thread.
+	w.scheduleMu.Lock()
+	defer w.scheduleMu.Unlock()
+
+	// Collect the schedules which are due.
+	var due []modules.PaymentSchedule
+	w.mu.Lock()
+	err := dbForEachPaymentSchedule(w.dbTx, func(_ crypto.Hash, ps modules.PaymentSchedule) {
+		if isDue(ps, height, timestamp) {
+			due = append(due, ps)
+		}
+	})
+	w.mu.Unlock()
+	if err != nil {
+		w.log.Println("ERROR: failed to load payment schedules:", err)
+		return
+	}
+
+	for _, ps := range due {
+		// Finish a payment which was interrupted before its outcome was
+		// recorded.
+		if len(ps.PendingTransactions) > 0 && w.managedResumePendingPayment(ps) {
+			w.managedUpdatePaymentSchedule(ps.ID, height, timestamp, ps.PendingTransactions, nil)
+			continue
+		}
+		txns, err := w.managedSendScheduledPayment(ps)
+		if err == nil && w.deps.Disrupt("PaymentScheduleInterrupted") {
+			continue
+		}
+		w.managedUpdatePaymentSchedule(ps.ID, height, timestamp, txns, err)
+	}
+}
@@ -0,0 +1,294 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestAdvanceSchedule is a unit test for advanceSchedule.
+func TestAdvanceSchedule(t *testing.T) {
+	// One-off payments are completed after the first payment.
+	ps := modules.PaymentSchedule{NextHeight: 10}
+	advanceSchedule(&ps, 10, 0)
+	if !ps.Completed || ps.Payments != 1 {
+		t.Fatal("one-off payment wasn't completed", ps)
+	}
+
+	// Recurring payments skip the occurrences that were missed.
+	ps = modules.PaymentSchedule{NextHeight: 10, Interval: 5, MaxPayments: 2}
+	advanceSchedule(&ps, 21, 0)
+	if ps.Completed || ps.NextHeight != 25 {
+		t.Fatal("wrong next height", ps)
+	}
+	advanceSchedule(&ps, 25, 0)
+	if !ps.Completed || ps.Payments != 2 {
+		t.Fatal("recurring payment wasn't completed", ps)
+	}
+
+	// Time-based schedules advance the time.
+	ps = modules.PaymentSchedule{NextTime: 100, Interval: 60}
+	advanceSchedule(&ps, 0, 100)
+	if ps.Completed || ps.NextTime != 160 {
+		t.Fatal("wrong next time", ps)
+	}
+}
+
+// TestPaymentSchedules tests executing scheduled payments.
+func TestPaymentSchedules(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	mine := func() {
+		b, _ := wt.miner.FindBlock()
+		if err := wt.cs.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	schedule := func(id modules.PaymentSchedule) (modules.PaymentSchedule, error) {
+		schedules, err := wt.wallet.PaymentSchedules()
+		if err != nil {
+			return modules.PaymentSchedule{}, err
+		}
+		for _, ps := range schedules {
+			if ps.ID == id.ID {
+				return ps, nil
+			}
+		}
+		return modules.PaymentSchedule{}, modules.ErrUnknownPaymentSchedule
+	}
+
+	// Invalid schedules are rejected.
+	height := wt.cs.Height()
+	invalid := []struct {
+		ps  modules.PaymentSchedule
+		err error
+	}{
+		{modules.PaymentSchedule{NextHeight: height}, errInvalidScheduleAmount},
+		{modules.PaymentSchedule{Amount: types.SiacoinPrecision}, errInvalidScheduleTrigger},
+		{modules.PaymentSchedule{Amount: types.SiacoinPrecision, NextHeight: height, NextTime: 1}, errInvalidScheduleTrigger},
+		{modules.PaymentSchedule{Amount: types.SiacoinPrecision, NextHeight: height, MaxPayments: 1}, errInvalidScheduleLimit},
+	}
+	for _, test := range invalid {
+		if _, err := wt.wallet.AddPaymentSchedule(test.ps); !errors.Contains(err, test.err) {
+			t.Fatalf("expected %v but got %v", test.err, err)
+		}
+	}
+
+	// Add a one-off payment for the next block, a time-based payment that is
+	// already due, a recurring payment and one that can't be afforded.
+	oneOff, err := wt.wallet.AddPaymentSchedule(modules.PaymentSchedule{
+		Amount:      types.SiacoinPrecision,
+		Destination: types.UnlockHash{1},
+		NextHeight:  height + 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	timed, err := wt.wallet.AddPaymentSchedule(modules.PaymentSchedule{
+		Amount:      types.SiacoinPrecision,
+		Destination: types.UnlockHash{2},
+		NextTime:    types.Timestamp(time.Now().Add(-time.Hour).Unix()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recurring, err := wt.wallet.AddPaymentSchedule(modules.PaymentSchedule{
+		Amount:      types.SiacoinPrecision,
+		Destination: types.UnlockHash{3},
+		NextHeight:  height + 1,
+		Interval:    2,
+		MaxPayments: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expensive, err := wt.wallet.AddPaymentSchedule(modules.PaymentSchedule{
+		Amount:      types.SiacoinPrecision.Mul64(1e12),
+		Destination: types.UnlockHash{4},
+		NextHeight:  height + 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mine a block to trigger the payments.
+	mine()
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		for _, id := range []modules.PaymentSchedule{oneOff, timed, recurring} {
+			ps, err := schedule(id)
+			if err != nil {
+				return err
+			}
+			if ps.Payments != 1 {
+				return errors.New("payment wasn't made")
+			}
+		}
+		ps, err := schedule(expensive)
+		if err != nil {
+			return err
+		}
+		if ps.Failures == 0 || ps.Payments != 0 {
+			return errors.New("payment didn't fail")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, errs, _, _ := wt.wallet.Alerts()
+	if len(errs) != 1 || errs[0].Msg != AlertMSGWalletPaymentSchedule {
+		t.Fatal("expected an alert for the failed payment", errs)
+	}
+
+	// The one-off payments are completed, the recurring payment is due again
+	// in two blocks.
+	for _, id := range []modules.PaymentSchedule{oneOff, timed} {
+		if ps, _ := schedule(id); !ps.Completed {
+			t.Fatal("one-off payment wasn't completed")
+		}
+	}
+	if ps, _ := schedule(recurring); ps.Completed || ps.NextHeight != height+3 {
+		t.Fatal("recurring payment wasn't advanced", ps.NextHeight, height+3)
+	}
+
+	// Mine two more blocks to complete the recurring payment.
+	mine()
+	mine()
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		ps, err := schedule(recurring)
+		if err != nil {
+			return err
+		}
+		if ps.Payments != 2 || !ps.Completed {
+			return errors.New("recurring payment wasn't completed")
+		}
+		ps, err = schedule(expensive)
+		if err != nil {
+			return err
+		}
+		if ps.Failures < 3 {
+			return errors.New("payment wasn't retried")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Removing the failing schedule removes the alert.
+	if err := wt.wallet.RemovePaymentSchedule(expensive.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.RemovePaymentSchedule(expensive.ID); !errors.Contains(err, modules.ErrUnknownPaymentSchedule) {
+		t.Fatal("expected ErrUnknownPaymentSchedule but got", err)
+	}
+	if _, errs, _, _ := wt.wallet.Alerts(); len(errs) != 0 {
+		t.Fatal("alert wasn't removed", errs)
+	}
+	if schedules, err := wt.wallet.PaymentSchedules(); err != nil || len(schedules) != 3 {
+		t.Fatal("wrong number of schedules", len(schedules), err)
+	}
+}
+
+// TestPaymentScheduleInterrupted tests that a scheduled payment whose outcome
+// wasn't recorded before the wallet stopped isn't paid twice.
+func TestPaymentScheduleInterrupted(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	deps := &dependencyPaymentScheduleInterrupted{}
+	wt, err := createWalletTester(t.Name(), deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	mine := func() {
+		b, _ := wt.miner.FindBlock()
+		if err := wt.cs.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	schedule := func() modules.PaymentSchedule {
+		schedules, err := wt.wallet.PaymentSchedules()
+		if err != nil || len(schedules) != 1 {
+			t.Fatal("expected one schedule", schedules, err)
+		}
+		return schedules[0]
+	}
+
+	// Interrupt the payment after it was broadcast.
+	deps.fail()
+	dest := types.UnlockHash{5}
+	_, err = wt.wallet.AddPaymentSchedule(modules.PaymentSchedule{
+		Amount:      types.SiacoinPrecision,
+		Destination: dest,
+		NextHeight:  wt.cs.Height() + 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if ps := schedule(); len(ps.PendingTransactions) == 0 {
+			return errors.New("payment isn't pending")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := schedule().PendingTransactions
+	if ps := schedule(); ps.Payments != 0 || ps.Completed {
+		t.Fatal("outcome of the interrupted payment was recorded", ps)
+	}
+
+	// The next block completes the pending payment instead of paying again.
+	mine()
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if ps := schedule(); !ps.Completed {
+			return errors.New("payment wasn't completed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := schedule()
+	if ps.Payments != 1 || len(ps.PendingTransactions) != 0 || ps.LastTransactionID != pending[len(pending)-1].ID() {
+		t.Fatal("wrong schedule after resuming the payment", ps)
+	}
+	mine()
+	txns, err := wt.wallet.Transactions(0, wt.cs.Height())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payments int
+	for _, pt := range txns {
+		for _, sco := range pt.Transaction.SiacoinOutputs {
+			if sco.UnlockHash == dest {
+				payments++
+			}
+		}
+	}
+	if payments != 1 {
+		t.Fatalf("expected 1 payment but got %v", payments)
+	}
+}
@@ -97,7 +97,7 @@ func decryptSeedFile(masterKey crypto.CipherKey, sf seedFile) (seed modules.Seed
 // regenerateLookahead creates future keys up to a maximum of maxKeys keys
 func (w *Wallet) regenerateLookahead(start uint64) {
 	// Check how many keys need to be generated
-	maxKeys := maxLookahead(start)
+	maxKeys := maxLookahead(start, w.lookaheadGap())
 	existingKeys := uint64(len(w.lookahead))
 
 	for i, k := range generateKeys(w.primarySeed, start+existingKeys, maxKeys-existingKeys) {
@@ -295,9 +295,10 @@ func (w *Wallet) NextAddress() (types.UnlockConditions, error) {
 
 // LoadSeed will track all of the addresses generated by the input seed,
 // reclaiming any funds that were lost due to a deleted file or lost encryption
-// key. An error will be returned if the seed has already been integrated with
-// the wallet.
-func (w *Wallet) LoadSeed(masterKey crypto.CipherKey, seed modules.Seed) error {
+// key. Only the blocks starting at startHeight are scanned for the seed's
+// transactions. An error will be returned if the seed has already been
+// integrated with the wallet.
+func (w *Wallet) LoadSeed(masterKey crypto.CipherKey, seed modules.Seed, startHeight types.BlockHeight) error {
 	if err := w.tg.Add(); err != nil {
 		return modules.ErrWalletShutdown
 	}
@@ -329,7 +330,14 @@ func (w *Wallet) LoadSeed(masterKey crypto.CipherKey, seed modules.Seed) error {
 	w.mu.RUnlock()
 
 	// scan blockchain to determine how many keys to generate for the seed
-	s := newSeedScanner(seed, w.log)
+	start, startHeight, err := w.scanStart(startHeight)
+	if err != nil {
+		return err
+	}
+	s, err := w.managedNewSeedScanner(seed, startHeight)
+	if err != nil {
+		return err
+	}
 	if err := s.scan(w.cs, w.tg.StopChan()); err != nil {
 		return err
 	}
@@ -339,7 +347,7 @@ func (w *Wallet) LoadSeed(masterKey crypto.CipherKey, seed modules.Seed) error {
 	seedProgress += seedProgress / 25
 	w.log.Printf("INFO: found key index %v in blockchain. Setting auxiliary seed progress to %v", s.largestIndexSeen, seedProgress)
 
-	err := func() error {
+	err = func() error {
 		w.mu.Lock()
 		defer w.mu.Unlock()
 
@@ -366,48 +374,24 @@ func (w *Wallet) LoadSeed(masterKey crypto.CipherKey, seed modules.Seed) error {
 		w.integrateSeed(seed, seedProgress)
 		w.seeds = append(w.seeds, seed)
 
-		// delete the set of processed transactions; they will be recreated
-		// when we rescan
-		if err = w.dbTx.DeleteBucket(bucketProcessedTransactions); err != nil {
-			return err
-		}
-		if _, err = w.dbTx.CreateBucket(bucketProcessedTransactions); err != nil {
-			return err
-		}
-		w.unconfirmedProcessedTransactions = nil
-
-		// reset the consensus change ID and height in preparation for rescan
-		err = dbPutConsensusChangeID(w.dbTx, modules.ConsensusChangeBeginning)
-		if err != nil {
-			return err
-		}
-		return dbPutConsensusHeight(w.dbTx, 0)
+		// delete the processed transactions that will be recreated when we
+		// rescan
+		return w.resetTransactionHistory(start, startHeight)
 	}()
 	if err != nil {
 		return err
 	}
 
 	// rescan the blockchain
-	w.cs.Unsubscribe(w)
-	w.tpool.Unsubscribe(w)
-
-	done := make(chan struct{})
-	go w.rescanMessage(done)
-	defer close(done)
-
-	err = w.cs.ConsensusSetSubscribe(w, modules.ConsensusChangeBeginning, w.tg.StopChan())
-	if err != nil {
-		return err
-	}
-	w.tpool.TransactionPoolSubscribe(w)
-	return nil
+	return w.managedRescan(start)
 }
 
 // SweepSeed scans the blockchain for outputs generated from seed and creates
 // a transaction that transfers them to the wallet. Note that this incurs a
 // transaction fee. It returns the total value of the outputs, minus the fee.
-// If only siafunds were found, the fee is deducted from the wallet.
-func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, err error) {
+// If only siafunds were found, the fee is deducted from the wallet. Only the
+// blocks starting at startHeight are scanned for the seed's outputs.
+func (w *Wallet) SweepSeed(seed modules.Seed, startHeight types.BlockHeight) (coins, funds types.Currency, err error) {
 	if err = w.tg.Add(); err != nil {
 		return types.Currency{}, types.Currency{}, modules.ErrWalletShutdown
 	}
@@ -448,7 +432,10 @@ func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, err
 
 	// scan blockchain for outputs, filtering out 'dust' (outputs that cost
 	// more in fees than they are worth)
-	s := newSeedScanner(seed, w.log)
+	s, err := w.managedNewSeedScanner(seed, startHeight)
+	if err != nil {
+		return
+	}
 	_, maxFee := w.tpool.FeeEstimation()
 	const outputSize = 350 // approx. size in bytes of an output and accompanying signature
 	const maxOutputs = 50  // approx. number of outputs that a transaction can handle
@@ -149,7 +149,7 @@ func TestLoadSeed(t *testing.T) {
 		t.Error("fresh wallet should not have a balance")
 	}
 	sk = crypto.NewWalletKey(crypto.HashObject(newSeed))
-	err = w.LoadSeed(sk, seed)
+	err = w.LoadSeed(sk, seed, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -253,7 +253,7 @@ func TestSweepSeedCoins(t *testing.T) {
 	}
 
 	// sweep the seed of the first wallet into the second
-	sweptCoins, _, err := w.SweepSeed(seed)
+	sweptCoins, _, err := w.SweepSeed(seed, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -334,7 +334,7 @@ func TestSweepSeedFunds(t *testing.T) {
 	}
 
 	// Sweep the seed.
-	coins, funds, err := wt.wallet.SweepSeed(seed)
+	coins, funds, err := wt.wallet.SweepSeed(seed, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -443,7 +443,7 @@ func TestSweepSeedSentFunds(t *testing.T) {
 	}
 
 	// Sweep the seed.
-	coins, funds, err := wt.wallet.SweepSeed(seed)
+	coins, funds, err := wt.wallet.SweepSeed(seed, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -539,7 +539,7 @@ func TestSweepSeedCoinsAndFunds(t *testing.T) {
 	}
 
 	// Sweep the seed.
-	coins, funds, err := wt.wallet.SweepSeed(seed)
+	coins, funds, err := wt.wallet.SweepSeed(seed, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
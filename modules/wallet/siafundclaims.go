@@ -0,0 +1,150 @@
+package wallet
+
+import (
+	"sort"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// revertSiafundClaims removes the siafund outputs created by the reverted
+// blocks of the consensus change from the claim history and marks the outputs
+// spent by them as unspent again.
+func (w *Wallet) revertSiafundClaims(tx *bolt.Tx, reverted []types.Block) error {
+	for _, block := range reverted {
+		for i := len(block.Transactions) - 1; i >= 0; i-- {
+			txn := block.Transactions[i]
+			for j := range txn.SiafundOutputs {
+				err := dbDeleteSiafundClaim(tx, txn.SiafundOutputID(uint64(j)))
+				if err != nil && !errors.Contains(err, errNoKey) {
+					return err
+				}
+			}
+			txid := txn.ID()
+			for _, sfi := range txn.SiafundInputs {
+				sc, err := dbGetSiafundClaim(tx, sfi.ParentID)
+				if errors.Contains(err, errNoKey) || sc.PayoutTransactionID != txid {
+					continue
+				} else if err != nil {
+					return err
+				}
+				sc = modules.SiafundClaim{
+					OutputID:      sc.OutputID,
+					Address:       sc.Address,
+					Value:         sc.Value,
+					ClaimStart:    sc.ClaimStart,
+					Height:        sc.Height,
+					Timestamp:     sc.Timestamp,
+					TransactionID: sc.TransactionID,
+				}
+				if err := dbPutSiafundClaim(tx, sc); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// applySiafundClaims records the siafund outputs received by the wallet in the
+// applied blocks of the consensus change and the payouts of the claims of the
+// outputs spent by them.
+func (w *Wallet) applySiafundClaims(tx *bolt.Tx, cc modules.ConsensusChange) error {
+	// The claim start of an output is only known from its diff and the payout
+	// of a claim from the delayed output created by the consensus set.
+	created := make(map[types.SiafundOutputID]types.SiafundOutput)
+	for _, diff := range cc.SiafundOutputDiffs {
+		if diff.Direction == modules.DiffApply {
+			created[diff.ID] = diff.SiafundOutput
+		}
+	}
+	payouts := make(map[types.SiacoinOutputID]modules.DelayedSiacoinOutputDiff)
+	for _, diff := range cc.DelayedSiacoinOutputDiffs {
+		if diff.Direction == modules.DiffApply {
+			payouts[diff.ID] = diff
+		}
+	}
+
+	consensusHeight := cc.InitialHeight()
+	for _, block := range cc.AppliedBlocks {
+		if block.ID() != types.GenesisID {
+			consensusHeight++
+		}
+		for _, txn := range block.Transactions {
+			txid := txn.ID()
+			for _, sfi := range txn.SiafundInputs {
+				sc, err := dbGetSiafundClaim(tx, sfi.ParentID)
+				if errors.Contains(err, errNoKey) {
+					continue
+				} else if err != nil {
+					return err
+				}
+				payout := payouts[sfi.ParentID.SiaClaimOutputID()]
+				sc.Claim = payout.SiacoinOutput.Value
+				sc.Spent = true
+				sc.SpentHeight = consensusHeight
+				sc.SpentTimestamp = block.Timestamp
+				sc.PayoutTransactionID = txid
+				sc.ClaimAddress = sfi.ClaimUnlockHash
+				sc.PayoutMaturityHeight = payout.MaturityHeight
+				if err := dbPutSiafundClaim(tx, sc); err != nil {
+					return err
+				}
+				w.log.Println("Siafund claim has been paid out:", sfi.ParentID, "::", sc.Claim.HumanString())
+			}
+			for i, sfo := range txn.SiafundOutputs {
+				if !w.isWalletAddress(sfo.UnlockHash) {
+					continue
+				}
+				id := txn.SiafundOutputID(uint64(i))
+				sc := modules.SiafundClaim{
+					OutputID:      id,
+					Address:       sfo.UnlockHash,
+					Value:         sfo.Value,
+					ClaimStart:    created[id].ClaimStart,
+					Height:        consensusHeight,
+					Timestamp:     block.Timestamp,
+					TransactionID: txid,
+				}
+				if err := dbPutSiafundClaim(tx, sc); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// SiafundClaims returns the claims of the siafund outputs the wallet received,
+// including the spent ones, ordered by the height at which they were received.
+// The claim of an unspent output is the amount it accrued so far.
+func (w *Wallet) SiafundClaims() ([]modules.SiafundClaim, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	siafundPool, err := dbGetSiafundPool(w.dbTx)
+	if err != nil {
+		return nil, err
+	}
+	var claims []modules.SiafundClaim
+	err = dbForEachSiafundClaim(w.dbTx, func(_ types.SiafundOutputID, sc modules.SiafundClaim) {
+		if !sc.Spent && siafundPool.Cmp(sc.ClaimStart) >= 0 {
+			// Same computation as the consensus set's payout.
+			sc.Claim = siafundPool.Sub(sc.ClaimStart).Div(types.SiafundCount).Mul(sc.Value)
+		}
+		claims = append(claims, sc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(claims, func(i, j int) bool {
+		return claims[i].Height < claims[j].Height
+	})
+	return claims, nil
+}
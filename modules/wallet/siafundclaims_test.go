@@ -0,0 +1,138 @@
+package wallet
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestSiafundClaims tests tracking the accrual and payout of siafund claims.
+func TestSiafundClaims(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Loading the siag key rescans the blockchain and records the genesis
+	// siafund output.
+	err = wt.wallet.LoadSiagKeys(wt.walletMasterKey, []string{"../../types/siag0of1of1.siakey"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := wt.wallet.SiafundClaims()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claims) != 1 || !claims[0].Value.Equals64(2000) || claims[0].Height != 0 || claims[0].Spent || !claims[0].Claim.IsZero() {
+		t.Fatal("expected the unspent genesis output without a claim", claims)
+	}
+	genesis := claims[0]
+
+	// Fill the siafund pool by creating a file contract.
+	height := wt.cs.Height()
+	payout := types.SiacoinPrecision.Mul64(1000)
+	tb, err := wt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.FundSiacoins(payout); err != nil {
+		t.Fatal(err)
+	}
+	outputs := []types.SiacoinOutput{{Value: types.PostTax(height, payout)}}
+	tb.AddFileContract(types.FileContract{
+		WindowStart:        height + 10,
+		WindowEnd:          height + 20,
+		Payout:             payout,
+		ValidProofOutputs:  outputs,
+		MissedProofOutputs: outputs,
+	})
+	txnSet, err := tb.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	pool := payout.Sub(types.PostTax(height, payout))
+	accrued := pool.Div(types.SiafundCount).Mul64(2000)
+	claims, err = wt.wallet.SiafundClaims()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claims) != 1 || !claims[0].Claim.Equals(accrued) {
+		t.Fatalf("expected claim of %v but got %v", accrued, claims)
+	}
+	_, _, claimBalance, err := wt.wallet.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claimBalance.Equals(accrued) {
+		t.Fatalf("claim balance %v doesn't match accrued claim %v", claimBalance, accrued)
+	}
+
+	// Spending the output pays out the claim. The wallet sends siafunds via
+	// an intermediate output whose claim is paid out in the same block. The
+	// change output starts a new claim and the output sent to the void isn't
+	// tracked.
+	txns, err := wt.wallet.SendSiafunds(types.NewCurrency64(12), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	claims, err = wt.wallet.SiafundClaims()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claims) != 3 || claims[0].OutputID != genesis.OutputID {
+		t.Fatal("expected the genesis, intermediate and change outputs", claims)
+	}
+	spentBy := func(id types.SiafundOutputID) types.TransactionID {
+		for _, txn := range txns {
+			for _, sfi := range txn.SiafundInputs {
+				if sfi.ParentID == id {
+					return txn.ID()
+				}
+			}
+		}
+		t.Fatal("no transaction spends", id)
+		return types.TransactionID{}
+	}
+	spent := claims[0]
+	if !spent.Spent || spent.SpentHeight != wt.cs.Height() || spent.PayoutTransactionID != spentBy(spent.OutputID) {
+		t.Fatal("wrong spent output", spent)
+	}
+	if !spent.Claim.Equals(accrued) || spent.PayoutMaturityHeight != wt.cs.Height()+types.MaturityDelay || !wt.wallet.isWalletAddress(spent.ClaimAddress) {
+		t.Fatal("wrong payout", spent)
+	}
+	for _, sc := range claims[1:] {
+		if sc.Height != wt.cs.Height() || !sc.ClaimStart.Equals(pool) || !sc.Claim.IsZero() {
+			t.Fatal("wrong claim of new output", sc)
+		}
+		switch {
+		case sc.Value.Equals64(12):
+			if !sc.Spent || sc.PayoutTransactionID != spentBy(sc.OutputID) {
+				t.Fatal("intermediate output wasn't spent", sc)
+			}
+		case sc.Value.Equals64(1988):
+			if sc.Spent {
+				t.Fatal("change output was spent", sc)
+			}
+		default:
+			t.Fatal("unexpected output", sc)
+		}
+	}
+}
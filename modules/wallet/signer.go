@@ -0,0 +1,368 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errInvalidSigner is returned if the description of an external signer
+	// is neither a command nor a unix socket.
+	errInvalidSigner = errors.New("external signer must be a command or 'unix:' followed by the path of a socket")
+
+	// errInvalidSignerPublicKey is returned if the external signer returns a
+	// public key which isn't a valid ed25519 key.
+	errInvalidSignerPublicKey = errors.New("external signer returned an invalid public key")
+
+	// errInvalidSignerSignature is returned if a signature returned by the
+	// external signer doesn't verify.
+	errInvalidSignerSignature = errors.New("external signer returned an invalid signature")
+
+	// errNoSigner is returned when the external signer is required but the
+	// wallet doesn't have one.
+	errNoSigner = errors.New("wallet has no external signer")
+)
+
+// A signerTransport sends a request to an external signer and returns its
+// response.
+type signerTransport interface {
+	roundTrip(req modules.SignerRequest) (modules.SignerResponse, error)
+}
+
+// processSigner runs the signer command for every request. The request is
+// written to the command's stdin as JSON and the response is read from its
+// stdout.
+type processSigner struct {
+	name string
+	args []string
+}
+
+// roundTrip implements signerTransport.
+func (ps processSigner) roundTrip(req modules.SignerRequest) (resp modules.SignerResponse, err error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return modules.SignerResponse{}, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), signerTimeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ps.name, ps.args...)
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return modules.SignerResponse{}, errors.AddContext(err, fmt.Sprintf("external signer failed: %s", strings.TrimSpace(stderr.String())))
+	}
+	err = json.Unmarshal(stdout.Bytes(), &resp)
+	return resp, errors.AddContext(err, "failed to decode response of external signer")
+}
+
+// socketSigner sends every request over a new connection to the unix socket
+// of the signer. The request and response are encoded as JSON.
+type socketSigner struct {
+	path string
+}
+
+// roundTrip implements signerTransport.
+func (ss socketSigner) roundTrip(req modules.SignerRequest) (resp modules.SignerResponse, err error) {
+	conn, err := net.DialTimeout("unix", ss.path, signerTimeout)
+	if err != nil {
+		return modules.SignerResponse{}, errors.AddContext(err, "failed to connect to external signer")
+	}
+	defer func() {
+		err = errors.Compose(err, conn.Close())
+	}()
+	if err := conn.SetDeadline(time.Now().Add(signerTimeout)); err != nil {
+		return modules.SignerResponse{}, err
+	}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return modules.SignerResponse{}, errors.AddContext(err, "failed to send request to external signer")
+	}
+	err = json.NewDecoder(conn).Decode(&resp)
+	return resp, errors.AddContext(err, "failed to decode response of external signer")
+}
+
+// externalSigner implements modules.ExternalSigner on top of a
+// signerTransport.
+type externalSigner struct {
+	transport signerTransport
+}
+
+// NewExternalSigner returns the ExternalSigner described by spec. A spec of
+// the form 'unix:<path>' connects to the signer listening on the unix socket
+// at path, any other spec is the command line of a signer process which is
+// run for every request.
+func NewExternalSigner(spec string) (modules.ExternalSigner, error) {
+	if strings.HasPrefix(spec, "unix:") {
+		path := strings.TrimPrefix(spec, "unix:")
+		if path == "" {
+			return nil, errInvalidSigner
+		}
+		return &externalSigner{transport: socketSigner{path: path}}, nil
+	}
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return nil, errInvalidSigner
+	}
+	return &externalSigner{transport: processSigner{name: fields[0], args: fields[1:]}}, nil
+}
+
+// request sends a request to the signer and converts an error reported by
+// the signer into an error.
+func (es *externalSigner) request(req modules.SignerRequest) (modules.SignerResponse, error) {
+	resp, err := es.transport.roundTrip(req)
+	if err != nil {
+		return modules.SignerResponse{}, err
+	}
+	if resp.Error != "" {
+		return modules.SignerResponse{}, fmt.Errorf("external signer rejected the request: %v", resp.Error)
+	}
+	return resp, nil
+}
+
+// PublicKey implements modules.ExternalSigner.
+func (es *externalSigner) PublicKey(keyIndex uint64) (types.SiaPublicKey, error) {
+	resp, err := es.request(modules.SignerRequest{
+		Method:   modules.SignerMethodPublicKey,
+		KeyIndex: keyIndex,
+	})
+	if err != nil {
+		return types.SiaPublicKey{}, err
+	}
+	return resp.PublicKey, nil
+}
+
+// Sign implements modules.ExternalSigner.
+func (es *externalSigner) Sign(txn types.Transaction, height types.BlockHeight, sigs []modules.SignatureRequest) ([][]byte, error) {
+	resp, err := es.request(modules.SignerRequest{
+		Method:      modules.SignerMethodSign,
+		Transaction: txn,
+		Height:      height,
+		Signatures:  sigs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Signatures, nil
+}
+
+// SetExternalSigner sets the signer which signs the spends from the wallet's
+// signer addresses.
+func (w *Wallet) SetExternalSigner(signer modules.ExternalSigner) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.signer = signer
+}
+
+// integrateSignerAddress adds a signer address to the wallet. Its spendable
+// key doesn't contain any secret keys.
+func (w *Wallet) integrateSignerAddress(sa modules.SignerAddress) {
+	w.signerAddrs[sa.Address] = sa
+	w.keys[sa.Address] = spendableKey{UnlockConditions: sa.UnlockConditions}
+}
+
+// NewSignerAddress returns a new address of the wallet whose key is held by
+// the external signer. The signer's keys are derived in order, so the
+// address is expected to be unused. The wallet isn't locked while waiting for
+// the signer.
+func (w *Wallet) NewSignerAddress() (modules.SignerAddress, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.SignerAddress{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.signerMu.Lock()
+	defer w.signerMu.Unlock()
+
+	w.mu.RLock()
+	unlocked, signer := w.unlocked, w.signer
+	keyIndex := uint64(len(w.signerAddrs))
+	w.mu.RUnlock()
+	if !unlocked {
+		return modules.SignerAddress{}, modules.ErrLockedWallet
+	}
+	if signer == nil {
+		return modules.SignerAddress{}, errNoSigner
+	}
+
+	pk, err := signer.PublicKey(keyIndex)
+	if err != nil {
+		return modules.SignerAddress{}, errors.AddContext(err, "failed to get public key from external signer")
+	}
+	if pk.Algorithm != types.SignatureEd25519 || len(pk.Key) != crypto.PublicKeySize {
+		return modules.SignerAddress{}, errInvalidSignerPublicKey
+	}
+	uc := types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{pk},
+		SignaturesRequired: 1,
+	}
+	sa := modules.SignerAddress{
+		Address:          uc.UnlockHash(),
+		UnlockConditions: uc,
+		KeyIndex:         keyIndex,
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// The wallet might have been locked while waiting for the signer.
+	if !w.unlocked {
+		return modules.SignerAddress{}, modules.ErrLockedWallet
+	}
+	if _, exists := w.keys[sa.Address]; exists {
+		return modules.SignerAddress{}, errors.New("address of the external signer already belongs to the wallet")
+	}
+	if err := dbPutSignerAddress(w.dbTx, sa); err != nil {
+		return modules.SignerAddress{}, errors.AddContext(err, "failed to store signer address")
+	}
+	w.integrateSignerAddress(sa)
+	return sa, w.syncDB()
+}
+
+// SignerAddresses returns the addresses of the wallet whose keys are held by
+// the external signer, ordered by their key index.
+func (w *Wallet) SignerAddresses() ([]modules.SignerAddress, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var addrs []modules.SignerAddress
+	err := dbForEachSignerAddress(w.dbTx, func(_ types.UnlockHash, sa modules.SignerAddress) {
+		addrs = append(addrs, sa)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return addrs[i].KeyIndex < addrs[j].KeyIndex
+	})
+	return addrs, nil
+}
+
+// signInputs adds the signatures for all inputs of txn, which must belong to
+// the wallet, using the wallet's secret keys. Inputs of signer addresses only
+// get an empty signature. The returned requests for these signatures must be
+// passed to managedSignerSign after releasing the wallet's lock.
+func (w *Wallet) signInputs(txn *types.Transaction, cf types.CoveredFields, height types.BlockHeight) ([]modules.SignatureRequest, error) {
+	var signerSigs []int
+	sign := func(parentID crypto.Hash, uc types.UnlockConditions) {
+		addr := uc.UnlockHash()
+		if _, ok := w.signerAddrs[addr]; !ok {
+			addSignatures(txn, cf, uc, parentID, w.keys[addr], height)
+			return
+		}
+		signerSigs = append(signerSigs, len(txn.TransactionSignatures))
+		txn.TransactionSignatures = append(txn.TransactionSignatures, types.TransactionSignature{
+			ParentID:       parentID,
+			CoveredFields:  cf,
+			PublicKeyIndex: 0,
+		})
+	}
+	for _, sci := range txn.SiacoinInputs {
+		sign(crypto.Hash(sci.ParentID), sci.UnlockConditions)
+	}
+	for _, sfi := range txn.SiafundInputs {
+		sign(crypto.Hash(sfi.ParentID), sfi.UnlockConditions)
+	}
+	if len(signerSigs) == 0 {
+		return nil, nil
+	}
+	return w.signerRequests(*txn, signerSigs, height)
+}
+
+// signParentInputs signs all inputs of the parent transaction txn. The
+// wallet's lock must be held. It is released while waiting for the external
+// signer.
+func (w *Wallet) signParentInputs(txn *types.Transaction, height types.BlockHeight) error {
+	reqs, err := w.signInputs(txn, types.FullCoveredFields, height)
+	if err != nil || len(reqs) == 0 {
+		return err
+	}
+	w.mu.Unlock()
+	err = w.managedSignerSign(txn, height, reqs)
+	w.mu.Lock()
+	if err != nil {
+		return err
+	}
+	// The wallet might have been locked while waiting for the signer.
+	if !w.unlocked {
+		return modules.ErrLockedWallet
+	}
+	return nil
+}
+
+// signerRequests returns the requests for the external signer to sign the
+// TransactionSignatures at the provided indices, which must belong to inputs
+// of signer addresses.
+func (w *Wallet) signerRequests(txn types.Transaction, sigIndices []int, height types.BlockHeight) ([]modules.SignatureRequest, error) {
+	if w.signer == nil {
+		return nil, errNoSigner
+	}
+	reqs := make([]modules.SignatureRequest, 0, len(sigIndices))
+	for _, i := range sigIndices {
+		uc, ok := findUnlockConditions(txn, txn.TransactionSignatures[i].ParentID)
+		if !ok {
+			return nil, errors.New("signature references IDs not present in transaction")
+		}
+		sa, ok := w.signerAddrs[uc.UnlockHash()]
+		if !ok || txn.TransactionSignatures[i].PublicKeyIndex >= uint64(len(uc.PublicKeys)) {
+			return nil, errors.New("could not locate signing key for " + txn.TransactionSignatures[i].ParentID.String())
+		}
+		reqs = append(reqs, modules.SignatureRequest{
+			Index:     uint64(i),
+			SigHash:   txn.SigHash(i, height),
+			KeyIndex:  sa.KeyIndex,
+			PublicKey: uc.PublicKeys[txn.TransactionSignatures[i].PublicKeyIndex],
+		})
+	}
+	return reqs, nil
+}
+
+// managedSignerSign lets the external signer sign the requested signatures of
+// txn. The returned signatures are verified before they are added to txn.
+// Since the signer might be slow, the wallet's lock must not be held.
+func (w *Wallet) managedSignerSign(txn *types.Transaction, height types.BlockHeight, reqs []modules.SignatureRequest) error {
+	if len(reqs) == 0 {
+		return nil
+	}
+	w.mu.RLock()
+	signer := w.signer
+	w.mu.RUnlock()
+	if signer == nil {
+		return errNoSigner
+	}
+	sigs, err := signer.Sign(*txn, height, reqs)
+	if err != nil {
+		return errors.AddContext(err, "external signer failed to sign transaction")
+	}
+	if len(sigs) != len(reqs) {
+		return fmt.Errorf("external signer returned %v signatures, expected %v", len(sigs), len(reqs))
+	}
+	for i, req := range reqs {
+		var pk crypto.PublicKey
+		var sig crypto.Signature
+		if len(req.PublicKey.Key) != len(pk) || len(sigs[i]) != len(sig) {
+			return errInvalidSignerSignature
+		}
+		copy(pk[:], req.PublicKey.Key)
+		copy(sig[:], sigs[i])
+		if err := crypto.VerifyHash(req.SigHash, pk, sig); err != nil {
+			return errors.Compose(errInvalidSignerSignature, err)
+		}
+		txn.TransactionSignatures[req.Index].Signature = sigs[i]
+	}
+	return nil
+}
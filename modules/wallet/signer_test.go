@@ -0,0 +1,431 @@
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// testSigner is an ExternalSigner which derives its keys from a seed.
+type testSigner struct {
+	seed    modules.Seed
+	corrupt bool
+	signed  int
+}
+
+// PublicKey implements modules.ExternalSigner.
+func (ts *testSigner) PublicKey(keyIndex uint64) (types.SiaPublicKey, error) {
+	return generateSpendableKey(ts.seed, keyIndex).UnlockConditions.PublicKeys[0], nil
+}
+
+// Sign implements modules.ExternalSigner.
+func (ts *testSigner) Sign(txn types.Transaction, height types.BlockHeight, sigs []modules.SignatureRequest) ([][]byte, error) {
+	var encoded [][]byte
+	for _, req := range sigs {
+		sk := generateSpendableKey(ts.seed, req.KeyIndex).SecretKeys[0]
+		// The signer computes the hash itself rather than trusting the
+		// request.
+		sig := crypto.SignHash(txn.SigHash(int(req.Index), height), sk)
+		if ts.corrupt {
+			sig[0]++
+		}
+		encoded = append(encoded, sig[:])
+	}
+	ts.signed += len(sigs)
+	return encoded, nil
+}
+
+// slowSigner is a testSigner which signals every call on called and then
+// waits for release before answering.
+type slowSigner struct {
+	testSigner
+	called  chan struct{}
+	release chan struct{}
+}
+
+// PublicKey implements modules.ExternalSigner.
+func (ss *slowSigner) PublicKey(keyIndex uint64) (types.SiaPublicKey, error) {
+	ss.called <- struct{}{}
+	<-ss.release
+	return ss.testSigner.PublicKey(keyIndex)
+}
+
+// Sign implements modules.ExternalSigner.
+func (ss *slowSigner) Sign(txn types.Transaction, height types.BlockHeight, sigs []modules.SignatureRequest) ([][]byte, error) {
+	ss.called <- struct{}{}
+	<-ss.release
+	return ss.testSigner.Sign(txn, height, sigs)
+}
+
+// serveSigner answers a request using the signer.
+func serveSigner(signer modules.ExternalSigner, req modules.SignerRequest) (resp modules.SignerResponse) {
+	var err error
+	switch req.Method {
+	case modules.SignerMethodPublicKey:
+		resp.PublicKey, err = signer.PublicKey(req.KeyIndex)
+	case modules.SignerMethodSign:
+		resp.Signatures, err = signer.Sign(req.Transaction, req.Height, req.Signatures)
+	default:
+		err = fmt.Errorf("unknown method %q", req.Method)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	return resp
+}
+
+// TestSignerHelperProcess is run as the signer process by
+// TestSignerTransports.
+func TestSignerHelperProcess(t *testing.T) {
+	if os.Getenv("WALLET_TEST_SIGNER") != "1" {
+		return
+	}
+	var req modules.SignerRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		os.Exit(1)
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(serveSigner(&testSigner{}, req)); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// TestSignerTransports tests talking to a signer process and to a signer
+// listening on a unix socket.
+func TestSignerTransports(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// Serve the test signer on a unix socket.
+	dir := build.TempDir(modules.WalletDir, t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "signer.sock")
+	os.Remove(path)
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			var req modules.SignerRequest
+			if err := json.NewDecoder(conn).Decode(&req); err == nil {
+				json.NewEncoder(conn).Encode(serveSigner(&testSigner{}, req))
+			}
+			conn.Close()
+		}
+	}()
+
+	// Run the test binary itself as the signer process.
+	if err := os.Setenv("WALLET_TEST_SIGNER", "1"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("WALLET_TEST_SIGNER")
+
+	if _, err := NewExternalSigner(" "); !errors.Contains(err, errInvalidSigner) {
+		t.Fatal("expected errInvalidSigner but got", err)
+	}
+	if _, err := NewExternalSigner("unix:"); !errors.Contains(err, errInvalidSigner) {
+		t.Fatal("expected errInvalidSigner but got", err)
+	}
+
+	sk := generateSpendableKey(modules.Seed{}, 3)
+	txn := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{UnlockConditions: sk.UnlockConditions}},
+		TransactionSignatures: []types.TransactionSignature{{
+			CoveredFields: types.FullCoveredFields,
+		}},
+	}
+	sigHash := txn.SigHash(0, 1)
+	for _, spec := range []string{"unix:" + path, os.Args[0] + " -test.run=^TestSignerHelperProcess$"} {
+		signer, err := NewExternalSigner(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pk, err := signer.PublicKey(3)
+		if err != nil {
+			t.Fatal(spec, err)
+		}
+		if !pk.Equals(sk.UnlockConditions.PublicKeys[0]) {
+			t.Fatal(spec, "wrong public key", pk)
+		}
+		sigs, err := signer.Sign(txn, 1, []modules.SignatureRequest{{
+			SigHash:   sigHash,
+			KeyIndex:  3,
+			PublicKey: pk,
+		}})
+		if err != nil {
+			t.Fatal(spec, err)
+		}
+		var sig crypto.Signature
+		copy(sig[:], sigs[0])
+		if len(sigs) != 1 || crypto.VerifyHash(sigHash, sk.SecretKeys[0].PublicKey(), sig) != nil {
+			t.Fatal(spec, "invalid signature")
+		}
+
+		// Errors of the signer are returned.
+		_, err = signer.(*externalSigner).request(modules.SignerRequest{Method: "foo"})
+		if err == nil {
+			t.Fatal(spec, "expected signer to reject unknown method")
+		}
+	}
+}
+
+// TestExternalSigner tests receiving and spending funds of addresses whose
+// keys are held by an external signer.
+func TestExternalSigner(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Move past the hardfork which changes the replay protection of the
+	// signatures.
+	for wt.cs.Height() <= types.ASICHardforkHeight {
+		mineSyncedBlock(t, wt)
+	}
+
+	if _, err := wt.wallet.NewSignerAddress(); !errors.Contains(err, errNoSigner) {
+		t.Fatal("expected errNoSigner but got", err)
+	}
+	signer := &testSigner{seed: modules.Seed{1}}
+	wt.wallet.SetExternalSigner(signer)
+	sa, err := wt.wallet.NewSignerAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sa.KeyIndex != 0 || sa.Address != generateSpendableKey(signer.seed, 0).UnlockConditions.UnlockHash() {
+		t.Fatal("wrong signer address", sa)
+	}
+
+	// Fund the signer address with two outputs.
+	for i := 0; i < 2; i++ {
+		if _, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(10), sa.Address); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mineSyncedBlock(t, wt)
+
+	// The address is restored after unlocking the wallet again.
+	if err := wt.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	if addrs, err := wt.wallet.SignerAddresses(); err != nil || len(addrs) != 1 || addrs[0].Address != sa.Address {
+		t.Fatal("signer address wasn't restored", addrs, err)
+	}
+	signerOutputs := func() (ids []types.SiacoinOutputID) {
+		uos, err := wt.wallet.UnspentOutputs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, uo := range uos {
+			if uo.UnlockHash == sa.Address && uo.ConfirmationHeight <= wt.cs.Height() {
+				if uo.IsWatchOnly {
+					t.Fatal("signer output is reported as watch-only")
+				}
+				ids = append(ids, types.SiacoinOutputID(uo.ID))
+			}
+		}
+		return ids
+	}
+	ids := signerOutputs()
+	if len(ids) != 2 {
+		t.Fatal("expected 2 signer outputs but got", len(ids))
+	}
+
+	// Spend the first output using coin control.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = wt.wallet.SendSiacoinsCoinControl(types.SiacoinPrecision, uc.UnlockHash(), false, modules.CoinControl{Inputs: ids[:1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer.signed != 1 {
+		t.Fatal("signer wasn't asked to sign the input", signer.signed)
+	}
+	mineSyncedBlock(t, wt)
+	if remaining := signerOutputs(); len(remaining) != 1 || remaining[0] != ids[1] {
+		t.Fatal("signer output wasn't spent", remaining)
+	}
+
+	// Sign a spend of the second output with SignTransaction. Invalid
+	// signatures of the signer are rejected.
+	txn := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{
+			ParentID:         ids[1],
+			UnlockConditions: sa.UnlockConditions,
+		}},
+		SiacoinOutputs: []types.SiacoinOutput{{
+			Value:      types.SiacoinPrecision.Mul64(10),
+			UnlockHash: uc.UnlockHash(),
+		}},
+		TransactionSignatures: []types.TransactionSignature{{
+			ParentID:      crypto.Hash(ids[1]),
+			CoveredFields: types.FullCoveredFields,
+		}},
+	}
+	signer.corrupt = true
+	if err := wt.wallet.SignTransaction(&txn, nil); !errors.Contains(err, errInvalidSignerSignature) {
+		t.Fatal("expected errInvalidSignerSignature but got", err)
+	}
+	signer.corrupt = false
+	if err := wt.wallet.SignTransaction(&txn, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.tpool.AcceptTransactionSet([]types.Transaction{txn}); err != nil {
+		t.Fatal(err)
+	}
+	mineSyncedBlock(t, wt)
+	if remaining := signerOutputs(); len(remaining) != 0 {
+		t.Fatal("signer output wasn't spent", remaining)
+	}
+}
+
+// TestExternalSignerSlow tests that the wallet isn't blocked while waiting for
+// the external signer.
+func TestExternalSignerSlow(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for wt.cs.Height() <= types.ASICHardforkHeight {
+		mineSyncedBlock(t, wt)
+	}
+
+	signer := &slowSigner{
+		testSigner: testSigner{seed: modules.Seed{2}},
+		called:     make(chan struct{}),
+		release:    make(chan struct{}),
+	}
+	wt.wallet.SetExternalSigner(signer)
+
+	// waitForSigner waits for the signer to be called, checks that the wallet
+	// still reports its height and processes new blocks and then lets the
+	// signer answer.
+	waitForSigner := func() {
+		t.Helper()
+		select {
+		case <-signer.called:
+		case <-time.After(10 * time.Second):
+			t.Fatal("signer wasn't called")
+		}
+		done := make(chan error)
+		go func() {
+			if _, err := wt.wallet.Height(); err != nil {
+				done <- err
+				return
+			}
+			b, err := wt.miner.FindBlock()
+			if err != nil {
+				done <- err
+				return
+			}
+			if err := wt.cs.AcceptBlock(b); err != nil {
+				done <- err
+				return
+			}
+			height, err := wt.wallet.Height()
+			if err == nil && height != wt.cs.Height() {
+				err = fmt.Errorf("wallet didn't process block: %v != %v", height, wt.cs.Height())
+			}
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("wallet is blocked by the external signer")
+		}
+		signer.release <- struct{}{}
+	}
+
+	// Create a signer address.
+	saChan := make(chan modules.SignerAddress, 1)
+	errChan := make(chan error, 1)
+	go func() {
+		sa, err := wt.wallet.NewSignerAddress()
+		saChan <- sa
+		errChan <- err
+	}()
+	waitForSigner()
+	sa := <-saChan
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+
+	// Fund the address.
+	if _, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(10), sa.Address); err != nil {
+		t.Fatal(err)
+	}
+	mineSyncedBlock(t, wt)
+	uos, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []types.SiacoinOutputID
+	for _, uo := range uos {
+		if uo.UnlockHash == sa.Address && uo.ConfirmationHeight <= wt.cs.Height() {
+			ids = append(ids, types.SiacoinOutputID(uo.ID))
+		}
+	}
+	if len(ids) != 1 {
+		t.Fatal("expected 1 signer output but got", len(ids))
+	}
+
+	// Spend the output of the address.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_, err := wt.wallet.SendSiacoinsCoinControl(types.SiacoinPrecision, uc.UnlockHash(), false, modules.CoinControl{Inputs: ids})
+		errChan <- err
+	}()
+	waitForSigner()
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	if signer.signed != 1 {
+		t.Fatal("signer wasn't asked to sign the input", signer.signed)
+	}
+}
@@ -0,0 +1,184 @@
+package wallet
+
+import (
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errInvalidTimelockedAddress is returned if the UnlockConditions of a
+	// timelocked address of the wallet don't consist of a single public key
+	// and a timelock.
+	errInvalidTimelockedAddress = errors.New("timelocked addresses require a timelock and exactly one public key and signature")
+
+	// errNoTimelockedOutputs is returned when sending timelocked siacoins
+	// without any outputs.
+	errNoTimelockedOutputs = errors.New("no timelocked outputs provided")
+
+	// errTimelockExpired is returned when sending to a timelocked address
+	// whose timelock has already expired.
+	errTimelockExpired = errors.New("timelock of the address has already expired")
+
+	// errUnknownTimelockedKey is returned if the public key of a timelocked
+	// address doesn't belong to the wallet.
+	errUnknownTimelockedKey = errors.New("public key of the timelocked address doesn't belong to the wallet")
+)
+
+// integrateTimelockedAddress adds the spendable key of a timelocked address
+// to the wallet. The key must have been integrated without the timelock
+// already.
+func (w *Wallet) integrateTimelockedAddress(uc types.UnlockConditions) bool {
+	sk, ok := w.seedSecretKey(uc.PublicKeys[0])
+	if !ok {
+		return false
+	}
+	w.keys[uc.UnlockHash()] = spendableKey{
+		UnlockConditions: uc,
+		SecretKeys:       []crypto.SecretKey{sk},
+	}
+	return true
+}
+
+// AddTimelockedAddresses instructs the wallet to track timelocked addresses
+// of its own keys, e.g. the addresses of a vesting schedule created by a
+// sender. If none of the addresses have appeared in the blockchain, the
+// unused flag may be set to true. Otherwise, the wallet must rescan the
+// blockchain to find their outputs.
+func (w *Wallet) AddTimelockedAddresses(ucs []types.UnlockConditions, unused bool) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	for _, uc := range ucs {
+		if uc.Timelock == 0 || len(uc.PublicKeys) != 1 || uc.SignaturesRequired != 1 {
+			return errInvalidTimelockedAddress
+		}
+	}
+
+	err := func() error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return modules.ErrLockedWallet
+		}
+		for _, uc := range ucs {
+			if _, exists := w.keys[uc.UnlockHash()]; exists {
+				continue
+			}
+			if !w.integrateTimelockedAddress(uc) {
+				return errors.AddContext(errUnknownTimelockedKey, uc.PublicKeys[0].String())
+			}
+			if err := dbPutTimelockedAddress(w.dbTx, uc); err != nil {
+				return errors.AddContext(err, "failed to store timelocked address")
+			}
+		}
+		if !unused {
+			// prepare to rescan
+			if err := w.resetTransactionHistory(modules.ConsensusChangeBeginning, 0); err != nil {
+				return err
+			}
+		}
+		return w.syncDB()
+	}()
+	if err != nil {
+		return err
+	}
+
+	if !unused {
+		return w.managedRescan(modules.ConsensusChangeBeginning)
+	}
+	return nil
+}
+
+// NewTimelockedAddress returns the UnlockConditions of a new address of the
+// wallet which can't be spent from before the timelock.
+func (w *Wallet) NewTimelockedAddress(timelock types.BlockHeight) (types.UnlockConditions, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.UnlockConditions{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if timelock == 0 {
+		return types.UnlockConditions{}, errInvalidTimelockedAddress
+	}
+
+	uc, err := w.NextAddress()
+	if err != nil {
+		return types.UnlockConditions{}, err
+	}
+	uc.Timelock = timelock
+	// The address is new, so it can't have appeared in the blockchain yet.
+	if err := w.AddTimelockedAddresses([]types.UnlockConditions{uc}, true); err != nil {
+		return types.UnlockConditions{}, err
+	}
+	return uc, nil
+}
+
+// SendTimelockedSiacoins sends siacoins to the timelocked addresses described
+// by the outputs' UnlockConditions.
+func (w *Wallet) SendTimelockedSiacoins(outputs []modules.TimelockedOutput) ([]types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if len(outputs) == 0 {
+		return nil, errNoTimelockedOutputs
+	}
+
+	height, err := w.Height()
+	if err != nil {
+		return nil, err
+	}
+	scos := make([]types.SiacoinOutput, 0, len(outputs))
+	for _, o := range outputs {
+		if o.UnlockConditions.Timelock <= height {
+			return nil, errors.AddContext(errTimelockExpired, o.UnlockConditions.UnlockHash().String())
+		}
+		scos = append(scos, types.SiacoinOutput{
+			Value:      o.Value,
+			UnlockHash: o.UnlockConditions.UnlockHash(),
+		})
+	}
+	return w.SendSiacoinsMulti(scos)
+}
+
+// TimelockedBalances returns the value of the wallet's confirmed outputs
+// which are still timelocked, grouped by the height at which they unlock.
+func (w *Wallet) TimelockedBalances() ([]modules.TimelockedBalance, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return nil, err
+	}
+	balances := make(map[types.BlockHeight]types.Currency)
+	err = dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		key, ok := w.keys[sco.UnlockHash]
+		if ok && key.UnlockConditions.Timelock > height {
+			balances[key.UnlockConditions.Timelock] = balances[key.UnlockConditions.Timelock].Add(sco.Value)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	tbs := make([]modules.TimelockedBalance, 0, len(balances))
+	for unlockHeight, value := range balances {
+		tbs = append(tbs, modules.TimelockedBalance{
+			UnlockHeight: unlockHeight,
+			Value:        value,
+		})
+	}
+	sort.Slice(tbs, func(i, j int) bool {
+		return tbs[i].UnlockHeight < tbs[j].UnlockHeight
+	})
+	return tbs, nil
+}
@@ -0,0 +1,148 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestTimelockedOutputs tests sending a vesting schedule to timelocked
+// addresses of the wallet and reporting the timelocked balances.
+func TestTimelockedOutputs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	timelocked := func() []modules.TimelockedBalance {
+		tbs, err := wt.wallet.TimelockedBalances()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tbs
+	}
+
+	// Move past the hardfork which changes the replay protection of the
+	// signatures.
+	for wt.cs.Height() <= types.ASICHardforkHeight {
+		mineSyncedBlock(t, wt)
+	}
+
+	// Invalid timelocked addresses are rejected.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, unknownPK := crypto.GenerateKeyPair()
+	invalid := []struct {
+		uc  types.UnlockConditions
+		err error
+	}{
+		{uc, errInvalidTimelockedAddress},
+		{types.UnlockConditions{Timelock: 1, PublicKeys: []types.SiaPublicKey{uc.PublicKeys[0], uc.PublicKeys[0]}, SignaturesRequired: 1}, errInvalidTimelockedAddress},
+		{types.UnlockConditions{Timelock: 1, PublicKeys: []types.SiaPublicKey{types.Ed25519PublicKey(unknownPK)}, SignaturesRequired: 1}, errUnknownTimelockedKey},
+	}
+	for i, test := range invalid {
+		if err := wt.wallet.AddTimelockedAddresses([]types.UnlockConditions{test.uc}, true); !errors.Contains(err, test.err) {
+			t.Fatalf("%v: expected %v but got %v", i, test.err, err)
+		}
+	}
+
+	// Create a vesting schedule for one of the wallet's keys and track it.
+	height := wt.cs.Height()
+	amount := types.SiacoinPrecision.Mul64(30)
+	outputs, err := modules.VestingSchedule(uc.PublicKeys[0], amount, height+3, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ucs []types.UnlockConditions
+	for _, o := range outputs {
+		ucs = append(ucs, o.UnlockConditions)
+	}
+	if err := wt.wallet.AddTimelockedAddresses(ucs, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.SendTimelockedSiacoins(outputs); err != nil {
+		t.Fatal(err)
+	}
+	mineSyncedBlock(t, wt)
+
+	tbs := timelocked()
+	if len(tbs) != 3 {
+		t.Fatal("expected 3 timelocked balances but got", len(tbs))
+	}
+	for i, tb := range tbs {
+		if tb.UnlockHeight != outputs[i].UnlockConditions.Timelock || !tb.Value.Equals(outputs[i].Value) {
+			t.Fatalf("%v: wrong timelocked balance %v", i, tb)
+		}
+	}
+
+	// The timelocked outputs can't be spent yet.
+	timelockedAddrs := make(map[types.UnlockHash]struct{})
+	for _, uc := range ucs {
+		timelockedAddrs[uc.UnlockHash()] = struct{}{}
+	}
+	var checked int
+	height = wt.cs.Height()
+	wt.wallet.mu.Lock()
+	err = dbForEachSiacoinOutput(wt.wallet.dbTx, func(id types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if _, ok := timelockedAddrs[sco.UnlockHash]; !ok {
+			return
+		}
+		checked++
+		if err := wt.wallet.checkOutput(wt.wallet.dbTx, height, id, sco, types.ZeroCurrency); !errors.Contains(err, errOutputTimelock) {
+			t.Error("expected errOutputTimelock but got", err)
+		}
+	})
+	wt.wallet.mu.Unlock()
+	if err != nil || checked != 3 {
+		t.Fatal("timelocked outputs weren't checked", checked, err)
+	}
+
+	// The addresses are still tracked after unlocking the wallet again.
+	if err := wt.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	if len(timelocked()) != 3 {
+		t.Fatal("timelocked addresses weren't restored on unlock")
+	}
+
+	// Once the first tranche unlocks, it is no longer reported.
+	for wt.cs.Height() < outputs[0].UnlockConditions.Timelock {
+		mineSyncedBlock(t, wt)
+	}
+	if tbs := timelocked(); len(tbs) != 2 || tbs[0].UnlockHeight != outputs[1].UnlockConditions.Timelock {
+		t.Fatal("unlocked tranche is still reported", tbs)
+	}
+
+	// Sending to an expired timelock is rejected.
+	if _, err := wt.wallet.SendTimelockedSiacoins(outputs[:1]); !errors.Contains(err, errTimelockExpired) {
+		t.Fatal("expected errTimelockExpired but got", err)
+	}
+
+	// New timelocked addresses are tracked immediately.
+	newUC, err := wt.wallet.NewTimelockedAddress(wt.cs.Height() + 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.SendTimelockedSiacoins([]modules.TimelockedOutput{{UnlockConditions: newUC, Value: amount}}); err != nil {
+		t.Fatal(err)
+	}
+	mineSyncedBlock(t, wt)
+	if tbs := timelocked(); len(tbs) != 3 || tbs[2].UnlockHeight != newUC.Timelock || !tbs[2].Value.Equals(amount) {
+		t.Fatal("new timelocked address isn't tracked", tbs)
+	}
+}
@@ -2,7 +2,7 @@ package wallet
 
 import (
 	"bytes"
-	"sort"
+	"fmt"
 
 	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/errors"
@@ -22,6 +22,10 @@ var (
 	// errDustOutput indicates an output is not spendable because it is dust.
 	errDustOutput = errors.New("output is too small")
 
+	// errOutputNotSpendable indicates an output belongs to a watched address or
+	// a multisig account, which the wallet can't spend on its own.
+	errOutputNotSpendable = errors.New("output can't be spent by the wallet's keys alone")
+
 	// errOutputTimelock indicates an output's timelock is still active.
 	errOutputTimelock = errors.New("wallet consensus set height is lower than the output timelock")
 
@@ -108,8 +112,11 @@ func (w *Wallet) checkOutput(tx *bolt.Tx, currentHeight types.BlockHeight, id ty
 			return errSpendHeightTooHigh
 		}
 	}
-	outputUnlockConditions := w.keys[output.UnlockHash].UnlockConditions
-	if currentHeight < outputUnlockConditions.Timelock {
+	key, ok := w.keys[output.UnlockHash]
+	if !ok {
+		return errOutputNotSpendable
+	}
+	if currentHeight < key.UnlockConditions.Timelock {
 		return errOutputTimelock
 	}
 
@@ -190,7 +197,14 @@ func (tb *transactionBuilder) MarkWalletInputs() bool {
 // transaction. A parent transaction may be needed to achieve an input with the
 // correct value. The siacoin input will not be signed until 'Sign' is called
 // on the transaction builder.
-func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
+func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
+	return tb.FundSiacoinsCoinControl(amount, modules.CoinControl{})
+}
+
+// FundSiacoinsCoinControl works like FundSiacoins but selects the outputs to
+// spend according to the provided CoinControl. Explicitly requested inputs are
+// all spent, even if a subset of them would be sufficient.
+func (tb *transactionBuilder) FundSiacoinsCoinControl(amount types.Currency, cc modules.CoinControl) (err error) {
 	if amount.IsZero() {
 		return nil
 	}
@@ -229,28 +243,59 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
 			so.outputs = append(so.outputs, sco)
 		}
 	}
-	sort.Sort(sort.Reverse(so))
+	// If specific inputs were requested, only consider those.
+	explicitInputs := len(cc.Inputs) > 0
+	if explicitInputs {
+		so, err = filterRequestedOutputs(so, cc.Inputs)
+		if err != nil {
+			return err
+		}
+	}
 
-	// Create and fund a parent transaction that will add the correct amount of
-	// siacoins to the transaction.
-	var fund types.Currency
-	// potentialFund tracks the balance of the wallet including outputs that
-	// have been spent in other unconfirmed transactions recently. This is to
-	// provide the user with a more useful error message in the event that they
-	// are overspending.
+	// Filter out the outputs which can't be spent. potentialFund tracks the
+	// balance of the wallet including outputs that have been spent in other
+	// unconfirmed transactions recently. This is to provide the user with a
+	// more useful error message in the event that they are overspending.
 	var potentialFund types.Currency
-	parentTxn := types.Transaction{}
-	var spentScoids []types.SiacoinOutputID
+	var spendable sortedOutputs
 	for i := range so.ids {
 		scoid := so.ids[i]
 		sco := so.outputs[i]
 		// Check that the output can be spent.
-		if err := tb.wallet.checkOutput(tb.wallet.dbTx, consensusHeight, scoid, sco, dustThreshold); err != nil {
+		err := tb.wallet.checkOutput(tb.wallet.dbTx, consensusHeight, scoid, sco, dustThreshold)
+		if _, frozenErr := dbGetFrozenOutput(tb.wallet.dbTx, scoid); err == nil && frozenErr == nil {
+			err = errOutputFrozen
+		}
+		if err != nil && explicitInputs {
+			return errors.AddContext(err, fmt.Sprintf("unable to spend output %v", scoid))
+		} else if err != nil {
 			if errors.Contains(err, errSpendHeightTooHigh) {
 				potentialFund = potentialFund.Add(sco.Value)
 			}
 			continue
 		}
+		spendable.ids = append(spendable.ids, scoid)
+		spendable.outputs = append(spendable.outputs, sco)
+		potentialFund = potentialFund.Add(sco.Value)
+	}
+
+	// Select the outputs to spend.
+	selected := spendable
+	if !explicitInputs {
+		selected, err = selectOutputs(spendable, amount, cc.Strategy)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Create and fund a parent transaction that will add the correct amount of
+	// siacoins to the transaction.
+	var fund types.Currency
+	parentTxn := types.Transaction{}
+	var spentScoids []types.SiacoinOutputID
+	for i := range selected.ids {
+		scoid := selected.ids[i]
+		sco := selected.outputs[i]
 
 		// Add a siacoin input for this output.
 		sci := types.SiacoinInput{
@@ -262,10 +307,6 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
 
 		// Add the output to the total fund
 		fund = fund.Add(sco.Value)
-		potentialFund = potentialFund.Add(sco.Value)
-		if fund.Cmp(amount) >= 0 {
-			break
-		}
 	}
 	if potentialFund.Cmp(amount) >= 0 && fund.Cmp(amount) < 0 {
 		return modules.ErrIncompleteTransactions
@@ -282,7 +323,7 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
 	}
 	defer func() {
 		if err != nil {
-			tb.wallet.managedMarkAddressUnused(parentUnlockConditions)
+			tb.wallet.markAddressUnused(parentUnlockConditions)
 		}
 	}()
 
@@ -300,7 +341,7 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
 		}
 		defer func() {
 			if err != nil {
-				tb.wallet.managedMarkAddressUnused(refundUnlockConditions)
+				tb.wallet.markAddressUnused(refundUnlockConditions)
 			}
 		}()
 		refundOutput := types.SiacoinOutput{
@@ -310,9 +351,27 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
 		parentTxn.SiacoinOutputs = append(parentTxn.SiacoinOutputs, refundOutput)
 	}
 
+	// Mark all outputs that are spent as spent. This is done before signing
+	// to prevent other transactions from spending them while the wallet waits
+	// for the external signer.
+	for _, scoid := range spentScoids {
+		err = dbPutSpentOutput(tb.wallet.dbTx, types.OutputID(scoid), consensusHeight)
+		if err != nil {
+			return err
+		}
+	}
+	defer func() {
+		if err != nil {
+			for _, scoid := range spentScoids {
+				dbDeleteSpentOutput(tb.wallet.dbTx, types.OutputID(scoid))
+			}
+		}
+	}()
+
 	// Sign all of the inputs to the parent transaction.
-	for _, sci := range parentTxn.SiacoinInputs {
-		addSignatures(&parentTxn, types.FullCoveredFields, sci.UnlockConditions, crypto.Hash(sci.ParentID), tb.wallet.keys[sci.UnlockConditions.UnlockHash()], consensusHeight)
+	err = tb.wallet.signParentInputs(&parentTxn, consensusHeight)
+	if err != nil {
+		return err
 	}
 	// Mark the parent output as spent. Must be done after the transaction is
 	// finished because otherwise the txid and output id will change.
@@ -330,14 +389,6 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
 	tb.parents = append(tb.parents, parentTxn)
 	tb.siacoinInputs = append(tb.siacoinInputs, len(tb.transaction.SiacoinInputs))
 	tb.transaction.SiacoinInputs = append(tb.transaction.SiacoinInputs, newInput)
-
-	// Mark all outputs that were spent as spent.
-	for _, scoid := range spentScoids {
-		err = dbPutSpentOutput(tb.wallet.dbTx, types.OutputID(scoid), consensusHeight)
-		if err != nil {
-			return err
-		}
-	}
 	return nil
 }
 
@@ -461,9 +512,27 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) (err error) {
 		parentTxn.SiafundOutputs = append(parentTxn.SiafundOutputs, refundOutput)
 	}
 
+	// Mark all outputs that are spent as spent. This is done before signing
+	// to prevent other transactions from spending them while the wallet waits
+	// for the external signer.
+	for _, sfoid := range spentSfoids {
+		err = dbPutSpentOutput(tb.wallet.dbTx, types.OutputID(sfoid), consensusHeight)
+		if err != nil {
+			return err
+		}
+	}
+	defer func() {
+		if err != nil {
+			for _, sfoid := range spentSfoids {
+				dbDeleteSpentOutput(tb.wallet.dbTx, types.OutputID(sfoid))
+			}
+		}
+	}()
+
 	// Sign all of the inputs to the parent transaction.
-	for _, sfi := range parentTxn.SiafundInputs {
-		addSignatures(&parentTxn, types.FullCoveredFields, sfi.UnlockConditions, crypto.Hash(sfi.ParentID), tb.wallet.keys[sfi.UnlockConditions.UnlockHash()], consensusHeight)
+	err = tb.wallet.signParentInputs(&parentTxn, consensusHeight)
+	if err != nil {
+		return err
 	}
 
 	// Add the exact output.
@@ -485,14 +554,6 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) (err error) {
 	tb.parents = append(tb.parents, parentTxn)
 	tb.siafundInputs = append(tb.siafundInputs, len(tb.transaction.SiafundInputs))
 	tb.transaction.SiafundInputs = append(tb.transaction.SiafundInputs, newInput)
-
-	// Mark all outputs that were spent as spent.
-	for _, sfoid := range spentSfoids {
-		err = dbPutSpentOutput(tb.wallet.dbTx, types.OutputID(sfoid), consensusHeight)
-		if err != nil {
-			return err
-		}
-	}
 	return nil
 }
 
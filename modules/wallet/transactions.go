@@ -38,6 +38,7 @@ func (w *Wallet) AddressTransactions(uh types.UnlockHash) (pts []modules.Process
 		}
 		pts = append(pts, pt)
 	}
+	dbLabelTransactions(w.dbTx, pts)
 	return pts, nil
 }
 
@@ -75,6 +76,7 @@ func (w *Wallet) AddressUnconfirmedTransactions(uh types.UnlockHash) (pts []modu
 			pts = append(pts, pt)
 		}
 	}
+	dbLabelTransactions(w.dbTx, pts)
 	return pts, err
 }
 
@@ -97,7 +99,9 @@ func (w *Wallet) Transaction(txid types.TransactionID) (pt modules.ProcessedTran
 	if err != nil {
 		for _, txn := range w.unconfirmedProcessedTransactions {
 			if txn.TransactionID == txid {
-				return txn, true, nil
+				pts := []modules.ProcessedTransaction{txn}
+				dbLabelTransactions(w.dbTx, pts)
+				return pts[0], true, nil
 			}
 		}
 		return modules.ProcessedTransaction{}, false, nil
@@ -105,6 +109,11 @@ func (w *Wallet) Transaction(txid types.TransactionID) (pt modules.ProcessedTran
 
 	// Retrieve the transaction
 	found = encoding.Unmarshal(w.dbTx.Bucket(bucketProcessedTransactions).Get(keyBytes), &pt) == nil
+	if found {
+		pts := []modules.ProcessedTransaction{pt}
+		dbLabelTransactions(w.dbTx, pts)
+		pt = pts[0]
+	}
 	return
 }
 
@@ -212,6 +221,7 @@ func (w *Wallet) Transactions(startHeight, endHeight types.BlockHeight) (pts []m
 			panic("Failed to decode the processed transaction")
 		}
 	}
+	dbLabelTransactions(w.dbTx, pts)
 	return
 }
 
@@ -309,7 +319,9 @@ func (w *Wallet) UnconfirmedTransactions() ([]modules.ProcessedTransaction, erro
 		return nil, err
 	}
 	defer w.tg.Done()
-	w.mu.RLock()
-	defer w.mu.RUnlock()
-	return w.unconfirmedProcessedTransactions, nil
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	pts := append([]modules.ProcessedTransaction(nil), w.unconfirmedProcessedTransactions...)
+	dbLabelTransactions(w.dbTx, pts)
+	return pts, nil
 }
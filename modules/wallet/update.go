@@ -69,11 +69,13 @@ func (w *Wallet) advanceSeedLookahead(index uint64) (bool, error) {
 }
 
 // isWalletAddress is a helper function that checks if an UnlockHash is
-// derived from one of the wallet's spendable keys or is being explicitly watched.
+// derived from one of the wallet's spendable keys, is being explicitly watched
+// or belongs to one of the wallet's multisig accounts.
 func (w *Wallet) isWalletAddress(uh types.UnlockHash) bool {
 	_, spendable := w.keys[uh]
 	_, watchonly := w.watchedAddrs[uh]
-	return spendable || watchonly
+	_, multisig := w.multisigAddrs[uh]
+	return spendable || watchonly || multisig
 }
 
 // updateLookahead uses a consensus change to update the seed progress if one of the outputs
@@ -117,6 +119,10 @@ func (w *Wallet) updateConfirmedSet(tx *bolt.Tx, cc modules.ConsensusChange) err
 		} else {
 			w.log.Println("Wallet has lost a spendable siacoin output:", diff.ID, "::", diff.SiacoinOutput.Value.HumanString())
 			err = dbDeleteSiacoinOutput(tx, diff.ID)
+			if err == nil {
+				// Spent outputs don't need to stay frozen.
+				err = dbDeleteFrozenOutput(tx, diff.ID)
+			}
 		}
 		if err != nil {
 			w.log.Severe("Could not update siacoin output:", err)
@@ -523,6 +529,14 @@ func (w *Wallet) ProcessConsensusChange(cc modules.ConsensusChange) {
 		w.log.Severe("ERROR: failed to apply consensus change:", err)
 		w.dbRollback = true
 	}
+	if err := w.revertSiafundClaims(w.dbTx, cc.RevertedBlocks); err != nil {
+		w.log.Severe("ERROR: failed to revert siafund claims:", err)
+		w.dbRollback = true
+	}
+	if err := w.applySiafundClaims(w.dbTx, cc); err != nil {
+		w.log.Severe("ERROR: failed to apply siafund claims:", err)
+		w.dbRollback = true
+	}
 	if err := dbPutConsensusChangeID(w.dbTx, cc.ID); err != nil {
 		w.log.Severe("ERROR: failed to update consensus change ID:", err)
 		w.dbRollback = true
@@ -532,8 +546,34 @@ func (w *Wallet) ProcessConsensusChange(cc modules.ConsensusChange) {
 		w.dbRollback = true
 	}
 
+	wasSynced := w.synced
+	w.synced = cc.Synced
+	droppedInvoiceAddrs := w.droppedInvoiceAddrs
+	w.droppedInvoiceAddrs = make(map[types.UnlockHash]struct{})
 	if cc.Synced {
+		// Payments made while the wallet wasn't synced aren't part of this
+		// change, which is why all invoices are updated once it is synced.
+		var touched map[types.UnlockHash]struct{}
+		if wasSynced {
+			touched = make(map[types.UnlockHash]struct{})
+			for _, b := range cc.RevertedBlocks {
+				w.addInvoiceAddresses(touched, b.Transactions)
+			}
+			for _, b := range cc.AppliedBlocks {
+				w.addInvoiceAddresses(touched, b.Transactions)
+			}
+			for addr := range droppedInvoiceAddrs {
+				touched[addr] = struct{}{}
+			}
+		}
+		if err := w.updateInvoices(cc.BlockHeight, types.CurrentTimestamp(), touched, true); err != nil {
+			w.log.Println("ERROR: failed to update invoices:", err)
+		}
 		go w.threadedDefragWallet()
+		if len(cc.AppliedBlocks) > 0 {
+			timestamp := cc.AppliedBlocks[len(cc.AppliedBlocks)-1].Timestamp
+			go w.threadedExecutePaymentSchedules(cc.BlockHeight, timestamp)
+		}
 	}
 }
 
@@ -570,11 +610,13 @@ func (w *Wallet) ReceiveUpdatedUnconfirmedTransactions(diff *modules.Transaction
 		newUPT := make([]modules.ProcessedTransaction, 0, len(w.unconfirmedProcessedTransactions))
 		for _, txn := range w.unconfirmedProcessedTransactions {
 			_, exists := droppedTransactions[txn.TransactionID]
-			if !exists {
-				// Transaction was not dropped, add it to the new unconfirmed
-				// transactions.
-				newUPT = append(newUPT, txn)
+			if exists {
+				w.addInvoiceAddresses(w.droppedInvoiceAddrs, []types.Transaction{txn.Transaction})
+				continue
 			}
+			// Transaction was not dropped, add it to the new unconfirmed
+			// transactions.
+			newUPT = append(newUPT, txn)
 		}
 
 		// Set the unconfirmed preocessed transactions to the pruned set.
@@ -649,4 +691,22 @@ func (w *Wallet) ReceiveUpdatedUnconfirmedTransactions(diff *modules.Transaction
 			w.unconfirmedProcessedTransactions = append(w.unconfirmedProcessedTransactions, pt)
 		}
 	}
+
+	// Payments to invoices are only tracked once the confirmed transactions
+	// are up to date. The invoices paid by dropped transactions are updated
+	// with the next consensus change, since the dropped transactions might
+	// have been confirmed by it.
+	if w.synced {
+		touched := make(map[types.UnlockHash]struct{})
+		for _, unconfirmedTxnSet := range diff.AppliedTransactions {
+			w.addInvoiceAddresses(touched, unconfirmedTxnSet.Transactions)
+		}
+		height, err := dbGetConsensusHeight(w.dbTx)
+		if err == nil {
+			err = w.updateInvoices(height, types.CurrentTimestamp(), touched, false)
+		}
+		if err != nil {
+			w.log.Println("ERROR: failed to update invoices:", err)
+		}
+	}
 }
@@ -80,6 +80,17 @@ type Wallet struct {
 	lookahead    map[types.UnlockHash]uint64
 	watchedAddrs map[types.UnlockHash]struct{}
 
+	// multisigAddrs contains the addresses of the multisig accounts. Their
+	// outputs are tracked like those of watched addresses, but the wallet can
+	// partially sign spends from them.
+	multisigAddrs map[types.UnlockHash]struct{}
+
+	// signerAddrs contains the addresses whose keys are held by the external
+	// signer. Their spendable keys don't contain any secret keys, inputs
+	// spending from them are signed by the signer instead.
+	signerAddrs map[types.UnlockHash]modules.SignerAddress
+	signer      modules.ExternalSigner
+
 	// unconfirmedProcessedTransactions tracks unconfirmed transactions.
 	//
 	// TODO: Replace this field with a linked list. Currently when a new
@@ -109,6 +120,18 @@ type Wallet struct {
 	// initialization.
 	scanLock siasync.TryMutex
 
+	// scheduleMu prevents scheduled payments from being executed
+	// concurrently, which could lead to paying a schedule twice.
+	scheduleMu sync.Mutex
+
+	// signerMu serializes the creation of signer addresses, which release mu
+	// while waiting for the external signer.
+	signerMu sync.Mutex
+
+	// staticAlerter is used to register alerts about failed scheduled
+	// payments.
+	staticAlerter *modules.GenericAlerter
+
 	// The wallet's ThreadGroup tells tracked functions to shut down and
 	// blocks until they have all exited before returning from Close.
 	tg threadgroup.ThreadGroup
@@ -116,6 +139,24 @@ type Wallet struct {
 	// defragDisabled determines if the wallet is set to defrag outputs once it
 	// reaches a certain threshold
 	defragDisabled bool
+
+	// defragPolicy controls when and how the wallet defrags its outputs.
+	defragPolicy modules.DefragPolicy
+
+	// addressGap is the number of unused addresses of the primary seed that
+	// the wallet watches. Zero selects defaultAddressGap.
+	addressGap uint64
+
+	// synced indicates whether the last consensus change processed by the
+	// wallet brought it up to date with a synced consensus set.
+	synced bool
+
+	// droppedInvoiceAddrs are the wallet addresses paid by unconfirmed
+	// transactions which were dropped from the transaction pool. The
+	// transaction pool drops transactions while processing a consensus
+	// change, before the wallet sees the change, so their invoices are
+	// updated once the wallet processed the change.
+	droppedInvoiceAddrs map[types.UnlockHash]struct{}
 }
 
 // Height return the internal processed consensus height of the wallet
@@ -206,10 +247,16 @@ func NewCustomWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, per
 		unusedKeys:   make(map[types.UnlockHash]types.UnlockConditions),
 		watchedAddrs: make(map[types.UnlockHash]struct{}),
 
-		unconfirmedSets: make(map[modules.TransactionSetID][]types.TransactionID),
+		multisigAddrs: make(map[types.UnlockHash]struct{}),
+		signerAddrs:   make(map[types.UnlockHash]modules.SignerAddress),
+
+		unconfirmedSets:     make(map[modules.TransactionSetID][]types.TransactionID),
+		droppedInvoiceAddrs: make(map[types.UnlockHash]struct{}),
 
 		persistDir: persistDir,
 
+		staticAlerter: modules.NewAlerter("wallet"),
+
 		deps: deps,
 	}
 	err := w.initPersist()
@@ -277,22 +324,39 @@ func (w *Wallet) Settings() (modules.WalletSettings, error) {
 		return modules.WalletSettings{}, modules.ErrWalletShutdown
 	}
 	defer w.tg.Done()
-	return modules.WalletSettings{
-		NoDefrag: w.defragDisabled,
-	}, nil
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.settings(), nil
 }
 
-// SetSettings will update the settings for the wallet.
+// SetSettings will update the settings for the wallet. The settings are
+// persisted in the wallet's database.
 func (w *Wallet) SetSettings(s modules.WalletSettings) error {
 	if err := w.tg.Add(); err != nil {
 		return modules.ErrWalletShutdown
 	}
 	defer w.tg.Done()
+	if err := validateDefragPolicy(s.DefragPolicy); err != nil {
+		return err
+	}
+	if s.AddressGap > maxAddressGap {
+		return errInvalidAddressGap
+	}
 
 	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := dbPutWalletSettings(w.dbTx, s); err != nil {
+		return errors.AddContext(err, "failed to store wallet settings")
+	}
 	w.defragDisabled = s.NoDefrag
-	w.mu.Unlock()
-	return nil
+	w.defragPolicy = s.DefragPolicy
+	if s.AddressGap != w.addressGap {
+		w.addressGap = s.AddressGap
+		if err := w.resizeLookahead(); err != nil {
+			return err
+		}
+	}
+	return w.syncDB()
 }
 
 // managedCanSpendUnlockHash returns true if and only if the the wallet has keys to spend from
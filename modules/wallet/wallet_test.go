@@ -325,7 +325,7 @@ func TestLookaheadGeneration(t *testing.T) {
 	}
 
 	actualKeys := uint64(len(wt.wallet.lookahead))
-	expectedKeys := maxLookahead(progress)
+	expectedKeys := maxLookahead(progress, defaultAddressGap)
 	if actualKeys != expectedKeys {
 		t.Errorf("expected len(lookahead) == %d but was %d", actualKeys, expectedKeys)
 	}
@@ -353,7 +353,7 @@ func TestLookaheadGeneration(t *testing.T) {
 	}
 
 	actualKeys = uint64(len(wt.wallet.lookahead))
-	expectedKeys = maxLookahead(progress)
+	expectedKeys = maxLookahead(progress, defaultAddressGap)
 	if actualKeys != expectedKeys {
 		t.Errorf("expected len(lookahead) == %d but was %d", actualKeys, expectedKeys)
 	}
@@ -0,0 +1,81 @@
+package modules
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/types"
+)
+
+// TestDefragPolicyAllowedAt probes the AllowedAt method of the DefragPolicy.
+func TestDefragPolicyAllowedAt(t *testing.T) {
+	// 2020-01-01 is a Wednesday.
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2020, 1, day, hour, min, 0, 0, time.Local)
+	}
+	office := DefragWindow{Start: "09:00", End: "17:00"}
+	night := DefragWindow{Days: []time.Weekday{time.Wednesday}, Start: "22:00", End: "06:00"}
+
+	tests := []struct {
+		windows []DefragWindow
+		t       time.Time
+		allowed bool
+	}{
+		{nil, at(1, 12, 0), true},
+		{[]DefragWindow{office}, at(1, 9, 0), true},
+		{[]DefragWindow{office}, at(1, 16, 59), true},
+		{[]DefragWindow{office}, at(1, 17, 0), false},
+		{[]DefragWindow{night}, at(1, 23, 30), true},
+		{[]DefragWindow{night}, at(2, 5, 0), true},
+		{[]DefragWindow{night}, at(2, 23, 30), false},
+		{[]DefragWindow{night}, at(1, 12, 0), false},
+		{[]DefragWindow{office, night}, at(1, 12, 0), true},
+	}
+	for i, test := range tests {
+		if (DefragPolicy{Windows: test.windows}).AllowedAt(test.t) != test.allowed {
+			t.Errorf("%v: expected allowed to be %v", i, test.allowed)
+		}
+	}
+}
+
+// TestVestingSchedule probes the VestingSchedule function.
+func TestVestingSchedule(t *testing.T) {
+	pk := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: make([]byte, 32)}
+	outputs, err := VestingSchedule(pk, types.NewCurrency64(100), 10, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 3 {
+		t.Fatal("wrong number of tranches", len(outputs))
+	}
+	for i, o := range outputs {
+		if o.UnlockConditions.Timelock != types.BlockHeight(10+5*i) {
+			t.Errorf("%v: wrong timelock %v", i, o.UnlockConditions.Timelock)
+		}
+		if len(o.UnlockConditions.PublicKeys) != 1 || o.UnlockConditions.SignaturesRequired != 1 {
+			t.Errorf("%v: wrong unlock conditions", i)
+		}
+	}
+	// The last tranche receives the remainder.
+	if !outputs[0].Value.Equals64(33) || !outputs[1].Value.Equals64(33) || !outputs[2].Value.Equals64(34) {
+		t.Fatal("wrong tranche values", outputs)
+	}
+
+	invalid := []struct {
+		amount             types.Currency
+		interval, tranches uint64
+	}{
+		{types.NewCurrency64(100), 5, 0},
+		{types.NewCurrency64(100), 0, 2},
+		{types.NewCurrency64(1), 5, 2},
+	}
+	for i, test := range invalid {
+		if _, err := VestingSchedule(pk, test.amount, 10, types.BlockHeight(test.interval), test.tranches); err != ErrInvalidVestingSchedule {
+			t.Errorf("%v: expected ErrInvalidVestingSchedule but got %v", i, err)
+		}
+	}
+	// A single tranche doesn't need an interval.
+	if _, err := VestingSchedule(pk, types.NewCurrency64(100), 10, 0, 1); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -290,9 +290,22 @@ func (c *Client) postRawResponse(resource string, body io.Reader) (http.Header,
 // postRawResponseWithHeaders requests the specified resource and allows to pass
 // custom headers. The response, if provided, will be returned in a byte slice
 func (c *Client) postRawResponseWithHeaders(resource string, body io.Reader, headers http.Header) (http.Header, []byte, error) {
-	req, err := c.NewRequest("POST", resource, body)
+	return c.rawResponseWithHeaders("POST", resource, body, headers)
+}
+
+// patchRawResponse requests the specified resource using a PATCH request. The
+// response, if provided, will be returned in a byte slice
+func (c *Client) patchRawResponse(resource string, body io.Reader) (http.Header, []byte, error) {
+	return c.rawResponseWithHeaders("PATCH", resource, body, http.Header{})
+}
+
+// rawResponseWithHeaders requests the specified resource using the given
+// method and allows to pass custom headers. The response, if provided, will be
+// returned in a byte slice
+func (c *Client) rawResponseWithHeaders(method, resource string, body io.Reader, headers http.Header) (http.Header, []byte, error) {
+	req, err := c.NewRequest(method, resource, body)
 	if err != nil {
-		return http.Header{}, nil, errors.AddContext(err, "failed to construct POST request")
+		return http.Header{}, nil, errors.AddContext(err, "failed to construct "+method+" request")
 	}
 
 	// Decorate the headers on the request object
@@ -305,7 +318,7 @@ func (c *Client) postRawResponseWithHeaders(resource string, body io.Reader, hea
 	httpClient := http.Client{CheckRedirect: c.CheckRedirect}
 	res, err := httpClient.Do(req)
 	if err != nil {
-		return http.Header{}, nil, errors.AddContext(err, "POST request failed")
+		return http.Header{}, nil, errors.AddContext(err, method+" request failed")
 	}
 	defer drainAndClose(res.Body)
 
@@ -313,13 +326,13 @@ func (c *Client) postRawResponseWithHeaders(resource string, body io.Reader, hea
 	// handling of modules that are not loaded
 	if res.StatusCode == api.StatusModuleNotLoaded || res.StatusCode == api.StatusModuleDisabled {
 		err = errors.Compose(readAPIError(res.Body), api.ErrAPICallNotRecognized)
-		return http.Header{}, nil, errors.AddContext(err, "unable to perform POST on "+resource)
+		return http.Header{}, nil, errors.AddContext(err, "unable to perform "+method+" on "+resource)
 	}
 
 	// If the status code is not 2xx, decode and return the accompanying
 	// api.Error.
 	if res.StatusCode < 200 || res.StatusCode > 299 {
-		return http.Header{}, nil, errors.AddContext(readAPIError(res.Body), "POST request error")
+		return http.Header{}, nil, errors.AddContext(readAPIError(res.Body), method+" request error")
 	}
 
 	if res.StatusCode == http.StatusNoContent {
@@ -2,8 +2,11 @@ package client
 
 import (
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
@@ -68,6 +71,33 @@ const (
 	// HostParamCustomRegistryPath is the locataion of the host's registry on
 	// disk.
 	HostParamCustomRegistryPath = HostParam("customregistrypath")
+	// HostParamDynamicPricing indicates if the host adjusts its storage and
+	// bandwidth prices based on its utilization.
+	HostParamDynamicPricing = HostParam("dynamicpricing")
+	// HostParamPricingDampingFactor is the weight of a new target price when
+	// the host adjusts its prices.
+	HostParamPricingDampingFactor = HostParam("pricingdampingfactor")
+	// HostParamMaxDownloadBandwidthPrice is the max download bandwidth price
+	// of dynamic pricing in hastings/byte.
+	HostParamMaxDownloadBandwidthPrice = HostParam("maxdownloadbandwidthprice")
+	// HostParamMaxStoragePrice is the max storage price of dynamic pricing in
+	// hastings/byte/block.
+	HostParamMaxStoragePrice = HostParam("maxstorageprice")
+	// HostParamMaxUploadBandwidthPrice is the max upload bandwidth price of
+	// dynamic pricing in hastings/byte.
+	HostParamMaxUploadBandwidthPrice = HostParam("maxuploadbandwidthprice")
+	// HostParamPricingTargetBandwidth is the bandwidth in bytes/s at which the
+	// bandwidth prices of dynamic pricing reach their maximum.
+	HostParamPricingTargetBandwidth = HostParam("pricingtargetbandwidth")
+	// HostParamMaxBandwidthPerMinute is the number of bytes a single renter
+	// can transfer per minute.
+	HostParamMaxBandwidthPerMinute = HostParam("maxbandwidthperminute")
+	// HostParamMaxConcurrentPrograms is the number of programs a single
+	// renter can execute in parallel.
+	HostParamMaxConcurrentPrograms = HostParam("maxconcurrentprograms")
+	// HostParamMaxRequestsPerMinute is the number of requests a single renter
+	// can send per minute.
+	HostParamMaxRequestsPerMinute = HostParam("maxrequestsperminute")
 )
 
 // HostAnnouncePost uses the /host/announce endpoint to announce the host to
@@ -84,6 +114,28 @@ func (c *Client) HostAnnounceAddrPost(address modules.NetAddress) (err error) {
 	return
 }
 
+// HostAccountsGet requests the /host/accounts endpoint.
+func (c *Client) HostAccountsGet() (hag api.HostAccountsGET, err error) {
+	err = c.get("/host/accounts", &hag)
+	return
+}
+
+// HostAccountCapPost uses the /host/accounts/:id/cap endpoint to cap the
+// balance of an ephemeral account.
+func (c *Client) HostAccountCapPost(id modules.AccountID, maxBalance types.Currency) (err error) {
+	values := url.Values{}
+	values.Set("maxbalance", maxBalance.String())
+	err = c.post(fmt.Sprintf("/host/accounts/%s/cap", id.SPK().String()), values.Encode(), nil)
+	return
+}
+
+// HostAccountExpirePost uses the /host/accounts/:id/expire endpoint to expire
+// an ephemeral account.
+func (c *Client) HostAccountExpirePost(id modules.AccountID) (err error) {
+	err = c.post(fmt.Sprintf("/host/accounts/%s/expire", id.SPK().String()), "", nil)
+	return
+}
+
 // HostContractInfoGet uses the /host/contracts endpoint to get information
 // about contracts on the host.
 func (c *Client) HostContractInfoGet() (cg api.ContractInfoGET, err error) {
@@ -123,6 +175,82 @@ func (c *Client) HostBandwidthGet() (gbg api.GatewayBandwidthGET, err error) {
 	return
 }
 
+// HostFinancialsGet requests the /host/financials endpoint. Zero times are
+// omitted from the query.
+func (c *Client) HostFinancialsGet(start, end time.Time) (hfg api.HostFinancialsGET, err error) {
+	values := url.Values{}
+	if !start.IsZero() {
+		values.Set("start", strconv.FormatInt(start.Unix(), 10))
+	}
+	if !end.IsZero() {
+		values.Set("end", strconv.FormatInt(end.Unix(), 10))
+	}
+	err = c.get("/host/financials?"+values.Encode(), &hfg)
+	return
+}
+
+// HostMigrationExportGet requests the /host/migration/export endpoint. The
+// caller is responsible for closing the returned reader.
+func (c *Client) HostMigrationExportGet() (io.ReadCloser, error) {
+	_, body, err := c.getReaderResponse("/host/migration/export")
+	return body, err
+}
+
+// HostMigrationImportPost uses the /host/migration/import endpoint to import a
+// migration archive created by another host.
+func (c *Client) HostMigrationImportPost(archive io.Reader) (err error) {
+	headers := http.Header{"Content-Type": []string{"application/x-tar"}}
+	_, _, err = c.postRawResponseWithHeaders("/host/migration/import", archive, headers)
+	return
+}
+
+// HostPricingGet requests the /host/pricing endpoint.
+func (c *Client) HostPricingGet() (hpg api.HostPricingGET, err error) {
+	err = c.get("/host/pricing", &hpg)
+	return
+}
+
+// HostRegistryGet requests the /host/registry endpoint to get a page of the
+// host's registry entries.
+func (c *Client) HostRegistryGet(offset, limit uint64) (hrg api.HostRegistryGET, err error) {
+	values := url.Values{}
+	values.Set("offset", strconv.FormatUint(offset, 10))
+	values.Set("limit", strconv.FormatUint(limit, 10))
+	err = c.get("/host/registry?"+values.Encode(), &hrg)
+	return
+}
+
+// HostRegistryExportGet requests the /host/registry/export endpoint.
+func (c *Client) HostRegistryExportGet() (export modules.HostRegistryExport, err error) {
+	err = c.get("/host/registry/export", &export)
+	return
+}
+
+// HostRegistryLimitsGet requests the /host/registry/limits endpoint.
+func (c *Client) HostRegistryLimitsGet() (hrlg api.HostRegistryLimitsGET, err error) {
+	err = c.get("/host/registry/limits", &hrlg)
+	return
+}
+
+// HostRegistryLimitsPost uses the /host/registry/limits endpoint to limit the
+// number of registry entries a public key can register.
+func (c *Client) HostRegistryLimitsPost(pubKey types.SiaPublicKey, limit uint64) (err error) {
+	values := url.Values{}
+	values.Set("publickey", pubKey.String())
+	values.Set("limit", strconv.FormatUint(limit, 10))
+	err = c.post("/host/registry/limits", values.Encode(), nil)
+	return
+}
+
+// HostRegistryLimitsRemovePost uses the /host/registry/limits/remove endpoint
+// to remove the registry entry limit of a public key.
+func (c *Client) HostRegistryLimitsRemovePost(pubKey types.SiaPublicKey) (err error) {
+	values := url.Values{}
+	values.Set("publickey", pubKey.String())
+	err = c.post("/host/registry/limits/remove", values.Encode(), nil)
+	return
+}
+
 // HostStorageFoldersAddPost uses the /host/storage/folders/add api endpoint to
 // add a storage folder to a host
 func (c *Client) HostStorageFoldersAddPost(path string, size uint64) (err error) {
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -289,6 +290,23 @@ func (c *Client) RenterDownloadGet(siaPath modules.SiaPath, destination string,
 	return modules.DownloadID(h.Get("ID")), nil
 }
 
+// RenterDownloadResumeGet uses the /renter/download endpoint to download a
+// full file to a destination on disk, reusing the chunks which are already
+// present in the destination.
+func (c *Client) RenterDownloadResumeGet(siaPath modules.SiaPath, destination string, async, root bool) (modules.DownloadID, error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("destination", destination)
+	values.Set("resume", fmt.Sprint(true))
+	values.Set("async", fmt.Sprint(async))
+	values.Set("root", fmt.Sprint(root))
+	h, _, err := c.getRawResponse(fmt.Sprintf("/renter/download/%s?%s", sp, values.Encode()))
+	if err != nil {
+		return "", err
+	}
+	return modules.DownloadID(h.Get("ID")), nil
+}
+
 // RenterDownloadInfoGet uses the /renter/downloadinfo endpoint to fetch
 // information about a download from the history.
 func (c *Client) RenterDownloadInfoGet(uid modules.DownloadID) (di api.DownloadInfo, err error) {
@@ -507,6 +525,29 @@ func (c *Client) RenterRenamePost(siaPathOld, siaPathNew modules.SiaPath, root b
 	return
 }
 
+// RenterReencodePost uses the /renter/reencode endpoint to re-encode the file
+// or directory at siaPath to the provided erasure code.
+func (c *Client) RenterReencodePost(siaPath modules.SiaPath, dataPieces, parityPieces uint64, root bool) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("root", fmt.Sprint(root))
+	err = c.post(fmt.Sprintf("/renter/reencode/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterReencodeCancelPost uses the /renter/reencode endpoint to cancel the
+// re-encode of the file or directory at siaPath.
+func (c *Client) RenterReencodeCancelPost(siaPath modules.SiaPath, root bool) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("cancel", "true")
+	values.Set("root", fmt.Sprint(root))
+	err = c.post(fmt.Sprintf("/renter/reencode/%s", sp), values.Encode(), nil)
+	return
+}
+
 // RenterSetStreamCacheSizePost uses the /renter endpoint to change the renter's
 // streamCacheSize for streaming
 func (c *Client) RenterSetStreamCacheSizePost(cacheSize uint64) (err error) {
@@ -516,6 +557,18 @@ func (c *Client) RenterSetStreamCacheSizePost(cacheSize uint64) (err error) {
 	return
 }
 
+// RenterPostBandwidthSchedule uses the /renter endpoint to set the renter's
+// bandwidth schedule.
+func (c *Client) RenterPostBandwidthSchedule(schedule modules.BandwidthSchedule) error {
+	b, err := json.Marshal(schedule)
+	if err != nil {
+		return err
+	}
+	values := url.Values{}
+	values.Set("bandwidthschedule", string(b))
+	return c.post("/renter", values.Encode(), nil)
+}
+
 // RenterSetCheckIPViolationPost uses the /renter endpoint to enable/disable the IP
 // violation check in the renter.
 func (c *Client) RenterSetCheckIPViolationPost(enabled bool) (err error) {
@@ -586,6 +639,19 @@ func (c *Client) RenterUploadForcePost(path string, siaPath modules.SiaPath, dat
 	return
 }
 
+// RenterUploadChecksumPost uses the /renter/upload endpoint to upload a file
+// and to record a checksum of its data.
+func (c *Client) RenterUploadChecksumPost(path string, siaPath modules.SiaPath, dataPieces, parityPieces uint64) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("source", path)
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("checksum", strconv.FormatBool(true))
+	err = c.post(fmt.Sprintf("/renter/upload/%s", sp), values.Encode(), nil)
+	return
+}
+
 // RenterUploadDefaultPost uses the /renter/upload endpoint with default
 // redundancy settings to upload a file.
 func (c *Client) RenterUploadDefaultPost(path string, siaPath modules.SiaPath) (err error) {
@@ -620,6 +686,54 @@ func (c *Client) RenterUploadStreamRepairPost(r io.Reader, siaPath modules.SiaPa
 	return err
 }
 
+// RenterUploadSessionsGet uses the /renter/uploadsessions endpoint to list the
+// renter's upload sessions.
+func (c *Client) RenterUploadSessionsGet() (uss api.RenterUploadSessionsGET, err error) {
+	err = c.get("/renter/uploadsessions", &uss)
+	return
+}
+
+// RenterUploadSessionsCreatePost uses the /renter/uploadsessions/create
+// endpoint to create a resumable upload session for a file of the given
+// length.
+func (c *Client) RenterUploadSessionsCreatePost(siaPath modules.SiaPath, length, dataPieces, parityPieces uint64, force bool) (us api.RenterUploadSessionGET, err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("length", strconv.FormatUint(length, 10))
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("force", strconv.FormatBool(force))
+	err = c.post(fmt.Sprintf("/renter/uploadsessions/create/%s", sp), values.Encode(), &us)
+	return
+}
+
+// RenterUploadSessionsAbortPost uses the /renter/uploadsessions/abort endpoint
+// to abort an upload session.
+func (c *Client) RenterUploadSessionsAbortPost(id modules.UploadSessionID) (err error) {
+	err = c.post(fmt.Sprintf("/renter/uploadsessions/abort/%s", id), "", nil)
+	return
+}
+
+// RenterUploadSessionGet uses the /renter/uploadsession endpoint to fetch an
+// upload session and its committed offset.
+func (c *Client) RenterUploadSessionGet(id modules.UploadSessionID) (us api.RenterUploadSessionGET, err error) {
+	err = c.get(fmt.Sprintf("/renter/uploadsession/%s", id), &us)
+	return
+}
+
+// RenterUploadSessionPatch uses the /renter/uploadsession endpoint to write the
+// data from r to an upload session at the given offset.
+func (c *Client) RenterUploadSessionPatch(id modules.UploadSessionID, offset uint64, r io.Reader) (us api.RenterUploadSessionGET, err error) {
+	values := url.Values{}
+	values.Set("offset", strconv.FormatUint(offset, 10))
+	_, resp, err := c.patchRawResponse(fmt.Sprintf("/renter/uploadsession/%s?%s", id, values.Encode()), r)
+	if err != nil {
+		return api.RenterUploadSessionGET{}, err
+	}
+	err = json.Unmarshal(resp, &us)
+	return
+}
+
 // RenterDirCreatePost uses the /renter/dir/ endpoint to create a directory for the
 // renter
 func (c *Client) RenterDirCreatePost(siaPath modules.SiaPath) (err error) {
@@ -661,6 +775,17 @@ func (c *Client) RenterDirRenamePost(siaPath, newSiaPath modules.SiaPath) (err e
 	return
 }
 
+// RenterDirSetPriorityPost uses the /renter/dir/ endpoint to set the repair
+// priority of a directory for the renter
+func (c *Client) RenterDirSetPriorityPost(siaPath modules.SiaPath, priority modules.RepairPriority) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "setpriority")
+	values.Set("priority", priority.String())
+	err = c.post(fmt.Sprintf("/renter/dir/%s", sp), values.Encode(), nil)
+	return
+}
+
 // RenterDirRootGet uses the /renter/dir/ endpoint to query a directory,
 // starting from the root path.
 func (c *Client) RenterDirRootGet(siaPath modules.SiaPath) (rd api.RenterDirectory, err error) {
@@ -1,11 +1,13 @@
 package client
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/url"
 	"strconv"
 
+	"gitlab.com/NebulousLabs/encoding"
 	mnemonics "gitlab.com/NebulousLabs/entropy-mnemonics"
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/crypto"
@@ -70,6 +72,15 @@ func (c *Client) WalletVerifyPasswordSeedGet(seed modules.Seed) (wvpg api.Wallet
 	return
 }
 
+// WalletDefragPost uses the /wallet/defrag endpoint to consolidate the
+// wallet's outputs. If dryRun is set, the defrag is only simulated.
+func (c *Client) WalletDefragPost(dryRun bool) (wdp api.WalletDefragPOST, err error) {
+	values := url.Values{}
+	values.Set("dryrun", fmt.Sprint(dryRun))
+	err = c.post("/wallet/defrag", values.Encode(), &wdp)
+	return
+}
+
 // WalletInitPost uses the /wallet/init endpoint to initialize and encrypt a
 // wallet
 func (c *Client) WalletInitPost(password string, force bool) (wip api.WalletInitPOST, err error) {
@@ -105,12 +116,100 @@ func (c *Client) WalletLastAddressesGet(count uint64) (wag api.WalletAddressesGE
 	return
 }
 
+// WalletLabelsGet uses the /wallet/labels endpoint to get the labels of the
+// wallet's transactions and addresses.
+func (c *Client) WalletLabelsGet() (wlg api.WalletLabelsGET, err error) {
+	err = c.get("/wallet/labels", &wlg)
+	return
+}
+
+// WalletTransactionLabelPost uses the /wallet/labels endpoint to set the label
+// and memo of a transaction.
+func (c *Client) WalletTransactionLabelPost(txid types.TransactionID, label, memo string) error {
+	values := url.Values{}
+	values.Set("transactionid", txid.String())
+	values.Set("label", label)
+	values.Set("memo", memo)
+	return c.post("/wallet/labels", values.Encode(), nil)
+}
+
+// WalletAddressLabelPost uses the /wallet/labels endpoint to set the label and
+// memo of an address.
+func (c *Client) WalletAddressLabelPost(addr types.UnlockHash, label, memo string) error {
+	values := url.Values{}
+	values.Set("address", addr.String())
+	values.Set("label", label)
+	values.Set("memo", memo)
+	return c.post("/wallet/labels", values.Encode(), nil)
+}
+
 // WalletLockPost uses the /wallet/lock endpoint to lock the wallet.
 func (c *Client) WalletLockPost() (err error) {
 	err = c.post("/wallet/lock", "", nil)
 	return
 }
 
+// WalletMultisigGet uses the /wallet/multisig endpoint to get the multisig
+// accounts of the wallet.
+func (c *Client) WalletMultisigGet() (wmg api.WalletMultisigGET, err error) {
+	err = c.get("/wallet/multisig", &wmg)
+	return
+}
+
+// WalletMultisigPost uses the /wallet/multisig endpoint to register a
+// multisig account with the wallet.
+func (c *Client) WalletMultisigPost(name string, uc types.UnlockConditions, localKeys []types.SiaPublicKey, unused bool) (wmp api.WalletMultisigPOST, err error) {
+	ucJSON, err := json.Marshal(uc)
+	if err != nil {
+		return api.WalletMultisigPOST{}, err
+	}
+	values := url.Values{}
+	values.Set("name", name)
+	values.Set("unlockconditions", string(ucJSON))
+	if len(localKeys) > 0 {
+		keysJSON, err := json.Marshal(localKeys)
+		if err != nil {
+			return api.WalletMultisigPOST{}, err
+		}
+		values.Set("localkeys", string(keysJSON))
+	}
+	values.Set("unused", strconv.FormatBool(unused))
+	err = c.post("/wallet/multisig", values.Encode(), &wmp)
+	return
+}
+
+// WalletMultisigRemovePost uses the /wallet/multisig/remove endpoint to stop
+// tracking a multisig account.
+func (c *Client) WalletMultisigRemovePost(addr types.UnlockHash, unused bool) error {
+	values := url.Values{}
+	values.Set("address", addr.String())
+	values.Set("unused", strconv.FormatBool(unused))
+	return c.post("/wallet/multisig/remove", values.Encode(), nil)
+}
+
+// WalletMultisigSignPost uses the /wallet/multisig/sign endpoint to add the
+// wallet's signatures to a transaction spending from a multisig account.
+func (c *Client) WalletMultisigSignPost(txn types.Transaction) (wmtp api.WalletMultisigTransactionPOST, err error) {
+	values := url.Values{}
+	values.Set("transaction", base64.StdEncoding.EncodeToString(encoding.Marshal(txn)))
+	err = c.post("/wallet/multisig/sign", values.Encode(), &wmtp)
+	return
+}
+
+// WalletMultisigSpendPost uses the /wallet/multisig/spend endpoint to build
+// a transaction sending the outputs from a multisig account.
+func (c *Client) WalletMultisigSpendPost(addr types.UnlockHash, outputs []types.SiacoinOutput) (wmtp api.WalletMultisigTransactionPOST, err error) {
+	outputsJSON, err := json.Marshal(outputs)
+	if err != nil {
+		return api.WalletMultisigTransactionPOST{}, err
+	}
+	values := url.Values{}
+	values.Set("address", addr.String())
+	values.Set("outputs", string(outputsJSON))
+	err = c.post("/wallet/multisig/spend", values.Encode(), &wmtp)
+	return
+}
+
 // WalletSeedPost uses the /wallet/seed endpoint to add a seed to the wallet's list
 // of seeds.
 func (c *Client) WalletSeedPost(seed, password string) (err error) {
@@ -121,6 +220,17 @@ func (c *Client) WalletSeedPost(seed, password string) (err error) {
 	return
 }
 
+// WalletSeedStartHeightPost works like WalletSeedPost but skips the blocks
+// before startHeight when scanning for the seed's transactions.
+func (c *Client) WalletSeedStartHeightPost(seed, password string, startHeight types.BlockHeight) (err error) {
+	values := url.Values{}
+	values.Set("seed", seed)
+	values.Set("encryptionpassword", password)
+	values.Set("startheight", fmt.Sprint(startHeight))
+	err = c.post("/wallet/seed", values.Encode(), nil)
+	return
+}
+
 // WalletSeedsGet uses the /wallet/seeds endpoint to return the wallet's
 // current seeds.
 func (c *Client) WalletSeedsGet() (wsg api.WalletSeedsGET, err error) {
@@ -128,10 +238,139 @@ func (c *Client) WalletSeedsGet() (wsg api.WalletSeedsGET, err error) {
 	return
 }
 
+// WalletOutputsFreezePost uses the /wallet/outputs/freeze endpoint to prevent
+// the wallet from spending the specified siacoin outputs.
+func (c *Client) WalletOutputsFreezePost(ids []types.SiacoinOutputID) error {
+	marshaledIDs, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	values := url.Values{}
+	values.Set("ids", string(marshaledIDs))
+	return c.post("/wallet/outputs/freeze", values.Encode(), nil)
+}
+
+// WalletOutputsFrozenGet requests the /wallet/outputs/frozen endpoint and
+// returns the ids of the frozen siacoin outputs.
+func (c *Client) WalletOutputsFrozenGet() (wofg api.WalletOutputsFrozenGET, err error) {
+	err = c.get("/wallet/outputs/frozen", &wofg)
+	return
+}
+
+// WalletOutputsUnfreezePost uses the /wallet/outputs/unfreeze endpoint to
+// allow the wallet to spend the specified siacoin outputs again.
+func (c *Client) WalletOutputsUnfreezePost(ids []types.SiacoinOutputID) error {
+	marshaledIDs, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	values := url.Values{}
+	values.Set("ids", string(marshaledIDs))
+	return c.post("/wallet/outputs/unfreeze", values.Encode(), nil)
+}
+
+// WalletInvoicesGet requests the /wallet/invoices endpoint and returns the
+// invoices of the wallet.
+func (c *Client) WalletInvoicesGet() (wig api.WalletInvoicesGET, err error) {
+	err = c.get("/wallet/invoices", &wig)
+	return
+}
+
+// WalletInvoicesPost uses the /wallet/invoices endpoint to create an invoice
+// for a payment to a new address of the wallet.
+func (c *Client) WalletInvoicesPost(inv modules.Invoice) (wip api.WalletInvoicesPOST, err error) {
+	values := url.Values{}
+	values.Set("amount", inv.Amount.String())
+	values.Set("memo", inv.Memo)
+	values.Set("expiry", fmt.Sprint(inv.Expiry))
+	values.Set("confirmations", fmt.Sprint(inv.RequiredConfirmations))
+	values.Set("callbackurl", inv.CallbackURL)
+	err = c.post("/wallet/invoices", values.Encode(), &wip)
+	return
+}
+
+// WalletInvoicesRemovePost uses the /wallet/invoices/remove endpoint to stop
+// tracking an invoice.
+func (c *Client) WalletInvoicesRemovePost(id crypto.Hash) error {
+	values := url.Values{}
+	values.Set("id", id.String())
+	return c.post("/wallet/invoices/remove", values.Encode(), nil)
+}
+
+// WalletSchedulesGet requests the /wallet/schedules endpoint and returns the
+// payment schedules of the wallet.
+func (c *Client) WalletSchedulesGet() (wsg api.WalletSchedulesGET, err error) {
+	err = c.get("/wallet/schedules", &wsg)
+	return
+}
+
+// WalletSchedulesPost uses the /wallet/schedules endpoint to add a payment
+// schedule to the wallet.
+func (c *Client) WalletSchedulesPost(ps modules.PaymentSchedule) (wsp api.WalletSchedulesPOST, err error) {
+	values := url.Values{}
+	values.Set("amount", ps.Amount.String())
+	values.Set("destination", ps.Destination.String())
+	values.Set("description", ps.Description)
+	values.Set("height", fmt.Sprint(ps.NextHeight))
+	values.Set("timestamp", fmt.Sprint(ps.NextTime))
+	values.Set("interval", fmt.Sprint(ps.Interval))
+	values.Set("maxpayments", fmt.Sprint(ps.MaxPayments))
+	err = c.post("/wallet/schedules", values.Encode(), &wsp)
+	return
+}
+
+// WalletRescanPost uses the /wallet/rescan endpoint to rescan the blockchain
+// starting at the provided height.
+func (c *Client) WalletRescanPost(height types.BlockHeight) (wrp api.WalletRescanPOST, err error) {
+	values := url.Values{}
+	values.Set("height", fmt.Sprint(height))
+	err = c.post("/wallet/rescan", values.Encode(), &wrp)
+	return
+}
+
+// WalletRescanBirthDatePost uses the /wallet/rescan endpoint to rescan the
+// blockchain for a wallet created at the provided birth date.
+func (c *Client) WalletRescanBirthDatePost(birthDate types.Timestamp) (wrp api.WalletRescanPOST, err error) {
+	values := url.Values{}
+	values.Set("birthdate", fmt.Sprint(birthDate))
+	err = c.post("/wallet/rescan", values.Encode(), &wrp)
+	return
+}
+
+// WalletSchedulesRemovePost uses the /wallet/schedules/remove endpoint to
+// remove a payment schedule from the wallet.
+func (c *Client) WalletSchedulesRemovePost(id crypto.Hash) error {
+	values := url.Values{}
+	values.Set("id", id.String())
+	return c.post("/wallet/schedules/remove", values.Encode(), nil)
+}
+
+// WalletSettingsGet uses the /wallet/settings endpoint to get the settings of
+// the wallet.
+func (c *Client) WalletSettingsGet() (wsg api.WalletSettingsGET, err error) {
+	err = c.get("/wallet/settings", &wsg)
+	return
+}
+
+// WalletSettingsPost uses the /wallet/settings endpoint to change the
+// settings of the wallet. Only the provided values are changed.
+func (c *Client) WalletSettingsPost(values url.Values) error {
+	return c.post("/wallet/settings", values.Encode(), nil)
+}
+
 // WalletSiacoinsMultiPost uses the /wallet/siacoin api endpoint to send money
 // to multiple addresses at once
 func (c *Client) WalletSiacoinsMultiPost(outputs []types.SiacoinOutput) (wsp api.WalletSiacoinsPOST, err error) {
-	values := url.Values{}
+	return c.WalletSiacoinsMultiCoinControlPost(outputs, modules.CoinControl{})
+}
+
+// WalletSiacoinsMultiCoinControlPost uses the /wallet/siacoin api endpoint to
+// send money to multiple addresses at once using the provided coin control.
+func (c *Client) WalletSiacoinsMultiCoinControlPost(outputs []types.SiacoinOutput, cc modules.CoinControl) (wsp api.WalletSiacoinsPOST, err error) {
+	values, err := coinControlValues(cc)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
 	marshaledOutputs, err := json.Marshal(outputs)
 	if err != nil {
 		return api.WalletSiacoinsPOST{}, err
@@ -144,7 +383,16 @@ func (c *Client) WalletSiacoinsMultiPost(outputs []types.SiacoinOutput) (wsp api
 // WalletSiacoinsPost uses the /wallet/siacoins api endpoint to send money to a
 // single address
 func (c *Client) WalletSiacoinsPost(amount types.Currency, destination types.UnlockHash, feeIncluded bool) (wsp api.WalletSiacoinsPOST, err error) {
-	values := url.Values{}
+	return c.WalletSiacoinsCoinControlPost(amount, destination, feeIncluded, modules.CoinControl{})
+}
+
+// WalletSiacoinsCoinControlPost uses the /wallet/siacoins api endpoint to send
+// money to a single address using the provided coin control.
+func (c *Client) WalletSiacoinsCoinControlPost(amount types.Currency, destination types.UnlockHash, feeIncluded bool, cc modules.CoinControl) (wsp api.WalletSiacoinsPOST, err error) {
+	values, err := coinControlValues(cc)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
 	values.Set("amount", amount.String())
 	values.Set("destination", destination.String())
 	values.Set("feeIncluded", strconv.FormatBool(feeIncluded))
@@ -152,6 +400,22 @@ func (c *Client) WalletSiacoinsPost(amount types.Currency, destination types.Unl
 	return
 }
 
+// coinControlValues encodes a CoinControl as /wallet/siacoins parameters.
+func coinControlValues(cc modules.CoinControl) (url.Values, error) {
+	values := url.Values{}
+	if len(cc.Inputs) > 0 {
+		marshaledInputs, err := json.Marshal(cc.Inputs)
+		if err != nil {
+			return nil, err
+		}
+		values.Set("inputs", string(marshaledInputs))
+	}
+	if cc.Strategy != "" {
+		values.Set("strategy", string(cc.Strategy))
+	}
+	return values, nil
+}
+
 // WalletSignPost uses the /wallet/sign api endpoint to sign a transaction.
 func (c *Client) WalletSignPost(txn types.Transaction, toSign []crypto.Hash) (wspr api.WalletSignPOSTResp, err error) {
 	json, err := json.Marshal(api.WalletSignPOSTParams{
@@ -175,6 +439,13 @@ func (c *Client) WalletSiafundsPost(amount types.Currency, destination types.Unl
 	return
 }
 
+// WalletSiafundClaimsGet requests the /wallet/siafunds/claims endpoint and
+// returns the claims of the siafund outputs received by the wallet.
+func (c *Client) WalletSiafundClaimsGet() (wscg api.WalletSiafundClaimsGET, err error) {
+	err = c.get("/wallet/siafunds/claims", &wscg)
+	return
+}
+
 // WalletSiagKeyPost uses the /wallet/siagkey endpoint to load a siag key into
 // the wallet.
 func (c *Client) WalletSiagKeyPost(keyfiles, password string) (err error) {
@@ -185,6 +456,20 @@ func (c *Client) WalletSiagKeyPost(keyfiles, password string) (err error) {
 	return
 }
 
+// WalletSignerGet uses the /wallet/signer endpoint to get the addresses of
+// the wallet whose keys are held by the external signer.
+func (c *Client) WalletSignerGet() (wsg api.WalletSignerGET, err error) {
+	err = c.get("/wallet/signer", &wsg)
+	return
+}
+
+// WalletSignerAddressPost uses the /wallet/signer/address endpoint to create
+// a new address of the wallet whose key is held by the external signer.
+func (c *Client) WalletSignerAddressPost() (wsap api.WalletSignerAddressPOST, err error) {
+	err = c.post("/wallet/signer/address", "", &wsap)
+	return
+}
+
 // WalletSweepPost uses the /wallet/sweep/seed endpoint to sweep a seed into
 // the current wallet.
 func (c *Client) WalletSweepPost(seed string) (wsp api.WalletSweepPOST, err error) {
@@ -194,6 +479,53 @@ func (c *Client) WalletSweepPost(seed string) (wsp api.WalletSweepPOST, err erro
 	return
 }
 
+// WalletSweepStartHeightPost works like WalletSweepPost but skips the blocks
+// before startHeight when scanning for the seed's outputs.
+func (c *Client) WalletSweepStartHeightPost(seed string, startHeight types.BlockHeight) (wsp api.WalletSweepPOST, err error) {
+	values := url.Values{}
+	values.Set("seed", seed)
+	values.Set("startheight", fmt.Sprint(startHeight))
+	err = c.post("/wallet/sweep/seed", values.Encode(), &wsp)
+	return
+}
+
+// WalletTimelockAddressPost uses the /wallet/timelock/address endpoint to
+// create a new address of the wallet which is timelocked until the provided
+// height.
+func (c *Client) WalletTimelockAddressPost(timelock types.BlockHeight) (wtap api.WalletTimelockAddressPOST, err error) {
+	values := url.Values{}
+	values.Set("timelock", fmt.Sprint(timelock))
+	err = c.post("/wallet/timelock/address", values.Encode(), &wtap)
+	return
+}
+
+// WalletTimelockSendPost uses the /wallet/timelock/send endpoint to send
+// siacoins to timelocked addresses.
+func (c *Client) WalletTimelockSendPost(outputs []modules.TimelockedOutput) (wtsp api.WalletTimelockSendPOST, err error) {
+	outputsJSON, err := json.Marshal(outputs)
+	if err != nil {
+		return api.WalletTimelockSendPOST{}, err
+	}
+	values := url.Values{}
+	values.Set("outputs", string(outputsJSON))
+	err = c.post("/wallet/timelock/send", values.Encode(), &wtsp)
+	return
+}
+
+// WalletTimelockTrackPost uses the /wallet/timelock/track endpoint to track
+// timelocked addresses of the wallet's keys. The unused flag should be set to
+// true if the addresses have never appeared in the blockchain.
+func (c *Client) WalletTimelockTrackPost(ucs []types.UnlockConditions, unused bool) error {
+	ucsJSON, err := json.Marshal(ucs)
+	if err != nil {
+		return err
+	}
+	values := url.Values{}
+	values.Set("unlockconditions", string(ucsJSON))
+	values.Set("unused", fmt.Sprint(unused))
+	return c.post("/wallet/timelock/track", values.Encode(), nil)
+}
+
 // WalletTransactionsGet requests the/wallet/transactions api resource for a
 // certain startheight and endheight
 func (c *Client) WalletTransactionsGet(startHeight types.BlockHeight, endHeight types.BlockHeight) (wtg api.WalletTransactionsGET, err error) {
@@ -232,6 +564,20 @@ func (c *Client) WalletUnspentGet() (wug api.WalletUnspentGET, err error) {
 	return
 }
 
+// WalletVestingPost uses the /wallet/vesting endpoint to send a vesting
+// schedule of tranches outputs to the public key, which unlock every interval
+// blocks starting at start.
+func (c *Client) WalletVestingPost(pk types.SiaPublicKey, amount types.Currency, start, interval types.BlockHeight, tranches uint64) (wtsp api.WalletTimelockSendPOST, err error) {
+	values := url.Values{}
+	values.Set("publickey", pk.String())
+	values.Set("amount", amount.String())
+	values.Set("start", fmt.Sprint(start))
+	values.Set("interval", fmt.Sprint(interval))
+	values.Set("tranches", fmt.Sprint(tranches))
+	err = c.post("/wallet/vesting", values.Encode(), &wtsp)
+	return
+}
+
 // WalletWatchGet requests the /wallet/watch endpoint and returns the set of
 // currently watched addresses.
 func (c *Client) WalletWatchGet() (wwg api.WalletWatchGET, err error) {
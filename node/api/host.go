@@ -23,6 +23,10 @@ var (
 	// manager.
 	errStorageFolderNotFound = errors.New("storage folder with the provided path could not be found")
 
+	// defaultRegistryPageLimit is the number of registry entries returned by
+	// /host/registry if no limit is specified.
+	defaultRegistryPageLimit = uint64(1000)
+
 	// ErrInvalidRPCDownloadRatio is returned if the user tries to set a value
 	// for the download price or the base RPC Price that violates the maximum
 	// ratio
@@ -35,6 +39,14 @@ var (
 )
 
 type (
+	// HostAccountsGET contains the information that is returned after a GET
+	// request to /host/accounts - the host's ephemeral accounts and aggregate
+	// metrics about them.
+	HostAccountsGET struct {
+		Accounts []modules.HostEphemeralAccount      `json:"accounts"`
+		Metrics  modules.HostEphemeralAccountMetrics `json:"metrics"`
+	}
+
 	// ContractInfoGET contains the information that is returned after a GET request
 	// to /host/contracts - information for the host about stored obligations.
 	ContractInfoGET struct {
@@ -67,6 +79,38 @@ type (
 		ConversionRate float64        `json:"conversionrate"`
 	}
 
+	// HostFinancialsGET contains the information that is returned after a GET
+	// request to /host/financials - the host's daily financial snapshots.
+	HostFinancialsGET struct {
+		Snapshots []modules.HostFinancialSnapshot `json:"snapshots"`
+	}
+
+	// HostPricingGET contains the information that is returned after a GET
+	// request to /host/pricing - the host's current storage and bandwidth
+	// prices and the recent decisions of its dynamic pricing engine.
+	HostPricingGET struct {
+		Enabled                bool                          `json:"enabled"`
+		DownloadBandwidthPrice types.Currency                `json:"downloadbandwidthprice"`
+		StoragePrice           types.Currency                `json:"storageprice"`
+		UploadBandwidthPrice   types.Currency                `json:"uploadbandwidthprice"`
+		Decisions              []modules.HostPricingDecision `json:"decisions"`
+	}
+
+	// HostRegistryGET contains the information that is returned after a GET
+	// request to /host/registry - a page of the host's registry entries and
+	// the total number of entries.
+	HostRegistryGET struct {
+		Entries []modules.HostRegistryEntry `json:"entries"`
+		Total   uint64                      `json:"total"`
+	}
+
+	// HostRegistryLimitsGET contains the information that is returned after a
+	// GET request to /host/registry/limits - the limits of registry entries
+	// set for public keys.
+	HostRegistryLimitsGET struct {
+		Limits []modules.HostRegistryKeyLimit `json:"limits"`
+	}
+
 	// StorageGET contains the information that is returned after a GET request
 	// to /host/storage - a bunch of information about the status of storage
 	// management on the host.
@@ -87,6 +131,15 @@ func RegisterRoutesHost(router *httprouter.Router, h modules.Host, deps modules.
 	router.POST("/host/announce", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		hostAnnounceHandler(h, w, req, ps)
 	}, requiredPassword))
+	router.GET("/host/accounts", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostAccountsHandlerGET(h, w, req, ps)
+	})
+	router.POST("/host/accounts/:id/cap", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostAccountCapHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
+	router.POST("/host/accounts/:id/expire", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostAccountExpireHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
 	router.GET("/host/contracts", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		hostContractInfoHandler(h, w, req, ps)
 	})
@@ -96,6 +149,33 @@ func RegisterRoutesHost(router *httprouter.Router, h modules.Host, deps modules.
 	router.GET("/host/bandwidth", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		hostBandwidthHandlerGET(h, w, req, ps)
 	})
+	router.GET("/host/financials", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostFinancialsHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/migration/export", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostMigrationExportHandlerGET(h, w, req, ps)
+	}, requiredPassword))
+	router.POST("/host/migration/import", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostMigrationImportHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
+	router.GET("/host/pricing", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostPricingHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/registry", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostRegistryHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/registry/export", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostRegistryExportHandlerGET(h, w, req, ps)
+	}, requiredPassword))
+	router.GET("/host/registry/limits", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostRegistryLimitsHandlerGET(h, w, req, ps)
+	})
+	router.POST("/host/registry/limits", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostRegistryLimitsHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
+	router.POST("/host/registry/limits/remove", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostRegistryLimitsRemoveHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
 
 	// Calls pertaining to the storage manager that the host uses.
 	router.GET("/host/storage", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -126,6 +206,54 @@ func folderIndex(folderPath string, storageFolders []modules.StorageFolderMetada
 	return -1, errStorageFolderNotFound
 }
 
+// hostAccountsHandlerGET handles GET requests to the /host/accounts endpoint.
+func hostAccountsHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostAccountsGET{
+		Accounts: host.EphemeralAccounts(),
+		Metrics:  host.EphemeralAccountMetrics(),
+	})
+}
+
+// hostAccountCapHandlerPOST handles POST requests to the
+// /host/accounts/:id/cap endpoint.
+func hostAccountCapHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var id modules.AccountID
+	if err := id.LoadString(ps.ByName("id")); err != nil {
+		WriteError(w, Error{"unable to parse account id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	maxBalanceStr := req.FormValue("maxbalance")
+	if maxBalanceStr == "" {
+		WriteError(w, Error{"maxbalance must be specified"}, http.StatusBadRequest)
+		return
+	}
+	var maxBalance types.Currency
+	if _, err := fmt.Sscan(maxBalanceStr, &maxBalance); err != nil {
+		WriteError(w, Error{"unable to parse maxbalance: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := host.CapEphemeralAccount(id, maxBalance); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostAccountExpireHandlerPOST handles POST requests to the
+// /host/accounts/:id/expire endpoint.
+func hostAccountExpireHandlerPOST(host modules.Host, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	var id modules.AccountID
+	if err := id.LoadString(ps.ByName("id")); err != nil {
+		WriteError(w, Error{"unable to parse account id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := host.ExpireEphemeralAccount(id); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // hostContractGetHandler handles the API call to get information about a contract.
 func hostContractGetHandler(host modules.Host, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
 	var obligationID types.FileContractID
@@ -203,6 +331,165 @@ func hostBandwidthHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.R
 	})
 }
 
+// hostFinancialsHandlerGET handles GET requests to the /host/financials
+// endpoint.
+func hostFinancialsHandlerGET(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the optional time range.
+	var start, end time.Time
+	if s := req.FormValue("start"); s != "" {
+		var unix int64
+		if _, err := fmt.Sscan(s, &unix); err != nil {
+			WriteError(w, Error{"unable to parse start: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		start = time.Unix(unix, 0)
+	}
+	if e := req.FormValue("end"); e != "" {
+		var unix int64
+		if _, err := fmt.Sscan(e, &unix); err != nil {
+			WriteError(w, Error{"unable to parse end: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		end = time.Unix(unix, 0)
+	}
+
+	snapshots, err := host.FinancialSnapshots(start, end)
+	if err != nil {
+		WriteError(w, Error{"failed to get financial snapshots: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if snapshots == nil {
+		snapshots = make([]modules.HostFinancialSnapshot, 0)
+	}
+	WriteJSON(w, HostFinancialsGET{
+		Snapshots: snapshots,
+	})
+}
+
+// hostMigrationExportHandlerGET handles GET requests to the
+// /host/migration/export endpoint by streaming the host's migration archive.
+func hostMigrationExportHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.Header().Set("Content-Disposition", `attachment; filename="host.tar"`)
+	err := host.ExportMigration(w)
+	if err != nil {
+		// If the archive was partially written already, the error ends up
+		// at the end of the archive which causes the import to fail.
+		WriteError(w, Error{"failed to export host: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+}
+
+// hostMigrationImportHandlerPOST handles POST requests to the
+// /host/migration/import endpoint. The request body is the migration archive.
+func hostMigrationImportHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	err := host.ImportMigration(req.Body)
+	if err != nil {
+		WriteError(w, Error{"failed to import host: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostRegistryHandlerGET handles GET requests to the /host/registry endpoint.
+func hostRegistryHandlerGET(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var offset uint64
+	if offsetStr := req.FormValue("offset"); offsetStr != "" {
+		if _, err := fmt.Sscan(offsetStr, &offset); err != nil {
+			WriteError(w, Error{"unable to parse offset: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	limit := defaultRegistryPageLimit
+	if limitStr := req.FormValue("limit"); limitStr != "" {
+		if _, err := fmt.Sscan(limitStr, &limit); err != nil {
+			WriteError(w, Error{"unable to parse limit: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	entries, total := host.RegistryEntries(offset, limit)
+	WriteJSON(w, HostRegistryGET{
+		Entries: entries,
+		Total:   total,
+	})
+}
+
+// hostRegistryExportHandlerGET handles GET requests to the
+// /host/registry/export endpoint by streaming the exported registry.
+func hostRegistryExportHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="registry.json"`)
+	err := host.ExportRegistry(w)
+	if err != nil {
+		WriteError(w, Error{"failed to export registry: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+}
+
+// hostRegistryLimitsHandlerGET handles GET requests to the
+// /host/registry/limits endpoint.
+func hostRegistryLimitsHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostRegistryLimitsGET{
+		Limits: host.RegistryKeyLimits(),
+	})
+}
+
+// hostRegistryLimitsHandlerPOST handles POST requests to the
+// /host/registry/limits endpoint.
+func hostRegistryLimitsHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var spk types.SiaPublicKey
+	if err := spk.LoadString(req.FormValue("publickey")); err != nil {
+		WriteError(w, Error{"unable to parse publickey: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	limitStr := req.FormValue("limit")
+	if limitStr == "" {
+		WriteError(w, Error{"limit must be specified"}, http.StatusBadRequest)
+		return
+	}
+	var limit uint64
+	if _, err := fmt.Sscan(limitStr, &limit); err != nil {
+		WriteError(w, Error{"unable to parse limit: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := host.SetRegistryKeyLimit(spk, limit); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostRegistryLimitsRemoveHandlerPOST handles POST requests to the
+// /host/registry/limits/remove endpoint.
+func hostRegistryLimitsRemoveHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var spk types.SiaPublicKey
+	if err := spk.LoadString(req.FormValue("publickey")); err != nil {
+		WriteError(w, Error{"unable to parse publickey: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := host.RemoveRegistryKeyLimit(spk); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostPricingHandlerGET handles GET requests to the /host/pricing endpoint.
+func hostPricingHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	es := host.ExternalSettings()
+	decisions := host.PricingDecisions()
+	if decisions == nil {
+		decisions = make([]modules.HostPricingDecision, 0)
+	}
+	WriteJSON(w, HostPricingGET{
+		Enabled:                host.InternalSettings().DynamicPricing.Enabled,
+		DownloadBandwidthPrice: es.DownloadBandwidthPrice,
+		StoragePrice:           es.StoragePrice,
+		UploadBandwidthPrice:   es.UploadBandwidthPrice,
+		Decisions:              decisions,
+	})
+}
+
 // parseHostSettings a request's query strings and returns a
 // modules.HostInternalSettings configured with the request's query string
 // parameters.
@@ -367,6 +654,79 @@ func parseHostSettings(host modules.Host, req *http.Request) (modules.HostIntern
 		settings.CustomRegistryPath = req.FormValue("customregistrypath")
 	}
 
+	if req.FormValue("dynamicpricing") != "" {
+		var x bool
+		_, err := fmt.Sscan(req.FormValue("dynamicpricing"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.DynamicPricing.Enabled = x
+	}
+	if req.FormValue("pricingdampingfactor") != "" {
+		var x float64
+		_, err := fmt.Sscan(req.FormValue("pricingdampingfactor"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.DynamicPricing.DampingFactor = x
+	}
+	if req.FormValue("maxdownloadbandwidthprice") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("maxdownloadbandwidthprice"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.DynamicPricing.MaxDownloadBandwidthPrice = x
+	}
+	if req.FormValue("maxstorageprice") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("maxstorageprice"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.DynamicPricing.MaxStoragePrice = x
+	}
+	if req.FormValue("maxuploadbandwidthprice") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("maxuploadbandwidthprice"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.DynamicPricing.MaxUploadBandwidthPrice = x
+	}
+	if req.FormValue("maxbandwidthperminute") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxbandwidthperminute"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.RenterQuotas.MaxBandwidthPerMinute = x
+	}
+	if req.FormValue("maxconcurrentprograms") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxconcurrentprograms"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.RenterQuotas.MaxConcurrentPrograms = x
+	}
+	if req.FormValue("maxrequestsperminute") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxrequestsperminute"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.RenterQuotas.MaxRequestsPerMinute = x
+	}
+	if req.FormValue("pricingtargetbandwidth") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("pricingtargetbandwidth"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.DynamicPricing.TargetBandwidth = x
+	}
+
 	// Validate the RPC, Sector Access, and Download Prices
 	minBaseRPCPrice := settings.MinBaseRPCPrice
 	maxBaseRPCPrice := settings.MaxBaseRPCPrice()
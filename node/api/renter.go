@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -222,6 +223,17 @@ type (
 		UnsyncedHosts []types.SiaPublicKey   `json:"unsyncedhosts"`
 	}
 
+	// RenterUploadSessionGET contains information about a single upload
+	// session.
+	RenterUploadSessionGET struct {
+		modules.UploadSession
+	}
+
+	// RenterUploadSessionsGET lists the renter's upload sessions.
+	RenterUploadSessionsGET struct {
+		Sessions []modules.UploadSession `json:"sessions"`
+	}
+
 	// RenterUploadReadyGet lists the upload ready status of the renter
 	RenterUploadReadyGet struct {
 		// Ready indicates whether of not the renter is ready to successfully
@@ -944,6 +956,15 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		}
 		settings.MaxUploadSpeed = uploadSpeed
 	}
+	// Scan the bandwidth schedule. (optional parameter)
+	if bs := req.FormValue("bandwidthschedule"); bs != "" {
+		var schedule modules.BandwidthSchedule
+		if err := json.Unmarshal([]byte(bs), &schedule); err != nil {
+			WriteError(w, Error{"unable to parse bandwidthschedule: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.BandwidthSchedule = schedule
+	}
 
 	// Scan the checkforipviolation flag.
 	if ipc := req.FormValue("checkforipviolation"); ipc != "" {
@@ -1426,6 +1447,66 @@ func (api *API) renterRecoveryScanHandlerGET(w http.ResponseWriter, _ *http.Requ
 	})
 }
 
+// renterReencodeHandler handles the API call to re-encode a file or directory
+// to a new erasure code.
+func (api *API) renterReencodeHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Determine whether the user is requesting a user siapath, or a root siapath.
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Rebase the user's input to the user folder if the user is requesting a user siapath.
+	if !root {
+		siaPath, err = rebaseInputSiaPath(siaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Cancel the re-encode if requested.
+	if req.FormValue("cancel") != "" {
+		cancel, err := strconv.ParseBool(req.FormValue("cancel"))
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'cancel' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if cancel {
+			err = api.renter.CancelReencode(siaPath)
+			if err != nil {
+				WriteError(w, Error{"unable to cancel re-encode: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+			WriteSuccess(w)
+			return
+		}
+	}
+
+	// Parse the new erasure code.
+	ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if ec == nil {
+		WriteError(w, Error{errNeedBothDataAndParityPieces.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.renter.Reencode(siaPath, ec)
+	if err != nil {
+		WriteError(w, Error{"unable to re-encode: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterRenameHandler handles the API call to rename a file entry in the
 // renter.
 func (api *API) renterRenameHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -1809,6 +1890,10 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 	// disk if available.
 	disablelocalfetchparam := req.FormValue("disablelocalfetch")
 
+	// resumeparam determines whether chunks already present in the
+	// destination are reused.
+	resumeparam := req.FormValue("resume")
+
 	// Parse the offset and length parameters.
 	var offset, length uint64
 	if len(offsetparam) > 0 {
@@ -1863,6 +1948,14 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 		}
 	}
 
+	var resume bool
+	if resumeparam != "" {
+		resume, err = scanBool(resumeparam)
+		if err != nil {
+			return modules.RenterDownloadParameters{}, errors.AddContext(err, "error parsing the resume flag")
+		}
+	}
+
 	dp := modules.RenterDownloadParameters{
 		Destination:      destination,
 		DisableDiskFetch: disableLocalFetch,
@@ -1870,6 +1963,7 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 		Length:           length,
 		Offset:           offset,
 		SiaPath:          siaPath,
+		Resume:           resume,
 	}
 	if httpresp {
 		dp.Httpwriter = w
@@ -1939,6 +2033,15 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 			return
 		}
 	}
+	// Check whether a checksum of the file should be recorded
+	checksum := false
+	if c := req.FormValue("checksum"); c != "" {
+		checksum, err = strconv.ParseBool(c)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'checksum' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	// Parse the erasure coder.
 	ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"))
 	if err != nil {
@@ -1963,6 +2066,7 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		ErasureCode:         ec,
 		Force:               force,
 		DisablePartialChunk: true, // TODO: remove this
+		Checksum:            checksum,
 
 		// NOTE: can make this an optional param.
 		CipherType: crypto.TypeDefaultRenter,
@@ -2066,6 +2170,19 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 			return
 		}
 	}
+	// Check whether a checksum of the stream should be recorded
+	checksum := false
+	if c := queryForm.Get("checksum"); c != "" {
+		checksum, err = strconv.ParseBool(c)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'checksum' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if repair && checksum {
+		WriteError(w, Error{"can't record a checksum when doing a repair"}, http.StatusBadRequest)
+		return
+	}
 	// Parse the erasure coder.
 	ec, err := parseErasureCodingParameters(queryForm.Get("datapieces"), queryForm.Get("paritypieces"))
 	if err != nil && !repair {
@@ -2093,6 +2210,7 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 		ErasureCode: ec,
 		Force:       force,
 		Repair:      repair,
+		Checksum:    checksum,
 
 		// NOTE: can make this an optional param.
 		CipherType: crypto.TypeDefaultRenter,
@@ -2105,6 +2223,113 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 	WriteSuccess(w)
 }
 
+// renterUploadSessionsHandlerGET handles the API call to list the renter's
+// upload sessions.
+func (api *API) renterUploadSessionsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterUploadSessionsGET{
+		Sessions: api.renter.UploadSessions(),
+	})
+}
+
+// renterUploadSessionsCreateHandlerPOST handles the API call to create a new
+// resumable upload session.
+func (api *API) renterUploadSessionsCreateHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Parse the length.
+	var length uint64
+	_, err := fmt.Sscan(req.FormValue("length"), &length)
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'length' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Check whether existing file should be overwritten
+	force := false
+	if f := req.FormValue("force"); f != "" {
+		force, err = strconv.ParseBool(f)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'force' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Parse the erasure coder.
+	ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err = rebaseInputSiaPath(siaPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	up := modules.FileUploadParams{
+		SiaPath:     siaPath,
+		ErasureCode: ec,
+		Force:       force,
+		CipherType:  crypto.TypeDefaultRenter,
+	}
+	session, err := api.renter.CreateUploadSession(up, length)
+	if err != nil {
+		WriteError(w, Error{"failed to create upload session: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterUploadSessionGET{session})
+}
+
+// renterUploadSessionsAbortHandlerPOST handles the API call to abort an upload
+// session.
+func (api *API) renterUploadSessionsAbortHandlerPOST(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	err := api.renter.AbortUploadSession(modules.UploadSessionID(ps.ByName("id")))
+	if err != nil {
+		WriteError(w, Error{"failed to abort upload session: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterUploadSessionHandlerGET handles the API call to fetch an upload
+// session and its committed offset.
+func (api *API) renterUploadSessionHandlerGET(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	session, err := api.renter.UploadSession(modules.UploadSessionID(ps.ByName("id")))
+	if err != nil {
+		WriteError(w, Error{"failed to fetch upload session: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterUploadSessionGET{session})
+}
+
+// renterUploadSessionHandlerPATCH handles the API call to write the request
+// body to an upload session at the offset specified in the query string. If the
+// offset doesn't match the session's committed offset, StatusConflict is
+// returned.
+func (api *API) renterUploadSessionHandlerPATCH(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	queryForm, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		WriteError(w, Error{"failed to parse query params"}, http.StatusBadRequest)
+		return
+	}
+	var offset uint64
+	_, err = fmt.Sscan(queryForm.Get("offset"), &offset)
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'offset' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	session, err := api.renter.WriteUploadSession(modules.UploadSessionID(ps.ByName("id")), offset, req.Body)
+	if errors.Contains(err, modules.ErrUploadSessionOffsetMismatch) {
+		WriteError(w, Error{fmt.Sprintf("write failed, committed offset is %v: %v", session.Offset, err)}, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("write failed, committed offset is %v: %v", session.Offset, err)}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterUploadSessionGET{session})
+}
+
 // renterValidateSiaPathHandler handles the API call that validates a siapath
 func (api *API) renterValidateSiaPathHandler(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
 	// Try and create a new siapath, this will validate the potential siapath
@@ -2266,6 +2491,21 @@ func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, p
 		WriteSuccess(w)
 		return
 	}
+	if action == "setpriority" {
+		var priority modules.RepairPriority
+		err := priority.FromString(req.FormValue("priority"))
+		if err != nil {
+			WriteError(w, Error{"failed to parse priority: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = api.renter.SetDirPriority(siaPath, priority)
+		if err != nil {
+			WriteError(w, Error{"failed to set directory priority: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
 
 	// Report that no calls were made
 	WriteError(w, Error{"no calls were made, please check your submission and try again"}, http.StatusInternalServerError)
@@ -105,6 +105,7 @@ func (api *API) buildHTTPRoutes() {
 		router.GET("/renter/download/*siapath", RequirePassword(api.renterDownloadHandler, requiredPassword))
 		router.POST("/renter/download/cancel", RequirePassword(api.renterCancelDownloadHandler, requiredPassword))
 		router.GET("/renter/downloadasync/*siapath", RequirePassword(api.renterDownloadAsyncHandler, requiredPassword))
+		router.POST("/renter/reencode/*siapath", RequirePassword(api.renterReencodeHandler, requiredPassword))
 		router.POST("/renter/rename/*siapath", RequirePassword(api.renterRenameHandler, requiredPassword))
 		router.GET("/renter/stream/*siapath", api.renterStreamHandler)
 		router.POST("/renter/upload/*siapath", RequirePassword(api.renterUploadHandler, requiredPassword))
@@ -112,6 +113,11 @@ func (api *API) buildHTTPRoutes() {
 		router.POST("/renter/uploads/pause", RequirePassword(api.renterUploadsPauseHandler, requiredPassword))
 		router.POST("/renter/uploads/resume", RequirePassword(api.renterUploadsResumeHandler, requiredPassword))
 		router.POST("/renter/uploadstream/*siapath", RequirePassword(api.renterUploadStreamHandler, requiredPassword))
+		router.GET("/renter/uploadsessions", api.renterUploadSessionsHandlerGET)
+		router.POST("/renter/uploadsessions/create/*siapath", RequirePassword(api.renterUploadSessionsCreateHandlerPOST, requiredPassword))
+		router.POST("/renter/uploadsessions/abort/:id", RequirePassword(api.renterUploadSessionsAbortHandlerPOST, requiredPassword))
+		router.GET("/renter/uploadsession/:id", api.renterUploadSessionHandlerGET)
+		router.PATCH("/renter/uploadsession/:id", RequirePassword(api.renterUploadSessionHandlerPATCH, requiredPassword))
 		router.POST("/renter/validatesiapath/*siapath", RequirePassword(api.renterValidateSiaPathHandler, requiredPassword))
 		router.GET("/renter/workers", api.renterWorkersHandler)
 		router.GET("/renter/hosts/*siapath", api.renterFileHostsHandler)
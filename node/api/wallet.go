@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -10,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/encoding"
 	mnemonics "gitlab.com/NebulousLabs/entropy-mnemonics"
 	"gitlab.com/NebulousLabs/errors"
 
@@ -33,6 +35,11 @@ type (
 		SiacoinClaimBalance types.Currency `json:"siacoinclaimbalance"`
 		SiafundBalance      types.Currency `json:"siafundbalance"`
 
+		// The timelocked balance is part of the confirmed siacoin balance
+		// but can't be spent before the listed heights.
+		TimelockedSiacoinBalance types.Currency              `json:"timelockedsiacoinbalance"`
+		TimelockedSiacoins       []modules.TimelockedBalance `json:"timelockedsiacoins"`
+
 		DustThreshold types.Currency `json:"dustthreshold"`
 	}
 
@@ -68,6 +75,15 @@ type (
 		TransactionIDs []types.TransactionID `json:"transactionids"`
 	}
 
+	// WalletSiafundClaimsGET contains the claims of the siafund outputs
+	// received by the wallet. AccruedClaims is the total claim of the
+	// unspent outputs and PaidClaims the total payout of the spent ones.
+	WalletSiafundClaimsGET struct {
+		AccruedClaims types.Currency         `json:"accruedclaims"`
+		PaidClaims    types.Currency         `json:"paidclaims"`
+		Claims        []modules.SiafundClaim `json:"claims"`
+	}
+
 	// WalletSignPOSTParams contains the unsigned transaction and a set of
 	// inputs to sign.
 	WalletSignPOSTParams struct {
@@ -158,6 +174,104 @@ type (
 	WalletWatchGET struct {
 		Addresses []types.UnlockHash `json:"addresses"`
 	}
+
+	// WalletDefragPOST contains the report of a manual or simulated defrag.
+	WalletDefragPOST struct {
+		Report modules.DefragReport `json:"report"`
+	}
+
+	// WalletSettingsGET contains the settings of the wallet.
+	WalletSettingsGET struct {
+		modules.WalletSettings
+	}
+
+	// WalletInvoicesGET contains the invoices of the wallet.
+	WalletInvoicesGET struct {
+		Invoices []modules.Invoice `json:"invoices"`
+	}
+
+	// WalletInvoicesPOST contains the invoice that was added to the wallet.
+	WalletInvoicesPOST struct {
+		Invoice modules.Invoice `json:"invoice"`
+	}
+
+	// WalletLabelsGET contains the labels of the wallet's transactions and
+	// addresses.
+	WalletLabelsGET struct {
+		Transactions []modules.TransactionLabel `json:"transactions"`
+		Addresses    []modules.AddressLabel     `json:"addresses"`
+	}
+
+	// WalletMultisigGET contains the multisig accounts of the wallet.
+	WalletMultisigGET struct {
+		Accounts []modules.MultisigAccount `json:"accounts"`
+	}
+
+	// WalletMultisigPOST contains the multisig account that was added to the
+	// wallet.
+	WalletMultisigPOST struct {
+		Account modules.MultisigAccount `json:"account"`
+	}
+
+	// WalletMultisigTransactionPOST contains a transaction spending from a
+	// multisig account after the wallet added its signatures. Complete
+	// indicates whether the transaction has enough signatures to be
+	// broadcast.
+	WalletMultisigTransactionPOST struct {
+		Transaction types.Transaction `json:"transaction"`
+		Complete    bool              `json:"complete"`
+	}
+
+	// WalletRescanPOST contains the height from which the wallet rescanned
+	// the blockchain.
+	WalletRescanPOST struct {
+		ScanHeight types.BlockHeight `json:"scanheight"`
+	}
+
+	// WalletSchedulesGET contains the payment schedules of the wallet.
+	WalletSchedulesGET struct {
+		Schedules []modules.PaymentSchedule `json:"schedules"`
+	}
+
+	// WalletSchedulesPOST contains the payment schedule that was added to
+	// the wallet.
+	WalletSchedulesPOST struct {
+		Schedule modules.PaymentSchedule `json:"schedule"`
+	}
+
+	// WalletSignerGET contains the addresses of the wallet whose keys are
+	// held by the external signer.
+	WalletSignerGET struct {
+		Addresses []modules.SignerAddress `json:"addresses"`
+	}
+
+	// WalletSignerAddressPOST contains a new address of the wallet whose key
+	// is held by the external signer.
+	WalletSignerAddressPOST struct {
+		modules.SignerAddress
+	}
+
+	// WalletTimelockAddressPOST contains a new timelocked address of the
+	// wallet.
+	WalletTimelockAddressPOST struct {
+		Address          types.UnlockHash       `json:"address"`
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+	}
+
+	// WalletTimelockSendPOST contains the timelocked outputs and the
+	// transactions sent in a POST call to /wallet/timelock/send or
+	// /wallet/vesting.
+	WalletTimelockSendPOST struct {
+		Outputs        []modules.TimelockedOutput `json:"outputs"`
+		Transactions   []types.Transaction        `json:"transactions"`
+		TransactionIDs []types.TransactionID      `json:"transactionids"`
+	}
+
+	// WalletOutputsFrozenGET contains the ids of the siacoin outputs which
+	// are frozen by the wallet.
+	WalletOutputsFrozenGET struct {
+		Outputs []types.SiacoinOutputID `json:"outputs"`
+	}
 )
 
 // RegisterRoutesWallet is a helper function to register all wallet routes.
@@ -180,33 +294,111 @@ func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requ
 	router.GET("/wallet/backup", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletBackupHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.POST("/wallet/defrag", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletDefragHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.POST("/wallet/init", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletInitHandler(wallet, w, req, ps)
 	}, requiredPassword))
 	router.POST("/wallet/init/seed", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletInitSeedHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.GET("/wallet/invoices", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletInvoicesHandlerGET(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/invoices", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletInvoicesHandlerPOST(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/invoices/remove", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletInvoicesRemoveHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.GET("/wallet/labels", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletLabelsHandlerGET(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/labels", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletLabelsHandlerPOST(wallet, w, req, ps)
+	}, requiredPassword))
 	router.POST("/wallet/lock", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletLockHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.GET("/wallet/multisig", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletMultisigHandlerGET(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/multisig", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletMultisigHandlerPOST(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/multisig/remove", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletMultisigRemoveHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/multisig/sign", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletMultisigSignHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/multisig/spend", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletMultisigSpendHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/rescan", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletRescanHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.POST("/wallet/seed", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSeedHandler(wallet, w, req, ps)
 	}, requiredPassword))
 	router.GET("/wallet/seeds", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSeedsHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.GET("/wallet/settings", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletSettingsHandlerGET(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/settings", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletSettingsHandlerPOST(wallet, w, req, ps)
+	}, requiredPassword))
 	router.POST("/wallet/siacoins", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSiacoinsHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.POST("/wallet/outputs/freeze", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletOutputsFreezeHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.GET("/wallet/outputs/frozen", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletOutputsFrozenHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/outputs/unfreeze", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletOutputsUnfreezeHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.GET("/wallet/schedules", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletSchedulesHandlerGET(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/schedules", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletSchedulesHandlerPOST(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/schedules/remove", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletSchedulesRemoveHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.POST("/wallet/siafunds", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSiafundsHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.GET("/wallet/siafunds/claims", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletSiafundClaimsHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.POST("/wallet/siagkey", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSiagkeyHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.GET("/wallet/signer", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletSignerHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/signer/address", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletSignerAddressHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.POST("/wallet/sweep/seed", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSweepSeedHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.POST("/wallet/timelock/address", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletTimelockAddressHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/timelock/send", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletTimelockSendHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/timelock/track", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletTimelockTrackHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.GET("/wallet/transaction/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletTransactionHandler(wallet, w, req, ps)
 	})
@@ -216,6 +408,9 @@ func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requ
 	router.GET("/wallet/transactions/:addr", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletTransactionsAddrHandler(wallet, w, req, ps)
 	})
+	router.POST("/wallet/vesting", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletVestingHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.GET("/wallet/verify/address/:addr", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletVerifyAddressHandler(w, req, ps)
 	})
@@ -301,6 +496,15 @@ func walletHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request
 		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
 		return
 	}
+	timelocked, err := wallet.TimelockedBalances()
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		return
+	}
+	var timelockedBal types.Currency
+	for _, tb := range timelocked {
+		timelockedBal = timelockedBal.Add(tb.Value)
+	}
 	WriteJSON(w, WalletGET{
 		Encrypted:  encrypted,
 		Unlocked:   unlocked,
@@ -314,6 +518,9 @@ func walletHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request
 		SiafundBalance:      siafundBal,
 		SiacoinClaimBalance: siaclaimBal,
 
+		TimelockedSiacoinBalance: timelockedBal,
+		TimelockedSiacoins:       timelocked,
+
 		DustThreshold: dustThreshold,
 	})
 }
@@ -406,6 +613,27 @@ func walletBackupHandler(wallet modules.Wallet, w http.ResponseWriter, req *http
 	WriteSuccess(w)
 }
 
+// walletDefragHandler handles API calls to /wallet/defrag.
+func walletDefragHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var dryRun bool
+	if str := req.FormValue("dryrun"); str != "" {
+		var err error
+		dryRun, err = scanBool(str)
+		if err != nil {
+			WriteError(w, Error{"could not read 'dryrun' from POST call to /wallet/defrag: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	report, err := wallet.Defrag(dryRun)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/defrag: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletDefragPOST{
+		Report: report,
+	})
+}
+
 // walletInitHandler handles API calls to /wallet/init.
 func walletInitHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var encryptionKey crypto.CipherKey
@@ -484,10 +712,17 @@ func walletSeedHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.R
 		WriteError(w, Error{"error when calling /wallet/seed: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
+	var startHeight types.BlockHeight
+	if str := req.FormValue("startheight"); str != "" {
+		if _, err := fmt.Sscan(str, &startHeight); err != nil {
+			WriteError(w, Error{"could not read 'startheight' from POST call to /wallet/seed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 
 	potentialKeys, _ := encryptionKeys(req.FormValue("encryptionpassword"))
 	for _, key := range potentialKeys {
-		err := wallet.LoadSeed(key, seed)
+		err := wallet.LoadSeed(key, seed, startHeight)
 		if err == nil {
 			WriteSuccess(w)
 			return
@@ -528,6 +763,124 @@ func walletSiagkeyHandler(wallet modules.Wallet, w http.ResponseWriter, req *htt
 	WriteError(w, Error{"error when calling /wallet/siagkey: " + modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
 }
 
+// walletInvoicesHandlerGET handles GET requests to /wallet/invoices.
+func walletInvoicesHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	invoices, err := wallet.Invoices()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/invoices: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, WalletInvoicesGET{
+		Invoices: invoices,
+	})
+}
+
+// walletInvoicesHandlerPOST handles POST requests to /wallet/invoices.
+func walletInvoicesHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	amount, ok := scanAmount(req.FormValue("amount"))
+	if !ok {
+		WriteError(w, Error{"could not read 'amount' from POST call to /wallet/invoices"}, http.StatusBadRequest)
+		return
+	}
+	inv := modules.Invoice{
+		Amount:      amount,
+		Memo:        req.FormValue("memo"),
+		CallbackURL: req.FormValue("callbackurl"),
+	}
+	uintParams := []struct {
+		name string
+		val  *uint64
+	}{
+		{"expiry", (*uint64)(&inv.Expiry)},
+		{"confirmations", &inv.RequiredConfirmations},
+	}
+	for _, param := range uintParams {
+		str := req.FormValue(param.name)
+		if str == "" {
+			continue
+		}
+		if _, err := fmt.Sscan(str, param.val); err != nil {
+			WriteError(w, Error{fmt.Sprintf("could not read '%v' from POST call to /wallet/invoices: %v", param.name, err)}, http.StatusBadRequest)
+			return
+		}
+	}
+	inv, err := wallet.AddInvoice(inv)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/invoices: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletInvoicesPOST{
+		Invoice: inv,
+	})
+}
+
+// walletInvoicesRemoveHandler handles API calls to /wallet/invoices/remove.
+func walletInvoicesRemoveHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var id crypto.Hash
+	if err := id.LoadString(req.FormValue("id")); err != nil {
+		WriteError(w, Error{"could not read 'id' from POST call to /wallet/invoices/remove: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err := wallet.RemoveInvoice(id)
+	if errors.Contains(err, modules.ErrUnknownInvoice) {
+		WriteError(w, Error{"error when calling /wallet/invoices/remove: " + err.Error()}, http.StatusBadRequest)
+		return
+	} else if err != nil {
+		WriteError(w, Error{"error when calling /wallet/invoices/remove: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletLabelsHandlerGET handles GET requests to /wallet/labels.
+func walletLabelsHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	txnLabels, err := wallet.TransactionLabels()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/labels: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	addrLabels, err := wallet.AddressLabels()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/labels: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, WalletLabelsGET{
+		Transactions: txnLabels,
+		Addresses:    addrLabels,
+	})
+}
+
+// walletLabelsHandlerPOST handles POST requests to /wallet/labels.
+func walletLabelsHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	txidStr, addrStr := req.FormValue("transactionid"), req.FormValue("address")
+	if (txidStr == "") == (addrStr == "") {
+		WriteError(w, Error{"exactly one of 'transactionid' and 'address' must be specified"}, http.StatusBadRequest)
+		return
+	}
+	label, memo := req.FormValue("label"), req.FormValue("memo")
+	var err error
+	if txidStr != "" {
+		var txid types.TransactionID
+		if err := txid.UnmarshalJSON([]byte("\"" + txidStr + "\"")); err != nil {
+			WriteError(w, Error{"could not read 'transactionid' from POST call to /wallet/labels: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = wallet.SetTransactionLabel(txid, label, memo)
+	} else {
+		addr, parseErr := scanAddress(addrStr)
+		if parseErr != nil {
+			WriteError(w, Error{"could not read 'address' from POST call to /wallet/labels: " + parseErr.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = wallet.SetAddressLabel(addr, label, memo)
+	}
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/labels: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // walletLockHandler handles API calls to /wallet/lock.
 func walletLockHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	err := wallet.Lock()
@@ -538,6 +891,140 @@ func walletLockHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Req
 	WriteSuccess(w)
 }
 
+// walletMultisigHandlerGET handles GET requests to /wallet/multisig.
+func walletMultisigHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	accounts, err := wallet.MultisigAccounts()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/multisig: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletMultisigGET{
+		Accounts: accounts,
+	})
+}
+
+// walletMultisigHandlerPOST handles POST requests to /wallet/multisig.
+func walletMultisigHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var uc types.UnlockConditions
+	if err := json.Unmarshal([]byte(req.FormValue("unlockconditions")), &uc); err != nil {
+		WriteError(w, Error{"could not read 'unlockconditions' from POST call to /wallet/multisig: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var localKeys []types.SiaPublicKey
+	if str := req.FormValue("localkeys"); str != "" {
+		if err := json.Unmarshal([]byte(str), &localKeys); err != nil {
+			WriteError(w, Error{"could not read 'localkeys' from POST call to /wallet/multisig: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	var unused bool
+	if str := req.FormValue("unused"); str != "" {
+		var err error
+		unused, err = scanBool(str)
+		if err != nil {
+			WriteError(w, Error{"could not read 'unused' from POST call to /wallet/multisig: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	ma, err := wallet.AddMultisigAccount(req.FormValue("name"), uc, localKeys, unused)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/multisig: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletMultisigPOST{
+		Account: ma,
+	})
+}
+
+// walletMultisigRemoveHandler handles API calls to /wallet/multisig/remove.
+func walletMultisigRemoveHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	addr, err := scanAddress(req.FormValue("address"))
+	if err != nil {
+		WriteError(w, Error{"could not read 'address' from POST call to /wallet/multisig/remove: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var unused bool
+	if str := req.FormValue("unused"); str != "" {
+		unused, err = scanBool(str)
+		if err != nil {
+			WriteError(w, Error{"could not read 'unused' from POST call to /wallet/multisig/remove: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := wallet.RemoveMultisigAccount(addr, unused); err != nil {
+		WriteError(w, Error{"error when calling /wallet/multisig/remove: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletMultisigSignHandler handles API calls to /wallet/multisig/sign. The
+// transaction can be provided as JSON or base64-encoded binary.
+func walletMultisigSignHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var txn types.Transaction
+	if err := json.Unmarshal([]byte(req.FormValue("transaction")), &txn); err != nil {
+		rawTransaction, err := base64.StdEncoding.DecodeString(req.FormValue("transaction"))
+		if err != nil {
+			WriteError(w, Error{"could not read 'transaction' from POST call to /wallet/multisig/sign: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if err := encoding.Unmarshal(rawTransaction, &txn); err != nil {
+			WriteError(w, Error{"error decoding transaction: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	complete, err := wallet.MultisigSign(&txn)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/multisig/sign: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletMultisigTransactionPOST{
+		Transaction: txn,
+		Complete:    complete,
+	})
+}
+
+// walletMultisigSpendHandler handles API calls to /wallet/multisig/spend.
+func walletMultisigSpendHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	addr, err := scanAddress(req.FormValue("address"))
+	if err != nil {
+		WriteError(w, Error{"could not read 'address' from POST call to /wallet/multisig/spend: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var outputs []types.SiacoinOutput
+	if req.FormValue("outputs") != "" {
+		if req.FormValue("amount") != "" || req.FormValue("destination") != "" {
+			WriteError(w, Error{"cannot supply both 'outputs' and single amount+destination pair"}, http.StatusBadRequest)
+			return
+		}
+		if err := json.Unmarshal([]byte(req.FormValue("outputs")), &outputs); err != nil {
+			WriteError(w, Error{"could not decode outputs: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	} else {
+		amount, ok := scanAmount(req.FormValue("amount"))
+		if !ok {
+			WriteError(w, Error{"could not read 'amount' from POST call to /wallet/multisig/spend"}, http.StatusBadRequest)
+			return
+		}
+		dest, err := scanAddress(req.FormValue("destination"))
+		if err != nil {
+			WriteError(w, Error{"could not read 'destination' from POST call to /wallet/multisig/spend"}, http.StatusBadRequest)
+			return
+		}
+		outputs = []types.SiacoinOutput{{Value: amount, UnlockHash: dest}}
+	}
+	txn, complete, err := wallet.MultisigSpend(addr, outputs)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/multisig/spend: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletMultisigTransactionPOST{
+		Transaction: txn,
+		Complete:    complete,
+	})
+}
+
 // walletSeedsHandler handles API calls to /wallet/seeds.
 func walletSeedsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	dictionary := mnemonics.DictionaryID(req.FormValue("dictionary"))
@@ -579,8 +1066,255 @@ func walletSeedsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.
 	})
 }
 
+// scanCoinControl parses the optional 'inputs' and 'strategy' parameters of a
+// request into a CoinControl.
+func scanCoinControl(req *http.Request) (cc modules.CoinControl, err error) {
+	if inputs := req.FormValue("inputs"); inputs != "" {
+		if err := json.Unmarshal([]byte(inputs), &cc.Inputs); err != nil {
+			return modules.CoinControl{}, errors.AddContext(err, "could not decode inputs")
+		}
+	}
+	cc.Strategy = modules.CoinSelectionStrategy(req.FormValue("strategy"))
+	if len(cc.Inputs) > 0 && cc.Strategy != "" {
+		return modules.CoinControl{}, errors.New("cannot supply both 'inputs' and 'strategy'")
+	}
+	return cc, nil
+}
+
+// scanOutputIDs parses a JSON array of siacoin output ids.
+func scanOutputIDs(s string) ([]types.SiacoinOutputID, error) {
+	var ids []types.SiacoinOutputID
+	if err := json.Unmarshal([]byte(s), &ids); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no output ids provided")
+	}
+	return ids, nil
+}
+
+// walletOutputsFreezeHandler handles API calls to /wallet/outputs/freeze.
+func walletOutputsFreezeHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	ids, err := scanOutputIDs(req.FormValue("ids"))
+	if err != nil {
+		WriteError(w, Error{"could not read 'ids' from POST call to /wallet/outputs/freeze: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := wallet.FreezeOutputs(ids); err != nil {
+		WriteError(w, Error{"error when calling /wallet/outputs/freeze: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletOutputsFrozenHandler handles API calls to /wallet/outputs/frozen.
+func walletOutputsFrozenHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	ids, err := wallet.FrozenOutputs()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/outputs/frozen: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, WalletOutputsFrozenGET{
+		Outputs: ids,
+	})
+}
+
+// walletOutputsUnfreezeHandler handles API calls to /wallet/outputs/unfreeze.
+func walletOutputsUnfreezeHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	ids, err := scanOutputIDs(req.FormValue("ids"))
+	if err != nil {
+		WriteError(w, Error{"could not read 'ids' from POST call to /wallet/outputs/unfreeze: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := wallet.UnfreezeOutputs(ids); err != nil {
+		WriteError(w, Error{"error when calling /wallet/outputs/unfreeze: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletSchedulesHandlerGET handles GET requests to /wallet/schedules.
+func walletSchedulesHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	schedules, err := wallet.PaymentSchedules()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/schedules: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, WalletSchedulesGET{
+		Schedules: schedules,
+	})
+}
+
+// walletSchedulesHandlerPOST handles POST requests to /wallet/schedules.
+func walletSchedulesHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	amount, ok := scanAmount(req.FormValue("amount"))
+	if !ok {
+		WriteError(w, Error{"could not read 'amount' from POST call to /wallet/schedules"}, http.StatusBadRequest)
+		return
+	}
+	dest, err := scanAddress(req.FormValue("destination"))
+	if err != nil {
+		WriteError(w, Error{"could not read 'destination' from POST call to /wallet/schedules"}, http.StatusBadRequest)
+		return
+	}
+	ps := modules.PaymentSchedule{
+		Amount:      amount,
+		Description: req.FormValue("description"),
+		Destination: dest,
+	}
+	uintParams := []struct {
+		name string
+		val  *uint64
+	}{
+		{"height", (*uint64)(&ps.NextHeight)},
+		{"timestamp", (*uint64)(&ps.NextTime)},
+		{"interval", &ps.Interval},
+		{"maxpayments", &ps.MaxPayments},
+	}
+	for _, param := range uintParams {
+		str := req.FormValue(param.name)
+		if str == "" {
+			continue
+		}
+		if _, err := fmt.Sscan(str, param.val); err != nil {
+			WriteError(w, Error{fmt.Sprintf("could not read '%v' from POST call to /wallet/schedules: %v", param.name, err)}, http.StatusBadRequest)
+			return
+		}
+	}
+	ps, err = wallet.AddPaymentSchedule(ps)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/schedules: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletSchedulesPOST{
+		Schedule: ps,
+	})
+}
+
+// walletSchedulesRemoveHandler handles API calls to /wallet/schedules/remove.
+func walletSchedulesRemoveHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var id crypto.Hash
+	if err := id.LoadString(req.FormValue("id")); err != nil {
+		WriteError(w, Error{"could not read 'id' from POST call to /wallet/schedules/remove: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err := wallet.RemovePaymentSchedule(id)
+	if errors.Contains(err, modules.ErrUnknownPaymentSchedule) {
+		WriteError(w, Error{"error when calling /wallet/schedules/remove: " + err.Error()}, http.StatusBadRequest)
+		return
+	} else if err != nil {
+		WriteError(w, Error{"error when calling /wallet/schedules/remove: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletSettingsHandlerGET handles GET requests to /wallet/settings.
+func walletSettingsHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	settings, err := wallet.Settings()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/settings: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, WalletSettingsGET{settings})
+}
+
+// walletSettingsHandlerPOST handles POST requests to /wallet/settings. Only
+// the provided settings are changed.
+func walletSettingsHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	settings, err := wallet.Settings()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/settings: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	if str := req.FormValue("nodefrag"); str != "" {
+		settings.NoDefrag, err = scanBool(str)
+		if err != nil {
+			WriteError(w, Error{"could not read 'nodefrag' from POST call to /wallet/settings: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	uintParams := []struct {
+		name string
+		val  *uint64
+	}{
+		{"defragthreshold", &settings.DefragPolicy.Threshold},
+		{"defragbatchsize", &settings.DefragPolicy.BatchSize},
+		{"addressgap", &settings.AddressGap},
+	}
+	for _, param := range uintParams {
+		str := req.FormValue(param.name)
+		if str == "" {
+			continue
+		}
+		if _, err := fmt.Sscan(str, param.val); err != nil {
+			WriteError(w, Error{fmt.Sprintf("could not read '%v' from POST call to /wallet/settings: %v", param.name, err)}, http.StatusBadRequest)
+			return
+		}
+	}
+	if str := req.FormValue("defragmaxfeeperbyte"); str != "" {
+		fee, ok := scanAmount(str)
+		if !ok {
+			WriteError(w, Error{"could not read 'defragmaxfeeperbyte' from POST call to /wallet/settings"}, http.StatusBadRequest)
+			return
+		}
+		settings.DefragPolicy.MaxFeePerByte = fee
+	}
+	if str := req.FormValue("defragwindows"); str != "" {
+		var windows []modules.DefragWindow
+		if err := json.Unmarshal([]byte(str), &windows); err != nil {
+			WriteError(w, Error{"could not read 'defragwindows' from POST call to /wallet/settings: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.DefragPolicy.Windows = windows
+	}
+	if err := wallet.SetSettings(settings); err != nil {
+		WriteError(w, Error{"error when calling /wallet/settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletRescanHandler handles API calls to /wallet/rescan.
+func walletRescanHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	heightStr, birthDateStr := req.FormValue("height"), req.FormValue("birthdate")
+	if (heightStr == "") == (birthDateStr == "") {
+		WriteError(w, Error{"exactly one of 'height' and 'birthdate' must be provided to /wallet/rescan"}, http.StatusBadRequest)
+		return
+	}
+	var height types.BlockHeight
+	if heightStr != "" {
+		if _, err := fmt.Sscan(heightStr, &height); err != nil {
+			WriteError(w, Error{"could not read 'height' from POST call to /wallet/rescan: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	} else {
+		var birthDate types.Timestamp
+		if _, err := fmt.Sscan(birthDateStr, &birthDate); err != nil {
+			WriteError(w, Error{"could not read 'birthdate' from POST call to /wallet/rescan: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		var err error
+		height, err = wallet.BirthHeight(birthDate)
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/rescan: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := wallet.Rescan(height); err != nil {
+		WriteError(w, Error{"error when calling /wallet/rescan: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletRescanPOST{ScanHeight: height})
+}
+
 // walletSiacoinsHandler handles API calls to /wallet/siacoins.
 func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	cc, err := scanCoinControl(req)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
 	var txns []types.Transaction
 	if req.FormValue("outputs") != "" {
 		// multiple amounts + destinations
@@ -595,7 +1329,7 @@ func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 			WriteError(w, Error{"could not decode outputs: " + err.Error()}, http.StatusInternalServerError)
 			return
 		}
-		txns, err = wallet.SendSiacoinsMulti(outputs)
+		txns, err = wallet.SendSiacoinsMultiCoinControl(outputs, cc)
 		if err != nil {
 			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
 			return
@@ -618,11 +1352,7 @@ func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 			return
 		}
 
-		if feeIncluded {
-			txns, err = wallet.SendSiacoinsFeeIncluded(amount, dest)
-		} else {
-			txns, err = wallet.SendSiacoins(amount, dest)
-		}
+		txns, err = wallet.SendSiacoinsCoinControl(amount, dest, feeIncluded, cc)
 		if err != nil {
 			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
 			return
@@ -667,6 +1397,48 @@ func walletSiafundsHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 	})
 }
 
+// walletSiafundClaimsHandler handles API calls to /wallet/siafunds/claims.
+func walletSiafundClaimsHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	claims, err := wallet.SiafundClaims()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/siafunds/claims: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	wscg := WalletSiafundClaimsGET{
+		Claims: claims,
+	}
+	for _, sc := range claims {
+		if sc.Spent {
+			wscg.PaidClaims = wscg.PaidClaims.Add(sc.Claim)
+		} else {
+			wscg.AccruedClaims = wscg.AccruedClaims.Add(sc.Claim)
+		}
+	}
+	WriteJSON(w, wscg)
+}
+
+// walletSignerHandler handles API calls to /wallet/signer.
+func walletSignerHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	addrs, err := wallet.SignerAddresses()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/signer: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletSignerGET{
+		Addresses: addrs,
+	})
+}
+
+// walletSignerAddressHandler handles API calls to /wallet/signer/address.
+func walletSignerAddressHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	sa, err := wallet.NewSignerAddress()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/signer/address: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletSignerAddressPOST{sa})
+}
+
 // walletSweepSeedHandler handles API calls to /wallet/sweep/seed.
 func walletSweepSeedHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// Get the seed using the dictionary + phrase
@@ -679,8 +1451,15 @@ func walletSweepSeedHandler(wallet modules.Wallet, w http.ResponseWriter, req *h
 		WriteError(w, Error{"error when calling /wallet/sweep/seed: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
+	var startHeight types.BlockHeight
+	if str := req.FormValue("startheight"); str != "" {
+		if _, err := fmt.Sscan(str, &startHeight); err != nil {
+			WriteError(w, Error{"could not read 'startheight' from POST call to /wallet/sweep/seed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 
-	coins, funds, err := wallet.SweepSeed(seed)
+	coins, funds, err := wallet.SweepSeed(seed, startHeight)
 	if err != nil {
 		WriteError(w, Error{"error when calling /wallet/sweep/seed: " + err.Error()}, http.StatusBadRequest)
 		return
@@ -691,6 +1470,129 @@ func walletSweepSeedHandler(wallet modules.Wallet, w http.ResponseWriter, req *h
 	})
 }
 
+// writeTimelockSend sends the timelocked outputs and writes the response of
+// /wallet/timelock/send and /wallet/vesting.
+func writeTimelockSend(wallet modules.Wallet, w http.ResponseWriter, outputs []modules.TimelockedOutput, call string) {
+	txns, err := wallet.SendTimelockedSiacoins(outputs)
+	if err != nil {
+		WriteError(w, Error{"error when calling " + call + ": " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletTimelockSendPOST{
+		Outputs:        outputs,
+		Transactions:   txns,
+		TransactionIDs: txids,
+	})
+}
+
+// walletTimelockAddressHandler handles API calls to /wallet/timelock/address.
+func walletTimelockAddressHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	timelock, err := strconv.ParseUint(req.FormValue("timelock"), 10, 64)
+	if err != nil {
+		WriteError(w, Error{"could not read 'timelock' from POST call to /wallet/timelock/address: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	uc, err := wallet.NewTimelockedAddress(types.BlockHeight(timelock))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/timelock/address: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletTimelockAddressPOST{
+		Address:          uc.UnlockHash(),
+		UnlockConditions: uc,
+	})
+}
+
+// walletTimelockSendHandler handles API calls to /wallet/timelock/send.
+func walletTimelockSendHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var outputs []modules.TimelockedOutput
+	if req.FormValue("outputs") != "" {
+		if req.FormValue("amount") != "" || req.FormValue("unlockconditions") != "" {
+			WriteError(w, Error{"cannot supply both 'outputs' and single amount+unlockconditions pair"}, http.StatusBadRequest)
+			return
+		}
+		if err := json.Unmarshal([]byte(req.FormValue("outputs")), &outputs); err != nil {
+			WriteError(w, Error{"could not decode outputs: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	} else {
+		amount, ok := scanAmount(req.FormValue("amount"))
+		if !ok {
+			WriteError(w, Error{"could not read 'amount' from POST call to /wallet/timelock/send"}, http.StatusBadRequest)
+			return
+		}
+		var uc types.UnlockConditions
+		if err := json.Unmarshal([]byte(req.FormValue("unlockconditions")), &uc); err != nil {
+			WriteError(w, Error{"could not read 'unlockconditions' from POST call to /wallet/timelock/send: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		outputs = []modules.TimelockedOutput{{UnlockConditions: uc, Value: amount}}
+	}
+	writeTimelockSend(wallet, w, outputs, "/wallet/timelock/send")
+}
+
+// walletTimelockTrackHandler handles API calls to /wallet/timelock/track.
+func walletTimelockTrackHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var ucs []types.UnlockConditions
+	if err := json.Unmarshal([]byte(req.FormValue("unlockconditions")), &ucs); err != nil {
+		WriteError(w, Error{"could not read 'unlockconditions' from POST call to /wallet/timelock/track: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var unused bool
+	if req.FormValue("unused") != "" {
+		var err error
+		unused, err = scanBool(req.FormValue("unused"))
+		if err != nil {
+			WriteError(w, Error{"could not read 'unused' from POST call to /wallet/timelock/track: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := wallet.AddTimelockedAddresses(ucs, unused); err != nil {
+		WriteError(w, Error{"error when calling /wallet/timelock/track: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletVestingHandler handles API calls to /wallet/vesting.
+func walletVestingHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var pk types.SiaPublicKey
+	if err := pk.LoadString(req.FormValue("publickey")); err != nil {
+		WriteError(w, Error{"could not read 'publickey' from POST call to /wallet/vesting: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	amount, ok := scanAmount(req.FormValue("amount"))
+	if !ok {
+		WriteError(w, Error{"could not read 'amount' from POST call to /wallet/vesting"}, http.StatusBadRequest)
+		return
+	}
+	start, err := strconv.ParseUint(req.FormValue("start"), 10, 64)
+	if err != nil {
+		WriteError(w, Error{"could not read 'start' from POST call to /wallet/vesting: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	interval, err := strconv.ParseUint(req.FormValue("interval"), 10, 64)
+	if err != nil {
+		WriteError(w, Error{"could not read 'interval' from POST call to /wallet/vesting: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	tranches, err := strconv.ParseUint(req.FormValue("tranches"), 10, 64)
+	if err != nil {
+		WriteError(w, Error{"could not read 'tranches' from POST call to /wallet/vesting: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	outputs, err := modules.VestingSchedule(pk, amount, types.BlockHeight(start), types.BlockHeight(interval), tranches)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/vesting: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	writeTimelockSend(wallet, w, outputs, "/wallet/vesting")
+}
+
 // walletTransactionHandler handles API calls to /wallet/transaction/:id.
 func walletTransactionHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
 	// Parse the id from the url.
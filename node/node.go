@@ -108,6 +108,10 @@ type NodeParams struct {
 	HostStorage uint64
 	RPCAddress  string
 
+	// WalletSigner signs the spends from the wallet's signer addresses. It
+	// is only used if the node creates the wallet.
+	WalletSigner modules.ExternalSigner
+
 	// Initialize node from existing seed.
 	PrimarySeed string
 
@@ -380,7 +384,14 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		}
 		i++
 		printfRelease("(%d/%d) Loading wallet...\n", i, numModules)
-		return wallet.NewCustomWallet(cs, tp, filepath.Join(dir, modules.WalletDir), walletDeps)
+		w, err := wallet.NewCustomWallet(cs, tp, filepath.Join(dir, modules.WalletDir), walletDeps)
+		if err != nil {
+			return nil, err
+		}
+		if params.WalletSigner != nil {
+			w.SetExternalSigner(params.WalletSigner)
+		}
+		return w, nil
 	}()
 	if err != nil {
 		errChan <- errors.Extend(err, errors.New("unable to create wallet"))
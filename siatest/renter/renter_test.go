@@ -1,6 +1,7 @@
 package renter
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -277,6 +278,7 @@ func TestRenterFour(t *testing.T) {
 		{Name: "TestNextPeriod", Test: testNextPeriod},
 		{Name: "TestPauseAndResumeRepairAndUploads", Test: testPauseAndResumeRepairAndUploads},
 		{Name: "TestDownloadServedFromDisk", Test: testDownloadServedFromDisk},
+		{Name: "TestDownloadResume", Test: testDownloadResume},
 		{Name: "TestDirMode", Test: testDirMode},
 		{Name: "TestEscapeSiaPath", Test: testEscapeSiaPath}, // Runs last because it uploads many files
 	}
@@ -4631,6 +4633,72 @@ func testDownloadServedFromDisk(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testDownloadResume tests that a download to disk can be resumed and that
+// the checksum recorded during the upload is reported.
+func testDownloadResume(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a file with a checksum.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	chunkSize := siatest.ChunkSize(dataPieces, crypto.TypeDefaultRenter)
+	lf, err := r.FilesDir().NewFile(int(2*chunkSize) + siatest.Fuzz() + 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := lf.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath := r.SiaPath(lf.Path())
+	if err := r.RenterUploadChecksumPost(lf.Path(), siaPath, dataPieces, parityPieces); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rf, err := r.RenterFileGet(siaPath)
+		if err != nil {
+			return err
+		}
+		if rf.File.Health > 0 {
+			return fmt.Errorf("file not fully uploaded yet: %v", rf.File.Health)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf, err := r.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rf.File.Checksum != crypto.HashBytes(data) {
+		t.Fatal("wrong checksum", rf.File.Checksum)
+	}
+
+	// Create a destination which contains the first chunk and garbage
+	// afterwards.
+	dst := filepath.Join(r.FilesDir().Path(), "resume")
+	partial := append(append([]byte{}, data[:chunkSize]...), fastrand.Bytes(int(chunkSize))...)
+	if err := ioutil.WriteFile(dst, partial, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Resume the download without the local copy.
+	if err := lf.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.RenterDownloadResumeGet(siaPath, dst, false, false); err != nil {
+		t.Fatal(err)
+	}
+	downloaded, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, data) {
+		t.Fatal("resumed download doesn't match the uploaded data")
+	}
+}
+
 // testDirMode is a subtest that makes sure that various ways of creating a dir
 // all set the correct permissions.
 func testDirMode(t *testing.T, tg *siatest.TestGroup) {
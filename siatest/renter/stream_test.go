@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -180,6 +181,7 @@ func TestRenterStream(t *testing.T) {
 	subTests := []siatest.SubTest{
 		{Name: "TestStreamLargeFile", Test: testStreamLargeFile},
 		{Name: "TestStreamRepair", Test: testStreamRepair},
+		{Name: "TestUploadSession", Test: testUploadSession},
 		{Name: "TestUploadStreaming", Test: testUploadStreaming},
 		{Name: "TestUploadStreamingWithBadDeps", Test: testUploadStreamingWithBadDeps},
 	}
@@ -291,6 +293,95 @@ func testStreamRepair(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testUploadSession uploads random data using a resumable upload session which
+// is interrupted by a restart of the renter.
+func testUploadSession(t *testing.T, tg *siatest.TestGroup) {
+	if len(tg.Renters()) == 0 {
+		t.Fatal("Test requires at least 1 renter")
+	}
+	// Create some random data to write.
+	fileSize := fastrand.Intn(2*int(modules.SectorSize)) + siatest.Fuzz() + 2 // between 1 and 2*SectorSize + 3 bytes
+	data := fastrand.Bytes(fileSize)
+
+	// Create the session.
+	siaPath, err := modules.NewSiaPath("/session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := tg.Renters()[0]
+	us, err := r.RenterUploadSessionsCreatePost(siaPath, uint64(len(data)), 1, uint64(len(tg.Hosts())-1), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Write the first half of the data and restart the renter.
+	half := uint64(len(data) / 2)
+	us, err = r.RenterUploadSessionPatch(us.ID, 0, bytes.NewReader(data[:half]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if us.Offset != half {
+		t.Fatalf("expected offset %v but was %v", half, us.Offset)
+	}
+	if err := tg.RestartNode(r); err != nil {
+		t.Fatal(err)
+	}
+
+	// Resume the upload from the committed offset.
+	us, err = r.RenterUploadSessionGet(us.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if us.Offset != half {
+		t.Fatalf("expected offset %v after restart but was %v", half, us.Offset)
+	}
+
+	// Writing at an offset other than the committed one is a conflict.
+	_, err = r.RenterUploadSessionPatch(us.ID, 0, bytes.NewReader(data))
+	if err == nil || !strings.Contains(err.Error(), modules.ErrUploadSessionOffsetMismatch.Error()) {
+		t.Fatal("expected offset mismatch but got", err)
+	}
+	us, err = r.RenterUploadSessionPatch(us.ID, us.Offset, bytes.NewReader(data[us.Offset:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !us.Complete || us.Offset != uint64(len(data)) {
+		t.Fatal("session should be complete", us)
+	}
+
+	// Make sure the file reached full redundancy.
+	err = build.Retry(100, 600*time.Millisecond, func() error {
+		rfg, err := r.RenterFileGet(siaPath)
+		if err != nil {
+			return err
+		}
+		if rfg.File.Redundancy < float64(len(tg.Hosts())) {
+			return fmt.Errorf("expected redundancy %v but was %v",
+				len(tg.Hosts()), rfg.File.Redundancy)
+		}
+		if rfg.File.Filesize != uint64(len(data)) {
+			return fmt.Errorf("expected uploaded file to have size %v but was %v",
+				len(data), rfg.File.Filesize)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Download the file again and compare it to the original data.
+	_, downloadedData, err := r.RenterDownloadHTTPResponseGet(siaPath, 0, uint64(len(data)), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, downloadedData) {
+		t.Fatal("Downloaded data doesn't match uploaded data")
+	}
+	// Remove the session.
+	if err := r.RenterUploadSessionsAbortPost(us.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // testUploadStreaming uploads random data using the upload streaming API.
 func testUploadStreaming(t *testing.T, tg *siatest.TestGroup) {
 	if len(tg.Renters()) == 0 {